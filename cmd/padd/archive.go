@@ -0,0 +1,361 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// archiveCommands are the subcommands that export the data directory to a zip archive or import one into it
+var archiveCommands = []string{"export", "import"}
+
+// How an import handles files that already exist in the data directory
+const (
+	conflictSkip      = "skip"      // Keep the existing file
+	conflictOverwrite = "overwrite" // Replace the existing file with the archived one
+	conflictRename    = "rename"    // Import the archived file next to the existing one, under a numbered name
+)
+
+// conflictModes are the ways an import can handle existing files, with the default first
+var conflictModes = []string{conflictSkip, conflictOverwrite, conflictRename}
+
+// maxArchiveEntryBytes caps the size of a single file extracted from an archive, so a malicious archive can't
+// fill the disk from a small upload
+const maxArchiveEntryBytes = 256 << 20 // 256 MB
+
+// maxArchiveUploadBytes caps the size of an uploaded archive, leaving room for one file of the largest size
+// plus the multipart form overhead
+const maxArchiveUploadBytes = maxArchiveEntryBytes + 1<<20
+
+// archiveExcludedDirs are the directories of the data directory that are left out of archives: the version
+// history, which git can copy on its own, and the server's logs
+var archiveExcludedDirs = []string{".git", "service"}
+
+// archiveSummary counts the outcome of exporting the data directory
+type archiveSummary struct {
+	Files     int      // Files written to the archive
+	Decrypted int      // Encrypted files written to the archive decrypted
+	Failed    []string // Encrypted files that couldn't be decrypted, written to the archive as they are
+}
+
+// importSummary lists the outcome of importing an archive
+type importSummary struct {
+	Imported []string // Paths written to the data directory
+	Skipped  []string // Paths that already existed and were kept
+}
+
+// writeArchive writes every file in the data directory to w as a zip archive. When keys is not nil, encrypted
+// files are decrypted with its identities before they're archived; any that can't be are archived encrypted
+// and listed in the summary, so an export never silently drops a file.
+func writeArchive(rootManager *files.RootManager, w io.Writer, keys *crypto.EncryptionManager) (archiveSummary, error) {
+	var summary archiveSummary
+
+	zw := zip.NewWriter(w)
+	err := rootManager.WalkDir(".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if slices.Contains(archiveExcludedDirs, filePath) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := rootManager.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		if keys != nil && crypto.IsAgeEncrypted(content) {
+			decrypted, err := keys.Decrypt(content)
+			if err != nil {
+				summary.Failed = append(summary.Failed, filePath)
+			} else {
+				content = []byte(decrypted)
+				summary.Decrypted++
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filePath)
+		header.Method = zip.Deflate
+
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(content); err != nil {
+			return err
+		}
+
+		summary.Files++
+		return nil
+	})
+	if err != nil {
+		return summary, fmt.Errorf("error archiving the data directory: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return summary, fmt.Errorf("error archiving the data directory: %w", err)
+	}
+
+	return summary, nil
+}
+
+// importArchive extracts a zip archive into the data directory, handling files that already exist as the
+// conflicts mode says. The archive is checked before anything is written, so one with an entry that would land
+// outside the data directory, or in its version history, is rejected as a whole. Renamed documents keep their
+// metadata sidecars alongside them.
+func importArchive(rootManager *files.RootManager, archive *zip.Reader, conflicts string) (importSummary, error) {
+	var summary importSummary
+
+	if !slices.Contains(conflictModes, conflicts) {
+		return summary, fmt.Errorf("unknown conflict mode %q (expected one of %s)", conflicts, strings.Join(conflictModes, ", "))
+	}
+
+	var entries []*zip.File
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+
+		name := path.Clean(entry.Name)
+		if !filepath.IsLocal(filepath.FromSlash(name)) || strings.Contains(entry.Name, `\`) {
+			return summary, fmt.Errorf("archive entry %q is outside the data directory", entry.Name)
+		}
+		if slices.Contains(archiveExcludedDirs, strings.SplitN(name, "/", 2)[0]) {
+			return summary, fmt.Errorf("archive entry %q is in the reserved %s directory", entry.Name, strings.SplitN(name, "/", 2)[0])
+		}
+		if entry.UncompressedSize64 > maxArchiveEntryBytes {
+			return summary, fmt.Errorf("archive entry %q is larger than %d MB", entry.Name, maxArchiveEntryBytes>>20)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	// Documents sort before their sidecars, so a sidecar can follow its document when it's renamed
+	sort.Slice(entries, func(i, j int) bool {
+		return path.Clean(entries[i].Name) < path.Clean(entries[j].Name)
+	})
+
+	renamed := make(map[string]string)
+	for _, entry := range entries {
+		name := filepath.FromSlash(path.Clean(entry.Name))
+		target := name
+
+		// A renamed document's sidecar goes with it, whether or not a sidecar exists under the original name
+		if document, ok := renamed[strings.TrimSuffix(name, files.SidecarSuffix)]; ok && files.IsSidecar(name) {
+			target = document + files.SidecarSuffix
+		} else if rootManager.FileExists(name) {
+			switch conflicts {
+			case conflictSkip:
+				summary.Skipped = append(summary.Skipped, name)
+				continue
+			case conflictRename:
+				target = availableName(rootManager, name)
+				renamed[name] = target
+			}
+		}
+
+		if err := extractEntry(rootManager, entry, target); err != nil {
+			return summary, fmt.Errorf("failed to import %s: %w", entry.Name, err)
+		}
+		summary.Imported = append(summary.Imported, target)
+	}
+
+	return summary, nil
+}
+
+// extractEntry writes the content of an archive entry to the target path in the data directory
+func extractEntry(rootManager *files.RootManager, entry *zip.File, target string) error {
+	reader, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer func(reader io.ReadCloser) {
+		_ = reader.Close()
+	}(reader)
+
+	content, err := io.ReadAll(io.LimitReader(reader, maxArchiveEntryBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(content) > maxArchiveEntryBytes {
+		return fmt.Errorf("larger than %d MB", maxArchiveEntryBytes>>20)
+	}
+
+	if dir := filepath.Dir(target); dir != "." {
+		if err := rootManager.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	// Imported files are never executable or writable by others, whatever mode the archive recorded
+	perm := os.FileMode(0644)
+	if mode := entry.Mode().Perm(); mode != 0 {
+		perm = mode&0644 | 0600
+	}
+
+	return rootManager.WriteFile(target, content, perm)
+}
+
+// availableName returns a numbered variant of a path that doesn't exist yet, such as notes-2.md for notes.md.
+// The number goes before the first dot of the file name, so sidecars keep their .meta.json suffix.
+func availableName(rootManager *files.RootManager, name string) string {
+	dir, base := filepath.Split(name)
+	stem, ext := base, ""
+	if i := strings.Index(base[1:], "."); i >= 0 {
+		stem, ext = base[:i+1], base[i+1:]
+	}
+
+	for n := 2; ; n++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, n, ext))
+		if !rootManager.FileExists(candidate) {
+			return candidate
+		}
+	}
+}
+
+// runArchiveCommand runs an archive subcommand against the data directory:
+//
+//	export [-decrypt] archive.zip
+//	import [-conflicts skip|overwrite|rename] archive.zip
+//
+// Exports are written to standard output when the archive is "-". Imported files are committed in one
+// revision when version history is on, and encrypted files are decrypted for export with the identities in
+// keys.
+func runArchiveCommand(dataDir string, keys *crypto.EncryptionManager, history bool, command string, args []string, stdout io.Writer) error {
+	flagSet := flag.NewFlagSet(appName+" "+command, flag.ContinueOnError)
+	flagSet.SetOutput(stdout)
+	var decrypt bool
+	var conflicts string
+	switch command {
+	case "export":
+		flagSet.BoolVar(&decrypt, "decrypt", false, "Decrypt encrypted files with the loaded identities before archiving them.")
+	case "import":
+		flagSet.StringVar(&conflicts, "conflicts", conflictSkip, "How to handle files that already exist: "+strings.Join(conflictModes, ", ")+".")
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if flagSet.NArg() != 1 {
+		return fmt.Errorf("usage: %s %s [options] archive.zip", appName, command)
+	}
+	archivePath := flagSet.Arg(0)
+
+	rootManager, err := files.NewRootManager(dataDir)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "export":
+		if decrypt {
+			if !keys.HasIdentities() {
+				return errors.New("an identity file is required to decrypt files")
+			}
+			if keys.IsLocked() {
+				return errors.New("the identity file is passphrase-protected; decrypt it with age before exporting")
+			}
+		} else {
+			keys = nil
+		}
+		return exportArchiveFile(rootManager, archivePath, keys, stdout)
+	case "import":
+		return importArchiveFile(rootManager, dataDir, archivePath, conflicts, history, stdout)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// exportArchiveFile writes the data directory to the archive at archivePath, or to stdout for "-"
+func exportArchiveFile(rootManager *files.RootManager, archivePath string, keys *crypto.EncryptionManager, stdout io.Writer) error {
+	out, report := stdout, stdout
+	if archivePath != "-" {
+		file, err := os.Create(archivePath)
+		if err != nil {
+			return err
+		}
+		defer func(file *os.File) {
+			_ = file.Close()
+		}(file)
+		out = file
+	} else {
+		report = os.Stderr
+	}
+
+	summary, err := writeArchive(rootManager, out, keys)
+	if err != nil {
+		return err
+	}
+
+	for _, failed := range summary.Failed {
+		_, _ = fmt.Fprintf(report, "failed to decrypt %s, archived encrypted\n", failed)
+	}
+	_, _ = fmt.Fprintf(report, "Archived %d files (%d decrypted)\n", summary.Files, summary.Decrypted)
+
+	return nil
+}
+
+// importArchiveFile imports the archive at archivePath into the data directory
+func importArchiveFile(rootManager *files.RootManager, dataDir, archivePath, conflicts string, history bool, stdout io.Writer) error {
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive %s: %w", archivePath, err)
+	}
+	defer func(archive *zip.ReadCloser) {
+		_ = archive.Close()
+	}(archive)
+
+	summary, err := importArchive(rootManager, &archive.Reader, conflicts)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range summary.Imported {
+		_, _ = fmt.Fprintf(stdout, "imported %s\n", path)
+	}
+	for _, path := range summary.Skipped {
+		_, _ = fmt.Fprintf(stdout, "skipped  %s\n", path)
+	}
+	_, _ = fmt.Fprintf(stdout, "\nImported %d files, %d existing files skipped\n", len(summary.Imported), len(summary.Skipped))
+
+	if len(summary.Imported) > 0 && (history || files.IsGitRepository(dataDir)) {
+		store, err := files.NewGitVersionStore(dataDir)
+		if err != nil {
+			return fmt.Errorf("could not record the import in version history: %w", err)
+		}
+		if err := store.Record("Import "+filepath.Base(archivePath), summary.Imported...); err != nil {
+			return fmt.Errorf("could not record the import in version history: %w", err)
+		}
+	}
+
+	return nil
+}
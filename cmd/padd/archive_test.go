@@ -0,0 +1,216 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// newTestArchive returns a zip archive of the given files, by path
+func newTestArchive(t *testing.T, entries map[string]string) *zip.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		entry, err := zw.Create(name)
+		assert.Nil(t, err)
+		_, err = entry.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	return archive
+}
+
+func TestWriteArchive(t *testing.T) {
+	rootManager, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	keys, otherKeys := newTestKeys(t), newTestKeys(t)
+
+	assert.Nil(t, rootManager.MkdirAll("resources/private", 0755))
+	assert.Nil(t, rootManager.MkdirAll(".git", 0755))
+	assert.Nil(t, rootManager.MkdirAll("service", 0755))
+	assert.Nil(t, rootManager.WriteString("inbox.md", "# Inbox\n"))
+	assert.Nil(t, rootManager.WriteString(".git/HEAD", "ref: refs/heads/main\n"))
+	assert.Nil(t, rootManager.WriteString("service/padd.log", "started\n"))
+
+	encrypted, err := keys.Encrypt("# Secret\n")
+	assert.Nil(t, err)
+	assert.Nil(t, rootManager.WriteFile("resources/private/secret.md", encrypted, 0600))
+	foreign, err := otherKeys.Encrypt("# Foreign\n")
+	assert.Nil(t, err)
+	assert.Nil(t, rootManager.WriteFile("resources/foreign.md", foreign, 0600))
+
+	read := func(archive []byte) map[string]string {
+		t.Helper()
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		assert.Nil(t, err)
+
+		contents := make(map[string]string)
+		for _, entry := range reader.File {
+			rc, err := entry.Open()
+			assert.Nil(t, err)
+			var content bytes.Buffer
+			_, err = content.ReadFrom(rc)
+			assert.Nil(t, err)
+			_ = rc.Close()
+			contents[entry.Name] = content.String()
+		}
+		return contents
+	}
+
+	// Encrypted files are archived as they are by default, leaving out the version history and logs
+	var buf bytes.Buffer
+	summary, err := writeArchive(rootManager, &buf, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, summary.Files, 3)
+	assert.Equal(t, summary.Decrypted, 0)
+
+	contents := read(buf.Bytes())
+	assert.Equal(t, len(contents), 3)
+	assert.Equal(t, contents["inbox.md"], "# Inbox\n")
+	assert.Equal(t, contents["resources/private/secret.md"], string(encrypted))
+
+	// Decrypted archives hold the plaintext of every file the keys can open
+	buf.Reset()
+	summary, err = writeArchive(rootManager, &buf, keys)
+	assert.Nil(t, err)
+	assert.Equal(t, summary.Decrypted, 1)
+	assert.Equal(t, summary.Failed, []string{"resources/foreign.md"})
+
+	contents = read(buf.Bytes())
+	assert.Equal(t, contents["resources/private/secret.md"], "# Secret\n")
+	assert.Equal(t, contents["resources/foreign.md"], string(foreign))
+}
+
+func TestImportArchive(t *testing.T) {
+	newRoot := func() *files.RootManager {
+		rootManager, err := files.NewRootManager(t.TempDir())
+		assert.Nil(t, err)
+		assert.Nil(t, rootManager.MkdirAll("resources", 0755))
+		assert.Nil(t, rootManager.WriteString("inbox.md", "# Existing inbox\n"))
+		assert.Nil(t, rootManager.WriteString("resources/data.csv", "a,b\n"))
+		assert.Nil(t, rootManager.WriteString("resources/data.csv.meta.json", "{}"))
+		return rootManager
+	}
+
+	archive := newTestArchive(t, map[string]string{
+		"inbox.md":                       "# Imported inbox\n",
+		"resources/data.csv":             "x,y\n",
+		"resources/data.csv.meta.json":   `{"title": "Data"}`,
+		"resources/trip/packing-list.md": "# Packing\n",
+	})
+
+	read := func(rootManager *files.RootManager, path string) string {
+		t.Helper()
+		content, err := rootManager.ReadFile(path)
+		assert.Nil(t, err)
+		return string(content)
+	}
+
+	// Skipping keeps every existing file
+	rootManager := newRoot()
+	summary, err := importArchive(rootManager, archive, conflictSkip)
+	assert.Nil(t, err)
+	assert.Equal(t, summary.Imported, []string{"resources/trip/packing-list.md"})
+	assert.Equal(t, len(summary.Skipped), 3)
+	assert.Equal(t, read(rootManager, "inbox.md"), "# Existing inbox\n")
+	assert.Equal(t, read(rootManager, "resources/trip/packing-list.md"), "# Packing\n")
+
+	// Overwriting replaces them
+	rootManager = newRoot()
+	summary, err = importArchive(rootManager, archive, conflictOverwrite)
+	assert.Nil(t, err)
+	assert.Equal(t, len(summary.Imported), 4)
+	assert.Equal(t, read(rootManager, "inbox.md"), "# Imported inbox\n")
+
+	// Renaming keeps both, and sidecars follow their renamed documents
+	rootManager = newRoot()
+	summary, err = importArchive(rootManager, archive, conflictRename)
+	assert.Nil(t, err)
+	assert.Equal(t, summary.Imported, []string{
+		"inbox-2.md",
+		"resources/data-2.csv",
+		"resources/data-2.csv.meta.json",
+		"resources/trip/packing-list.md",
+	})
+	assert.Equal(t, read(rootManager, "inbox.md"), "# Existing inbox\n")
+	assert.Equal(t, read(rootManager, "inbox-2.md"), "# Imported inbox\n")
+	assert.Equal(t, read(rootManager, "resources/data-2.csv.meta.json"), `{"title": "Data"}`)
+
+	_, err = importArchive(rootManager, archive, "merge")
+	assert.NotNil(t, err)
+}
+
+func TestImportArchive_RenameWithoutLocalSidecar(t *testing.T) {
+	rootManager, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+	assert.Nil(t, rootManager.WriteString("data.csv", "a,b\n"))
+
+	archive := newTestArchive(t, map[string]string{
+		"data.csv":           "x,y\n",
+		"data.csv.meta.json": `{"title": "Data"}`,
+	})
+
+	// The local data.csv has no sidecar, but the imported one still follows the renamed CSV
+	summary, err := importArchive(rootManager, archive, conflictRename)
+	assert.Nil(t, err)
+	assert.Equal(t, summary.Imported, []string{"data-2.csv", "data-2.csv.meta.json"})
+	assert.False(t, rootManager.FileExists("data.csv.meta.json"))
+
+	content, err := rootManager.ReadFile("data-2.csv.meta.json")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), `{"title": "Data"}`)
+}
+
+func TestImportArchive_RejectsUnsafePaths(t *testing.T) {
+	for _, name := range []string{"../escape.md", "/etc/passwd", `..\escape.md`, ".git/config", "service/padd.log"} {
+		t.Run(name, func(t *testing.T) {
+			rootManager, err := files.NewRootManager(t.TempDir())
+			assert.Nil(t, err)
+
+			archive := newTestArchive(t, map[string]string{"inbox.md": "# Inbox\n", name: "payload"})
+			summary, err := importArchive(rootManager, archive, conflictOverwrite)
+			assert.NotNil(t, err)
+			assert.Equal(t, len(summary.Imported), 0)
+			assert.False(t, rootManager.FileExists("inbox.md"))
+		})
+	}
+}
+
+func TestImportArchive_MasksFileModes(t *testing.T) {
+	rootManager, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, mode := range map[string]os.FileMode{"script.md": 0777, "private.md": 0600, "shared.md": 0666} {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		header.SetMode(mode)
+		entry, err := zw.CreateHeader(header)
+		assert.Nil(t, err)
+		_, err = entry.Write([]byte("# " + name + "\n"))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	_, err = importArchive(rootManager, archive, conflictSkip)
+	assert.Nil(t, err)
+
+	// Nothing is executable or writable by others, and private files stay private
+	for name, want := range map[string]os.FileMode{"script.md": 0644, "private.md": 0600, "shared.md": 0644} {
+		info, err := rootManager.Stat(name)
+		assert.Nil(t, err)
+		assert.Equal(t, info.Mode().Perm(), want)
+	}
+}
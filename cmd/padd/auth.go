@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashPasswordCommand is the subcommand that prints the bcrypt hash of a password, for -password-hash
+const hashPasswordCommand = "hash-password"
+
+// sessionCookieName is the cookie that carries the session token of a signed-in browser
+const sessionCookieName = "padd_session"
+
+// defaultSessionDuration is how long a sign-in lasts
+const defaultSessionDuration = 30 * 24 * time.Hour
+
+// signedInKey marks the context of requests from a signed-in session
+type signedInKey struct{}
+
+// authenticator signs a single user in with a password and keeps the sessions in memory, so restarting the
+// server signs everyone out. When serving several vaults, one authenticator is shared by all of them, so
+// signing in once covers every vault.
+type authenticator struct {
+	passwordHash []byte
+	duration     time.Duration
+	mu           sync.Mutex
+	sessions     map[string]time.Time // Expiry of each session, by the SHA-256 of its token
+	now          func() time.Time
+}
+
+// newAuthenticator returns an authenticator for the password with the given bcrypt hash, whose sessions
+// last for duration
+func newAuthenticator(passwordHash string, duration time.Duration) (*authenticator, error) {
+	passwordHash = strings.TrimSpace(passwordHash)
+	if _, err := bcrypt.Cost([]byte(passwordHash)); err != nil {
+		return nil, fmt.Errorf("password hash must be a bcrypt hash, as printed by the %s command: %w", hashPasswordCommand, err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid session duration: %v", duration)
+	}
+
+	return &authenticator{
+		passwordHash: []byte(passwordHash),
+		duration:     duration,
+		sessions:     make(map[string]time.Time),
+		now:          time.Now,
+	}, nil
+}
+
+// WithAuth requires signing in with a password before any page can be viewed or changed. A nil authenticator
+// leaves the server open, which is only safe when it's bound to localhost.
+func WithAuth(auth *authenticator) ServerOption {
+	return func(s *Server) error {
+		s.auth = auth
+		return nil
+	}
+}
+
+// checkPassword reports whether password is the one the authenticator was configured with
+func (a *authenticator) checkPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword(a.passwordHash, []byte(password)) == nil
+}
+
+// startSession signs the browser in, setting a cookie with a new session token
+func (a *authenticator) startSession(w http.ResponseWriter, r *http.Request) error {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	value := base64.RawURLEncoding.EncodeToString(token)
+
+	a.mu.Lock()
+	now := a.now()
+	for key, expires := range a.sessions {
+		if !now.Before(expires) {
+			delete(a.sessions, key)
+		}
+	}
+	a.sessions[sessionKey(value)] = now.Add(a.duration)
+	a.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(a.duration.Seconds()),
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// endSession signs the browser out, forgetting its session and clearing the cookie
+func (a *authenticator) endSession(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		a.mu.Lock()
+		delete(a.sessions, sessionKey(cookie.Value))
+		a.mu.Unlock()
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isSecureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// hasSession reports whether the request carries the cookie of a session that hasn't expired
+func (a *authenticator) hasSession(r *http.Request) bool {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	expires, ok := a.sessions[sessionKey(cookie.Value)]
+	return ok && a.now().Before(expires)
+}
+
+// sessionKey returns the key a session is kept under, so the tokens themselves aren't held in memory
+func sessionKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// requireAuth wraps a handler so only signed-in browsers, and requests that carry the write token, get
+// through when a password is set. Other page views are sent to the login page, and everything else is
+// rejected with 401 Unauthorized. Requests that change data must also come from the server's own pages,
+// which stops other sites from making them with the session cookie.
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil || s.hasWriteToken(r) || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if isMutatingMethod(r.Method) && !isSameOrigin(r) {
+			http.Error(w, "Cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+
+		if s.auth.hasSession(r) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), signedInKey{}, true)))
+			return
+		}
+
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet && !isHXRequest(r) && !strings.HasPrefix(r.URL.Path, "/api/") {
+			s.redirectTo(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()))
+			return
+		}
+
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// isSignedIn reports whether the request came from a signed-in session
+func isSignedIn(r *http.Request) bool {
+	signedIn, _ := r.Context().Value(signedInKey{}).(bool)
+	return signedIn
+}
+
+// isPublicPath reports whether the path can be requested without signing in
+func isPublicPath(path string) bool {
+	return path == "/login" || path == "/robots.txt" || strings.HasPrefix(path, "/static/")
+}
+
+// isSameOrigin reports whether a request was made by a page of this server. Browsers say where a request
+// came from in Sec-Fetch-Site or, when they're older, Origin; requests with neither didn't come from a
+// browser, so they can't have been forged by another site.
+func isSameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return true
+	case "":
+	default:
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// isSecureRequest reports whether the request reached the server, or the proxy in front of it, over HTTPS
+func isSecureRequest(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// runHashPassword reads a password from the first line of stdin and prints its bcrypt hash, to be given
+// to -password-hash or PADD_PASSWORD_HASH
+func runHashPassword(stdin io.Reader, stdout io.Writer) error {
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return fmt.Errorf("usage: echo 'password' | %s %s", appName, hashPasswordCommand)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(stdout, string(hash))
+	return err
+}
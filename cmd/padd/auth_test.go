@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func newTestAuthenticator(t *testing.T, password string) *authenticator {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	assert.Nil(t, err)
+	auth, err := newAuthenticator(string(hash), time.Hour)
+	assert.Nil(t, err)
+	return auth
+}
+
+// login posts the password to the login page and returns the session cookie, if one was set
+func login(t *testing.T, handler http.Handler, password string) (*httptest.ResponseRecorder, *http.Cookie) {
+	t.Helper()
+
+	form := url.Values{"password": {password}, "next": {"/resources"}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == sessionCookieName {
+			return rec, cookie
+		}
+	}
+	return rec, nil
+}
+
+func TestRequireAuth(t *testing.T) {
+	server := newTestServer(t, WithAuth(newTestAuthenticator(t, "correct horse")))
+	handler := server.setupRoutes()
+
+	get := func(path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Pages send signed-out browsers to the login page, and the API turns them away
+	rec := get("/inbox?edit=1", nil)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/login?next=%2Finbox%3Fedit%3D1")
+	assert.Equal(t, get("/api/tasks", nil).Code, http.StatusUnauthorized)
+	assert.Equal(t, get("/login", nil).Code, http.StatusOK)
+	assert.Equal(t, get("/static/css/app.css", nil).Code, http.StatusOK)
+
+	// A wrong password doesn't start a session
+	rec, cookie := login(t, handler, "wrong")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/login?next=%2Fresources")
+	assert.Nil(t, cookie)
+
+	rec, cookie = login(t, handler, "correct horse")
+	assert.Equal(t, rec.Header().Get("Location"), "/resources")
+	assert.NotNil(t, cookie)
+	assert.True(t, cookie.HttpOnly)
+
+	rec = get("/inbox", cookie)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `hx-post="/logout"`))
+
+	// Signing out ends the session
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Header().Get("Location"), "/login")
+	assert.Equal(t, get("/inbox", cookie).Code, http.StatusFound)
+}
+
+func TestRequireAuth_CrossOrigin(t *testing.T) {
+	server := newTestServer(t, WithAuth(newTestAuthenticator(t, "correct horse")))
+	handler := server.setupRoutes()
+	_, cookie := login(t, handler, "correct horse")
+
+	save := func(headers map[string]string) int {
+		t.Helper()
+
+		form := url.Values{"content": {"# Inbox\n\nsaved\n"}}
+		req := httptest.NewRequest(http.MethodPost, "/inbox", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, save(map[string]string{"Sec-Fetch-Site": "cross-site"}), http.StatusForbidden)
+	assert.Equal(t, save(map[string]string{"Origin": "https://evil.example"}), http.StatusForbidden)
+	assert.Equal(t, save(map[string]string{"Sec-Fetch-Site": "same-origin"}), http.StatusFound)
+	assert.Equal(t, save(map[string]string{"Origin": "http://example.com"}), http.StatusFound)
+}
+
+func TestRequireAuth_WriteToken(t *testing.T) {
+	server := newTestServer(t, WithAuth(newTestAuthenticator(t, "correct horse")), WithWriteToken("s3cret"))
+	handler := server.setupRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+	req.Header.Set(writeTokenHeader, "s3cret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	// A signed-in browser can make changes without the write token
+	_, cookie := login(t, handler, "correct horse")
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n- [ ] Call Sam\n"))
+	server.fileRepo.ReloadCaches()
+
+	req = httptest.NewRequest(http.MethodPatch, "/tasks/toggle/1", nil)
+	req.Header.Set("X-PADD-File-ID", "active")
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+func TestAuthenticator_SessionExpires(t *testing.T) {
+	auth := newTestAuthenticator(t, "correct horse")
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	rec := httptest.NewRecorder()
+	assert.Nil(t, auth.startSession(rec, httptest.NewRequest(http.MethodPost, "/login", nil)))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(rec.Result().Cookies()[0])
+	assert.True(t, auth.hasSession(req))
+
+	now = now.Add(2 * time.Hour)
+	assert.False(t, auth.hasSession(req))
+}
+
+func TestNewAuthenticator_Invalid(t *testing.T) {
+	_, err := newAuthenticator("correct horse", time.Hour)
+	assert.NotNil(t, err)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	assert.Nil(t, err)
+	_, err = newAuthenticator(string(hash), 0)
+	assert.NotNil(t, err)
+}
+
+func TestRunHashPassword(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, runHashPassword(strings.NewReader("correct horse\n"), &out))
+
+	hash := strings.TrimSpace(out.String())
+	assert.Nil(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("correct horse")))
+	assert.NotNil(t, runHashPassword(strings.NewReader("\n"), &out))
+}
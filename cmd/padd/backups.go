@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// Default backup schedule
+const (
+	defaultBackupInterval = 24 * time.Hour
+	defaultBackupKeep     = 7
+)
+
+// backupCheckInterval is the longest the backup task waits between checks for a backup that's due, so
+// backups stay on schedule across restarts
+const backupCheckInterval = time.Hour
+
+// backupPrefix starts the name of every backup archive, which is followed by the time it was taken
+const backupPrefix = "padd-"
+
+// backupTimeFormat is the layout of the time in a backup archive's name, which sorts oldest first
+const backupTimeFormat = "20060102-150405"
+
+// backupStatus describes the most recent backup
+type backupStatus struct {
+	Time  time.Time // When the backup was taken; zero if there hasn't been one
+	Path  string    // Archive the backup was written to
+	Files int       // Files in the archive; 0 for backups found on disk at startup
+	Error string    // Why the backup failed, if it did
+}
+
+// backupManager snapshots the data directory as zip archives in a backup directory, keeping a limited
+// number of the most recent ones
+type backupManager struct {
+	dir      string
+	interval time.Duration // How often backups are taken; 0 only takes them by hand
+	keep     int           // How many archives are kept; 0 keeps them all
+	logger   *slog.Logger
+
+	mu   sync.Mutex // Serializes backups, and guards last
+	last backupStatus
+}
+
+// WithBackups snapshots the data directory as a timestamped zip archive in dir every interval, keeping the
+// keep most recent archives (0 keeps them all). An interval of 0 only takes backups by hand, from /settings.
+// Encrypted files are archived as they are, so backups are no less private than the data directory. When
+// serving several vaults, each vault is backed up to a subdirectory of dir named after it.
+func WithBackups(dir string, interval time.Duration, keep int) ServerOption {
+	return func(s *Server) error {
+		if dir == "" {
+			return nil
+		}
+		if interval < 0 {
+			return fmt.Errorf("invalid backup interval: %v", interval)
+		}
+		if keep < 0 {
+			return fmt.Errorf("invalid number of backups to keep: %d", keep)
+		}
+
+		s.backups = &backupManager{dir: dir, interval: interval, keep: keep}
+		return nil
+	}
+}
+
+// open creates the backup directory of the vault mounted at basePath, checking that it's outside the data
+// directory so backups never include each other, and loads the status of the newest backup already in it
+func (b *backupManager) open(dataDir, basePath string) error {
+	dir, err := filepath.Abs(filepath.Join(b.dir, strings.TrimPrefix(basePath, "/")))
+	if err != nil {
+		return fmt.Errorf("invalid backup directory: %w", err)
+	}
+	dataDir, err = filepath.Abs(dataDir)
+	if err != nil {
+		return fmt.Errorf("invalid data directory: %w", err)
+	}
+	if rel, err := filepath.Rel(dataDir, dir); err == nil && filepath.IsLocal(rel) {
+		return fmt.Errorf("backup directory %s can't be inside the data directory", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("could not create backup directory: %w", err)
+	}
+
+	b.dir = dir
+	b.last = b.newestOnDisk()
+	return nil
+}
+
+// setupBackups starts the background task that takes scheduled backups, if they're enabled
+func (s *Server) setupBackups() {
+	if s.backups == nil || s.backups.interval <= 0 {
+		return
+	}
+
+	s.backgroundRunner.AddPeriodicTask("backups", min(s.backups.interval, backupCheckInterval), func(ctx context.Context) error {
+		if last := s.backups.status(); !last.Time.IsZero() && last.Error == "" && time.Since(last.Time) < s.backups.interval {
+			return nil
+		}
+
+		status := s.backups.backup(s.rootManager)
+		if status.Error != "" {
+			return fmt.Errorf("backup failed: %s", status.Error)
+		}
+		return nil
+	})
+}
+
+// backup takes a backup of the data directory now and returns its status
+func (b *backupManager) backup(rootManager *files.RootManager) backupStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	status := backupStatus{Time: now, Path: filepath.Join(b.dir, backupPrefix+now.Format(backupTimeFormat)+".zip")}
+
+	count, err := b.write(rootManager, status.Path)
+	if err != nil {
+		status.Error = err.Error()
+		b.last = status
+		return status
+	}
+	status.Files = count
+	b.last = status
+
+	b.logger.Info("Backed up files", "count", count, "path", status.Path)
+	b.prune()
+	return status
+}
+
+// write writes the data directory to the archive at path. The archive is written under a temporary name
+// and renamed when it's complete, so a partial archive is never mistaken for a backup.
+func (b *backupManager) write(rootManager *files.RootManager, path string) (int, error) {
+	tmpPath := path + ".partial"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+
+	summary, err := writeArchive(rootManager, file, nil)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return 0, err
+	}
+
+	return summary.Files, nil
+}
+
+// status returns the status of the most recent backup
+func (b *backupManager) status() backupStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
+
+// archives returns the names of the backup archives in the backup directory, oldest first
+func (b *backupManager) archives() []string {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		b.logger.Error("Error reading backup directory", "dir", b.dir, "error", err)
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, ".zip") {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// newestOnDisk returns the status of the newest backup archive in the backup directory, so the schedule and
+// the settings page pick up where they left off after a restart
+func (b *backupManager) newestOnDisk() backupStatus {
+	names := b.archives()
+	if len(names) == 0 {
+		return backupStatus{}
+	}
+
+	name := names[len(names)-1]
+	taken, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), ".zip"), time.Local)
+	if err != nil {
+		return backupStatus{}
+	}
+
+	return backupStatus{Time: taken, Path: filepath.Join(b.dir, name)}
+}
+
+// prune removes the oldest backup archives beyond the number to keep
+func (b *backupManager) prune() {
+	if b.keep <= 0 {
+		return
+	}
+
+	names := b.archives()
+	for len(names) > b.keep {
+		if err := os.Remove(filepath.Join(b.dir, names[0])); err != nil {
+			b.logger.Error("Error removing old backup", "file", names[0], "error", err)
+		}
+		names = names[1:]
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestBackups(t *testing.T) {
+	t.Parallel()
+
+	backupDir := t.TempDir()
+
+	// Backups from earlier runs are picked up, and pruned beyond the number to keep
+	for _, name := range []string{"padd-20240101-080000.zip", "padd-20240102-080000.zip", "notes.zip"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(backupDir, name), nil, 0600))
+	}
+
+	server := newTestServer(t, WithBackups(backupDir, 0, 2))
+	assert.Equal(t, server.backups.status().Path, filepath.Join(backupDir, "padd-20240102-080000.zip"))
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settings", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "2024-01-02 08:00"))
+
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/settings/backup", nil))
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	status := server.backups.status()
+	assert.Equal(t, status.Error, "")
+	assert.MatchesRegexp(t, filepath.Base(status.Path), `^padd-\d{8}-\d{6}\.zip$`)
+
+	archive, err := zip.OpenReader(status.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, len(archive.File), status.Files)
+	assert.Nil(t, archive.Close())
+
+	entries, err := os.ReadDir(backupDir)
+	assert.Nil(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, names, []string{"notes.zip", "padd-20240102-080000.zip", filepath.Base(status.Path)})
+}
+
+func TestBackups_Scheduled(t *testing.T) {
+	t.Parallel()
+
+	// A backup is taken at startup when none is recent enough
+	server := newTestServer(t, WithBackups(t.TempDir(), time.Hour, 0))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.backups.status().Time.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	status := server.backups.status()
+	assert.Equal(t, status.Error, "")
+	_, err := os.Stat(status.Path)
+	assert.Nil(t, err)
+}
+
+func TestWithBackups_InsideDataDirectory(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	_, err := NewServer(context.Background(), dataDir, WithBackups(filepath.Join(dataDir, "backups"), time.Hour, 0))
+	assert.NotNil(t, err)
+
+	rec := httptest.NewRecorder()
+	newTestServer(t).setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/settings/backup", nil))
+	assert.Equal(t, rec.Code, http.StatusFound)
+}
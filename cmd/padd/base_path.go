@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// basePathPattern matches attributes in rendered HTML whose value is an absolute path on this server.
+// Protocol-relative values ("//host/...") don't match.
+var basePathPattern = regexp.MustCompile(`(\s(?:href|src|action|hx-get|hx-post|hx-put|hx-patch|hx-delete|cancel-url|icons-api-url)=")/([^/])`)
+
+// WithBasePath mounts the server under a path prefix (e.g., "/work"), so it can serve one of several vaults.
+// Redirects and the absolute links in rendered HTML are prefixed to match.
+func WithBasePath(basePath string) ServerOption {
+	return func(s *Server) error {
+		basePath = strings.TrimRight(basePath, "/")
+		if basePath != "" && !strings.HasPrefix(basePath, "/") {
+			return fmt.Errorf("base path must start with a slash: %s", basePath)
+		}
+		s.basePath = basePath
+		return nil
+	}
+}
+
+// prefixBasePath wraps a handler so the absolute links in its HTML responses point under the base path.
+// The handlers and templates keep writing root-relative links, since they don't know where they're mounted.
+func (s *Server) prefixBasePath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bw := &basePathWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+
+		switch {
+		case bw.buffering:
+			body := basePathPattern.ReplaceAll(bw.buf.Bytes(), []byte("${1}"+s.basePath+"/${2}"))
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(bw.status)
+			_, _ = w.Write(body)
+		case bw.pending:
+			w.WriteHeader(bw.status)
+		}
+	})
+}
+
+// basePathWriter holds back HTML response bodies so their links can be rewritten; other responses pass
+// through. The decision waits for the first write when the handler sets a status before a content type.
+type basePathWriter struct {
+	http.ResponseWriter
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	pending     bool
+	buffering   bool
+}
+
+func (bw *basePathWriter) WriteHeader(status int) {
+	if bw.wroteHeader {
+		return
+	}
+	bw.wroteHeader = true
+	bw.status = status
+
+	if bw.Header().Get("Content-Type") == "" {
+		bw.pending = true
+		return
+	}
+
+	bw.start()
+}
+
+func (bw *basePathWriter) Write(p []byte) (int, error) {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+
+	if bw.pending {
+		bw.Header().Set("Content-Type", http.DetectContentType(p))
+		bw.pending = false
+		bw.start()
+	}
+
+	if bw.buffering {
+		return bw.buf.Write(p)
+	}
+
+	return bw.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter, so http.ResponseController can flush streamed responses
+func (bw *basePathWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
+
+// start either begins buffering an HTML response or sends the status of any other response
+func (bw *basePathWriter) start() {
+	if strings.HasPrefix(bw.Header().Get("Content-Type"), "text/html") {
+		bw.buffering = true
+		bw.Header().Del("Content-Length")
+		return
+	}
+
+	bw.ResponseWriter.WriteHeader(bw.status)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestLimitRequestBody_RejectsOversizedBody(t *testing.T) {
+	server := newTestServer(t)
+	server.maxBodyBytes = 64
+
+	form := url.Values{"content": {strings.Repeat("x", 128)}}
+	req := httptest.NewRequest(http.MethodPost, "/inbox", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusRequestEntityTooLarge)
+
+	// The inbox was not overwritten
+	content, err := server.rootManager.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(content), "xxxx"))
+}
+
+func TestLimitRequestBody_UnknownLength(t *testing.T) {
+	server := newTestServer(t)
+	server.maxBodyBytes = 64
+
+	form := url.Values{"content": {strings.Repeat("x", 128)}}
+	req := httptest.NewRequest(http.MethodPost, "/inbox", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusRequestEntityTooLarge)
+}
+
+func TestLimitRequestBody_AllowsGet(t *testing.T) {
+	server := newTestServer(t)
+	server.maxBodyBytes = 1
+
+	req := httptest.NewRequest(http.MethodGet, "/inbox", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
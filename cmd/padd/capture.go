@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// captureCommands are the subcommands that add an entry to the data directory without starting the server
+var captureCommands = []string{"add", "daily", "journal"}
+
+// openFileRepository opens the file repository of a data directory for use outside the server, with the same
+// file config as the server. Changes are
+// recorded in the version history when history is enabled or the data directory is already a git repository,
+// the same as the server does.
+func openFileRepository(dataDir string, config files.FileConfig, encryptionManager *crypto.EncryptionManager, history bool) (*files.FileRepository, error) {
+	rootManager, err := files.NewRootManager(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	fileRepo := files.NewFileRepository(rootManager, files.DefaultFileConfig)
+	if err := fileRepo.SetConfig(config); err != nil {
+		return nil, err
+	}
+	fileRepo.SetEncryptionManager(encryptionManager)
+	if err := fileRepo.Initialize(); err != nil {
+		return nil, fmt.Errorf("could not initialize file repository: %w", err)
+	}
+	fileRepo.ReloadCaches()
+
+	if history || files.IsGitRepository(dataDir) {
+		store, err := files.NewGitVersionStore(dataDir)
+		if err != nil {
+			return nil, fmt.Errorf("could not enable version history: %w", err)
+		}
+		fileRepo.SetVersionStore(store)
+	}
+
+	return fileRepo, nil
+}
+
+// runCapture runs a capture subcommand against the file repository:
+//
+//	add [-to id] [-section header] [-task] text
+//	daily text
+//	journal text
+//
+// The entry is the remaining arguments joined by spaces, or standard input when there are none, so it can
+// be piped in from scripts. It prints the ID of the file the entry was added to.
+func runCapture(fileRepo *files.FileRepository, command string, args []string, stdin io.Reader, stdout io.Writer) error {
+	flagSet := flag.NewFlagSet(appName+" "+command, flag.ContinueOnError)
+	flagSet.SetOutput(stdout)
+	var to, section string
+	var asTask bool
+	if command == "add" {
+		flagSet.StringVar(&to, "to", defaultClipTarget, "File ID to add the entry to.")
+		flagSet.StringVar(&section, "section", "", "Section header (without ##) to add the entry under. Defaults to the top of the file.")
+		flagSet.BoolVar(&asTask, "task", false, "Add the entry as a task.")
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	entry := strings.TrimSpace(strings.Join(flagSet.Args(), " "))
+	if entry == "" && stdin != nil {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("error reading entry: %w", err)
+		}
+		entry = strings.TrimSpace(string(input))
+	}
+	if entry == "" {
+		return errors.New("entry cannot be empty")
+	}
+
+	var doc *files.Document
+	var err error
+	switch command {
+	case "daily", "journal":
+		directory := fileRepo.Config().DailyDirectory
+		if command == "journal" {
+			directory = fileRepo.Config().JournalDirectory
+		}
+		doc, err = fileRepo.AddTemporalEntry(directory, entry, files.EntryInsertionConfig{
+			Strategy:       files.InsertByTimestamp,
+			EntryTimestamp: time.Now(),
+			EntryFormatter: files.TimestampEntryFormatter,
+		})
+	case "add":
+		doc, err = addCaptureEntry(fileRepo, to, section, asTask, entry)
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add entry: %w", err)
+	}
+
+	_, _ = fmt.Fprintln(stdout, doc.Info.ID)
+	return nil
+}
+
+// addCaptureEntry adds an entry to a file the way the "Add an Entry" form does: as a note or task at the
+// top of the file, or of a section when a header is given
+func addCaptureEntry(fileRepo *files.FileRepository, fileID, section string, asTask bool, entry string) (*files.Document, error) {
+	doc, err := fileRepo.GetDocument(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	var header string
+	if section = strings.TrimSpace(section); section != "" {
+		header = "## " + section
+	}
+
+	entryFormatter := files.NoteEntryFormatter
+	if asTask {
+		entryFormatter = files.TaskEntryFormatter
+	}
+
+	err = doc.AddEntry(entry, files.EntryInsertionConfig{
+		Strategy:       files.InsertInSection,
+		EntryFormatter: entryFormatter,
+		SectionConfig: &files.SectionInsertionConfig{
+			SectionHeader:  header,
+			InsertAtTop:    true,
+			BlankLineAfter: false,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
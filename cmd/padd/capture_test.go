@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestRunCapture_Add(t *testing.T) {
+	fileRepo, err := openFileRepository(t.TempDir(), files.DefaultFileConfig, crypto.NewEncryptionManager(), false)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	err = runCapture(fileRepo, "add", []string{"buy", "milk"}, nil, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, out.String(), "inbox\n")
+
+	err = runCapture(fileRepo, "add", []string{"-task", "-section", "Errands", "call the bank"}, nil, &out)
+	assert.Nil(t, err)
+
+	doc, err := fileRepo.GetDocument("inbox")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "buy milk"))
+	assert.True(t, strings.Contains(content, "## Errands"))
+	assert.True(t, strings.Contains(content, "- [ ] call the bank"))
+}
+
+func TestRunCapture_Temporal(t *testing.T) {
+	fileRepo, err := openFileRepository(t.TempDir(), files.DefaultFileConfig, crypto.NewEncryptionManager(), false)
+	assert.Nil(t, err)
+
+	var out bytes.Buffer
+	err = runCapture(fileRepo, "daily", nil, strings.NewReader("shipped the release\n"), &out)
+	assert.Nil(t, err)
+
+	id := strings.TrimSpace(out.String())
+	assert.Equal(t, id, "daily/"+strings.ToLower(time.Now().Format("2006/01-January")))
+
+	doc, err := fileRepo.GetOrCreateTemporalDocument("daily", time.Now())
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "shipped the release"))
+}
+
+func TestRunCapture_Errors(t *testing.T) {
+	fileRepo, err := openFileRepository(t.TempDir(), files.DefaultFileConfig, crypto.NewEncryptionManager(), false)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+	}{
+		{name: "empty entry", command: "add", args: []string{"  "}},
+		{name: "missing file", command: "add", args: []string{"-to", "resources/missing", "note"}},
+		{name: "add flags on temporal", command: "journal", args: []string{"-task", "note"}},
+		{name: "unknown command", command: "remove", args: []string{"note"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runCapture(fileRepo, tt.command, tt.args, strings.NewReader(""), &bytes.Buffer{})
+			assert.NotNil(t, err)
+		})
+	}
+}
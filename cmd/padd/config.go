@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	envPaddConfig  = "PADD_CONFIG"
+	configFileName = "config.yaml"
+	metadataKey    = "metadata" // Config file key holding badge colors rather than an option
+)
+
+// configEnvVars are the environment variables that set options, by flag name. An environment variable that
+// is set takes precedence over the config file.
+var configEnvVars = map[string]string{
+	"data":          envPaddData,
+	"keys-dir":      envPaddKeys,
+	"identity":      envPaddIdentities,
+	"recipient":     envPaddRecipients,
+	"write-token":   envPaddWriteToken,
+	"sync-remote":   envPaddSyncRemote,
+	"password-hash": envPaddPassword,
+	"tls-cert":      envPaddTLSCert,
+	"tls-key":       envPaddTLSKey,
+	"autocert":      envPaddAutocert,
+}
+
+// configFile holds the settings read from the config file. Every command line option can be set in it, by
+// its long name without the dash, and badge colors can be set under "metadata" with the keys of
+// metadata.json.
+type configFile struct {
+	path     string
+	options  map[string][]string // Option values by flag name; lists give a repeatable option several values
+	metadata map[string]any
+}
+
+// getXDGConfigHome determines the XDG_CONFIG_HOME directory.
+func getXDGConfigHome() (string, error) {
+	xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	if xdgConfigHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("unable to determine user home directory: %v", err)
+		}
+		xdgConfigHome = filepath.Join(homeDir, ".config")
+	}
+
+	return xdgConfigHome, nil
+}
+
+// loadConfigFile reads the config file given by the -config flag or PADD_CONFIG, or else
+// XDG_CONFIG_HOME/padd/config.yaml. A missing default config file isn't an error; nil is returned.
+func loadConfigFile(flagValue string) (*configFile, error) {
+	path := getConfigValue(flagValue, envPaddConfig, "")
+	if path == "" {
+		xdgConfigHome, err := getXDGConfigHome()
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine XDG_CONFIG_HOME: %v", err)
+		}
+
+		path = filepath.Join(xdgConfigHome, "padd", configFileName)
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+
+	config, err := parseConfigFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	config.path = path
+
+	return config, nil
+}
+
+// parseConfigFile parses the YAML content of a config file
+func parseConfigFile(content []byte) (*configFile, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	config := &configFile{options: make(map[string][]string)}
+	for name, value := range raw {
+		if name == metadataKey {
+			metadata, ok := stringKeys(value).(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a mapping", metadataKey)
+			}
+			config.metadata = metadata
+			continue
+		}
+
+		switch value := value.(type) {
+		case nil:
+			config.options[name] = []string{""}
+		case []any:
+			values := make([]string, 0, len(value))
+			for _, item := range value {
+				values = append(values, expandHome(fmt.Sprint(item)))
+			}
+			config.options[name] = values
+		case map[any]any:
+			return nil, fmt.Errorf("option %s must be a value or a list", name)
+		default:
+			config.options[name] = []string{expandHome(fmt.Sprint(value))}
+		}
+	}
+
+	return config, nil
+}
+
+// apply sets the options in the config file on flagSet, once it has parsed the command line. Options given
+// on the command line, under either of their names, or by an environment variable keep those values.
+func (c *configFile) apply(flagSet *flag.FlagSet) error {
+	fromCommandLine := make(map[flag.Value]bool)
+	flagSet.Visit(func(f *flag.Flag) {
+		fromCommandLine[f.Value] = true
+	})
+
+	names := make([]string, 0, len(c.options))
+	for name := range c.options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		f := flagSet.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown option %q in config file %s", name, c.path)
+		}
+		if fromCommandLine[f.Value] || os.Getenv(configEnvVars[name]) != "" {
+			continue
+		}
+
+		values := c.options[name]
+		if _, repeatable := f.Value.(*vaultList); !repeatable {
+			values = []string{strings.Join(values, ",")}
+		}
+		for _, value := range values {
+			if err := flagSet.Set(name, value); err != nil {
+				return fmt.Errorf("invalid value for %s in config file %s: %w", name, c.path, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// stringKeys converts the map[any]any values that YAML decodes mappings into to map[string]any, like the
+// mappings decoded from JSON
+func stringKeys(value any) any {
+	switch value := value.(type) {
+	case map[any]any:
+		converted := make(map[string]any, len(value))
+		for k, v := range value {
+			converted[fmt.Sprint(k)] = stringKeys(v)
+		}
+		return converted
+	case map[string]any:
+		for k, v := range value {
+			value[k] = stringKeys(v)
+		}
+		return value
+	case []any:
+		for i, v := range value {
+			value[i] = stringKeys(v)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// expandHome replaces a leading "~/" with the user's home directory, since the shell doesn't expand paths in
+// the config file
+func expandHome(value string) string {
+	if !strings.HasPrefix(value, "~/") {
+		return value
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return value
+	}
+
+	return filepath.Join(homeDir, value[2:])
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+const testConfigFile = `
+data: /srv/notes
+port: 9090
+history: true
+backup-interval: 12h
+encrypt-dirs: [resources/private, journal]
+vault:
+  - work=/srv/work
+  - home=/srv/home
+metadata:
+  status_colors:
+    blocked: danger vivid
+  tag_color: warning muted
+`
+
+// testFlagSet defines a few of the server's options, with the short aliases main uses
+func testFlagSet(port *int, dataDir *string, history *bool, backupInterval *time.Duration, encryptDirs *string, vaults *vaultList) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	flagSet.IntVar(port, "port", 8080, "")
+	flagSet.IntVar(port, "p", 8080, "")
+	flagSet.StringVar(dataDir, "data", "", "")
+	flagSet.StringVar(dataDir, "d", "", "")
+	flagSet.BoolVar(history, "history", false, "")
+	flagSet.DurationVar(backupInterval, "backup-interval", 24*time.Hour, "")
+	flagSet.StringVar(encryptDirs, "encrypt-dirs", "", "")
+	flagSet.Var(vaults, "vault", "")
+	return flagSet
+}
+
+func TestConfigFile_Apply(t *testing.T) {
+	config, err := parseConfigFile([]byte(testConfigFile))
+	assert.Nil(t, err)
+
+	var port int
+	var dataDir, encryptDirs string
+	var history bool
+	var backupInterval time.Duration
+	var vaults vaultList
+	flagSet := testFlagSet(&port, &dataDir, &history, &backupInterval, &encryptDirs, &vaults)
+	assert.Nil(t, flagSet.Parse(nil))
+
+	t.Setenv(envPaddData, "")
+	assert.Nil(t, config.apply(flagSet))
+	assert.Equal(t, port, 9090)
+	assert.Equal(t, dataDir, "/srv/notes")
+	assert.True(t, history)
+	assert.Equal(t, backupInterval, 12*time.Hour)
+	assert.Equal(t, encryptDirs, "resources/private,journal")
+	assert.Equal(t, len(vaults), 2)
+	assert.Equal(t, vaults[1].Dir, "/srv/home")
+
+	statusColors, ok := config.metadata["status_colors"].(map[string]any)
+	assert.True(t, ok)
+	assert.Equal(t, statusColors["blocked"], any("danger vivid"))
+}
+
+func TestConfigFile_Precedence(t *testing.T) {
+	config, err := parseConfigFile([]byte(testConfigFile))
+	assert.Nil(t, err)
+
+	var port int
+	var dataDir, encryptDirs string
+	var history bool
+	var backupInterval time.Duration
+	var vaults vaultList
+	flagSet := testFlagSet(&port, &dataDir, &history, &backupInterval, &encryptDirs, &vaults)
+
+	// The short alias counts as giving the option on the command line, and the environment beats the file
+	assert.Nil(t, flagSet.Parse([]string{"-p", "7070"}))
+	t.Setenv(envPaddData, "/home/me/notes")
+	assert.Nil(t, config.apply(flagSet))
+	assert.Equal(t, port, 7070)
+	assert.Equal(t, dataDir, "")
+	assert.Equal(t, backupInterval, 12*time.Hour)
+}
+
+func TestConfigFile_Invalid(t *testing.T) {
+	var port int
+	var dataDir, encryptDirs string
+	var history bool
+	var backupInterval time.Duration
+	var vaults vaultList
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown option", content: "colour: blue"},
+		{name: "invalid value", content: "port: many"},
+		{name: "mapping value", content: "port:\n  http: 80"},
+		{name: "metadata not a mapping", content: "metadata: red"},
+		{name: "not yaml", content: "port: [8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseConfigFile([]byte(tt.content))
+			if err == nil {
+				flagSet := testFlagSet(&port, &dataDir, &history, &backupInterval, &encryptDirs, &vaults)
+				assert.Nil(t, flagSet.Parse(nil))
+				err = config.apply(flagSet)
+			}
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv(envPaddConfig, "")
+
+	// A missing default config file is fine, but a missing file that was asked for isn't
+	config, err := loadConfigFile("")
+	assert.Nil(t, err)
+	assert.True(t, config == nil)
+	_, err = loadConfigFile(filepath.Join(configHome, "missing.yaml"))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(configHome, "padd"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(configHome, "padd", configFileName), []byte("port: 9090\nbackup-dir: ~/backups\n"), 0600))
+	config, err = loadConfigFile("")
+	assert.Nil(t, err)
+	assert.Equal(t, config.options["port"][0], "9090")
+
+	homeDir, err := os.UserHomeDir()
+	assert.Nil(t, err)
+	assert.Equal(t, config.options["backup-dir"][0], filepath.Join(homeDir, "backups"))
+}
+
+func TestWithMetadataColors(t *testing.T) {
+	config, err := parseConfigFile([]byte(testConfigFile))
+	assert.Nil(t, err)
+
+	server := newTestServer(t, WithMetadataColors(config.metadata))
+	assert.Equal(t, server.getStatusColor("blocked"), "danger vivid")
+	assert.Equal(t, server.getTagColor(), "warning muted")
+
+	// metadata.json in the data directory overrides the config file
+	assert.Nil(t, server.rootManager.WriteString("metadata.json", `{"status_colors": {"blocked": "primary muted"}}`))
+	server.setupMetadataConfig()
+	assert.Equal(t, server.getStatusColor("blocked"), "primary muted")
+	assert.Equal(t, server.getTagColor(), "warning muted")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// temporalGranularities maps the -temporal-files option values to their granularity
+var temporalGranularities = map[string]files.TemporalGranularity{
+	"monthly": files.MonthlyFiles,
+	"daily":   files.DailyFiles,
+}
+
+// donePlacements maps the -done-placement option values to their placement
+var donePlacements = map[string]files.DonePlacement{
+	"end":         files.DoneAtEnd,
+	"before-tags": files.DoneBeforeTags,
+}
+
+// newFileConfig returns the default file config with the core files, temporal granularity, @done placement,
+// and exclude globs given as option values. Lists are comma-separated.
+func newFileConfig(coreFiles, granularity, donePlacement, excludeGlobs string) (files.FileConfig, error) {
+	config := files.DefaultFileConfig
+
+	config.CoreFiles = splitList(coreFiles)
+	if len(config.CoreFiles) == 0 {
+		return config, fmt.Errorf("at least one core file is required")
+	}
+
+	var ok bool
+	if config.TemporalGranularity, ok = temporalGranularities[granularity]; !ok {
+		return config, fmt.Errorf("invalid temporal file granularity %q (expected monthly or daily)", granularity)
+	}
+	if config.DonePlacement, ok = donePlacements[donePlacement]; !ok {
+		return config, fmt.Errorf("invalid @done placement %q (expected end or before-tags)", donePlacement)
+	}
+	config.ExcludeGlobs = splitList(excludeGlobs)
+
+	return config, nil
+}
+
+// splitList splits a comma-separated option value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
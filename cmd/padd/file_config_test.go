@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestNewFileConfig(t *testing.T) {
+	config, err := newFileConfig(" todo.md, someday.md,", "daily", "before-tags", "**/drafts/**,*.tmp.md")
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Join(config.CoreFiles, ","), "todo.md,someday.md")
+	assert.Equal(t, config.TemporalGranularity, files.DailyFiles)
+	assert.Equal(t, config.DonePlacement, files.DoneBeforeTags)
+	assert.Equal(t, len(config.ExcludeGlobs), 2)
+	assert.True(t, config.NormalizeOnSave)
+
+	_, err = newFileConfig("", "monthly", "end", "")
+	assert.NotNil(t, err)
+	_, err = newFileConfig("inbox.md", "weekly", "end", "")
+	assert.NotNil(t, err)
+	_, err = newFileConfig("inbox.md", "monthly", "start", "")
+	assert.NotNil(t, err)
+}
+
+func TestWithFileConfig(t *testing.T) {
+	config, err := newFileConfig("todo.md,someday.md", "daily", "end", "")
+	assert.Nil(t, err)
+
+	server := newTestServer(t, WithFileConfig(config))
+	assert.True(t, server.rootManager.FileExists("todo.md"))
+	assert.False(t, server.rootManager.FileExists("inbox.md"))
+
+	// The first core file is the home page, and the core files lead the navigation menu
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Todo"))
+
+	var ids []string
+	for _, item := range server.navigationMenu("") {
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, strings.Join(ids, ","), "todo,someday,daily,journal,resources")
+
+	// Daily entries go in a file per day
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/daily", nil))
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.MatchesRegexp(t, rec.Header().Get("Location"), `^/daily/\d{4}/\d{2}/\d{2}$`)
+}
+
+func TestWithFileConfig_Directories(t *testing.T) {
+	config, err := newFileConfig("inbox.md", "monthly", "end", "")
+	assert.Nil(t, err)
+	config.ResourcesDirectory = "notes"
+	config.DailyDirectory = "log"
+	config.JournalDirectory = "diary"
+	config.DocumentCacheSize = 5
+
+	server := newTestServer(t, WithFileConfig(config))
+	assert.True(t, server.rootManager.FileExists("notes"))
+	assert.True(t, server.rootManager.FileExists("log"))
+	assert.True(t, server.rootManager.FileExists("diary"))
+	assert.False(t, server.rootManager.FileExists("resources"))
+	assert.Equal(t, server.fileRepo.Config().DocumentCacheSize, 5)
+
+	doc, err := server.fileRepo.GetOrCreateResourceDocument("plan", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, doc.Info.Path, "notes/plan.md")
+	assert.Equal(t, doc.Info.RelativePath(), "plan.md")
+
+	// Directories are distinct names at the top of the data directory
+	for _, dirs := range [][3]string{
+		{"", "log", "diary"},
+		{"notes", "logs/daily", "diary"},
+		{"notes", "log", ".diary"},
+		{"notes", "log", "log"},
+	} {
+		invalid := config
+		invalid.ResourcesDirectory, invalid.DailyDirectory, invalid.JournalDirectory = dirs[0], dirs[1], dirs[2]
+		assert.NotNil(t, WithFileConfig(invalid)(server))
+	}
+}
+
+func TestWithFileConfig_DirectoryRoutes(t *testing.T) {
+	config, err := newFileConfig("inbox.md", "monthly", "end", "")
+	assert.Nil(t, err)
+	config.ResourcesDirectory = "notes"
+	config.DailyDirectory = "log"
+	config.JournalDirectory = "diary"
+
+	server := newTestServer(t, WithFileConfig(config))
+	handler := server.setupRoutes()
+	get := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	// The temporal and resource pages follow their directories
+	rec := get("/log")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.MatchesRegexp(t, rec.Header().Get("Location"), `^/log/\d{4}/\d{2}-[a-z]+$`)
+	assert.Equal(t, get("/diary/archive").Code, http.StatusOK)
+	assert.Equal(t, get("/notes").Code, http.StatusOK)
+	assert.Equal(t, get("/daily").Code, http.StatusNotFound)
+
+	var ids []string
+	for _, item := range server.navigationMenu("") {
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, strings.Join(ids, ","), "inbox,log,diary,notes")
+
+	// Entries are added to the renamed directory, and its pages link to its own routes
+	form := url.Values{"entry": {"Walked the dog"}}
+	req := httptest.NewRequest(http.MethodPost, "/log", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	server.fileRepo.ReloadCaches()
+
+	page := get(get("/log").Header().Get("Location"))
+	assert.Equal(t, page.Code, http.StatusOK)
+	assert.True(t, strings.Contains(page.Body.String(), "Walked the dog"))
+	assert.True(t, strings.Contains(page.Body.String(), `href="/log/archive"`))
+	assert.True(t, strings.Contains(page.Body.String(), `"/log"`))
+
+	// Directories can't take the name of a route
+	config.DailyDirectory = "search"
+	assert.NotNil(t, WithFileConfig(config)(server))
+}
+
+func TestWithFileConfig_AutoUpdatedAt(t *testing.T) {
+	config, err := newFileConfig("inbox.md", "monthly", "end", "")
+	assert.Nil(t, err)
+	config.AutoUpdatedAt = true
+
+	server := newTestServer(t, WithFileConfig(config))
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "---\ndue_date: 2025-10-01\n---\n# Notes\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := postSave(server, "resources/notes", url.Values{"content": {"---\ndue_date: 2025-10-01\n---\n# Notes\n\nEdited.\n"}})
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	doc, err := server.fileRepo.GetDocument("resources/notes")
+	assert.Nil(t, err)
+	fm, err := doc.Frontmatter()
+	assert.Nil(t, err)
+	updatedAt, ok := fm.Get("updated_at")
+	assert.True(t, ok)
+
+	// The page header shows the stamp without it being edited in
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resources/notes", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Updated: "+updatedAt))
+	assert.True(t, strings.Contains(rec.Body.String(), "Due: 2025-10-01"))
+}
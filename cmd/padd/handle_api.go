@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// APIErrorResponse is the JSON payload returned by API endpoints on failure
+type APIErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// splitDocumentAction splits a document API path (e.g., "resources/notes/breadcrumbs") into the
+// document ID and the trailing action segment. Document IDs may contain slashes, so the action
+// is always the last path segment.
+func splitDocumentAction(path string) (id string, action string) {
+	path = strings.Trim(path, "/")
+	idx := strings.LastIndex(path, "/")
+	if idx == -1 {
+		return "", path
+	}
+
+	return path[:idx], path[idx+1:]
+}
+
+// excerptMaxChars is the longest excerpt served for link previews
+const excerptMaxChars = 280
+
+// ExcerptResponse is the JSON payload returned for a document's link preview
+type ExcerptResponse struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Excerpt string `json:"excerpt"`
+}
+
+// LockResponse is the JSON payload returned after toggling a document's lock
+type LockResponse struct {
+	ID     string `json:"id"`
+	Locked bool   `json:"locked"`
+}
+
+// handleDocumentAPI dispatches /api/doc/{id}/{action} requests to the matching handler
+func (s *Server) handleDocumentAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, action := splitDocumentAction(r.PathValue("path"))
+	if id == "" {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Missing document ID"}, http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "breadcrumbs":
+		s.handleBreadcrumbsAPI(w, r, id)
+	case r.Method == http.MethodGet && action == "excerpt":
+		s.handleExcerptAPI(w, r, id)
+	case r.Method == http.MethodPost && action == "lock":
+		s.handleLockAPI(w, r, id)
+	default:
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Unknown document action: " + action}, http.StatusNotFound)
+	}
+}
+
+// handleBreadcrumbsAPI serves the breadcrumb parts for a document as JSON
+func (s *Server) handleBreadcrumbsAPI(w http.ResponseWriter, _ *http.Request, id string) {
+	info, err := s.fileRepo.FileInfo(id)
+	if err != nil {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Document not found"}, http.StatusNotFound)
+		return
+	}
+
+	s.respondWithJSON(w, s.fileRepo.Breadcrumbs(info))
+}
+
+// handleExcerptAPI serves the first paragraph of a document for link previews
+func (s *Server) handleExcerptAPI(w http.ResponseWriter, r *http.Request, id string) {
+	doc, err := s.fileRepo.GetDocument(id)
+	if err != nil || doc.Info.IsDirectory || doc.Info.IsCSV() {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Document not found"}, http.StatusNotFound)
+		return
+	}
+
+	// Content is still encrypted when no identities are loaded to decrypt it
+	content, err := doc.Content()
+	if err != nil {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Failed to read document"}, http.StatusInternalServerError)
+		return
+	}
+	if crypto.IsAgeEncrypted([]byte(content)) {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Document is encrypted"}, http.StatusForbidden)
+		return
+	}
+
+	excerpt, err := doc.Excerpt(excerptMaxChars)
+	if err != nil {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Failed to read document"}, http.StatusInternalServerError)
+		return
+	}
+
+	response := ExcerptResponse{ID: doc.Info.ID, Title: doc.Info.TitleBase, Excerpt: excerpt}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleLockAPI toggles the "locked" frontmatter flag of a document
+func (s *Server) handleLockAPI(w http.ResponseWriter, r *http.Request, id string) {
+	doc, err := s.fileRepo.GetDocument(id)
+	if err != nil || doc.Info.IsDirectory {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Document not found"}, http.StatusNotFound)
+		return
+	}
+
+	locked, err := doc.IsLocked()
+	if err != nil {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Failed to read document"}, http.StatusInternalServerError)
+		return
+	}
+
+	if err := doc.SetLocked(!locked); err != nil {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Failed to update lock"}, http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(LockResponse{ID: doc.Info.ID, Locked: !locked}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// MetadataValuesResponse is the JSON payload listing the values in use for a frontmatter key
+type MetadataValuesResponse struct {
+	Key    string         `json:"key"`
+	Values map[string]int `json:"values"`
+}
+
+// handleMetadataValuesAPI serves the distinct values of a frontmatter key, with the number of documents
+// using each, for building filter menus
+func (s *Server) handleMetadataValuesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	key := r.PathValue("key")
+	values, err := s.fileRepo.DistinctMetadataValues(key)
+	if err != nil {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Invalid metadata key"}, http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(MetadataValuesResponse{Key: key, Values: values}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// TasksResponse is the JSON payload listing the tasks across all files
+type TasksResponse struct {
+	Counts files.TaskCounts `json:"counts"`
+	Tasks  []files.FileTask `json:"tasks"`
+}
+
+// handleTasksAPI serves every task in the indexed files as JSON, along with the total, completed, and
+// pending counts. The optional "status" query parameter ("pending" or "completed") limits the tasks listed,
+// but the counts always cover every task.
+func (s *Server) handleTasksAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status != "" && status != "pending" && status != "completed" {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Invalid status: " + status}, http.StatusBadRequest)
+		return
+	}
+
+	response := TasksResponse{Tasks: []files.FileTask{}}
+	for _, task := range s.fileRepo.AllTasks() {
+		response.Counts.Total++
+		if task.Completed {
+			response.Counts.Completed++
+		}
+
+		if status == "" || (status == "completed") == task.Completed {
+			response.Tasks = append(response.Tasks, task)
+		}
+	}
+	response.Counts.Pending = response.Counts.Total - response.Counts.Completed
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// ReloadConfigResponse is the JSON payload returned after reloading the configuration
+type ReloadConfigResponse struct {
+	Reloaded bool `json:"reloaded"`
+}
+
+// handleReloadConfig re-reads metadata.json and nav.json and refreshes the file caches so color and navigation changes
+// apply without restarting the server
+func (s *Server) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.setupMetadataConfig()
+	s.setupNavigation()
+	s.fileRepo.ReloadCaches()
+
+	if err := json.NewEncoder(w).Encode(ReloadConfigResponse{Reloaded: true}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
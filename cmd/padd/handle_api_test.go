@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestHandleLockAPI(t *testing.T) {
+	server := newTestServer(t)
+
+	lock := func() LockResponse {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/doc/active/lock", nil)
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		assert.Equal(t, rec.Code, http.StatusOK)
+
+		var response LockResponse
+		assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+		return response
+	}
+
+	assert.True(t, lock().Locked)
+
+	doc, err := server.fileRepo.GetDocument("active")
+	assert.Nil(t, err)
+	locked, err := doc.IsLocked()
+	assert.Nil(t, err)
+	assert.True(t, locked)
+
+	// Toggling again unlocks
+	assert.False(t, lock().Locked)
+
+	// Lock is POST-only
+	req := httptest.NewRequest(http.MethodGet, "/api/doc/active/lock", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestHandleReloadConfig(t *testing.T) {
+	server := newTestServer(t)
+	assert.Equal(t, server.getStatusColor("blocked"), "neutral muted")
+	assert.Equal(t, server.getStatusColor("draft"), "neutral muted")
+
+	err := server.rootManager.WriteString("metadata.json", `{"status_colors": {"blocked": "danger vivid", "draft": "primary muted"}}`)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reload-config", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response ReloadConfigResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.True(t, response.Reloaded)
+
+	assert.Equal(t, server.getStatusColor("blocked"), "danger vivid")
+	assert.Equal(t, server.getStatusColor("draft"), "primary muted")
+	assert.Equal(t, server.getStatusColor("review"), "secondary muted")
+}
+
+func TestHandleReloadConfig_Navigation(t *testing.T) {
+	server := newTestServer(t)
+	assert.Equal(t, len(server.navigation()), 5)
+
+	err := server.rootManager.WriteString(navFile, `{"items": [{"id": "inbox"}, {"id": "daily", "title": "Log"}]}`)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reload-config", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var titles []string
+	for _, item := range server.navigationMenu("") {
+		titles = append(titles, item.Title)
+	}
+	assert.Equal(t, titles, []string{"Inbox", "Log"})
+}
+
+// TestHandleReloadConfig_ConcurrentRendering renders pages while the configuration is rewritten and reloaded.
+// It's meant for the race detector (make test runs go test -race).
+func TestHandleReloadConfig_ConcurrentRendering(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/alpha.md", "---\nstatus: blocked\ntags: [work]\n---\n# Alpha\n"))
+	server.fileRepo.ReloadCaches()
+	handler := server.setupRoutes()
+
+	configs := []struct{ metadata, nav string }{
+		{`{"status_colors": {"blocked": "danger vivid"}}`, `{"items": [{"id": "inbox"}, {"id": "resources/alpha"}]}`},
+		{`{"status_colors": {"blocked": "primary muted"}, "tag_color": "warning muted"}`, `{"items": [{"id": "daily", "title": "Log"}]}`},
+	}
+	pages := []string{"/inbox", "/active", "/resources", "/resources/alpha"}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		for _, page := range pages {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, page, nil))
+				assert.Equal(t, rec.Code, http.StatusOK)
+			}()
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			config := configs[i%len(configs)]
+			assert.Nil(t, server.rootManager.WriteString("metadata.json", config.metadata))
+			assert.Nil(t, server.rootManager.WriteString(navFile, config.nav))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/reload-config", nil))
+			assert.Equal(t, rec.Code, http.StatusOK)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHandleExcerptAPI(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/project-ideas.md", "---\ntitle: Ideas\n---\n# Project Ideas\n\nA list of **ideas**\nworth trying.\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/doc/resources/project-ideas/excerpt", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response ExcerptResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, response.ID, "resources/project-ideas")
+	assert.Equal(t, response.Excerpt, "A list of **ideas** worth trying.")
+}
+
+func TestHandleExcerptAPI_NotFound(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/doc/resources/missing/excerpt", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestHandleBreadcrumbsAPI(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.MkdirAll("resources/projects", 0755))
+	assert.Nil(t, server.rootManager.WriteString("resources/projects/plan.md", "# Plan\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/doc/resources/projects/plan/breadcrumbs", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+
+	var crumbs []files.Breadcrumb
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&crumbs))
+	assert.Equal(t, len(crumbs), 3)
+	assert.True(t, crumbs[len(crumbs)-1].IsLast)
+
+	// Errors are JSON too
+	req = httptest.NewRequest(http.MethodGet, "/api/doc/resources/missing/breadcrumbs", nil)
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+	var response APIErrorResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, response.Error, "Document not found")
+}
+
+func TestRespondWithJSON_EncodingError(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.respondWithJSON(rec, map[string]any{"unencodable": make(chan int)})
+
+	assert.Equal(t, rec.Code, http.StatusInternalServerError)
+	var response APIErrorResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, response.Error, "Failed to encode response")
+}
+
+func TestHandleMetadataValuesAPI(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/alpha.md", "---\nstatus: active\n---\n# Alpha\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/beta.md", "---\nstatus: blocked\n---\n# Beta\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/gamma.md", "---\nstatus: active\n---\n# Gamma\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/meta/status", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response MetadataValuesResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, response.Key, "status")
+	assert.Equal(t, response.Values["active"], 2)
+	assert.Equal(t, response.Values["blocked"], 1)
+}
+
+func TestHandleGraphAPI(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/alpha.md", "# Alpha\n\nSee [[inbox]] and [Example](https://example.com).\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/graph", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var graph files.Graph
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&graph))
+	assert.True(t, len(graph.Nodes) >= 3)
+	assert.Equal(t, graph.Edges, []files.GraphEdge{{Source: "resources/alpha", Target: "inbox"}})
+}
+
+func TestHandleTasksAPI(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/alpha.md", "## Errands\n\n- [ ] Buy milk\n- [x] Mail letter @done(2025-01-02)\n"))
+	server.fileRepo.ReloadCaches()
+
+	get := func(url string) (*httptest.ResponseRecorder, TasksResponse) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+
+		var response TasksResponse
+		if rec.Code == http.StatusOK {
+			assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+		}
+		return rec, response
+	}
+
+	rec, all := get("/api/tasks")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, all.Counts.Total, len(all.Tasks))
+	assert.Equal(t, all.Counts.Pending+all.Counts.Completed, all.Counts.Total)
+
+	_, pending := get("/api/tasks?status=pending")
+	assert.Equal(t, pending.Counts, all.Counts)
+	assert.Equal(t, len(pending.Tasks), all.Counts.Pending)
+
+	var buyMilk files.FileTask
+	for _, task := range pending.Tasks {
+		assert.False(t, task.Completed)
+		if task.FileID == "resources/alpha" {
+			buyMilk = task
+		}
+	}
+	assert.Equal(t, buyMilk.Label, "Buy milk")
+	assert.Equal(t, buyMilk.Section, "Errands")
+	assert.Equal(t, buyMilk.ID, 1)
+
+	rec, _ = get("/api/tasks?status=someday")
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/web"
+)
+
+// handleArchivePage shows the forms for exporting the data directory as a zip archive and importing one
+func (s *Server) handleArchivePage(w http.ResponseWriter, r *http.Request) {
+	keys := s.fileRepo.EncryptionManager()
+
+	data := web.PageData{
+		Title:        "Export and Import",
+		NavMenuFiles: s.navigationMenu(r.URL.Path),
+		Flashes:      s.flashManager.Get(w, r),
+		CanDecrypt:   keys.HasIdentities() && !keys.IsLocked(),
+	}
+
+	if err := s.executePage(w, "maintenance_archive.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleArchiveExport downloads every file in the data directory as a zip archive. With the "decrypt" query
+// parameter set, encrypted files are decrypted with the loaded identities first.
+func (s *Server) handleArchiveExport(w http.ResponseWriter, r *http.Request) {
+	var keys *crypto.EncryptionManager
+	if r.FormValue("decrypt") != "" {
+		keys = s.fileRepo.EncryptionManager()
+		if !keys.HasIdentities() || keys.IsLocked() {
+			s.flashManager.SetError(w, r, "Encrypted files can't be decrypted until identities are loaded and unlocked")
+			s.redirectTo(w, r, "/maintenance/archive")
+			return
+		}
+	}
+
+	fileName := "padd-" + time.Now().Format("20060102-150405") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+
+	// The archive is streamed as it's written, so an error part way through can only be logged
+	summary, err := writeArchive(s.rootManager, w, keys)
+	if err != nil {
+		s.logger.Error("Error exporting the data directory", "error", err)
+		return
+	}
+	for _, failed := range summary.Failed {
+		s.logger.Warn("Failed to decrypt file for export, archived encrypted", "file", failed)
+	}
+}
+
+// handleArchiveImport extracts an uploaded zip archive in the "archive" form field into the data directory.
+// The "conflicts" form value says what happens to files that already exist: they're skipped (the default),
+// overwritten, or kept with the imported file saved under a numbered name.
+func (s *Server) handleArchiveImport(w http.ResponseWriter, r *http.Request) {
+	importFailed := func(message string) {
+		s.flashManager.SetError(w, r, message)
+		s.redirectTo(w, r, "/maintenance/archive")
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		importFailed(fmt.Sprintf("Failed to read the upload: %v", err))
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("archive")
+	if err != nil {
+		importFailed("Choose a .zip archive to import")
+		return
+	}
+	defer func(file multipart.File) {
+		_ = file.Close()
+	}(file)
+
+	conflicts := r.FormValue("conflicts")
+	if conflicts == "" {
+		conflicts = conflictSkip
+	}
+	if !slices.Contains(conflictModes, conflicts) {
+		importFailed(fmt.Sprintf("Unknown conflict handling %q", conflicts))
+		return
+	}
+
+	archive, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		importFailed(fmt.Sprintf("%s is not a zip archive", fileHeader.Filename))
+		return
+	}
+
+	summary, err := importArchive(s.rootManager, archive, conflicts)
+	if len(summary.Imported) > 0 {
+		s.fileRepo.RecordChanges("Import "+fileHeader.Filename, summary.Imported...)
+		s.fileRepo.ReloadCaches()
+	}
+	if err != nil {
+		importFailed(fmt.Sprintf("Failed to import %s: %v", fileHeader.Filename, err))
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, fmt.Sprintf("Imported %d files from %s, %d existing files skipped",
+		len(summary.Imported), fileHeader.Filename, len(summary.Skipped)))
+	s.redirectTo(w, r, "/maintenance/archive")
+}
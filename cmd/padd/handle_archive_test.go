@@ -0,0 +1,107 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleArchive(t *testing.T) {
+	t.Parallel()
+
+	source := newTestServer(t)
+	assert.Nil(t, source.rootManager.MkdirAll("resources/trip", 0755))
+	assert.Nil(t, source.rootManager.WriteString("resources/trip/packing-list.md", "# Packing\n"))
+	assert.Nil(t, source.rootManager.WriteString("inbox.md", "# Source inbox\n"))
+
+	rec := httptest.NewRecorder()
+	source.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/maintenance/archive/export", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/zip")
+	assert.MatchesRegexp(t, rec.Header().Get("Content-Disposition"), `^attachment; filename=padd-\d{8}-\d{6}\.zip$`)
+	archive := rec.Body.Bytes()
+
+	// Decrypting needs identities to decrypt with
+	rec = httptest.NewRecorder()
+	source.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/maintenance/archive/export?decrypt=1", nil))
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	upload := func(server *Server, conflicts string) *httptest.ResponseRecorder {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("archive", "backup.zip")
+		assert.Nil(t, err)
+		_, err = part.Write(archive)
+		assert.Nil(t, err)
+		assert.Nil(t, form.WriteField("conflicts", conflicts))
+		assert.Nil(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/maintenance/archive/import", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Importing into another server adds the new files, and keeps its own by default
+	target := newTestServer(t)
+	rec = upload(target, "")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.True(t, target.fileRepo.FileIDExists("resources/trip/packing-list"))
+	content, err := target.rootManager.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.True(t, string(content) != "# Source inbox\n")
+
+	rec = upload(target, "overwrite")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	content, err = target.rootManager.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "# Source inbox\n")
+
+	// Uploads that aren't archives are rejected without changing anything
+	archive = []byte("not a zip")
+	rec = upload(target, "overwrite")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/maintenance/archive")
+
+	rec = httptest.NewRecorder()
+	target.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/maintenance/archive", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+func TestHandleArchiveImport_LargerThanMaxBody(t *testing.T) {
+	server := newTestServer(t)
+	server.maxBodyBytes = 64
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	entry, err := zw.Create("resources/big.md")
+	assert.Nil(t, err)
+	_, err = entry.Write([]byte("# Big\n\n" + strings.Repeat("Lorem ipsum dolor sit amet. ", 20)))
+	assert.Nil(t, err)
+	assert.Nil(t, zw.Close())
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("archive", "backup.zip")
+	assert.Nil(t, err)
+	_, err = part.Write(archive.Bytes())
+	assert.Nil(t, err)
+	assert.Nil(t, form.Close())
+
+	// Archive uploads have their own limit, above the server's maximum body size
+	req := httptest.NewRequest(http.MethodPost, "/maintenance/archive/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.True(t, server.rootManager.FileExists("resources/big.md"))
+}
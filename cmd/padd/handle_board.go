@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/web"
+)
+
+// handleBoard shows a document's tasks as a board, with a column per section or per @status value. The
+// "by" query parameter picks "section" or "status"; without it, documents that use @status are grouped by
+// status.
+func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.boardDocument(w, r)
+	if !ok {
+		return
+	}
+
+	grouping, err := boardGrouping(doc, r.FormValue("by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.showBoard(w, r, doc, grouping)
+}
+
+// handleBoardMove moves the task in the "task" form value to the board column in the "column" form value,
+// rewriting the markdown. htmx requests get the updated board back; other requests are redirected to it.
+func (s *Server) handleBoardMove(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.boardDocument(w, r)
+	if !ok {
+		return
+	}
+
+	grouping, err := boardGrouping(doc, r.FormValue("by"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	taskID, err := strconv.Atoi(r.FormValue("task"))
+	if err != nil || taskID <= 0 {
+		http.Error(w, "Invalid task parameter", http.StatusBadRequest)
+		return
+	}
+
+	if err := doc.MoveTaskToColumn(taskID, grouping, r.FormValue("column")); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, files.ErrDocumentLocked) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if !isHXRequest(r) {
+		s.redirectTo(w, r, "/board/"+doc.Info.ID+"?"+url.Values{"by": {boardGroupingName(grouping)}}.Encode())
+		return
+	}
+
+	s.showBoard(w, r, doc, grouping)
+}
+
+// showBoard renders the board page for a document
+func (s *Server) showBoard(w http.ResponseWriter, r *http.Request, doc *files.Document, grouping files.BoardGrouping) {
+	columns, err := doc.TaskBoard(grouping)
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	data := web.PageData{
+		Title:        "Board for " + doc.Info.TitleBase,
+		CurrentFile:  doc.Info,
+		NavMenuFiles: s.navigationMenu(doc.Info.ID),
+		Board: &web.BoardData{
+			Grouping: boardGroupingName(grouping),
+			Columns:  columns,
+		},
+	}
+
+	data.Flashes = s.flashManager.Get(w, r)
+	if err := s.executePage(w, "board.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// boardDocument returns the markdown document named by the request's path, or shows a not found page
+func (s *Server) boardDocument(w http.ResponseWriter, r *http.Request) (*files.Document, bool) {
+	doc, err := s.fileRepo.GetDocument(r.PathValue("id"))
+	if err != nil || doc.Info.IsDirectory || doc.Info.IsCSV() {
+		s.showPageNotFound(w, r)
+		return nil, false
+	}
+
+	return doc, true
+}
+
+// boardGrouping reads the "by" parameter of the board endpoints. An empty value groups by status when the
+// document uses @status annotations, and by section otherwise.
+func boardGrouping(doc *files.Document, by string) (files.BoardGrouping, error) {
+	switch by {
+	case "section":
+		return files.BoardBySection, nil
+	case "status":
+		return files.BoardByStatus, nil
+	case "":
+		hasStatuses, err := doc.HasTaskStatuses()
+		if err != nil {
+			return files.BoardBySection, err
+		}
+		if hasStatuses {
+			return files.BoardByStatus, nil
+		}
+		return files.BoardBySection, nil
+	default:
+		return files.BoardBySection, errors.New("invalid by parameter (expected section or status)")
+	}
+}
+
+// boardGroupingName returns the "by" parameter value for a grouping
+func boardGroupingName(grouping files.BoardGrouping) string {
+	if grouping == files.BoardByStatus {
+		return "status"
+	}
+	return "section"
+}
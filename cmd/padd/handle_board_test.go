@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleBoard(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n## Today\n\n- [ ] Write report\n\n## Later\n\n- [ ] Read book\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/board/active", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `data-board-column="## Today"`))
+	assert.True(t, strings.Contains(body, `data-board-task="1"`))
+
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/board/active?by=priority", nil))
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/board/missing", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestHandleBoardMove(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n- [ ] Write report @status(todo)\n- [ ] Read book @status(doing)\n"))
+	server.fileRepo.ReloadCaches()
+
+	form := url.Values{"task": {"1"}, "column": {"doing"}}
+	req := httptest.NewRequest(http.MethodPost, "/board/active", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	// Documents using @status are grouped by status, and htmx gets the updated board back
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `id="board"`))
+
+	doc, err := server.fileRepo.GetDocument("active")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Active\n\n- [ ] Write report @status(doing)\n- [ ] Read book @status(doing)\n")
+
+	// Without htmx, the move redirects back to the board
+	form = url.Values{"task": {"2"}, "column": {"done"}, "by": {"status"}}
+	req = httptest.NewRequest(http.MethodPost, "/board/active", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/board/active?by=status")
+
+	form = url.Values{"task": {"9"}, "column": {"done"}}
+	req = httptest.NewRequest(http.MethodPost, "/board/active", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+}
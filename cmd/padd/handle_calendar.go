@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
+	"github.com/patrickward/padd/internal/web"
+)
+
+// handleCalendar shows a heatmap of entries per day for a temporal directory over a year. The directory and
+// year come from the "type" (default the daily directory) and "year" (default the current year) query parameters.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	fileType := r.URL.Query().Get("type")
+	if fileType == "" {
+		fileType = s.fileRepo.Config().DailyDirectory
+	}
+
+	year := time.Now().Year()
+	if yearParam := r.URL.Query().Get("year"); yearParam != "" {
+		parsed, err := strconv.Atoi(yearParam)
+		if err != nil || parsed < 1 || parsed > 9999 {
+			s.showPageNotFound(w, r)
+			return
+		}
+		year = parsed
+	}
+
+	activity, err := s.fileRepo.TemporalActivity(fileType, year)
+	if err != nil {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	data := web.PageData{
+		Title:        fmt.Sprintf("%s Calendar %d", contentutil.TitleCase(fileType), year),
+		NavMenuFiles: s.navigationMenu(fileType),
+		Calendar:     web.NewCalendarData(fileType, year, activity),
+	}
+
+	if err := s.executePage(w, "calendar.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleMonthCalendar shows a month of a temporal directory as a grid, with each day that has entries linking
+// to its day header in the file that holds it
+func (s *Server) handleMonthCalendar(w http.ResponseWriter, r *http.Request) {
+	fileType := r.PathValue("type")
+
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil || year < 1 || year > 9999 {
+		s.showPageNotFound(w, r)
+		return
+	}
+	month, err := strconv.Atoi(r.PathValue("month"))
+	if err != nil || month < 1 || month > 12 {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	headers, activity, err := s.fileRepo.TemporalMonth(fileType, year, time.Month(month))
+	if err != nil {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	links := make(map[string]string, len(headers))
+	for _, header := range headers {
+		date := header.Date.Format("2006-01-02")
+		if _, ok := links[date]; !ok {
+			links[date] = "/" + header.FileID + "#" + header.Anchor
+		}
+	}
+
+	data := web.PageData{
+		Title:         fmt.Sprintf("%s Calendar %s %d", contentutil.TitleCase(fileType), time.Month(month), year),
+		NavMenuFiles:  s.navigationMenu(fileType),
+		MonthCalendar: web.NewMonthCalendarData(fileType, year, time.Month(month), activity, links),
+	}
+
+	if err := s.executePage(w, "calendar_month.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
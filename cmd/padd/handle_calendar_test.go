@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleCalendar(t *testing.T) {
+	server := newTestServer(t)
+
+	err := server.rootManager.MkdirAll("daily/2024", 0755)
+	assert.Nil(t, err)
+	err = server.rootManager.WriteString("daily/2024/02-february.md", "## Thursday, February 29, 2024\n\n### 08:00:00 AM\n\nOne\n\n### 09:00:00 AM\n\nTwo\n")
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/calendar?type=daily&year=2024", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "Daily Calendar 2024"))
+	assert.True(t, strings.Contains(body, `title="2024-02-29: 2 entries"`))
+	assert.True(t, strings.Contains(body, `class="calendar-day level-2"`))
+}
+
+func TestHandleCalendar_InvalidParams(t *testing.T) {
+	server := newTestServer(t)
+
+	for _, target := range []string{"/calendar?type=resources", "/calendar?year=abc"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleMonthCalendar(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.MkdirAll("daily/2024", 0755))
+	assert.Nil(t, server.rootManager.WriteString("daily/2024/02-february.md", "# February 2024\n\n## Thursday, February 29, 2024\n\n### 08:00:00 AM\n\nOne\n\n## Monday, February 5, 2024\n\nTwo\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendar/daily/2024/02", nil))
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "Daily Calendar February 2024"))
+	assert.True(t, strings.Contains(body, `href="/daily/2024/02-february#thursday-february-29-2024"`))
+	assert.True(t, strings.Contains(body, `href="/calendar/daily/2024/01"`))
+	assert.True(t, strings.Contains(body, `href="/calendar/daily/2024/03"`))
+
+	// The link's anchor is the ID the day header gets on the file's page
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/daily/2024/02-february", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `id="thursday-february-29-2024"`))
+
+	for _, target := range []string{"/calendar/resources/2024/02", "/calendar/daily/2024/13", "/calendar/daily/year/02"} {
+		rec = httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// defaultClipTarget is the file ID that web clips are added to
+const defaultClipTarget = "inbox"
+
+// ClipResponse is the JSON payload returned by the clip endpoint
+type ClipResponse struct {
+	Success bool   `json:"success"`
+	FileID  string `json:"fileId,omitempty"`
+	Entry   string `json:"entry,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// handleClip adds a web clip to the clip target file (the inbox by default).
+//
+// The "url" form field is required and must be an http or https URL. The "title" field defaults to the URL,
+// and the optional "note" field is appended after the link: "- [title](url) — note".
+func (s *Server) handleClip(w http.ResponseWriter, r *http.Request) {
+	s.setClipCORSHeaders(w, r)
+	w.Header().Set("Content-Type", "application/json")
+
+	clipURL, err := validateClipURL(r.FormValue("url"))
+	if err != nil {
+		s.respondWithJSONError(w, ClipResponse{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	entry := formatClipEntry(clipURL, r.FormValue("title"), r.FormValue("note"))
+
+	doc, err := s.fileRepo.GetDocument(s.clipTarget)
+	if err != nil {
+		s.respondWithJSONError(w, ClipResponse{Error: "Clip target not found"}, http.StatusNotFound)
+		return
+	}
+
+	config := files.EntryInsertionConfig{
+		Strategy:       files.InsertInSection,
+		EntryFormatter: files.NoteEntryFormatter,
+		SectionConfig: &files.SectionInsertionConfig{
+			InsertAtTop: true,
+		},
+	}
+
+	if err := doc.AddEntry(entry, config); err != nil {
+		s.respondWithJSONError(w, ClipResponse{Error: fmt.Sprintf("Failed to add clip: %v", err)}, http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(ClipResponse{
+		Success: true,
+		FileID:  doc.Info.ID,
+		Entry:   entry,
+	})
+}
+
+// handleClipPreflight answers CORS preflight requests for the clip endpoint
+func (s *Server) handleClipPreflight(w http.ResponseWriter, r *http.Request) {
+	s.setClipCORSHeaders(w, r)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setClipCORSHeaders allows cross-origin clip requests from the configured origin only
+func (s *Server) setClipCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if s.clipAllowedOrigin == "" || origin == "" {
+		return
+	}
+
+	w.Header().Add("Vary", "Origin")
+	if s.clipAllowedOrigin == "*" || origin == s.clipAllowedOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+writeTokenHeader)
+	}
+}
+
+// validateClipURL ensures a clipped URL is an absolute http or https URL
+func validateClipURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("URL is required")
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("URL must be an absolute http or https URL")
+	}
+
+	return parsed, nil
+}
+
+// formatClipEntry formats a clip as a markdown list item. Line breaks are collapsed and brackets in
+// the title are escaped, so the entry always stays a single, well-formed link.
+func formatClipEntry(clipURL *url.URL, title, note string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		title = clipURL.String()
+	}
+	title = strings.NewReplacer("[", `\[`, "]", `\]`).Replace(title)
+
+	// Escape parentheses so the URL can't end the link early
+	link := strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(clipURL.String())
+
+	entry := fmt.Sprintf("- [%s](%s)", title, link)
+	if note = strings.Join(strings.Fields(note), " "); note != "" {
+		entry += " — " + note
+	}
+
+	return entry
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func postClip(t *testing.T, server *Server, form url.Values, origin string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/clip", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestHandleClip(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := postClip(t, server, url.Values{
+		"url":   {"https://example.com/article"},
+		"title": {"An [Interesting]\nArticle"},
+		"note":  {"read later"},
+	}, "")
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response ClipResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.True(t, response.Success)
+	assert.Equal(t, response.FileID, "inbox")
+
+	wantEntry := `- [An \[Interesting\] Article](https://example.com/article) — read later`
+	assert.Equal(t, response.Entry, wantEntry)
+
+	content, err := server.rootManager.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(content), wantEntry))
+}
+
+func TestHandleClip_ConfiguredTarget(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, WithClipTarget("active")(server))
+
+	rec := postClip(t, server, url.Values{"url": {"https://example.com"}}, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	content, err := server.rootManager.ReadFile("active.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(content), "- [https://example.com](https://example.com)"))
+}
+
+func TestHandleClip_InvalidURL(t *testing.T) {
+	server := newTestServer(t)
+
+	for _, rawURL := range []string{"", "javascript:alert(1)", "/relative/path", "ftp://example.com"} {
+		rec := postClip(t, server, url.Values{"url": {rawURL}}, "")
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleClip_CORS(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, WithClipAllowedOrigin("https://allowed.example")(server))
+
+	rec := postClip(t, server, url.Values{"url": {"https://example.com"}}, "https://allowed.example")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "https://allowed.example")
+
+	rec = postClip(t, server, url.Values{"url": {"https://example.com"}}, "https://other.example")
+	assert.Equal(t, rec.Header().Get("Access-Control-Allow-Origin"), "")
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/clip", nil)
+	req.Header.Set("Origin", "https://allowed.example")
+	preflight := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(preflight, req)
+	assert.Equal(t, preflight.Code, http.StatusNoContent)
+	assert.Equal(t, preflight.Header().Get("Access-Control-Allow-Methods"), "POST, OPTIONS")
+}
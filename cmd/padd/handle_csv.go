@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// handleCSVCellEdit renders a form to edit a cell of a CSV file.
+func (s *Server) handleCSVCellEdit(w http.ResponseWriter, r *http.Request) {
+	doc, row, col, done := s.csvCellFromRequest(w, r)
+	if done {
+		return
+	}
+
+	value, err := doc.GetCell(row, col)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.showCSVCell(w, r, doc, "csv_cell_edit", row, col, value, "")
+}
+
+// handleCSVCellShow renders a cell of a CSV file for display.
+func (s *Server) handleCSVCellShow(w http.ResponseWriter, r *http.Request) {
+	doc, row, col, done := s.csvCellFromRequest(w, r)
+	if done {
+		return
+	}
+
+	value, err := doc.GetCell(row, col)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.showCSVCell(w, r, doc, "csv_cell_show", row, col, value, "")
+}
+
+// handleCSVCellUpdate updates a cell of a CSV file with the "value" form value. Values that don't match the
+// column's type are sent back in the edit form with the validation message and a 422 status.
+func (s *Server) handleCSVCellUpdate(w http.ResponseWriter, r *http.Request) {
+	doc, row, col, done := s.csvCellFromRequest(w, r)
+	if done {
+		return
+	}
+
+	value := r.FormValue("value")
+	if err := doc.UpdateCell(row, col, value); err != nil {
+		var cellErr *files.CellError
+		if errors.As(err, &cellErr) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			s.showCSVCell(w, r, doc, "csv_cell_edit", row, col, value, "Enter a "+cellErr.Type.Description()+".")
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.showCSVCell(w, r, doc, "csv_cell_show", row, col, value, "")
+}
+
+// showCSVCell renders a CSV cell snippet
+func (s *Server) showCSVCell(w http.ResponseWriter, r *http.Request, doc *files.CSVDocument, snippet string, row, col int, value, message string) {
+	metadata, err := doc.GetMetadata()
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	if err := s.executeSnippet(w, snippet, map[string]any{
+		"Row":      row,
+		"Col":      col,
+		"Value":    value,
+		"Metadata": metadata,
+		"Error":    message,
+	}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// csvCellFromRequest returns the CSV document and the cell position from the request. Like the table cell
+// endpoints, the file ID comes from the X-PADD-File-ID header.
+func (s *Server) csvCellFromRequest(w http.ResponseWriter, r *http.Request) (*files.CSVDocument, int, int, bool) {
+	fileID := r.Header.Get("X-PADD-File-ID")
+	if fileID == "" {
+		http.Error(w, "Missing file ID", http.StatusBadRequest)
+		return nil, 0, 0, true
+	}
+
+	doc, err := s.fileRepo.GetCSVDocument(fileID)
+	if err != nil {
+		http.Error(w, "Invalid file", http.StatusBadRequest)
+		return nil, 0, 0, true
+	}
+
+	row, ok := pathIndex(w, r, "row")
+	if !ok {
+		return nil, 0, 0, true
+	}
+
+	col, ok := pathIndex(w, r, "col")
+	if !ok {
+		return nil, 0, 0, true
+	}
+
+	return doc, row, col, false
+}
+
+// handleCSVAddColumn adds a column with the "header" form value at the "col" index, or at the end when "col"
+// is empty.
+func (s *Server) handleCSVAddColumn(w http.ResponseWriter, r *http.Request) {
+	s.updateCSVColumns(w, r, "Column added", func(doc *files.CSVDocument) error {
+		index, err := doc.ColumnCount()
+		if err != nil {
+			return err
+		}
+
+		if r.FormValue("col") != "" {
+			if index, err = strconv.Atoi(r.FormValue("col")); err != nil {
+				return fmt.Errorf("invalid column index %q", r.FormValue("col"))
+			}
+		}
+
+		return doc.AddColumn(index, r.FormValue("header"))
+	})
+}
+
+// handleCSVDeleteColumn deletes the column at the "col" index.
+func (s *Server) handleCSVDeleteColumn(w http.ResponseWriter, r *http.Request) {
+	s.updateCSVColumns(w, r, "Column deleted", func(doc *files.CSVDocument) error {
+		index, err := csvColumnIndex(r, "col")
+		if err != nil {
+			return err
+		}
+
+		return doc.DeleteColumn(index)
+	})
+}
+
+// handleCSVRenameColumn renames the column at the "col" index to the "header" form value.
+func (s *Server) handleCSVRenameColumn(w http.ResponseWriter, r *http.Request) {
+	s.updateCSVColumns(w, r, "Column renamed", func(doc *files.CSVDocument) error {
+		index, err := csvColumnIndex(r, "col")
+		if err != nil {
+			return err
+		}
+
+		return doc.RenameHeader(index, r.FormValue("header"))
+	})
+}
+
+// handleCSVMoveColumn moves the column at the "col" index to the "to" index.
+func (s *Server) handleCSVMoveColumn(w http.ResponseWriter, r *http.Request) {
+	s.updateCSVColumns(w, r, "Column moved", func(doc *files.CSVDocument) error {
+		from, err := csvColumnIndex(r, "col")
+		if err != nil {
+			return err
+		}
+
+		to, err := csvColumnIndex(r, "to")
+		if err != nil {
+			return err
+		}
+
+		return doc.MoveColumn(from, to)
+	})
+}
+
+// updateCSVColumns applies a column change to the CSV file named by the request's path, then returns to the
+// file's page with a flash message saying how it went.
+func (s *Server) updateCSVColumns(w http.ResponseWriter, r *http.Request, success string, update func(doc *files.CSVDocument) error) {
+	doc, err := s.fileRepo.GetDocument(r.PathValue("id"))
+	if err != nil || !doc.Info.IsCSV() {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	if err := update(files.NewCSVDocument(doc)); err != nil {
+		s.flashManager.SetError(w, r, fmt.Sprintf("Failed to update columns: %v", err))
+	} else {
+		s.flashManager.SetSuccess(w, r, success)
+	}
+
+	s.redirectTo(w, r, "/"+doc.Info.ID)
+}
+
+// csvColumnIndex reads a column index from a form value
+func csvColumnIndex(r *http.Request, name string) (int, error) {
+	index, err := strconv.Atoi(r.FormValue(name))
+	if err != nil {
+		return 0, fmt.Errorf("invalid column index %q", r.FormValue(name))
+	}
+
+	return index, nil
+}
+
+// handleCSVImport saves an uploaded .csv, .tsv, or .xlsx file in the "file" form field as a new CSV file in
+// the resources directory. The "filename" form value names the new file; without it, the name comes from the
+// uploaded file. Existing files are never overwritten.
+func (s *Server) handleCSVImport(w http.ResponseWriter, r *http.Request) {
+	const maxFileSize = 10 << 20 // 10 MB
+	r.Body = http.MaxBytesReader(w, r.Body, maxFileSize)
+
+	importFailed := func(message string) {
+		s.flashManager.SetError(w, r, message)
+		s.redirectTo(w, r, "/resources")
+	}
+
+	if err := r.ParseMultipartForm(maxFileSize); err != nil {
+		importFailed(fmt.Sprintf("Failed to read the upload: %v", err))
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		importFailed("Choose a .csv, .tsv, or .xlsx file to import")
+		return
+	}
+	defer func(file multipart.File) {
+		_ = file.Close()
+	}(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		importFailed(fmt.Sprintf("Failed to read the upload: %v", err))
+		return
+	}
+
+	records, err := files.ParseSpreadsheet(fileHeader.Filename, data)
+	if err != nil {
+		importFailed(fmt.Sprintf("Failed to import %s: %v", fileHeader.Filename, err))
+		return
+	}
+
+	fileName := strings.TrimSpace(r.FormValue("filename"))
+	if fileName == "" {
+		base := filepath.Base(fileHeader.Filename)
+		fileName = s.fileRepo.CreateID(strings.TrimSuffix(base, filepath.Ext(base)))
+	}
+
+	if !filenameIsValid(fileName) || strings.Contains(fileName, "..") {
+		importFailed("Filename must contain only letters, numbers, dashes, periods, underscores, and forward slashes")
+		return
+	}
+
+	// Imports are always saved as CSV, whatever format they were uploaded in
+	for _, ext := range files.ImportFormats {
+		fileName = strings.TrimSuffix(fileName, ext)
+	}
+	fileName += ".csv"
+	fullPath := filepath.Join(s.fileRepo.Config().ResourcesDirectory, fileName)
+
+	if s.rootManager.FileExists(fullPath) {
+		importFailed(fmt.Sprintf("%s already exists", fullPath))
+		return
+	}
+
+	content, err := files.EncodeCSV(records, ',')
+	if err != nil {
+		importFailed(fmt.Sprintf("Failed to import %s: %v", fileHeader.Filename, err))
+		return
+	}
+
+	if strings.Contains(fileName, "/") {
+		if err := s.rootManager.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			importFailed("Failed to create directories")
+			return
+		}
+	}
+
+	if err := s.fileRepo.CreateFile(fullPath, content); err != nil {
+		importFailed("Failed to create file")
+		return
+	}
+
+	s.fileRepo.ReloadResources()
+
+	s.flashManager.SetSuccess(w, r, fmt.Sprintf("Imported %d rows from %s", len(records)-1, fileHeader.Filename))
+	s.redirectTo(w, r, "/"+s.fileRepo.CreateID(fullPath))
+}
+
+// handleCSVExport downloads a CSV file in the format named by the "format" query parameter: csv (the
+// default), tsv, xlsx, or json.
+func (s *Server) handleCSVExport(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.fileRepo.GetCSVDocument(r.PathValue("id"))
+	if err != nil {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	format := r.FormValue("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	var buf bytes.Buffer
+	var contentType string
+	switch format {
+	case "csv", "tsv":
+		delimiter := ','
+		contentType = "text/csv; charset=utf-8"
+		if format == "tsv" {
+			delimiter = '\t'
+			contentType = "text/tab-separated-values; charset=utf-8"
+		}
+
+		var records [][]string
+		if records, err = doc.GetRecords(); err == nil {
+			var content string
+			content, err = files.EncodeCSV(records, delimiter)
+			buf.WriteString(content)
+		}
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = doc.WriteXLSX(&buf)
+	case "json":
+		contentType = "application/json"
+		err = doc.WriteJSON(&buf)
+	default:
+		http.Error(w, "Invalid format parameter (expected csv, tsv, xlsx, or json)", http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	base := filepath.Base(doc.Info.Path)
+	fileName := strings.TrimSuffix(base, filepath.Ext(base)) + "." + format
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	_, _ = w.Write(buf.Bytes())
+}
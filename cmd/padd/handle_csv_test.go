@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleCSVColumns(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/people.csv", "name,age\nZoe,30\n"))
+	server.fileRepo.ReloadCaches()
+
+	post := func(path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/csv/columns/add/resources/people.csv", url.Values{"header": {"city"}})
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/people.csv")
+
+	rec = post("/csv/columns/rename/resources/people.csv", url.Values{"col": {"0"}, "header": {"Name"}})
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	rec = post("/csv/columns/move/resources/people.csv", url.Values{"col": {"2"}, "to": {"0"}})
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	rec = post("/csv/columns/delete/resources/people.csv", url.Values{"col": {"2"}})
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	doc, err := server.fileRepo.GetDocument("resources/people.csv")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "city,Name\n,Zoe\n")
+
+	// The CSV view shows the column menus
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resources/people.csv", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `action="/csv/columns/rename/resources/people.csv"`))
+
+	// Failures are reported with a flash message on the way back to the file
+	rec = post("/csv/columns/delete/resources/people.csv", url.Values{"col": {"7"}})
+	assert.Equal(t, rec.Code, http.StatusFound)
+	content, err = doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "city,Name\n,Zoe\n")
+
+	rec = post("/csv/columns/add/inbox", url.Values{"header": {"city"}})
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestHandleCSVImportExport(t *testing.T) {
+	server := newTestServer(t)
+
+	upload := func(name, content, filename string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile("file", name)
+		assert.Nil(t, err)
+		_, err = part.Write([]byte(content))
+		assert.Nil(t, err)
+		assert.Nil(t, form.WriteField("filename", filename))
+		assert.Nil(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/csv/import", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// TSV uploads are saved as CSV, named after the upload
+	rec := upload("Team List.tsv", "name\tcity\nZoe\tOslo, NO\n", "")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/team-list.csv")
+
+	content, err := server.rootManager.ReadFile("resources/team-list.csv")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "name,city\nZoe,\"Oslo, NO\"\n")
+
+	// Existing files aren't overwritten
+	rec = upload("other.csv", "a,b\n", "team-list")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources")
+	content, err = server.rootManager.ReadFile("resources/team-list.csv")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "name,city\nZoe,\"Oslo, NO\"\n")
+
+	rec = upload("notes.txt", "a,b\n", "")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources")
+	assert.False(t, server.rootManager.FileExists("resources/notes.csv"))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Exports round-trip through the importer
+	rec = get("/csv/export/resources/team-list.csv?format=xlsx")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Disposition"), "attachment; filename=team-list.xlsx")
+
+	rec = upload("team-list.xlsx", rec.Body.String(), "copies/team")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/copies/team.csv")
+	content, err = server.rootManager.ReadFile("resources/copies/team.csv")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "name,city\nZoe,\"Oslo, NO\"\n")
+
+	rec = get("/csv/export/resources/team-list.csv?format=json")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+	var rows []map[string]string
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &rows))
+	assert.Equal(t, rows, []map[string]string{{"name": "Zoe", "city": "Oslo, NO"}})
+
+	rec = get("/csv/export/resources/team-list.csv?format=tsv")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Body.String(), "name\tcity\nZoe\tOslo, NO\n")
+
+	rec = get("/csv/export/resources/team-list.csv?format=pdf")
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+	rec = get("/csv/export/inbox?format=json")
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestHandleCSVCell(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/orders.csv", "item,qty,due\nPens,3,2025-01-31\nInk,1,2025-01-02\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/orders.csv.meta.json", `{"sort_column":"due","column_types":{"1":"number","2":"date"}}`))
+	server.fileRepo.ReloadCaches()
+
+	send := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-PADD-File-ID", "resources/orders.csv")
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Sorted views still edit cells by their row in the file, and show typed values formatted
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resources/orders.csv", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Index(body, `id="csv-cell-2-0"`) < strings.Index(body, `id="csv-cell-1-0"`))
+	assert.True(t, strings.Contains(body, `class="csv-cell csv-type-number"`))
+	assert.True(t, strings.Contains(body, ">Jan 31, 2025</span>"))
+
+	rec = send(http.MethodGet, "/csv/cells/edit/1/1", nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `hx-patch="/csv/cells/1/1"`))
+	assert.True(t, strings.Contains(rec.Body.String(), `value="3"`))
+
+	// Values that don't match the column type come back in the form with a message
+	rec = send(http.MethodPatch, "/csv/cells/1/1", url.Values{"value": {"three"}})
+	assert.Equal(t, rec.Code, http.StatusUnprocessableEntity)
+	assert.True(t, strings.Contains(rec.Body.String(), `value="three"`))
+	assert.True(t, strings.Contains(rec.Body.String(), "Enter a number."))
+
+	rec = send(http.MethodPatch, "/csv/cells/1/1", url.Values{"value": {"4"}})
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `id="csv-cell-1-1"`))
+
+	content, err := server.rootManager.ReadFile("resources/orders.csv")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "item,qty,due\nPens,4,2025-01-31\nInk,1,2025-01-02\n")
+
+	rec = send(http.MethodGet, "/csv/cells/edit/5/0", nil)
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+}
+
+func TestHandleCSVViewQuery(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/orders.csv", "item,qty,status\nPens,10,open\nInk,9,done\nPaper,12,open\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/orders.csv.meta.json", `{"column_types":{"1":"number"}}`))
+	server.fileRepo.ReloadCaches()
+
+	get := func(query url.Values) string {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resources/orders.csv?"+query.Encode(), nil))
+		assert.Equal(t, rec.Code, http.StatusOK)
+		return rec.Body.String()
+	}
+
+	body := get(url.Values{"sort": {"-qty"}, "filter": {"status=open", ""}})
+	assert.True(t, strings.Index(body, `id="csv-cell-3-0"`) < strings.Index(body, `id="csv-cell-1-0"`))
+	assert.False(t, strings.Contains(body, `id="csv-cell-2-0"`))
+	assert.True(t, strings.Contains(body, "Showing 2 of 3 rows"))
+	assert.True(t, strings.Contains(body, `value="status=open"`))
+
+	// Invalid parameters are reported, and every row is shown
+	body = get(url.Values{"filter": {"qty>lots"}})
+	assert.True(t, strings.Contains(body, `class="callout warning`))
+	assert.True(t, strings.Contains(body, `id="csv-cell-2-0"`))
+}
@@ -18,12 +18,25 @@ func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
 	content, err := doc.Content()
 	if err != nil {
 		s.showServerError(w, r, err)
+		return
+	}
+
+	// Saving the ciphertext shown while locked would encrypt it a second time
+	if s.redirectToUnlock(w, r, content) {
+		return
+	}
+
+	hash, err := doc.Checksum()
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
 	}
 
 	data := web.PageData{
 		Title:        "Edit - " + doc.Info.Title,
 		CurrentFile:  doc.Info,
 		RawContent:   content,
+		ContentHash:  hash,
 		IsEditing:    true,
 		NavMenuFiles: s.navigationMenu(id),
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"slices"
@@ -24,37 +25,31 @@ func (s *Server) handleAddTemporalEntry(directory string) func(w http.ResponseWr
 
 		// Make sure directory is one of the temporal directories
 		if !slices.Contains(s.fileRepo.Config().TemporalDirectories(), directory) {
-			s.flashManager.SetError(w, "Invalid directory")
+			s.flashManager.SetError(w, r, "Invalid directory")
 			s.redirectTo(w, r, "/")
 			return
 		}
 
 		if entry == "" {
-			s.flashManager.SetError(w, "Entry cannot be empty")
-			s.redirectTo(w, r, "/"+directory)
-			return
-		}
-
-		doc, err := s.fileRepo.GetOrCreateTemporalDocument(directory, time.Now())
-		if err != nil {
-			s.flashManager.SetError(w, fmt.Sprintf("Failed to get daily document: %v", err))
+			s.flashManager.SetError(w, r, "Entry cannot be empty")
 			s.redirectTo(w, r, "/"+directory)
 			return
 		}
 
 		config := files.EntryInsertionConfig{
 			Strategy:       files.InsertByTimestamp,
+			EntryTimestamp: time.Now(),
 			EntryFormatter: files.TimestampEntryFormatter,
 		}
 
-		if err := doc.AddEntry(entry, config); err != nil {
-			s.flashManager.SetError(w, fmt.Sprintf("Failed to add entry: %v", err))
+		doc, err := s.fileRepo.AddTemporalEntry(directory, entry, config)
+		if err != nil {
+			s.flashManager.SetError(w, r, fmt.Sprintf("Failed to add entry: %v", err))
 			s.redirectTo(w, r, "/"+directory)
 			return
 		}
 
-		s.flashManager.SetSuccess(w, "Entry added successfully")
-		s.redirectTo(w, r, "/"+directory)
+		s.entryAdded(w, r, doc, "/"+directory)
 	}
 }
 
@@ -65,7 +60,7 @@ func (s *Server) handleAddTemporalEntry(directory string) func(w http.ResponseWr
 func (s *Server) handleAddEntry(w http.ResponseWriter, r *http.Request) {
 	fileID := r.PathValue("id")
 	if fileID == "" {
-		s.flashManager.SetError(w, "File ID is required")
+		s.flashManager.SetError(w, r, "File ID is required")
 		s.redirectTo(w, r, "/")
 		return
 	}
@@ -109,14 +104,14 @@ func (s *Server) addEntry(w http.ResponseWriter, r *http.Request, config EntryCo
 
 	entry := strings.TrimSpace(r.FormValue("entry"))
 	if entry == "" {
-		s.flashManager.SetError(w, "Entry cannot be empty")
+		s.flashManager.SetError(w, r, "Entry cannot be empty")
 		s.redirectTo(w, r, config.RedirectPath)
 		return
 	}
 
 	doc, err := s.fileRepo.GetDocument(config.FileID)
 	if err != nil {
-		s.flashManager.SetError(w, "Invalid file ID")
+		s.flashManager.SetError(w, r, "Invalid file ID")
 		s.redirectTo(w, r, "/")
 		return
 	}
@@ -133,11 +128,53 @@ func (s *Server) addEntry(w http.ResponseWriter, r *http.Request, config EntryCo
 	}
 
 	if err := doc.AddEntry(entry, insertionConfig); err != nil {
-		s.flashManager.SetError(w, fmt.Sprintf("Failed to add entry: %v", err))
+		s.flashManager.SetError(w, r, fmt.Sprintf("Failed to add entry: %v", err))
 		s.redirectTo(w, r, config.RedirectPath)
 		return
 	}
 
-	s.flashManager.SetSuccess(w, "Entry added successfully")
-	s.redirectTo(w, r, config.RedirectPath)
+	s.entryAdded(w, r, doc, config.RedirectPath)
+}
+
+// entryAdded responds to a successful add. Htmx requests get the success message as a fragment along with
+// the padd:reload-header trigger, so the page header updates in place. Other requests are redirected.
+func (s *Server) entryAdded(w http.ResponseWriter, r *http.Request, doc *files.Document, redirectPath string) {
+	if !isHXRequest(r) {
+		s.flashManager.SetSuccess(w, r, "Entry added successfully")
+		s.redirectTo(w, r, redirectPath)
+		return
+	}
+
+	s.triggerHeaderReload(w, r, doc)
+	if err := s.executeSnippet(w, "entry_added", map[string]any{
+		"Message": "Entry added successfully",
+	}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// headerReloadDetail is the event detail sent with the padd:reload-header trigger after an entry is added
+type headerReloadDetail struct {
+	ID    string           `json:"id"`
+	Tasks files.TaskCounts `json:"tasks"`
+}
+
+// triggerHeaderReload sets the padd:reload-header HX-Trigger on htmx requests so the page header reloads
+// after an entry is added. The event detail carries the document's updated task counts.
+func (s *Server) triggerHeaderReload(w http.ResponseWriter, r *http.Request, doc *files.Document) {
+	if !isHXRequest(r) {
+		return
+	}
+
+	// Fall back to a plain trigger if the task counts can't be included
+	event := reloadPageHeaderTrigger["HX-Trigger"]
+	trigger := event
+	if counts, err := doc.CountTasks(); err == nil {
+		detail := map[string]headerReloadDetail{event: {ID: doc.Info.ID, Tasks: counts}}
+		if payload, err := json.Marshal(detail); err == nil {
+			trigger = string(payload)
+		}
+	}
+
+	w.Header().Set("HX-Trigger", trigger)
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func postEntry(t *testing.T, server *Server, target string, form url.Values, htmx bool) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if htmx {
+		req.Header.Set("HX-Request", "true")
+	}
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleAddEntry_HXTrigger(t *testing.T) {
+	server := newTestServer(t)
+
+	err := server.rootManager.WriteString("active.md", "# Active\n\n- [x] Done already\n")
+	assert.Nil(t, err)
+
+	rec := postEntry(t, server, "/add/active", url.Values{"entry": {"New task"}, "as_task": {"true"}}, true)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("HX-Redirect"), "")
+	assert.True(t, strings.Contains(rec.Body.String(), "Entry added successfully"))
+
+	var trigger map[string]headerReloadDetail
+	assert.Nil(t, json.Unmarshal([]byte(rec.Header().Get("HX-Trigger")), &trigger))
+
+	detail, ok := trigger["padd:reload-header"]
+	assert.True(t, ok)
+	assert.Equal(t, detail.ID, "active")
+	assert.Equal(t, detail.Tasks, files.TaskCounts{Total: 2, Completed: 1, Pending: 1})
+}
+
+func TestHandleAddEntry_NoTriggerWithoutHX(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := postEntry(t, server, "/add/inbox", url.Values{"entry": {"A note"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("HX-Trigger"), "")
+}
+
+func TestHandleAddTemporalEntry_HXTrigger(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := postEntry(t, server, "/daily", url.Values{"entry": {"Logged"}}, true)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("HX-Redirect"), "")
+	assert.True(t, strings.Contains(rec.Header().Get("HX-Trigger"), "padd:reload-header"))
+
+	// Failed adds don't reload the header
+	rec = postEntry(t, server, "/daily", url.Values{"entry": {"  "}}, true)
+	assert.Equal(t, rec.Header().Get("HX-Trigger"), "")
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/patrickward/padd/internal/files"
 	"github.com/patrickward/padd/internal/web"
@@ -12,6 +13,10 @@ import (
 // If the request header for HX-Request is true, then send a 204 with a HX-Redirect header.
 // Otherwise, send a 302 redirect.
 func (s *Server) redirectTo(w http.ResponseWriter, r *http.Request, url string) {
+	if strings.HasPrefix(url, "/") {
+		url = s.basePath + url
+	}
+
 	// If the request header for HX-Request is true, then send a 204 with a HX-Redirect header
 	if r.Header.Get("HX-Request") == "true" {
 		w.Header().Set("HX-Redirect", url)
@@ -79,6 +84,19 @@ func (s *Server) respondWithJSONError(w http.ResponseWriter, payload any, code i
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+// respondWithJSON writes payload as JSON. The payload is encoded before anything is written, so a payload
+// that can't be encoded is answered with a JSON error and a 500 status instead.
+func (s *Server) respondWithJSON(w http.ResponseWriter, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Error("Error encoding JSON response", "error", err)
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Failed to encode response"}, http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(append(body, '\n'))
+}
+
 func (s *Server) showDocumentError(w http.ResponseWriter, r *http.Request, doc *files.Document, err error) {
 	w.WriteHeader(http.StatusInternalServerError)
 
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd"
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/pdf"
+	"github.com/patrickward/padd/internal/rendering"
+)
+
+// exportImagePattern matches the src of images served from the images directory
+var exportImagePattern = regexp.MustCompile(`(<img[^>]+src=")(/images/[^"?#]+)"`)
+
+// exportLinkPattern matches the href of links to documents on this server
+var exportLinkPattern = regexp.MustCompile(`href="/([^"#?]+)"`)
+
+// exportCheckboxPattern matches the task checkboxes, which are disabled in exports since there's no server to
+// toggle them
+var exportCheckboxPattern = regexp.MustCompile(`<input((?: checked="")?) type="checkbox"`)
+
+// exportSection is one document in an HTML export
+type exportSection struct {
+	Anchor  string
+	Title   string
+	Content template.HTML
+}
+
+// exportDocument is a document included in an export, with its content decrypted
+type exportDocument struct {
+	Info    files.FileInfo
+	Content string
+}
+
+// handleExport downloads a document, or every document in a directory, as a standalone file in the format
+// named by the "format" query parameter: html (the default), with its styles and images inlined so it can be
+// opened anywhere, or pdf.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if files.IsSidecar(id) {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	doc, err := s.fileRepo.GetDocument(id)
+	if err != nil {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	format := r.FormValue("format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" && format != "pdf" {
+		http.Error(w, "Invalid format parameter (expected html or pdf)", http.StatusBadRequest)
+		return
+	}
+
+	documents, err := s.exportDocuments(doc.Info)
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	if len(documents) == 0 {
+		s.flashManager.SetError(w, r, "There are no documents to export")
+		s.redirectTo(w, r, "/"+doc.Info.ID)
+		return
+	}
+
+	title := doc.Info.TitleBase
+	if len(documents) == 1 {
+		title = s.documentTitle(documents[0])
+	}
+
+	var buf bytes.Buffer
+	var contentType string
+	switch format {
+	case "pdf":
+		contentType = "application/pdf"
+		output := pdf.New(title)
+		for _, document := range documents {
+			s.renderer.WritePDF(output, document.Content, document.Info.TitleBase)
+		}
+		_, err = output.WriteTo(&buf)
+	default:
+		contentType = "text/html; charset=utf-8"
+		err = s.writeHTMLExport(&buf, title, documents)
+	}
+
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	base := filepath.Base(doc.Info.Path)
+	fileName := strings.TrimSuffix(base, filepath.Ext(base)) + "." + format
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// exportDocuments returns the documents to export for a file or directory. Directories include the documents
+// in their subdirectories, leaving out drafts, and encrypted documents that can't be decrypted are skipped.
+func (s *Server) exportDocuments(info files.FileInfo) ([]exportDocument, error) {
+	infos := []files.FileInfo{info}
+	if info.IsDirectory {
+		infos = nil
+		if info.DirectoryNode != nil {
+			infos = info.DirectoryNode.WithoutDrafts().Flatten()
+		}
+	}
+
+	var documents []exportDocument
+	for _, fileInfo := range infos {
+		// CSV files are exported as the table their shortcode renders
+		if fileInfo.IsCSV() {
+			documents = append(documents, exportDocument{Info: fileInfo, Content: "{{csvtable " + fileInfo.ID + "}}"})
+			continue
+		}
+
+		doc, err := s.fileRepo.GetDocument(fileInfo.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := doc.Content()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", fileInfo.ID, err)
+		}
+
+		if crypto.IsAgeEncrypted([]byte(content)) {
+			continue
+		}
+
+		documents = append(documents, exportDocument{Info: fileInfo, Content: content})
+	}
+
+	return documents, nil
+}
+
+// documentTitle returns the title a document is rendered with
+func (s *Server) documentTitle(document exportDocument) string {
+	if title := s.renderer.Render(document.Content).Title; title != "" {
+		return title
+	}
+	return document.Info.TitleBase
+}
+
+// writeHTMLExport writes documents as a single HTML page. Links between the exported documents point to their
+// sections, and images from the images directory are inlined as data URIs.
+func (s *Server) writeHTMLExport(w io.Writer, title string, documents []exportDocument) error {
+	anchors := make(map[string]string, len(documents))
+	for _, document := range documents {
+		anchors[document.Info.ID] = "doc-" + strings.NewReplacer("/", "-", ".", "-").Replace(document.Info.ID)
+	}
+
+	sections := make([]exportSection, len(documents))
+	for i, document := range documents {
+		rendered := s.renderer.RenderWithOptions(document.Content, rendering.RenderOptions{DocumentPath: document.Info.Path})
+
+		content := exportLinkPattern.ReplaceAllStringFunc(string(rendered.HTML), func(link string) string {
+			if anchor, ok := anchors[exportLinkPattern.FindStringSubmatch(link)[1]]; ok {
+				return `href="#` + anchor + `"`
+			}
+			return link
+		})
+		content = exportCheckboxPattern.ReplaceAllString(content, `<input${1} disabled="" type="checkbox"`)
+		content = exportImagePattern.ReplaceAllStringFunc(content, func(img string) string {
+			match := exportImagePattern.FindStringSubmatch(img)
+			if dataURI, ok := s.imageDataURI(strings.TrimPrefix(match[2], "/images/")); ok {
+				return match[1] + dataURI + `"`
+			}
+			return img
+		})
+
+		sectionTitle := rendered.Title
+		if sectionTitle == "" {
+			sectionTitle = document.Info.TitleBase
+		}
+
+		sections[i] = exportSection{
+			Anchor:  anchors[document.Info.ID],
+			Title:   sectionTitle,
+			Content: template.HTML(content),
+		}
+	}
+
+	css, err := padd.StaticFS.ReadFile("static/css/export.css")
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("document.html").Funcs(customFuncs()).ParseFS(padd.TemplateFS, "templates/export/document.html")
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, map[string]any{
+		"Title":      title,
+		"CSS":        template.CSS(css),
+		"Sections":   sections,
+		"ExportedAt": time.Now(),
+	})
+}
+
+// imageDataURI returns an image from the user's images directory, or the embedded defaults, as a data URI
+func (s *Server) imageDataURI(imagePath string) (string, bool) {
+	contentType := getImageContentType(filepath.Ext(imagePath))
+	if contentType == "" || files.IsSidecar(imagePath) {
+		return "", false
+	}
+
+	content, err := s.rootManager.ReadFile(filepath.Join("images", imagePath))
+	if err != nil {
+		content, err = fs.ReadFile(padd.StaticFS, "static/images/"+imagePath)
+		if err != nil {
+			return "", false
+		}
+	}
+
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(content), true
+}
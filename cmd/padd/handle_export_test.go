@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleExport(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.MkdirAll("resources/trip", 0755))
+	assert.Nil(t, server.rootManager.MkdirAll("images", 0755))
+	assert.Nil(t, server.rootManager.WriteString("resources/trip/plan.md", "# Trip Plan\n\n- [x] Book flights\n- [ ] Pack\n\nSee [[Packing]].\n\n![Map](/images/map.svg)\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/trip/packing.md", "# Packing\n\nSocks & a <b>hat</b>.\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/trip/idea.md", "---\ndraft: true\n---\n# Idea\n"))
+	assert.Nil(t, server.rootManager.WriteString("images/map.svg", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`))
+	assert.Nil(t, server.rootManager.WriteString("images/photo.png", "png"))
+	assert.Nil(t, server.rootManager.WriteString("resources/photos.md", "# Photos\n\n![Beach](/images/photo.png)\n"))
+	server.fileRepo.ReloadCaches()
+
+	export := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	// A single document is a standalone page, with its images inlined
+	rec := export("/export/resources/photos")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	assert.Equal(t, rec.Header().Get("Content-Disposition"), `attachment; filename=photos.html`)
+	body := rec.Body.String()
+	assert.True(t, strings.HasPrefix(body, "<!DOCTYPE html>"))
+	assert.True(t, strings.Contains(body, "<title>Photos</title>"))
+	assert.True(t, strings.Contains(body, `src="data:image/png;base64,cG5n"`))
+	assert.True(t, strings.Contains(body, "<style>"))
+
+	// Directories include every document but drafts, with links between them pointing to their sections
+	rec = export("/export/resources/trip?format=html")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body = rec.Body.String()
+	assert.True(t, strings.Contains(body, `<article id="doc-resources-trip-plan"`))
+	assert.True(t, strings.Contains(body, `<article id="doc-resources-trip-packing"`))
+	assert.False(t, strings.Contains(body, "Idea"))
+	assert.True(t, strings.Contains(body, `href="#doc-resources-trip-packing"`))
+	assert.True(t, strings.Contains(body, `<input checked="" disabled="" type="checkbox"`))
+	assert.True(t, strings.Contains(body, "<svg"))
+
+	rec = export("/export/resources/trip?format=pdf")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/pdf")
+	assert.Equal(t, rec.Header().Get("Content-Disposition"), `attachment; filename=trip.pdf`)
+	assert.True(t, bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF-1.4")))
+	assert.True(t, bytes.Contains(rec.Body.Bytes(), []byte("/Count 2 ")))
+
+	assert.Equal(t, export("/export/resources/trip?format=docx").Code, http.StatusBadRequest)
+	assert.Equal(t, export("/export/resources/missing").Code, http.StatusNotFound)
+}
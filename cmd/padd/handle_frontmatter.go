@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// frontmatterFields are the single-value fields the details panel edits, in the order they're added to
+// frontmatter that doesn't have them yet. Tags are edited separately, as a list.
+var frontmatterFields = []string{"title", "status", "priority", "due_date"}
+
+// handleFrontmatterEdit renders the details panel for a document's frontmatter, filled in with its current
+// values. It replaces the page header's panel, and canceling reloads the header.
+func (s *Server) handleFrontmatterEdit(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.fileRepo.GetDocument(r.PathValue("id"))
+	if err != nil || doc.Info.IsCSV() {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	fm, err := doc.Frontmatter()
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	title, _ := fm.Get("title")
+	status, _ := fm.Get("status")
+	priority, _ := fm.Get("priority")
+	dueDate, _ := fm.Get("due_date")
+
+	if err := s.executeSnippet(w, "frontmatter_edit", map[string]any{
+		"ID":       doc.Info.ID,
+		"Title":    title,
+		"Tags":     strings.Join(fm.GetList("tags"), ", "),
+		"Status":   status,
+		"Priority": priority,
+		"DueDate":  dueDate,
+		// Suggest the values that have badge colors
+		"StatusValues":   slices.Sorted(maps.Keys(s.metadata().StatusColors)),
+		"PriorityValues": slices.Sorted(maps.Keys(s.metadata().PriorityColors)),
+	}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleFrontmatterUpdate saves the details panel to a document's frontmatter. Each field is set to its
+// submitted value or removed when left blank; other fields, and the order of the existing ones, are kept.
+// Tags are a comma-separated list, and the due date, when given, must be YYYY-MM-DD.
+func (s *Server) handleFrontmatterUpdate(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.fileRepo.GetDocument(r.PathValue("id"))
+	if err != nil || doc.Info.IsCSV() {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	fm, err := doc.Frontmatter()
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	for _, key := range frontmatterFields {
+		value := strings.TrimSpace(r.FormValue(key))
+		if value == "" {
+			fm.Delete(key)
+			continue
+		}
+		if key == "due_date" {
+			if _, err := time.Parse("2006-01-02", value); err != nil {
+				s.flashManager.SetError(w, r, "Due date must be a date like 2006-01-02")
+				s.redirectTo(w, r, "/"+doc.Info.ID)
+				return
+			}
+		}
+		fm.Set(key, value)
+	}
+
+	if tags := splitList(r.FormValue("tags")); len(tags) > 0 {
+		fm.SetList("tags", tags)
+	} else {
+		fm.Delete("tags")
+	}
+
+	err = doc.SaveFrontmatter(fm)
+	if errors.Is(err, files.ErrDocumentLocked) {
+		s.flashManager.SetError(w, r, "This document is locked and can't be edited")
+		s.redirectTo(w, r, "/"+doc.Info.ID)
+		return
+	}
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, "Details saved")
+	s.redirectTo(w, r, "/"+doc.Info.ID)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleFrontmatterEdit(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/plan.md", "---\ntitle: Launch Plan\ntags: [work, q3]\npriority: high\n---\n\n# Plan\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/frontmatter/edit/resources/plan", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `name="title" value="Launch Plan"`))
+	assert.True(t, strings.Contains(body, `name="tags" value="work, q3"`))
+	assert.True(t, strings.Contains(body, `name="priority" value="high"`))
+	assert.True(t, strings.Contains(body, `<option value="in-progress">`))
+}
+
+func TestHandleFrontmatterUpdate(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/plan.md", "---\ntitle: Launch Plan\nauthor: Sam\npriority: high\n---\n\n# Plan\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := postEntry(t, server, "/frontmatter/resources/plan", url.Values{
+		"title":    {"Launch Plan: Phase 2"},
+		"tags":     {"work, q3 goals"},
+		"status":   {"in-progress"},
+		"priority": {""},
+		"due_date": {"2025-10-01"},
+	}, true)
+	assert.Equal(t, rec.Header().Get("HX-Redirect"), "/resources/plan")
+
+	// Other fields and the order of existing ones are kept, and blank fields are removed
+	content, err := server.rootManager.ReadFile("resources/plan.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), `---
+title: "Launch Plan: Phase 2"
+author: Sam
+status: in-progress
+due_date: 2025-10-01
+tags: [work, q3 goals]
+---
+
+# Plan
+`)
+
+	// An invalid due date leaves the file as it was
+	postEntry(t, server, "/frontmatter/resources/plan", url.Values{"due_date": {"next week"}}, true)
+	unchanged, err := server.rootManager.ReadFile("resources/plan.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(unchanged), string(content))
+}
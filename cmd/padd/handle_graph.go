@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/patrickward/padd/internal/web"
+)
+
+// graphGroupings are the values of the "group" query parameter of the note graph, by whether they group nodes
+// by tag
+var graphGroupings = map[string]bool{
+	"":     false,
+	"tags": true,
+}
+
+// handleGraph shows the note graph of files and the links between them. Requests that ask for JSON, with a
+// ?format=json parameter or an Accept header that lists application/json, get the graph's nodes and edges
+// instead, which is what the page draws. The optional "group" query parameter ("tags") clusters notes by their
+// first tag.
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	group := r.URL.Query().Get("group")
+	if _, ok := graphGroupings[group]; !ok {
+		http.Error(w, "Invalid group: "+group, http.StatusBadRequest)
+		return
+	}
+
+	if wantsJSON(r) {
+		w.Header().Set("Vary", "Accept")
+		s.writeGraph(w, r)
+		return
+	}
+
+	graph := s.fileRepo.LinkGraph()
+	data := web.PageData{
+		Title:        "Graph",
+		NavMenuFiles: s.navigationMenu(r.URL.Path),
+		Graph: &web.GraphData{
+			Group: group,
+			Nodes: len(graph.Nodes),
+			Edges: len(graph.Edges),
+		},
+	}
+
+	if err := s.executePage(w, "graph.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// wantsJSON reports whether the request asks for JSON instead of the HTML page, either with a ?format=json
+// parameter or an Accept header that lists application/json
+func wantsJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "json" || acceptsMediaType(r, "application/json")
+}
+
+// handleGraphAPI serves the note graph of files and the links between them as JSON, taking the same "group"
+// query parameter as the graph page
+func (s *Server) handleGraphAPI(w http.ResponseWriter, r *http.Request) {
+	s.writeGraph(w, r)
+}
+
+// writeGraph responds with the note graph as JSON, grouped as the "group" query parameter asks
+func (s *Server) writeGraph(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	group := r.URL.Query().Get("group")
+	byTag, ok := graphGroupings[group]
+	if !ok {
+		s.respondWithJSONError(w, APIErrorResponse{Error: "Invalid group: " + group}, http.StatusBadRequest)
+		return
+	}
+
+	graph := s.fileRepo.LinkGraph()
+	if byTag {
+		graph.GroupByTag()
+	}
+
+	if err := json.NewEncoder(w).Encode(graph); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
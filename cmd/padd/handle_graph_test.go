@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestHandleGraph(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/alpha.md", "---\ntags: [project]\n---\n# Alpha\n\nSee [[inbox]].\n"))
+	server.fileRepo.ReloadCaches()
+
+	get := func(url, accept string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	groups := func(rec *httptest.ResponseRecorder) map[string]string {
+		t.Helper()
+
+		var graph files.Graph
+		assert.Nil(t, json.NewDecoder(rec.Body).Decode(&graph))
+		result := make(map[string]string)
+		for _, node := range graph.Nodes {
+			result[node.ID] = node.Group
+		}
+		return result
+	}
+
+	// The page draws the graph from its JSON
+	rec := get("/graph", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `id="note-graph"`))
+	assert.True(t, strings.Contains(rec.Body.String(), "Notes: 3, links: 1"))
+
+	// JSON, asked for with a parameter or the Accept header
+	rec = get("/graph?format=json", "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, groups(rec)["resources/alpha"], "")
+
+	rec = get("/graph?group=tags", "application/json")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Vary"), "Accept")
+	assert.Equal(t, groups(rec)["resources/alpha"], "project")
+
+	rec = get("/api/graph?group=tags", "")
+	assert.Equal(t, groups(rec)["resources/alpha"], "project")
+
+	// Unknown groupings are rejected
+	assert.Equal(t, get("/graph?group=colors", "").Code, http.StatusBadRequest)
+	assert.Equal(t, get("/api/graph?group=colors", "").Code, http.StatusBadRequest)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/web"
+)
+
+// WithHistory records every change to a file as a git commit in the data directory. History is turned on
+// when enabled is true, initializing a repository if needed, or when the data directory is already a git
+// repository.
+func WithHistory(enabled bool) ServerOption {
+	return func(s *Server) error {
+		if !enabled && !files.IsGitRepository(s.dataDir) {
+			return nil
+		}
+
+		store, err := files.NewGitVersionStore(s.dataDir)
+		if err != nil {
+			return fmt.Errorf("could not enable version history: %w", err)
+		}
+		s.fileRepo.SetVersionStore(store)
+		return nil
+	}
+}
+
+// handleHistory lists the revisions of a document. With a "rev" query parameter, it also shows the document
+// as it was at that revision.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.historyDocument(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := doc.History()
+	if err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to list revisions: %w", err))
+		return
+	}
+
+	data := web.PageData{
+		Title:        "History of " + doc.Info.TitleBase,
+		CurrentFile:  doc.Info,
+		NavMenuFiles: s.navigationMenu(doc.Info.ID),
+		Revisions:    revisions,
+	}
+
+	if rev := strings.TrimSpace(r.URL.Query().Get("rev")); rev != "" {
+		content, err := doc.RevisionContent(rev)
+		if err != nil {
+			s.showPageNotFound(w, r)
+			return
+		}
+
+		data.Revision = rev
+		data.Content = s.renderer.Render(content).HTML
+	}
+
+	data.Flashes = s.flashManager.Get(w, r)
+	if err := s.executePage(w, "history.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleRestoreRevision restores a document to the revision in the "rev" form value. The restore is
+// itself recorded as a new revision.
+func (s *Server) handleRestoreRevision(w http.ResponseWriter, r *http.Request) {
+	doc, ok := s.historyDocument(w, r)
+	if !ok {
+		return
+	}
+
+	rev := strings.TrimSpace(r.FormValue("rev"))
+	if rev == "" {
+		s.flashManager.SetError(w, r, "Revision is required")
+		s.redirectTo(w, r, "/history/"+doc.Info.ID)
+		return
+	}
+
+	if err := doc.Restore(rev); err != nil {
+		s.flashManager.SetError(w, r, fmt.Sprintf("Failed to restore revision: %v", err))
+		s.redirectTo(w, r, "/history/"+doc.Info.ID)
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, "Revision restored")
+	s.redirectTo(w, r, "/"+doc.Info.ID)
+}
+
+// historyDocument returns the markdown document of a history request. It shows a 404 page when history
+// isn't enabled or the document doesn't exist.
+func (s *Server) historyDocument(w http.ResponseWriter, r *http.Request) (*files.Document, bool) {
+	if !s.fileRepo.HasVersionStore() {
+		s.showPageNotFound(w, r)
+		return nil, false
+	}
+
+	doc, err := s.fileRepo.GetDocument(r.PathValue("id"))
+	if err != nil || doc.Info.IsDirectory || doc.Info.IsCSV() {
+		s.showPageNotFound(w, r)
+		return nil, false
+	}
+
+	return doc, true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	server := newTestServer(t, WithHistory(true))
+	doc, err := server.fileRepo.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Inbox\n\n- [ ] Original task\n"))
+	assert.Nil(t, doc.Save("# Inbox\n\n- [ ] Changed task\n"))
+
+	revisions, err := doc.History()
+	assert.Nil(t, err)
+	assert.Equal(t, len(revisions), 2)
+	original := revisions[1].ID
+
+	// Lists the revisions
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/history/inbox", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `href="/history/inbox?rev=`+original+`"`))
+
+	// Shows an older revision
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/history/inbox?rev="+original, nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Original task"))
+
+	// Unknown revisions are not found
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/history/inbox?rev=HEAD", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	// Restores an older revision
+	req := httptest.NewRequest(http.MethodPost, "/history/inbox", strings.NewReader(url.Values{"rev": {original}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/inbox")
+
+	doc, err = server.fileRepo.GetDocument("inbox")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Inbox\n\n- [ ] Original task\n")
+}
+
+func TestHandleHistory_Disabled(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/history/inbox", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+
+	// The view has no history link
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/inbox", nil))
+	assert.False(t, strings.Contains(rec.Body.String(), `href="/history/inbox"`))
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
@@ -15,6 +16,21 @@ import (
 	"time"
 
 	"github.com/patrickward/padd"
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/imaging"
+)
+
+// Default image processing settings
+const (
+	defaultImageMaxSize = 2048 // Uploaded photos are scaled down to fit 2048x2048
+	defaultThumbSize    = 320
+)
+
+// Directories that uploaded images are saved in, within the data directory
+const (
+	uploadsDir   = "images/uploads"
+	thumbsDir    = "images/thumbs"
+	originalsDir = "images/originals"
 )
 
 // handleImages creates a file server that serves images from both static defaults and user directory
@@ -22,7 +38,7 @@ func (s *Server) handleImages() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract the image path (remove "/images/" prefix)
 		imagePath := strings.TrimPrefix(r.URL.Path, "/images/")
-		if imagePath == "" {
+		if imagePath == "" || files.IsSidecar(imagePath) {
 			http.NotFound(w, r)
 			return
 		}
@@ -134,22 +150,70 @@ func getImageContentType(ext string) string {
 	}
 }
 
+// imageExtension returns the file extension for an image content type, or an empty string for types that
+// aren't images
+func imageExtension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "image/svg+xml":
+		return ".svg"
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/x-icon", "image/vnd.microsoft.icon":
+		return ".ico"
+	default:
+		return ""
+	}
+}
+
 type ImageUploadResponse struct {
-	Success bool   `json:"success"`
-	DataURI string `json:"dataUri,omitempty"`
-	Error   string `json:"error,omitempty"`
+	Success      bool   `json:"success"`
+	DataURI      string `json:"dataUri,omitempty"`
+	ThumbnailURI string `json:"thumbnailUri,omitempty"`
+	Markdown     string `json:"markdown,omitempty"`
+	Document     string `json:"document,omitempty"`
+	Error        string `json:"error,omitempty"`
 }
 
-// handleImageUpload handles image uploads
+// UploadedImage describes an uploaded image and the document it was added to
+type UploadedImage struct {
+	URI          string    `json:"uri"`
+	ThumbnailURI string    `json:"thumbnailUri,omitempty"`
+	Document     string    `json:"document,omitempty"`
+	Alt          string    `json:"alt,omitempty"`
+	UploadedAt   time.Time `json:"uploadedAt"`
+}
+
+// imageRecord is saved in a sidecar next to each upload (e.g., "images/uploads/photo.png.meta.json"), so the
+// images added to a document can be found later
+type imageRecord struct {
+	Document   string    `json:"document,omitempty"`
+	Alt        string    `json:"alt,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// imageRecordSuffix is the suffix of metadata sidecars, so files.IsSidecar recognizes image records
+const imageRecordSuffix = files.SidecarSuffix
+
+// maxImageUploadSize limits the size of uploaded images
+const maxImageUploadSize = 10 << 20 // 10 MB
+
+// handleImageUpload handles image uploads from the image dialog. JPEG and PNG images larger than the configured
+// maximum are scaled down before they're saved, and a thumbnail is saved alongside them.
 func (s *Server) handleImageUpload(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Limit the size of uplaoded images
-	const maxFileSize = 10 << 20 // 10 MB
-	r.Body = http.MaxBytesReader(w, r.Body, maxFileSize)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadSize)
 
 	// Pares the multipart form
-	if err := r.ParseMultipartForm(maxFileSize); err != nil {
+	if err := r.ParseMultipartForm(maxImageUploadSize); err != nil {
 		response := ImageUploadResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to parse multipart form: %v", err),
@@ -158,6 +222,11 @@ func (s *Server) handleImageUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	documentID, ok := s.imageDocumentFromRequest(w, r)
+	if !ok {
+		return
+	}
+
 	// Get the uploaded file
 	file, fileHeader, err := r.FormFile("image")
 	if err != nil {
@@ -175,13 +244,8 @@ func (s *Server) handleImageUpload(w http.ResponseWriter, r *http.Request) {
 
 	// validate the file
 	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-	contentType := getImageContentType(ext)
-	if contentType == "" {
-		response := ImageUploadResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Unsupported file type: %s. Accepted types: .svg, .png, .jpg, .jpeg, .gif, .webp, .ico", ext),
-		}
-		s.respondWithJSONError(w, response, http.StatusBadRequest)
+	if getImageContentType(ext) == "" {
+		s.respondWithJSONError(w, unsupportedImageResponse(ext), http.StatusBadRequest)
 		return
 	}
 
@@ -196,19 +260,174 @@ func (s *Server) handleImageUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filename := s.generateImageFilename(fileContent, ext)
+	alt := strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+	s.storeUploadedImage(w, r, fileContent, ext, documentID, alt)
+}
+
+// handleImagePaste handles images pasted or dropped into the editor. The image is either the "image" field of
+// a multipart form or the raw request body, sent with the image's content type (e.g., "image/png"). The alt
+// text comes from the "alt" form field or query parameter, and the document the image is added to from the
+// X-PADD-File-ID header. The response includes the markdown that embeds the image.
+func (s *Server) handleImagePaste(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadSize)
+
+	documentID, ok := s.imageDocumentFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var content []byte
+	var ext, alt string
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(maxImageUploadSize); err != nil {
+			s.respondWithJSONError(w, ImageUploadResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to parse multipart form: %v", err),
+			}, http.StatusBadRequest)
+			return
+		}
+
+		file, fileHeader, err := r.FormFile("image")
+		if err != nil {
+			s.respondWithJSONError(w, ImageUploadResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to get uploaded file: %v", err),
+			}, http.StatusBadRequest)
+			return
+		}
+		defer func(file multipart.File) {
+			_ = file.Close()
+		}(file)
+
+		// Pasted images don't always have a useful name, so fall back to the part's content type
+		ext = strings.ToLower(filepath.Ext(fileHeader.Filename))
+		if getImageContentType(ext) == "" {
+			ext = imageExtension(fileHeader.Header.Get("Content-Type"))
+		}
+
+		alt = r.FormValue("alt")
+		if alt == "" && fileHeader.Filename != "image"+ext {
+			alt = strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+		}
+
+		if content, err = io.ReadAll(file); err != nil {
+			s.respondWithJSONError(w, ImageUploadResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to read uploaded file: %v", err),
+			}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		ext = imageExtension(mediaType)
+		alt = r.URL.Query().Get("alt")
+
+		var err error
+		if content, err = io.ReadAll(r.Body); err != nil {
+			s.respondWithJSONError(w, ImageUploadResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to read pasted image: %v", err),
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if getImageContentType(ext) == "" {
+		s.respondWithJSONError(w, unsupportedImageResponse(ext), http.StatusBadRequest)
+		return
+	}
+
+	if len(content) == 0 {
+		s.respondWithJSONError(w, ImageUploadResponse{Success: false, Error: "The pasted image is empty"}, http.StatusBadRequest)
+		return
+	}
 
-	uploadsDir := "images/uploads"
-	if err := s.rootManager.MkdirAll(uploadsDir, 0755); err != nil {
+	if alt == "" {
+		alt = "Pasted image"
+	}
+
+	s.storeUploadedImage(w, r, content, ext, documentID, alt)
+}
+
+// handleUploadedImagesAPI serves a JSON list of uploaded images, newest first. The "doc" query parameter limits
+// the list to the images added to a document.
+func (s *Server) handleUploadedImagesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	documentID := r.URL.Query().Get("doc")
+	images := make([]UploadedImage, 0)
+
+	_ = s.rootManager.WalkDir(uploadsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Continue walking despite errors
+		}
+
+		if d.IsDir() || !strings.HasSuffix(path, imageRecordSuffix) {
+			return nil
+		}
+
+		content, err := s.rootManager.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		var record imageRecord
+		if json.Unmarshal(content, &record) != nil || (documentID != "" && record.Document != documentID) {
+			return nil
+		}
+
+		filename := strings.TrimSuffix(d.Name(), imageRecordSuffix)
+		image := UploadedImage{
+			URI:        fmt.Sprintf("/%s/%s", uploadsDir, filename),
+			Document:   record.Document,
+			Alt:        record.Alt,
+			UploadedAt: record.UploadedAt,
+		}
+		if imaging.Supported(filepath.Ext(filename)) {
+			image.ThumbnailURI = fmt.Sprintf("/%s/%s", thumbsDir, filename)
+		}
+
+		images = append(images, image)
+		return nil
+	})
+
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].UploadedAt.After(images[j].UploadedAt)
+	})
+
+	if err := json.NewEncoder(w).Encode(images); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// storeUploadedImage scales an uploaded image down, saves it with its thumbnail and record, and sends the
+// upload response
+func (s *Server) storeUploadedImage(w http.ResponseWriter, r *http.Request, content []byte, ext, documentID, alt string) {
+	// Scale large photos down, keeping the original's hash in the filename so re-uploads are easy to spot
+	processed, resized, err := imaging.Fit(content, ext, s.imageMaxWidth, s.imageMaxHeight)
+	if err != nil {
 		s.respondWithJSONError(w, ImageUploadResponse{
 			Success: false,
-			Error:   fmt.Sprintf("Failed to create uploads directory: %v", err),
-		}, http.StatusInternalServerError)
+			Error:   fmt.Sprintf("Failed to process image: %v", err),
+		}, http.StatusBadRequest)
 		return
 	}
 
-	imagePath := filepath.Join(uploadsDir, filename)
-	if err := s.rootManager.WriteFile(imagePath, fileContent, 0644); err != nil {
+	filename := s.generateImageFilename(content, ext)
+
+	if resized && s.keepOriginals {
+		if err := s.saveImage(originalsDir, filename, content); err != nil {
+			s.respondWithJSONError(w, ImageUploadResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Failed to save original image: %v", err),
+			}, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := s.saveImage(uploadsDir, filename, processed); err != nil {
 		s.respondWithJSONError(w, ImageUploadResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to save uploaded file: %v", err),
@@ -216,16 +435,117 @@ func (s *Server) handleImageUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	imageURL := fmt.Sprintf("/images/uploads/%s", filename)
+	record, err := json.Marshal(imageRecord{Document: documentID, Alt: alt, UploadedAt: time.Now()})
+	if err == nil {
+		err = s.rootManager.WriteFile(filepath.Join(uploadsDir, filename+imageRecordSuffix), record, 0644)
+	}
+	if err != nil {
+		s.logger.Error("Failed to save the image record", "file", filename, "error", err)
+	}
+
 	response := ImageUploadResponse{
-		Success: true,
-		DataURI: imageURL,
+		Success:  true,
+		DataURI:  fmt.Sprintf("/%s/%s", uploadsDir, filename),
+		Document: documentID,
+	}
+	response.Markdown = fmt.Sprintf("![%s](%s)", markdownImageAlt(alt), response.DataURI)
+
+	// The upload has already been saved, so a thumbnail that fails is left to be made when it's first requested
+	if imaging.Supported(ext) {
+		if _, err := s.createThumbnail(filename, processed); err != nil {
+			s.logger.Error("Failed to create thumbnail", "file", filename, "error", err)
+		}
+		response.ThumbnailURI = fmt.Sprintf("/%s/%s", thumbsDir, filename)
 	}
+
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		s.showServerError(w, r, err)
 	}
 }
 
+// imageDocumentFromRequest returns the ID of the document an image is being added to, from the X-PADD-File-ID
+// header. Uploads don't have to belong to a document, but an unknown document is rejected.
+func (s *Server) imageDocumentFromRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	documentID := r.Header.Get("X-PADD-File-ID")
+	if documentID == "" {
+		return "", true
+	}
+
+	if _, err := s.fileRepo.GetDocument(documentID); err != nil {
+		s.respondWithJSONError(w, ImageUploadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Unknown document: %s", documentID),
+		}, http.StatusBadRequest)
+		return "", false
+	}
+
+	return documentID, true
+}
+
+func unsupportedImageResponse(ext string) ImageUploadResponse {
+	return ImageUploadResponse{
+		Success: false,
+		Error:   fmt.Sprintf("Unsupported file type: %s. Accepted types: .svg, .png, .jpg, .jpeg, .gif, .webp, .ico", ext),
+	}
+}
+
+// markdownImageAlt escapes alt text for an image's markdown, keeping it on one line
+func markdownImageAlt(alt string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "\r", " ", "\n", " ").Replace(strings.TrimSpace(alt))
+}
+
+// handleImageThumbnail serves the thumbnail of an uploaded image, creating it the first time it's requested
+// for uploads that don't have one yet
+func (s *Server) handleImageThumbnail(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	ext := filepath.Ext(name)
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") || !imaging.Supported(ext) {
+		http.NotFound(w, r)
+		return
+	}
+
+	thumbnail, err := s.rootManager.ReadFile(filepath.Join(thumbsDir, name))
+	if err != nil {
+		upload, err := s.rootManager.ReadFile(filepath.Join(uploadsDir, name))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		thumbnail, err = s.createThumbnail(name, upload)
+		if err != nil {
+			s.showServerError(w, r, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", getImageContentType(ext))
+	_, _ = w.Write(thumbnail)
+}
+
+// createThumbnail scales an image down to the thumbnail size and saves it in the thumbnails directory
+func (s *Server) createThumbnail(filename string, content []byte) ([]byte, error) {
+	thumbnail, _, err := imaging.Fit(content, filepath.Ext(filename), s.thumbSize, s.thumbSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.saveImage(thumbsDir, filename, thumbnail); err != nil {
+		return nil, err
+	}
+
+	return thumbnail, nil
+}
+
+// saveImage writes an image to a directory within the data directory, creating the directory if needed
+func (s *Server) saveImage(dir, filename string, content []byte) error {
+	if err := s.rootManager.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", dir, err)
+	}
+
+	return s.rootManager.WriteFile(filepath.Join(dir, filename), content, 0644)
+}
+
 // generateImageFilename generates a unique filename for an image based on its content
 func (s *Server) generateImageFilename(content []byte, ext string) string {
 	hash := sha256.Sum256(content)
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleImageUpload(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, WithImageProcessing(100, 100, 20, true))
+
+	var photo bytes.Buffer
+	assert.Nil(t, png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 400, 200))))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", "photo.png")
+	assert.Nil(t, err)
+	_, err = part.Write(photo.Bytes())
+	assert.Nil(t, err)
+	assert.Nil(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/images/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response ImageUploadResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.True(t, response.Success)
+	assert.MatchesRegexp(t, response.DataURI, `^/images/uploads/\d{8}-\d{6}-[0-9a-f]{12}\.png$`)
+	filename := strings.TrimPrefix(response.DataURI, "/images/uploads/")
+	assert.Equal(t, response.ThumbnailURI, "/images/thumbs/"+filename)
+
+	size := func(path string) (int, int) {
+		t.Helper()
+		content, err := server.rootManager.ReadFile(path)
+		assert.Nil(t, err)
+		config, _, err := image.DecodeConfig(bytes.NewReader(content))
+		assert.Nil(t, err)
+		return config.Width, config.Height
+	}
+
+	// The upload is scaled down, the original is kept, and the thumbnail fits the thumbnail size
+	width, height := size("images/uploads/" + filename)
+	assert.Equal(t, [2]int{width, height}, [2]int{100, 50})
+	width, height = size("images/originals/" + filename)
+	assert.Equal(t, [2]int{width, height}, [2]int{400, 200})
+	width, height = size("images/thumbs/" + filename)
+	assert.Equal(t, [2]int{width, height}, [2]int{20, 10})
+
+	// Thumbnails that are missing are created when they're requested
+	assert.Nil(t, server.rootManager.Remove("images/thumbs/"+filename))
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, response.ThumbnailURI, nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "image/png")
+	width, height = size("images/thumbs/" + filename)
+	assert.Equal(t, [2]int{width, height}, [2]int{20, 10})
+
+	for _, path := range []string{"/images/thumbs/missing.png", "/images/thumbs/..png", "/images/thumbs/icon.svg"} {
+		rec = httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleImagePaste(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t)
+
+	var photo, screenshot bytes.Buffer
+	assert.Nil(t, png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 10, 10))))
+	assert.Nil(t, png.Encode(&screenshot, image.NewRGBA(image.Rect(0, 0, 20, 10))))
+
+	paste := func(req *http.Request, fileID string) (*httptest.ResponseRecorder, ImageUploadResponse) {
+		t.Helper()
+		if fileID != "" {
+			req.Header.Set("X-PADD-File-ID", fileID)
+		}
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+
+		var response ImageUploadResponse
+		assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+		return rec, response
+	}
+
+	// Raw clipboard payloads take their type from the content type
+	req := httptest.NewRequest(http.MethodPost, "/api/images/paste?alt=Sales+%5Bchart%5D", bytes.NewReader(photo.Bytes()))
+	req.Header.Set("Content-Type", "image/png")
+	rec, response := paste(req, "active")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, response.Success)
+	assert.Equal(t, response.Document, "active")
+	assert.MatchesRegexp(t, response.DataURI, `^/images/uploads/.+\.png$`)
+	assert.Equal(t, response.Markdown, `![Sales \[chart\]](`+response.DataURI+`)`)
+
+	// Pasted files named "image.png" by the browser get generic alt text
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", "image.png")
+	assert.Nil(t, err)
+	_, err = part.Write(screenshot.Bytes())
+	assert.Nil(t, err)
+	assert.Nil(t, form.Close())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/images/paste", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec, response = paste(req, "")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.MatchesRegexp(t, response.Markdown, `^!\[Pasted image\]\(/images/uploads/.+\.png\)$`)
+
+	// Unknown documents and content that isn't an image are rejected
+	req = httptest.NewRequest(http.MethodPost, "/api/images/paste", bytes.NewReader(photo.Bytes()))
+	req.Header.Set("Content-Type", "image/png")
+	rec, response = paste(req, "missing-note")
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+	assert.False(t, response.Success)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/images/paste", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "text/plain")
+	rec, _ = paste(req, "")
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+	// Images are listed by the document they were added to
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/images?doc=active", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var images []UploadedImage
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&images))
+	assert.Equal(t, len(images), 1)
+	assert.Equal(t, images[0].Alt, "Sales [chart]")
+	assert.Equal(t, images[0].Document, "active")
+
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/images", nil))
+	images = nil
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&images))
+	assert.Equal(t, len(images), 2)
+
+	// Image records aren't served as images
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, images[0].URI+".meta.json", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/patrickward/padd/internal/web"
+)
+
+// handleLoginPage shows the password form for signing in
+func (s *Server) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	next := unlockRedirectPath(r.URL.Query().Get("next"))
+	if s.auth == nil || isSignedIn(r) {
+		s.redirectTo(w, r, next)
+		return
+	}
+
+	data := web.PageData{
+		Title:     "Sign In",
+		LoginNext: next,
+		Flashes:   s.flashManager.Get(w, r),
+	}
+
+	if err := s.executePage(w, "login.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleLogin signs the browser in when the "password" form value is correct, and redirects to the page in
+// the "next" form value
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := unlockRedirectPath(r.FormValue("next"))
+	if s.auth == nil {
+		s.redirectTo(w, r, next)
+		return
+	}
+
+	if !s.auth.checkPassword(r.FormValue("password")) {
+		s.flashManager.SetError(w, r, "Incorrect password")
+		s.redirectTo(w, r, "/login?next="+url.QueryEscape(next))
+		return
+	}
+
+	if err := s.auth.startSession(w, r); err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to start a session: %w", err))
+		return
+	}
+
+	s.redirectTo(w, r, next)
+}
+
+// handleLogout signs the browser out
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if s.auth != nil {
+		s.auth.endSession(w, r)
+	}
+
+	s.flashManager.SetSuccess(w, r, "Signed out")
+	s.redirectTo(w, r, "/login")
+}
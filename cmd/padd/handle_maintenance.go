@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/patrickward/padd/internal/web"
+)
+
+// handleLinkReport shows the links across all files that don't lead anywhere, grouped by file: wiki links that
+// don't resolve, relative links to files that don't exist, and, when external link checks are on, the external
+// URLs that failed the most recent check. Internal links are scanned on every request, so fixes show up right
+// away; external URLs are only as current as the last background check.
+func (s *Server) handleLinkReport(w http.ResponseWriter, r *http.Request) {
+	check, err := s.fileRepo.CheckLinks()
+	if err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to scan for broken links: %w", err))
+		return
+	}
+
+	report := &web.LinkReport{}
+	files := make(map[string]*web.LinkReportFile)
+	file := func(id string) *web.LinkReportFile {
+		if files[id] == nil {
+			files[id] = &web.LinkReportFile{ID: id}
+		}
+		return files[id]
+	}
+
+	for id, links := range check.BrokenWiki {
+		file(id).Wiki = links
+	}
+	for id, links := range check.DeadRelative {
+		file(id).Relative = links
+	}
+
+	if s.linkChecker != nil {
+		checked, failures, running := s.linkChecker.status()
+		report.ExternalEnabled = true
+		report.ExternalChecked = checked
+		report.ExternalChecking = running
+
+		// URLs no longer linked from any file are left out
+		for _, rawURL := range slices.Sorted(maps.Keys(failures)) {
+			for _, id := range check.External[rawURL] {
+				file(id).External = append(file(id).External, web.ExternalLinkFailure{URL: rawURL, Reason: failures[rawURL]})
+			}
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(files)) {
+		report.Files = append(report.Files, *files[id])
+	}
+
+	data := web.PageData{
+		Title:        "Link Report",
+		NavMenuFiles: s.navigationMenu(r.URL.Path),
+		Flashes:      s.flashManager.Get(w, r),
+		LinkReport:   report,
+	}
+
+	if err := s.executePage(w, "reports_links.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleMovedLinkReport permanently redirects the broken link page's old address, /maintenance/links, to the
+// link report that replaced it
+func (s *Server) handleMovedLinkReport(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, s.basePath+"/reports/links", http.StatusMovedPermanently)
+}
+
+// handleCheckLinksNow starts a check of the external URLs in the background, rather than waiting for the next
+// scheduled one
+func (s *Server) handleCheckLinksNow(w http.ResponseWriter, r *http.Request) {
+	if s.linkChecker == nil {
+		s.flashManager.SetError(w, r, "External links aren't checked; start the server with -link-check-interval to turn it on")
+		s.redirectTo(w, r, "/reports/links")
+		return
+	}
+
+	s.backgroundRunner.StartOneTimeTask("link-check", s.checkExternalLinks)
+	s.flashManager.SetSuccess(w, r, "Checking external links in the background")
+	s.redirectTo(w, r, "/reports/links")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleBrokenLinks(t *testing.T) {
+	server := newTestServer(t)
+
+	err := server.rootManager.WriteString("resources/notes.md", "# Notes\n\n[[inbox]] and [[Lost Page]]\n")
+	assert.Nil(t, err)
+	server.fileRepo.ReloadCaches()
+
+	// The old address of the broken link page leads to the link report
+	req := httptest.NewRequest(http.MethodGet, "/maintenance/links", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusMovedPermanently)
+	assert.Equal(t, rec.Header().Get("Location"), "/reports/links")
+
+	req = httptest.NewRequest(http.MethodGet, "/reports/links", nil)
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `<h2><a href="/resources/notes">`))
+	assert.True(t, strings.Contains(body, "<code>[[Lost Page]]</code>"))
+	assert.False(t, strings.Contains(body, "<code>[[inbox]]</code>"))
+}
+
+func TestHandleLinkReport(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer external.Close()
+
+	server := newTestServer(t)
+	assert.Nil(t, WithExternalLinkCheck(time.Hour, 0)(server))
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n\n"+
+		"[[Lost Page]], [gone](gone.md), [kept](kept.pdf), [today](/daily)\n\n"+
+		"[ok]("+external.URL+"/ok), [no head]("+external.URL+"/no-head), [dead]("+external.URL+"/dead)\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/kept.pdf", "%PDF-1.4\n"))
+	server.fileRepo.ReloadCaches()
+
+	get := func() string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports/links", nil))
+		assert.Equal(t, rec.Code, http.StatusOK)
+		return rec.Body.String()
+	}
+
+	// Internal links are reported before external links have been checked
+	body := get()
+	assert.True(t, strings.Contains(body, "<code>[[Lost Page]]</code>"))
+	assert.True(t, strings.Contains(body, "<code>gone.md</code>"))
+	assert.False(t, strings.Contains(body, "kept.pdf"))
+	assert.False(t, strings.Contains(body, "<code>/daily</code>"))
+	assert.True(t, strings.Contains(body, "External links haven't been checked yet."))
+
+	assert.Nil(t, server.checkExternalLinks(context.Background()))
+
+	body = get()
+	assert.True(t, strings.Contains(body, external.URL+"/dead"))
+	assert.True(t, strings.Contains(body, "404 Not Found"))
+	assert.False(t, strings.Contains(body, external.URL+"/ok<"))
+	assert.False(t, strings.Contains(body, external.URL+"/no-head<"))
+	assert.True(t, strings.Contains(body, "External links were last checked"))
+}
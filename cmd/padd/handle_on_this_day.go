@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/web"
+)
+
+const (
+	onThisDayWidgetLimit   = 5   // Entries listed on the home page; the rest are on /on-this-day
+	onThisDayExcerptLength = 140 // Characters of each entry's excerpt on the home page
+)
+
+// handleOnThisDay shows the daily and journal entries from the same day of the month in earlier months and
+// years on one page. The optional "date" query parameter (YYYY-MM-DD) recalls another day instead of today.
+func (s *Server) handleOnThisDay(w http.ResponseWriter, r *http.Request) {
+	date := time.Now()
+	if dateParam := r.URL.Query().Get("date"); dateParam != "" {
+		parsed, err := time.Parse("2006-01-02", dateParam)
+		if err != nil {
+			s.showPageNotFound(w, r)
+			return
+		}
+		date = parsed
+	}
+
+	entries, err := s.fileRepo.OnThisDay(date)
+	if err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to find entries on this day: %w", err))
+		return
+	}
+
+	s.showAssembledPage(w, r, onThisDayMarkdown(date, entries), "on-this-day", "on-this-day", s.fileRepo.Config().JournalDirectory)
+}
+
+// onThisDayMarkdown assembles the entries from earlier months into one markdown document, with each day
+// header naming the directory it came from
+func onThisDayMarkdown(date time.Time, entries []files.DayEntry) string {
+	var sb strings.Builder
+	sb.WriteString("# On This Day\n\n")
+	if len(entries) == 0 {
+		fmt.Fprintf(&sb, "Nothing from the %s of earlier months yet.\n", ordinal(date.Day()))
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "Entries from the %s of earlier months and years.\n", ordinal(date.Day()))
+	for _, entry := range entries {
+		sb.WriteString("\n")
+		fmt.Fprintf(&sb, "%s (%s)\n", entry.Lines[0], contentutil.TitleCase(entry.Type))
+		sb.WriteString(strings.Join(entry.Lines[1:], "\n"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// onThisDayWidget lists the most recent entries from the same day of earlier months for the home page. Errors
+// are logged rather than shown, since the list is an extra on a page that works without it.
+func (s *Server) onThisDayWidget(date time.Time) []web.OnThisDayEntry {
+	entries, err := s.fileRepo.OnThisDay(date)
+	if err != nil {
+		s.logger.Warn("Error finding entries on this day", "error", err)
+		return nil
+	}
+
+	var widget []web.OnThisDayEntry
+	for _, entry := range entries[:min(len(entries), onThisDayWidgetLimit)] {
+		link := "/" + entry.FileID
+		if entry.Anchor != "" {
+			link += "#" + entry.Anchor
+		}
+		widget = append(widget, web.OnThisDayEntry{
+			Title:   fmt.Sprintf("%s · %s", entry.Date.Format("January 2, 2006"), contentutil.TitleCase(entry.Type)),
+			Link:    link,
+			Excerpt: entry.Excerpt(onThisDayExcerptLength),
+		})
+	}
+
+	return widget
+}
+
+// ordinal formats a day of the month as an ordinal number (e.g., 1st, 2nd, 11th, 23rd)
+func ordinal(day int) string {
+	suffix := "th"
+	switch {
+	case day%100 >= 11 && day%100 <= 13:
+	case day%10 == 1:
+		suffix = "st"
+	case day%10 == 2:
+		suffix = "nd"
+	case day%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", day, suffix)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleOnThisDay(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.MkdirAll("journal/2023", 0755))
+	assert.Nil(t, server.rootManager.WriteString("journal/2023/09-september.md", "# September 2023\n\n## Friday, September 15, 2023\n\nMoved into the new flat.\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/on-this-day?date=2025-09-15", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "Entries from the 15th of earlier months and years."))
+	assert.True(t, strings.Contains(body, "Moved into the new flat."))
+
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/on-this-day?date=2025-09-14", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Nothing from the 14th of earlier months yet."))
+}
+
+func TestHandleView_OnThisDayWidget(t *testing.T) {
+	server := newTestServer(t)
+
+	// Four years back keeps the same day, even on February 29
+	now := time.Now()
+	date := time.Date(now.Year()-4, now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	info, _ := server.fileRepo.TemporalFileInfo("daily", date)
+	assert.Nil(t, server.rootManager.MkdirAll(path.Dir(info.Path), 0755))
+	assert.Nil(t, server.rootManager.WriteString(info.Path, "## "+date.Format("Monday, January 2, 2006")+"\n\nBought a bike.\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "On this day"))
+	assert.True(t, strings.Contains(body, "Bought a bike."))
+	assert.True(t, strings.Contains(body, `href="/`+info.ID+"#"+strings.ToLower(date.Format("Monday-January-2-2006"))+`"`))
+
+	// Other pages don't recall anything
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/active", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.False(t, strings.Contains(rec.Body.String(), "Bought a bike."))
+}
+
+func TestOrdinal(t *testing.T) {
+	for day, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 22: "22nd", 31: "31st"} {
+		assert.Equal(t, ordinal(day), want)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxPreviewSize limits the size of markdown accepted by the preview endpoint
+const maxPreviewSize = 1 << 20 // 1 MB
+
+// handlePreview renders posted markdown to HTML without saving it.
+//
+// The markdown can be sent as the "content" form field or as the raw request body. The response is the
+// sanitized HTML fragment, with wiki links resolved against the current repository.
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxPreviewSize)
+
+	var content string
+	contentType := r.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") || strings.HasPrefix(contentType, "multipart/form-data") {
+		content = r.FormValue("content")
+	} else {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		content = string(body)
+	}
+
+	rendered := s.renderer.Render(content)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if rendered.Title != "" {
+		_, _ = io.WriteString(w, "<h1>"+template.HTMLEscapeString(rendered.Title)+"</h1>\n")
+	}
+	_, _ = io.WriteString(w, string(rendered.HTML))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandlePreview_ResolvesWikiLinks(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/project-ideas.md", "# Project Ideas\n"))
+	server.fileRepo.ReloadCaches()
+
+	form := url.Values{"content": {"# Draft\n\nSee [[project-ideas]] for **more**."}}
+	req := httptest.NewRequest(http.MethodPost, "/api/preview", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `<h1>Draft</h1>`))
+	assert.True(t, strings.Contains(body, `<a href="/resources/project-ideas"`))
+	assert.True(t, strings.Contains(body, `<strong>more</strong>`))
+}
+
+func TestHandlePreview_RawBodyIsSanitized(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/preview", strings.NewReader("Hello <script>alert(1)</script>"))
+	req.Header.Set("Content-Type", "text/markdown")
+	rec := httptest.NewRecorder()
+
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Hello"))
+	assert.False(t, strings.Contains(rec.Body.String(), "<script>"))
+}
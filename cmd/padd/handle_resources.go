@@ -2,31 +2,31 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/patrickward/padd/internal/files"
 	"github.com/patrickward/padd/internal/web"
 )
 
 // handleResources shows a list of available resource files
 func (s *Server) handleResources(w http.ResponseWriter, r *http.Request) {
-	tree := s.fileRepo.DirectoryTreeFor(s.fileRepo.Config().ResourcesDirectory)
+	tree := s.listingTree(r, s.fileRepo.Config().ResourcesDirectory)
 
 	data := web.PageData{
 		Title:         "Resources",
 		NavMenuFiles:  s.navigationMenu(r.URL.Path),
 		IsResources:   true,
 		DirectoryTree: tree,
+		NoteTemplates: s.fileRepo.NoteTemplates(),
 	}
 
-	// Check for a flash message
-	if flash := s.flashManager.Get(w, r); flash != nil {
-		data.FlashMessage = flash.Message
-		data.FlashMessageType = flash.Type
-	}
+	// Check for flash messages
+	data.Flashes = s.flashManager.Get(w, r)
 
 	if err := s.executePage(w, "resources.html", data); err != nil {
 		s.showServerError(w, r, err)
@@ -36,14 +36,14 @@ func (s *Server) handleResources(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleCreateResource(w http.ResponseWriter, r *http.Request) {
 	fileName := strings.TrimSpace(r.FormValue("filename"))
 	if fileName == "" {
-		s.flashManager.SetError(w, "Filename cannot be empty")
+		s.flashManager.SetError(w, r, "Filename cannot be empty")
 		s.redirectTo(w, r, "/resources")
 		return
 	}
 
 	// Validate filename contains only allowed characters
 	if !filenameIsValid(fileName) {
-		s.flashManager.SetError(w, "Filename must contain only letters, numbers, dashes, periods, underscores, and forward slashes")
+		s.flashManager.SetError(w, r, "Filename must contain only letters, numbers, dashes, periods, underscores, and forward slashes")
 		s.redirectTo(w, r, "/resources")
 		return
 	}
@@ -54,13 +54,13 @@ func (s *Server) handleCreateResource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Construct full path within resources directory
-	fullPath := filepath.Join(resourcesDir, fileName)
+	fullPath := filepath.Join(s.fileRepo.Config().ResourcesDirectory, fileName)
 
 	// Create directories if the filename contains path separators
 	if strings.Contains(fileName, "/") {
 		dir := filepath.Dir(fullPath)
 		if err := s.rootManager.MkdirAll(dir, 0755); err != nil {
-			s.flashManager.SetError(w, "Failed to create directories")
+			s.flashManager.SetError(w, r, "Failed to create directories")
 			s.redirectTo(w, r, "/resources")
 			return
 		}
@@ -68,36 +68,153 @@ func (s *Server) handleCreateResource(w http.ResponseWriter, r *http.Request) {
 
 	// Check if file already exists
 	if s.rootManager.FileExists(fullPath) {
-		s.flashManager.SetError(w, "File already exists")
+		s.flashManager.SetError(w, r, "File already exists")
 		s.redirectTo(w, r, "/resources")
 		return
 	}
 
-	// Create the new file with default content
-	defaultContent := fmt.Sprintf("---\ncreated_at: %s\n---\n",
-		time.Now().Format("2006-01-02 15:04:05"))
+	// Seed the file from the chosen template, if any
+	if templateName := r.FormValue("template"); templateName != "" {
+		doc, err := s.fileRepo.GetOrCreateResourceDocument(fileName, templateName, splitList(r.FormValue("tags")))
+		if err != nil {
+			if errors.Is(err, files.ErrTemplateNotFound) {
+				s.flashManager.SetError(w, r, "Template not found")
+			} else {
+				s.logger.Warn("Error creating file from template", "template", templateName, "error", err)
+				s.flashManager.SetError(w, r, "Failed to create file from template")
+			}
+			s.redirectTo(w, r, "/resources")
+			return
+		}
+
+		s.flashManager.SetSuccess(w, r, "File created successfully")
+		s.redirectTo(w, r, "/"+doc.Info.ID)
+		return
+	}
 
-	if err := s.rootManager.WriteString(fullPath, defaultContent); err != nil {
-		s.flashManager.SetError(w, "Failed to create file")
+	// Create the new file, which gets its default frontmatter from the file repository
+	if err := s.fileRepo.CreateFile(fullPath, ""); err != nil {
+		s.flashManager.SetError(w, r, "Failed to create file")
 		s.redirectTo(w, r, "/resources")
 		return
 	}
 
+	if s.fileRepo.EncryptsByDefault(fullPath) && !s.fileRepo.CanEncrypt() {
+		s.flashManager.Set(w, r, "warning", "Encryption keys aren't loaded, so this file was created unencrypted")
+	}
+
 	// Refresh the resource cache
 	s.fileRepo.ReloadResources()
 
 	// Redirect to the new file
-	fileID := "resources/" + s.fileRepo.CreateID(fileName)
-	s.flashManager.SetSuccess(w, "File created successfully")
+	fileID := s.fileRepo.CreateID(fullPath)
+	s.flashManager.SetSuccess(w, r, "File created successfully")
 	s.redirectTo(w, r, "/"+fileID)
 }
 
+// handleMoveResource renames or moves a resource file to the ID in the "new_id" form value, rewriting the
+// links that point at it
+func (s *Server) handleMoveResource(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	newID := strings.TrimSpace(r.FormValue("new_id"))
+	if newID == "" || !filenameIsValid(newID) {
+		s.flashManager.SetError(w, r, "New name must contain only letters, numbers, dashes, periods, underscores, and forward slashes")
+		s.redirectTo(w, r, "/"+id)
+		return
+	}
+
+	doc, locked, err := s.fileRepo.MoveDocument(id, newID)
+	if err != nil {
+		s.flashManager.SetError(w, r, fmt.Sprintf("Failed to move file: %v", err))
+		s.redirectTo(w, r, "/"+id)
+		return
+	}
+
+	if len(locked) > 0 {
+		s.flashManager.Set(w, r, "warning", fmt.Sprintf("File moved, but links in these locked documents weren't updated: %s", strings.Join(locked, ", ")))
+		s.redirectTo(w, r, "/"+doc.Info.ID)
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, "File moved successfully")
+	s.redirectTo(w, r, "/"+doc.Info.ID)
+}
+
+// handleDeleteResource permanently deletes a file, or a directory that holds no files, and redirects to its
+// parent directory
+func (s *Server) handleDeleteResource(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var err error
+	if s.fileRepo.FileIDExists(id) {
+		err = s.fileRepo.DeleteDocument(id)
+	} else {
+		err = s.fileRepo.DeleteDirectory(id)
+	}
+
+	if err != nil {
+		s.flashManager.SetError(w, r, fmt.Sprintf("Failed to delete: %v", err))
+		if _, err := s.fileRepo.FileInfo(id); err == nil {
+			s.redirectTo(w, r, "/"+id)
+		} else {
+			s.redirectTo(w, r, "/resources")
+		}
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, "Deleted successfully")
+	s.redirectTo(w, r, s.parentDirectoryURL(id))
+}
+
+// handleArchiveResource moves a file into the archive and redirects to its parent directory
+func (s *Server) handleArchiveResource(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := s.fileRepo.ArchiveDocument(id); err != nil {
+		s.flashManager.SetError(w, r, fmt.Sprintf("Failed to archive file: %v", err))
+		s.redirectTo(w, r, "/"+id)
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, "File archived successfully")
+	s.redirectTo(w, r, s.parentDirectoryURL(id))
+}
+
+// parentDirectoryURL returns the URL of the directory holding id, falling back to the resources page when
+// that directory no longer has any files to list
+func (s *Server) parentDirectoryURL(id string) string {
+	parent := path.Dir(id)
+	if parent == "." {
+		return "/resources"
+	}
+	if _, err := s.fileRepo.FileInfo(parent); err != nil {
+		return "/resources"
+	}
+	return "/" + parent
+}
+
 // handleRefreshResources refreshes the resource file cache and redirects back to the resources page
 func (s *Server) handleRefreshResources(w http.ResponseWriter, r *http.Request) {
 	s.fileRepo.ReloadResources()
 	s.redirectTo(w, r, "/resources")
 }
 
+// listingTree returns the directory tree to list for a directory. Draft files are left out
+// unless the request opts in with the "drafts=1" query parameter.
+func (s *Server) listingTree(r *http.Request, directory string) *files.DirectoryNode {
+	tree := s.fileRepo.DirectoryTreeFor(directory)
+	if includeDrafts(r) {
+		return tree
+	}
+
+	return tree.WithoutDrafts()
+}
+
+// includeDrafts returns true if the request opts in to showing draft files
+func includeDrafts(r *http.Request) bool {
+	return r.URL.Query().Get("drafts") == "1"
+}
+
 // filenameIsValid checks if a filename contains only allowed characters
 func filenameIsValid(fileName string) bool {
 	for _, r := range fileName {
@@ -112,10 +229,10 @@ func filenameIsValid(fileName string) bool {
 }
 
 // handleResourcesAPI serves a JSON list of available resource files in their hierarchical structure
-func (s *Server) handleResourcesAPI(w http.ResponseWriter, _ *http.Request) {
+func (s *Server) handleResourcesAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	tree := s.fileRepo.DirectoryTreeFor(s.fileRepo.Config().ResourcesDirectory)
+	tree := s.listingTree(r, s.fileRepo.Config().ResourcesDirectory)
 
 	if err := json.NewEncoder(w).Encode(tree); err != nil {
 		s.showServerError(w, nil, err)
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+func TestHandleCreateResource_EncryptedDirectory(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, WithEncryptedDirectories([]string{"resources/private"})(server))
+
+	rec := postEntry(t, server, "/resources", url.Values{"filename": {"private/plan"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/private/plan")
+
+	raw, err := server.rootManager.ReadFile("resources/private/plan.md")
+	assert.Nil(t, err)
+	value, ok := contentutil.FrontmatterValue(contentutil.SplitLines(string(raw)), "encrypted")
+	assert.True(t, ok)
+	assert.Equal(t, value, "true")
+
+	// No keys are loaded, so the user is warned that the file is plaintext
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	flashes, err := url.QueryUnescape(cookies[0].Value)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(flashes, `"type":"warning"`))
+}
+
+func TestHandleCreateResource_PlainDirectory(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, WithEncryptedDirectories([]string{"resources/private"})(server))
+
+	rec := postEntry(t, server, "/resources", url.Values{"filename": {"notes"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	raw, err := server.rootManager.ReadFile("resources/notes.md")
+	assert.Nil(t, err)
+	_, ok := contentutil.FrontmatterValue(contentutil.SplitLines(string(raw)), "encrypted")
+	assert.False(t, ok)
+	_, ok = contentutil.FrontmatterValue(contentutil.SplitLines(string(raw)), "created_at")
+	assert.True(t, ok)
+
+	flashes, err := url.QueryUnescape(rec.Result().Cookies()[0].Value)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(flashes, `"type":"warning"`))
+}
+
+func TestHandleCreateResource_Template(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.MkdirAll("templates", 0755))
+	assert.Nil(t, server.rootManager.WriteString("templates/meeting.md", "---\ntags: [{{range $i, $tag := .Tags}}{{if $i}}, {{end}}{{$tag}}{{end}}]\n---\n\n# {{.Title}}\n\n## Agenda\n"))
+	assert.Nil(t, server.rootManager.WriteString("templates/daily.md", "### Plan\n"))
+
+	// Day templates aren't offered for new resources
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resources", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `<option value="meeting">meeting</option>`))
+	assert.False(t, strings.Contains(rec.Body.String(), `<option value="daily">`))
+
+	rec = postEntry(t, server, "/resources", url.Values{"filename": {"meetings/kickoff"}, "template": {"meeting"}, "tags": {"work, planning"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/meetings/kickoff")
+
+	raw, err := server.rootManager.ReadFile("resources/meetings/kickoff.md")
+	assert.Nil(t, err)
+	lines := contentutil.SplitLines(string(raw))
+	tags, ok := contentutil.FrontmatterValue(lines, "tags")
+	assert.True(t, ok)
+	assert.Equal(t, tags, "[work, planning]")
+	_, ok = contentutil.FrontmatterValue(lines, "created_at")
+	assert.True(t, ok)
+	assert.True(t, strings.Contains(string(raw), "# Kickoff\n\n## Agenda\n"))
+
+	rec = postEntry(t, server, "/resources", url.Values{"filename": {"standup"}, "template": {"daily"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources")
+	assert.False(t, server.rootManager.FileExists("resources/standup.md"))
+}
+
+func TestHandleMoveResource(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n"))
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\nSee [[notes]]\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := postEntry(t, server, "/move/resources/notes", url.Values{"new_id": {"archive/old-notes"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/archive/old-notes")
+
+	assert.True(t, server.fileRepo.FileIDExists("resources/archive/old-notes"))
+	raw, err := server.rootManager.ReadFile("active.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Active\n\nSee [[archive/old-notes]]\n")
+}
+
+func TestHandleMoveResource_LockedLinks(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n"))
+	assert.Nil(t, server.rootManager.WriteString("active.md", "---\nlocked: true\n---\n# Active\n\nSee [[notes]]\n"))
+	server.fileRepo.ReloadCaches()
+
+	// The move goes ahead, and the locked document is left alone
+	rec := postEntry(t, server, "/move/resources/notes", url.Values{"new_id": {"archive/old-notes"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/archive/old-notes")
+
+	raw, err := server.rootManager.ReadFile("active.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "---\nlocked: true\n---\n# Active\n\nSee [[notes]]\n")
+}
+
+func TestHandleMoveResource_Invalid(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n"))
+	server.fileRepo.ReloadCaches()
+
+	tests := []struct {
+		name  string
+		id    string
+		newID string
+	}{
+		{name: "invalid characters", id: "resources/notes", newID: "my notes!"},
+		{name: "core file", id: "inbox", newID: "inbox-copy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postEntry(t, server, "/move/"+tt.id, url.Values{"new_id": {tt.newID}}, false)
+			assert.Equal(t, rec.Code, http.StatusFound)
+			assert.Equal(t, rec.Header().Get("Location"), "/"+tt.id)
+		})
+	}
+
+	assert.True(t, server.rootManager.FileExists("resources/notes.md"))
+}
+
+func TestHandleDeleteResource(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.MkdirAll("resources/projects", 0755))
+	assert.Nil(t, server.rootManager.WriteString("resources/projects/plan.md", "# Plan\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/projects/notes.md", "# Notes\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := postEntry(t, server, "/delete/resources/projects/plan", url.Values{}, true)
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get("HX-Redirect"), "/resources/projects")
+	assert.False(t, server.rootManager.FileExists("resources/projects/plan.md"))
+	assert.False(t, server.fileRepo.FileIDExists("resources/projects/plan"))
+
+	// The directory still holds a file
+	rec = postEntry(t, server, "/delete/resources/projects", url.Values{}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/projects")
+	assert.True(t, server.rootManager.FileExists("resources/projects/notes.md"))
+
+	// Deleting the last file leaves an empty directory that isn't listed, so the redirect falls back
+	rec = postEntry(t, server, "/delete/resources/projects/notes", url.Values{}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources")
+
+	rec = postEntry(t, server, "/delete/resources/projects", url.Values{}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources")
+	_, err := server.rootManager.Stat("resources/projects")
+	assert.NotNil(t, err)
+}
+
+func TestHandleDeleteResource_CoreFile(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := postEntry(t, server, "/delete/inbox", url.Values{}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/inbox")
+	assert.True(t, server.rootManager.FileExists("inbox.md"))
+}
+
+func TestHandleArchiveResource(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := postEntry(t, server, "/archive/resources/notes", url.Values{}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources")
+	assert.False(t, server.fileRepo.FileIDExists("resources/notes"))
+	assert.True(t, server.rootManager.FileExists("_archive/resources/notes.md"))
+
+	rec = postEntry(t, server, "/archive/inbox", url.Values{}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/inbox")
+	assert.True(t, server.rootManager.FileExists("inbox.md"))
+}
@@ -1,9 +1,21 @@
 package main
 
 import (
+	"errors"
 	"net/http"
+
+	"github.com/patrickward/padd/internal/contentutil"
+	"github.com/patrickward/padd/internal/files"
 )
 
+// handleSave saves the submitted content for a document.
+//
+// If the form includes a "base_hash" field, the save only succeeds if the document is unchanged on disk since
+// it was loaded. On a conflict, a 409 snippet shows how the latest version differs from the submitted content
+// and lets the user overwrite (the "overwrite" form field) or save a manual merge against the latest version.
+//
+// Autosaves (the "autosave" form field) don't redirect. They respond with a status snippet that also
+// updates the editor's base hash, so the next save is checked against the content just written.
 func (s *Server) handleSave(w http.ResponseWriter, r *http.Request) {
 	doc, err := s.fileRepo.GetDocument(r.PathValue("id"))
 	if err != nil {
@@ -12,11 +24,88 @@ func (s *Server) handleSave(w http.ResponseWriter, r *http.Request) {
 	}
 
 	content := r.FormValue("content")
-	if err = doc.Save(content); err != nil {
+	baseHash := r.FormValue("base_hash")
+	autosave := r.FormValue("autosave") == "true"
+
+	if baseHash != "" && r.FormValue("overwrite") != "true" {
+		err = doc.SaveIfUnchanged(content, baseHash)
+	} else {
+		err = doc.Save(content)
+	}
+
+	if errors.Is(err, files.ErrSaveConflict) {
+		s.showSaveConflict(w, r, doc, content, autosave)
+		return
+	}
+
+	if errors.Is(err, files.ErrDocumentLocked) {
+		if autosave {
+			s.showAutosaveStatus(w, r, doc, "This document is locked and can't be edited")
+			return
+		}
+		s.flashManager.SetError(w, r, "This document is locked and can't be edited")
+		s.redirectTo(w, r, "/"+doc.Info.ID)
+		return
+	}
+
+	if err != nil {
 		s.showServerError(w, r, err)
 		return
 	}
 
-	s.flashManager.SetSuccess(w, "File saved successfully")
+	if autosave {
+		s.showAutosaveStatus(w, r, doc, "Saved")
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, "File saved successfully")
 	s.redirectTo(w, r, "/"+doc.Info.ID)
 }
+
+// showSaveConflict sends a 409 snippet with a line diff from the submitted content to the latest version,
+// offering to overwrite the latest version or to save the editor's content as a merge of both.
+func (s *Server) showSaveConflict(w http.ResponseWriter, r *http.Request, doc *files.Document, submitted string, autosave bool) {
+	latest, err := doc.Content()
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	latestHash, err := doc.Checksum()
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	// Autosaves target the status line, so send the conflict where a regular save would show it
+	if autosave {
+		w.Header().Set("HX-Retarget", "#system-error")
+		w.Header().Set("HX-Reswap", "outerHTML show:top")
+	}
+
+	w.WriteHeader(http.StatusConflict)
+	if err := s.executeSnippet(w, "save_conflict.html", map[string]any{
+		"ID":         doc.Info.ID,
+		"LatestHash": latestHash,
+		"Diff":       contentutil.DiffLines(submitted, latest),
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// showAutosaveStatus sends the autosave status snippet along with the document's current hash
+func (s *Server) showAutosaveStatus(w http.ResponseWriter, r *http.Request, doc *files.Document, message string) {
+	hash, err := doc.Checksum()
+	if err != nil {
+		s.showServerError(w, r, err)
+		return
+	}
+
+	if err := s.executeSnippet(w, "autosave_status.html", map[string]any{
+		"ID":      doc.Info.ID,
+		"Hash":    hash,
+		"Message": message,
+	}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+// postSave submits the edit form for a document
+func postSave(server *Server, id string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/"+id, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	return rec
+}
+
+// documentHash loads a document and returns its checksum
+func documentHash(t *testing.T, server *Server, id string) string {
+	t.Helper()
+
+	doc, err := server.fileRepo.GetDocument(id)
+	assert.Nil(t, err)
+	hash, err := doc.Checksum()
+	assert.Nil(t, err)
+	return hash
+}
+
+func TestHandleSave_ConflictShowsDiff(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\nshared line\n"))
+	server.fileRepo.ReloadCaches()
+
+	baseHash := documentHash(t, server, "resources/notes")
+
+	// Someone else saves first
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\nshared line\ntheir line\n"))
+
+	rec := postSave(server, "resources/notes", url.Values{
+		"content":   {"# Notes\nshared line\nmy line\n"},
+		"base_hash": {baseHash},
+	})
+
+	assert.Equal(t, rec.Code, http.StatusConflict)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "<del>- my line</del>"))
+	assert.True(t, strings.Contains(body, "<ins>+ their line</ins>"))
+	assert.True(t, strings.Contains(body, documentHash(t, server, "resources/notes")))
+
+	content, err := server.rootManager.ReadFile("resources/notes.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(content), "their line"))
+}
+
+func TestHandleSave_AutosaveReturnsNewHash(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := postSave(server, "resources/notes", url.Values{
+		"content":   {"# Notes\n\nDraft"},
+		"base_hash": {documentHash(t, server, "resources/notes")},
+		"autosave":  {"true"},
+	})
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Location"), "")
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `id="autosave-status"`))
+	assert.True(t, strings.Contains(body, `value="`+documentHash(t, server, "resources/notes")+`"`))
+	assert.True(t, strings.Contains(body, `hx-swap-oob="true"`))
+}
+
+func TestHandleSave_AutosaveConflictRetargets(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := postSave(server, "resources/notes", url.Values{
+		"content":   {"# Notes\n\nDraft"},
+		"base_hash": {"stale"},
+		"autosave":  {"true"},
+	})
+
+	assert.Equal(t, rec.Code, http.StatusConflict)
+	assert.Equal(t, rec.Header().Get("HX-Retarget"), "#system-error")
+}
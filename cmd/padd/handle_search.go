@@ -1,15 +1,20 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 
-	"github.com/patrickward/padd/internal/contentutil"
-	"github.com/patrickward/padd/internal/files"
 	"github.com/patrickward/padd/internal/rendering"
 	"github.com/patrickward/padd/internal/web"
 )
 
+const (
+	defaultSearchMinLength = 2
+	defaultSearchMaxLength = 256
+)
+
 type searchResults map[string][]web.SearchMatch
 
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
@@ -19,25 +24,23 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	results := make(searchResults)
+	// Skip scanning for queries that are too short to be useful or too long to be meaningful
+	if message := s.validateSearchQuery(query); message != "" {
+		data := web.PageData{
+			Title:        "Search Results",
+			IsSearching:  true,
+			SearchQuery:  query,
+			ErrorMessage: message,
+			NavMenuFiles: s.navigationMenu(""),
+		}
 
-	// Search core files
-	for _, file := range s.fileRepo.CoreFiles() {
-		if matches := s.searchFile(file, query); len(matches) > 0 {
-			results[file.ID] = matches
+		if err := s.executePage(w, "search.html", data); err != nil {
+			s.showServerError(w, r, err)
 		}
+		return
 	}
 
-	// Search resource files
-	resourceDir := s.fileRepo.DirectoryTreeFor(s.fileRepo.Config().ResourcesDirectory)
-	s.searchDirectory(query, resourceDir, results)
-
-	// Search temporal files
-	temporalDirectories := s.fileRepo.Config().TemporalDirectories()
-	for _, dir := range temporalDirectories {
-		node := s.fileRepo.DirectoryTreeFor(dir)
-		s.searchDirectory(query, node, results)
-	}
+	results := s.searchFiles(query, includeDrafts(r))
 
 	data := web.PageData{
 		Title:         "Search Results",
@@ -52,43 +55,49 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// searchDirectory recursively searches a directory for matches to a query and adds to the results map
-func (s *Server) searchDirectory(query string, directory *files.DirectoryNode, results searchResults) {
-	for _, file := range directory.Files {
-		if matches := s.searchFile(file, query); len(matches) > 0 {
-			results[file.ID] = matches
-		}
+// validateSearchQuery returns a user-facing message if the query length is outside the configured limits
+func (s *Server) validateSearchQuery(query string) string {
+	length := utf8.RuneCountInString(query)
+	if length < s.searchMinLength {
+		return fmt.Sprintf("Search queries must be at least %d characters long.", s.searchMinLength)
 	}
 
-	for _, child := range directory.Directories {
-		s.searchDirectory(query, child, results)
+	if length > s.searchMaxLength {
+		return fmt.Sprintf("Search queries must be at most %d characters long.", s.searchMaxLength)
 	}
+
+	return ""
 }
 
-func (s *Server) searchFile(file files.FileInfo, query string) []web.SearchMatch {
-	var matches []web.SearchMatch
-	content, err := s.rootManager.ReadFile(file.Path)
-	if err != nil {
-		return matches
-	}
+// searchFiles queries the search index and renders the matching lines of the core, resource, and temporal
+// files. Draft files are skipped unless withDrafts is true.
+func (s *Server) searchFiles(query string, withDrafts bool) searchResults {
+	results := make(searchResults)
+	coreFiles := s.fileRepo.CoreFiles()
 
-	lines := contentutil.SplitLines(string(content))
-	matchIndex := 1 // To track the occurrence of matches in a line
-	queryLower := strings.ToLower(query)
-	for i, line := range lines {
-		if strings.Contains(strings.ToLower(line), queryLower) {
+	for id, lines := range s.fileRepo.Search(query) {
+		info, err := s.fileRepo.FileInfo(id)
+		if err != nil || (info.IsDraft && !withDrafts) {
+			continue
+		}
+
+		if _, isCore := coreFiles[id]; !isCore && !info.IsResource && !info.IsTemporal {
+			continue
+		}
 
-			cleanedLine := rendering.StripMarkdownMarkers(line)
+		matches := make([]web.SearchMatch, 0, len(lines))
+		for i, line := range lines {
+			cleanedLine := rendering.StripMarkdownMarkers(line.Line)
 			renderedContent := s.renderer.Render(cleanedLine)
 			matches = append(matches, web.SearchMatch{
-				LineNum:    i + 1,
-				Line:       line,
+				LineNum:    line.LineNum,
+				Line:       line.Line,
 				Rendered:   renderedContent.HTML,
-				MatchIndex: matchIndex,
+				MatchIndex: i + 1,
 			})
-			matchIndex++
 		}
+		results[id] = matches
 	}
 
-	return matches
+	return results
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleSearch_BelowMinimumLength(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=e", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "Search queries must be at least 2 characters long."))
+
+	// The inbox contains an "e", but no files were scanned
+	assert.False(t, strings.Contains(body, `<h2><a href="/inbox">`))
+}
+
+func TestHandleSearch_AboveMaximumLength(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q="+strings.Repeat("x", defaultSearchMaxLength+1), nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Search queries must be at most 256 characters long."))
+}
+
+func TestHandleSearch_NormalQuery(t *testing.T) {
+	server := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=inbox", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.False(t, strings.Contains(body, "Search queries must be"))
+	assert.True(t, strings.Contains(body, `<h2><a href="/inbox">`))
+}
+
+func TestHandleSearch_Drafts(t *testing.T) {
+	server := newTestServer(t)
+
+	err := server.rootManager.WriteString("resources/secret.md", "---\ndraft: true\n---\n# Secret\n\nThe zebra plan.\n")
+	assert.Nil(t, err)
+	server.fileRepo.ReloadCaches()
+
+	// Hidden from search by default
+	req := httptest.NewRequest(http.MethodGet, "/search?q=zebra", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.False(t, strings.Contains(rec.Body.String(), `<h2><a href="/resources/secret">`))
+
+	// Included when opted in
+	req = httptest.NewRequest(http.MethodGet, "/search?q=zebra&drafts=1", nil)
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `<h2><a href="/resources/secret">`))
+
+	// Still viewable directly, with a draft badge
+	req = httptest.NewRequest(http.MethodGet, "/resources/secret", nil)
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "The zebra plan."))
+	assert.True(t, strings.Contains(body, `<span class="badge neutral muted">Draft</span>`))
+}
+
+func TestHandleSearch_UsesIndexUpdatedOnSave(t *testing.T) {
+	server := newTestServer(t)
+
+	doc, err := server.fileRepo.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Inbox\n\n- [ ] Feed the axolotl\n"))
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=axolotl", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `<h2><a href="/inbox">`))
+}
+
+func TestHandleSearch_ConfiguredLimits(t *testing.T) {
+	server := newTestServer(t, WithSearchQueryLimits(4, 8))
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=inb", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.True(t, strings.Contains(rec.Body.String(), "Search queries must be at least 4 characters long."))
+
+	req = httptest.NewRequest(http.MethodGet, "/search?q=inbox-notes", nil)
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.True(t, strings.Contains(rec.Body.String(), "Search queries must be at most 8 characters long."))
+
+	req = httptest.NewRequest(http.MethodGet, "/search?q=inbox", nil)
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.True(t, strings.Contains(rec.Body.String(), `<h2><a href="/inbox">`))
+}
+
+func TestWithSearchQueryLimits_Invalid(t *testing.T) {
+	server := newTestServer(t)
+
+	assert.NotNil(t, WithSearchQueryLimits(0, 10)(server))
+	assert.NotNil(t, WithSearchQueryLimits(5, 4)(server))
+	assert.Equal(t, server.searchMinLength, defaultSearchMinLength)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/patrickward/padd/internal/web"
+)
+
+// handleSettings shows the settings page: the backup schedule with the outcome of the most recent backup, and
+// links to the maintenance tools
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	data := web.PageData{
+		Title:        "Settings",
+		NavMenuFiles: s.navigationMenu(r.URL.Path),
+		Flashes:      s.flashManager.Get(w, r),
+	}
+
+	if s.backups != nil {
+		last := s.backups.status()
+		data.Backups = &web.BackupData{
+			Dir:       s.backups.dir,
+			Interval:  s.backups.interval,
+			Keep:      s.backups.keep,
+			LastTime:  last.Time,
+			LastPath:  last.Path,
+			LastFiles: last.Files,
+			LastError: last.Error,
+		}
+	}
+
+	if err := s.executePage(w, "settings.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleBackupNow takes a backup of the data directory right away
+func (s *Server) handleBackupNow(w http.ResponseWriter, r *http.Request) {
+	if s.backups == nil {
+		s.flashManager.SetError(w, r, "Backups are off; start the server with -backup-dir to turn them on")
+		s.redirectTo(w, r, "/settings")
+		return
+	}
+
+	status := s.backups.backup(s.rootManager)
+	if status.Error != "" {
+		s.flashManager.SetError(w, r, fmt.Sprintf("Backup failed: %s", status.Error))
+	} else {
+		s.flashManager.SetSuccess(w, r, fmt.Sprintf("Backed up %d files to %s", status.Files, filepath.Base(status.Path)))
+	}
+	s.redirectTo(w, r, "/settings")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// handleTableCellEdit renders a form to edit a cell of a markdown table.
+func (s *Server) handleTableCellEdit(w http.ResponseWriter, r *http.Request) {
+	s.showTableCell(w, r, "table_cell_edit")
+}
+
+// handleTableCellShow renders a cell of a markdown table for display.
+func (s *Server) handleTableCellShow(w http.ResponseWriter, r *http.Request) {
+	s.showTableCell(w, r, "table_cell_show")
+}
+
+// handleTableCellUpdate updates a cell of a markdown table with the "value" form value.
+func (s *Server) handleTableCellUpdate(w http.ResponseWriter, r *http.Request) {
+	doc, cell, done := s.tableCellFromRequest(w, r)
+	if done {
+		return
+	}
+
+	if err := doc.UpdateCell(cell.Table, cell.Row, cell.Col, r.FormValue("value")); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.showTableCell(w, r, "table_cell_show")
+}
+
+// tableCell identifies a cell of a markdown table by position
+type tableCell struct {
+	Table int
+	Row   int
+	Col   int
+}
+
+// showTableCell renders a table cell snippet with the cell's current value
+func (s *Server) showTableCell(w http.ResponseWriter, r *http.Request, snippet string) {
+	doc, cell, done := s.tableCellFromRequest(w, r)
+	if done {
+		return
+	}
+
+	value, err := doc.GetCell(cell.Table, cell.Row, cell.Col)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.executeSnippet(w, snippet, map[string]any{
+		"Table": cell.Table,
+		"Row":   cell.Row,
+		"Col":   cell.Col,
+		"Value": value,
+	}); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// tableCellFromRequest returns the table document and the cell position from the request. Like the task
+// endpoints, the file ID comes from the X-PADD-File-ID header.
+func (s *Server) tableCellFromRequest(w http.ResponseWriter, r *http.Request) (*files.TableDocument, tableCell, bool) {
+	fileID := r.Header.Get("X-PADD-File-ID")
+	if fileID == "" {
+		http.Error(w, "Missing file ID", http.StatusBadRequest)
+		return nil, tableCell{}, true
+	}
+
+	doc, err := s.fileRepo.GetDocument(fileID)
+	if err != nil || doc.Info.IsCSV() {
+		http.Error(w, "Invalid file", http.StatusBadRequest)
+		return nil, tableCell{}, true
+	}
+
+	var cell tableCell
+	var ok bool
+	if cell.Table, ok = pathIndex(w, r, "table"); !ok {
+		return nil, tableCell{}, true
+	}
+	if cell.Row, ok = pathIndex(w, r, "row"); !ok {
+		return nil, tableCell{}, true
+	}
+	if cell.Col, ok = pathIndex(w, r, "col"); !ok {
+		return nil, tableCell{}, true
+	}
+
+	return files.NewTableDocument(doc), cell, false
+}
+
+// pathIndex reads a zero-based index from a path value, sending a bad request response if it isn't one
+func pathIndex(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	n, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || n < 0 {
+		http.Error(w, "Invalid "+name+" parameter", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return n, true
+}
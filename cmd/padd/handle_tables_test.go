@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleTableCell(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n| Item | Qty |\n|------|-----|\n| Milk | 2 |\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/tables/edit/0/1/1", nil)
+	req.Header.Set("X-PADD-File-ID", "active")
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `hx-patch="/tables/0/1/1"`))
+	assert.True(t, strings.Contains(rec.Body.String(), `value="2"`))
+
+	form := url.Values{"value": {"3"}}
+	req = httptest.NewRequest(http.MethodPatch, "/tables/0/1/1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-PADD-File-ID", "active")
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `id="table-0-cell-1-1"`))
+
+	doc, err := server.fileRepo.GetDocument("active")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "| Milk | 3 |"))
+
+	for _, path := range []string{"/tables/edit/0/5/0", "/tables/edit/0/-1/0", "/tables/edit/1/0/0"} {
+		req = httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-PADD-File-ID", "active")
+		rec = httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -126,11 +128,74 @@ func (s *Server) handleTaskDelete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// TaskMoveResponse is returned to non-htmx callers, such as drag and drop scripts, after a task is moved
+type TaskMoveResponse struct {
+	Target  string `json:"target"`
+	Section string `json:"section,omitempty"`
+}
+
+// handleTaskMove moves a task item, with its nested lines, to a section of another file (or another section
+// of the same file). The target file ID and section come from the "target" and "section" form values. When
+// the section is missing, the target may be given as "id#Section", which is what the edit form's prompt
+// sends in the HX-Prompt header.
+func (s *Server) handleTaskMove(w http.ResponseWriter, r *http.Request) {
+	doc, checkboxID, done := s.taskDocumentFromRequest(w, r)
+	if done {
+		return
+	}
+
+	targetID := strings.TrimSpace(r.FormValue("target"))
+	if targetID == "" {
+		targetID = strings.TrimSpace(r.Header.Get("HX-Prompt"))
+	}
+
+	section := strings.TrimSpace(r.FormValue("section"))
+	if section == "" {
+		targetID, section, _ = strings.Cut(targetID, "#")
+		targetID = strings.TrimSpace(targetID)
+		section = strings.TrimSpace(section)
+	}
+
+	if targetID == "" {
+		http.Error(w, "Missing target parameter", http.StatusBadRequest)
+		return
+	}
+
+	target, err := s.fileRepo.GetDocument(targetID)
+	if err != nil {
+		http.Error(w, "Invalid target file", http.StatusNotFound)
+		return
+	}
+
+	if err := doc.MoveTask(checkboxID, target, section); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, files.ErrDocumentLocked) {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	if !isHXRequest(r) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(TaskMoveResponse{Target: target.Info.ID, Section: section}); err != nil {
+			s.showServerError(w, r, err)
+		}
+		return
+	}
+
+	s.flashManager.SetSuccess(w, r, "Moved task to "+target.Info.TitleBase+".")
+
+	// Task IDs shift in both files, so refresh the page
+	w.Header().Set("HX-Refresh", "true")
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // handleArchiveDoneTasks archives all completed tasks from a specified file to their respective daily files.
 func (s *Server) handleArchiveDoneTasks(w http.ResponseWriter, r *http.Request) {
 	fileID := r.PathValue("id")
 	if fileID == "" {
-		s.flashManager.SetError(w, "File ID is required.")
+		s.flashManager.SetError(w, r, "File ID is required.")
 		w.Header().Set("HX-Redirect", r.Header.Get("Referer"))
 		w.WriteHeader(http.StatusSeeOther)
 		return
@@ -138,7 +203,7 @@ func (s *Server) handleArchiveDoneTasks(w http.ResponseWriter, r *http.Request)
 
 	doc, err := s.fileRepo.GetDocument(fileID)
 	if err != nil {
-		s.flashManager.SetError(w, "Invalid file.")
+		s.flashManager.SetError(w, r, "Invalid file.")
 		w.Header().Set("HX-Redirect", r.Header.Get("Referer"))
 		w.WriteHeader(http.StatusSeeOther)
 		return
@@ -146,7 +211,7 @@ func (s *Server) handleArchiveDoneTasks(w http.ResponseWriter, r *http.Request)
 
 	// Skip temporal files
 	if s.fileRepo.FileIsTemporal(doc.Info.Path) {
-		s.flashManager.SetError(w, "Cannot archive tasks from temporal files.")
+		s.flashManager.SetError(w, r, "Cannot archive tasks from temporal files.")
 		w.Header().Set("HX-Redirect", r.Header.Get("Referer"))
 		w.WriteHeader(http.StatusSeeOther)
 		return
@@ -154,23 +219,14 @@ func (s *Server) handleArchiveDoneTasks(w http.ResponseWriter, r *http.Request)
 
 	completedTasks, err := doc.ArchiveCompletedTasks()
 	if err != nil {
-		s.flashManager.SetError(w, "Failed to archive tasks: "+err.Error())
+		s.flashManager.SetError(w, r, "Failed to archive tasks: "+err.Error())
 		w.Header().Set("HX-Redirect", r.Header.Get("Referer"))
 		w.WriteHeader(http.StatusSeeOther)
 		return
 	}
 
 	if len(completedTasks) == 0 {
-		s.flashManager.SetSuccess(w, "No completed tasks to archive.")
-		w.Header().Set("HX-Redirect", r.Header.Get("Referer"))
-		w.WriteHeader(http.StatusSeeOther)
-		return
-	}
-
-	// Add archived tasks to today's daily file
-	dailyDoc, err := s.fileRepo.GetOrCreateTemporalDocument("daily", time.Now())
-	if err != nil {
-		s.flashManager.SetError(w, "Failed to get daily document: "+err.Error())
+		s.flashManager.SetSuccess(w, r, "No completed tasks to archive.")
 		w.Header().Set("HX-Redirect", r.Header.Get("Referer"))
 		w.WriteHeader(http.StatusSeeOther)
 		return
@@ -181,18 +237,20 @@ func (s *Server) handleArchiveDoneTasks(w http.ResponseWriter, r *http.Request)
 
 	config := files.EntryInsertionConfig{
 		Strategy:       files.InsertByTimestamp,
+		EntryTimestamp: time.Now(),
 		EntryFormatter: files.TimestampEntryFormatter,
 	}
 
-	if err := dailyDoc.AddEntry(archivedContent, config); err != nil {
-		s.flashManager.SetError(w, "Failed to add archived tasks to daily file: "+err.Error())
+	// Add archived tasks to today's daily file
+	if _, err := s.fileRepo.AddTemporalEntry(s.fileRepo.Config().DailyDirectory, archivedContent, config); err != nil {
+		s.flashManager.SetError(w, r, "Failed to add archived tasks to daily file: "+err.Error())
 		w.Header().Set("HX-Redirect", r.Header.Get("Referer"))
 		w.WriteHeader(http.StatusSeeOther)
 		return
 	}
 
 	// Set a flash message indicating how many tasks were archived
-	s.flashManager.SetSuccess(w, fmt.Sprintf("Archived %d completed task(s).", len(completedTasks)))
+	s.flashManager.SetSuccess(w, r, fmt.Sprintf("Archived %d completed task(s).", len(completedTasks)))
 
 	// Add the HX-Refresh header to refresh the task list.
 	w.Header().Set("HX-Refresh", "true")
@@ -210,6 +268,7 @@ func (s *Server) taskDocumentFromRequest(w http.ResponseWriter, r *http.Request)
 	doc, err := s.fileRepo.GetDocument(fileID)
 	if err != nil {
 		http.Error(w, "Invalid file", http.StatusBadRequest)
+		return nil, 0, true
 	}
 
 	checkboxIDStr := r.PathValue("id")
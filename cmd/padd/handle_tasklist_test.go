@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleTaskMove(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("inbox.md", "# Inbox\n\n- [ ] Call Sam\n- [ ] Buy milk\n"))
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n## Work\n\n- [ ] Ship release\n"))
+	server.fileRepo.ReloadCaches()
+
+	// Drag and drop callers post the target and section as form values and get JSON back
+	form := url.Values{"target": {"active"}, "section": {"Work"}}
+	req := httptest.NewRequest(http.MethodPost, "/tasks/move/1", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-PADD-File-ID", "inbox")
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	var response TaskMoveResponse
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, response, TaskMoveResponse{Target: "active", Section: "Work"})
+
+	// The edit form's prompt sends "id#Section" in the HX-Prompt header
+	req = httptest.NewRequest(http.MethodPost, "/tasks/move/1", nil)
+	req.Header.Set("X-PADD-File-ID", "inbox")
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Prompt", "active#Errands")
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get("HX-Refresh"), "true")
+
+	active, err := server.fileRepo.GetDocument("active")
+	assert.Nil(t, err)
+	content, err := active.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "## Work\n\n- [ ] Call Sam\n- [ ] Ship release\n"))
+	assert.True(t, strings.Contains(content, "## Errands\n\n- [ ] Buy milk\n"))
+
+	inbox, err := server.fileRepo.GetDocument("inbox")
+	assert.Nil(t, err)
+	content, err = inbox.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Inbox\n")
+}
+
+func TestHandleTaskMove_InvalidTarget(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("inbox.md", "# Inbox\n\n- [ ] Call Sam\n"))
+	server.fileRepo.ReloadCaches()
+
+	for _, target := range []string{"", "missing/file"} {
+		form := url.Values{"target": {target}}
+		req := httptest.NewRequest(http.MethodPost, "/tasks/move/1", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-PADD-File-ID", "inbox")
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+
+		assert.NotEqual(t, rec.Code, http.StatusOK)
+	}
+}
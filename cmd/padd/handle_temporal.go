@@ -1,26 +1,24 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/patrickward/padd/internal/contentutil"
 	"github.com/patrickward/padd/internal/files"
 	"github.com/patrickward/padd/internal/web"
 )
 
-func (s *Server) handleTemporalArchive(w http.ResponseWriter, r *http.Request) {
-	// Get the type from the URL path (e.g., /daily/archive is "daily", /journal/archive is "journal")
-	path := strings.Trim(r.URL.Path, "/")
-	parts := strings.Split(path, "/")
-	if len(parts) != 2 || (parts[0] != "daily" && parts[0] != "journal") || parts[1] != "archive" {
-		s.showPageNotFound(w, r)
-		return
+// handleTemporalArchive lists the files of a temporal directory (e.g., /daily/archive for "daily")
+func (s *Server) handleTemporalArchive(fileType string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.showTemporalArchive(w, r, fileType)
 	}
+}
 
-	fileType := parts[0]
-
-	directoryTree := s.fileRepo.DirectoryTreeFor(fileType)
+func (s *Server) showTemporalArchive(w http.ResponseWriter, r *http.Request, fileType string) {
+	directoryTree := s.listingTree(r, fileType)
 
 	archiveFile := files.FileInfo{
 		ID:        fileType + "-archive",
@@ -38,13 +36,78 @@ func (s *Server) handleTemporalArchive(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check for flash messages
-	if flash := s.flashManager.Get(w, r); flash != nil {
-		data.FlashMessage = flash.Message
-		data.FlashMessageType = flash.Type
-	}
+	data.Flashes = s.flashManager.Get(w, r)
 
 	if err := s.executePage(w, "temporal_archive.html", data); err != nil {
 		s.showServerError(w, r, err)
 		return
 	}
 }
+
+// handleTemporalSummary shows the most recent day sections of a temporal directory on one page. The summary
+// isn't a file, so it gets its own ID; task IDs in the summary don't match any file's tasks.
+func (s *Server) handleTemporalSummary(w http.ResponseWriter, r *http.Request, fileType string) {
+	summary, err := s.fileRepo.TemporalSummary(fileType, s.summaryDays)
+	if err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to build %s summary: %w", fileType, err))
+		return
+	}
+
+	s.showAssembledPage(w, r, summary, fileType+"-summary", fileType+"/summary", fileType)
+}
+
+// handleWeeklyReview shows the daily and journal entries and the completed tasks of the last seven days on
+// one page. The optional "date" query parameter (YYYY-MM-DD) reviews the week ending on that day instead.
+func (s *Server) handleWeeklyReview(w http.ResponseWriter, r *http.Request) {
+	end := time.Now()
+	if dateParam := r.URL.Query().Get("date"); dateParam != "" {
+		parsed, err := time.Parse("2006-01-02", dateParam)
+		if err != nil {
+			s.showPageNotFound(w, r)
+			return
+		}
+		end = parsed
+	}
+
+	review, err := s.fileRepo.WeeklyReview(end)
+	if err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to build weekly review: %w", err))
+		return
+	}
+
+	s.showAssembledPage(w, r, review, "review-weekly", "review/weekly", s.fileRepo.Config().DailyDirectory)
+}
+
+// showAssembledPage renders markdown assembled from several files, such as a summary or review, as a
+// read-only page. The page isn't a file, so it gets its own ID and path; navSection is the navigation menu
+// item shown as active.
+func (s *Server) showAssembledPage(w http.ResponseWriter, r *http.Request, content, id, path, navSection string) {
+	rendered := s.renderer.Render(content)
+
+	pageFile := files.FileInfo{
+		ID:         id,
+		Path:       path,
+		Title:      rendered.Title,
+		TitleBase:  rendered.Title,
+		IsTemporal: true,
+	}
+
+	data := web.PageData{
+		Title:          rendered.Title,
+		SectionHeaders: rendered.SectionHeaders,
+		TasksTotal:     rendered.TasksTotal,
+		TasksCompleted: rendered.TasksCompleted,
+		TasksPending:   rendered.TasksPending,
+		CurrentFile:    pageFile,
+		Content:        rendered.HTML,
+		NavMenuFiles:   s.navigationMenu(navSection),
+	}
+
+	// Check for flash messages
+	data.Flashes = s.flashManager.Get(w, r)
+
+	s.setNoIndex(w)
+	if err := s.executePage(w, "view.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleTemporalRoot_Summary(t *testing.T) {
+	server := newTestServer(t, WithTemporalSummary(7))
+	assert.Nil(t, server.rootManager.MkdirAll("daily/2024", 0755))
+	assert.Nil(t, server.rootManager.WriteString("daily/2024/03-march.md", "# March 2024\n\n## Friday, March 1, 2024\n\n### 10:00:00 AM\n\nShipped the release.\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/daily", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "Shipped the release."))
+}
+
+func TestHandleWeeklyReview(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.MkdirAll("daily/2024", 0755))
+	assert.Nil(t, server.rootManager.MkdirAll("journal/2024", 0755))
+	assert.Nil(t, server.rootManager.WriteString("daily/2024/03-march.md", "# March 2024\n\n## Friday, March 8, 2024\n\n- [x] Shipped the release @done(2024-03-08)\n"))
+	assert.Nil(t, server.rootManager.WriteString("journal/2024/03-march.md", "# March 2024\n\n## Monday, March 4, 2024\n\nStarted the project.\n\n## Friday, March 1, 2024\n\nToo old to include.\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/review/weekly?date=2024-03-08", nil))
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "Weekly Review"))
+	assert.True(t, strings.Contains(body, "Shipped the release"))
+	assert.True(t, strings.Contains(body, "Started the project."))
+	assert.False(t, strings.Contains(body, "Too old to include."))
+
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/review/weekly?date=last-week", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
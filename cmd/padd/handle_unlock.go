@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/web"
+)
+
+// WithLockAfter locks passphrase-protected identities again once they've been unlocked for the given
+// duration. Zero keeps them unlocked until they're locked by hand or the server restarts.
+func WithLockAfter(d time.Duration) ServerOption {
+	return func(s *Server) error {
+		if d < 0 {
+			return fmt.Errorf("lock-after duration cannot be negative")
+		}
+		s.lockAfter = d
+		return nil
+	}
+}
+
+// handleUnlockPage shows the passphrase form for unlocking passphrase-protected identities
+func (s *Server) handleUnlockPage(w http.ResponseWriter, r *http.Request) {
+	next := unlockRedirectPath(r.URL.Query().Get("next"))
+	if !s.fileRepo.EncryptionManager().IsLocked() {
+		s.redirectTo(w, r, next)
+		return
+	}
+
+	data := web.PageData{
+		Title:        "Unlock Encrypted Files",
+		NavMenuFiles: s.navigationMenu(""),
+		UnlockNext:   next,
+		Flashes:      s.flashManager.Get(w, r),
+	}
+
+	if err := s.executePage(w, "unlock.html", data); err != nil {
+		s.showServerError(w, r, err)
+	}
+}
+
+// handleUnlock unlocks the passphrase-protected identities with the "passphrase" form value, so encrypted
+// files can be read and searched, and redirects to the page in the "next" form value
+func (s *Server) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	next := unlockRedirectPath(r.FormValue("next"))
+	manager := s.fileRepo.EncryptionManager()
+
+	if err := manager.Unlock(r.FormValue("passphrase")); err != nil {
+		if errors.Is(err, crypto.ErrIncorrectPassphrase) {
+			s.flashManager.SetError(w, r, "Incorrect passphrase")
+		} else {
+			s.flashManager.SetError(w, r, fmt.Sprintf("Failed to unlock: %v", err))
+		}
+		s.redirectTo(w, r, "/unlock?next="+url.QueryEscape(next))
+		return
+	}
+
+	s.fileRepo.ReloadEncryption()
+	s.scheduleLock()
+
+	s.flashManager.SetSuccess(w, r, "Encrypted files unlocked")
+	s.redirectTo(w, r, next)
+}
+
+// handleLock locks the passphrase-protected identities again
+func (s *Server) handleLock(w http.ResponseWriter, r *http.Request) {
+	s.lock()
+
+	s.flashManager.SetSuccess(w, r, "Encrypted files locked")
+	s.redirectTo(w, r, "/")
+}
+
+// redirectToUnlock sends requests for an encrypted document to the unlock page while the identities that
+// decrypt it are locked. It returns false when there's nothing to unlock.
+func (s *Server) redirectToUnlock(w http.ResponseWriter, r *http.Request, content string) bool {
+	if !crypto.IsAgeEncrypted([]byte(content)) || !s.fileRepo.EncryptionManager().IsLocked() {
+		return false
+	}
+
+	s.flashManager.SetError(w, r, "Unlock encrypted files to view this document")
+	s.redirectTo(w, r, "/unlock?next="+url.QueryEscape(unlockRedirectPath(r.URL.Path)))
+	return true
+}
+
+// encryptionLockState returns "locked" or "unlocked" when the identities are passphrase-protected, and an
+// empty string when there's nothing to unlock
+func (s *Server) encryptionLockState() string {
+	manager := s.fileRepo.EncryptionManager()
+	switch {
+	case !manager.HasPassphraseProtectedIdentities():
+		return ""
+	case manager.IsLocked():
+		return "locked"
+	default:
+		return "unlocked"
+	}
+}
+
+// lock drops the unlocked identities and stops any scheduled lock
+func (s *Server) lock() {
+	s.lockMu.Lock()
+	if s.lockTimer != nil {
+		s.lockTimer.Stop()
+		s.lockTimer = nil
+	}
+	s.lockMu.Unlock()
+
+	s.fileRepo.EncryptionManager().Lock()
+	s.fileRepo.ReloadEncryption()
+}
+
+// scheduleLock locks the identities again after the lock-after duration, restarting the countdown if one is
+// already running
+func (s *Server) scheduleLock() {
+	if s.lockAfter <= 0 {
+		return
+	}
+
+	s.lockMu.Lock()
+	defer s.lockMu.Unlock()
+
+	if s.lockTimer != nil {
+		s.lockTimer.Stop()
+	}
+	s.lockTimer = time.AfterFunc(s.lockAfter, s.lock)
+}
+
+// unlockRedirectPath returns the local path to go to after unlocking, falling back to the home page for
+// anything that could leave the site
+func unlockRedirectPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
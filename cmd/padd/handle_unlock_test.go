@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/crypto"
+)
+
+const testPassphrase = "correct horse battery staple"
+
+// newLockedTestServer returns a server whose identity file is protected by testPassphrase, with an encrypted
+// resource file that reads "The launch codes"
+func newLockedTestServer(t *testing.T, opts ...ServerOption) *Server {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	assert.Nil(t, err)
+
+	// A low work factor keeps the test fast; age -p uses a much higher one
+	recipient, err := age.NewScryptRecipient(testPassphrase)
+	assert.Nil(t, err)
+	recipient.SetWorkFactor(10)
+
+	var identityFile bytes.Buffer
+	writer, err := age.Encrypt(&identityFile, recipient)
+	assert.Nil(t, err)
+	_, err = writer.Write([]byte(identity.String() + "\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+
+	keysDir := t.TempDir()
+	identitiesFile := filepath.Join(keysDir, "key.txt")
+	recipientsFile := filepath.Join(keysDir, "key.pub")
+	assert.Nil(t, os.WriteFile(identitiesFile, identityFile.Bytes(), 0600))
+	assert.Nil(t, os.WriteFile(recipientsFile, []byte(identity.Recipient().String()+"\n"), 0644))
+
+	manager := crypto.NewEncryptionManager()
+	assert.Nil(t, manager.LoadEncryptionKeys(identitiesFile, recipientsFile))
+	assert.True(t, manager.IsLocked())
+
+	server := newTestServer(t, append([]ServerOption{WithEncryptionManager(manager)}, opts...)...)
+	doc, err := server.fileRepo.GetOrCreateResourceDocument("secret", "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("---\nencrypted: true\n---\n# Secret\n\nThe launch codes\n"))
+
+	raw, err := server.rootManager.ReadFile("resources/secret.md")
+	assert.Nil(t, err)
+	assert.True(t, crypto.IsAgeEncrypted(raw))
+
+	return server
+}
+
+func getPage(server *Server, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestHandleUnlock(t *testing.T) {
+	server := newLockedTestServer(t)
+
+	// Encrypted documents send the reader to the unlock page while locked
+	rec := getPage(server, "/resources/secret")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/unlock?next=%2Fresources%2Fsecret")
+
+	rec = getPage(server, "/unlock?next=/resources/secret")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), `name="passphrase"`))
+	assert.True(t, strings.Contains(rec.Body.String(), `value="/resources/secret"`))
+	assert.Equal(t, len(server.fileRepo.Search("launch codes")), 0)
+
+	rec = postEntry(t, server, "/unlock", url.Values{"passphrase": {"wrong"}, "next": {"/resources/secret"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/unlock?next=%2Fresources%2Fsecret")
+	assert.True(t, server.fileRepo.EncryptionManager().IsLocked())
+
+	rec = postEntry(t, server, "/unlock", url.Values{"passphrase": {testPassphrase}, "next": {"/resources/secret"}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/resources/secret")
+	assert.False(t, server.fileRepo.EncryptionManager().IsLocked())
+
+	rec = getPage(server, "/resources/secret")
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.Contains(rec.Body.String(), "The launch codes"))
+	assert.Equal(t, len(server.fileRepo.Search("launch codes")), 1)
+
+	rec = postEntry(t, server, "/lock", url.Values{}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.True(t, server.fileRepo.EncryptionManager().IsLocked())
+	assert.Equal(t, len(server.fileRepo.Search("launch codes")), 0)
+
+	rec = getPage(server, "/edit/resources/secret")
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/unlock?next=%2Fedit%2Fresources%2Fsecret")
+}
+
+func TestHandleUnlock_LockAfter(t *testing.T) {
+	server := newLockedTestServer(t, WithLockAfter(20*time.Millisecond))
+
+	rec := postEntry(t, server, "/unlock", url.Values{"passphrase": {testPassphrase}}, false)
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/")
+	assert.False(t, server.fileRepo.EncryptionManager().IsLocked())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !server.fileRepo.EncryptionManager().IsLocked() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.True(t, server.fileRepo.EncryptionManager().IsLocked())
+}
+
+func TestUnlockRedirectPath(t *testing.T) {
+	tests := []struct {
+		next string
+		want string
+	}{
+		{next: "/resources/secret", want: "/resources/secret"},
+		{next: "", want: "/"},
+		{next: "https://example.com", want: "/"},
+		{next: "//example.com", want: "/"},
+		{next: "/\\example.com", want: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.next, func(t *testing.T) {
+			assert.Equal(t, unlockRedirectPath(tt.next), tt.want)
+		})
+	}
+}
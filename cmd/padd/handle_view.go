@@ -2,10 +2,12 @@ package main
 
 import (
 	"fmt"
+	"mime"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/patrickward/padd/internal/crypto"
 	"github.com/patrickward/padd/internal/files"
 	"github.com/patrickward/padd/internal/rendering"
 	"github.com/patrickward/padd/internal/web"
@@ -23,11 +25,18 @@ func (s *Server) handlePageHeader(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleView(w http.ResponseWriter, r *http.Request) {
+	s.setNoIndex(w)
+
 	data, done := s.processPageView(w, r)
 	if done {
 		return
 	}
 
+	// The home page recalls entries from this day in earlier months
+	if data.CurrentFile.ID == s.homeID() {
+		data.OnThisDay = s.onThisDayWidget(time.Now())
+	}
+
 	if err := s.executePage(w, "view.html", data); err != nil {
 		s.showServerError(w, r, err)
 	}
@@ -35,6 +44,11 @@ func (s *Server) handleView(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleTemporalRoot(path string) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if s.summaryDays > 0 {
+			s.handleTemporalSummary(w, r, path)
+			return
+		}
+
 		doc, err := s.fileRepo.GetOrCreateTemporalDocument(path, time.Now())
 		if err != nil {
 			s.showServerError(w, r, fmt.Errorf("failed to get or create temporal document: %w", err))
@@ -54,7 +68,13 @@ func (s *Server) renderDirectoryView(w http.ResponseWriter, r *http.Request, dat
 func (s *Server) processPageView(w http.ResponseWriter, r *http.Request) (web.PageData, bool) {
 	id := r.PathValue("id")
 	if id == "" {
-		id = "inbox"
+		id = s.homeID()
+	}
+
+	// Metadata sidecars are never shown as raw JSON
+	if files.IsSidecar(id) {
+		s.showPageNotFound(w, r)
+		return web.PageData{}, true
 	}
 
 	doc, err := s.fileRepo.GetDocument(id)
@@ -73,16 +93,25 @@ func (s *Server) processPageView(w http.ResponseWriter, r *http.Request) (web.Pa
 	}
 
 	// Check if this is a CSV file
-	if strings.HasSuffix(strings.ToLower(doc.Info.Path), ".csv") {
+	if doc.Info.IsCSV() {
 		return s.renderCsvView(w, r, doc)
 	}
 
+	if wantsRawMarkdown(r) {
+		s.writeRawMarkdown(w, r, doc)
+		return web.PageData{}, true
+	}
+
 	content, err := doc.Content()
 	if err != nil {
 		s.showServerError(w, r, fmt.Errorf("failed to get document content: %w", err))
 		return web.PageData{}, true
 	}
 
+	if s.redirectToUnlock(w, r, content) {
+		return web.PageData{}, true
+	}
+
 	// Get the search query and match parameters
 	searchQuery := strings.TrimSpace(r.URL.Query().Get("q"))
 	var searchMatch int
@@ -91,13 +120,12 @@ func (s *Server) processPageView(w http.ResponseWriter, r *http.Request) (web.Pa
 	}
 
 	// Render content with search highlighting if needed
-	var renderedContent rendering.RenderedContent
-	if searchQuery != "" {
-		renderedContent = s.renderer.RenderWithHighlight(string(content), searchQuery, searchMatch)
-	} else {
-		//renderedContent = s.renderMarkdown(string(content))
-		renderedContent = s.renderer.Render(string(content))
-	}
+	renderedContent := s.renderer.RenderWithOptions(string(content), rendering.RenderOptions{
+		SearchQuery:  searchQuery,
+		TargetIndex:  searchMatch,
+		EnableSearch: searchQuery != "",
+		DocumentPath: doc.Info.Path,
+	})
 
 	if renderedContent.Title == "" {
 		renderedContent.Title = doc.Info.TitleBase
@@ -115,18 +143,56 @@ func (s *Server) processPageView(w http.ResponseWriter, r *http.Request) (web.Pa
 		NavMenuFiles:   s.navigationMenu(doc.Info.ID),
 		SearchQuery:    searchQuery,
 		SearchMatch:    searchMatch,
+		Backlinks:      doc.Backlinks(),
 	}
 
 	data = s.addMetadataToPageData(data, renderedContent.Metadata)
-
-	// Check for a flash message
-	if flash := s.flashManager.Get(w, r); flash != nil {
-		data.FlashMessage = flash.Message
-		data.FlashMessageType = flash.Type
+	if query := strings.TrimSpace(getMetadataString(renderedContent.Metadata, "query", "")); query != "" {
+		data.SavedSearch = s.runSavedSearch(doc.Info.ID, query)
 	}
+
+	// Check for flash messages
+	data.Flashes = s.flashManager.Get(w, r)
 	return data, false
 }
 
+// wantsRawMarkdown reports whether the request asks for a document's markdown instead of the HTML view,
+// either with a ?raw=1 parameter or an Accept header that lists text/markdown
+func wantsRawMarkdown(r *http.Request) bool {
+	return r.URL.Query().Get("raw") == "1" || acceptsMediaType(r, "text/markdown")
+}
+
+// acceptsMediaType reports whether the request's Accept header lists mediaType
+func acceptsMediaType(r *http.Request, mediaType string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		parsed, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && parsed == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeRawMarkdown responds with the document's decrypted markdown
+func (s *Server) writeRawMarkdown(w http.ResponseWriter, r *http.Request, doc *files.Document) {
+	content, err := doc.Content()
+	if err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to get document content: %w", err))
+		return
+	}
+
+	// Content is still encrypted when no identities are loaded to decrypt it
+	if crypto.IsAgeEncrypted([]byte(content)) {
+		http.Error(w, "Document is encrypted and no identities are loaded to decrypt it", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Vary", "Accept")
+	_, _ = w.Write([]byte(content))
+}
+
 func (s *Server) renderCsvView(w http.ResponseWriter, r *http.Request, doc *files.Document) (web.PageData, bool) {
 	csvDoc := files.NewCSVDocument(doc)
 
@@ -144,9 +210,27 @@ func (s *Server) renderCsvView(w http.ResponseWriter, r *http.Request, doc *file
 		return web.PageData{}, true
 	}
 
-	// Apply sorting if specified in metadata
-	if metadata.SortColumn != "" && len(records) > 1 {
-		records = s.sortCSVRecords(records, metadata)
+	// Pick and order the rows with the query parameters, keeping each record's row in the file so its cells
+	// can be edited. Invalid parameters are reported, and the metadata's sort settings are used instead.
+	var queryError string
+	query, err := csvQueryFromRequest(csvDoc, r)
+	if err != nil {
+		queryError = err.Error()
+		if query, err = csvDoc.DefaultQuery(); err != nil {
+			s.showServerError(w, r, fmt.Errorf("failed to sort CSV records: %w", err))
+			return web.PageData{}, true
+		}
+	}
+
+	rowIndexes, err := csvDoc.Query(query)
+	if err != nil {
+		s.showServerError(w, r, fmt.Errorf("failed to sort CSV records: %w", err))
+		return web.PageData{}, true
+	}
+
+	shown := make([][]string, len(rowIndexes))
+	for i, row := range rowIndexes {
+		shown[i] = records[row]
 	}
 
 	// Determine the title
@@ -165,20 +249,29 @@ func (s *Server) renderCsvView(w http.ResponseWriter, r *http.Request, doc *file
 	rowCount, _ := csvDoc.RecordCount()
 	colCount, _ := csvDoc.ColumnCount()
 
+	var filters []string
+	for _, expr := range r.URL.Query()["filter"] {
+		if strings.TrimSpace(expr) != "" {
+			filters = append(filters, expr)
+		}
+	}
+
 	csvData := &web.CSVData{
-		Records:     records,
+		Records:     shown,
+		RowIndexes:  rowIndexes,
 		Metadata:    metadata,
 		RecordCount: rowCount,
 		ColumnCount: colCount,
+		Sort:        r.URL.Query().Get("sort"),
+		Filters:     filters,
+		ShownCount:  max(len(shown)-1, 0),
+		QueryError:  queryError,
 	}
 
 	data.CSVData = csvData
 
-	// Check for a flash message
-	if flash := s.flashManager.Get(w, r); flash != nil {
-		data.FlashMessage = flash.Message
-		data.FlashMessageType = flash.Type
-	}
+	// Check for flash messages
+	data.Flashes = s.flashManager.Get(w, r)
 
 	if err := s.executePage(w, "view_csv.html", data); err != nil {
 		s.showServerError(w, r, err)
@@ -187,72 +280,31 @@ func (s *Server) renderCsvView(w http.ResponseWriter, r *http.Request, doc *file
 	return data, true
 }
 
-// sortCSVRecords sorts CSV records based on metadata sort settings
-func (s *Server) sortCSVRecords(records [][]string, metadata *files.CSVMetadata) [][]string {
-	if len(records) <= 1 {
-		return records
+// csvQueryFromRequest reads the "sort" and "filter" query parameters of the CSV view. "filter" can be given
+// more than once; without "sort", records are sorted by the metadata's sort settings.
+func csvQueryFromRequest(doc *files.CSVDocument, r *http.Request) (files.CSVQuery, error) {
+	query, err := doc.DefaultQuery()
+	if err != nil {
+		return files.CSVQuery{}, err
 	}
 
-	// Find the column index to sort by
-	sortColIndex := -1
-
-	// Try to match by column name first (if headers are defined)
-	if len(metadata.Headers) > 0 && len(records) > 0 {
-		for i, header := range metadata.Headers {
-			if header == metadata.SortColumn {
-				sortColIndex = i
-				break
-			}
+	if spec := r.URL.Query().Get("sort"); spec != "" {
+		if query.Sorts, err = doc.ParseSort(spec); err != nil {
+			return files.CSVQuery{}, err
 		}
 	}
 
-	// If not found by name, try to match against the actual first row
-	if sortColIndex == -1 && len(records[0]) > 0 {
-		for i, header := range records[0] {
-			if header == metadata.SortColumn {
-				sortColIndex = i
-				break
-			}
+	for _, expr := range r.URL.Query()["filter"] {
+		if strings.TrimSpace(expr) == "" {
+			continue
 		}
-	}
 
-	// If still not found, return unsorted
-	if sortColIndex == -1 || sortColIndex >= len(records[0]) {
-		return records
-	}
-
-	// Create a copy to sort (preserve header row)
-	header := records[0]
-	dataRows := make([][]string, len(records)-1)
-	copy(dataRows, records[1:])
-
-	// Simple sort implementation
-	for i := 0; i < len(dataRows)-1; i++ {
-		for j := 0; j < len(dataRows)-i-1; j++ {
-			if len(dataRows[j]) <= sortColIndex || len(dataRows[j+1]) <= sortColIndex {
-				continue
-			}
-
-			val1 := strings.ToLower(strings.TrimSpace(dataRows[j][sortColIndex]))
-			val2 := strings.ToLower(strings.TrimSpace(dataRows[j+1][sortColIndex]))
-
-			shouldSwap := false
-			if metadata.SortDesc {
-				shouldSwap = val1 < val2
-			} else {
-				shouldSwap = val1 > val2
-			}
-
-			if shouldSwap {
-				dataRows[j], dataRows[j+1] = dataRows[j+1], dataRows[j]
-			}
+		filter, err := doc.ParseFilter(expr)
+		if err != nil {
+			return files.CSVQuery{}, err
 		}
+		query.Filters = append(query.Filters, filter)
 	}
 
-	// Reconstruct with header
-	result := make([][]string, len(records))
-	result[0] = header
-	copy(result[1:], dataRows)
-
-	return result
+	return query, nil
 }
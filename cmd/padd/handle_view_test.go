@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleView_RawMarkdown(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n\n- [ ] Call **Sam**\n"))
+	server.fileRepo.ReloadCaches()
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{name: "accept header", target: "/resources/notes", accept: "text/markdown"},
+		{name: "accept header with parameters", target: "/resources/notes", accept: "text/markdown; charset=utf-8, text/plain;q=0.5"},
+		{name: "raw parameter", target: "/resources/notes?raw=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			server.setupRoutes().ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, http.StatusOK)
+			assert.Equal(t, rec.Header().Get("Content-Type"), "text/markdown; charset=utf-8")
+			assert.Equal(t, rec.Body.String(), "# Notes\n\n- [ ] Call **Sam**\n")
+		})
+	}
+}
+
+func TestHandleView_HTMLByDefault(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/notes.md", "# Notes\n\nCall **Sam**\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/resources/notes", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	rec := httptest.NewRecorder()
+
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html"))
+	assert.True(t, strings.Contains(rec.Body.String(), "<strong>Sam</strong>"))
+}
+
+func TestHandleView_RawMarkdownEncryptedWithoutIdentities(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/secret.md", "age-encryption.org/v1\n-> X25519 abc\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodGet, "/resources/secret?raw=1", nil)
+	rec := httptest.NewRecorder()
+
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusForbidden)
+	assert.False(t, strings.Contains(rec.Body.String(), "age-encryption.org"))
+}
+
+func TestHandleView_Backlinks(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/alpha.md", "# Alpha\n\nFollow up in [[inbox]].\n"))
+	server.fileRepo.ReloadCaches()
+
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/inbox", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, "Linked from"))
+	assert.True(t, strings.Contains(body, `<a href="/resources/alpha">Alpha</a>`))
+
+	// Files without backlinks have no section
+	rec = httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/active", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.False(t, strings.Contains(rec.Body.String(), "Linked from"))
+}
+
+func TestHandleView_SavedSearch(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("resources/site.md", "---\ntags: [project]\nstatus: in-progress\n---\n# Site\n\n- [ ] Ship it\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/api.md", "---\ntags: [project]\nstatus: blocked\n---\n# API\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/projects.md", "---\ntags: [project]\nstatus: in-progress\nquery: tag:project status:in-progress\n---\n# Projects\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/todo.md", "---\nquery: tag:project is:open\n---\n# Todo\n"))
+	assert.Nil(t, server.rootManager.WriteString("resources/broken.md", "---\nquery: is:someday\n---\n# Broken\n"))
+	server.fileRepo.ReloadCaches()
+
+	view := func(target string) string {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, rec.Code, http.StatusOK)
+		return rec.Body.String()
+	}
+
+	// Matching notes are listed below the content, leaving out the note itself
+	body := view("/resources/projects")
+	assert.True(t, strings.Contains(body, "Matching notes"))
+	assert.True(t, strings.Contains(body, `<a href="/resources/site">Site</a>`))
+	assert.False(t, strings.Contains(body, `<a href="/resources/api">API</a>`))
+	assert.False(t, strings.Contains(body, `<a href="/resources/projects">Projects</a>`))
+
+	body = view("/resources/todo")
+	assert.True(t, strings.Contains(body, "Matching tasks"))
+	assert.True(t, strings.Contains(body, "Ship it"))
+
+	body = view("/resources/broken")
+	assert.True(t, strings.Contains(body, "unknown is:someday"))
+
+	// Notes without a query have no section
+	assert.False(t, strings.Contains(view("/resources/site"), "Matching notes"))
+}
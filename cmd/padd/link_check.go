@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Link check defaults
+const (
+	defaultLinkCheckDelay   = time.Second      // Wait between requests to external URLs, so no site is flooded
+	defaultLinkCheckTimeout = 10 * time.Second // Per-request timeout for external URLs
+)
+
+// linkChecker checks the external URLs linked from documents, one request at a time, and keeps the failures
+// from the most recent check for the link report
+type linkChecker struct {
+	interval time.Duration // How often external URLs are checked in the background
+	delay    time.Duration // Wait between requests
+	client   *http.Client
+
+	mu       sync.Mutex        // Guards the fields below
+	running  bool              // Whether a check is in progress
+	checked  time.Time         // When the most recent check finished; zero if there hasn't been one
+	failures map[string]string // Why each failing URL failed, in the most recent check
+}
+
+// WithExternalLinkCheck checks the http and https URLs linked from documents every interval, in the
+// background, waiting delay between requests. Failing URLs are listed in the link report at /reports/links.
+// An interval of 0 leaves external URLs unchecked.
+func WithExternalLinkCheck(interval, delay time.Duration) ServerOption {
+	return func(s *Server) error {
+		if interval == 0 {
+			return nil
+		}
+		if interval < 0 {
+			return fmt.Errorf("invalid link check interval: %v", interval)
+		}
+		if delay < 0 {
+			return fmt.Errorf("invalid link check delay: %v", delay)
+		}
+
+		s.linkChecker = &linkChecker{
+			interval: interval,
+			delay:    delay,
+			client:   &http.Client{Timeout: defaultLinkCheckTimeout},
+		}
+		return nil
+	}
+}
+
+// setupLinkCheck starts the background task that checks external URLs, if external link checks are on
+func (s *Server) setupLinkCheck() {
+	if s.linkChecker == nil {
+		return
+	}
+
+	s.backgroundRunner.AddPeriodicTask("link-check", s.linkChecker.interval, s.checkExternalLinks)
+}
+
+// checkExternalLinks checks every external URL linked from a document and records the ones that fail. A check
+// that's already running is left to finish instead of starting another.
+func (s *Server) checkExternalLinks(ctx context.Context) error {
+	lc := s.linkChecker
+	lc.mu.Lock()
+	if lc.running {
+		lc.mu.Unlock()
+		return nil
+	}
+	lc.running = true
+	lc.mu.Unlock()
+
+	defer func() {
+		lc.mu.Lock()
+		lc.running = false
+		lc.mu.Unlock()
+	}()
+
+	check, err := s.fileRepo.CheckLinks()
+	if err != nil {
+		return fmt.Errorf("failed to scan for links: %w", err)
+	}
+
+	failures := make(map[string]string)
+	for i, rawURL := range slices.Sorted(maps.Keys(check.External)) {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(lc.delay):
+			}
+		}
+
+		if reason := lc.probe(ctx, rawURL); reason != "" {
+			failures[rawURL] = reason
+		}
+	}
+
+	lc.mu.Lock()
+	lc.checked = time.Now()
+	lc.failures = failures
+	lc.mu.Unlock()
+
+	s.logger.Info("Checked external links", "urls", len(check.External), "failing", len(failures))
+	return nil
+}
+
+// status returns when external URLs were last checked, why each failing URL failed, and whether a check is
+// in progress
+func (lc *linkChecker) status() (checked time.Time, failures map[string]string, running bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.checked, lc.failures, lc.running
+}
+
+// probe requests rawURL and returns why it failed, or an empty string if it succeeded. A HEAD request is tried
+// first, falling back to GET for servers that don't allow HEAD.
+func (lc *linkChecker) probe(ctx context.Context, rawURL string) string {
+	status, err := lc.request(ctx, http.MethodHead, rawURL)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = lc.request(ctx, http.MethodGet, rawURL)
+	}
+
+	switch {
+	case err != nil:
+		return err.Error()
+	case status >= http.StatusBadRequest:
+		return fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return ""
+}
+
+// request sends a request to rawURL and returns the response's status code
+func (lc *linkChecker) request(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "padd-link-checker")
+
+	resp, err := lc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// liveEventsKeepAlive is how often an idle event stream gets a comment, so proxies don't close it
+const liveEventsKeepAlive = 30 * time.Second
+
+// LiveEvent is the JSON data of a "change" event sent on the /events stream
+type LiveEvent struct {
+	Operation string `json:"operation"`
+	ID        string `json:"id"`
+	OldID     string `json:"old_id,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	External  bool   `json:"external,omitempty"`
+}
+
+// liveEventHub fans document changes out to every open /events stream
+type liveEventHub struct {
+	mu      sync.Mutex
+	clients map[chan LiveEvent]struct{}
+	closed  bool
+}
+
+func newLiveEventHub() *liveEventHub {
+	return &liveEventHub{
+		clients: make(map[chan LiveEvent]struct{}),
+	}
+}
+
+// subscribe returns a channel that receives every published event. The channel is closed when the hub is.
+func (h *liveEventHub) subscribe() chan LiveEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan LiveEvent, 16)
+	if h.closed {
+		close(ch)
+		return ch
+	}
+
+	h.clients[ch] = struct{}{}
+	return ch
+}
+
+func (h *liveEventHub) unsubscribe(ch chan LiveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[ch]; ok {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// publish sends the event to every subscriber. Subscribers that have fallen behind miss the event rather
+// than holding up the change that caused it.
+func (h *liveEventHub) publish(event LiveEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.clients {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// close ends every open stream, so the HTTP server can shut down without waiting on them
+func (h *liveEventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// setupLiveEvents registers the change listener that publishes document changes to the /events streams
+func (s *Server) setupLiveEvents() {
+	s.fileRepo.OnChange(func(event files.ChangeEvent) {
+		s.liveEvents.publish(LiveEvent{
+			Operation: string(event.Operation),
+			ID:        event.ID,
+			OldID:     event.OldID,
+			Hash:      event.Hash,
+			External:  event.External,
+		})
+	})
+}
+
+// handleEvents streams a server-sent "change" event whenever a document is saved, deleted, or renamed, whether
+// from another tab, the API, or an edit made outside the application. Open pages use it to refresh themselves.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// The server's write timeout is meant for regular responses and would end the stream
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	events := s.liveEvents.subscribe()
+	defer s.liveEvents.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// Tell the browser how long to wait before reconnecting after the stream ends
+	_, _ = fmt.Fprint(w, "retry: 5000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(liveEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "event: change\ndata: %s\n\n", data)
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
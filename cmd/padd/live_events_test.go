@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleEvents_StreamsDocumentChanges(t *testing.T) {
+	server := newTestServer(t)
+	httpServer := httptest.NewServer(server.setupRoutes())
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/events")
+	assert.Nil(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+	// Read past the retry interval sent when the stream opens
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, line, "retry: 5000\n")
+
+	doc, err := server.fileRepo.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Inbox\n\nFrom another tab"))
+
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	var event LiveEvent
+	timeout := time.After(5 * time.Second)
+	for event.ID == "" {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("event stream closed before the change arrived")
+			}
+			if data, found := strings.CutPrefix(line, "data: "); found {
+				assert.Nil(t, json.Unmarshal([]byte(data), &event))
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the change event")
+		}
+	}
+
+	hash, err := doc.Checksum()
+	assert.Nil(t, err)
+	assert.Equal(t, event.Operation, "save")
+	assert.Equal(t, event.ID, "inbox")
+	assert.Equal(t, event.Hash, hash)
+}
+
+func TestLiveEventHub_CloseEndsStreams(t *testing.T) {
+	hub := newLiveEventHub()
+	events := hub.subscribe()
+
+	hub.publish(LiveEvent{Operation: "save", ID: "inbox"})
+	hub.close()
+
+	event, ok := <-events
+	assert.True(t, ok)
+	assert.Equal(t, event.ID, "inbox")
+
+	_, ok = <-events
+	assert.False(t, ok)
+
+	// Streams opened after closing end right away
+	_, ok = <-hub.subscribe()
+	assert.False(t, ok)
+	hub.unsubscribe(events)
+}
+
+func TestHandleEvents_UnderBasePath(t *testing.T) {
+	server := newTestServer(t, WithBasePath("/work"))
+	server.liveEvents.close()
+
+	// The vault router strips the base path, and the stream passes through the link rewriting
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "text/event-stream")
+	assert.True(t, rec.Flushed)
+}
@@ -1,20 +1,30 @@
 package main
 
 import (
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// Log formats for LogConfig.Format
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
 type LogConfig struct {
-	LogFile    string // Log file path
-	MaxSize    int    // Max size in megabytes
-	MaxBackups int    // Max number of backups
-	MaxAge     int    // Max age in days
-	Compress   bool   // Compress backups
+	LogFile    string     // Log file path
+	MaxSize    int        // Max size in megabytes
+	MaxBackups int        // Max number of backups
+	MaxAge     int        // Max age in days
+	Compress   bool       // Compress backups
+	Level      slog.Level // Lowest level that's logged
+	Format     string     // "text" for key=value lines, or "json"
 }
 
 func DefaultLogConfig(dataDir string) LogConfig {
@@ -24,12 +34,16 @@ func DefaultLogConfig(dataDir string) LogConfig {
 		MaxBackups: 3,
 		MaxAge:     30,
 		Compress:   true,
+		Level:      slog.LevelInfo,
+		Format:     logFormatText,
 	}
 }
 
-func SetupLogging(config LogConfig) error {
+// SetupLogging logs to stdout and the rotated log file, and returns the logger. The logger also becomes the
+// default, so messages from the standard log package go to the same places.
+func SetupLogging(config LogConfig) (*slog.Logger, error) {
 	if err := os.MkdirAll(filepath.Dir(config.LogFile), 0755); err != nil {
-		return err
+		return nil, err
 	}
 
 	logger := &lumberjack.Logger{
@@ -41,7 +55,52 @@ func SetupLogging(config LogConfig) error {
 	}
 
 	multiWriter := io.MultiWriter(os.Stdout, logger)
-	log.SetOutput(multiWriter)
+	slogger, err := newLogger(multiWriter, config.Level, config.Format)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slogger)
+
+	return slogger, nil
+}
+
+// newLogger returns a logger that writes messages at level and above to w in the given format
+func newLogger(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(format) {
+	case logFormatText, "":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q (expected %s or %s)", format, logFormatText, logFormatJSON)
+	}
+}
 
-	return nil
+// parseLogLevel parses a level name: debug, info, warn, or error
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("invalid log level %q (expected debug, info, warn, or error)", name)
+	}
+	return level, nil
+}
+
+// WithLogger logs server, file repository, encryption, and background task messages to logger instead of the
+// default logger
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(s *Server) error {
+		if logger == nil {
+			return nil
+		}
+
+		s.logger = logger
+		s.fileRepo.SetLogger(logger)
+		s.backgroundRunner.SetLogger(logger)
+		if manager := s.fileRepo.EncryptionManager(); manager != nil {
+			manager.SetLogger(logger)
+		}
+		return nil
+	}
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		want slog.Level
+	}{
+		{name: "debug", want: slog.LevelDebug},
+		{name: "info", want: slog.LevelInfo},
+		{name: "WARN", want: slog.LevelWarn},
+		{name: "error", want: slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := parseLogLevel(tt.name)
+			assert.Nil(t, err)
+			assert.Equal(t, level, tt.want)
+		})
+	}
+
+	_, err := parseLogLevel("loud")
+	assert.NotNil(t, err)
+}
+
+func TestNewLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, slog.LevelInfo, logFormatText)
+	assert.Nil(t, err)
+	logger.Debug("hidden")
+	logger.Info("shown", "count", 2)
+	assert.False(t, strings.Contains(out.String(), "hidden"))
+	assert.True(t, strings.Contains(out.String(), "msg=shown count=2"))
+
+	_, err = newLogger(&out, slog.LevelInfo, "xml")
+	assert.NotNil(t, err)
+}
+
+func TestWithLogger_AccessLog(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, slog.LevelInfo, logFormatJSON)
+	assert.Nil(t, err)
+
+	server := newTestServer(t, WithLogger(logger), WithAccessLog(true))
+	server.setupRoutes().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/inbox", nil))
+
+	var entry map[string]any
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, entry["msg"], any("request"))
+	assert.Equal(t, entry["path"], any("/inbox"))
+	assert.Equal(t, entry["status"], any(float64(http.StatusOK)))
+}
+
+func TestWithLogger_EncryptionManager(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, slog.LevelInfo, logFormatText)
+	assert.Nil(t, err)
+
+	server := newLockedTestServer(t, WithLogger(logger))
+	manager := server.fileRepo.EncryptionManager()
+	assert.Nil(t, manager.Unlock(testPassphrase))
+	manager.Lock()
+
+	assert.True(t, strings.Contains(out.String(), `msg="Encryption identities unlocked" identities=1`))
+	assert.True(t, strings.Contains(out.String(), `msg="Encryption identities locked"`))
+}
@@ -4,21 +4,33 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/flash"
+	"github.com/patrickward/padd/internal/remote"
 	"github.com/patrickward/padd/internal/version"
 )
 
 const (
 	appName           = "PADD"
-	resourcesDir      = "resources"
 	envPaddData       = "PADD_DATA_DIR"
 	envPaddKeys       = "PADD_KEYS_DIR"
 	envPaddIdentities = "PADD_IDENTITIES_FILE"
 	envPaddRecipients = "PADD_RECIPIENTS_FILE"
+	envPaddWriteToken = "PADD_WRITE_TOKEN"
+	envPaddSyncRemote = "PADD_SYNC_REMOTE"
+	envPaddPassword   = "PADD_PASSWORD_HASH"
+	envPaddTLSCert    = "PADD_TLS_CERT"
+	envPaddTLSKey     = "PADD_TLS_KEY"
+	envPaddAutocert   = "PADD_AUTOCERT_DOMAINS"
 )
 
 // getXDGDataHome determines the XDG_DATA_HOME directory.
@@ -79,7 +91,7 @@ func getConfigValue(flagValue, envVar, defaultValue string) string {
 func getDefaultKeys(keysDir string) (identitiesFile, recipientsFile string) {
 	// Check if the data directory exists
 	if _, err := os.Stat(keysDir); os.IsNotExist(err) {
-		log.Printf("the keys directory %s does not exist", keysDir)
+		slog.Info("Keys directory does not exist", "dir", keysDir)
 		return "", ""
 	}
 
@@ -88,18 +100,24 @@ func getDefaultKeys(keysDir string) (identitiesFile, recipientsFile string) {
 	recipientsFile = filepath.Join(keysDir, "key.pub")
 
 	if _, err := os.Stat(identitiesFile); os.IsNotExist(err) {
-		log.Printf("default identities key file %s does not exist", identitiesFile)
+		slog.Info("Default identities key file does not exist", "file", identitiesFile)
 		return "", ""
 	}
 
 	if _, err := os.Stat(recipientsFile); os.IsNotExist(err) {
-		log.Printf("default recipients key file %s does not exist", recipientsFile)
+		slog.Info("Default recipients key file does not exist", "file", recipientsFile)
 		return "", ""
 	}
 
 	return identitiesFile, recipientsFile
 }
 
+// fatal logs an error message with its attributes and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
 	var port int
 	var addr string
@@ -108,7 +126,71 @@ func main() {
 	var identitiesFile string
 	var recipientsFile string
 	var generateKeys bool
+	var rotateKeysMode bool
+	var newRecipientsFile string
 	var showVersion bool
+	var readTimeout time.Duration
+	var writeTimeout time.Duration
+	var idleTimeout time.Duration
+	var maxBodyBytes int64
+	var clipTarget string
+	var clipOrigin string
+	var flashMaxAge time.Duration
+	var encryptDirs string
+	var webhookURL string
+	var robotsFile string
+	var private bool
+	var summaryDays int
+	var writeToken string
+	var vaults vaultList
+	var history bool
+	var searchEncrypted bool
+	var lockAfter time.Duration
+	var watchFiles bool
+	var recurringInterval time.Duration
+	var recurringTarget string
+	var backupDir string
+	var backupInterval time.Duration
+	var backupKeep int
+	var syncRemote string
+	var syncInterval time.Duration
+	var linkCheckInterval time.Duration
+	var linkCheckDelay time.Duration
+	var imageMaxWidth int
+	var imageMaxHeight int
+	var thumbSize int
+	var keepOriginals bool
+	var passwordHash string
+	var sessionDuration time.Duration
+	var tlsCert string
+	var tlsKey string
+	var autocertDomains string
+	var autocertEmail string
+	var autocertCache string
+	var httpRedirectPort int
+	var accessLog bool
+	var logLevel string
+	var logFormat string
+	var configPath string
+	var cacheRefresh time.Duration
+	var cacheWarmers int
+	var searchMinLength int
+	var searchMaxLength int
+	var coreFiles string
+	var temporalFiles string
+	var donePlacement string
+	var excludeGlobs string
+	var unicodeIDs bool
+	var normalizeOnSave bool
+	var autoCreatedAt bool
+	var autoUpdatedAt bool
+	var timestampDirs string
+	var dedupeKeepsCompleted bool
+	var maxDepth int
+	var resourcesDir string
+	var dailyDir string
+	var journalDir string
+	var documentCacheSize int
 
 	// Note to self about Flag aliases: Go's flag package allows multiple flag names to point to the same variable.
 	// When you call BoolVar/StringVar/etc. multiple times with the same variable pointer,
@@ -117,6 +199,7 @@ func main() {
 	// The default value is only applied once, not overridden - both flags share the same
 	// default and will set the same variable when used by the user.
 	flagSet := flag.NewFlagSet(appName, flag.ExitOnError)
+	flagSet.StringVar(&configPath, "config", "", "Config file that sets any of these options (default \"$XDG_CONFIG_HOME/padd/config.yaml\", or set PADD_CONFIG).")
 	flagSet.StringVar(&dataDirFlag, "data", "", "Directory to store markdown files.")
 	flagSet.StringVar(&dataDirFlag, "d", "", "Directory to store markdown files.")
 	flagSet.StringVar(&keysDirFlag, "keys-dir", "", "Directory for key operations (generation, etc.)")
@@ -127,12 +210,77 @@ func main() {
 	flagSet.StringVar(&recipientsFile, "r", "", "Use the recipient file at the specified path for encryption.")
 	flagSet.BoolVar(&generateKeys, "generate-keys", false, "Generate a new key pair and save to keys-dir.")
 	flagSet.BoolVar(&generateKeys, "g", false, "Generate a new key pair and save to keys-dir.")
+	flagSet.BoolVar(&rotateKeysMode, "rotate-keys", false, "Re-encrypt every encrypted file in the data directory for the recipients in -new-recipient.")
+	flagSet.StringVar(&newRecipientsFile, "new-recipient", "", "Recipient file with the public keys to re-encrypt files for when rotating keys.")
 
 	flagSet.IntVar(&port, "port", 8080, "Port to run the server on.")
 	flagSet.IntVar(&port, "p", 8080, "Port to run the server on.")
 	flagSet.StringVar(&addr, "addr", "localhost", "Address to bind the server to.")
 	flagSet.StringVar(&addr, "a", "localhost", "Address to bind the server to.")
 
+	flagSet.StringVar(&tlsCert, "tls-cert", "", "Serve HTTPS with the certificate in this PEM file (or set PADD_TLS_CERT).")
+	flagSet.StringVar(&tlsKey, "tls-key", "", "Private key for -tls-cert, as a PEM file (or set PADD_TLS_KEY).")
+	flagSet.StringVar(&autocertDomains, "autocert", "", "Serve HTTPS with Let's Encrypt certificates for these comma-separated domains (or set PADD_AUTOCERT_DOMAINS).")
+	flagSet.StringVar(&autocertEmail, "autocert-email", "", "Email address Let's Encrypt sends certificate expiry notices to.")
+	flagSet.StringVar(&autocertCache, "autocert-cache", "", "Directory to keep Let's Encrypt certificates in (default \"$XDG_DATA_HOME/padd/certs\").")
+	flagSet.IntVar(&httpRedirectPort, "http-redirect-port", 0, "When serving HTTPS, also listen for plain HTTP on this port and redirect it to HTTPS (0 disables).")
+
+	flagSet.BoolVar(&accessLog, "access-log", false, "Log every request with its method, path, status code, and duration.")
+	flagSet.StringVar(&logLevel, "log-level", "info", "Lowest level of messages logged: debug, info, warn, or error.")
+	flagSet.StringVar(&logFormat, "log-format", logFormatText, "Format of log messages: text or json.")
+	flagSet.DurationVar(&readTimeout, "read-timeout", defaultReadTimeout, "Maximum duration for reading a request.")
+	flagSet.DurationVar(&writeTimeout, "write-timeout", defaultWriteTimeout, "Maximum duration before timing out writes of a response.")
+	flagSet.DurationVar(&idleTimeout, "idle-timeout", defaultIdleTimeout, "Maximum duration to wait for the next request on a keep-alive connection.")
+	flagSet.Int64Var(&maxBodyBytes, "max-body-bytes", defaultMaxBodyBytes, "Maximum request body size in bytes for POST, PUT, and PATCH requests.")
+	flagSet.StringVar(&clipTarget, "clip-target", defaultClipTarget, "File ID that web clips are added to.")
+	flagSet.StringVar(&clipOrigin, "clip-origin", "", "Origin allowed to post web clips from other sites (\"*\" for any).")
+	flagSet.StringVar(&encryptDirs, "encrypt-dirs", "", "Comma-separated directories whose new files are encrypted by default (e.g., resources/private).")
+	flagSet.StringVar(&webhookURL, "webhook", "", "URL to notify with a JSON POST when documents are saved, deleted, or renamed.")
+	flagSet.StringVar(&robotsFile, "robots", "", "Path to a file served as /robots.txt (defaults to disallowing all crawlers).")
+	flagSet.BoolVar(&private, "private", false, "Ask search engines not to index document views with an X-Robots-Tag header.")
+	flagSet.IntVar(&summaryDays, "summary-days", 0, "Show a summary of this many recent days at /daily and /journal instead of the current month (0 disables).")
+	flagSet.StringVar(&writeToken, "write-token", "", "Shared secret required in the X-PADD-Token header of requests that change data (or set PADD_WRITE_TOKEN).")
+	flagSet.StringVar(&passwordHash, "password-hash", "", "Require signing in with the password of this bcrypt hash, printed by the hash-password command (or set PADD_PASSWORD_HASH).")
+	flagSet.DurationVar(&sessionDuration, "session-duration", defaultSessionDuration, "How long a sign-in lasts when -password-hash is set.")
+	flagSet.BoolVar(&history, "history", false, "Commit every change to a git repository in the data directory (on by default when it already is one).")
+	flagSet.BoolVar(&searchEncrypted, "search-encrypted", true, "Include encrypted files in search when identities are loaded to decrypt them.")
+	flagSet.IntVar(&searchMinLength, "search-min-length", defaultSearchMinLength, "Shortest search query, in characters, that is searched for.")
+	flagSet.IntVar(&searchMaxLength, "search-max-length", defaultSearchMaxLength, "Longest search query, in characters, that is searched for.")
+	flagSet.DurationVar(&lockAfter, "lock-after", 0, "Lock passphrase-protected identities again this long after unlocking them (0 keeps them unlocked).")
+	flagSet.StringVar(&coreFiles, "core-files", "inbox.md,active.md", "Comma-separated markdown files kept at the top of the data directory; the first is the home page and receives renewed recurring tasks.")
+	flagSet.StringVar(&temporalFiles, "temporal-files", "monthly", "Keep daily and journal entries in a file per month (monthly, e.g. daily/2025/09-september.md) or per day (daily, e.g. daily/2025/09/15.md).")
+	flagSet.StringVar(&resourcesDir, "resources-dir", files.DefaultFileConfig.ResourcesDirectory, "Directory at the top of the data directory that holds resource files.")
+	flagSet.StringVar(&dailyDir, "daily-dir", files.DefaultFileConfig.DailyDirectory, "Directory at the top of the data directory that holds daily entries; also the path of the daily pages.")
+	flagSet.StringVar(&journalDir, "journal-dir", files.DefaultFileConfig.JournalDirectory, "Directory at the top of the data directory that holds journal entries; also the path of the journal pages.")
+	flagSet.StringVar(&donePlacement, "done-placement", "end", "Where @done is added to completed tasks: end, or before-tags (before trailing #tags and @contexts).")
+	flagSet.StringVar(&excludeGlobs, "exclude", "", "Comma-separated glob patterns of files to leave out of the index (e.g., **/drafts/**,*.tmp.md).")
+	flagSet.BoolVar(&unicodeIDs, "unicode-ids", false, "Keep Unicode letters and digits in file IDs instead of dropping them.")
+	flagSet.BoolVar(&normalizeOnSave, "normalize-on-save", true, "Trim surrounding whitespace from documents and end them with a single newline when saving.")
+	flagSet.BoolVar(&autoCreatedAt, "auto-created-at", true, "Add created_at frontmatter to new markdown files.")
+	flagSet.BoolVar(&autoUpdatedAt, "auto-updated-at", false, "Keep updated_at frontmatter in markdown files current, setting it on every save.")
+	flagSet.StringVar(&timestampDirs, "timestamp-dirs", "", "Comma-separated directories that -auto-created-at and -auto-updated-at are limited to (e.g., resources); empty is everywhere.")
+	flagSet.BoolVar(&dedupeKeepsCompleted, "dedupe-keeps-completed", false, "Keep a completed duplicate over an earlier pending one when removing duplicate tasks.")
+	flagSet.IntVar(&maxDepth, "max-depth", 0, "Deepest directory level indexed, counted from the data directory (0 is unlimited).")
+	flagSet.DurationVar(&cacheRefresh, "cache-refresh", defaultCacheRefresh, "How often stale resource caches are reloaded in the background.")
+	flagSet.IntVar(&documentCacheSize, "document-cache-size", 0, "Most documents kept in memory after they're read (0 uses the default of 1000).")
+	flagSet.IntVar(&cacheWarmers, "cache-warmers", 0, "Concurrent loads that preload document content into the cache at startup and after each cache rebuild (0 disables).")
+	flagSet.BoolVar(&watchFiles, "watch", true, "Watch the data directory and refresh caches when files are changed by other programs.")
+	flagSet.DurationVar(&recurringInterval, "recurring-interval", defaultRecurringInterval, "How often completed @repeat and @every tasks are checked for renewal (0 disables).")
+	flagSet.StringVar(&recurringTarget, "recurring-target", "section", "Where renewed recurring tasks are added: section (above the completed task) or inbox.")
+	flagSet.StringVar(&backupDir, "backup-dir", "", "Directory to save scheduled zip backups of the data directory in (backups are off when empty).")
+	flagSet.DurationVar(&backupInterval, "backup-interval", defaultBackupInterval, "How often a backup is taken when -backup-dir is set (0 only takes them by hand, from /settings).")
+	flagSet.IntVar(&backupKeep, "backup-keep", defaultBackupKeep, "How many of the most recent backups to keep (0 keeps them all).")
+	flagSet.StringVar(&syncRemote, "sync-remote", "", "Remote to sync the data directory with: s3://bucket/prefix or a directory (or set PADD_SYNC_REMOTE).")
+	flagSet.DurationVar(&syncInterval, "sync-interval", defaultSyncInterval, "How often the server syncs with -sync-remote (0 leaves syncing to the sync command).")
+	flagSet.DurationVar(&linkCheckInterval, "link-check-interval", 0, "How often the external links in documents are checked in the background, for the report at /reports/links (0 leaves them unchecked).")
+	flagSet.DurationVar(&linkCheckDelay, "link-check-delay", defaultLinkCheckDelay, "Wait between requests when checking external links.")
+	flagSet.IntVar(&imageMaxWidth, "image-max-width", defaultImageMaxSize, "Scale uploaded JPEG and PNG images down to fit this width (0 for no limit).")
+	flagSet.IntVar(&imageMaxHeight, "image-max-height", defaultImageMaxSize, "Scale uploaded JPEG and PNG images down to fit this height (0 for no limit).")
+	flagSet.IntVar(&thumbSize, "thumb-size", defaultThumbSize, "Width and height that image thumbnails fit within.")
+	flagSet.BoolVar(&keepOriginals, "keep-originals", false, "Keep the uploaded originals of images that are scaled down, in images/originals.")
+	flagSet.Var(&vaults, "vault", "Serve a named data directory under /<name>/, given as name=dir[,keysDir]. Repeat for each vault; the first is the default.")
+	flagSet.DurationVar(&flashMaxAge, "flash-max-age", flash.DefaultMaxAge, "How long flash messages are kept before they expire unread.")
+
 	flagSet.BoolVar(&showVersion, "version", false, "Show application version.")
 	flagSet.BoolVar(&showVersion, "v", false, "Show application version.")
 
@@ -143,8 +291,29 @@ func main() {
 		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -generate-keys -keys-dir ~/.padd/keys\n\n", appName)
 		_, _ = fmt.Fprintf(flagSet.Output(), "  # Use specific identity and recipient:\n")
 		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -identity ~/.padd/keys/key.txt -recipient ~/.padd/keys/key.pub...\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Use a passphrase-protected identity (created with age -p), unlocked in the browser:\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -identity ~/.padd/keys/key.txt.age -recipient ~/.padd/keys/key.pub -lock-after 1h\n\n", appName)
 		_, _ = fmt.Fprintf(flagSet.Output(), "  # Use YubiKey plugin:\n")
 		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -identity ~/.age/yubikey-identities.txt -recipient ~/.padd/keys/key.pub...\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Re-encrypt every encrypted file for a new key pair:\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -rotate-keys -identity ~/.padd/keys/old.txt -new-recipient ~/.padd/keys/new.pub\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Capture without the server (the entry is read from stdin when omitted):\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s add \"buy milk\"\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s add -to resources/groceries -section Produce -task \"apples\"\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s daily \"shipped the release\"\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s journal \"quiet morning\"\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Back up the data directory as a zip archive, and restore it elsewhere:\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s export -decrypt padd-backup.zip\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s import -conflicts overwrite padd-backup.zip\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Sync with a bucket shared with another machine (credentials come from the AWS_* variables):\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -sync-remote s3://my-bucket/padd sync\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Require a password, to serve on the local network:\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  export PADD_PASSWORD_HASH=$(echo 'correct horse' | %s hash-password)\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -addr 0.0.0.0\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Serve HTTPS with a Let's Encrypt certificate, redirecting plain HTTP:\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -addr 0.0.0.0 -port 443 -autocert notes.example.com -http-redirect-port 80\n\n", appName)
+		_, _ = fmt.Fprintf(flagSet.Output(), "  # Keep options in a config file, under their long names (e.g., port: 9090):\n")
+		_, _ = fmt.Fprintf(flagSet.Output(), "  %s -config ~/.config/padd/config.yaml\n\n", appName)
 		_, _ = fmt.Fprintf(flagSet.Output(), "Options:\n")
 		flagSet.PrintDefaults()
 	}
@@ -152,7 +321,7 @@ func main() {
 	// Parse the flags
 	err := flagSet.Parse(os.Args[1:])
 	if err != nil {
-		log.Fatal(fmt.Errorf("error parsing flags: %v", err))
+		fatal("Error parsing flags", "error", err)
 	}
 
 	if showVersion {
@@ -161,21 +330,32 @@ func main() {
 		return
 	}
 
+	// Options in the config file apply unless they were given on the command line or by environment variable
+	config, err := loadConfigFile(configPath)
+	if err != nil {
+		fatal("Error loading config file", "error", err)
+	}
+	if config != nil {
+		if err := config.apply(flagSet); err != nil {
+			fatal("Error applying config file", "error", err)
+		}
+	}
+
 	// Resolve the keys directory.
 	keysDir, err := getConfigDataDirectory(keysDirFlag, envPaddKeys, "keys")
 	if err != nil {
-		log.Fatal(fmt.Errorf("error determining keys directory: %v", err))
+		fatal("Error determining keys directory", "error", err)
 	}
 
 	// Generate new keys - outputs to timestamped key pair files in the keys directory.
 	if generateKeys {
 		if err != nil {
-			log.Fatal(fmt.Errorf("error determining keys directory: %v", err))
+			fatal("Error determining keys directory", "error", err)
 		}
 
 		publicKey, _, publicPath, privatePath, err := crypto.GenerateNewEncryptionPair(keysDir)
 		if err != nil {
-			log.Fatal(fmt.Errorf("error generating new encryption identity: %v", err))
+			fatal("Error generating new encryption identity", "error", err)
 		}
 
 		fmt.Printf("Generated new encryption identity:\n")
@@ -188,31 +368,101 @@ func main() {
 		return
 	}
 
+	// The hash-password subcommand prints the hash for -password-hash and exits
+	if args := flagSet.Args(); len(args) > 0 && args[0] == hashPasswordCommand {
+		if err := runHashPassword(os.Stdin, os.Stdout); err != nil {
+			fatal("Error hashing password", "error", err)
+		}
+		return
+	}
+
 	// Resolve the data directory.
 	dataDir, err := getConfigDataDirectory(dataDirFlag, envPaddData, "data")
 	if err != nil {
-		log.Fatal(fmt.Errorf("error determining data directory: %v", err))
-	}
-
-	// Set up log rotation
-	logConfig := DefaultLogConfig(dataDir)
-	if err := SetupLogging(logConfig); err != nil {
-		log.Fatal(fmt.Errorf("error setting up logging: %v", err))
+		fatal("Error determining data directory", "error", err)
 	}
 
 	// Set up the encryption config
-	encryptionManager := crypto.NewEncryptionManager()
 	identitiesFile = getConfigValue(identitiesFile, envPaddIdentities, "")
 	recipientsFile = getConfigValue(recipientsFile, envPaddRecipients, "")
 	if identitiesFile == "" || recipientsFile == "" {
 		identitiesFile, recipientsFile = getDefaultKeys(keysDir)
 	}
 
-	if err = encryptionManager.LoadEncryptionKeys(identitiesFile, recipientsFile); err != nil {
-		log.Printf("Error loading encryption keys: %v", err)
-		log.Printf("Encryption disabled!")
-	} else {
-		log.Printf("Encryption enabled!")
+	fileConfig, err := newFileConfig(coreFiles, temporalFiles, donePlacement, excludeGlobs)
+	if err != nil {
+		fatal("Error reading file options", "error", err)
+	}
+	fileConfig.UnicodeIDs = unicodeIDs
+	fileConfig.NormalizeOnSave = normalizeOnSave
+	fileConfig.AutoCreatedAt = autoCreatedAt
+	fileConfig.AutoUpdatedAt = autoUpdatedAt
+	fileConfig.TimestampDirectories = splitList(timestampDirs)
+	fileConfig.DedupeKeepsCompleted = dedupeKeepsCompleted
+	fileConfig.MaxDepth = maxDepth
+	fileConfig.ResourcesDirectory = resourcesDir
+	fileConfig.DailyDirectory = dailyDir
+	fileConfig.JournalDirectory = journalDir
+	fileConfig.DocumentCacheSize = documentCacheSize
+
+	// Rotate keys - re-encrypts the data directory and exits
+	if rotateKeysMode {
+		if err := runRotateKeys(dataDir, identitiesFile, newRecipientsFile, history); err != nil {
+			fatal("Error rotating keys", "error", err)
+		}
+		return
+	}
+
+	syncRemote = getConfigValue(syncRemote, envPaddSyncRemote, "")
+
+	// The sync subcommand syncs the data directory with its remote and exits without starting the server
+	if args := flagSet.Args(); len(args) > 0 && args[0] == syncCommand {
+		if err := runSync(dataDir, syncRemote, history, args[1:], os.Stdout); err != nil {
+			fatal("Error syncing", "error", err)
+		}
+		return
+	}
+
+	// Archive subcommands export or import the data directory and exit without starting the server
+	if args := flagSet.Args(); len(args) > 0 && slices.Contains(archiveCommands, args[0]) {
+		keys := crypto.NewEncryptionManager()
+		if identitiesFile != "" {
+			if err := keys.AddIdentitiesFromFile(identitiesFile); err != nil {
+				fatal("Failed to load identity file", "file", identitiesFile, "error", err)
+			}
+		}
+		if err := runArchiveCommand(dataDir, keys, history, args[0], args[1:], os.Stdout); err != nil {
+			fatal("Error running archive command", "command", args[0], "error", err)
+		}
+		return
+	}
+
+	// Capture subcommands add an entry and exit without starting the server
+	if args := flagSet.Args(); len(args) > 0 {
+		if !slices.Contains(captureCommands, args[0]) {
+			commands := append(slices.Concat(captureCommands, archiveCommands), syncCommand, hashPasswordCommand)
+			fatal("Unknown command", "command", args[0], "expected", strings.Join(commands, ", "))
+		}
+
+		fileRepo, err := openFileRepository(dataDir, fileConfig, loadEncryptionManager(identitiesFile, recipientsFile), history)
+		if err != nil {
+			fatal("Error opening data directory", "error", err)
+		}
+		if err := runCapture(fileRepo, args[0], args[1:], os.Stdin, os.Stdout); err != nil {
+			fatal("Error capturing entry", "command", args[0], "error", err)
+		}
+		return
+	}
+
+	// Set up log rotation
+	logConfig := DefaultLogConfig(dataDir)
+	logConfig.Format = logFormat
+	if logConfig.Level, err = parseLogLevel(logLevel); err != nil {
+		fatal("Invalid log level", "error", err)
+	}
+	logger, err := SetupLogging(logConfig)
+	if err != nil {
+		fatal("Error setting up logging", "error", err)
 	}
 
 	// Create a context for the server
@@ -220,13 +470,147 @@ func main() {
 	defer cancel()
 
 	// Create the server and start it
-	server, err := NewServer(ctx, dataDir, WithEncryptionManager(encryptionManager))
+	opts := []ServerOption{
+		WithFileConfig(fileConfig),
+		WithTimeouts(readTimeout, writeTimeout, idleTimeout),
+		WithMaxBodyBytes(maxBodyBytes),
+		WithClipTarget(clipTarget),
+		WithClipAllowedOrigin(clipOrigin),
+		WithFlashMaxAge(flashMaxAge),
+		WithEncryptedDirectories(strings.Split(encryptDirs, ",")),
+		WithPrivate(private),
+		WithTemporalSummary(summaryDays),
+		WithHistory(history),
+		WithSearchEncrypted(searchEncrypted),
+		WithSearchQueryLimits(searchMinLength, searchMaxLength),
+		WithLockAfter(lockAfter),
+		WithFileWatcher(watchFiles),
+		WithCacheRefresh(cacheRefresh),
+		WithCacheWarming(cacheWarmers),
+		WithRecurringTasks(recurringInterval, recurringTarget),
+		WithBackups(backupDir, backupInterval, backupKeep),
+		WithExternalLinkCheck(linkCheckInterval, linkCheckDelay),
+		WithImageProcessing(imageMaxWidth, imageMaxHeight, thumbSize, keepOriginals),
+		WithAccessLog(accessLog),
+		WithLogger(logger),
+	}
+	if config != nil && config.metadata != nil {
+		opts = append(opts, WithMetadataColors(config.metadata))
+	}
+	if writeToken = getConfigValue(writeToken, envPaddWriteToken, ""); writeToken != "" {
+		opts = append(opts, WithWriteToken(writeToken))
+	}
+	tlsCert, tlsKey = getConfigValue(tlsCert, envPaddTLSCert, ""), getConfigValue(tlsKey, envPaddTLSKey, "")
+	if tlsCert != "" || tlsKey != "" {
+		opts = append(opts, WithTLS(tlsCert, tlsKey))
+	}
+	if autocertDomains = getConfigValue(autocertDomains, envPaddAutocert, ""); autocertDomains != "" {
+		cacheDir, err := getConfigDataDirectory(autocertCache, "", "certs")
+		if err != nil {
+			fatal("Error determining certificate cache directory", "error", err)
+		}
+		opts = append(opts, WithAutocert(strings.Split(autocertDomains, ","), cacheDir, autocertEmail))
+	}
+	opts = append(opts, WithHTTPRedirect(httpRedirectPort))
+	if passwordHash = getConfigValue(passwordHash, envPaddPassword, ""); passwordHash != "" {
+		auth, err := newAuthenticator(passwordHash, sessionDuration)
+		if err != nil {
+			fatal("Error setting up authentication", "error", err)
+		}
+		opts = append(opts, WithAuth(auth))
+	} else if !isLoopbackAddr(addr) {
+		logger.Warn("Serving without a password; set -password-hash to require signing in", "addr", addr)
+	}
+	if robotsFile != "" {
+		robotsTxt, err := os.ReadFile(robotsFile)
+		if err != nil {
+			fatal("Error reading robots file", "error", err)
+		}
+		opts = append(opts, WithRobotsTxt(string(robotsTxt)))
+	}
+	if webhookURL != "" {
+		opts = append(opts, WithWebhook(webhookURL))
+	}
+	if syncRemote != "" {
+		store, err := remote.Open(syncRemote)
+		if err != nil {
+			fatal("Error opening sync remote", "error", err)
+		}
+		opts = append(opts, WithSync(store, syncInterval))
+	}
+
+	if len(vaults) > 0 {
+		router, err := newVaults(ctx, vaults, identitiesFile, recipientsFile, opts)
+		if err != nil {
+			fatal("Error initializing vaults", "error", err)
+		}
+
+		if err = router.Start(addr, port); err != nil {
+			fatal("Error starting server", "error", err)
+		}
+		return
+	}
+
+	opts = append(opts, WithEncryptionManager(loadEncryptionManager(identitiesFile, recipientsFile)))
+	server, err := NewServer(ctx, dataDir, opts...)
 	if err != nil {
-		log.Fatal(fmt.Errorf("error initializing server: %v", err))
+		fatal("Error initializing server", "error", err)
 	}
 
 	err = server.Start(addr, port)
 	if err != nil {
-		log.Fatal(err)
+		fatal("Error starting server", "error", err)
+	}
+}
+
+// loadEncryptionManager returns an encryption manager with the given keys loaded. Encryption is disabled,
+// rather than failing, when the keys can't be loaded.
+func loadEncryptionManager(identitiesFile, recipientsFile string) *crypto.EncryptionManager {
+	encryptionManager := crypto.NewEncryptionManager()
+	if err := encryptionManager.LoadEncryptionKeys(identitiesFile, recipientsFile); err != nil {
+		slog.Warn("Error loading encryption keys; encryption disabled", "error", err)
+	} else if encryptionManager.IsLocked() {
+		slog.Info("Encryption enabled; identities are passphrase-protected, so unlock them at /unlock to read encrypted files")
+	} else {
+		slog.Info("Encryption enabled")
+	}
+
+	return encryptionManager
+}
+
+// newVaults creates a server for each vault, mounted under the vault's name, and a router in front of them.
+// Each vault uses the keys in its own keys directory when it has one, and the global keys otherwise.
+func newVaults(ctx context.Context, vaults vaultList, identitiesFile, recipientsFile string, opts []ServerOption) (*vaultRouter, error) {
+	names := make([]string, 0, len(vaults))
+	servers := make(map[string]*Server, len(vaults))
+	for _, vault := range vaults {
+		vaultIdentities, vaultRecipients := identitiesFile, recipientsFile
+		if vault.KeysDir != "" {
+			vaultIdentities, vaultRecipients = getDefaultKeys(vault.KeysDir)
+		}
+
+		slog.Info("Loading vault", "vault", vault.Name, "dir", vault.Dir)
+		vaultOpts := append(slices.Clone(opts),
+			WithEncryptionManager(loadEncryptionManager(vaultIdentities, vaultRecipients)),
+			WithBasePath("/"+vault.Name),
+		)
+		server, err := NewServer(ctx, vault.Dir, vaultOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("vault %s: %w", vault.Name, err)
+		}
+
+		names = append(names, vault.Name)
+		servers[vault.Name] = server
+	}
+
+	return newVaultRouter(names, servers)
+}
+
+// isLoopbackAddr reports whether the server address only accepts connections from this machine
+func isLoopbackAddr(addr string) bool {
+	if addr == "localhost" {
+		return true
 	}
+	ip := net.ParseIP(addr)
+	return ip != nil && ip.IsLoopback()
 }
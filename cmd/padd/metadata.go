@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"log"
 	"strings"
 
 	"github.com/patrickward/padd/internal/web"
@@ -18,6 +17,7 @@ type MetadataConfig struct {
 
 func (s *Server) addMetadataToPageData(data web.PageData, metadata map[string]any) web.PageData {
 	data.Encrypted = getMetadataBool(metadata, "encrypted", data.Encrypted)
+	data.Locked = getMetadataBool(metadata, "locked", data.Locked)
 	data.Description = getMetadataString(metadata, "description", data.Description)
 	data.Category = getMetadataString(metadata, "category", data.Category)
 	status := getMetadataString(metadata, "status", data.Status)
@@ -38,6 +38,8 @@ func (s *Server) addMetadataToPageData(data web.PageData, metadata map[string]an
 	return data
 }
 
+// setupMetadataConfig loads the badge colors from metadata.json in the data directory, falling back to the
+// defaults. It is safe to call while pages are rendering, so the config can be reloaded without a restart.
 func (s *Server) setupMetadataConfig() {
 	metadata := MetadataConfig{
 		StatusColors: map[string]string{
@@ -61,6 +63,9 @@ func (s *Server) setupMetadataConfig() {
 		ContextColor: "secondary muted",
 	}
 
+	// Colors from the config file apply first, so metadata.json in the data directory can override them
+	mergeMetadataColors(&metadata, s.configMetadata)
+
 	// Find the "metadata.json" file from the user's data directory
 	if s.rootManager.FileExists("metadata.json") {
 		content, err := s.rootManager.ReadFile("metadata.json")
@@ -69,73 +74,99 @@ func (s *Server) setupMetadataConfig() {
 			var fileMetadata map[string]any
 			err := json.Unmarshal(content, &fileMetadata)
 			if err != nil {
-				log.Printf("Error parsing metadata.json: %v\n", err)
+				s.logger.Warn("Error parsing metadata.json", "error", err)
 			}
 
 			if err == nil {
-				if statusColors, ok := fileMetadata["status_colors"].(map[string]any); ok {
-					for k, v := range statusColors {
-						if color, ok := v.(string); ok {
-							metadata.StatusColors[k] = color
-						}
-					}
-				}
-				if priorityColors, ok := fileMetadata["priority_colors"].(map[string]any); ok {
-					for k, v := range priorityColors {
-						if color, ok := v.(string); ok {
-							metadata.PriorityColors[k] = color
-						}
-					}
-				}
-				if dueColor, ok := fileMetadata["due_color"].(string); ok {
-					metadata.DueColor = dueColor
-				}
-				if tagColor, ok := fileMetadata["tag_color"].(string); ok {
-					metadata.TagColor = tagColor
-				}
-				if contextColor, ok := fileMetadata["context_color"].(string); ok {
-					metadata.ContextColor = contextColor
-				}
+				mergeMetadataColors(&metadata, fileMetadata)
 			}
 		}
 	}
 
+	s.metadataMu.Lock()
 	s.metadataConfig = metadata
+	s.metadataMu.Unlock()
+}
+
+// WithMetadataColors sets badge colors, with the keys of metadata.json, that apply unless metadata.json in the
+// data directory overrides them
+func WithMetadataColors(colors map[string]any) ServerOption {
+	return func(s *Server) error {
+		s.configMetadata = colors
+		s.setupMetadataConfig()
+		return nil
+	}
+}
+
+// mergeMetadataColors overrides the colors in metadata with those set in colors, which has the keys of
+// metadata.json
+func mergeMetadataColors(metadata *MetadataConfig, colors map[string]any) {
+	if statusColors, ok := colors["status_colors"].(map[string]any); ok {
+		for k, v := range statusColors {
+			if color, ok := v.(string); ok {
+				metadata.StatusColors[k] = color
+			}
+		}
+	}
+	if priorityColors, ok := colors["priority_colors"].(map[string]any); ok {
+		for k, v := range priorityColors {
+			if color, ok := v.(string); ok {
+				metadata.PriorityColors[k] = color
+			}
+		}
+	}
+	if dueColor, ok := colors["due_color"].(string); ok {
+		metadata.DueColor = dueColor
+	}
+	if tagColor, ok := colors["tag_color"].(string); ok {
+		metadata.TagColor = tagColor
+	}
+	if contextColor, ok := colors["context_color"].(string); ok {
+		metadata.ContextColor = contextColor
+	}
+}
+
+// metadata returns the current metadata config. Each load builds fresh maps, so the returned config is never
+// modified and can be read without holding the lock.
+func (s *Server) metadata() MetadataConfig {
+	s.metadataMu.RLock()
+	defer s.metadataMu.RUnlock()
+	return s.metadataConfig
 }
 
 func (s *Server) getStatusColor(status string) string {
-	if color, ok := s.metadataConfig.StatusColors[status]; ok {
+	if color, ok := s.metadata().StatusColors[status]; ok {
 		return color
 	}
 	return "neutral muted"
 }
 
 func (s *Server) getPriorityColor(priority string) string {
-	if color, ok := s.metadataConfig.PriorityColors[priority]; ok {
+	if color, ok := s.metadata().PriorityColors[priority]; ok {
 		return color
 	}
 	return "neutral muted"
 }
 
 func (s *Server) getDueColor() string {
-	if s.metadataConfig.DueColor != "" {
-		return s.metadataConfig.DueColor
+	if color := s.metadata().DueColor; color != "" {
+		return color
 	}
 
 	return "danger muted"
 }
 
 func (s *Server) getTagColor() string {
-	if s.metadataConfig.TagColor != "" {
-		return s.metadataConfig.TagColor
+	if color := s.metadata().TagColor; color != "" {
+		return color
 	}
 
 	return "primary muted"
 }
 
 func (s *Server) getContextColor() string {
-	if s.metadataConfig.ContextColor != "" {
-		return s.metadataConfig.ContextColor
+	if color := s.metadata().ContextColor; color != "" {
+		return color
 	}
 
 	return "secondary muted"
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the histogram buckets for request, render, and cache
+// refresh times
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram counts observed durations in durationBuckets
+type histogram struct {
+	counts []uint64 // Observations in each bucket, not including the smaller buckets
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(durationBuckets))
+	}
+
+	seconds := d.Seconds()
+	if i, _ := slices.BinarySearch(durationBuckets, seconds); i < len(durationBuckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// requestKey labels the request counter
+type requestKey struct {
+	method string
+	route  string
+	status int
+}
+
+// metrics collects the counters served at /metrics in the Prometheus text format
+type metrics struct {
+	mu               sync.Mutex
+	requests         map[requestKey]uint64
+	requestDurations map[string]*histogram // By route
+	renderDurations  map[string]*histogram // By page template
+	cacheRefreshes   histogram
+	cacheFiles       int
+	taskErrors       map[string]uint64 // By background task
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:         make(map[requestKey]uint64),
+		requestDurations: make(map[string]*histogram),
+		renderDurations:  make(map[string]*histogram),
+		taskErrors:       make(map[string]uint64),
+	}
+}
+
+// observeRequest counts a served request. Requests are labeled by the route pattern that served them, such
+// as "GET /{id...}", rather than by path, so the number of series stays small.
+func (m *metrics) observeRequest(method, route string, status int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{method: method, route: route, status: status}]++
+	observeInto(m.requestDurations, route, d)
+}
+
+// observeRender records how long a page template took to render
+func (m *metrics) observeRender(page string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	observeInto(m.renderDurations, page, d)
+}
+
+// observeCacheRefresh records a rebuild of the file caches
+func (m *metrics) observeCacheRefresh(refresh files.CacheRefresh) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cacheRefreshes.observe(refresh.Duration)
+	m.cacheFiles = refresh.Files
+}
+
+// countTaskError counts an error returned by a background task
+func (m *metrics) countTaskError(task string, _ error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.taskErrors[task]++
+}
+
+func observeInto(histograms map[string]*histogram, label string, d time.Duration) {
+	h, ok := histograms[label]
+	if !ok {
+		h = &histogram{}
+		histograms[label] = h
+	}
+	h.observe(d)
+}
+
+// write writes the metrics in the Prometheus text exposition format
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeHeader(w, "padd_http_requests_total", "counter", "Requests served, by method, route, and status code.")
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		return cmp.Or(strings.Compare(a.route, b.route), strings.Compare(a.method, b.method), cmp.Compare(a.status, b.status))
+	})
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "padd_http_requests_total{method=%s,route=%s,status=\"%d\"} %d\n",
+			quoteLabel(key.method), quoteLabel(key.route), key.status, m.requests[key])
+	}
+
+	writeHeader(w, "padd_http_request_duration_seconds", "histogram", "Time taken to serve requests, by route.")
+	writeHistograms(w, "padd_http_request_duration_seconds", "route", m.requestDurations)
+
+	writeHeader(w, "padd_template_render_duration_seconds", "histogram", "Time taken to render page templates, by template.")
+	writeHistograms(w, "padd_template_render_duration_seconds", "template", m.renderDurations)
+
+	writeHeader(w, "padd_cache_refresh_duration_seconds", "histogram", "Time taken to rebuild the file caches and search index.")
+	writeHistogram(w, "padd_cache_refresh_duration_seconds", "", &m.cacheRefreshes)
+
+	writeHeader(w, "padd_cache_files", "gauge", "Files in the file caches as of the last refresh.")
+	_, _ = fmt.Fprintf(w, "padd_cache_files %d\n", m.cacheFiles)
+
+	writeHeader(w, "padd_background_task_errors_total", "counter", "Errors returned by background tasks, by task.")
+	for _, task := range sortedKeys(m.taskErrors) {
+		_, _ = fmt.Fprintf(w, "padd_background_task_errors_total{task=%s} %d\n", quoteLabel(task), m.taskErrors[task])
+	}
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeHistograms(w io.Writer, name, label string, histograms map[string]*histogram) {
+	for _, value := range sortedKeys(histograms) {
+		writeHistogram(w, name, label+"="+quoteLabel(value), histograms[value])
+	}
+}
+
+// writeHistogram writes one histogram's cumulative buckets, sum, and count. labels is empty or a list of
+// label pairs without braces.
+func writeHistogram(w io.Writer, name, labels string, h *histogram) {
+	prefix := labels
+	if prefix != "" {
+		prefix += ","
+	}
+
+	var cumulative uint64
+	for i, bound := range durationBuckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		_, _ = fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, prefix, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	_, _ = fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, prefix, h.count)
+
+	braces := ""
+	if labels != "" {
+		braces = "{" + labels + "}"
+	}
+	_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", name, braces, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	_, _ = fmt.Fprintf(w, "%s_count%s %d\n", name, braces, h.count)
+}
+
+// quoteLabel quotes a label value, escaping the characters the text format requires
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// handleMetrics serves the server's metrics in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.metrics.write(w)
+}
+
+// WithAccessLog logs every request with its method, path, status code, and duration
+func WithAccessLog(enabled bool) ServerOption {
+	return func(s *Server) error {
+		s.accessLog = enabled
+		return nil
+	}
+}
+
+// observeRequests wraps a handler so every request is counted in the metrics and, when the access log is
+// on, logged. Requests are labeled with the route of mux that serves them.
+func (s *Server) observeRequests(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		elapsed := time.Since(start)
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		s.metrics.observeRequest(r.Method, route, sw.status, elapsed)
+
+		if s.accessLog {
+			s.logger.Info("request",
+				"method", r.Method,
+				"path", s.basePath+r.URL.Path,
+				"status", sw.status,
+				"duration", elapsed,
+				"bytes", sw.bytes,
+			)
+		}
+	})
+}
+
+// statusWriter records the status code and size of a response
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if !sw.wroteHeader {
+		sw.status = status
+		sw.wroteHeader = true
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	sw.wroteHeader = true
+	n, err := sw.ResponseWriter.Write(p)
+	sw.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so streaming responses can flush
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
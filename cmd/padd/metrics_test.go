@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleMetrics(t *testing.T) {
+	server := newTestServer(t)
+	handler := server.setupRoutes()
+
+	for _, path := range []string{"/inbox", "/inbox", "/missing/page"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	server.backgroundRunner.StartOneTimeTask("failing", func(ctx context.Context) error {
+		return errors.New("disk full")
+	})
+	taskErrors := func() uint64 {
+		server.metrics.mu.Lock()
+		defer server.metrics.mu.Unlock()
+		return server.metrics.taskErrors["failing"]
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for taskErrors() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `padd_http_requests_total{method="GET",route="GET /{id...}",status="200"} 2`))
+	assert.True(t, strings.Contains(body, `padd_http_requests_total{method="GET",route="GET /{id...}",status="404"} 1`))
+	assert.True(t, strings.Contains(body, `padd_http_request_duration_seconds_count{route="GET /{id...}"} 3`))
+	assert.True(t, strings.Contains(body, `padd_template_render_duration_seconds_bucket{template="view.html",le="+Inf"} 2`))
+	assert.MatchesRegexp(t, body, `(?m)^padd_cache_refresh_duration_seconds_count [1-9]`)
+	assert.MatchesRegexp(t, body, `(?m)^padd_cache_files [1-9]`)
+	assert.True(t, strings.Contains(body, `padd_background_task_errors_total{task="failing"} 1`))
+}
+
+func TestHandleMetrics_BearerToken(t *testing.T) {
+	server := newTestServer(t, WithAuth(newTestAuthenticator(t, "correct horse")), WithWriteToken("s3cret"))
+	handler := server.setupRoutes()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, rec.Code, http.StatusFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	h.observe(3 * time.Millisecond)
+	h.observe(300 * time.Millisecond)
+	h.observe(time.Minute)
+
+	var out strings.Builder
+	writeHistogram(&out, "test_seconds", `route="x"`, &h)
+
+	body := out.String()
+	assert.True(t, strings.Contains(body, `test_seconds_bucket{route="x",le="0.005"} 1`+"\n"))
+	assert.True(t, strings.Contains(body, `test_seconds_bucket{route="x",le="0.25"} 1`+"\n"))
+	assert.True(t, strings.Contains(body, `test_seconds_bucket{route="x",le="0.5"} 2`+"\n"))
+	assert.True(t, strings.Contains(body, `test_seconds_bucket{route="x",le="10"} 2`+"\n"))
+	assert.True(t, strings.Contains(body, `test_seconds_bucket{route="x",le="+Inf"} 3`+"\n"))
+	assert.True(t, strings.Contains(body, `test_seconds_count{route="x"} 3`+"\n"))
+	assert.Equal(t, quoteLabel("a\"b\\c\n"), `"a\"b\\c\n"`)
+}
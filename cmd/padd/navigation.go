@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/patrickward/padd/internal/contentutil"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// navFile is the file in the data directory that configures the navigation menu
+const navFile = "nav.json"
+
+// navItem is an entry in the navigation menu: daily, journal, resources, or any file or directory by its ID
+type navItem struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"` // Shown instead of the file's title when set
+}
+
+// navConfig is the content of nav.json. Items are shown in order, so leaving one out hides it.
+type navConfig struct {
+	Items []navItem `json:"items"`
+}
+
+// builtinNavItem returns the menu entry of the daily, journal, or resources directory, which aren't looked up
+// in the file index, reporting false for any other ID
+func (s *Server) builtinNavItem(id string) (files.FileInfo, bool) {
+	config := s.fileRepo.Config()
+	title := contentutil.TitleCase(id)
+	info := files.FileInfo{ID: id, Path: id, Title: title, TitleBase: title, IsDirectory: true}
+
+	switch id {
+	case config.DailyDirectory, config.JournalDirectory:
+		info.IsTemporal = true
+	case config.ResourcesDirectory:
+		info.IsResource = true
+	default:
+		return files.FileInfo{}, false
+	}
+
+	return info, true
+}
+
+// defaultNavigation is the navigation menu when nav.json doesn't configure one: the core files, then the
+// daily, journal, and resources directories
+func (s *Server) defaultNavigation() []navItem {
+	config := s.fileRepo.Config()
+
+	var items []navItem
+	for _, file := range config.CoreFiles {
+		items = append(items, navItem{ID: s.fileRepo.CreateID(file)})
+	}
+	return append(items, navItem{ID: config.DailyDirectory}, navItem{ID: config.JournalDirectory}, navItem{ID: config.ResourcesDirectory})
+}
+
+// setupNavigation loads the navigation menu from nav.json in the data directory, falling back to the
+// default menu. Like setupMetadataConfig, it is safe to call while pages are rendering.
+func (s *Server) setupNavigation() {
+	items := s.defaultNavigation()
+
+	if s.rootManager.FileExists(navFile) {
+		content, err := s.rootManager.ReadFile(navFile)
+		if err == nil {
+			var config navConfig
+			if err := json.Unmarshal(content, &config); err != nil {
+				s.logger.Warn("Error parsing nav.json", "error", err)
+			} else if config.Items != nil {
+				items = config.Items
+			}
+		}
+	}
+
+	s.metadataMu.Lock()
+	s.navItems = items
+	s.metadataMu.Unlock()
+}
+
+// navigation returns the configured navigation menu items
+func (s *Server) navigation() []navItem {
+	s.metadataMu.RLock()
+	defer s.metadataMu.RUnlock()
+	return s.navItems
+}
+
+// navFileInfo resolves a navigation item to the file info the menu shows, reporting false when the item's
+// file or directory doesn't exist
+func (s *Server) navFileInfo(item navItem, current string) (files.FileInfo, bool) {
+	info, builtin := s.builtinNavItem(item.ID)
+	if !builtin {
+		found, err := s.fileRepo.FileInfo(item.ID)
+		if err != nil {
+			return files.FileInfo{}, false
+		}
+		info = files.FileInfo{
+			ID:          found.ID,
+			Path:        found.Path,
+			Title:       found.TitleBase,
+			TitleBase:   found.TitleBase,
+			IsTemporal:  found.IsTemporal,
+			IsResource:  found.IsResource,
+			IsDirectory: found.IsDirectory,
+		}
+	}
+
+	if item.Title != "" {
+		info.Title = item.Title
+	}
+	// Directories are active for any file below them
+	info.IsNavActive = isNavActive(current, info.ID, info.IsDirectory)
+
+	return info, true
+}
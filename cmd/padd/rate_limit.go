@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a simple fixed-window rate limiter shared by the write-ish endpoints.
+// PADD is a single-user app, so a single global window is enough to stop runaway clients.
+type rateLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	count       int
+}
+
+// newRateLimiter creates a rate limiter allowing limit requests per window
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Allow reports whether another request is allowed in the current window
+func (rl *rateLimiter) Allow() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rl.windowStart) >= rl.window {
+		rl.windowStart = now
+		rl.count = 0
+	}
+
+	if rl.count >= rl.limit {
+		return false
+	}
+
+	rl.count++
+	return true
+}
+
+// rateLimited wraps a handler with the server's write rate limiter
+func (s *Server) rateLimited(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.writeLimiter.Allow() {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next(w, r)
+	}
+}
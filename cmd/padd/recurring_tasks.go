@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// defaultRecurringInterval is how often completed recurring tasks are checked for renewal
+const defaultRecurringInterval = time.Hour
+
+// recurrenceTargets maps the -recurring-target flag values to where renewed tasks are added
+var recurrenceTargets = map[string]files.RecurrenceTarget{
+	"section": files.RecurInPlace,
+	"inbox":   files.RecurToInbox,
+}
+
+// WithRecurringTasks renews completed @repeat and @every tasks every interval, adding the new pending task
+// either to the completed task's section ("section") or to the inbox ("inbox"). An interval of 0 disables
+// renewal.
+func WithRecurringTasks(interval time.Duration, target string) ServerOption {
+	return func(s *Server) error {
+		if interval < 0 {
+			return fmt.Errorf("invalid recurring task interval: %v", interval)
+		}
+
+		recurrenceTarget, ok := recurrenceTargets[target]
+		if !ok {
+			return fmt.Errorf("invalid recurring task target %q (expected section or inbox)", target)
+		}
+
+		s.recurringInterval = interval
+		s.recurringTarget = recurrenceTarget
+		return nil
+	}
+}
+
+// setupRecurringTasks starts the background task that renews recurring tasks, if renewal is enabled
+func (s *Server) setupRecurringTasks() {
+	if s.recurringInterval <= 0 {
+		return
+	}
+
+	manager := files.NewRecurringTaskManager(s.fileRepo, s.recurringTarget)
+	s.backgroundRunner.AddPeriodicTask("recurring-tasks", s.recurringInterval, func(ctx context.Context) error {
+		renewed, err := manager.Run(ctx, time.Now())
+		if renewed > 0 {
+			s.logger.Info("Renewed recurring tasks", "count", renewed)
+		}
+		return err
+	})
+}
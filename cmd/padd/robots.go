@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io"
+	"net/http"
+)
+
+// defaultRobotsTxt asks all crawlers to stay away, since a notes server has nothing worth indexing
+const defaultRobotsTxt = "User-agent: *\nDisallow: /\n"
+
+// WithRobotsTxt sets the body served at /robots.txt
+func WithRobotsTxt(content string) ServerOption {
+	return func(s *Server) error {
+		s.robotsTxt = content
+		return nil
+	}
+}
+
+// WithPrivate marks the deployment as private, so document views carry an X-Robots-Tag: noindex
+// header for crawlers that ignore robots.txt
+func WithPrivate(private bool) ServerOption {
+	return func(s *Server) error {
+		s.private = private
+		return nil
+	}
+}
+
+func (s *Server) handleRobots(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, s.robotsTxt)
+}
+
+// setNoIndex adds the noindex header to the response when the server is private
+func (s *Server) setNoIndex(w http.ResponseWriter) {
+	if s.private {
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
+}
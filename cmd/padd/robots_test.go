@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestHandleRobots(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []ServerOption
+		want string
+	}{
+		{name: "default disallows all", want: "User-agent: *\nDisallow: /\n"},
+		{name: "configured", opts: []ServerOption{WithRobotsTxt("User-agent: *\nAllow: /\n")}, want: "User-agent: *\nAllow: /\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.opts...)
+			req := httptest.NewRequest(http.MethodGet, "/robots.txt", nil)
+			rec := httptest.NewRecorder()
+
+			server.setupRoutes().ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, http.StatusOK)
+			assert.Equal(t, rec.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+			assert.Equal(t, rec.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestHandleView_NoIndexHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		private bool
+		want    string
+	}{
+		{name: "private", private: true, want: "noindex"},
+		{name: "public", private: false, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, WithPrivate(tt.private))
+			req := httptest.NewRequest(http.MethodGet, "/inbox", nil)
+			rec := httptest.NewRecorder()
+
+			server.setupRoutes().ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, http.StatusOK)
+			assert.Equal(t, rec.Header().Get("X-Robots-Tag"), tt.want)
+		})
+	}
+}
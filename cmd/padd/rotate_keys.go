@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// rotatingSuffix marks the temporary copy a re-encrypted file is written to before it replaces the original
+const rotatingSuffix = ".rotating"
+
+// rotateSummary counts the outcome of a key rotation
+type rotateSummary struct {
+	Rotated []string // Paths re-encrypted for the new recipients
+	Failed  int      // Encrypted files that couldn't be decrypted or rewritten
+	Skipped int      // Plaintext files, left as they are
+}
+
+// rotateKeys re-encrypts every age-encrypted file in the data directory for the recipients of newKeys. Each
+// file is decrypted with the identities of oldKeys, written to a temporary file, and then renamed over the
+// original, so an interrupted rotation never leaves a file half-written. Progress is written to out, and a
+// file that fails is reported and skipped rather than stopping the rotation.
+func rotateKeys(rootManager *files.RootManager, oldKeys, newKeys *crypto.EncryptionManager, out io.Writer) (rotateSummary, error) {
+	var summary rotateSummary
+
+	if !oldKeys.HasIdentities() {
+		return summary, fmt.Errorf("no identities are loaded to decrypt the existing files")
+	}
+	if !newKeys.HasRecipients() {
+		return summary, fmt.Errorf("no new recipients are loaded to encrypt the files for")
+	}
+
+	err := rootManager.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		content, err := rootManager.ReadFile(path)
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "failed  %s: %v\n", path, err)
+			summary.Failed++
+			return nil
+		}
+
+		if !crypto.IsAgeEncrypted(content) {
+			summary.Skipped++
+			return nil
+		}
+
+		if err := rotateFile(rootManager, path, content, oldKeys, newKeys); err != nil {
+			_, _ = fmt.Fprintf(out, "failed  %s: %v\n", path, err)
+			summary.Failed++
+			return nil
+		}
+
+		_, _ = fmt.Fprintf(out, "rotated %s\n", path)
+		summary.Rotated = append(summary.Rotated, path)
+		return nil
+	})
+	if err != nil {
+		return summary, fmt.Errorf("error walking the data directory: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(out, "\nRotated %d files, %d failed, %d unencrypted files skipped\n",
+		len(summary.Rotated), summary.Failed, summary.Skipped)
+
+	return summary, nil
+}
+
+// rotateFile decrypts the content of the file at path with oldKeys and replaces the file with the content
+// encrypted for newKeys
+func rotateFile(rootManager *files.RootManager, path string, content []byte, oldKeys, newKeys *crypto.EncryptionManager) error {
+	decrypted, err := oldKeys.Decrypt(content)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := newKeys.Encrypt(decrypted)
+	if err != nil {
+		return err
+	}
+
+	stat, err := rootManager.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + rotatingSuffix
+	if err := rootManager.WriteFile(tmpPath, encrypted, stat.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+
+	if err := rootManager.Rename(tmpPath, path); err != nil {
+		_ = rootManager.Remove(tmpPath)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// runRotateKeys rotates the keys of the data directory from the identities in identitiesFile to the
+// recipients in newRecipientsFile, committing the re-encrypted files in one revision when version history
+// is on. It fails if any file couldn't be rotated, so scripts can tell the old keys are still needed.
+func runRotateKeys(dataDir, identitiesFile, newRecipientsFile string, history bool) error {
+	if identitiesFile == "" {
+		return fmt.Errorf("an identity file is required to decrypt the existing files")
+	}
+	if newRecipientsFile == "" {
+		return fmt.Errorf("-new-recipient is required to rotate keys")
+	}
+
+	oldKeys := crypto.NewEncryptionManager()
+	if err := oldKeys.AddIdentitiesFromFile(identitiesFile); err != nil {
+		return fmt.Errorf("failed to load identity file %s: %w", identitiesFile, err)
+	}
+	if oldKeys.IsLocked() {
+		return fmt.Errorf("identity file %s is passphrase-protected; decrypt it with age before rotating keys", identitiesFile)
+	}
+
+	newKeys := crypto.NewEncryptionManager()
+	if err := newKeys.AddRecipientsFromFile(newRecipientsFile); err != nil {
+		return fmt.Errorf("failed to load recipient file %s: %w", newRecipientsFile, err)
+	}
+
+	rootManager, err := files.NewRootManager(dataDir)
+	if err != nil {
+		return err
+	}
+
+	summary, err := rotateKeys(rootManager, oldKeys, newKeys, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if len(summary.Rotated) > 0 && (history || files.IsGitRepository(dataDir)) {
+		store, err := files.NewGitVersionStore(dataDir)
+		if err != nil {
+			return fmt.Errorf("could not record the rotation in version history: %w", err)
+		}
+		if err := store.Record("Rotate encryption keys", summary.Rotated...); err != nil {
+			return fmt.Errorf("could not record the rotation in version history: %w", err)
+		}
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d files could not be rotated", summary.Failed)
+	}
+
+	return nil
+}
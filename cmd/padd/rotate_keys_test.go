@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// newTestKeys returns an encryption manager with a new key pair loaded as both identity and recipient
+func newTestKeys(t *testing.T) *crypto.EncryptionManager {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	assert.Nil(t, err)
+
+	manager := crypto.NewEncryptionManager()
+	assert.Nil(t, manager.AddIdentity(identity.String()))
+	assert.Nil(t, manager.AddRecipient(identity.Recipient().String()))
+	manager.Activate()
+	return manager
+}
+
+func TestRotateKeys(t *testing.T) {
+	rootManager, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	oldKeys, newKeys, otherKeys := newTestKeys(t), newTestKeys(t), newTestKeys(t)
+
+	writeEncrypted := func(path, content string, keys *crypto.EncryptionManager) {
+		encrypted, err := keys.Encrypt(content)
+		assert.Nil(t, err)
+		assert.Nil(t, rootManager.WriteFile(path, encrypted, 0600))
+	}
+
+	assert.Nil(t, rootManager.MkdirAll("resources/private", 0755))
+	writeEncrypted("resources/private/secret.md", "# Secret\n", oldKeys)
+	writeEncrypted("journal.md", "# Journal\n", oldKeys)
+	writeEncrypted("resources/foreign.md", "# Foreign\n", otherKeys)
+	assert.Nil(t, rootManager.WriteString("inbox.md", "# Inbox\n"))
+
+	var out bytes.Buffer
+	summary, err := rotateKeys(rootManager, oldKeys, newKeys, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, len(summary.Rotated), 2)
+	assert.Equal(t, summary.Failed, 1)
+	assert.Equal(t, summary.Skipped, 1)
+	assert.True(t, strings.Contains(out.String(), "rotated resources/private/secret.md"))
+	assert.True(t, strings.Contains(out.String(), "failed  resources/foreign.md"))
+	assert.True(t, strings.Contains(out.String(), "Rotated 2 files, 1 failed, 1 unencrypted files skipped"))
+
+	// Rotated files open with the new identity only, and keep their permissions
+	raw, err := rootManager.ReadFile("resources/private/secret.md")
+	assert.Nil(t, err)
+	content, err := newKeys.Decrypt(raw)
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Secret\n")
+	_, err = oldKeys.Decrypt(raw)
+	assert.NotNil(t, err)
+
+	stat, err := rootManager.Stat("resources/private/secret.md")
+	assert.Nil(t, err)
+	assert.Equal(t, stat.Mode().Perm(), 0600)
+	assert.False(t, rootManager.FileExists("resources/private/secret.md"+rotatingSuffix))
+
+	// Files the old identity can't open and plaintext files are left alone
+	raw, err = rootManager.ReadFile("resources/foreign.md")
+	assert.Nil(t, err)
+	_, err = otherKeys.Decrypt(raw)
+	assert.Nil(t, err)
+
+	raw, err = rootManager.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Inbox\n")
+}
+
+func TestRotateKeys_RequiresKeys(t *testing.T) {
+	rootManager, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	_, err = rotateKeys(rootManager, crypto.NewEncryptionManager(), newTestKeys(t), &bytes.Buffer{})
+	assert.NotNil(t, err)
+
+	_, err = rotateKeys(rootManager, newTestKeys(t), crypto.NewEncryptionManager(), &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
@@ -6,44 +6,120 @@ import (
 	"github.com/patrickward/padd"
 )
 
+// reservedRoutePrefixes are the first path segments of the fixed routes. The resources, daily, and journal
+// directories are served under their own names, so they can't use one of these.
+var reservedRoutePrefixes = []string{
+	"add", "api", "archive", "board", "calendar", "csv", "delete", "edit", "events", "export", "frontmatter", "graph",
+	"history", "images", "lock", "login", "logout", "maintenance", "metrics", "move", "on-this-day", "page-header",
+	"reports", "review", "robots.txt", "search", "settings", "static", "tables", "tasks", "unlock", "vaults",
+}
+
 func (s *Server) setupRoutes() http.Handler {
 	mux := http.NewServeMux()
+	config := s.fileRepo.Config()
 
 	// Serve static files
 	fileServer := http.FileServer(http.FS(padd.StaticFS))
 	mux.Handle("GET /static/", fileServer)
+	mux.HandleFunc("GET /robots.txt", s.handleRobots)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /events", s.handleEvents)
 
 	// Serve images (both embedded defaults and user-provided)
+	mux.HandleFunc("GET /images/thumbs/{name}", s.handleImageThumbnail)
 	mux.Handle("GET /images/", s.handleImages())
 	mux.HandleFunc("GET /api/icons", s.handleIconsAPI)
-	mux.HandleFunc("POST /api/images/upload", s.handleImageUpload)
+	mux.HandleFunc("POST /api/images/upload", s.rateLimited(s.handleImageUpload))
+	mux.HandleFunc("POST /api/images/paste", s.rateLimited(s.handleImagePaste))
+	mux.HandleFunc("GET /api/images", s.handleUploadedImagesAPI)
+	mux.HandleFunc("POST /api/preview", s.rateLimited(s.handlePreview))
+	mux.HandleFunc("GET /api/doc/{path...}", s.handleDocumentAPI)
+	mux.HandleFunc("POST /api/doc/{path...}", s.rateLimited(s.handleDocumentAPI))
+	mux.HandleFunc("GET /api/meta/{key}", s.handleMetadataValuesAPI)
+	mux.HandleFunc("GET /api/graph", s.handleGraphAPI)
+	mux.HandleFunc("GET /api/tasks", s.handleTasksAPI)
+	mux.HandleFunc("POST /api/reload-config", s.rateLimited(s.handleReloadConfig))
+	mux.HandleFunc("POST /api/clip", s.rateLimited(s.handleClip))
+	mux.HandleFunc("OPTIONS /api/clip", s.handleClipPreflight)
 
 	// Tasks
 	mux.HandleFunc("PATCH /tasks/toggle/{id...}", s.handleTaskToggle)
 	mux.HandleFunc("GET /tasks/edit/{id...}", s.handleTaskEdit)
 	mux.HandleFunc("GET /tasks/show/{id...}", s.handleTaskShow)
 	mux.HandleFunc("POST /tasks/complete/{id...}", s.handleArchiveDoneTasks)
+	mux.HandleFunc("POST /tasks/move/{id...}", s.handleTaskMove)
 	mux.HandleFunc("PATCH /tasks/{id...}", s.handleTaskUpdate)
 	mux.HandleFunc("DELETE /tasks/{id...}", s.handleTaskDelete)
 
+	// Markdown tables
+	mux.HandleFunc("GET /tables/edit/{table}/{row}/{col}", s.handleTableCellEdit)
+	mux.HandleFunc("GET /tables/show/{table}/{row}/{col}", s.handleTableCellShow)
+	mux.HandleFunc("PATCH /tables/{table}/{row}/{col}", s.handleTableCellUpdate)
+
+	// CSV files
+	mux.HandleFunc("GET /csv/cells/edit/{row}/{col}", s.handleCSVCellEdit)
+	mux.HandleFunc("GET /csv/cells/show/{row}/{col}", s.handleCSVCellShow)
+	mux.HandleFunc("PATCH /csv/cells/{row}/{col}", s.handleCSVCellUpdate)
+	mux.HandleFunc("POST /csv/columns/add/{id...}", s.rateLimited(s.handleCSVAddColumn))
+	mux.HandleFunc("POST /csv/columns/delete/{id...}", s.rateLimited(s.handleCSVDeleteColumn))
+	mux.HandleFunc("POST /csv/columns/rename/{id...}", s.rateLimited(s.handleCSVRenameColumn))
+	mux.HandleFunc("POST /csv/columns/move/{id...}", s.rateLimited(s.handleCSVMoveColumn))
+	mux.HandleFunc("POST /csv/import", s.rateLimited(s.handleCSVImport))
+	mux.HandleFunc("GET /csv/export/{id...}", s.handleCSVExport)
+
 	// Content
 	mux.HandleFunc("GET /edit/{id...}", s.handleEdit)
-	mux.HandleFunc("GET /daily/archive", s.handleTemporalArchive)
-	mux.HandleFunc("GET /daily", s.handleTemporalRoot("daily"))
-	mux.HandleFunc("POST /daily", s.handleAddTemporalEntry("daily"))
+	mux.HandleFunc("GET /export/{id...}", s.handleExport)
+	// Temporal and resource pages are served under their directories' names
+	for _, directory := range config.TemporalDirectories() {
+		mux.HandleFunc("GET /"+directory+"/archive", s.handleTemporalArchive(directory))
+		mux.HandleFunc("GET /"+directory, s.handleTemporalRoot(directory))
+		mux.HandleFunc("POST /"+directory, s.handleAddTemporalEntry(directory))
+	}
 	mux.HandleFunc("POST /add/{id...}", s.handleAddEntry)
-	mux.HandleFunc("GET /journal/archive", s.handleTemporalArchive)
-	mux.HandleFunc("GET /journal", s.handleTemporalRoot("journal"))
-	mux.HandleFunc("POST /journal", s.handleAddTemporalEntry("journal"))
 	mux.HandleFunc("GET /search", s.handleSearch)
-	mux.HandleFunc("GET /resources", s.handleResources)
-	mux.HandleFunc("POST /resources", s.handleCreateResource)
-	mux.HandleFunc("POST /resources/refresh", s.handleRefreshResources)
+	mux.HandleFunc("GET /"+config.ResourcesDirectory, s.handleResources)
+	mux.HandleFunc("POST /"+config.ResourcesDirectory, s.handleCreateResource)
+	mux.HandleFunc("POST /"+config.ResourcesDirectory+"/refresh", s.handleRefreshResources)
+	mux.HandleFunc("POST /move/{id...}", s.rateLimited(s.handleMoveResource))
+	mux.HandleFunc("POST /delete/{id...}", s.rateLimited(s.handleDeleteResource))
+	mux.HandleFunc("POST /archive/{id...}", s.rateLimited(s.handleArchiveResource))
 	mux.HandleFunc("GET /page-header/{id...}", s.handlePageHeader)
-	mux.HandleFunc("POST /{id...}", s.handleSave)
+	mux.HandleFunc("GET /frontmatter/edit/{id...}", s.handleFrontmatterEdit)
+	mux.HandleFunc("POST /frontmatter/{id...}", s.rateLimited(s.handleFrontmatterUpdate))
+	mux.HandleFunc("GET /calendar", s.handleCalendar)
+	mux.HandleFunc("GET /calendar/{type}/{year}/{month}", s.handleMonthCalendar)
+	mux.HandleFunc("GET /review/weekly", s.handleWeeklyReview)
+	mux.HandleFunc("GET /on-this-day", s.handleOnThisDay)
+	mux.HandleFunc("GET /board/{id...}", s.handleBoard)
+	mux.HandleFunc("POST /board/{id...}", s.rateLimited(s.handleBoardMove))
+	mux.HandleFunc("GET /graph", s.handleGraph)
+	mux.HandleFunc("GET /settings", s.handleSettings)
+	mux.HandleFunc("POST /settings/backup", s.rateLimited(s.handleBackupNow))
+	mux.HandleFunc("GET /maintenance/links", s.handleMovedLinkReport)
+	mux.HandleFunc("GET /reports/links", s.handleLinkReport)
+	mux.HandleFunc("POST /reports/links/check", s.rateLimited(s.handleCheckLinksNow))
+	mux.HandleFunc("GET /maintenance/archive", s.handleArchivePage)
+	mux.HandleFunc("GET /maintenance/archive/export", s.handleArchiveExport)
+	mux.HandleFunc("POST /maintenance/archive/import", s.rateLimited(s.handleArchiveImport))
+	mux.HandleFunc("GET /history/{id...}", s.handleHistory)
+	mux.HandleFunc("POST /history/{id...}", s.rateLimited(s.handleRestoreRevision))
+	mux.HandleFunc("GET /vaults/{name}", s.handleSwitchVault)
+	mux.HandleFunc("GET /login", s.handleLoginPage)
+	mux.HandleFunc("POST /login", s.rateLimited(s.handleLogin))
+	mux.HandleFunc("POST /logout", s.handleLogout)
+	mux.HandleFunc("GET /unlock", s.handleUnlockPage)
+	mux.HandleFunc("POST /unlock", s.rateLimited(s.handleUnlock))
+	mux.HandleFunc("POST /lock", s.handleLock)
+	mux.HandleFunc("POST /{id...}", s.rateLimited(s.handleSave))
 
 	// Handles page views and root
 	mux.HandleFunc("GET /{id...}", s.handleView)
 
-	return mux
+	handler := s.observeRequests(mux, s.requireAuth(s.requireWriteToken(s.limitRequestBody(mux))))
+	if s.basePath != "" {
+		handler = s.prefixBasePath(handler)
+	}
+
+	return handler
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/web"
+)
+
+// runSavedSearch runs the "query" frontmatter of the note with the given ID, leaving the note itself out of
+// the results. A query that can't be parsed is returned with the reason, which the page shows in place of the
+// results.
+func (s *Server) runSavedSearch(id, query string) *web.SavedSearchData {
+	data := &web.SavedSearchData{Query: query}
+
+	q, err := files.ParseNoteQuery(query)
+	if err != nil {
+		data.Error = err.Error()
+		return data
+	}
+
+	result := s.fileRepo.RunNoteQuery(q)
+	data.Tasks = q.Tasks
+	data.Files = slices.DeleteFunc(result.Files, func(info files.FileInfo) bool {
+		return info.ID == id
+	})
+	data.Items = slices.DeleteFunc(result.Tasks, func(task files.FileTask) bool {
+		return task.FileID == id
+	})
+
+	return data
+}
@@ -5,34 +5,94 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/patrickward/padd/internal/crypto"
 	"github.com/patrickward/padd/internal/files"
 	"github.com/patrickward/padd/internal/flash"
+	"github.com/patrickward/padd/internal/remote"
 	"github.com/patrickward/padd/internal/rendering"
 	"github.com/patrickward/padd/internal/workers"
 )
 
 // Server holds the application state and configuration
 type Server struct {
-	dataDir          string
-	rootManager      *files.RootManager
-	fileRepo         *files.FileRepository
-	flashManager     *flash.Manager
-	backgroundRunner *workers.BackgroundWorker
-	renderer         *rendering.MarkdownRenderer
-	baseTempl        *template.Template // Common templates (layouts, partials)
-	httpServer       *http.Server
-	metadataConfig   MetadataConfig
+	dataDir           string
+	rootManager       *files.RootManager
+	fileRepo          *files.FileRepository
+	flashManager      *flash.Manager
+	backgroundRunner  *workers.BackgroundWorker
+	renderer          *rendering.MarkdownRenderer
+	baseTempl         *template.Template // Common templates (layouts, partials)
+	httpServer        *http.Server
+	metadataConfig    MetadataConfig
+	metadataMu        sync.RWMutex   // Guards metadataConfig and navItems, which can be reloaded while serving requests
+	navItems          []navItem      // Navigation menu from nav.json
+	configMetadata    map[string]any // Badge colors from the config file, overridden by metadata.json
+	writeLimiter      *rateLimiter
+	searchMinLength   int  // Minimum search query length, in characters
+	searchMaxLength   int  // Maximum search query length, in characters
+	cacheWarmers      int  // Number of concurrent document cache warmers; 0 disables warming
+	watchFiles        bool // Whether to watch the data directory for changes made outside the application
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	maxBodyBytes      int64  // Maximum request body size for POST, PUT, and PATCH requests
+	clipTarget        string // File ID that web clips are added to
+	clipAllowedOrigin string // Origin allowed to post clips cross-origin ("*" for any); empty disables CORS
+	webhookURL        string // URL notified when documents change; empty disables webhooks
+	webhookTimeout    time.Duration
+	webhookAttempts   int
+	webhookBackoff    time.Duration
+	robotsTxt         string         // Body served at /robots.txt
+	private           bool           // Whether document views ask crawlers not to index them
+	summaryDays       int            // Days of recent entries shown at /daily and /journal; 0 redirects to the current month
+	writeToken        string         // Shared secret required by requests that change data; empty disables the check
+	auth              *authenticator // Requires signing in with a password; nil leaves the server open
+	basePath          string         // Path prefix the server is mounted under when serving one of several vaults
+	vaults            []string       // Names of all the vaults served alongside this one, for the vault switcher
+	lockAfter         time.Duration  // How long passphrase-protected identities stay unlocked; 0 is until locked by hand
+	lockTimer         *time.Timer    // Locks the identities again once lockAfter has passed
+	lockMu            sync.Mutex     // Guards lockTimer
+	liveEvents        *liveEventHub  // Publishes document changes to the open /events streams
+	recurringInterval time.Duration  // How often recurring tasks are renewed; 0 disables renewal
+	recurringTarget   files.RecurrenceTarget
+	imageMaxWidth     int            // Uploaded photos are scaled down to fit this width; 0 leaves the width unbounded
+	imageMaxHeight    int            // Uploaded photos are scaled down to fit this height; 0 leaves the height unbounded
+	thumbSize         int            // Width and height that thumbnails fit within
+	keepOriginals     bool           // Whether uploads that are scaled down are also saved as uploaded, in images/originals
+	backups           *backupManager // Takes scheduled and manual backups; nil when backups are off
+	syncer            *remote.Syncer // Syncs the data directory with a remote store; nil when sync is off
+	syncInterval      time.Duration  // How often the data directory is synced; 0 leaves it to the sync subcommand
+	linkChecker       *linkChecker   // Checks external links in the background; nil when external links aren't checked
+	tls               *tlsSettings   // Serves HTTPS when a certificate or Let's Encrypt is configured; nil serves plain HTTP
+	metrics           *metrics       // Request, render, cache, and background task counters served at /metrics
+	accessLog         bool           // Whether every request is logged
+	logger            *slog.Logger   // Logs server events; the default logger unless WithLogger is given
+	cacheRefresh      time.Duration  // How often stale resource caches are reloaded in the background
 }
 
+// Default HTTP server timeouts
+const (
+	defaultReadTimeout  = 5 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+	defaultIdleTimeout  = time.Minute
+)
+
+// defaultCacheRefresh is how often stale resource caches are reloaded in the background
+const defaultCacheRefresh = 5 * time.Minute
+
+// watchDebounce is how long the file watcher waits for a burst of external changes to settle
+const watchDebounce = 250 * time.Millisecond
+
 // ServerOption for configuring the server with functional options pattern
 type ServerOption func(*Server) error
 
@@ -60,39 +120,269 @@ func NewServer(ctx context.Context, dataDir string, opts ...ServerOption) (*Serv
 		fileRepo:         fileRepo,
 		renderer:         renderer,
 		baseTempl:        tmpl,
-		flashManager:     flash.NewManager(),
+		flashManager:     flash.NewManager(flash.DefaultMaxAge),
 		backgroundRunner: backgroundRunner,
+		writeLimiter:     newRateLimiter(30, time.Second),
+		searchMinLength:  defaultSearchMinLength,
+		searchMaxLength:  defaultSearchMaxLength,
+		readTimeout:      defaultReadTimeout,
+		writeTimeout:     defaultWriteTimeout,
+		idleTimeout:      defaultIdleTimeout,
+		maxBodyBytes:     defaultMaxBodyBytes,
+		clipTarget:       defaultClipTarget,
+		webhookTimeout:   defaultWebhookTimeout,
+		webhookAttempts:  defaultWebhookAttempts,
+		webhookBackoff:   defaultWebhookBackoff,
+		robotsTxt:        defaultRobotsTxt,
+		liveEvents:       newLiveEventHub(),
+		imageMaxWidth:    defaultImageMaxSize,
+		imageMaxHeight:   defaultImageMaxSize,
+		thumbSize:        defaultThumbSize,
+		metrics:          newMetrics(),
+		logger:           slog.Default(),
+		cacheRefresh:     defaultCacheRefresh,
 	}
+	s.fileRepo.OnCacheRefresh(s.metrics.observeCacheRefresh)
+	s.backgroundRunner.OnError(s.metrics.countTaskError)
 
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	// The core files and caches depend on the file config and encryption options, so load them once all
+	// options are applied
 	err = s.fileRepo.Initialize()
 	if err != nil {
 		return nil, fmt.Errorf("could not initialize file repository: %w", err)
 	}
 
 	s.setupMetadataConfig()
+	s.setupNavigation()
 	s.fileRepo.ReloadCaches()
-	s.setupBackgroundTasks()
 
-	for _, opt := range opts {
-		if err := opt(s); err != nil {
+	if s.backups != nil {
+		s.backups.logger = s.logger
+		if err := s.backups.open(s.dataDir, s.basePath); err != nil {
 			return nil, err
 		}
 	}
+	if s.syncer != nil && s.basePath != "" {
+		s.syncer = remote.NewSyncer(s.rootManager, remote.WithPrefix(s.syncer.Store(), s.basePath))
+	}
+
+	// Background tasks start immediately, so set them up once all options are applied
+	s.setupBackgroundTasks()
+	s.setupWebhook()
+	s.setupLiveEvents()
 
 	return s, nil
 }
 
-// WithEncryptionManager sets the encryption manager for the server
+// WithFileConfig sets the core files, temporal file granularity, and indexing settings of the file repository.
+// It replaces the encrypted directories too, so give it before WithEncryptedDirectories and WithSearchEncrypted.
+func WithFileConfig(config files.FileConfig) ServerOption {
+	return func(s *Server) error {
+		for _, directory := range []string{config.ResourcesDirectory, config.DailyDirectory, config.JournalDirectory} {
+			if slices.Contains(reservedRoutePrefixes, directory) {
+				return fmt.Errorf("directory %q has the name of a route", directory)
+			}
+		}
+		return s.fileRepo.SetConfig(config)
+	}
+}
+
+// WithEncryptionManager sets the encryption manager for the server, which logs to the server's logger
 func WithEncryptionManager(manager *crypto.EncryptionManager) ServerOption {
 	return func(s *Server) error {
+		if manager != nil {
+			manager.SetLogger(s.logger)
+		}
 		s.fileRepo.SetEncryptionManager(manager)
 		return nil
 	}
 }
 
+// WithSearchEncrypted sets whether encrypted files are searchable when identities are loaded to decrypt them.
+// Encrypted files are never searchable without identities.
+func WithSearchEncrypted(enabled bool) ServerOption {
+	return func(s *Server) error {
+		s.fileRepo.SetExcludeEncrypted(!enabled)
+		return nil
+	}
+}
+
+// WithEncryptedDirectories sets the directories (e.g., "resources/private") whose new files are
+// encrypted by default
+func WithEncryptedDirectories(dirs []string) ServerOption {
+	return func(s *Server) error {
+		s.fileRepo.SetEncryptedDirectories(dirs)
+		return nil
+	}
+}
+
+// WithSearchQueryLimits sets the minimum and maximum search query lengths
+func WithSearchQueryLimits(minLength, maxLength int) ServerOption {
+	return func(s *Server) error {
+		if minLength < 1 || maxLength < minLength {
+			return fmt.Errorf("invalid search query limits: min %d, max %d", minLength, maxLength)
+		}
+		s.searchMinLength = minLength
+		s.searchMaxLength = maxLength
+		return nil
+	}
+}
+
+// WithCacheWarming enables preloading document content into the document cache in the background at startup
+// and after every rebuild of the file caches, using at most the given number of concurrent loads. A
+// concurrency of 0 leaves warming off.
+func WithCacheWarming(concurrency int) ServerOption {
+	return func(s *Server) error {
+		if concurrency < 0 {
+			return fmt.Errorf("invalid cache warming concurrency: %d", concurrency)
+		}
+		s.cacheWarmers = concurrency
+		return nil
+	}
+}
+
+// WithCacheRefresh sets how often stale resource caches are reloaded in the background
+func WithCacheRefresh(interval time.Duration) ServerOption {
+	return func(s *Server) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid cache refresh interval: %v", interval)
+		}
+		s.cacheRefresh = interval
+		return nil
+	}
+}
+
+// WithFileWatcher sets whether the data directory is watched for files changed by other programs, such as an
+// editor or a sync client, so the caches are updated right away instead of at the next periodic refresh
+func WithFileWatcher(enabled bool) ServerOption {
+	return func(s *Server) error {
+		s.watchFiles = enabled
+		return nil
+	}
+}
+
+// WithTimeouts sets the HTTP server read, write, and idle timeouts
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(s *Server) error {
+		if read <= 0 || write <= 0 || idle <= 0 {
+			return fmt.Errorf("invalid server timeouts: read %v, write %v, idle %v", read, write, idle)
+		}
+		s.readTimeout = read
+		s.writeTimeout = write
+		s.idleTimeout = idle
+		return nil
+	}
+}
+
+// WithMaxBodyBytes sets the maximum request body size for POST, PUT, and PATCH requests
+func WithMaxBodyBytes(maxBytes int64) ServerOption {
+	return func(s *Server) error {
+		if maxBytes <= 0 {
+			return fmt.Errorf("invalid maximum body size: %d", maxBytes)
+		}
+		s.maxBodyBytes = maxBytes
+		return nil
+	}
+}
+
+// WithImageProcessing sets the dimensions uploaded photos are scaled down to fit (0 leaves a side unbounded),
+// the size of their thumbnails, and whether the uploaded originals of scaled photos are kept
+func WithImageProcessing(maxWidth, maxHeight, thumbSize int, keepOriginals bool) ServerOption {
+	return func(s *Server) error {
+		if maxWidth < 0 || maxHeight < 0 || thumbSize < 1 {
+			return fmt.Errorf("invalid image sizes: max %dx%d, thumbnails %d", maxWidth, maxHeight, thumbSize)
+		}
+		s.imageMaxWidth = maxWidth
+		s.imageMaxHeight = maxHeight
+		s.thumbSize = thumbSize
+		s.keepOriginals = keepOriginals
+		return nil
+	}
+}
+
+// WithClipTarget sets the file ID that web clips are added to
+func WithClipTarget(fileID string) ServerOption {
+	return func(s *Server) error {
+		fileID = strings.Trim(strings.TrimSpace(fileID), "/")
+		if fileID == "" {
+			return fmt.Errorf("clip target cannot be empty")
+		}
+		s.clipTarget = fileID
+		return nil
+	}
+}
+
+// WithClipAllowedOrigin sets the origin allowed to post web clips from another site, such as
+// a bookmarklet running on the clipped page. Use "*" to allow any origin.
+func WithClipAllowedOrigin(origin string) ServerOption {
+	return func(s *Server) error {
+		s.clipAllowedOrigin = strings.TrimSpace(origin)
+		return nil
+	}
+}
+
+// WithFlashMaxAge sets how long queued flash messages survive before they expire unread
+func WithFlashMaxAge(maxAge time.Duration) ServerOption {
+	return func(s *Server) error {
+		if maxAge <= 0 {
+			return fmt.Errorf("invalid flash max age: %v", maxAge)
+		}
+		s.flashManager = flash.NewManager(maxAge)
+		return nil
+	}
+}
+
+// WithTemporalSummary shows a summary of the given number of most recent days at /daily and /journal,
+// instead of redirecting to the current month
+func WithTemporalSummary(days int) ServerOption {
+	return func(s *Server) error {
+		if days < 0 {
+			return fmt.Errorf("invalid temporal summary days: %d", days)
+		}
+		s.summaryDays = days
+		return nil
+	}
+}
+
+// setupCacheWarming warms the document cache now and again after every rebuild of the file caches, if cache
+// warming is on. Rebuilds that happen while a warm is running are coalesced into one more warm afterward.
+func (s *Server) setupCacheWarming() {
+	if s.cacheWarmers == 0 {
+		return
+	}
+
+	pending := make(chan struct{}, 1)
+	request := func() {
+		select {
+		case pending <- struct{}{}:
+		default:
+		}
+	}
+
+	s.fileRepo.OnCacheRefresh(func(files.CacheRefresh) { request() })
+	request()
+
+	s.backgroundRunner.StartOneTimeTask("cache-warming", func(ctx context.Context) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-pending:
+				loaded := s.fileRepo.WarmDocumentCache(ctx, s.cacheWarmers)
+				s.logger.Debug("Document cache warmed", "documents", loaded)
+			}
+		}
+	})
+}
+
 func (s *Server) setupBackgroundTasks() {
-	// TODO: Make the cache duration configurable
-	backgroundCacheDuration := 5 * time.Minute
+	backgroundCacheDuration := s.cacheRefresh
 
 	s.backgroundRunner.AddPeriodicTask(
 		"cache-refresh",
@@ -103,6 +393,18 @@ func (s *Server) setupBackgroundTasks() {
 		},
 	)
 
+	s.setupCacheWarming()
+	s.setupRecurringTasks()
+	s.setupBackups()
+	s.setupSync()
+	s.setupLinkCheck()
+
+	if s.watchFiles {
+		s.backgroundRunner.StartOneTimeTask("file-watcher", func(ctx context.Context) error {
+			return s.fileRepo.WatchForExternalChanges(ctx, watchDebounce)
+		})
+	}
+
 	// Example: Add other background tasks as needed
 	// s.backgroundRunner.AddPeriodicTask(
 	//     "health-check",
@@ -127,7 +429,7 @@ func (s *Server) backgroundTask(name string, fn func() error) {
 		defer func() {
 			if r := recover(); r != nil {
 				// Handle panic
-				log.Printf("Background task %s panicked: %v", name, r)
+				s.logger.Error("Background task panicked", "task", name, "panic", r)
 			}
 		}()
 
@@ -141,26 +443,42 @@ func (s *Server) Start(add string, port int) error {
 
 	s.httpServer = &http.Server{
 		Addr:         serverAddr,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  time.Minute,
+		ReadTimeout:  s.readTimeout,
+		WriteTimeout: s.writeTimeout,
+		IdleTimeout:  s.idleTimeout,
 		Handler:      s.setupRoutes(),
 	}
+	s.httpServer.RegisterOnShutdown(s.liveEvents.close)
 
 	// Start background tasks
 	s.backgroundRunner.Start()
 
+	s.logger.Info("Server started", "url", s.scheme()+"://"+serverAddr, "data_dir", s.dataDir)
+	if err := listenUntilSignal(s.httpServer, s.tls); err != nil {
+		return err
+	}
+
+	return s.Shutdown()
+}
+
+// listenUntilSignal serves HTTP requests, or HTTPS ones when TLS is enabled, until the server fails or the
+// process receives SIGINT or SIGTERM. It does not shut the server down; callers do that once it returns
+// without an error.
+func listenUntilSignal(httpServer *http.Server, tlsSettings *tlsSettings) error {
 	// Channel to receive OS signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Start the http server in a separate goroutine
-	serverErrors := make(chan error, 1)
-	go func() {
-		log.Printf("Server started on %s\n", serverAddr)
-		log.Printf("Data directory: %s\n", s.dataDir)
-		serverErrors <- s.httpServer.ListenAndServe()
-	}()
+	serverErrors := make(chan error, 2)
+	if tlsSettings.enabled() {
+		stopRedirect := tlsSettings.listen(httpServer, serverErrors)
+		defer stopRedirect()
+	} else {
+		go func() {
+			serverErrors <- httpServer.ListenAndServe()
+		}()
+	}
 
 	// Wait for either termination signal or server error
 	select {
@@ -169,15 +487,15 @@ func (s *Server) Start(add string, port int) error {
 			return fmt.Errorf("could not start server: %w", err)
 		}
 	case sig := <-sigChan:
-		log.Printf("Received signal %v, initiating shutdown\n", sig)
+		slog.Info("Received signal, initiating shutdown", "signal", sig)
 	}
 
-	return s.Shutdown()
+	return nil
 }
 
 // Shutdown gracefully shuts down the server and background tasks
 func (s *Server) Shutdown() error {
-	log.Println("Shutting down server...")
+	s.logger.Info("Shutting down server")
 
 	// Create a timeout context for the shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -185,68 +503,49 @@ func (s *Server) Shutdown() error {
 
 	// Shutdown the HTTP server
 	if s.httpServer != nil {
-		log.Println("Shutting down HTTP server...")
+		s.logger.Debug("Shutting down HTTP server")
 		if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Error during HTTP server shutdown: %v", err)
+			s.logger.Error("Error during HTTP server shutdown", "error", err)
 		}
 	}
 
 	// Shutdown background tasks
 	s.backgroundRunner.Shutdown()
 
-	log.Println("Server shutdown complete")
+	s.logger.Info("Server shutdown complete")
 	return nil
 }
 
-// navigationMenu returns the list of navigation menu items
-// TODO: Make this configurable
+// navigationMenu returns the list of navigation menu items, as configured in nav.json. Items whose file or
+// directory doesn't exist are left out.
 func (s *Server) navigationMenu(current string) []files.FileInfo {
 
 	current = strings.TrimPrefix(current, "/")
 	if current == "" {
-		current = "inbox"
+		current = s.homeID()
 	}
 
-	files := []files.FileInfo{
-		{
-			ID:          "inbox",
-			Path:        "inbox.md",
-			Title:       "Inbox",
-			TitleBase:   "Inbox",
-			IsNavActive: current == "inbox",
-		},
-		{
-			ID:          "active",
-			Path:        "active.md",
-			Title:       "Active",
-			TitleBase:   "Active",
-			IsNavActive: current == "active",
-		},
-		{
-			ID:          "daily",
-			Path:        "daily",
-			Title:       "Daily",
-			TitleBase:   "Daily",
-			IsTemporal:  true,
-			IsNavActive: current == "daily" || strings.HasPrefix(current, "daily/"),
-		},
-		{
-			ID:          "journal",
-			Path:        "journal",
-			Title:       "Journal",
-			TitleBase:   "Journal",
-			IsTemporal:  true,
-			IsNavActive: current == "journal" || strings.HasPrefix(current, "journal/"),
-		},
-		{
-			ID:          "resources",
-			Path:        "resources",
-			Title:       "Resources",
-			TitleBase:   "Resources",
-			IsResource:  true,
-			IsNavActive: current == "resources" || strings.HasPrefix(current, "resources/"),
-		},
+	var menu []files.FileInfo
+	for _, item := range s.navigation() {
+		if info, ok := s.navFileInfo(item, current); ok {
+			menu = append(menu, info)
+		}
+	}
+
+	return menu
+}
+
+// homeID returns the ID of the first core file, which is shown at /
+func (s *Server) homeID() string {
+	return s.fileRepo.CreateID(s.fileRepo.Config().CoreFiles[0])
+}
+
+// isNavActive reports whether a navigation item should be highlighted for the current file ID.
+// Root items (directories such as daily or resources) are also active for any file beneath them.
+func isNavActive(current, itemID string, isRoot bool) bool {
+	if current == itemID {
+		return true
 	}
 
-	return files
+	return isRoot && strings.HasPrefix(current, itemID+"/")
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+// newTestServer creates a server backed by a temporary data directory
+func newTestServer(t *testing.T, opts ...ServerOption) *Server {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server, err := NewServer(ctx, t.TempDir(), opts...)
+	assert.Nil(t, err)
+
+	t.Cleanup(func() {
+		cancel()
+		server.backgroundRunner.Shutdown()
+	})
+
+	return server
+}
+
+func TestIsNavActive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		current string
+		itemID  string
+		isRoot  bool
+		want    bool
+	}{
+		{"exact file", "inbox", "inbox", false, true},
+		{"exact root", "resources", "resources", true, true},
+		{"root prefix", "resources/notes/todo", "resources", true, true},
+		{"file does not match prefix", "inbox/other", "inbox", false, false},
+		{"different item", "active", "inbox", false, false},
+		{"partial segment", "dailyx/2025", "daily", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, isNavActive(tt.current, tt.itemID, tt.isRoot), tt.want)
+		})
+	}
+}
+
+func TestServer_NavigationMenu_Active(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(t)
+
+	active := map[string]bool{}
+	for _, item := range server.navigationMenu("/journal/2025/09") {
+		active[item.ID] = item.IsNavActive
+	}
+
+	assert.True(t, active["journal"])
+	assert.False(t, active["daily"])
+	assert.False(t, active["inbox"])
+}
+
+func TestServer_NavigationMenu_Configured(t *testing.T) {
+	t.Parallel()
+	server := newTestServer(t)
+
+	assert.Nil(t, server.fileRepo.CreateFile("resources/padd.md", "# PADD"))
+	assert.Nil(t, server.rootManager.WriteString(navFile, `{"items": [
+		{"id": "inbox"},
+		{"id": "resources/padd", "title": "Project"},
+		{"id": "resources/missing"},
+		{"id": "daily", "title": "Log"}
+	]}`))
+	server.setupNavigation()
+	server.fileRepo.ReloadCaches()
+
+	var ids, titles []string
+	active := map[string]bool{}
+	for _, item := range server.navigationMenu("/resources/padd") {
+		ids = append(ids, item.ID)
+		titles = append(titles, item.Title)
+		active[item.ID] = item.IsNavActive
+	}
+
+	assert.Equal(t, strings.Join(ids, ","), "inbox,resources/padd,daily")
+	assert.Equal(t, strings.Join(titles, ","), "Inbox,Project,Log")
+	assert.True(t, active["resources/padd"])
+	assert.False(t, active["inbox"])
+
+	// An invalid nav.json falls back to the default menu
+	assert.Nil(t, server.rootManager.WriteString(navFile, `{"items": [`))
+	server.setupNavigation()
+	assert.Equal(t, len(server.navigationMenu("")), 5)
+}
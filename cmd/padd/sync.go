@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/remote"
+)
+
+// syncCommand is the subcommand that syncs the data directory with its remote without starting the server
+const syncCommand = "sync"
+
+// defaultSyncInterval is how often the server syncs with its remote
+const defaultSyncInterval = 15 * time.Minute
+
+// syncModes maps the arguments of the sync subcommand to the direction of the sync
+var syncModes = map[string]remote.Mode{
+	"":     remote.Both,
+	"push": remote.Push,
+	"pull": remote.Pull,
+}
+
+// WithSync syncs the data directory with a remote store every interval, pushing local changes and pulling
+// remote ones. An interval of 0 leaves syncing to the sync subcommand. When serving several vaults, each vault
+// is synced to a prefix of the store named after it.
+func WithSync(store remote.Store, interval time.Duration) ServerOption {
+	return func(s *Server) error {
+		if store == nil {
+			return nil
+		}
+		if interval < 0 {
+			return fmt.Errorf("invalid sync interval: %v", interval)
+		}
+
+		s.syncer = remote.NewSyncer(s.rootManager, store)
+		s.syncInterval = interval
+		return nil
+	}
+}
+
+// setupSync starts the background task that syncs the data directory with its remote, if it has one
+func (s *Server) setupSync() {
+	if s.syncer == nil || s.syncInterval <= 0 {
+		return
+	}
+
+	s.backgroundRunner.AddPeriodicTask("sync", s.syncInterval, func(ctx context.Context) error {
+		result, err := s.syncer.Sync(ctx, remote.Both, false)
+		if paths := result.LocalPaths(); len(paths) > 0 {
+			s.fileRepo.RecordChanges("Sync from "+s.syncer.Store().String(), paths...)
+			s.fileRepo.ReloadCaches()
+		}
+		if err != nil {
+			return fmt.Errorf("sync with %s failed: %w", s.syncer.Store(), err)
+		}
+
+		for _, change := range result.Changes {
+			if change.Action == remote.ActionConflict {
+				s.logger.Warn("Sync conflict; the remote version was saved as a copy", "path", change.Path, "copy", change.Copy)
+			}
+		}
+		if len(result.Changes) > 0 {
+			s.logger.Info("Synced files", "count", len(result.Changes), "remote", s.syncer.Store())
+		}
+		return nil
+	})
+}
+
+// runSync runs the sync subcommand against the data directory:
+//
+//	sync [-dry-run] [push|pull]
+//
+// Without push or pull, local changes are pushed and remote ones pulled. Each change is printed as it's made,
+// and files changed by the sync are committed in one revision when version history is on.
+func runSync(dataDir, remoteURL string, history bool, args []string, stdout io.Writer) error {
+	flagSet := flag.NewFlagSet(appName+" "+syncCommand, flag.ContinueOnError)
+	flagSet.SetOutput(stdout)
+	var dryRun bool
+	flagSet.BoolVar(&dryRun, "dry-run", false, "Print the changes a sync would make without making them.")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	mode, ok := syncModes[flagSet.Arg(0)]
+	if !ok || flagSet.NArg() > 1 {
+		return fmt.Errorf("usage: %s %s [-dry-run] [push|pull]", appName, syncCommand)
+	}
+
+	if remoteURL == "" {
+		return fmt.Errorf("-sync-remote (or %s) is required to sync", envPaddSyncRemote)
+	}
+	store, err := remote.Open(remoteURL)
+	if err != nil {
+		return err
+	}
+
+	rootManager, err := files.NewRootManager(dataDir)
+	if err != nil {
+		return err
+	}
+
+	result, err := remote.NewSyncer(rootManager, store).Sync(context.Background(), mode, dryRun)
+	for _, change := range result.Changes {
+		if change.Action == remote.ActionConflict {
+			_, _ = fmt.Fprintf(stdout, "%-13s %s (remote version saved as %s)\n", change.Action, change.Path, change.Copy)
+		} else {
+			_, _ = fmt.Fprintf(stdout, "%-13s %s\n", change.Action, change.Path)
+		}
+	}
+	for _, path := range result.Skipped {
+		_, _ = fmt.Fprintf(stdout, "%-13s %s (changed on both sides)\n", "skipped", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		_, _ = fmt.Fprintf(stdout, "\nA sync would change %d files with %s\n", len(result.Changes), store)
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "\nSynced %d files with %s\n", len(result.Changes), store)
+
+	if paths := result.LocalPaths(); len(paths) > 0 && (history || files.IsGitRepository(dataDir)) {
+		versions, err := files.NewGitVersionStore(dataDir)
+		if err != nil {
+			return fmt.Errorf("could not record the sync in version history: %w", err)
+		}
+		if err := versions.Record("Sync from "+store.String(), paths...); err != nil {
+			return fmt.Errorf("could not record the sync in version history: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/remote"
+)
+
+func TestRunSync(t *testing.T) {
+	remoteDir, laptopDir, desktopDir := t.TempDir(), t.TempDir(), t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(laptopDir, "inbox.md"), []byte("# Inbox\n"), 0644))
+
+	var out bytes.Buffer
+	assert.Nil(t, runSync(laptopDir, remoteDir, false, []string{"-dry-run"}, &out))
+	assert.True(t, strings.Contains(out.String(), "push          inbox.md"))
+	_, err := os.Stat(filepath.Join(remoteDir, "inbox.md"))
+	assert.True(t, os.IsNotExist(err))
+
+	out.Reset()
+	assert.Nil(t, runSync(laptopDir, remoteDir, false, []string{"push"}, &out))
+	assert.True(t, strings.Contains(out.String(), "Synced 1 files with "+remoteDir))
+
+	out.Reset()
+	assert.Nil(t, runSync(desktopDir, remoteDir, false, nil, &out))
+	assert.True(t, strings.Contains(out.String(), "pull          inbox.md"))
+	content, err := os.ReadFile(filepath.Join(desktopDir, "inbox.md"))
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "# Inbox\n")
+
+	assert.NotNil(t, runSync(desktopDir, "", false, nil, &out))
+	assert.NotNil(t, runSync(desktopDir, remoteDir, false, []string{"sideways"}, &out))
+}
+
+func TestWithSync(t *testing.T) {
+	t.Parallel()
+
+	store, err := remote.NewDirStore(t.TempDir())
+	assert.Nil(t, err)
+
+	// Another machine pushes a note to the remote
+	otherDir := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(otherDir, "resources"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(otherDir, "resources", "shared.md"), []byte("# Shared\n"), 0644))
+	assert.Nil(t, runSync(otherDir, store.String(), false, nil, &bytes.Buffer{}))
+
+	// The server syncs at startup, pulling the note and pushing its own files
+	server := newTestServer(t, WithSync(store, time.Hour))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.fileRepo.FileIDExists("resources/shared") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, server.fileRepo.FileIDExists("resources/shared"))
+
+	_, err = os.Stat(filepath.Join(store.String(), "inbox.md"))
+	assert.Nil(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"html/template"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/patrickward/padd"
 	"github.com/patrickward/padd/internal/version"
@@ -17,6 +18,8 @@ func customFuncs() template.FuncMap {
 		"hasPrefix": strings.HasPrefix,
 		"hasSuffix": strings.HasSuffix,
 		"toLower":   strings.ToLower,
+		"now":       time.Now,
+		"add":       func(a, b int) int { return a + b },
 		"dict": func(values ...interface{}) (map[string]interface{}, error) {
 			if len(values)%2 != 0 {
 				return nil, fmt.Errorf("dict requires an even number of arguments")
@@ -44,9 +47,19 @@ func parseTemplates() (*template.Template, error) {
 
 // executePage renders a full page template with the given data
 func (s *Server) executePage(w http.ResponseWriter, page string, data web.PageData) error {
+	start := time.Now()
 	// Add the version and directory details to the data
 	data.PADDVersion = version.Get()
 	data.PADDDataDir = s.dataDir
+	data.BasePath = s.basePath
+	data.Vaults = s.vaults
+	data.Vault = strings.TrimPrefix(s.basePath, "/")
+	data.HasHistory = s.fileRepo.HasVersionStore()
+	data.EncryptionLock = s.encryptionLockState()
+	config := s.fileRepo.Config()
+	data.ResourcesDirectory = config.ResourcesDirectory
+	data.DailyDirectory = config.DailyDirectory
+	data.JournalDirectory = config.JournalDirectory
 
 	// Clone the base template to avoid altering it
 	tmpl, err := s.baseTempl.Clone()
@@ -59,6 +72,9 @@ func (s *Server) executePage(w http.ResponseWriter, page string, data web.PageDa
 		page = page + ".html"
 	}
 
+	// Every page but the login page needs a session when a password is set
+	data.SignedIn = s.auth != nil && page != "login.html"
+
 	// Parse the specific page template
 	pagePattern := fmt.Sprintf("templates/pages/%s", page)
 	tmpl, err = tmpl.ParseFS(padd.TemplateFS, pagePattern)
@@ -66,7 +82,9 @@ func (s *Server) executePage(w http.ResponseWriter, page string, data web.PageDa
 		return err
 	}
 
-	return tmpl.ExecuteTemplate(w, page, data)
+	err = tmpl.ExecuteTemplate(w, page, data)
+	s.metrics.observeRender(page, time.Since(start))
+	return err
 }
 
 // executeSnippet renders a snippet template (partial) with the given data (no layout)
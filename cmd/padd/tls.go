@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Timeouts of the server that redirects plain HTTP to HTTPS, which only ever sends short responses
+const (
+	redirectReadTimeout  = 5 * time.Second
+	redirectWriteTimeout = 5 * time.Second
+	redirectIdleTimeout  = 30 * time.Second
+)
+
+// tlsSettings configures the server to serve HTTPS, with either a certificate from files or one obtained
+// from Let's Encrypt
+type tlsSettings struct {
+	certFile     string            // Certificate file, when not using Let's Encrypt
+	keyFile      string            // Private key file for certFile
+	manager      *autocert.Manager // Obtains and renews certificates from Let's Encrypt; nil when using files
+	redirectPort int               // Port that redirects plain HTTP to HTTPS (and answers ACME challenges); 0 for none
+}
+
+// WithTLS serves HTTPS with the certificate and private key in the given PEM files. The files are read
+// again when the server starts, so renewed certificates are picked up on restart.
+func WithTLS(certFile, keyFile string) ServerOption {
+	return func(s *Server) error {
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("both a TLS certificate and key file are required")
+		}
+		if s.tls != nil && s.tls.manager != nil {
+			return fmt.Errorf("a TLS certificate file can't be combined with Let's Encrypt")
+		}
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return fmt.Errorf("error loading TLS certificate: %w", err)
+		}
+
+		s.tlsSettings().certFile, s.tlsSettings().keyFile = certFile, keyFile
+		return nil
+	}
+}
+
+// WithAutocert serves HTTPS with certificates for the given domains, obtained from Let's Encrypt when first
+// requested and renewed before they expire. Certificates are kept in cacheDir, and email, which may be empty,
+// is given to Let's Encrypt for expiry notices. The domains must resolve to this server, which must be
+// reachable on port 443, or on port 80 with WithHTTPRedirect(80).
+func WithAutocert(domains []string, cacheDir, email string) ServerOption {
+	return func(s *Server) error {
+		var hosts []string
+		for _, domain := range domains {
+			if domain = strings.TrimSpace(domain); domain != "" {
+				hosts = append(hosts, domain)
+			}
+		}
+		if len(hosts) == 0 {
+			return nil
+		}
+		if s.tls != nil && s.tls.certFile != "" {
+			return fmt.Errorf("a TLS certificate file can't be combined with Let's Encrypt")
+		}
+		if cacheDir == "" {
+			return fmt.Errorf("a certificate cache directory is required for Let's Encrypt")
+		}
+
+		s.tlsSettings().manager = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(cacheDir),
+			HostPolicy: autocert.HostWhitelist(hosts...),
+			Email:      email,
+		}
+		return nil
+	}
+}
+
+// WithHTTPRedirect listens for plain HTTP on the given port and redirects every request to HTTPS. It has no
+// effect unless the server serves HTTPS.
+func WithHTTPRedirect(port int) ServerOption {
+	return func(s *Server) error {
+		if port < 0 || port > 65535 {
+			return fmt.Errorf("invalid HTTP redirect port: %d", port)
+		}
+		if port > 0 {
+			s.tlsSettings().redirectPort = port
+		}
+		return nil
+	}
+}
+
+// tlsSettings returns the server's TLS settings, creating them if needed
+func (s *Server) tlsSettings() *tlsSettings {
+	if s.tls == nil {
+		s.tls = &tlsSettings{}
+	}
+	return s.tls
+}
+
+// enabled reports whether the settings serve HTTPS. A redirect port alone doesn't.
+func (t *tlsSettings) enabled() bool {
+	return t != nil && (t.certFile != "" || t.manager != nil)
+}
+
+// scheme returns the URL scheme the server is served over
+func (s *Server) scheme() string {
+	if s.tls.enabled() {
+		return "https"
+	}
+	return "http"
+}
+
+// configure sets up the HTTPS server, leaving out the protocol versions and cipher suites that are no longer
+// considered safe
+func (t *tlsSettings) configure(httpServer *http.Server) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.manager != nil {
+		config = t.manager.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+	}
+	httpServer.TLSConfig = config
+}
+
+// listen serves HTTPS on the configured server, and starts the plain HTTP redirect when it's configured.
+// The returned function shuts the redirect down.
+func (t *tlsSettings) listen(httpServer *http.Server, serverErrors chan<- error) func() {
+	t.configure(httpServer)
+	go func() {
+		serverErrors <- httpServer.ListenAndServeTLS(t.certFile, t.keyFile)
+	}()
+
+	if t.redirectPort == 0 {
+		return func() {}
+	}
+
+	host, _, err := net.SplitHostPort(httpServer.Addr)
+	if err != nil {
+		host = ""
+	}
+	var handler http.Handler = httpsRedirect(httpServer.Addr)
+	if t.manager != nil {
+		handler = t.manager.HTTPHandler(handler)
+	}
+	redirectServer := &http.Server{
+		Addr:         net.JoinHostPort(host, strconv.Itoa(t.redirectPort)),
+		ReadTimeout:  redirectReadTimeout,
+		WriteTimeout: redirectWriteTimeout,
+		IdleTimeout:  redirectIdleTimeout,
+		Handler:      handler,
+	}
+
+	go func() {
+		slog.Info("Redirecting HTTP to HTTPS", "addr", redirectServer.Addr)
+		if err := redirectServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErrors <- fmt.Errorf("HTTP redirect: %w", err)
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), redirectWriteTimeout)
+		defer cancel()
+		_ = redirectServer.Shutdown(ctx)
+	}
+}
+
+// httpsRedirect returns a handler that redirects requests to the same host and path over HTTPS, on the
+// port of the HTTPS server listening on httpsAddr
+func httpsRedirect(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
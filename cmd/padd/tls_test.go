@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+// writeTestCertificate writes a self-signed certificate for localhost and its key, returning their paths
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600))
+	return certFile, keyFile
+}
+
+func TestWithTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	server := newTestServer(t, WithTLS(certFile, keyFile), WithHTTPRedirect(8080))
+	assert.True(t, server.tls.enabled())
+	assert.Equal(t, server.scheme(), "https")
+	assert.Equal(t, server.tls.redirectPort, 8080)
+
+	httpServer := &http.Server{}
+	server.tls.configure(httpServer)
+	assert.Equal(t, httpServer.TLSConfig.MinVersion, uint16(tls.VersionTLS12))
+
+	// A redirect port alone doesn't serve HTTPS
+	assert.Equal(t, newTestServer(t, WithHTTPRedirect(8080)).scheme(), "http")
+}
+
+func TestWithTLS_Invalid(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	assert.NotNil(t, WithTLS(certFile, "")(&Server{}))
+	assert.NotNil(t, WithTLS(certFile, certFile)(&Server{}))
+	assert.NotNil(t, WithHTTPRedirect(70000)(&Server{}))
+
+	// A certificate file and Let's Encrypt can't be combined, in either order
+	server := &Server{}
+	assert.Nil(t, WithTLS(certFile, keyFile)(server))
+	assert.NotNil(t, WithAutocert([]string{"notes.example.com"}, t.TempDir(), "")(server))
+
+	server = &Server{}
+	assert.Nil(t, WithAutocert([]string{"notes.example.com"}, t.TempDir(), "")(server))
+	assert.NotNil(t, WithTLS(certFile, keyFile)(server))
+}
+
+func TestWithAutocert(t *testing.T) {
+	server := &Server{}
+	assert.Nil(t, WithAutocert([]string{" "}, t.TempDir(), "")(server))
+	assert.False(t, server.tls.enabled())
+
+	assert.Nil(t, WithAutocert([]string{"notes.example.com", " home.example.com"}, t.TempDir(), "me@example.com")(server))
+	assert.True(t, server.tls.enabled())
+	assert.Nil(t, server.tls.manager.HostPolicy(t.Context(), "home.example.com"))
+	assert.NotNil(t, server.tls.manager.HostPolicy(t.Context(), "other.example.com"))
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsAddr string
+		target    string
+		want      string
+	}{
+		{name: "default port", httpsAddr: "0.0.0.0:443", target: "http://notes.example.com/inbox?edit=1", want: "https://notes.example.com/inbox?edit=1"},
+		{name: "other port", httpsAddr: ":8443", target: "http://notes.example.com:8080/resources", want: "https://notes.example.com:8443/resources"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			httpsRedirect(tt.httpsAddr).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, rec.Code, http.StatusMovedPermanently)
+			assert.Equal(t, rec.Header().Get("Location"), tt.want)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd"
+)
+
+// vaultNamePattern restricts vault names to a single, URL-safe path segment
+var vaultNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Vault is a named data directory served under its own path prefix (e.g., "work" at /work/)
+type Vault struct {
+	Name    string // Name of the vault, used as its path prefix
+	Dir     string // Data directory for the vault
+	KeysDir string // Directory with the vault's key.txt and key.pub; empty uses the global keys
+}
+
+// vaultList collects the repeatable -vault flag, each given as name=dir[,keysDir]
+type vaultList []Vault
+
+func (v *vaultList) String() string {
+	names := make([]string, 0, len(*v))
+	for _, vault := range *v {
+		names = append(names, vault.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (v *vaultList) Set(value string) error {
+	name, rest, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("vault must be given as name=dir[,keysDir]: %s", value)
+	}
+
+	dir, keysDir, _ := strings.Cut(rest, ",")
+	vault := Vault{Name: strings.TrimSpace(name), Dir: strings.TrimSpace(dir), KeysDir: strings.TrimSpace(keysDir)}
+	if !vaultNamePattern.MatchString(vault.Name) || vault.Name == "static" {
+		return fmt.Errorf("invalid vault name: %q", vault.Name)
+	}
+	if vault.Dir == "" {
+		return fmt.Errorf("vault %s has no data directory", vault.Name)
+	}
+	for _, existing := range *v {
+		if existing.Name == vault.Name {
+			return fmt.Errorf("duplicate vault name: %s", vault.Name)
+		}
+	}
+
+	*v = append(*v, vault)
+	return nil
+}
+
+// vaultRouter serves several vaults from one HTTP server, each with its own Server (and so its own
+// RootManager, FileRepository, and caches), routed by the first path segment
+type vaultRouter struct {
+	names   []string           // Vault names in the order they were configured; the first is the default
+	servers map[string]*Server // Servers by vault name
+	handler http.Handler
+}
+
+// newVaultRouter routes requests for /<name>/... to the server of each named vault. The servers should
+// have been created with the matching WithBasePath option.
+func newVaultRouter(names []string, servers map[string]*Server) (*vaultRouter, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no vaults configured")
+	}
+
+	vr := &vaultRouter{names: names, servers: servers}
+	mux := http.NewServeMux()
+	mux.Handle("GET /static/", http.FileServer(http.FS(padd.StaticFS)))
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/"+names[0]+"/", http.StatusFound)
+	})
+
+	for _, name := range names {
+		server, ok := servers[name]
+		if !ok {
+			return nil, fmt.Errorf("no server for vault %s", name)
+		}
+		server.vaults = names
+		prefix := "/" + name
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, server.setupRoutes()))
+	}
+
+	vr.handler = mux
+	return vr, nil
+}
+
+func (vr *vaultRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vr.handler.ServeHTTP(w, r)
+}
+
+// Start serves every vault and runs each vault's background tasks until the process is signaled to stop.
+// HTTP timeouts and TLS settings are taken from the default vault's server.
+func (vr *vaultRouter) Start(addr string, port int) error {
+	serverAddr := fmt.Sprintf("%s:%d", addr, port)
+	first := vr.servers[vr.names[0]]
+
+	httpServer := &http.Server{
+		Addr:         serverAddr,
+		ReadTimeout:  first.readTimeout,
+		WriteTimeout: first.writeTimeout,
+		IdleTimeout:  first.idleTimeout,
+		Handler:      vr,
+	}
+
+	for _, name := range vr.names {
+		httpServer.RegisterOnShutdown(vr.servers[name].liveEvents.close)
+		vr.servers[name].backgroundRunner.Start()
+	}
+
+	first.logger.Info("Server started", "url", first.scheme()+"://"+serverAddr)
+	for _, name := range vr.names {
+		first.logger.Info("Serving vault", "vault", name, "path", "/"+name+"/", "data_dir", vr.servers[name].dataDir)
+	}
+	if err := listenUntilSignal(httpServer, first.tls); err != nil {
+		return err
+	}
+
+	first.logger.Info("Shutting down server")
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		first.logger.Error("Error during HTTP server shutdown", "error", err)
+	}
+
+	for _, name := range vr.names {
+		vr.servers[name].backgroundRunner.Shutdown()
+	}
+
+	first.logger.Info("Server shutdown complete")
+	return nil
+}
+
+// handleSwitchVault redirects to the root of another vault served alongside this one. The redirect is sent
+// as is, since the target is outside this vault's base path.
+func (s *Server) handleSwitchVault(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !slices.Contains(s.vaults, name) {
+		s.showPageNotFound(w, r)
+		return
+	}
+
+	http.Redirect(w, r, "/"+name+"/", http.StatusFound)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func newTestVaults(t *testing.T) (*vaultRouter, *Server, *Server) {
+	t.Helper()
+
+	work := newTestServer(t, WithBasePath("/work"))
+	personal := newTestServer(t, WithBasePath("/personal"))
+	router, err := newVaultRouter([]string{"work", "personal"}, map[string]*Server{"work": work, "personal": personal})
+	assert.Nil(t, err)
+
+	return router, work, personal
+}
+
+func TestVaultRouter_IsolatesVaults(t *testing.T) {
+	router, work, personal := newTestVaults(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/work/resources", strings.NewReader(url.Values{"filename": {"plans"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/work/resources/plans")
+
+	// The file and its cache entry exist only in the work vault
+	_, err := work.rootManager.ReadFile("resources/plans.md")
+	assert.Nil(t, err)
+	_, err = personal.rootManager.ReadFile("resources/plans.md")
+	assert.NotNil(t, err)
+	assert.True(t, work.fileRepo.FileIDExists("resources/plans"))
+	assert.False(t, personal.fileRepo.FileIDExists("resources/plans"))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/work/resources/plans", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/personal/resources/plans", nil))
+	assert.Equal(t, rec.Code, http.StatusNotFound)
+}
+
+func TestVaultRouter_Routing(t *testing.T) {
+	router, _, _ := newTestVaults(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		code     int
+		location string
+	}{
+		{name: "root redirects to the first vault", path: "/", code: http.StatusFound, location: "/work/"},
+		{name: "vault root", path: "/personal/", code: http.StatusOK},
+		{name: "shared static files", path: "/static/css/app.css", code: http.StatusOK},
+		{name: "unknown vault", path: "/other/inbox", code: http.StatusNotFound},
+		{name: "switch vault", path: "/work/vaults/personal", code: http.StatusFound, location: "/personal/"},
+		{name: "switch to unknown vault", path: "/work/vaults/other", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, rec.Code, tt.code)
+			assert.Equal(t, rec.Header().Get("Location"), tt.location)
+		})
+	}
+}
+
+func TestVaultRouter_PrefixesLinks(t *testing.T) {
+	router, _, _ := newTestVaults(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/work/inbox", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	body := rec.Body.String()
+	assert.True(t, strings.Contains(body, `href="/work/static/css/app.css"`))
+	assert.True(t, strings.Contains(body, `<meta name="app:base-path" content="/work">`))
+	assert.False(t, strings.Contains(body, `href="/static/`))
+
+	// The vault switcher lists every vault, marking the current one
+	assert.True(t, strings.Contains(body, `href="/work/vaults/work" class="active"`))
+	assert.True(t, strings.Contains(body, `href="/work/vaults/personal"`))
+}
+
+func TestVaultList_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    vaultList
+		wantErr bool
+	}{
+		{name: "name and dir", values: []string{"work=/data/work"}, want: vaultList{{Name: "work", Dir: "/data/work"}}},
+		{name: "with keys dir", values: []string{"work=/data/work,/keys/work"}, want: vaultList{{Name: "work", Dir: "/data/work", KeysDir: "/keys/work"}}},
+		{name: "missing dir", values: []string{"work="}, wantErr: true},
+		{name: "missing separator", values: []string{"/data/work"}, wantErr: true},
+		{name: "invalid name", values: []string{"My Work=/data/work"}, wantErr: true},
+		{name: "reserved name", values: []string{"static=/data/static"}, wantErr: true},
+		{name: "duplicate name", values: []string{"work=/a", "work=/b"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vaults vaultList
+			var err error
+			for _, value := range tt.values {
+				if err = vaults.Set(value); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, len(vaults), len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, vaults[i], tt.want[i])
+			}
+		})
+	}
+}
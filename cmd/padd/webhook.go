@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// Webhook delivery defaults
+const (
+	defaultWebhookTimeout  = 10 * time.Second // Per-attempt request timeout
+	defaultWebhookAttempts = 3                // Total attempts, including the first
+	defaultWebhookBackoff  = time.Second      // Delay before the first retry, doubled for each retry after that
+)
+
+// WebhookPayload is the JSON body posted to the webhook URL when a document changes. It only
+// describes the change; the document content is never sent.
+type WebhookPayload struct {
+	Operation string    `json:"operation"`
+	ID        string    `json:"id"`
+	OldID     string    `json:"old_id,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WithWebhook enables posting a WebhookPayload to the given http or https URL whenever a document
+// is saved, deleted, or renamed
+func WithWebhook(webhookURL string) ServerOption {
+	return func(s *Server) error {
+		parsed, err := url.Parse(webhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid webhook URL: %q", webhookURL)
+		}
+		s.webhookURL = webhookURL
+		return nil
+	}
+}
+
+// setupWebhook registers the change listener that sends webhooks, if a webhook URL is configured
+func (s *Server) setupWebhook() {
+	if s.webhookURL == "" {
+		return
+	}
+
+	s.fileRepo.OnChange(func(event files.ChangeEvent) {
+		payload := WebhookPayload{
+			Operation: string(event.Operation),
+			ID:        event.ID,
+			OldID:     event.OldID,
+			Hash:      event.Hash,
+			Timestamp: event.Time,
+		}
+
+		// Deliver in the background so saving never waits on the webhook
+		s.backgroundRunner.StartOneTimeTask("webhook-"+payload.Operation, func(ctx context.Context) error {
+			return s.sendWebhook(ctx, payload)
+		})
+	})
+}
+
+// sendWebhook posts the payload to the webhook URL, retrying with exponential backoff until it gets a
+// 2xx response, runs out of attempts, or the context is cancelled
+func (s *Server) sendWebhook(ctx context.Context, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	client := &http.Client{Timeout: s.webhookTimeout}
+	backoff := s.webhookBackoff
+
+	for attempt := 1; ; attempt++ {
+		err = s.postWebhook(ctx, client, body)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= s.webhookAttempts {
+			return fmt.Errorf("webhook for %s %s failed after %d attempts: %w", payload.Operation, payload.ID, attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook makes a single webhook delivery attempt
+func (s *Server) postWebhook(ctx context.Context, client *http.Client, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+// webhookReceiver starts a test server that records webhook payloads, responding with the given status codes
+// in order and 200 once they run out
+func webhookReceiver(t *testing.T, statuses ...int) (*httptest.Server, <-chan WebhookPayload) {
+	t.Helper()
+
+	received := make(chan WebhookPayload, 10)
+	var calls atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, r.Header.Get("Content-Type"), "application/json")
+
+		status := http.StatusOK
+		if call := int(calls.Add(1)); call <= len(statuses) {
+			status = statuses[call-1]
+		}
+		w.WriteHeader(status)
+
+		if status == http.StatusOK {
+			received <- payload
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, received
+}
+
+func waitForWebhook(t *testing.T, received <-chan WebhookPayload) WebhookPayload {
+	t.Helper()
+
+	select {
+	case payload := <-received:
+		return payload
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for webhook")
+		return WebhookPayload{}
+	}
+}
+
+func TestWebhook_FiresOnSave(t *testing.T) {
+	ts, received := webhookReceiver(t)
+	server := newTestServer(t, WithWebhook(ts.URL))
+
+	doc, err := server.fileRepo.GetOrCreateResourceDocument("notes", "", nil)
+	assert.Nil(t, err)
+
+	assert.Nil(t, doc.Save("# Notes\n\nHello\n"))
+
+	payload := waitForWebhook(t, received)
+	assert.Equal(t, payload.Operation, "save")
+	assert.Equal(t, payload.ID, doc.Info.ID)
+	assert.True(t, payload.Hash != "")
+	assert.False(t, payload.Timestamp.IsZero())
+}
+
+func TestWebhook_RetriesFailedDelivery(t *testing.T) {
+	ts, received := webhookReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	server := newTestServer(t, WithWebhook(ts.URL))
+	server.webhookBackoff = time.Millisecond
+
+	err := server.sendWebhook(context.Background(), WebhookPayload{Operation: "delete", ID: "resources/old"})
+	assert.Nil(t, err)
+
+	payload := waitForWebhook(t, received)
+	assert.Equal(t, payload.Operation, "delete")
+	assert.Equal(t, payload.ID, "resources/old")
+}
+
+func TestWebhook_GivesUpAfterAttempts(t *testing.T) {
+	ts, _ := webhookReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	server := newTestServer(t, WithWebhook(ts.URL))
+	server.webhookBackoff = time.Millisecond
+
+	err := server.sendWebhook(context.Background(), WebhookPayload{Operation: "save", ID: "inbox"})
+	assert.NotNil(t, err)
+}
+
+func TestWithWebhook_InvalidURL(t *testing.T) {
+	for _, webhookURL := range []string{"", "ftp://example.com/hook", "not a url", "http://"} {
+		assert.NotNil(t, WithWebhook(webhookURL)(&Server{}))
+	}
+}
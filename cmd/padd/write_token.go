@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// writeTokenHeader is the request header that carries the write token
+const writeTokenHeader = "X-PADD-Token"
+
+// writeTokenParam is the query parameter that gives a browser the write token, e.g., /inbox?write_token=...
+const writeTokenParam = "write_token"
+
+// writeTokenCookieName is the cookie that keeps the write token in a browser given it with writeTokenParam
+const writeTokenCookieName = "padd_write_token"
+
+// writeTokenCookieDuration is how long a browser keeps the write token
+const writeTokenCookieDuration = 365 * 24 * time.Hour
+
+// WithWriteToken requires the given shared secret in the X-PADD-Token header of every request that changes
+// data, so a read-only shared link can't toggle tasks or save documents
+func WithWriteToken(token string) ServerOption {
+	return func(s *Server) error {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return fmt.Errorf("write token cannot be empty")
+		}
+		s.writeToken = token
+		return nil
+	}
+}
+
+// requireWriteToken wraps a handler so POST, PUT, PATCH, and DELETE requests are rejected with
+// 401 Unauthorized unless they carry the write token, in a header or in the cookie a browser gets by opening
+// any page with the write_token parameter. Requests pass through when no token is configured, and when a
+// password is set, since requireAuth has then already let in only signed-in sessions and requests with the token.
+func (s *Server) requireWriteToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.writeToken == "" || s.auth != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodGet && r.URL.Query().Has(writeTokenParam) {
+			s.storeWriteToken(w, r)
+			return
+		}
+
+		if !isMutatingMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.hasWriteToken(r) && !s.hasWriteTokenCookie(r) {
+			http.Error(w, "Changes need the write token; open PADD once with ?"+writeTokenParam+"=<token> to make them from this browser", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasWriteToken reports whether the request carries the server's write token, in the X-PADD-Token header
+// or as a bearer token, which is what monitoring tools like Prometheus send
+func (s *Server) hasWriteToken(r *http.Request) bool {
+	token := r.Header.Get(writeTokenHeader)
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token == "" {
+		token = bearer
+	}
+	return s.writeToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.writeToken)) == 1
+}
+
+// storeWriteToken keeps a correct write token from the query string in a cookie, so the browser's own requests
+// carry it, and redirects to the page without the parameter so the token doesn't stay in the address bar
+func (s *Server) storeWriteToken(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if token := query.Get(writeTokenParam); subtle.ConstantTimeCompare([]byte(token), []byte(s.writeToken)) == 1 {
+		http.SetCookie(w, &http.Cookie{
+			Name:     writeTokenCookieName,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   int(writeTokenCookieDuration.Seconds()),
+			HttpOnly: true,
+			Secure:   isSecureRequest(r),
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+
+	query.Del(writeTokenParam)
+	target := r.URL.Path
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+	s.redirectTo(w, r, target)
+}
+
+// hasWriteTokenCookie reports whether the request carries the write token cookie and comes from the server's
+// own pages, so other sites can't make changes with it
+func (s *Server) hasWriteTokenCookie(r *http.Request) bool {
+	cookie, err := r.Cookie(writeTokenCookieName)
+	if err != nil || !isSameOrigin(r) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(s.writeToken)) == 1
+}
+
+// isMutatingMethod reports whether requests with the method can change data
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
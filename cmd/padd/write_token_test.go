@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestRequireWriteToken_TaskToggle(t *testing.T) {
+	server := newTestServer(t, WithWriteToken("s3cret"))
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n- [ ] Call Sam\n"))
+	server.fileRepo.ReloadCaches()
+
+	toggle := func(token string) int {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/toggle/1", nil)
+		req.Header.Set("X-PADD-File-ID", "active")
+		if token != "" {
+			req.Header.Set(writeTokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		server.setupRoutes().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, toggle(""), http.StatusUnauthorized)
+	assert.Equal(t, toggle("wrong"), http.StatusUnauthorized)
+
+	content, err := server.rootManager.ReadFile("active.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(content), "- [ ] Call Sam"))
+
+	assert.Equal(t, toggle("s3cret"), http.StatusOK)
+
+	content, err = server.rootManager.ReadFile("active.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(content), "- [x] Call Sam"))
+}
+
+func TestRequireWriteToken_BrowserCookie(t *testing.T) {
+	server := newTestServer(t, WithWriteToken("s3cret"))
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n- [ ] Call Sam\n"))
+	server.fileRepo.ReloadCaches()
+	handler := server.setupRoutes()
+
+	// A wrong token sets no cookie
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/active?write_token=wrong", nil))
+	assert.Equal(t, len(rec.Result().Cookies()), 0)
+
+	// The right one is kept in a cookie, and the page is reloaded without it
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/active?write_token=s3cret&view=raw", nil))
+	assert.Equal(t, rec.Code, http.StatusFound)
+	assert.Equal(t, rec.Header().Get("Location"), "/active?view=raw")
+
+	cookies := rec.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, writeTokenCookieName)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, cookies[0].SameSite, http.SameSiteStrictMode)
+
+	toggle := func(site string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPatch, "/tasks/toggle/1", nil)
+		req.Header.Set("X-PADD-File-ID", "active")
+		req.Header.Set("Sec-Fetch-Site", site)
+		req.AddCookie(cookies[0])
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Other sites can't use the cookie
+	assert.Equal(t, toggle("cross-site").Code, http.StatusUnauthorized)
+	assert.Equal(t, toggle("same-origin").Code, http.StatusOK)
+
+	content, err := server.rootManager.ReadFile("active.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(content), "- [x] Call Sam"))
+}
+
+func TestRequireWriteToken_ReadsAllowed(t *testing.T) {
+	server := newTestServer(t, WithWriteToken("s3cret"))
+
+	req := httptest.NewRequest(http.MethodGet, "/inbox", nil)
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+func TestRequireWriteToken_DisabledByDefault(t *testing.T) {
+	server := newTestServer(t)
+	assert.Nil(t, server.rootManager.WriteString("active.md", "# Active\n\n- [ ] Call Sam\n"))
+	server.fileRepo.ReloadCaches()
+
+	req := httptest.NewRequest(http.MethodPatch, "/tasks/toggle/1", nil)
+	req.Header.Set("X-PADD-File-ID", "active")
+	rec := httptest.NewRecorder()
+	server.setupRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+}
+
+func TestWithWriteToken_Empty(t *testing.T) {
+	assert.NotNil(t, WithWriteToken("  ")(&Server{}))
+}
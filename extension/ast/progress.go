@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"strconv"
+
+	gast "github.com/yuin/goldmark/ast"
+)
+
+// A Progress struct represents a [done/total] progress marker in the AST.
+type Progress struct {
+	gast.BaseInline
+	Done  int
+	Total int
+}
+
+// Dump implements Node.Dump.
+func (n *Progress) Dump(source []byte, level int) {
+	m := map[string]string{
+		"Done":  strconv.Itoa(n.Done),
+		"Total": strconv.Itoa(n.Total),
+	}
+	gast.DumpHelper(n, source, level, m, nil)
+}
+
+// KindProgress is a NodeKind of the Progress node.
+var KindProgress = gast.NewNodeKind("Progress")
+
+// Kind implements Node.Kind.
+func (n *Progress) Kind() gast.NodeKind {
+	return KindProgress
+}
+
+// NewProgress returns a new Progress node.
+func NewProgress(done, total int) *Progress {
+	return &Progress{
+		Done:  done,
+		Total: total,
+	}
+}
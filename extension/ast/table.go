@@ -0,0 +1,42 @@
+package ast
+
+import (
+	"fmt"
+
+	gast "github.com/yuin/goldmark/ast"
+)
+
+// A TableCellContent struct wraps the contents of a table cell so the cell can be edited in place.
+type TableCellContent struct {
+	gast.BaseInline
+	Table int // Position of the table in the document, from 0
+	Row   int // Row of the cell, where 0 is the header row
+	Col   int // Column of the cell, from 0
+}
+
+// Dump implements Node.Dump.
+func (n *TableCellContent) Dump(source []byte, level int) {
+	m := map[string]string{
+		"Table": fmt.Sprintf("%d", n.Table),
+		"Row":   fmt.Sprintf("%d", n.Row),
+		"Col":   fmt.Sprintf("%d", n.Col),
+	}
+	gast.DumpHelper(n, source, level, m, nil)
+}
+
+// KindTableCellContent is a NodeKind of the TableCellContent node.
+var KindTableCellContent = gast.NewNodeKind("TableCellContent")
+
+// Kind implements Node.Kind.
+func (n *TableCellContent) Kind() gast.NodeKind {
+	return KindTableCellContent
+}
+
+// NewTableCellContent returns a new TableCellContent node.
+func NewTableCellContent(table, row, col int) *TableCellContent {
+	return &TableCellContent{
+		Table: table,
+		Row:   row,
+		Col:   col,
+	}
+}
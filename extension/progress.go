@@ -0,0 +1,111 @@
+package extension
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/patrickward/padd/extension/ast"
+)
+
+// progressRegexp matches a progress marker such as [3/5]. Task checkboxes ([ ] and [x]) never match.
+var progressRegexp = regexp.MustCompile(`^\[(\d{1,6})/(\d{1,6})\]`)
+
+type progressParser struct {
+}
+
+var defaultProgressParser = &progressParser{}
+
+// NewProgressParser returns a new InlineParser that parses [done/total] progress markers.
+// This parser must take precedence over the parser.LinkParser.
+func NewProgressParser() parser.InlineParser {
+	return defaultProgressParser
+}
+
+func (p *progressParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p *progressParser) Parse(parent gast.Node, block text.Reader, pc parser.Context) gast.Node {
+	line, _ := block.PeekLine()
+	m := progressRegexp.FindSubmatchIndex(line)
+	if m == nil {
+		return nil
+	}
+
+	// Leave links such as [3/5](url) and [3/5][ref] to the link parser
+	if m[1] < len(line) && (line[m[1]] == '(' || line[m[1]] == '[') {
+		return nil
+	}
+
+	done, _ := strconv.Atoi(string(line[m[2]:m[3]]))
+	total, _ := strconv.Atoi(string(line[m[4]:m[5]]))
+	if total == 0 {
+		return nil
+	}
+
+	block.Advance(m[1])
+	return ast.NewProgress(done, total)
+}
+
+// ProgressHTMLRenderer is a renderer for the Progress node.
+type ProgressHTMLRenderer struct {
+	html.Config
+}
+
+// NewProgressHTMLRenderer creates a new ProgressHTMLRenderer.
+func NewProgressHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &ProgressHTMLRenderer{
+		Config: html.NewConfig(),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *ProgressHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindProgress, r.renderProgress)
+}
+
+func (r *ProgressHTMLRenderer) renderProgress(w util.BufWriter, source []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if !entering {
+		return gast.WalkContinue, nil
+	}
+
+	n := node.(*ast.Progress)
+
+	// Markers like [6/5] are shown as they are written, but the bar never overflows
+	percent := min(n.Done*100/n.Total, 100)
+
+	_, _ = w.WriteString(`<span class="progress">`)
+	_, _ = w.WriteString(fmt.Sprintf(`<span class="progress-bar" style="width: %d%%"></span>`, percent))
+	_, _ = w.WriteString(`</span>`)
+	_, _ = w.WriteString(fmt.Sprintf(`<span class="progress-label">%d/%d</span>`, n.Done, n.Total))
+
+	return gast.WalkContinue, nil
+}
+
+type progressExtension struct {
+}
+
+// Progress is an extension that renders [done/total] markers as progress bars.
+var Progress = &progressExtension{}
+
+func (e *progressExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(NewProgressParser(), 100),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewProgressHTMLRenderer(), 500),
+	))
+}
@@ -0,0 +1,108 @@
+package extension
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/patrickward/padd/extension/ast"
+)
+
+// GeneratedTableMarker is an HTML comment placed on the line before a table that isn't in the document's
+// source, such as one expanded from a {{csvtable}} shortcode. Marked tables are left out of the numbering
+// and can't be edited in place.
+const GeneratedTableMarker = "<!-- padd:generated-table -->"
+
+// tableCellTransformer wraps the contents of every table cell in a TableCellContent node. Tables are numbered
+// in document order from 0, the same way files.TableDocument finds them, so a cell can be edited by position.
+type tableCellTransformer struct {
+}
+
+func (t *tableCellTransformer) Transform(doc *gast.Document, reader text.Reader, _ parser.Context) {
+	tableCount := 0
+	_ = gast.Walk(doc, func(node gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering || node.Kind() != east.KindTable {
+			return gast.WalkContinue, nil
+		}
+
+		if isGeneratedTable(node, reader.Source()) {
+			return gast.WalkSkipChildren, nil
+		}
+
+		row := 0
+		for r := node.FirstChild(); r != nil; r = r.NextSibling() {
+			col := 0
+			for cell := r.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				content := ast.NewTableCellContent(tableCount, row, col)
+				for child := cell.FirstChild(); child != nil; {
+					next := child.NextSibling()
+					content.AppendChild(content, child)
+					child = next
+				}
+				cell.AppendChild(cell, content)
+				col++
+			}
+			row++
+		}
+		tableCount++
+
+		return gast.WalkSkipChildren, nil
+	})
+}
+
+// isGeneratedTable reports whether a table follows the GeneratedTableMarker
+func isGeneratedTable(table gast.Node, source []byte) bool {
+	prev, ok := table.PreviousSibling().(*gast.HTMLBlock)
+	if !ok || prev.Lines().Len() == 0 {
+		return false
+	}
+
+	return strings.TrimSpace(string(prev.Lines().Value(source))) == GeneratedTableMarker
+}
+
+// TableCellHTMLRenderer is a renderer.NodeRenderer implementation that renders the contents of table cells
+// so that double-clicking a cell opens its editor.
+type TableCellHTMLRenderer struct {
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *TableCellHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindTableCellContent, r.renderTableCellContent)
+}
+
+func (r *TableCellHTMLRenderer) renderTableCellContent(
+	w util.BufWriter, source []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString(`</span>`)
+		return gast.WalkContinue, nil
+	}
+	n := node.(*ast.TableCellContent)
+
+	_, _ = w.WriteString(fmt.Sprintf(`<span id="table-%d-cell-%d-%d" class="table-cell" hx-get="/tables/edit/%d/%d/%d" hx-trigger="dblclick" hx-swap="outerHTML">`,
+		n.Table, n.Row, n.Col, n.Table, n.Row, n.Col))
+
+	return gast.WalkContinue, nil
+}
+
+type tableCells struct {
+}
+
+// TableCells is an extension that lets the cells of GFM tables be edited in place. It must be used along
+// with the goldmark table extension.
+var TableCells = &tableCells{}
+
+func (e *tableCells) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&tableCellTransformer{}, 0),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&TableCellHTMLRenderer{}, 500),
+	))
+}
@@ -0,0 +1,110 @@
+package contentutil
+
+// DiffOp is the kind of change a DiffLine describes
+type DiffOp int
+
+const (
+	DiffEqual  DiffOp = iota // The line is in both versions
+	DiffDelete               // The line is only in the old version
+	DiffInsert               // The line is only in the new version
+)
+
+// DiffLine is a line of a line-based diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// IsDelete returns true if the line is only in the old version
+func (l DiffLine) IsDelete() bool {
+	return l.Op == DiffDelete
+}
+
+// IsInsert returns true if the line is only in the new version
+func (l DiffLine) IsInsert() bool {
+	return l.Op == DiffInsert
+}
+
+// maxDiffCells caps the size of the table used to find the longest common subsequence. Larger inputs are
+// diffed as a whole replacement of the changed middle, which is still correct, just less precise.
+const maxDiffCells = 4_000_000
+
+// DiffLines returns the line-based diff that turns oldContent into newContent, keeping the longest common
+// subsequence of lines and marking everything else as deleted or inserted
+func DiffLines(oldContent, newContent string) []DiffLine {
+	a, b := SplitLines(oldContent), SplitLines(newContent)
+
+	// Lines shared at the start and end don't need the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+	diff = append(diff, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: line})
+	}
+
+	return diff
+}
+
+// diffMiddle diffs the lines between the common prefix and suffix using a longest common subsequence table
+func diffMiddle(a, b []string) []DiffLine {
+	var diff []DiffLine
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: line})
+		}
+		for _, line := range b {
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+
+	return diff
+}
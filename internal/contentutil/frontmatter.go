@@ -44,3 +44,75 @@ func FindFrontmatter(lines []string) FrontmatterBounds {
 	// Return empty bounds if no closing delimiter found
 	return FrontmatterBounds{}
 }
+
+// FrontmatterValue returns the value of a top-level "key: value" entry in the frontmatter of the given lines.
+// Surrounding quotes are removed from the value. This is a lightweight lookup for simple scalar values;
+// use a YAML parser for anything more complex.
+func FrontmatterValue(lines []string, key string) (string, bool) {
+	bounds := FindFrontmatter(lines)
+	if !bounds.Found {
+		return "", false
+	}
+
+	// End is the line after the closing delimiter
+	for _, line := range lines[bounds.Start+1 : bounds.End-1] {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) != key || strings.HasPrefix(name, " ") {
+			continue
+		}
+
+		return strings.Trim(strings.TrimSpace(value), `"'`), true
+	}
+
+	return "", false
+}
+
+// FrontmatterValues returns all top-level "key: value" entries in the frontmatter of the given lines, using
+// the same rules as FrontmatterValue. Keys without a scalar value, such as the parent of a nested list, are
+// skipped. It returns nil if there is no frontmatter.
+func FrontmatterValues(lines []string) map[string]string {
+	bounds := FindFrontmatter(lines)
+	if !bounds.Found {
+		return nil
+	}
+
+	values := make(map[string]string)
+	for _, line := range lines[bounds.Start+1 : bounds.End-1] {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(name, " ") || strings.HasPrefix(name, "-") {
+			continue
+		}
+
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if value == "" {
+			continue
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+
+	return values
+}
+
+// SetFrontmatterValue sets a top-level "key: value" entry in the frontmatter of the given lines, replacing
+// any existing entry for the key. If there is no frontmatter, a new block is added at the top.
+func SetFrontmatterValue(lines []string, key, value string) []string {
+	entry := key + ": " + value
+
+	bounds := FindFrontmatter(lines)
+	if !bounds.Found {
+		return append([]string{"---", entry, "---"}, lines...)
+	}
+
+	result := make([]string, 0, len(lines)+1)
+	result = append(result, lines[:bounds.Start+1]...)
+	result = append(result, entry)
+	for _, line := range lines[bounds.Start+1 : bounds.End-1] {
+		if name, _, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) == key && !strings.HasPrefix(name, " ") {
+			continue
+		}
+		result = append(result, line)
+	}
+	result = append(result, lines[bounds.End-1:]...)
+
+	return result
+}
@@ -3,25 +3,35 @@ package crypto
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"filippo.io/age"
+	"filippo.io/age/armor"
 
 	"github.com/patrickward/padd/internal/contentutil"
 )
 
+// ErrIncorrectPassphrase is returned when a passphrase doesn't unlock the passphrase-protected identities
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+
 // EncryptionManager handles age encryption/decryption operations
 type EncryptionManager struct {
 	recipients []age.Recipient
 	identities []age.Identity
+	locked     [][]byte       // Passphrase-protected identity files, kept encrypted until unlocked
+	unlocked   []age.Identity // Identities from the locked files, until Lock drops them again
 	mu         sync.RWMutex
 	active     bool
+	logger     *slog.Logger
 }
 
 // NewEncryptionManager creates a new encryption manager
@@ -29,9 +39,17 @@ func NewEncryptionManager() *EncryptionManager {
 	return &EncryptionManager{
 		recipients: make([]age.Recipient, 0),
 		identities: make([]age.Identity, 0),
+		logger:     slog.Default(),
 	}
 }
 
+// SetLogger sets the logger for this EncryptionManager, which defaults to slog.Default()
+func (em *EncryptionManager) SetLogger(logger *slog.Logger) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	em.logger = logger
+}
+
 // AddRecipient adds a recipient for encryption (public key)
 func (em *EncryptionManager) AddRecipient(publicKey string) error {
 	em.mu.Lock()
@@ -99,18 +117,23 @@ func (em *EncryptionManager) AddIdentity(identityStr string) error {
 	return nil
 }
 
-// AddIdentitiesFromFile loads an identity from a file
+// AddIdentitiesFromFile loads an identity from a file. An identity file encrypted with a passphrase (e.g., by
+// "age -p") is kept locked, and its identities are only loaded once Unlock is called with the passphrase.
 func (em *EncryptionManager) AddIdentitiesFromFile(filePath string) error {
-	keyFile, err := os.Open(filePath)
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open key file: %w", err)
 	}
 
-	defer func(keyFile *os.File) {
-		_ = keyFile.Close()
-	}(keyFile)
+	if isPassphraseProtected(content) {
+		em.mu.Lock()
+		defer em.mu.Unlock()
 
-	identities, err := age.ParseIdentities(keyFile)
+		em.locked = append(em.locked, content)
+		return nil
+	}
+
+	identities, err := age.ParseIdentities(bytes.NewReader(content))
 	if err != nil {
 		return fmt.Errorf("failed to parse identities: %w", err)
 	}
@@ -125,6 +148,77 @@ func (em *EncryptionManager) AddIdentitiesFromFile(filePath string) error {
 	return nil
 }
 
+// Unlock decrypts the passphrase-protected identity files with the passphrase and loads their identities. It
+// returns ErrIncorrectPassphrase if the passphrase doesn't decrypt every locked file, in which case nothing is
+// loaded.
+func (em *EncryptionManager) Unlock(passphrase string) error {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	if len(em.locked) == 0 {
+		return fmt.Errorf("no passphrase-protected identities to unlock")
+	}
+
+	scryptIdentity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to create passphrase identity: %w", err)
+	}
+
+	var unlocked []age.Identity
+	for _, content := range em.locked {
+		var src io.Reader = bytes.NewReader(content)
+		if isArmored(content) {
+			src = armor.NewReader(src)
+		}
+
+		decryptReader, err := age.Decrypt(src, scryptIdentity)
+		if err != nil {
+			var noMatch *age.NoIdentityMatchError
+			if errors.As(err, &noMatch) {
+				return ErrIncorrectPassphrase
+			}
+			return fmt.Errorf("failed to decrypt identity file: %w", err)
+		}
+
+		identities, err := age.ParseIdentities(decryptReader)
+		if err != nil {
+			return fmt.Errorf("failed to parse identities: %w", err)
+		}
+		unlocked = append(unlocked, identities...)
+	}
+
+	em.unlocked = unlocked
+	em.logger.Info("Encryption identities unlocked", "identities", len(unlocked))
+	return nil
+}
+
+// Lock drops the identities loaded by Unlock, so files can't be decrypted until it's unlocked again.
+// Identities that weren't passphrase-protected stay loaded.
+func (em *EncryptionManager) Lock() {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	if em.unlocked != nil {
+		em.logger.Info("Encryption identities locked")
+	}
+	em.unlocked = nil
+}
+
+// IsLocked returns true if passphrase-protected identities are waiting to be unlocked
+func (em *EncryptionManager) IsLocked() bool {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return len(em.locked) > 0 && em.unlocked == nil
+}
+
+// HasPassphraseProtectedIdentities returns true if any identity file is protected by a passphrase, so the
+// manager can be unlocked and locked
+func (em *EncryptionManager) HasPassphraseProtectedIdentities() bool {
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+	return len(em.locked) > 0
+}
+
 // Activate starts an encryption session
 func (em *EncryptionManager) Activate() {
 	em.mu.Lock()
@@ -153,11 +247,12 @@ func (em *EncryptionManager) HasRecipients() bool {
 	return len(em.recipients) > 0
 }
 
-// HasIdentities returns true if any identities are configured
+// HasIdentities returns true if any identities are configured. Passphrase-protected identities only count
+// once they're unlocked.
 func (em *EncryptionManager) HasIdentities() bool {
 	em.mu.RLock()
 	defer em.mu.RUnlock()
-	return len(em.identities) > 0
+	return len(em.identities)+len(em.unlocked) > 0
 }
 
 // Encrypt encrypts content using the configured recipients
@@ -193,13 +288,14 @@ func (em *EncryptionManager) Decrypt(encryptedContent []byte) (string, error) {
 	em.mu.RLock()
 	defer em.mu.RUnlock()
 
-	if len(em.identities) == 0 {
+	identities := append(slices.Clone(em.identities), em.unlocked...)
+	if len(identities) == 0 {
 		return "", fmt.Errorf("no identities configured for decryption")
 	}
 
 	reader := bytes.NewReader(encryptedContent)
 
-	decryptReader, err := age.Decrypt(reader, em.identities...)
+	decryptReader, err := age.Decrypt(reader, identities...)
 	if err != nil {
 		return "", fmt.Errorf("failed to decrypt: %w", err)
 	}
@@ -222,6 +318,20 @@ func IsAgeEncrypted(content []byte) bool {
 	return bytes.HasPrefix(content, []byte("age-encryption.org/v1"))
 }
 
+// armorHeader begins an age file in the ASCII armored format (e.g., written by "age -p -a")
+const armorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// isArmored checks if content is an ASCII armored age file
+func isArmored(content []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(content), []byte(armorHeader))
+}
+
+// isPassphraseProtected checks if the content of an identity file is itself age encrypted, as identity files
+// protected with a passphrase are
+func isPassphraseProtected(content []byte) bool {
+	return IsAgeEncrypted(content) || isArmored(content)
+}
+
 // HasEncryptedFrontmatter checks if content has encrypted: true in frontmatter
 func HasEncryptedFrontmatter(content string) bool {
 	lines := contentutil.SplitLines(content)
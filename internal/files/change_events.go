@@ -0,0 +1,74 @@
+package files
+
+import "time"
+
+// ChangeOperation names the kind of change made to a file
+type ChangeOperation string
+
+const (
+	// ChangeSave is sent when a document is written
+	ChangeSave ChangeOperation = "save"
+	// ChangeDelete is sent when a document is deleted
+	ChangeDelete ChangeOperation = "delete"
+	// ChangeRename is sent when a file is moved, including archiving and restoring it
+	ChangeRename ChangeOperation = "rename"
+)
+
+// ChangeEvent describes a change to a file. It carries only metadata, never the file content.
+type ChangeEvent struct {
+	Operation ChangeOperation
+	ID        string
+	OldID     string // The ID before a rename; empty for other operations
+	Hash      string // SHA-256 of the saved content; empty for other operations and external changes
+	Time      time.Time
+	External  bool // True when the change was made outside the application and picked up by the file watcher
+}
+
+// OnChange registers a function that is called after a file is saved, deleted, or renamed, including changes
+// made outside the application when they are applied with ApplyExternalChanges. Listeners run
+// synchronously on the goroutine making the change, so they should hand off any slow work.
+func (fr *FileRepository) OnChange(listener func(ChangeEvent)) {
+	fr.changeMu.Lock()
+	defer fr.changeMu.Unlock()
+	fr.changeListeners = append(fr.changeListeners, listener)
+}
+
+// notifyChange calls every registered change listener with the event
+func (fr *FileRepository) notifyChange(event ChangeEvent) {
+	fr.changeMu.RLock()
+	listeners := fr.changeListeners
+	fr.changeMu.RUnlock()
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// CacheRefresh describes a rebuild of the file caches and search index
+type CacheRefresh struct {
+	Files    int           // Number of files in the rebuilt caches
+	Duration time.Duration // How long the rebuild took
+}
+
+// OnCacheRefresh registers a function that is called after the file caches and search index are rebuilt.
+// Listeners run synchronously on the goroutine that rebuilt the caches.
+func (fr *FileRepository) OnCacheRefresh(listener func(CacheRefresh)) {
+	fr.changeMu.Lock()
+	defer fr.changeMu.Unlock()
+	fr.refreshListeners = append(fr.refreshListeners, listener)
+}
+
+// notifyCacheRefresh calls every registered cache refresh listener with the refresh
+func (fr *FileRepository) notifyCacheRefresh(refresh CacheRefresh) {
+	fr.changeMu.RLock()
+	listeners := fr.refreshListeners
+	fr.changeMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(refresh)
+	}
+}
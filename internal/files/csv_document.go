@@ -4,10 +4,24 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
+	"unicode/utf8"
+
+	"github.com/patrickward/padd/internal/crypto"
 )
 
+// SidecarSuffix is the suffix for metadata sidecar files stored alongside documents
+const SidecarSuffix = ".meta.json"
+
+// IsSidecar returns true if the path is a metadata sidecar file (e.g., "data.csv.meta.json").
+// Sidecars are never indexed, searched, or viewable as documents.
+func IsSidecar(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), SidecarSuffix)
+}
+
 // CSVDocument represents a CSV document
 type CSVDocument struct {
 	*Document
@@ -26,6 +40,7 @@ type CSVMetadata struct {
 	ColumnTypes map[int]CellType  `json:"column_types,omitempty"`
 	Headers     []string          `json:"headers,omitempty"`
 	Custom      map[string]string `json:"custom,omitempty"`
+	Delimiter   string            `json:"delimiter,omitempty"` // Single-character field separator; defaults to a tab for .tsv files and a comma otherwise
 }
 
 // CellType represents the type of a cell in a CSV document
@@ -65,11 +80,27 @@ func (c *CSVDocument) SaveMetadata(metadata *CSVMetadata) error {
 
 	metaPath := c.getMetadataPath()
 
+	if _, err := parseCSVDelimiter(metadata.Delimiter); err != nil {
+		return err
+	}
+
 	data, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal csv metadata: %w", err)
 	}
 
+	// Keep the sidecar encrypted alongside an encrypted CSV
+	if c.isEncrypted() {
+		if !c.repo.encryptionManager.IsActive() || !c.repo.encryptionManager.HasRecipients() {
+			return fmt.Errorf("failed to save csv metadata: no recipients to encrypt metadata for %s", c.Info.Path)
+		}
+
+		data, err = c.repo.encryptionManager.Encrypt(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt csv metadata: %w", err)
+		}
+	}
+
 	if err := c.repo.rootManager.WriteFile(metaPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to save csv metadata: %w", err)
 	}
@@ -115,6 +146,8 @@ func (c *CSVDocument) GetCell(row, col int) (string, error) {
 	return record[col], nil
 }
 
+// UpdateCell sets the value of a cell and saves the file. Values in data rows must match the column's type
+// in the metadata; a *CellError is returned when they don't.
 func (c *CSVDocument) UpdateCell(row, col int, value string) error {
 	records, err := c.GetRecords()
 	if err != nil {
@@ -129,6 +162,17 @@ func (c *CSVDocument) UpdateCell(row, col int, value string) error {
 		return fmt.Errorf("column index %d out of range (record has %d columns)", col, len(records[row]))
 	}
 
+	if row > 0 {
+		metadata, err := c.GetMetadata()
+		if err != nil {
+			return err
+		}
+
+		if cellType := metadata.ColumnType(col); cellType.Validate(value) != nil {
+			return &CellError{Row: row, Col: col, Type: cellType, Value: value}
+		}
+	}
+
 	c.recordsMu.Lock()
 	defer c.recordsMu.Unlock()
 
@@ -138,6 +182,8 @@ func (c *CSVDocument) UpdateCell(row, col int, value string) error {
 	return c.saveRecords(records)
 }
 
+// UpdateRecord replaces a record and saves the file. Values in data rows must match their column types; a
+// *ValidationError lists the ones that don't.
 func (c *CSVDocument) UpdateRecord(row int, values []string) error {
 	records, err := c.GetRecords()
 	if err != nil {
@@ -148,6 +194,10 @@ func (c *CSVDocument) UpdateRecord(row int, values []string) error {
 		return fmt.Errorf("record index %d out of range (document has %d records)", row, len(records))
 	}
 
+	if err := c.validateRecord(row, values); err != nil {
+		return err
+	}
+
 	c.recordsMu.Lock()
 	defer c.recordsMu.Unlock()
 
@@ -157,12 +207,17 @@ func (c *CSVDocument) UpdateRecord(row int, values []string) error {
 	return c.saveRecords(records)
 }
 
+// AddRecord appends a record and saves the file. Like UpdateRecord, values must match their column types.
 func (c *CSVDocument) AddRecord(values []string) error {
 	records, err := c.GetRecords()
 	if err != nil {
 		return err
 	}
 
+	if err := c.validateRecord(len(records), values); err != nil {
+		return err
+	}
+
 	c.recordsMu.Lock()
 	defer c.recordsMu.Unlock()
 
@@ -217,6 +272,261 @@ func (c *CSVDocument) ColumnCount() (int, error) {
 	return len(records[0]), nil
 }
 
+// AddColumn inserts a column with the given header at index, shifting later columns right. An index equal to
+// the column count appends the column. Every record gets an empty cell, and the metadata headers and column
+// types shift with the columns.
+func (c *CSVDocument) AddColumn(index int, header string) error {
+	header, err := csvHeader(header)
+	if err != nil {
+		return err
+	}
+
+	records, err := c.GetRecords()
+	if err != nil {
+		return err
+	}
+
+	columns := 0
+	if len(records) > 0 {
+		columns = len(records[0])
+	}
+
+	if index < 0 || index > columns {
+		return fmt.Errorf("column index %d out of range (document has %d columns)", index, columns)
+	}
+
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+
+	if len(records) == 0 {
+		records = [][]string{{}}
+	}
+
+	for i := range records {
+		value := ""
+		if i == 0 {
+			value = header
+		}
+		records[i] = slices.Insert(records[i], index, value)
+	}
+	c.records = records
+
+	if err := c.saveRecords(records); err != nil {
+		return err
+	}
+
+	return c.updateColumnMetadata(func(metadata *CSVMetadata) {
+		if index <= len(metadata.Headers) && len(metadata.Headers) > 0 {
+			metadata.Headers = slices.Insert(metadata.Headers, index, header)
+		}
+		metadata.ColumnTypes = remapColumnTypes(metadata.ColumnTypes, func(col int) int {
+			if col >= index {
+				return col + 1
+			}
+			return col
+		})
+	})
+}
+
+// DeleteColumn removes the column at index from every record, along with its metadata header and column
+// type. The last remaining column can't be deleted.
+func (c *CSVDocument) DeleteColumn(index int) error {
+	records, err := c.GetRecords()
+	if err != nil {
+		return err
+	}
+
+	if err := checkColumnIndex(records, index); err != nil {
+		return err
+	}
+
+	if len(records[0]) == 1 {
+		return fmt.Errorf("cannot delete the only column")
+	}
+
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+
+	deleted := records[0][index]
+	for i := range records {
+		records[i] = slices.Delete(records[i], index, index+1)
+	}
+	c.records = records
+
+	if err := c.saveRecords(records); err != nil {
+		return err
+	}
+
+	return c.updateColumnMetadata(func(metadata *CSVMetadata) {
+		if index < len(metadata.Headers) {
+			deleted = metadata.Headers[index]
+			metadata.Headers = slices.Delete(metadata.Headers, index, index+1)
+		}
+		if metadata.SortColumn == deleted {
+			metadata.SortColumn = ""
+			metadata.SortDesc = false
+		}
+		delete(metadata.ColumnTypes, index)
+		metadata.ColumnTypes = remapColumnTypes(metadata.ColumnTypes, func(col int) int {
+			if col > index {
+				return col - 1
+			}
+			return col
+		})
+	})
+}
+
+// RenameHeader changes the header of the column at index, in the header row and in the metadata headers.
+// A sort column that named the old header follows the rename.
+func (c *CSVDocument) RenameHeader(index int, header string) error {
+	header, err := csvHeader(header)
+	if err != nil {
+		return err
+	}
+
+	records, err := c.GetRecords()
+	if err != nil {
+		return err
+	}
+
+	if err := checkColumnIndex(records, index); err != nil {
+		return err
+	}
+
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+
+	renamed := records[0][index]
+	records[0][index] = header
+	c.records = records
+
+	if err := c.saveRecords(records); err != nil {
+		return err
+	}
+
+	return c.updateColumnMetadata(func(metadata *CSVMetadata) {
+		if index < len(metadata.Headers) {
+			renamed = metadata.Headers[index]
+			metadata.Headers[index] = header
+		}
+		if metadata.SortColumn == renamed {
+			metadata.SortColumn = header
+		}
+	})
+}
+
+// MoveColumn moves the column at from to the position to, shifting the columns in between. The metadata
+// headers and column types move with the column.
+func (c *CSVDocument) MoveColumn(from, to int) error {
+	records, err := c.GetRecords()
+	if err != nil {
+		return err
+	}
+
+	if err := checkColumnIndex(records, from); err != nil {
+		return err
+	}
+
+	if err := checkColumnIndex(records, to); err != nil {
+		return err
+	}
+
+	if from == to {
+		return nil
+	}
+
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+
+	for i := range records {
+		records[i] = moveElement(records[i], from, to)
+	}
+	c.records = records
+
+	if err := c.saveRecords(records); err != nil {
+		return err
+	}
+
+	return c.updateColumnMetadata(func(metadata *CSVMetadata) {
+		if from < len(metadata.Headers) && to < len(metadata.Headers) {
+			metadata.Headers = moveElement(metadata.Headers, from, to)
+		}
+		metadata.ColumnTypes = remapColumnTypes(metadata.ColumnTypes, func(col int) int {
+			switch {
+			case col == from:
+				return to
+			case from < to && col > from && col <= to:
+				return col - 1
+			case to < from && col >= to && col < from:
+				return col + 1
+			default:
+				return col
+			}
+		})
+	})
+}
+
+// updateColumnMetadata applies a column change to the metadata and saves it. Nothing is written when there is no
+// sidecar and the change leaves the metadata without headers or column types.
+func (c *CSVDocument) updateColumnMetadata(update func(metadata *CSVMetadata)) error {
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return err
+	}
+
+	updated := *metadata
+	updated.Headers = slices.Clone(metadata.Headers)
+	updated.ColumnTypes = maps.Clone(metadata.ColumnTypes)
+	update(&updated)
+
+	if !c.repo.rootManager.FileExists(c.getMetadataPath()) && len(updated.Headers) == 0 && len(updated.ColumnTypes) == 0 {
+		return nil
+	}
+
+	return c.SaveMetadata(&updated)
+}
+
+// checkColumnIndex returns an error if index isn't a column of the records
+func checkColumnIndex(records [][]string, index int) error {
+	columns := 0
+	if len(records) > 0 {
+		columns = len(records[0])
+	}
+
+	if index < 0 || index >= columns {
+		return fmt.Errorf("column index %d out of range (document has %d columns)", index, columns)
+	}
+
+	return nil
+}
+
+// csvHeader trims a column header, which can't be empty
+func csvHeader(header string) (string, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return "", fmt.Errorf("column header cannot be empty")
+	}
+
+	return header, nil
+}
+
+// remapColumnTypes moves column types to the columns given by newIndex
+func remapColumnTypes(types map[int]CellType, newIndex func(col int) int) map[int]CellType {
+	remapped := make(map[int]CellType, len(types))
+	for col, cellType := range types {
+		remapped[newIndex(col)] = cellType
+	}
+
+	return remapped
+}
+
+// moveElement moves the element at from to the position to, shifting the elements in between
+func moveElement[T any](s []T, from, to int) []T {
+	element := s[from]
+	s = slices.Delete(s, from, from+1)
+	return slices.Insert(s, to, element)
+}
+
 func emptyCSVMetadata() *CSVMetadata {
 	return &CSVMetadata{
 		ColumnTypes: make(map[int]CellType),
@@ -240,11 +550,25 @@ func (c *CSVDocument) loadMetadata() (*CSVMetadata, error) {
 		return nil, fmt.Errorf("failed to load csv metadata: %w", err)
 	}
 
+	if c.repo.encryptionManager.IsActive() &&
+		c.repo.encryptionManager.HasIdentities() &&
+		crypto.IsAgeEncrypted(data) {
+		decrypted, err := c.repo.encryptionManager.Decrypt(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt csv metadata: %w", err)
+		}
+		data = []byte(decrypted)
+	}
+
 	var metadata CSVMetadata
 	if err := json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal csv metadata: %w", err)
 	}
 
+	if _, err := parseCSVDelimiter(metadata.Delimiter); err != nil {
+		return nil, err
+	}
+
 	if metadata.ColumnTypes == nil {
 		metadata.ColumnTypes = make(map[int]CellType)
 	}
@@ -260,8 +584,52 @@ func (c *CSVDocument) loadMetadata() (*CSVMetadata, error) {
 	return &metadata, nil
 }
 
+// delimiter returns the field separator for the document: the metadata delimiter if set, otherwise
+// a tab for .tsv files and a comma for everything else
+func (c *CSVDocument) delimiter() (rune, error) {
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return 0, err
+	}
+
+	if metadata.Delimiter != "" {
+		return parseCSVDelimiter(metadata.Delimiter)
+	}
+
+	if strings.HasSuffix(strings.ToLower(c.Info.Path), ".tsv") {
+		return '\t', nil
+	}
+
+	return ',', nil
+}
+
+// parseCSVDelimiter returns the delimiter a metadata delimiter names, or 0 for an empty one. A delimiter must
+// be a single character that encoding/csv accepts: not a quote, a line break, or the Unicode replacement character.
+func parseCSVDelimiter(delimiter string) (rune, error) {
+	if delimiter == "" {
+		return 0, nil
+	}
+
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid csv delimiter %q: must be a single character other than a quote or line break", delimiter)
+	}
+
+	return r, nil
+}
+
+// isEncrypted returns true if the CSV file is age-encrypted on disk
+func (c *CSVDocument) isEncrypted() bool {
+	content, err := c.repo.rootManager.ReadFile(c.Info.Path)
+	if err != nil {
+		return false
+	}
+
+	return crypto.IsAgeEncrypted(content)
+}
+
 func (c *CSVDocument) getMetadataPath() string {
-	return c.Info.Path + ".meta.json"
+	return c.Info.Path + SidecarSuffix
 }
 
 func (c *CSVDocument) loadRecords() ([][]string, error) {
@@ -273,7 +641,13 @@ func (c *CSVDocument) loadRecords() ([][]string, error) {
 		return nil, fmt.Errorf("failed to load csv content: %w", err)
 	}
 
+	delimiter, err := c.delimiter()
+	if err != nil {
+		return nil, err
+	}
+
 	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = delimiter
 	records, err := reader.ReadAll()
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse csv content: %w", err)
@@ -284,19 +658,15 @@ func (c *CSVDocument) loadRecords() ([][]string, error) {
 }
 
 func (c *CSVDocument) saveRecords(records [][]string) error {
-	var buf strings.Builder
-	writer := csv.NewWriter(&buf)
-
-	for _, record := range records {
-		if err := writer.Write(record); err != nil {
-			return fmt.Errorf("failed to write csv record: %w", err)
-		}
+	delimiter, err := c.delimiter()
+	if err != nil {
+		return err
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return fmt.Errorf("failed to flush csv writer: %w", err)
+	content, err := EncodeCSV(records, delimiter)
+	if err != nil {
+		return err
 	}
 
-	return c.Document.Save(buf.String())
+	return c.Document.Save(content)
 }
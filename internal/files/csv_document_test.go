@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/crypto"
 	"github.com/patrickward/padd/internal/files"
 )
 
@@ -242,6 +243,189 @@ func TestCSVDocument_Metadata(t *testing.T) {
 	assert.Equal(t, loadedMeta.Headers[0], "Name")
 }
 
+func TestCSVDocument_EncryptedMetadata(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	publicKey, privateKey, _, _, err := crypto.GenerateNewEncryptionPair(t.TempDir())
+	assert.Nil(t, err)
+
+	em := crypto.NewEncryptionManager()
+	assert.Nil(t, em.AddRecipient(publicKey))
+	assert.Nil(t, em.AddIdentity(privateKey))
+	em.Activate()
+	fr.SetEncryptionManager(em)
+
+	// Write an encrypted CSV
+	encrypted, err := em.Encrypt("name,age\nJohn,25\n")
+	assert.Nil(t, err)
+	err = rm.WriteFile("secret.csv", encrypted, 0644)
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("secret.csv")
+	assert.Nil(t, err)
+
+	err = files.NewCSVDocument(doc).SaveMetadata(&files.CSVMetadata{Title: "Secret Data", SortColumn: "age"})
+	assert.Nil(t, err)
+
+	// The sidecar is encrypted on disk
+	raw, err := rm.ReadFile("secret.csv.meta.json")
+	assert.Nil(t, err)
+	assert.True(t, crypto.IsAgeEncrypted(raw))
+	assert.False(t, containsString(string(raw), "Secret Data"))
+
+	// And round-trips through a fresh document
+	doc, err = fr.GetDocument("secret.csv")
+	assert.Nil(t, err)
+
+	metadata, err := files.NewCSVDocument(doc).GetMetadata()
+	assert.Nil(t, err)
+	assert.Equal(t, metadata.Title, "Secret Data")
+	assert.Equal(t, metadata.SortColumn, "age")
+}
+
+func TestCSVDocument_TSV(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("people.tsv", "name\tcity\nJohn\tNew York, NY\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	csvDoc, err := fr.GetCSVDocument("people.tsv")
+	assert.Nil(t, err)
+
+	cell, err := csvDoc.GetCell(1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, cell, "New York, NY")
+
+	err = csvDoc.UpdateCell(1, 0, "Jane")
+	assert.Nil(t, err)
+
+	content, err := rm.ReadFile("people.tsv")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "name\tcity\nJane\tNew York, NY\n")
+}
+
+func TestCSVDocument_SemicolonDelimiter(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("prices.csv", "item;price\nBread;2,50\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("prices.csv.meta.json", `{"delimiter": ";"}`)
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	csvDoc, err := fr.GetCSVDocument("prices.csv")
+	assert.Nil(t, err)
+
+	count, err := csvDoc.ColumnCount()
+	assert.Nil(t, err)
+	assert.Equal(t, count, 2)
+
+	cell, err := csvDoc.GetCell(1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, cell, "2,50")
+
+	err = csvDoc.AddRecord([]string{"Milk", "1,20"})
+	assert.Nil(t, err)
+
+	content, err := rm.ReadFile("prices.csv")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "item;price\nBread;2,50\nMilk;1,20\n")
+}
+
+func TestCSVDocument_InvalidDelimiter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		metadata string
+	}{
+		{name: "code point", metadata: `{"delimiter": 59}`},
+		{name: "several characters", metadata: `{"delimiter": ";;"}`},
+		{name: "quote", metadata: `{"delimiter": "\""}`},
+		{name: "line break", metadata: `{"delimiter": "\n"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tmp := t.TempDir()
+			fr, rm := setupTestFileRepo(t, tmp)
+			err := fr.Initialize()
+			assert.Nil(t, err)
+
+			err = rm.WriteString("prices.csv", "item;price\nBread;2,50\n")
+			assert.Nil(t, err)
+			err = rm.WriteString("prices.csv.meta.json", tt.metadata)
+			assert.Nil(t, err)
+			fr.ReloadCaches()
+
+			csvDoc, err := fr.GetCSVDocument("prices.csv")
+			assert.Nil(t, err)
+
+			_, err = csvDoc.GetMetadata()
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestCSVDocument_SaveMetadata_InvalidDelimiter(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("prices.csv", "item,price\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	csvDoc, err := fr.GetCSVDocument("prices.csv")
+	assert.Nil(t, err)
+
+	err = csvDoc.SaveMetadata(&files.CSVMetadata{Delimiter: "::"})
+	assert.NotNil(t, err)
+	assert.False(t, rm.FileExists("prices.csv.meta.json"))
+}
+
+func TestCSVDocument_MetadataSidecarNotViewable(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("test.csv", "name,age\nJohn,25\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("test.csv.meta.json", `{"title": "Test Data"}`)
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	assert.True(t, files.IsSidecar("test.csv.meta.json"))
+	assert.False(t, files.IsSidecar("test.csv"))
+
+	// The sidecar is never indexed or returned as a document
+	assert.False(t, fr.FileIDExists("test.csv.meta.json"))
+	_, err = fr.GetDocument("test.csv.meta.json")
+	assert.NotNil(t, err)
+
+	// The CSV itself is still viewable
+	_, err = fr.GetDocument("test.csv")
+	assert.Nil(t, err)
+}
+
 func TestCSVDocument_ErrorHandling(t *testing.T) {
 	t.Parallel()
 	tmp := t.TempDir()
@@ -329,3 +513,99 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+func TestCSVDocument_ColumnOperations(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("test.csv", "name,age,city\nJohn,25,NYC\nJane,30,LA\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("test.csv")
+	assert.Nil(t, err)
+	csvDoc := files.NewCSVDocument(doc)
+
+	err = csvDoc.SaveMetadata(&files.CSVMetadata{
+		SortColumn:  "Age",
+		Headers:     []string{"Name", "Age", "City"},
+		ColumnTypes: map[int]files.CellType{1: files.CellTypeNum, 2: files.CellTypeText},
+	})
+	assert.Nil(t, err)
+
+	// Add a column between name and age
+	err = csvDoc.AddColumn(1, " email ")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "name,email,age,city\nJohn,,25,NYC\nJane,,30,LA\n")
+
+	metadata, err := csvDoc.GetMetadata()
+	assert.Nil(t, err)
+	assert.Equal(t, metadata.Headers, []string{"Name", "email", "Age", "City"})
+	assert.Equal(t, metadata.ColumnTypes, map[int]files.CellType{2: files.CellTypeNum, 3: files.CellTypeText})
+
+	// Move city to the front
+	err = csvDoc.MoveColumn(3, 0)
+	assert.Nil(t, err)
+	content, err = doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "city,name,email,age\nNYC,John,,25\nLA,Jane,,30\n")
+
+	metadata, err = csvDoc.GetMetadata()
+	assert.Nil(t, err)
+	assert.Equal(t, metadata.Headers, []string{"City", "Name", "email", "Age"})
+	assert.Equal(t, metadata.ColumnTypes, map[int]files.CellType{0: files.CellTypeText, 3: files.CellTypeNum})
+
+	// Rename age; the sort column follows
+	err = csvDoc.RenameHeader(3, "Years")
+	assert.Nil(t, err)
+	metadata, err = csvDoc.GetMetadata()
+	assert.Nil(t, err)
+	assert.Equal(t, metadata.SortColumn, "Years")
+	record, err := csvDoc.GetRecord(0)
+	assert.Nil(t, err)
+	assert.Equal(t, record, []string{"city", "name", "email", "Years"})
+
+	// Delete the sort column
+	err = csvDoc.DeleteColumn(3)
+	assert.Nil(t, err)
+	content, err = doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "city,name,email\nNYC,John,\nLA,Jane,\n")
+
+	metadata, err = csvDoc.GetMetadata()
+	assert.Nil(t, err)
+	assert.Equal(t, metadata.SortColumn, "")
+	assert.Equal(t, metadata.Headers, []string{"City", "Name", "email"})
+	assert.Equal(t, metadata.ColumnTypes, map[int]files.CellType{0: files.CellTypeText})
+}
+
+func TestCSVDocument_ColumnOperationErrors(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("test.csv", "name\nJohn\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("test.csv")
+	assert.Nil(t, err)
+	csvDoc := files.NewCSVDocument(doc)
+
+	assert.NotNil(t, csvDoc.AddColumn(2, "age"))
+	assert.NotNil(t, csvDoc.AddColumn(1, "  "))
+	assert.NotNil(t, csvDoc.DeleteColumn(0))
+	assert.NotNil(t, csvDoc.RenameHeader(1, "age"))
+	assert.NotNil(t, csvDoc.MoveColumn(0, 1))
+
+	// Column changes without any metadata don't create a sidecar
+	assert.Nil(t, csvDoc.AddColumn(1, "age"))
+	assert.False(t, rm.FileExists("test.csv.meta.json"))
+}
@@ -0,0 +1,328 @@
+package files
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// csvFilterOps are the operators of a filter expression, with two-character operators first so they match
+// before their one-character prefixes
+var csvFilterOps = []string{"!=", "!~", "<=", ">=", "=", "~", "<", ">"}
+
+// CSVSort orders records by the values of a column
+type CSVSort struct {
+	Column int
+	Desc   bool
+}
+
+// CSVFilter keeps the records whose value in a column matches. Op is one of = and != (equal), ~ and !~
+// (contains, ignoring case), or <, <=, >, and >=.
+type CSVFilter struct {
+	Column int
+	Op     string
+	Value  string
+}
+
+// CSVQuery picks and orders the data rows of a CSV document. Records must match every filter, and are
+// sorted by each sort in turn, so later sorts break ties in earlier ones.
+type CSVQuery struct {
+	Sorts   []CSVSort
+	Filters []CSVFilter
+}
+
+// ParseSort reads a sort parameter: a comma-separated list of columns, each prefixed with "-" to sort it in
+// descending order, e.g., "due,-qty". Columns are named by their headers, ignoring case.
+func (c *CSVDocument) ParseSort(spec string) ([]CSVSort, error) {
+	var sorts []CSVSort
+	for _, part := range strings.Split(spec, ",") {
+		name := strings.TrimSpace(part)
+		if name == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(name, "-")
+		col, err := c.ColumnIndex(strings.TrimSpace(strings.TrimPrefix(name, "-")))
+		if err != nil {
+			return nil, err
+		}
+
+		sorts = append(sorts, CSVSort{Column: col, Desc: desc})
+	}
+
+	return sorts, nil
+}
+
+// ParseFilter reads a filter expression made of a column header, an operator, and a value, e.g.,
+// "status=done", "name~smith", or "qty>=3". Values are compared by the column's type, so "due<2025-02-01"
+// compares dates; ordering a typed column by a value that isn't of its type is an error.
+func (c *CSVDocument) ParseFilter(expr string) (CSVFilter, error) {
+	opIndex, op := -1, ""
+	for _, candidate := range csvFilterOps {
+		if i := strings.Index(expr, candidate); i > 0 && (opIndex == -1 || i < opIndex) {
+			opIndex, op = i, candidate
+		}
+	}
+
+	if opIndex == -1 {
+		return CSVFilter{}, fmt.Errorf("invalid filter %q (expected a column, an operator such as = or ~, and a value)", expr)
+	}
+
+	col, err := c.ColumnIndex(strings.TrimSpace(expr[:opIndex]))
+	if err != nil {
+		return CSVFilter{}, err
+	}
+
+	filter := CSVFilter{Column: col, Op: op, Value: strings.TrimSpace(expr[opIndex+len(op):])}
+
+	if strings.ContainsAny(op, "<>") {
+		metadata, err := c.GetMetadata()
+		if err != nil {
+			return CSVFilter{}, err
+		}
+
+		if err := metadata.ColumnType(col).Validate(filter.Value); err != nil {
+			return CSVFilter{}, fmt.Errorf("invalid filter %q: %w", expr, err)
+		}
+	}
+
+	return filter, nil
+}
+
+// ColumnIndex returns the index of the column with the given header. Metadata headers are checked before
+// the header row, and exact matches before matches that ignore case.
+func (c *CSVDocument) ColumnIndex(name string) (int, error) {
+	records, err := c.GetRecords()
+	if err != nil {
+		return 0, err
+	}
+
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return 0, err
+	}
+
+	var header []string
+	if len(records) > 0 {
+		header = records[0]
+	}
+
+	for _, match := range []func(a, b string) bool{func(a, b string) bool { return a == b }, strings.EqualFold} {
+		for _, headers := range [][]string{metadata.Headers, header} {
+			for i, h := range headers {
+				if i < len(header) && match(h, name) {
+					return i, nil
+				}
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("column %q not found", name)
+}
+
+// DefaultQuery returns the query for the metadata's sort settings. A sort column that no longer exists is
+// ignored.
+func (c *CSVDocument) DefaultQuery() (CSVQuery, error) {
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return CSVQuery{}, err
+	}
+
+	if metadata.SortColumn == "" {
+		return CSVQuery{}, nil
+	}
+
+	col, err := c.ColumnIndex(metadata.SortColumn)
+	if err != nil {
+		return CSVQuery{}, nil
+	}
+
+	return CSVQuery{Sorts: []CSVSort{{Column: col, Desc: metadata.SortDesc}}}, nil
+}
+
+// Query returns the indexes of the records the query picks, in order, with the header row (0) first. Values
+// are compared by their column's type: numbers numerically, dates and times chronologically, and text
+// ignoring case. In either direction, values that don't match the column's type sort after the ones that do,
+// and blanks sort last.
+func (c *CSVDocument) Query(query CSVQuery) ([]int, error) {
+	records, err := c.GetRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []int{0}
+	for row := 1; row < len(records); row++ {
+		if metadata.matchesFilters(records[row], query.Filters) {
+			rows = append(rows, row)
+		}
+	}
+
+	slices.SortStableFunc(rows[1:], func(a, b int) int {
+		for _, sort := range query.Sorts {
+			cellType := metadata.ColumnType(sort.Column)
+			x, y := csvCell(records[a], sort.Column), csvCell(records[b], sort.Column)
+
+			// Blanks and then mistyped values go last, whichever way the column is sorted
+			if result := cmp.Compare(csvSortRank(cellType, x), csvSortRank(cellType, y)); result != 0 {
+				return result
+			}
+
+			if result := cellType.Compare(x, y); result != 0 {
+				if sort.Desc {
+					return -result
+				}
+				return result
+			}
+		}
+		return 0
+	})
+
+	return rows, nil
+}
+
+// SortedRecords returns the records sorted by the metadata's sort settings, with the header row first
+func (c *CSVDocument) SortedRecords() ([][]string, error) {
+	query, err := c.DefaultQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := c.GetRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([][]string, len(rows))
+	for i, row := range rows {
+		sorted[i] = records[row]
+	}
+
+	return sorted, nil
+}
+
+// Compare orders two values of the type, returning -1, 0, or 1. Values that can't be read as the type sort
+// after the ones that can, and are compared to each other as text.
+func (t CellType) Compare(a, b string) int {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+
+	var result int
+	var ok bool
+	switch t {
+	case CellTypeNum:
+		result, ok = compareParsed(a, b, parseCSVNumber, cmp.Compare[float64])
+	case CellTypeDate:
+		result, ok = compareParsed(a, b, func(v string) (time.Time, bool) { return parseCSVTime(v, csvDateLayouts) }, time.Time.Compare)
+	case CellTypeTime:
+		result, ok = compareParsed(a, b, func(v string) (time.Time, bool) { return parseCSVTime(v, csvTimeLayouts) }, time.Time.Compare)
+	case CellTypeBool:
+		result, ok = compareParsed(a, b, parseCSVBool, func(x, y bool) int {
+			switch {
+			case x == y:
+				return 0
+			case x:
+				return 1
+			default:
+				return -1
+			}
+		})
+	}
+
+	if ok {
+		return result
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// compareParsed compares two values after parsing them. A value that parses sorts before one that doesn't;
+// false is returned when neither parses.
+func compareParsed[T any](a, b string, parse func(string) (T, bool), compare func(x, y T) int) (int, bool) {
+	x, okX := parse(a)
+	y, okY := parse(b)
+
+	switch {
+	case okX && okY:
+		return compare(x, y), true
+	case okX:
+		return -1, true
+	case okY:
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// matchesFilters returns true if the record matches every filter
+func (m *CSVMetadata) matchesFilters(record []string, filters []CSVFilter) bool {
+	for _, filter := range filters {
+		value := csvCell(record, filter.Column)
+		cellType := m.ColumnType(filter.Column)
+
+		var matches bool
+		switch filter.Op {
+		case "~", "!~":
+			matches = strings.Contains(strings.ToLower(value), strings.ToLower(filter.Value)) == (filter.Op == "~")
+		case "=", "!=":
+			matches = (cellType.Compare(value, filter.Value) == 0) == (filter.Op == "=")
+		default:
+			// Ordering only applies to values of the column's type, so blanks and mistyped values never match
+			if strings.TrimSpace(value) == "" || (cellType != CellTypeText && cellType.Validate(value) != nil) {
+				return false
+			}
+
+			result := cellType.Compare(value, filter.Value)
+			switch filter.Op {
+			case "<":
+				matches = result < 0
+			case "<=":
+				matches = result <= 0
+			case ">":
+				matches = result > 0
+			case ">=":
+				matches = result >= 0
+			}
+		}
+
+		if !matches {
+			return false
+		}
+	}
+
+	return true
+}
+
+// csvSortRank groups values for sorting: values of the type (0), then values that aren't (1), then blanks (2)
+func csvSortRank(cellType CellType, value string) int {
+	switch {
+	case strings.TrimSpace(value) == "":
+		return 2
+	case cellType.Validate(value) != nil:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// csvCell returns the value of a column in a record, or an empty string for short records
+func csvCell(record []string, col int) string {
+	if col < len(record) {
+		return record[col]
+	}
+	return ""
+}
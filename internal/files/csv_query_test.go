@@ -0,0 +1,116 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func newQueryTestCSV(t *testing.T) *files.CSVDocument {
+	t.Helper()
+
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.WriteString("orders.csv", "Item,Qty,Due,Status\n"+
+		"Pens,10,2025-02-01,open\n"+
+		"Ink,9,01/15/2025,done\n"+
+		"Paper,,2025-01-20,open\n"+
+		"Clips,10,\"Jan 5, 2025\",open\n"+
+		"Tape,many,2025-03-01,done\n"))
+	fr.ReloadCaches()
+
+	csvDoc, err := fr.GetCSVDocument("orders.csv")
+	assert.Nil(t, err)
+	assert.Nil(t, csvDoc.SaveMetadata(&files.CSVMetadata{ColumnTypes: map[int]files.CellType{1: files.CellTypeNum, 2: files.CellTypeDate}}))
+
+	return csvDoc
+}
+
+func TestCSVDocument_QuerySort(t *testing.T) {
+	t.Parallel()
+	csvDoc := newQueryTestCSV(t)
+
+	query := func(sort string) []int {
+		t.Helper()
+
+		sorts, err := csvDoc.ParseSort(sort)
+		assert.Nil(t, err)
+		rows, err := csvDoc.Query(files.CSVQuery{Sorts: sorts})
+		assert.Nil(t, err)
+		return rows
+	}
+
+	// Numbers sort numerically, with mistyped values after numbers and blanks last in either direction
+	assert.Equal(t, query("qty"), []int{0, 2, 1, 4, 5, 3})
+	assert.Equal(t, query("-qty"), []int{0, 1, 4, 2, 5, 3})
+
+	// Dates sort chronologically whatever their format
+	assert.Equal(t, query("due"), []int{0, 4, 2, 3, 1, 5})
+
+	// Later columns break ties in earlier ones, and column names ignore case
+	assert.Equal(t, query("STATUS, -due"), []int{0, 5, 2, 1, 3, 4})
+
+	_, err := csvDoc.ParseSort("qty,color")
+	assert.NotNil(t, err)
+}
+
+func TestCSVDocument_QueryFilter(t *testing.T) {
+	t.Parallel()
+	csvDoc := newQueryTestCSV(t)
+
+	query := func(exprs ...string) []int {
+		t.Helper()
+
+		var q files.CSVQuery
+		for _, expr := range exprs {
+			filter, err := csvDoc.ParseFilter(expr)
+			assert.Nil(t, err)
+			q.Filters = append(q.Filters, filter)
+		}
+
+		rows, err := csvDoc.Query(q)
+		assert.Nil(t, err)
+		return rows
+	}
+
+	assert.Equal(t, query("status=DONE"), []int{0, 2, 5})
+	assert.Equal(t, query("item~p"), []int{0, 1, 3, 4, 5})
+	assert.Equal(t, query("item!~p"), []int{0, 2})
+	assert.Equal(t, query("qty=10.0"), []int{0, 1, 4})
+	assert.Equal(t, query("qty >= 10"), []int{0, 1, 4})
+	assert.Equal(t, query("due<Feb 1, 2025"), []int{0, 2, 3, 4})
+	assert.Equal(t, query("due<2025-01-20", "status=open"), []int{0, 4})
+	assert.Equal(t, query("status!=open"), []int{0, 2, 5})
+
+	for _, expr := range []string{"qty>lots", "color=red", "status", "=open"} {
+		_, err := csvDoc.ParseFilter(expr)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestCSVDocument_SortedRecords(t *testing.T) {
+	t.Parallel()
+	csvDoc := newQueryTestCSV(t)
+
+	metadata, err := csvDoc.GetMetadata()
+	assert.Nil(t, err)
+	metadata.SortColumn = "Due"
+	metadata.SortDesc = true
+	assert.Nil(t, csvDoc.SaveMetadata(metadata))
+
+	records, err := csvDoc.SortedRecords()
+	assert.Nil(t, err)
+	assert.Equal(t, records[0][0], "Item")
+	assert.Equal(t, records[1][0], "Tape")
+	assert.Equal(t, records[5][0], "Clips")
+
+	// A sort column that no longer exists leaves the records in file order
+	metadata.SortColumn = "Gone"
+	assert.Nil(t, csvDoc.SaveMetadata(metadata))
+	records, err = csvDoc.SortedRecords()
+	assert.Nil(t, err)
+	assert.Equal(t, records[1][0], "Pens")
+}
@@ -0,0 +1,166 @@
+package files
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportFormats lists the file extensions ParseSpreadsheet accepts
+var ImportFormats = []string{".csv", ".tsv", ".xlsx"}
+
+// ParseSpreadsheet reads the records of an uploaded spreadsheet, picking the format from the file name's
+// extension: .csv, .tsv, or .xlsx (the first worksheet). Rows are padded to the same length, so the records
+// can be saved as a CSV file.
+func ParseSpreadsheet(name string, data []byte) ([][]string, error) {
+	var records [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".tsv":
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+		if strings.EqualFold(filepath.Ext(name), ".tsv") {
+			reader.Comma = '\t'
+		}
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		if records, err = reader.ReadAll(); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(name), err)
+		}
+	case ".xlsx":
+		if records, err = readXLSX(data); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file type %q (expected %s)", filepath.Ext(name), strings.Join(ImportFormats, ", "))
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no rows", filepath.Base(name))
+	}
+
+	width := 0
+	for _, record := range records {
+		width = max(width, len(record))
+	}
+	for i := range records {
+		for len(records[i]) < width {
+			records[i] = append(records[i], "")
+		}
+	}
+
+	return records, nil
+}
+
+// EncodeCSV writes records as delimited text
+func EncodeCSV(records [][]string, delimiter rune) (string, error) {
+	var buf strings.Builder
+	writer := csv.NewWriter(&buf)
+	writer.Comma = delimiter
+
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write csv record: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", fmt.Errorf("failed to flush csv writer: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// WriteXLSX writes the document as an .xlsx workbook with a single worksheet. Columns typed as numbers in
+// the metadata are written as numeric cells, so spreadsheets can calculate with them.
+func (c *CSVDocument) WriteXLSX(w io.Writer) error {
+	records, err := c.GetRecords()
+	if err != nil {
+		return err
+	}
+
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return err
+	}
+
+	return writeXLSX(w, records, metadata.ColumnTypes)
+}
+
+// WriteJSON writes the document as a JSON array with an object per row, keyed by the header row in column
+// order. Repeated or empty headers get their column number appended, e.g., "name_3" or "column_2", so every
+// value keeps its own key.
+func (c *CSVDocument) WriteJSON(w io.Writer) error {
+	records, err := c.GetRecords()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	if len(records) > 0 {
+		keys := jsonKeys(records[0])
+		for i, record := range records[1:] {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n  {")
+			for j, key := range keys {
+				if j > 0 {
+					buf.WriteString(", ")
+				}
+
+				value := ""
+				if j < len(record) {
+					value = record[j]
+				}
+
+				writeJSONString(&buf, key)
+				buf.WriteString(": ")
+				writeJSONString(&buf, value)
+			}
+			buf.WriteString("}")
+		}
+		if len(records) > 1 {
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("]\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeJSONString writes s as a JSON string, leaving HTML characters unescaped since exports aren't
+// embedded in pages
+func writeJSONString(buf *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)       // Strings always encode
+	buf.Truncate(buf.Len() - 1) // Drop the encoder's trailing newline
+}
+
+// jsonKeys makes a unique object key for each header
+func jsonKeys(headers []string) []string {
+	keys := make([]string, len(headers))
+	seen := make(map[string]bool, len(headers))
+	for i, header := range headers {
+		key := strings.TrimSpace(header)
+		if key == "" {
+			key = "column"
+		}
+		if seen[key] || key == "column" {
+			key += "_" + strconv.Itoa(i+1)
+		}
+		seen[key] = true
+		keys[i] = key
+	}
+
+	return keys
+}
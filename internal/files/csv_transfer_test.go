@@ -0,0 +1,96 @@
+package files_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestParseSpreadsheet(t *testing.T) {
+	t.Parallel()
+
+	records, err := files.ParseSpreadsheet("people.tsv", []byte("name\tnotes\nZoe\tlikes, commas\nAl\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, records, [][]string{{"name", "notes"}, {"Zoe", "likes, commas"}, {"Al", ""}})
+
+	records, err = files.ParseSpreadsheet("People.CSV", []byte("\ufeffname,age\nZoe,30\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, records, [][]string{{"name", "age"}, {"Zoe", "30"}})
+
+	_, err = files.ParseSpreadsheet("people.numbers", []byte("name"))
+	assert.NotNil(t, err)
+
+	_, err = files.ParseSpreadsheet("empty.csv", nil)
+	assert.NotNil(t, err)
+
+	_, err = files.ParseSpreadsheet("broken.xlsx", []byte("not a zip"))
+	assert.ErrorIs(t, err, files.ErrInvalidXLSX)
+}
+
+func TestParseSpreadsheet_XLSX(t *testing.T) {
+	t.Parallel()
+
+	// A workbook as spreadsheet apps write it: shared and rich text strings, skipped cells, booleans, and a
+	// sheet that isn't named sheet1.xml
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Data" sheetId="1" r:id="rId3"/><sheet name="Other" sheetId="2" r:id="rId4"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId4" Target="worksheets/sheet1.xml"/><Relationship Id="rId3" Target="/xl/worksheets/data.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>name</t></si><si><t>done</t></si><si><r><t>Zoe </t></r><r><t>Smith</t></r></si></sst>`,
+		"xl/worksheets/data.xml": `<?xml version="1.0"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>42.5</v></c><c r="C2" t="b"><v>1</v></c></row>` +
+			`<row r="3"><c r="A3"/></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData/></worksheet>`,
+	} {
+		file, err := archive.Create(name)
+		assert.Nil(t, err)
+		_, err = file.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, archive.Close())
+
+	records, err := files.ParseSpreadsheet("data.xlsx", buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, records, [][]string{{"name", "", "done"}, {"Zoe Smith", "42.5", "TRUE"}})
+}
+
+func TestCSVDocument_Export(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.WriteString("people.csv", "name,age,name\nZoe <z@example.com>,30,Z\n\"Al, Jr.\",007,\n"))
+	fr.ReloadCaches()
+
+	csvDoc, err := fr.GetCSVDocument("people.csv")
+	assert.Nil(t, err)
+	assert.Nil(t, csvDoc.SaveMetadata(&files.CSVMetadata{ColumnTypes: map[int]files.CellType{1: files.CellTypeNum}}))
+
+	// XLSX exports read back as the same records
+	var xlsx bytes.Buffer
+	assert.Nil(t, csvDoc.WriteXLSX(&xlsx))
+	records, err := files.ParseSpreadsheet("people.xlsx", xlsx.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, records, [][]string{{"name", "age", "name"}, {"Zoe <z@example.com>", "30", "Z"}, {"Al, Jr.", "007", ""}})
+
+	// JSON exports have an object per row, keyed by unique headers
+	var out bytes.Buffer
+	assert.Nil(t, csvDoc.WriteJSON(&out))
+	var rows []map[string]string
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &rows))
+	assert.Equal(t, rows, []map[string]string{
+		{"name": "Zoe <z@example.com>", "age": "30", "name_3": "Z"},
+		{"name": "Al, Jr.", "age": "007", "name_3": ""},
+	})
+	assert.True(t, bytes.HasPrefix(out.Bytes(), []byte(`[`+"\n"+`  {"name": "Zoe <z@example.com>", "age": "30"`)))
+}
@@ -0,0 +1,176 @@
+package files
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvDateLayouts are the date formats accepted in date columns, with the ISO format first
+var csvDateLayouts = []string{"2006-01-02", "2006/01/02", "01/02/2006", "1/2/2006", "Jan 2, 2006", "January 2, 2006", "2 Jan 2006"}
+
+// csvTimeLayouts are the time formats accepted in time columns
+var csvTimeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04 pm", "3:04pm"}
+
+// CellError describes a cell whose value doesn't match its column's type. Row and Col use the same numbering
+// as GetCell, with row 0 for the header row.
+type CellError struct {
+	Row   int
+	Col   int
+	Type  CellType
+	Value string
+}
+
+func (e *CellError) Error() string {
+	return fmt.Sprintf("row %d, column %d: %q is not a valid %s", e.Row, e.Col+1, e.Value, e.Type.Description())
+}
+
+// ValidationError lists the cells of a record that don't match their column types
+type ValidationError struct {
+	Cells []*CellError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Cells))
+	for i, cell := range e.Cells {
+		messages[i] = cell.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Description returns what values of the type look like, for error messages and hints
+func (t CellType) Description() string {
+	switch t {
+	case CellTypeNum:
+		return "number"
+	case CellTypeDate:
+		return "date (e.g., 2025-01-31)"
+	case CellTypeTime:
+		return "time (e.g., 14:30 or 2:30 PM)"
+	case CellTypeBool:
+		return "yes/no value (e.g., true, false, yes, or no)"
+	default:
+		return "text value"
+	}
+}
+
+// Validate returns an error if the value can't be read as the type. Empty values are always valid, so cells
+// can be left blank.
+func (t CellType) Validate(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	var ok bool
+	switch t {
+	case CellTypeNum:
+		_, ok = parseCSVNumber(value)
+	case CellTypeDate:
+		_, ok = parseCSVTime(value, csvDateLayouts)
+	case CellTypeTime:
+		_, ok = parseCSVTime(value, csvTimeLayouts)
+	case CellTypeBool:
+		_, ok = parseCSVBool(value)
+	default:
+		ok = true
+	}
+
+	if !ok {
+		return fmt.Errorf("%q is not a valid %s", value, t.Description())
+	}
+
+	return nil
+}
+
+// Format returns the value as it's shown in the CSV view: dates like "Jan 31, 2025", times like "2:30 PM",
+// and yes/no values as "Yes" or "No". Numbers, text, and values that don't match the type are shown as is.
+func (t CellType) Format(value string) string {
+	trimmed := strings.TrimSpace(value)
+	switch t {
+	case CellTypeDate:
+		if date, ok := parseCSVTime(trimmed, csvDateLayouts); ok {
+			return date.Format("Jan 2, 2006")
+		}
+	case CellTypeTime:
+		if clock, ok := parseCSVTime(trimmed, csvTimeLayouts); ok {
+			return clock.Format("3:04 PM")
+		}
+	case CellTypeBool:
+		if b, ok := parseCSVBool(trimmed); ok {
+			return map[bool]string{true: "Yes", false: "No"}[b]
+		}
+	}
+
+	return value
+}
+
+// ColumnType returns the type of a column, defaulting to text
+func (m *CSVMetadata) ColumnType(col int) CellType {
+	if cellType, ok := m.ColumnTypes[col]; ok && cellType != "" {
+		return cellType
+	}
+	return CellTypeText
+}
+
+// ValidCell returns true if the value matches the type of its column
+func (m *CSVMetadata) ValidCell(col int, value string) bool {
+	return m.ColumnType(col).Validate(value) == nil
+}
+
+// FormatCell returns a cell's value formatted for its column type
+func (m *CSVMetadata) FormatCell(col int, value string) string {
+	return m.ColumnType(col).Format(value)
+}
+
+// validateRecord checks the values of a data row against the column types. The header row isn't typed, so
+// it's never checked.
+func (c *CSVDocument) validateRecord(row int, values []string) error {
+	if row == 0 {
+		return nil
+	}
+
+	metadata, err := c.GetMetadata()
+	if err != nil {
+		return err
+	}
+
+	var invalid []*CellError
+	for col, value := range values {
+		cellType := metadata.ColumnType(col)
+		if cellType.Validate(value) != nil {
+			invalid = append(invalid, &CellError{Row: row, Col: col, Type: cellType, Value: value})
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &ValidationError{Cells: invalid}
+	}
+
+	return nil
+}
+
+func parseCSVNumber(value string) (float64, bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	return n, err == nil
+}
+
+func parseCSVTime(value string, layouts []string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseCSVBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1":
+		return true, true
+	case "false", "no", "n", "0":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,98 @@
+package files_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestCellType_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		cellType files.CellType
+		value    string
+		valid    bool
+	}{
+		{files.CellTypeNum, "42", true},
+		{files.CellTypeNum, "-3.5e2", true},
+		{files.CellTypeNum, "forty", false},
+		{files.CellTypeDate, "2025-01-31", true},
+		{files.CellTypeDate, "01/31/2025", true},
+		{files.CellTypeDate, "Jan 31, 2025", true},
+		{files.CellTypeDate, "2025-02-30", false},
+		{files.CellTypeTime, "14:30", true},
+		{files.CellTypeTime, "2:30 PM", true},
+		{files.CellTypeTime, "25:00", false},
+		{files.CellTypeBool, "Yes", true},
+		{files.CellTypeBool, "false", true},
+		{files.CellTypeBool, "maybe", false},
+		{files.CellTypeText, "anything", true},
+		{files.CellTypeNum, "  ", true}, // Blank cells are always allowed
+	}
+
+	for _, tt := range tests {
+		err := tt.cellType.Validate(tt.value)
+		if tt.valid {
+			assert.Nil(t, err)
+		} else {
+			assert.NotNil(t, err)
+		}
+	}
+}
+
+func TestCellType_Format(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, files.CellTypeDate.Format("2025-01-31"), "Jan 31, 2025")
+	assert.Equal(t, files.CellTypeTime.Format("14:30"), "2:30 PM")
+	assert.Equal(t, files.CellTypeBool.Format("y"), "Yes")
+	assert.Equal(t, files.CellTypeNum.Format("1234.50"), "1234.50")
+	assert.Equal(t, files.CellTypeDate.Format("someday"), "someday")
+}
+
+func TestCSVDocument_TypedValidation(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.WriteString("orders.csv", "item,qty,due\nPens,3,2025-01-31\n"))
+	fr.ReloadCaches()
+
+	csvDoc, err := fr.GetCSVDocument("orders.csv")
+	assert.Nil(t, err)
+	assert.Nil(t, csvDoc.SaveMetadata(&files.CSVMetadata{ColumnTypes: map[int]files.CellType{1: files.CellTypeNum, 2: files.CellTypeDate}}))
+
+	// Cells are checked against their column's type
+	err = csvDoc.UpdateCell(1, 1, "lots")
+	var cellErr *files.CellError
+	assert.True(t, errors.As(err, &cellErr))
+	assert.Equal(t, *cellErr, files.CellError{Row: 1, Col: 1, Type: files.CellTypeNum, Value: "lots"})
+
+	assert.Nil(t, csvDoc.UpdateCell(1, 1, "4"))
+	assert.Nil(t, csvDoc.UpdateCell(1, 2, ""))
+
+	// The header row isn't typed
+	assert.Nil(t, csvDoc.UpdateCell(0, 1, "quantity"))
+
+	// Records list every invalid cell
+	err = csvDoc.AddRecord([]string{"Paper", "ten", "tomorrow"})
+	var validationErr *files.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, len(validationErr.Cells), 2)
+	assert.Equal(t, validationErr.Cells[0].Col, 1)
+	assert.Equal(t, validationErr.Cells[1].Col, 2)
+	assert.Equal(t, validationErr.Cells[1].Row, 2)
+
+	err = csvDoc.UpdateRecord(1, []string{"Pens", "4", "31/01/2025"})
+	assert.True(t, errors.As(err, &validationErr))
+
+	assert.Nil(t, csvDoc.AddRecord([]string{"Paper", "10", "2025-02-01"}))
+
+	content, err := csvDoc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "item,quantity,due\nPens,4,\nPaper,10,2025-02-01\n")
+}
@@ -1,6 +1,8 @@
 package files
 
 import (
+	"maps"
+	"slices"
 	"strings"
 )
 
@@ -52,3 +54,88 @@ func (dn *DirectoryNode) FindDirectory(path string) *DirectoryNode {
 
 	return currentNode
 }
+
+// withFileUpdate returns a copy of the tree in which update has been applied to the file with the given ID in
+// the directory at path. Only the directories on the path are copied, so the original tree is left untouched
+// for anyone still reading it. The tree is returned as is when the directory doesn't exist.
+func (dn *DirectoryNode) withFileUpdate(path, id string, update func(*FileInfo)) *DirectoryNode {
+	clone := &DirectoryNode{Name: dn.Name, Files: dn.Files, Directories: dn.Directories}
+
+	if path == "" {
+		clone.Files = slices.Clone(dn.Files)
+		for i := range clone.Files {
+			if clone.Files[i].ID == id {
+				update(&clone.Files[i])
+			}
+		}
+		return clone
+	}
+
+	name, rest, _ := strings.Cut(path, "/")
+	child, ok := dn.Directories[name]
+	if !ok {
+		return dn
+	}
+
+	clone.Directories = maps.Clone(dn.Directories)
+	clone.Directories[name] = child.withFileUpdate(rest, id, update)
+	return clone
+}
+
+// Flatten returns all files in the subtree. Files in a directory come before the files in its
+// subdirectories, and subdirectories are visited in name order.
+func (dn *DirectoryNode) Flatten() []FileInfo {
+	result := make([]FileInfo, 0, len(dn.Files))
+	result = append(result, dn.Files...)
+
+	for _, name := range dn.sortedDirectoryNames() {
+		result = append(result, dn.Directories[name].Flatten()...)
+	}
+
+	return result
+}
+
+// CountFiles returns the number of files in the subtree
+func (dn *DirectoryNode) CountFiles() int {
+	count := len(dn.Files)
+	for _, child := range dn.Directories {
+		count += child.CountFiles()
+	}
+
+	return count
+}
+
+// WithoutDrafts returns a copy of the subtree without draft files. Subdirectories left empty
+// by the filtering are dropped as well.
+func (dn *DirectoryNode) WithoutDrafts() *DirectoryNode {
+	filtered := &DirectoryNode{
+		Name:        dn.Name,
+		Files:       make([]FileInfo, 0, len(dn.Files)),
+		Directories: make(map[string]*DirectoryNode, len(dn.Directories)),
+	}
+
+	for _, file := range dn.Files {
+		if !file.IsDraft {
+			filtered.Files = append(filtered.Files, file)
+		}
+	}
+
+	for name, child := range dn.Directories {
+		if childFiltered := child.WithoutDrafts(); !childFiltered.IsEmpty() || child.IsEmpty() {
+			filtered.Directories[name] = childFiltered
+		}
+	}
+
+	return filtered
+}
+
+// sortedDirectoryNames returns the names of the subdirectories in sorted order
+func (dn *DirectoryNode) sortedDirectoryNames() []string {
+	names := make([]string, 0, len(dn.Directories))
+	for name := range dn.Directories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
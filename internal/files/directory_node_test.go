@@ -0,0 +1,41 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+)
+
+func TestDirectoryNode_Flatten(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, "")
+
+	dir := fr.DirectoryTreeFor(fr.Config().ResourcesDirectory)
+	flattened := dir.Flatten()
+
+	var paths []string
+	for _, file := range flattened {
+		paths = append(paths, file.Path)
+	}
+
+	assert.Equal(t, paths, []string{
+		"resources/looney.md",
+		"resources/characters/roadrunner.md",
+		"resources/characters/wile.md",
+		"resources/characters/minor/michigan.md",
+	})
+}
+
+func TestDirectoryNode_CountFiles(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, "")
+
+	dir := fr.DirectoryTreeFor(fr.Config().ResourcesDirectory)
+	assert.Equal(t, dir.CountFiles(), 4)
+	assert.Equal(t, dir.Directories["characters"].CountFiles(), 3)
+	assert.Equal(t, dir.Directories["characters"].Directories["minor"].CountFiles(), 1)
+
+	daily := fr.DirectoryTreeFor(fr.Config().DailyDirectory)
+	assert.Equal(t, daily.CountFiles(), 4)
+	assert.Equal(t, len(daily.Flatten()), daily.CountFiles())
+}
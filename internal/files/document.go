@@ -1,15 +1,49 @@
 package files
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/patrickward/padd/internal/contentutil"
 	"github.com/patrickward/padd/internal/crypto"
 )
 
+// ErrSaveConflict is returned when a document was changed on disk after the client loaded it
+var ErrSaveConflict = errors.New("document was modified since it was loaded")
+
+// ErrDocumentLocked is returned when saving a document whose frontmatter has "locked: true"
+var ErrDocumentLocked = errors.New("document is locked")
+
+// ErrWrongTemporalMonth is returned when a timestamped entry is added to a monthly temporal file for a
+// different month. FileRepository.AddTemporalEntry routes entries to the right file instead.
+var ErrWrongTemporalMonth = errors.New("entry date is outside the temporal file's month")
+
+// dayHeaderLayout is the time layout of the "## " day headers in temporal files
+const dayHeaderLayout = "Monday, January 2, 2006"
+
+// parseDayHeader parses a "## Monday, January 2, 2006" line into its date. Returns false for any other line,
+// including H2s that are not day headers.
+func parseDayHeader(line string) (time.Time, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "## ") {
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(dayHeaderLayout, strings.TrimSpace(line[3:]))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
+
 // EntryInsertionStrategy defines how to insert entries into a file
 type EntryInsertionStrategy int
 
@@ -61,28 +95,72 @@ func (d *Document) load() error {
 		return nil
 	}
 
+	content, err := d.readCached()
+	if err != nil {
+		return err
+	}
+
+	d.content = content
+	d.loaded = true
+	return nil
+}
+
+// readCached returns the document content from the repository's document cache when it is still
+// current, and otherwise reads it from disk and caches it
+func (d *Document) readCached() (string, error) {
+	stat, err := d.repo.rootManager.Stat(d.Info.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to load document %s: %w", d.Info.Path, err)
+	}
+
+	if content, ok := d.repo.documentCache.get(d.Info.Path, stat); ok {
+		return content, nil
+	}
+
+	// Requests for the same document that arrive together share one read
+	return d.repo.documentCache.loads.do(d.Info.Path, func() (string, error) {
+		content, err := d.readFromDisk()
+		if err != nil {
+			return "", err
+		}
+
+		d.repo.documentCache.set(d.Info.Path, stat, content)
+		return content, nil
+	})
+}
+
+// readFromDisk reads and, if needed, decrypts the document content without caching it
+func (d *Document) readFromDisk() (string, error) {
 	content, err := d.repo.rootManager.ReadFile(d.Info.Path)
 	if err != nil {
-		return fmt.Errorf("failed to load document %s: %w", d.Info.Path, err)
+		return "", fmt.Errorf("failed to load document %s: %w", d.Info.Path, err)
 	}
 
-	if d.repo.encryptionManager.IsActive() &&
-		d.repo.encryptionManager.HasIdentities() {
-		if crypto.IsAgeEncrypted(content) {
-			decrypted, err := d.repo.encryptionManager.Decrypt(content)
-			if err != nil {
-				return fmt.Errorf("failed to decrypt document %s: %w", d.Info.Path, err)
-			}
-			d.content = decrypted
-		} else {
-			d.content = string(content)
+	if d.repo.CanDecrypt() && crypto.IsAgeEncrypted(content) {
+		decrypted, err := d.repo.encryptionManager.Decrypt(content)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt document %s: %w", d.Info.Path, err)
 		}
-	} else {
-		d.content = string(content)
+		return decrypted, nil
 	}
 
-	d.loaded = true
-	return nil
+	return string(content), nil
+}
+
+// Checksum returns a SHA-256 hash of the document content. Clients pass it back to
+// SaveIfUnchanged to detect edits made by someone else in the meantime.
+func (d *Document) Checksum() (string, error) {
+	if err := d.load(); err != nil {
+		return "", err
+	}
+
+	return contentChecksum(d.content), nil
+}
+
+// contentChecksum returns the hex-encoded SHA-256 hash of the content
+func contentChecksum(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
 }
 
 // Content returns the content of the document
@@ -94,16 +172,106 @@ func (d *Document) Content() (string, error) {
 	return d.content, nil
 }
 
-// Save writes the document to disk
+// Save writes the document to disk. Unless normalization is disabled in the FileConfig, the content is
+// trimmed and ends with a single newline. Normalization happens before any encryption. Locked documents
+// are not written and return ErrDocumentLocked; use ForceSave or SetLocked to change them.
 func (d *Document) Save(content string) error {
-	// Remove space at the front of the content
-	content = strings.TrimSpace(content)
-	content += "\n"
+	unlock := d.repo.lockPath(d.Info.Path)
+	defer unlock()
+
+	return d.save(content)
+}
+
+// save writes the document unless it is locked. The caller must hold the document's path lock.
+func (d *Document) save(content string) error {
+	locked, err := d.IsLocked()
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		return fmt.Errorf("failed to save document %s: %w", d.Info.Path, ErrDocumentLocked)
+	}
 
-	if d.repo.encryptionManager.IsActive() &&
-		d.repo.encryptionManager.HasRecipients() &&
-		crypto.HasEncryptedFrontmatter(content) {
+	return d.writeContent(content)
+}
+
+// ForceSave writes the document to disk even if it is locked
+func (d *Document) ForceSave(content string) error {
+	return d.write(content)
+}
+
+// IsLocked returns true if the document's frontmatter has "locked: true". Documents that don't exist
+// yet are never locked.
+func (d *Document) IsLocked() (bool, error) {
+	if !d.loaded && !d.repo.rootManager.FileExists(d.Info.Path) {
+		return false, nil
+	}
+
+	if err := d.load(); err != nil {
+		return false, err
+	}
+
+	value, ok := contentutil.FrontmatterValue(contentutil.SplitLines(d.content), "locked")
+	return ok && strings.EqualFold(value, "true"), nil
+}
+
+// SetLocked sets or clears the "locked" frontmatter flag. Changing the lock is the one edit
+// allowed on a locked document.
+func (d *Document) SetLocked(locked bool) error {
+	if err := d.load(); err != nil {
+		return err
+	}
+
+	lines := contentutil.SplitLines(d.content)
+	bounds := contentutil.FindFrontmatter(lines)
+
+	var result []string
+	switch {
+	case bounds.Found:
+		// Drop any existing locked entry, then add it back after the opening delimiter if needed
+		result = append(result, lines[:bounds.Start+1]...)
+		if locked {
+			result = append(result, "locked: true")
+		}
+		for _, line := range lines[bounds.Start+1 : bounds.End-1] {
+			if name, _, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) == "locked" {
+				continue
+			}
+			result = append(result, line)
+		}
+		result = append(result, lines[bounds.End-1:]...)
+	case locked:
+		result = append([]string{"---", "locked: true", "---"}, lines...)
+	default:
+		return nil
+	}
+
+	return d.write(strings.Join(result, "\n"))
+}
+
+// write writes the content to disk, encrypting it if needed, and updates the loaded content. With AutoUpdatedAt,
+// the "updated_at" frontmatter is refreshed first.
+func (d *Document) write(content string) error {
+	unlock := d.repo.lockPath(d.Info.Path)
+	defer unlock()
+
+	return d.writeContent(content)
+}
 
+// writeContent does the work of write. The caller must hold the document's path lock.
+func (d *Document) writeContent(content string) error {
+	if d.repo.config.AutoUpdatedAt && d.repo.stampsTimestamps(d.Info.Path) {
+		content = withUpdatedAt(content, time.Now())
+	}
+
+	if d.repo.config.NormalizeOnSave {
+		content = strings.TrimSpace(content)
+		content += "\n"
+	}
+
+	encrypt := d.repo.CanEncrypt() && crypto.HasEncryptedFrontmatter(content)
+	if encrypt {
 		encrypted, err := d.repo.encryptionManager.Encrypt(content)
 		if err != nil {
 			return fmt.Errorf("failed to encrypt document %s: %w", d.Info.Path, err)
@@ -121,13 +289,66 @@ func (d *Document) Save(content string) error {
 	d.content = content
 	d.loaded = true
 	d.invalidateTaskCache()
+	d.repo.documentCache.invalidate(d.Info.Path)
+
+	// Encrypted files aren't scanned when the index is built, and are only searchable when they can be
+	// decrypted, so keep them the same way here
+	var scan markdownScan
+	id := d.repo.CreateID(d.Info.Path)
+	if !encrypt {
+		scan = scanContent(content)
+		d.repo.searchIndex.Update(id, content)
+	} else if d.repo.searchesEncrypted() {
+		d.repo.searchIndex.Update(id, content)
+	} else {
+		d.repo.searchIndex.Remove(id)
+	}
+	d.Info.applyScan(scan)
+	d.repo.updateScanResults(d.Info.Path, scan)
+
+	d.repo.notifyChange(ChangeEvent{Operation: ChangeSave, ID: id, Hash: contentChecksum(content)})
+	d.repo.recordVersion("Update "+id, d.Info.Path)
 
 	return nil
 }
 
+// SaveIfUnchanged writes the document to disk only if the content on disk still matches baseHash,
+// the checksum of the content the client started from. Otherwise, it returns ErrSaveConflict and the
+// document holds the content currently on disk, so callers can show what changed. The check and the write
+// happen under the document's path lock, so a concurrent save can't slip in between them.
+func (d *Document) SaveIfUnchanged(content, baseHash string) error {
+	unlock := d.repo.lockPath(d.Info.Path)
+	defer unlock()
+
+	current, err := d.readFromDisk()
+	if err != nil {
+		return err
+	}
+
+	if contentChecksum(current) != baseHash {
+		d.content = current
+		d.loaded = true
+		return fmt.Errorf("failed to save document %s: %w", d.Info.Path, ErrSaveConflict)
+	}
+
+	// Check the lock against what's on disk rather than what this document loaded earlier
+	d.content = current
+	d.loaded = true
+	return d.save(content)
+}
+
 // Delete deletes the document from disk
 func (d *Document) Delete() error {
-	return d.repo.rootManager.Remove(d.Info.Path)
+	d.repo.documentCache.invalidate(d.Info.Path)
+	if err := d.repo.rootManager.Remove(d.Info.Path); err != nil {
+		return err
+	}
+
+	id := d.repo.CreateID(d.Info.Path)
+	d.repo.searchIndex.Remove(id)
+	d.repo.notifyChange(ChangeEvent{Operation: ChangeDelete, ID: id})
+	d.repo.recordVersion("Delete "+id, d.Info.Path)
+	return nil
 }
 
 // AddEntry adds content to the document
@@ -136,12 +357,78 @@ func (d *Document) AddEntry(entry string, config EntryInsertionConfig) error {
 		return err
 	}
 
+	if err := d.checkTemporalMonth(config); err != nil {
+		return err
+	}
+
 	if d.content == "" {
 		d.content = entry
 		return nil
 	}
 
 	lines := contentutil.SplitLines(d.content)
+	result, err := d.insertEntry(lines, entry, config)
+	if err != nil {
+		return err
+	}
+
+	return d.Save(strings.Join(result, "\n"))
+}
+
+// AddEntries adds several entries to the document, producing the same content as calling AddEntry for each
+// entry in order. All insertions happen in memory and the document is saved once, which avoids re-reading
+// and re-saving a large file for every entry. All entries share a single timestamp.
+func (d *Document) AddEntries(entries []string, config EntryInsertionConfig) error {
+	if err := d.load(); err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Pin the timestamp so entries are grouped under the same day header
+	config.EntryTimestamp = config.Timestamp()
+
+	if err := d.checkTemporalMonth(config); err != nil {
+		return err
+	}
+
+	content := d.content
+	if content == "" {
+		content = entries[0]
+		entries = entries[1:]
+	}
+
+	lines := contentutil.SplitLines(content)
+	for _, entry := range entries {
+		var err error
+		lines, err = d.insertEntry(lines, entry, config)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Save(strings.Join(lines, "\n"))
+}
+
+// checkTemporalMonth makes sure a timestamp-ordered entry added to a monthly temporal file is dated in that
+// file's month, so day headers never end up in the wrong file
+func (d *Document) checkTemporalMonth(config EntryInsertionConfig) error {
+	if config.Strategy != InsertByTimestamp || !d.Info.IsTemporal {
+		return nil
+	}
+
+	if timestamp := config.Timestamp(); !d.Info.CoversDate(timestamp) {
+		return fmt.Errorf("failed to add entry for %s to %s: %w", timestamp.Format("2006-01-02"), d.Info.ID, ErrWrongTemporalMonth)
+	}
+
+	return nil
+}
+
+// insertEntry formats an entry and inserts it into the lines using the configured strategy. Multi-line
+// entries are split into separate lines, so later insertions can find the headers inside them.
+func (d *Document) insertEntry(lines []string, entry string, config EntryInsertionConfig) ([]string, error) {
 	formattedEntry := config.EntryFormatter(entry, config.Timestamp())
 
 	var result []string
@@ -155,10 +442,154 @@ func (d *Document) AddEntry(entry string, config EntryInsertionConfig) error {
 	case AppendToFile:
 		result = append(lines, formattedEntry)
 	default:
-		return fmt.Errorf("unsupported entry insertion strategy: %d", config.Strategy)
+		return nil, fmt.Errorf("unsupported entry insertion strategy: %d", config.Strategy)
 	}
 
-	return d.Save(strings.Join(result, "\n"))
+	if strings.ContainsAny(formattedEntry, "\r\n") {
+		result = contentutil.SplitLines(strings.Join(result, "\n"))
+	}
+
+	// Section and timestamp inserts even out the spacing of the section they went into, so repeated inserts
+	// stay even. The rest of the document keeps its layout, and verbatim saves are left alone entirely.
+	if d.repo == nil || d.repo.config.NormalizeOnSave {
+		switch config.Strategy {
+		case InsertInSection:
+			result = normalizeSectionSpacing(result, config.SectionConfig.SectionHeader)
+		case InsertByTimestamp:
+			result = normalizeSectionSpacing(result, dayHeaderLine(config.Timestamp()))
+		}
+	}
+
+	return result, nil
+}
+
+// normalizeSectionSpacing applies the blank line policy for inserted entries to the section that starts with
+// header (e.g., "## Inbox" or a day header), through the line before the next heading of the same or a higher
+// level: runs of blank lines collapse into one, every heading is separated from the content around it by
+// exactly one blank line, and one blank line separates the section from the content before and after it. The
+// rest of the document is returned as it is, and so are fenced and indented code blocks inside the section.
+func normalizeSectionSpacing(lines []string, header string) []string {
+	header = strings.TrimSpace(header)
+	matches := headingPattern.FindStringSubmatch(header)
+	if matches == nil {
+		return lines
+	}
+	level := len(matches[2])
+
+	bodyStart := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		bodyStart = bounds.End
+	}
+
+	sectionStart := -1
+	for i := bodyStart; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == header {
+			sectionStart = i
+			break
+		}
+	}
+	if sectionStart == -1 {
+		return lines
+	}
+
+	sectionEnd := len(lines)
+	fence := ""
+	for i := sectionStart + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(lines[i]); m != nil && len(m[2]) <= level {
+			sectionEnd = i
+			break
+		}
+	}
+
+	// Exactly one blank line between the section and the content before it
+	before := sectionStart
+	for before > bodyStart && strings.TrimSpace(lines[before-1]) == "" {
+		before--
+	}
+
+	result := make([]string, 0, len(lines)+4)
+	result = append(result, lines[:before]...)
+	if before > bodyStart {
+		result = append(result, "")
+	}
+
+	// separate adds a blank line unless the result already ends with one
+	separate := func() {
+		if len(result) > 0 && result[len(result)-1] != "" {
+			result = append(result, "")
+		}
+	}
+
+	fence = ""
+	afterHeading := false
+	blanks := 0
+	for _, line := range lines[sectionStart:sectionEnd] {
+		trimmed := strings.TrimSpace(line)
+
+		// Keep fenced code blocks verbatim, including their blank lines
+		if fence != "" {
+			result = append(result, line)
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		if trimmed == "" {
+			blanks++
+			continue
+		}
+
+		switch {
+		case blanks > 0 && isIndentedCode(line) && len(result) > 0 && isIndentedCode(result[len(result)-1]):
+			// Blank lines inside an indented code block are part of the code
+			for range blanks {
+				result = append(result, "")
+			}
+		case blanks > 0 || afterHeading:
+			separate()
+		}
+		blanks = 0
+
+		if headingPattern.MatchString(line) {
+			separate()
+			result = append(result, line)
+			afterHeading = true
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+		}
+		result = append(result, line)
+		afterHeading = false
+	}
+
+	// Exactly one blank line between the section and the content after it, or a single newline at the end
+	after := sectionEnd
+	for after < len(lines) && strings.TrimSpace(lines[after]) == "" {
+		after++
+	}
+	separate()
+	result = append(result, lines[after:]...)
+
+	return result
+}
+
+// isIndentedCode reports whether line is indented enough to be part of an indented code block
+func isIndentedCode(line string) bool {
+	return strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")
 }
 
 func (d *Document) insertInSection(lines []string, formattedEntry string, config SectionInsertionConfig) []string {
@@ -217,20 +648,36 @@ func (d *Document) insertInSection(lines []string, formattedEntry string, config
 		}
 		result = append(result, lines[insertPos:]...)
 	} else {
-		// Insert at bottom of section (before next ## header)
-		result = append(result, lines[:sectionEndIdx]...)
+		// Insert at bottom of section, after its last content line (before next ## header)
+		insertPos := sectionEndIdx
+		for insertPos > sectionStartIdx+1 && strings.TrimSpace(lines[insertPos-1]) == "" {
+			insertPos--
+		}
+
+		result = append(result, lines[:insertPos]...)
 		result = append(result, formattedEntry)
 		if config.BlankLineAfter {
 			result = append(result, "")
 		}
-		result = append(result, lines[sectionEndIdx:]...)
+		result = append(result, lines[insertPos:]...)
 	}
 
 	return result
 }
 
+// dayHeaderLine returns the ## header line of the day section for timestamp
+func dayHeaderLine(timestamp time.Time) string {
+	return "## " + timestamp.Format(dayHeaderLayout)
+}
+
 func (d *Document) insertByTimestamp(lines []string, formattedEntry string, timestamp time.Time) []string {
-	dayHeader := fmt.Sprintf("## %s", timestamp.Format("Monday, January 2, 2006"))
+	dayHeader := dayHeaderLine(timestamp)
+
+	// A new day section starts with the entry, followed by the directory's day template if it has one
+	newSection := []string{dayHeader, "", formattedEntry}
+	if d.repo != nil {
+		newSection = append(newSection, d.repo.daySectionTemplate(d.Info.Path, timestamp)...)
+	}
 
 	// Find the insertion point after any frontmatter and the main header
 	insertPos := 0
@@ -261,38 +708,31 @@ func (d *Document) insertByTimestamp(lines []string, formattedEntry string, time
 	for i := insertPos; i < len(lines); i++ {
 		line := strings.TrimSpace(lines[i])
 
-		// If we find our exact day header, just add it and return
+		// If we find our exact day header, add the entry after any blank lines below it and return
 		if line == dayHeader {
+			entryPos := i + 1
+			for entryPos < len(lines) && strings.TrimSpace(lines[entryPos]) == "" {
+				entryPos++
+			}
 			result := make([]string, 0, len(lines)+1)
-			result = append(result, lines[:i+1]...)
-			result = append(result, "")
+			result = append(result, lines[:entryPos]...)
 			result = append(result, formattedEntry)
-			result = append(result, lines[i+1:]...)
+			result = append(result, lines[entryPos:]...)
 			return result
 		}
 
 		// Check if this is a day header
-		if strings.HasPrefix(line, "## ") && len(line) > 3 {
-			headerDateStr := line[3:]
-			if headerDate, err := time.Parse("Monday, January 2, 2006", headerDateStr); err == nil {
-				dateHeaders = append(dateHeaders, struct {
-					index int
-					date  time.Time
-				}{i, headerDate})
-			}
+		if headerDate, ok := parseDayHeader(line); ok {
+			dateHeaders = append(dateHeaders, struct {
+				index int
+				date  time.Time
+			}{i, headerDate})
 		}
 	}
 
 	// If no existing date headers found, insert at the top
 	if len(dateHeaders) == 0 {
-		result := make([]string, 0, len(lines)+3)
-		result = append(result, lines[:insertPos]...)
-		result = append(result, dayHeader)
-		result = append(result, "")
-		result = append(result, formattedEntry)
-		result = append(result, "")
-		result = append(result, lines[insertPos:]...)
-		return result
+		return spliceSection(lines, insertPos, newSection)
 	}
 
 	// Find the correct position for the new entry
@@ -305,24 +745,30 @@ func (d *Document) insertByTimestamp(lines []string, formattedEntry string, time
 		}
 	}
 
-	result := make([]string, 0, len(lines)+2)
-
 	if insertIdx == -1 {
-		// Insertion date is newer than all existing dates - add to top
-		result = append(result, lines...)
-		result = append(result, "")
-		result = append(result, dayHeader)
-		result = append(result, "")
-		result = append(result, formattedEntry)
-	} else {
-		// Insert at the specified position
-		result = append(result, lines[:insertIdx]...)
-		result = append(result, dayHeader)
+		// Insertion date is older than all existing dates - add to bottom, ahead of any trailing blank lines
+		insertIdx = len(lines)
+		for insertIdx > insertPos && strings.TrimSpace(lines[insertIdx-1]) == "" {
+			insertIdx--
+		}
+	}
+
+	return spliceSection(lines, insertIdx, newSection)
+}
+
+// spliceSection inserts a new section into lines at pos, with a blank line on either side unless the
+// neighbouring line is already blank, so the section is spaced out even when the document isn't normalized
+func spliceSection(lines []string, pos int, section []string) []string {
+	result := make([]string, 0, len(lines)+len(section)+2)
+	result = append(result, lines[:pos]...)
+	if pos > 0 && strings.TrimSpace(lines[pos-1]) != "" {
 		result = append(result, "")
-		result = append(result, formattedEntry)
+	}
+	result = append(result, section...)
+	if pos < len(lines) && strings.TrimSpace(lines[pos]) != "" {
 		result = append(result, "")
-		result = append(result, lines[insertIdx:]...)
 	}
+	result = append(result, lines[pos:]...)
 
 	return result
 }
@@ -365,6 +811,256 @@ func (d *Document) createNewSection(lines []string, formattedEntry string, confi
 	return result
 }
 
+// RemoveSection removes a ## section, from its header line through the line before the next ## header
+// (or the end of the document). The header may be given with or without the "## " prefix. Frontmatter
+// and the main # header are never removed. It returns false if the section was not found.
+func (d *Document) RemoveSection(header string) (bool, error) {
+	if err := d.load(); err != nil {
+		return false, err
+	}
+
+	lines := contentutil.SplitLines(d.content)
+
+	sectionStartIdx, sectionEndIdx := findSection(lines, header)
+	if sectionStartIdx == -1 {
+		return false, nil
+	}
+
+	result := make([]string, 0, len(lines)-(sectionEndIdx-sectionStartIdx))
+	result = append(result, lines[:sectionStartIdx]...)
+	result = append(result, lines[sectionEndIdx:]...)
+
+	if err := d.Save(strings.Join(result, "\n")); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// findSection returns the line of the ## header matching header, given with or without the "## " prefix, and
+// the line that ends its section (the next ## header or the end of the lines). Sections inside the frontmatter
+// are never matched, and ## lines inside fenced code blocks are neither headers nor section ends. The start is
+// -1 if the section was not found.
+func findSection(lines []string, header string) (int, int) {
+	targetHeader := strings.TrimSpace(header)
+	if !strings.HasPrefix(targetHeader, "## ") {
+		targetHeader = "## " + targetHeader
+	}
+
+	// Never look for sections inside the frontmatter (End is the first line after it)
+	searchStart := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		searchStart = bounds.End
+	}
+
+	start := -1
+	fence := ""
+	for i := searchStart; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		// Track fenced code blocks, which close with the same marker that opened them
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			continue
+		} else if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		switch {
+		case start == -1 && trimmed == targetHeader:
+			start = i
+		case start != -1 && strings.HasPrefix(trimmed, "## "):
+			return start, i
+		}
+	}
+
+	return start, len(lines)
+}
+
+// InsertAfterMatch inserts entry on the line after the first line matching pattern, such as a
+// "<!-- INSERT HERE -->" anchor. The pattern is a plain substring unless isRegex is set. Frontmatter is never
+// matched; use InsertAfterMatchIncludingFrontmatter for that. It returns false if no line matched.
+func (d *Document) InsertAfterMatch(pattern string, isRegex bool, entry string) (bool, error) {
+	return d.insertAfterMatch(pattern, isRegex, entry, false)
+}
+
+// InsertAfterMatchIncludingFrontmatter works like InsertAfterMatch but also matches lines inside the frontmatter
+func (d *Document) InsertAfterMatchIncludingFrontmatter(pattern string, isRegex bool, entry string) (bool, error) {
+	return d.insertAfterMatch(pattern, isRegex, entry, true)
+}
+
+func (d *Document) insertAfterMatch(pattern string, isRegex bool, entry string, includeFrontmatter bool) (bool, error) {
+	if pattern == "" {
+		return false, fmt.Errorf("match pattern cannot be empty")
+	}
+
+	matches := func(line string) bool { return strings.Contains(line, pattern) }
+	if isRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid match pattern: %w", err)
+		}
+		matches = re.MatchString
+	}
+
+	if err := d.load(); err != nil {
+		return false, err
+	}
+
+	lines := contentutil.SplitLines(d.content)
+
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found && !includeFrontmatter {
+		start = bounds.End
+	}
+
+	for i := start; i < len(lines); i++ {
+		if !matches(lines[i]) {
+			continue
+		}
+
+		entryLines := contentutil.SplitLines(entry)
+		result := make([]string, 0, len(lines)+len(entryLines))
+		result = append(result, lines[:i+1]...)
+		result = append(result, entryLines...)
+		result = append(result, lines[i+1:]...)
+
+		if err := d.Save(strings.Join(result, "\n")); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// headingPattern matches an ATX markdown heading, capturing the indent, the # markers, and the rest of the line
+var headingPattern = regexp.MustCompile(`^( {0,3})(#{1,6})(\s.*)?$`)
+
+// ShiftHeadings rewrites every heading in the document by delta levels (e.g., 1 turns ## into ###, -1 turns
+// ## into #), clamping between H1 and H6. Frontmatter and fenced code blocks are left untouched.
+func (d *Document) ShiftHeadings(delta int) error {
+	if err := d.load(); err != nil {
+		return err
+	}
+
+	if delta == 0 {
+		return nil
+	}
+
+	lines := contentutil.SplitLines(d.content)
+
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		start = bounds.End
+	}
+
+	fence := ""
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+
+		// Track fenced code blocks, which close with the same marker that opened them
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			continue
+		} else if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		matches := headingPattern.FindStringSubmatch(lines[i])
+		if matches == nil {
+			continue
+		}
+
+		level := min(max(len(matches[2])+delta, 1), 6)
+		lines[i] = matches[1] + strings.Repeat("#", level) + matches[3]
+	}
+
+	return d.Save(strings.Join(lines, "\n"))
+}
+
+// thematicBreakPattern matches a markdown thematic break such as "---", "***", or "_ _ _"
+var thematicBreakPattern = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+
+// Excerpt returns the first paragraph of prose in the document, skipping frontmatter, headings, blank lines,
+// thematic breaks, HTML comments, and fenced code blocks. Lines of the paragraph are joined with spaces.
+// If the paragraph is longer than maxChars, it is cut at a word boundary and ends with an ellipsis.
+// A maxChars of zero or less returns the whole paragraph.
+func (d *Document) Excerpt(maxChars int) (string, error) {
+	if err := d.load(); err != nil {
+		return "", err
+	}
+
+	lines := contentutil.SplitLines(d.content)
+
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		start = bounds.End
+	}
+
+	return excerpt(lines[start:], maxChars), nil
+}
+
+// excerpt returns the first paragraph of prose in lines, as described for Document.Excerpt
+func excerpt(lines []string, maxChars int) string {
+	var paragraph []string
+	fence := ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+		} else if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		skip := fence != "" ||
+			trimmed == "" ||
+			headingPattern.MatchString(line) ||
+			thematicBreakPattern.MatchString(line) ||
+			(strings.HasPrefix(trimmed, "<!--") && strings.HasSuffix(trimmed, "-->"))
+
+		if skip {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+
+		paragraph = append(paragraph, trimmed)
+	}
+
+	return truncateWords(strings.Join(paragraph, " "), maxChars)
+}
+
+// truncateWords shortens text to at most maxChars characters, cutting at the last word boundary and
+// adding an ellipsis. A maxChars of zero or less leaves the text unchanged.
+func truncateWords(text string, maxChars int) string {
+	runes := []rune(text)
+	if maxChars <= 0 || len(runes) <= maxChars {
+		return text
+	}
+
+	cut := string(runes[:maxChars])
+	if idx := strings.LastIndexFunc(cut, unicode.IsSpace); idx > 0 && !unicode.IsSpace(runes[maxChars]) {
+		cut = cut[:idx]
+	}
+
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
 // Entry formatters
 
 func NoteEntryFormatter(entry string, _ time.Time) string {
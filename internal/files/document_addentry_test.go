@@ -1,6 +1,7 @@
 package files_test
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -270,7 +271,7 @@ func TestDocument_AddEntry_InsertByTimestamp_EmptyFile(t *testing.T) {
 	// Explicitly reload caches to ensure we've initialized
 	fr.ReloadCaches()
 
-	doc, err := fr.GetOrCreateResourceDocument("empty-doc")
+	doc, err := fr.GetOrCreateResourceDocument("empty-doc", "", nil)
 	assert.Nil(t, err)
 
 	// Add entry to empty file
@@ -290,7 +291,7 @@ func TestDocument_AddEntry_InsertByTimestamp_EmptyFile(t *testing.T) {
 	// Assert equal, but ignore all whitespace
 	content = strings.ReplaceAll(content, "\n", "")
 	content = strings.ReplaceAll(content, " ", "")
-	assert.Equal(t, content, "#empty-doc.md##Monday,September15,2025###10:00:00AMFirstentry")
+	assert.MatchesRegexp(t, content, `^---created_at:[0-9:-]+---#empty-doc.md##Monday,September15,2025###10:00:00AMFirstentry$`)
 }
 
 func TestDocument_AddEntry_InsertByTimestamp_NoMainHeader(t *testing.T) {
@@ -352,7 +353,7 @@ func TestDocument_AddEntry_PrependToFile(t *testing.T) {
 	// Explicitly reload caches to ensure we've initialized
 	fr.ReloadCaches()
 
-	doc, err := fr.GetOrCreateResourceDocument("test-doc")
+	doc, err := fr.GetOrCreateResourceDocument("test-doc", "", nil)
 	assert.Nil(t, err)
 
 	initialContent := `# Test Document
@@ -388,7 +389,7 @@ func TestDocument_AddEntry_AppendToFile(t *testing.T) {
 	// Explicitly reload caches to ensure we've initialized
 	fr.ReloadCaches()
 
-	doc, err := fr.GetOrCreateResourceDocument("test-doc")
+	doc, err := fr.GetOrCreateResourceDocument("test-doc", "", nil)
 	assert.Nil(t, err)
 
 	initialContent := `# Test Document
@@ -424,7 +425,7 @@ func TestDocument_AddEntry_InsertInSection_ExistingSection(t *testing.T) {
 	// Explicitly reload caches to ensure we've initialized
 	fr.ReloadCaches()
 
-	doc, err := fr.GetOrCreateResourceDocument("test-doc")
+	doc, err := fr.GetOrCreateResourceDocument("test-doc", "", nil)
 	assert.Nil(t, err)
 
 	initialContent := `# Test Document
@@ -484,7 +485,7 @@ func TestDocument_AddEntry_InsertInSection_NewSection(t *testing.T) {
 	// Explicitly reload caches to ensure we've initialized
 	fr.ReloadCaches()
 
-	doc, err := fr.GetOrCreateResourceDocument("test-doc")
+	doc, err := fr.GetOrCreateResourceDocument("test-doc", "", nil)
 	assert.Nil(t, err)
 
 	initialContent := `# Test Document
@@ -532,3 +533,308 @@ Some existing content`
 	}
 	assert.True(t, found)
 }
+
+func TestDocument_AddEntries_MatchesSequentialAddEntry(t *testing.T) {
+	t.Parallel()
+
+	entryTime := time.Date(2025, 9, 16, 14, 30, 0, 0, time.UTC)
+	configs := map[string]files.EntryInsertionConfig{
+		"timestamp": {
+			Strategy:       files.InsertByTimestamp,
+			EntryTimestamp: entryTime,
+			EntryFormatter: files.TimestampEntryFormatter,
+		},
+		"section": {
+			Strategy:       files.InsertInSection,
+			EntryTimestamp: entryTime,
+			EntryFormatter: files.TaskEntryFormatter,
+			SectionConfig:  &files.SectionInsertionConfig{SectionHeader: "## Inbox", InsertAtTop: true},
+		},
+		"append": {
+			Strategy:       files.AppendToFile,
+			EntryTimestamp: entryTime,
+			EntryFormatter: files.NoteEntryFormatter,
+		},
+	}
+
+	entries := make([]string, 100)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("Entry %d", i+1)
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			initialContent := "# Daily September 2025\n\n## Monday, September 15, 2025\n\nOlder entry"
+
+			sequential := setupTaskDocument(t, initialContent)
+			start := time.Now()
+			for _, entry := range entries {
+				assert.Nil(t, sequential.AddEntry(entry, config))
+			}
+			sequentialDuration := time.Since(start)
+
+			batch := setupTaskDocument(t, initialContent)
+			start = time.Now()
+			assert.Nil(t, batch.AddEntries(entries, config))
+			batchDuration := time.Since(start)
+
+			t.Logf("sequential: %v, batch: %v", sequentialDuration, batchDuration)
+
+			want, err := sequential.Content()
+			assert.Nil(t, err)
+			got, err := batch.Content()
+			assert.Nil(t, err)
+			assert.Equal(t, got, want)
+			assert.True(t, strings.Contains(got, "Entry 100"))
+		})
+	}
+}
+
+func TestDocument_AddEntry_InsertByTimestamp_StableSpacing(t *testing.T) {
+	t.Parallel()
+
+	doc := setupTaskDocument(t, "# Daily September 2025\n\n\n## Monday, September 15, 2025\n### 09:00:00 AM\nOld entry\n\n\n")
+
+	config := func(timestamp time.Time) files.EntryInsertionConfig {
+		return files.EntryInsertionConfig{
+			Strategy:       files.InsertByTimestamp,
+			EntryTimestamp: timestamp,
+			EntryFormatter: files.TimestampEntryFormatter,
+		}
+	}
+
+	// Same day, a newer day, an older day, then the same days again
+	timestamps := []time.Time{
+		time.Date(2025, 9, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 9, 16, 8, 0, 0, 0, time.UTC),
+		time.Date(2025, 9, 14, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 9, 16, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 9, 15, 11, 0, 0, 0, time.UTC),
+	}
+	for i, timestamp := range timestamps {
+		assert.Nil(t, doc.AddEntry(fmt.Sprintf("Entry %d", i+1), config(timestamp)))
+	}
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, `# Daily September 2025
+
+## Tuesday, September 16, 2025
+
+### 09:00:00 AM
+
+Entry 4
+
+### 08:00:00 AM
+
+Entry 2
+
+## Monday, September 15, 2025
+
+### 11:00:00 AM
+
+Entry 5
+
+### 10:00:00 AM
+
+Entry 1
+
+### 09:00:00 AM
+
+Old entry
+
+## Sunday, September 14, 2025
+
+### 06:00:00 PM
+
+Entry 3
+`)
+
+	// Another insert only adds the entry and its separating blank lines
+	assert.Nil(t, doc.AddEntry("Entry 6", config(time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC))))
+	updated, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Count(updated, "\n"), strings.Count(content, "\n")+4)
+	assert.False(t, strings.Contains(updated, "\n\n\n"))
+}
+
+func TestDocument_AddEntry_InsertInSection_StableSpacing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		insertAtTop bool
+		want        string
+	}{
+		{
+			name:        "top",
+			insertAtTop: true,
+			want:        "# Tasks\n\n## Inbox\n\n- [ ] Third\n- [ ] Second\n- [ ] First\n- [ ] Existing\n\n## Notes\n\nA note\n",
+		},
+		{
+			name:        "bottom",
+			insertAtTop: false,
+			want:        "# Tasks\n\n## Inbox\n\n- [ ] Existing\n- [ ] First\n- [ ] Second\n- [ ] Third\n\n## Notes\n\nA note\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := setupTaskDocument(t, "# Tasks\n\n## Inbox\n- [ ] Existing\n\n\n\n## Notes\n\nA note")
+			config := files.EntryInsertionConfig{
+				Strategy:       files.InsertInSection,
+				EntryFormatter: files.TaskEntryFormatter,
+				SectionConfig:  &files.SectionInsertionConfig{SectionHeader: "## Inbox", InsertAtTop: tt.insertAtTop},
+			}
+
+			for _, entry := range []string{"First", "Second", "Third"} {
+				assert.Nil(t, doc.AddEntry(entry, config))
+			}
+
+			content, err := doc.Content()
+			assert.Nil(t, err)
+			assert.Equal(t, content, tt.want)
+		})
+	}
+}
+
+func TestFileRepository_AddTemporalEntry_AdjacentMonth(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	config := files.EntryInsertionConfig{EntryFormatter: files.TimestampEntryFormatter}
+
+	config.EntryTimestamp = time.Date(2025, 9, 30, 23, 30, 0, 0, time.UTC)
+	september, err := fr.AddTemporalEntry("daily", "Last entry of September", config)
+	assert.Nil(t, err)
+	assert.Equal(t, september.Info.Path, "daily/2025/09-september.md")
+
+	config.EntryTimestamp = time.Date(2025, 10, 1, 0, 15, 0, 0, time.UTC)
+	october, err := fr.AddTemporalEntry("daily", "First entry of October", config)
+	assert.Nil(t, err)
+	assert.Equal(t, october.Info.Path, "daily/2025/10-october.md")
+
+	septemberContent, err := rm.ReadFile("daily/2025/09-september.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(septemberContent), "## Tuesday, September 30, 2025"))
+	assert.False(t, strings.Contains(string(septemberContent), "October"))
+
+	octoberContent, err := rm.ReadFile("daily/2025/10-october.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(octoberContent), "## Wednesday, October 1, 2025"))
+	assert.True(t, strings.Contains(string(octoberContent), "First entry of October"))
+}
+
+func TestDocument_AddEntry_WrongTemporalMonth(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	doc, err := fr.GetOrCreateTemporalDocument("daily", time.Date(2025, 9, 15, 10, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	config := files.EntryInsertionConfig{
+		Strategy:       files.InsertByTimestamp,
+		EntryTimestamp: time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC),
+		EntryFormatter: files.TimestampEntryFormatter,
+	}
+
+	assert.ErrorIs(t, doc.AddEntry("Misplaced", config), files.ErrWrongTemporalMonth)
+	assert.ErrorIs(t, doc.AddEntries([]string{"Misplaced"}, config), files.ErrWrongTemporalMonth)
+
+	// Other strategies don't depend on the date
+	config.Strategy = files.AppendToFile
+	assert.Nil(t, doc.AddEntry("Appended", config))
+}
+
+func TestDocument_AddEntry_InsertInSection_KeepsOtherSections(t *testing.T) {
+	t.Parallel()
+
+	doc := setupTaskDocument(t, "# Tasks\n\n\n## Inbox\n- [ ] Existing\n\n\n## Code\nExample:\n\n    line one\n\n\n    line two\n\n\n\nAfter")
+	config := files.EntryInsertionConfig{
+		Strategy:       files.InsertInSection,
+		EntryFormatter: files.TaskEntryFormatter,
+		SectionConfig:  &files.SectionInsertionConfig{SectionHeader: "## Inbox"},
+	}
+	assert.Nil(t, doc.AddEntry("New", config))
+
+	// Only the Inbox section and its edges are re-spaced; the Code section keeps its blank lines
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Tasks\n\n## Inbox\n\n- [ ] Existing\n- [ ] New\n\n## Code\nExample:\n\n    line one\n\n\n    line two\n\n\n\nAfter\n")
+
+	// An indented code block inside the target section keeps its blank lines too
+	config.SectionConfig.SectionHeader = "## Code"
+	assert.Nil(t, doc.AddEntry("Last", config))
+	content, err = doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Tasks\n\n## Inbox\n\n- [ ] Existing\n- [ ] New\n\n## Code\n\nExample:\n\n    line one\n\n\n    line two\n\nAfter\n- [ ] Last\n")
+}
+
+func TestDocument_AddEntry_VerbatimSkipsSpacing(t *testing.T) {
+	t.Parallel()
+
+	rm, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+	config := files.DefaultFileConfig
+	config.NormalizeOnSave = false
+	fr := files.NewFileRepository(rm, config)
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Inbox\n\n\n## Today\n- [ ] Existing\n\n\n"))
+
+	assert.Nil(t, doc.AddEntry("New", files.EntryInsertionConfig{
+		Strategy:       files.InsertInSection,
+		EntryFormatter: files.TaskEntryFormatter,
+		SectionConfig:  &files.SectionInsertionConfig{SectionHeader: "## Today"},
+	}))
+
+	raw, err := rm.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Inbox\n\n\n## Today\n- [ ] Existing\n- [ ] New\n\n\n")
+}
+
+func TestDocument_AddEntry_VerbatimDaySectionSpacing(t *testing.T) {
+	t.Parallel()
+
+	rm, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+	config := files.DefaultFileConfig
+	config.NormalizeOnSave = false
+	fr := files.NewFileRepository(rm, config)
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Inbox\n\n## Tuesday, March 10, 2026\n- [ ] Middle\n"))
+
+	add := func(entry string, timestamp time.Time) {
+		assert.Nil(t, doc.AddEntry(entry, files.EntryInsertionConfig{
+			Strategy:       files.InsertByTimestamp,
+			EntryTimestamp: timestamp,
+			EntryFormatter: files.TaskEntryFormatter,
+		}))
+	}
+
+	// New day sections are padded with blank lines even though the document isn't normalized
+	add("Newest", time.Date(2026, 3, 12, 9, 0, 0, 0, time.Local))
+	add("Oldest", time.Date(2026, 3, 8, 9, 0, 0, 0, time.Local))
+	add("Also newest", time.Date(2026, 3, 12, 10, 0, 0, 0, time.Local))
+
+	raw, err := rm.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Inbox\n\n"+
+		"## Thursday, March 12, 2026\n\n- [ ] Also newest\n- [ ] Newest\n\n"+
+		"## Tuesday, March 10, 2026\n- [ ] Middle\n\n"+
+		"## Sunday, March 8, 2026\n\n- [ ] Oldest\n")
+}
@@ -0,0 +1,224 @@
+package files
+
+import (
+	"container/list"
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// defaultDocumentCacheSize is how many documents the document cache holds when FileConfig doesn't say
+const defaultDocumentCacheSize = 1000
+
+// documentCache holds loaded (and decrypted) document content keyed by path. Entries are checked against
+// the file's modification time and size, so changes made outside the application are picked up on the next read.
+// It holds at most limit documents, dropping the least recently used one to make room for another.
+type documentCache struct {
+	mu      sync.Mutex
+	limit   int
+	entries map[string]*list.Element // Elements hold a *cachedDocument, most recently used first
+	order   *list.List
+	loads   loadGroup
+}
+
+type cachedDocument struct {
+	path    string
+	content string
+	modTime time.Time
+	size    int64
+}
+
+// newDocumentCache creates a document cache that holds at most limit documents, or the default number when
+// limit is 0 or less
+func newDocumentCache(limit int) *documentCache {
+	if limit <= 0 {
+		limit = defaultDocumentCacheSize
+	}
+
+	return &documentCache{
+		limit:   limit,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the cached content for a path if it is still current for the given file info
+func (dc *documentCache) get(path string, info os.FileInfo) (string, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	element, ok := dc.entries[path]
+	if !ok {
+		return "", false
+	}
+
+	entry := element.Value.(*cachedDocument)
+	if !entry.modTime.Equal(info.ModTime()) || entry.size != info.Size() {
+		return "", false
+	}
+
+	dc.order.MoveToFront(element)
+	return entry.content, true
+}
+
+func (dc *documentCache) set(path string, info os.FileInfo, content string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	entry := &cachedDocument{path: path, content: content, modTime: info.ModTime(), size: info.Size()}
+	if element, ok := dc.entries[path]; ok {
+		element.Value = entry
+		dc.order.MoveToFront(element)
+		return
+	}
+
+	dc.entries[path] = dc.order.PushFront(entry)
+	for dc.order.Len() > dc.limit {
+		oldest := dc.order.Back()
+		dc.order.Remove(oldest)
+		delete(dc.entries, oldest.Value.(*cachedDocument).path)
+	}
+}
+
+func (dc *documentCache) invalidate(path string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if element, ok := dc.entries[path]; ok {
+		dc.order.Remove(element)
+		delete(dc.entries, path)
+	}
+}
+
+// clear drops every cached document
+func (dc *documentCache) clear() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	dc.entries = make(map[string]*list.Element)
+	dc.order.Init()
+}
+
+func (dc *documentCache) contains(path string) bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	_, ok := dc.entries[path]
+	return ok
+}
+
+// loadGroup coalesces concurrent loads of the same path, so a document that several requests (or the cache
+// warmer) ask for at once is read and decrypted only once. It works like golang.org/x/sync/singleflight.
+type loadGroup struct {
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+// loadCall is a load in progress, whose result is shared with every caller waiting on it
+type loadCall struct {
+	wg      sync.WaitGroup
+	content string
+	err     error
+}
+
+// do calls load for path, unless a load of the same path is already in progress, in which case it waits for
+// that load and returns its result
+func (g *loadGroup) do(path string, load func() (string, error)) (string, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[path]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.content, call.err
+	}
+
+	if g.calls == nil {
+		g.calls = make(map[string]*loadCall)
+	}
+	call := &loadCall{}
+	call.wg.Add(1)
+	g.calls[path] = call
+	g.mu.Unlock()
+
+	call.content, call.err = load()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, path)
+	g.mu.Unlock()
+
+	return call.content, call.err
+}
+
+// IsDocumentCached returns true if the content of the document with the given ID is in the document cache
+func (fr *FileRepository) IsDocumentCached(id string) bool {
+	info, err := fr.FileInfo(id)
+	if err != nil {
+		return false
+	}
+
+	return fr.documentCache.contains(info.Path)
+}
+
+// WarmDocumentCache loads the indexed documents into the document cache, so the first view of each file
+// doesn't pay the read and decryption cost. No more documents are loaded than the cache holds. At most
+// concurrency documents are loaded at once, and warming stops early when the context is cancelled. It returns
+// the number of documents loaded.
+func (fr *FileRepository) WarmDocumentCache(ctx context.Context, concurrency int) int {
+	concurrency = max(concurrency, 1)
+
+	fr.cacheMux.RLock()
+	ids := make([]string, 0, len(fr.fileIndex))
+	for id := range fr.fileIndex {
+		ids = append(ids, id)
+	}
+	fr.cacheMux.RUnlock()
+
+	if limit := fr.documentCache.limit; len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	loaded := 0
+	sem := make(chan struct{}, concurrency)
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return loaded
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// Give request handlers a chance to run first
+			runtime.Gosched()
+
+			doc, err := fr.GetDocument(id)
+			if err != nil {
+				return
+			}
+
+			if _, err := doc.Content(); err != nil {
+				return
+			}
+
+			mu.Lock()
+			loaded++
+			mu.Unlock()
+		}(id)
+	}
+
+	wg.Wait()
+	return loaded
+}
@@ -0,0 +1,103 @@
+package files_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestFileRepository_WarmDocumentCache(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, "")
+
+	assert.False(t, fr.IsDocumentCached("resources/looney"))
+
+	loaded := fr.WarmDocumentCache(context.Background(), 2)
+	assert.True(t, loaded > 0)
+	assert.True(t, fr.IsDocumentCached("inbox"))
+	assert.True(t, fr.IsDocumentCached("resources/looney"))
+	assert.True(t, fr.IsDocumentCached("resources/characters/minor/michigan"))
+}
+
+func TestFileRepository_WarmDocumentCache_Cancelled(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, fr.WarmDocumentCache(ctx, 1), 0)
+}
+
+func TestDocument_CacheInvalidatedOnSave(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	fr.WarmDocumentCache(context.Background(), 1)
+	assert.True(t, fr.IsDocumentCached("inbox"))
+
+	// Changes made outside the application are picked up
+	err = rm.WriteString("inbox.md", "# Changed outside\n")
+	assert.Nil(t, err)
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Changed outside\n")
+
+	err = doc.Save("# Saved")
+	assert.Nil(t, err)
+	assert.False(t, fr.IsDocumentCached("inbox"))
+}
+
+func TestFileRepository_DocumentCacheSize(t *testing.T) {
+	t.Parallel()
+	rm, err := files.NewRootManager("./testdata/data")
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.DocumentCacheSize = 2
+	fr := files.NewFileRepository(rm, config)
+	fr.ReloadCaches()
+
+	for _, id := range []string{"inbox", "resources/looney", "resources/characters/minor/michigan"} {
+		doc, err := fr.GetDocument(id)
+		assert.Nil(t, err)
+		_, err = doc.Content()
+		assert.Nil(t, err)
+	}
+
+	// The least recently read document is evicted
+	assert.False(t, fr.IsDocumentCached("inbox"))
+	assert.True(t, fr.IsDocumentCached("resources/looney"))
+	assert.True(t, fr.IsDocumentCached("resources/characters/minor/michigan"))
+}
+
+func TestFileRepository_DocumentCache_ConcurrentLoads(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, "")
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			doc, err := fr.GetDocument("resources/looney")
+			assert.Nil(t, err)
+			content, err := doc.Content()
+			assert.Nil(t, err)
+			assert.True(t, content != "")
+		}()
+	}
+	wg.Wait()
+
+	assert.True(t, fr.IsDocumentCached("resources/looney"))
+}
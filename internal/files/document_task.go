@@ -1,29 +1,297 @@
 package files
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
 )
 
 type Task struct {
-	ID        int
-	Label     string
-	IsChecked bool
-	LineIndex int
-	Prefix    string // e.g., "- " or "* "
-	State     string // " " or "x" or "X"
-	Suffix    string // The rest of the line
+	ID         int
+	Label      string
+	IsChecked  bool
+	LineIndex  int
+	Prefix     string      // e.g., "- " or "* "
+	State      string      // " " or "x" or "X"
+	Suffix     string      // The rest of the line
+	IsPinned   bool        // Marked with 📌 or @pin to keep it at the top of its section
+	Recurrence *Recurrence // The @repeat or @every schedule; nil for tasks that don't recur
+	Status     string      // The value of a @status(...) annotation, e.g., "doing"
 }
 
 //goland:noinspection RegExpRedundantEscape
 var taskListPattern = regexp.MustCompile(`^(\s*[-*]\s+)\[([ xX])\](.*)$`)
 
+var doneTagPattern = regexp.MustCompile(`\s*@done\(\d{4}-\d{2}-\d{2}\)`)
+
+// trailingTagsPattern matches the run of #tags and @contexts at the end of a task label
+var trailingTagsPattern = regexp.MustCompile(`(?:\s+[#@][\p{L}\p{N}_\-/]+)+$`)
+
+// pinPattern matches the 📌 and @pin markers that pin a task to the top of its section
+var pinPattern = regexp.MustCompile(`📌|(?:^|\s)@pin(?:\s|$)`)
+
+// recurrencePattern matches the @repeat(...) and @every(...) schedules of recurring tasks
+var recurrencePattern = regexp.MustCompile(`\s*@(repeat|every)\(([^)]*)\)`)
+
+// doneDatePattern captures the date of an @done tag
+var doneDatePattern = regexp.MustCompile(`@done\((\d{4}-\d{2}-\d{2})\)`)
+
+// leadingCheckboxPattern matches a checkbox marker at the start of a task label
+var leadingCheckboxPattern = regexp.MustCompile(`^\[([ xX])\]`)
+
+// ErrEmptyTaskLabel is returned when a task label is blank
+var ErrEmptyTaskLabel = errors.New("task label cannot be empty")
+
+// sanitizeTaskLabel cleans a label so it can be written on a single task line without changing the task's
+// syntax. Line breaks become spaces, surrounding whitespace is trimmed, and a leading checkbox marker such
+// as "[x]" is escaped, so it renders as text instead of reading as the task's state.
+func sanitizeTaskLabel(label string) (string, error) {
+	label = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(label)
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return "", ErrEmptyTaskLabel
+	}
+
+	if leadingCheckboxPattern.MatchString(label) {
+		label = `\` + label
+	}
+
+	return label, nil
+}
+
+// formatTaskLine builds a task line in the "prefix[state] label" form
+func formatTaskLine(prefix, state, label string) string {
+	return fmt.Sprintf("%s[%s] %s", prefix, state, label)
+}
+
+// DonePlacement controls where the @done tag is inserted when a task is completed
+type DonePlacement int
+
+const (
+	// DoneAtEnd appends the @done tag to the end of the task label
+	DoneAtEnd DonePlacement = iota
+	// DoneBeforeTags inserts the @done tag before any trailing #tags and @contexts
+	DoneBeforeTags
+)
+
+// addDoneTag adds an @done tag for the given date to a task label at the configured position
+func addDoneTag(label string, placement DonePlacement, date time.Time) string {
+	label = strings.TrimSpace(label)
+	doneTag := fmt.Sprintf(" @done(%s)", date.Format("2006-01-02"))
+
+	if placement == DoneBeforeTags {
+		if loc := trailingTagsPattern.FindStringIndex(label); loc != nil && loc[0] > 0 {
+			return label[:loc[0]] + doneTag + label[loc[0]:]
+		}
+	}
+
+	return label + doneTag
+}
+
+// Recurrence is the schedule of a recurring task, written as @repeat(interval) or @every(days). Intervals are
+// daily, weekly, biweekly, monthly, quarterly, yearly, or a count and unit such as 10d, 2w, 6m, or 1y. Days are
+// weekday names, full or abbreviated and separated by commas (e.g., monday or mon,thu), or "day", "weekday",
+// or "weekend".
+type Recurrence struct {
+	Days     int            // Days between occurrences, for intervals counted in days or weeks
+	Months   int            // Months between occurrences, for intervals counted in months or years
+	Weekdays []time.Weekday // Days of the week the task comes back on, for @every
+}
+
+// recurrenceIntervals maps the named @repeat intervals to their length
+var recurrenceIntervals = map[string]Recurrence{
+	"daily":     {Days: 1},
+	"weekly":    {Days: 7},
+	"biweekly":  {Days: 14},
+	"monthly":   {Months: 1},
+	"quarterly": {Months: 3},
+	"yearly":    {Months: 12},
+	"annually":  {Months: 12},
+}
+
+// countedIntervalPattern matches @repeat intervals such as 10d, 2w, 6m, or 1y
+var countedIntervalPattern = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// ParseRecurrence returns the schedule of the first @repeat or @every annotation in a task label. It returns
+// false if the label has none, or if the schedule can't be read.
+func ParseRecurrence(label string) (Recurrence, bool) {
+	matches := recurrencePattern.FindStringSubmatch(label)
+	if matches == nil {
+		return Recurrence{}, false
+	}
+
+	schedule := strings.ToLower(strings.TrimSpace(matches[2]))
+	if matches[1] == "every" {
+		return parseWeekdays(schedule)
+	}
+
+	if recurrence, ok := recurrenceIntervals[schedule]; ok {
+		return recurrence, true
+	}
+
+	counted := countedIntervalPattern.FindStringSubmatch(schedule)
+	if counted == nil {
+		return Recurrence{}, false
+	}
+
+	var count int
+	if _, err := fmt.Sscan(counted[1], &count); err != nil || count < 1 {
+		return Recurrence{}, false
+	}
+
+	switch counted[2] {
+	case "d":
+		return Recurrence{Days: count}, true
+	case "w":
+		return Recurrence{Days: count * 7}, true
+	case "m":
+		return Recurrence{Months: count}, true
+	default:
+		return Recurrence{Months: count * 12}, true
+	}
+}
+
+// parseWeekdays reads the days of an @every schedule
+func parseWeekdays(schedule string) (Recurrence, bool) {
+	var weekdays []time.Weekday
+	for _, name := range strings.Split(schedule, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "day", "daily":
+			return Recurrence{Days: 1}, true
+		case "weekday", "weekdays":
+			weekdays = append(weekdays, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+		case "weekend", "weekends":
+			weekdays = append(weekdays, time.Saturday, time.Sunday)
+		default:
+			weekday, ok := parseWeekday(name)
+			if !ok {
+				return Recurrence{}, false
+			}
+			weekdays = append(weekdays, weekday)
+		}
+	}
+
+	return Recurrence{Weekdays: weekdays}, len(weekdays) > 0
+}
+
+// parseWeekday reads a weekday name, such as "monday" or "mon"
+func parseWeekday(name string) (time.Weekday, bool) {
+	if len(name) < 3 {
+		return 0, false
+	}
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.HasPrefix(strings.ToLower(day.String()), name) {
+			return day, true
+		}
+	}
+
+	return 0, false
+}
+
+// Next returns the date of the first occurrence after the given date
+func (r Recurrence) Next(after time.Time) time.Time {
+	date := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+
+	switch {
+	case r.Months > 0:
+		return date.AddDate(0, r.Months, 0)
+	case len(r.Weekdays) > 0:
+		for i := 1; i <= 7; i++ {
+			next := date.AddDate(0, 0, i)
+			if slices.Contains(r.Weekdays, next.Weekday()) {
+				return next
+			}
+		}
+	}
+
+	return date.AddDate(0, 0, max(r.Days, 1))
+}
+
+// isRecurrenceDue returns true if a completed recurring task should come back by now. Tasks completed
+// without an @done date are always due.
+func isRecurrenceDue(task Task, now time.Time) bool {
+	matches := doneDatePattern.FindStringSubmatch(task.Label)
+	if matches == nil {
+		return true
+	}
+
+	done, err := time.ParseInLocation("2006-01-02", matches[1], now.Location())
+	if err != nil {
+		return true
+	}
+
+	return !now.Before(task.Recurrence.Next(done))
+}
+
+// TaskCounts summarizes the tasks in a document
+type TaskCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Pending   int `json:"pending"`
+}
+
+// CountTasks returns the number of total, completed, and pending tasks in the document
+func (d *Document) CountTasks() (TaskCounts, error) {
+	tasks, err := d.getAllTasks()
+	if err != nil {
+		return TaskCounts{}, err
+	}
+
+	counts := TaskCounts{Total: len(tasks)}
+	for _, task := range tasks {
+		if task.IsChecked {
+			counts.Completed++
+		}
+	}
+	counts.Pending = counts.Total - counts.Completed
+
+	return counts, nil
+}
+
+// countTaskLines counts the tasks in the lines with a plain line scan, without building a Task for each one
+func countTaskLines(lines []string) TaskCounts {
+	var counts TaskCounts
+	for _, line := range lines {
+		if matches := taskListPattern.FindStringSubmatch(line); matches != nil {
+			counts.Total++
+			if matches[2] != " " {
+				counts.Completed++
+			}
+		}
+	}
+	counts.Pending = counts.Total - counts.Completed
+
+	return counts
+}
+
 func (d *Document) GetTask(taskID int) (*Task, error) {
 	return d.findTaskByID(taskID)
 }
 
+// GetTaskByLabel returns the task whose label matches the given label. Labels are compared after trimming
+// whitespace and stripping any @done tag. It returns an error if no task or more than one task matches.
+func (d *Document) GetTaskByLabel(label string) (*Task, error) {
+	return d.findTaskByLabel(label)
+}
+
+// ToggleTaskByLabel toggles the task whose label matches the given label. Unlike task IDs, labels don't
+// shift when other tasks are added or removed, which makes them a more stable reference for automation.
+func (d *Document) ToggleTaskByLabel(label string) (*Task, error) {
+	task, err := d.findTaskByLabel(label)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.ToggleTask(task.ID)
+}
+
 func (d *Document) ToggleTask(taskID int) (*Task, error) {
 	task, err := d.findTaskByID(taskID)
 	if err != nil {
@@ -37,10 +305,10 @@ func (d *Document) ToggleTask(taskID int) (*Task, error) {
 
 	if strings.TrimSpace(task.State) == "" {
 		newState = "x"
-		newSuffix = strings.TrimSpace(task.Suffix) + fmt.Sprintf(" @done(%s)", time.Now().Format("2006-01-02"))
+		newSuffix = addDoneTag(task.Suffix, d.repo.config.DonePlacement, time.Now())
 	} else {
 		newState = " "
-		newSuffix = regexp.MustCompile(`\s*@done\(\d{4}-\d{2}-\d{2}\)`).ReplaceAllString(task.Suffix, "")
+		newSuffix = strings.TrimSpace(doneTagPattern.ReplaceAllString(task.Suffix, ""))
 	}
 
 	lines[task.LineIndex] = fmt.Sprintf("%s[%s] %s", task.Prefix, newState, newSuffix)
@@ -65,26 +333,30 @@ func (d *Document) UpdateTaskLabel(taskID int, newLabel string) (*Task, error) {
 		return nil, err
 	}
 
+	newLabel, err = sanitizeTaskLabel(newLabel)
+	if err != nil {
+		return nil, err
+	}
+
 	lines := strings.Split(d.content, "\n")
 
 	// If task is checked and doesn't have @done tag, add it
 	if task.IsChecked {
-		doneTags := regexp.MustCompile(`\s*@done\(\d{4}-\d{2}-\d{2}\)`).FindString(newLabel)
+		doneTags := doneTagPattern.FindString(newLabel)
 		if doneTags == "" {
-			newLabel += fmt.Sprintf(" @done(%s)", time.Now().Format("2006-01-02"))
+			newLabel = addDoneTag(newLabel, d.repo.config.DonePlacement, time.Now())
 		}
 	}
 
-	newSuffix := " " + strings.TrimSpace(newLabel)
-	lines[task.LineIndex] = fmt.Sprintf("%s[%s]%s", task.Prefix, task.State, newSuffix)
+	lines[task.LineIndex] = formatTaskLine(task.Prefix, task.State, newLabel)
 
 	updatedContent := strings.Join(lines, "\n")
 	if err := d.Save(updatedContent); err != nil {
 		return nil, fmt.Errorf("failed to save: %w", err)
 	}
 
-	task.Label = strings.TrimSpace(newSuffix)
-	task.Suffix = newSuffix
+	task.Label = newLabel
+	task.Suffix = " " + newLabel
 
 	return task, nil
 }
@@ -104,6 +376,92 @@ func (d *Document) DeleteTask(taskID int) error {
 	return d.Save(updatedContent)
 }
 
+// MoveTask moves a task, along with the lines nested under it, into a section of the target document. The
+// section is a heading's text (e.g., "Work") and is created at the top of the target when it's missing; an
+// empty section moves the task to the top of the target. The task keeps its state and label, and a nested
+// task is outdented to the top level. The task is added to the target before it's removed here, so a
+// failed save never loses it. Task IDs in both documents shift afterward.
+func (d *Document) MoveTask(taskID int, target *Document, section string) error {
+	task, err := d.findTaskByID(taskID)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(d.content, "\n")
+	end := taskBlockEnd(lines, task.LineIndex)
+	block := outdentLines(lines[task.LineIndex:end], len(task.Prefix)-len(strings.TrimLeft(task.Prefix, " \t")))
+	remaining := slices.Concat(lines[:task.LineIndex], lines[end:])
+
+	header := strings.TrimSpace(section)
+	if header != "" && !strings.HasPrefix(header, "#") {
+		header = "## " + header
+	}
+
+	config := EntryInsertionConfig{
+		Strategy:       InsertInSection,
+		EntryFormatter: func(entry string, _ time.Time) string { return entry },
+		SectionConfig: &SectionInsertionConfig{
+			SectionHeader: header,
+			InsertAtTop:   true,
+		},
+	}
+
+	// Moving between sections of the same document is a single save
+	if target.Info.Path == d.Info.Path {
+		result, err := d.insertEntry(remaining, strings.Join(block, "\n"), config)
+		if err != nil {
+			return err
+		}
+		return d.Save(strings.Join(result, "\n"))
+	}
+
+	if locked, err := d.IsLocked(); err != nil {
+		return err
+	} else if locked {
+		return fmt.Errorf("failed to move task from %s: %w", d.Info.Path, ErrDocumentLocked)
+	}
+
+	if err := target.load(); err != nil {
+		return err
+	}
+
+	result, err := target.insertEntry(contentutil.SplitLines(target.content), strings.Join(block, "\n"), config)
+	if err != nil {
+		return err
+	}
+
+	if err := target.Save(strings.Join(result, "\n")); err != nil {
+		return err
+	}
+
+	return d.Save(strings.Join(remaining, "\n"))
+}
+
+// taskBlockEnd returns the index just past the task at idx and the lines nested under it
+func taskBlockEnd(lines []string, idx int) int {
+	indent := len(lines[idx]) - len(strings.TrimLeft(lines[idx], " \t"))
+
+	end := idx + 1
+	for end < len(lines) && strings.TrimSpace(lines[end]) != "" &&
+		len(lines[end])-len(strings.TrimLeft(lines[end], " \t")) > indent {
+		end++
+	}
+
+	return end
+}
+
+// outdentLines removes up to width leading spaces or tabs from each line
+func outdentLines(lines []string, width int) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		cut := min(width, len(line)-len(trimmed))
+		result[i] = line[cut:]
+	}
+
+	return result
+}
+
 func (d *Document) ArchiveCompletedTasks() ([]string, error) {
 	if err := d.load(); err != nil {
 		return nil, err
@@ -134,6 +492,247 @@ func (d *Document) ArchiveCompletedTasks() ([]string, error) {
 	return completedTasks, nil
 }
 
+// RenewRecurringTasks adds a pending copy of each completed recurring task whose next occurrence has come by
+// now, just above the completed task. The schedule moves to the copy, so the completed task stays behind as a
+// record and isn't renewed again. It returns the labels of the new tasks.
+func (d *Document) RenewRecurringTasks(now time.Time) ([]string, error) {
+	return d.renewRecurringTasks(now, true)
+}
+
+// DetachRecurringTasks is like RenewRecurringTasks, but leaves adding the new tasks to the caller, such as
+// to put them in the inbox. It removes the schedule from each due completed task and returns the labels
+// for the new tasks.
+func (d *Document) DetachRecurringTasks(now time.Time) ([]string, error) {
+	return d.renewRecurringTasks(now, false)
+}
+
+func (d *Document) renewRecurringTasks(now time.Time, inPlace bool) ([]string, error) {
+	renewed, lines, err := d.dueRecurrences(now, inPlace)
+	if err != nil || len(renewed) == 0 {
+		return nil, err
+	}
+
+	if err := d.Save(strings.Join(lines, "\n")); err != nil {
+		return nil, fmt.Errorf("failed to save: %w", err)
+	}
+
+	return renewed, nil
+}
+
+// dueRecurrences returns the labels for the new tasks of the recurring tasks that are due by now, in document
+// order, along with the document's lines with each due task's schedule removed and, when inPlace is true, its
+// new task added above it. Nothing is saved.
+func (d *Document) dueRecurrences(now time.Time, inPlace bool) ([]string, []string, error) {
+	tasks, err := d.getAllTasks()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := strings.Split(d.content, "\n")
+	var renewed []string
+
+	// Work from the bottom up, so inserted copies don't shift the lines of the tasks still to come
+	for i := len(tasks) - 1; i >= 0; i-- {
+		task := tasks[i]
+		if !task.IsChecked || task.Recurrence == nil || !isRecurrenceDue(task, now) {
+			continue
+		}
+
+		label := strings.TrimSpace(doneTagPattern.ReplaceAllString(task.Label, ""))
+		completed := strings.TrimSpace(recurrencePattern.ReplaceAllString(task.Label, ""))
+		lines[task.LineIndex] = formatTaskLine(task.Prefix, task.State, completed)
+		if inPlace {
+			lines = slices.Insert(lines, task.LineIndex, formatTaskLine(task.Prefix, " ", label))
+		}
+		renewed = append(renewed, label)
+	}
+
+	slices.Reverse(renewed)
+	return renewed, lines, nil
+}
+
+// TaskSort controls how SortTasksInSection orders tasks. The sort is stable, so tasks that compare equal keep
+// their relative order.
+type TaskSort struct {
+	PinnedFirst bool // Pinned tasks come before unpinned tasks
+	ByState     bool // Pending tasks come before completed tasks
+}
+
+// compare orders two tasks by the configured keys, with pinning taking precedence over state
+func (ts TaskSort) compare(a, b Task) int {
+	if ts.PinnedFirst && a.IsPinned != b.IsPinned {
+		if a.IsPinned {
+			return -1
+		}
+		return 1
+	}
+
+	if ts.ByState && a.IsChecked != b.IsChecked {
+		if a.IsChecked {
+			return 1
+		}
+		return -1
+	}
+
+	return 0
+}
+
+// taskBlock is a top-level task line together with its indented continuation lines and subtasks
+type taskBlock struct {
+	task  Task
+	lines []string
+}
+
+// SortTasksInSection reorders the top-level tasks in a ## section, given with or without the "## " prefix.
+// Each run of consecutive tasks is sorted on its own, and a task's indented lines and subtasks move with it.
+// Other content in the section stays in place. It returns false if the section was not found.
+func (d *Document) SortTasksInSection(header string, order TaskSort) (bool, error) {
+	if err := d.load(); err != nil {
+		return false, err
+	}
+
+	lines := contentutil.SplitLines(d.content)
+
+	sectionStartIdx, sectionEndIdx := findSection(lines, header)
+	if sectionStartIdx == -1 {
+		return false, nil
+	}
+
+	tasksByLine := make(map[int]Task)
+	for _, task := range d.extractAllTasks(lines) {
+		if task.Prefix == strings.TrimLeft(task.Prefix, " \t") {
+			tasksByLine[task.LineIndex] = task
+		}
+	}
+
+	for i := sectionStartIdx + 1; i < sectionEndIdx; {
+		if _, ok := tasksByLine[i]; !ok {
+			i++
+			continue
+		}
+
+		// Collect the run of consecutive task blocks starting here
+		runStart := i
+		var blocks []taskBlock
+		for i < sectionEndIdx {
+			task, ok := tasksByLine[i]
+			if !ok {
+				break
+			}
+
+			end := i + 1
+			for end < sectionEndIdx && isIndentedContinuation(lines[end]) {
+				end++
+			}
+			blocks = append(blocks, taskBlock{task: task, lines: slices.Clone(lines[i:end])})
+			i = end
+		}
+
+		slices.SortStableFunc(blocks, func(a, b taskBlock) int {
+			return order.compare(a.task, b.task)
+		})
+
+		pos := runStart
+		for _, block := range blocks {
+			pos += copy(lines[pos:], block.lines)
+		}
+	}
+
+	if err := d.Save(strings.Join(lines, "\n")); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DedupeTasks removes duplicate tasks from a ## section, given with or without the "## " prefix, and returns
+// how many were removed. Tasks are duplicates when their labels match after trimming and stripping @done tags,
+// whatever their state. The first occurrence is kept, unless FileConfig.DedupeKeepsCompleted is set, in which
+// case the first completed occurrence is kept if there is one. Tasks with nested lines are never removed, so
+// subtasks and notes are not lost.
+func (d *Document) DedupeTasks(sectionHeader string) (int, error) {
+	if err := d.load(); err != nil {
+		return 0, err
+	}
+
+	lines := contentutil.SplitLines(d.content)
+
+	sectionStartIdx, sectionEndIdx := findSection(lines, sectionHeader)
+	if sectionStartIdx == -1 {
+		return 0, fmt.Errorf("section %q not found", sectionHeader)
+	}
+
+	// Group the tasks in the section by label, in document order
+	var labels []string
+	groups := make(map[string][]Task)
+	for _, task := range d.extractAllTasks(lines) {
+		if task.LineIndex <= sectionStartIdx || task.LineIndex >= sectionEndIdx {
+			continue
+		}
+
+		label := normalizeTaskLabel(task.Label)
+		if _, ok := groups[label]; !ok {
+			labels = append(labels, label)
+		}
+		groups[label] = append(groups[label], task)
+	}
+
+	remove := make(map[int]bool)
+	for _, label := range labels {
+		group := groups[label]
+		if len(group) < 2 {
+			continue
+		}
+
+		keep := 0
+		if d.repo.config.DedupeKeepsCompleted {
+			if i := slices.IndexFunc(group, func(t Task) bool { return t.IsChecked }); i != -1 {
+				keep = i
+			}
+		}
+
+		for i, task := range group {
+			if i != keep && !hasNestedLines(lines, task.LineIndex) {
+				remove[task.LineIndex] = true
+			}
+		}
+	}
+
+	if len(remove) == 0 {
+		return 0, nil
+	}
+
+	result := make([]string, 0, len(lines)-len(remove))
+	for i, line := range lines {
+		if !remove[i] {
+			result = append(result, line)
+		}
+	}
+
+	if err := d.Save(strings.Join(result, "\n")); err != nil {
+		return 0, err
+	}
+
+	return len(remove), nil
+}
+
+// hasNestedLines reports whether the list item on line idx is followed by lines indented deeper than it
+func hasNestedLines(lines []string, idx int) bool {
+	if idx+1 >= len(lines) || strings.TrimSpace(lines[idx+1]) == "" {
+		return false
+	}
+
+	indent := len(lines[idx]) - len(strings.TrimLeft(lines[idx], " \t"))
+	nextIndent := len(lines[idx+1]) - len(strings.TrimLeft(lines[idx+1], " \t"))
+
+	return nextIndent > indent
+}
+
+// isIndentedContinuation reports whether a line belongs to the list item above it
+func isIndentedContinuation(line string) bool {
+	return strings.TrimSpace(line) != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"))
+}
+
 func (d *Document) findTaskByID(taskID int) (*Task, error) {
 	tasks, err := d.getAllTasks()
 	if err != nil {
@@ -147,6 +746,37 @@ func (d *Document) findTaskByID(taskID int) (*Task, error) {
 	return &tasks[taskID-1], nil
 }
 
+func (d *Document) findTaskByLabel(label string) (*Task, error) {
+	tasks, err := d.getAllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	target := normalizeTaskLabel(label)
+	var found *Task
+	for i := range tasks {
+		if normalizeTaskLabel(tasks[i].Label) != target {
+			continue
+		}
+
+		if found != nil {
+			return nil, fmt.Errorf("task label %q is ambiguous (matches tasks %d and %d)", target, found.ID, tasks[i].ID)
+		}
+		found = &tasks[i]
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("task with label %q not found", target)
+	}
+
+	return found, nil
+}
+
+// normalizeTaskLabel trims a task label and strips any @done tag for comparison.
+func normalizeTaskLabel(label string) string {
+	return strings.TrimSpace(doneTagPattern.ReplaceAllString(label, ""))
+}
+
 // extractAllTasks extracts all tasks from the given lines.
 func (d *Document) extractAllTasks(lines []string) []Task {
 	var tasks []Task
@@ -160,7 +790,7 @@ func (d *Document) extractAllTasks(lines []string) []Task {
 			suffix := matches[3]
 			label := strings.TrimSpace(suffix)
 			isChecked := state == "x" || state == "X"
-			tasks = append(tasks, Task{
+			task := Task{
 				ID:        taskCount,
 				Label:     label,
 				IsChecked: isChecked,
@@ -168,7 +798,15 @@ func (d *Document) extractAllTasks(lines []string) []Task {
 				Prefix:    prefix,
 				State:     state,
 				Suffix:    suffix,
-			})
+				IsPinned:  pinPattern.MatchString(label),
+			}
+			if recurrence, ok := ParseRecurrence(label); ok {
+				task.Recurrence = &recurrence
+			}
+			if status := statusPattern.FindStringSubmatch(label); status != nil {
+				task.Status = strings.TrimSpace(status[1])
+			}
+			tasks = append(tasks, task)
 		}
 	}
 
@@ -0,0 +1,490 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func setupTaskDocument(t *testing.T, content string) *files.Document {
+	t.Helper()
+
+	tmp := t.TempDir()
+	fr, _ := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("active")
+	assert.Nil(t, err)
+
+	err = doc.Save(content)
+	assert.Nil(t, err)
+
+	return doc
+}
+
+func TestDocument_ToggleTaskByLabel(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, `# Active
+
+- [ ] Write report
+- [ ] Call the plumber
+- [x] Pay rent @done(2025-09-01)`)
+
+	task, err := doc.ToggleTaskByLabel("  Call the plumber ")
+	assert.Nil(t, err)
+	assert.Equal(t, task.ID, 2)
+	assert.True(t, task.IsChecked)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "- [x] Call the plumber @done("))
+
+	// The @done tag is ignored when matching
+	task, err = doc.GetTaskByLabel("Pay rent")
+	assert.Nil(t, err)
+	assert.Equal(t, task.ID, 3)
+
+	task, err = doc.ToggleTaskByLabel("Pay rent @done(2025-09-01)")
+	assert.Nil(t, err)
+	assert.False(t, task.IsChecked)
+}
+
+func TestDocument_ToggleTaskByLabel_Ambiguous(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, `# Active
+
+- [ ] Water plants
+- [x] Water plants @done(2025-09-01)`)
+
+	_, err := doc.ToggleTaskByLabel("Water plants")
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "ambiguous"))
+
+	// Nothing was toggled
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "- [ ] Water plants"))
+}
+
+func TestDocument_GetTaskByLabel_NotFound(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, `# Active
+
+- [ ] Write report`)
+
+	_, err := doc.GetTaskByLabel("Write the report")
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "not found"))
+}
+
+func TestDocument_ToggleTask_DonePlacement(t *testing.T) {
+	t.Parallel()
+
+	today := time.Now().Format("2006-01-02")
+	tests := []struct {
+		name      string
+		placement files.DonePlacement
+		want      string
+	}{
+		{"at end", files.DoneAtEnd, "Write report #project @work @done(" + today + ")"},
+		{"before tags", files.DoneBeforeTags, "Write report @done(" + today + ") #project @work"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rm, err := files.NewRootManager(t.TempDir())
+			assert.Nil(t, err)
+
+			config := files.DefaultFileConfig
+			config.DonePlacement = tt.placement
+			fr := files.NewFileRepository(rm, config)
+			assert.Nil(t, fr.Initialize())
+			fr.ReloadCaches()
+
+			doc, err := fr.GetDocument("active")
+			assert.Nil(t, err)
+			assert.Nil(t, doc.Save("# Active\n\n- [ ] Write report #project @work"))
+
+			task, err := doc.ToggleTask(1)
+			assert.Nil(t, err)
+			assert.True(t, task.IsChecked)
+			assert.Equal(t, task.Label, tt.want)
+
+			// Un-completing strips the tag regardless of its position
+			task, err = doc.ToggleTask(1)
+			assert.Nil(t, err)
+			assert.False(t, task.IsChecked)
+			assert.Equal(t, task.Label, "Write report #project @work")
+
+			content, err := doc.Content()
+			assert.Nil(t, err)
+			assert.Equal(t, content, "# Active\n\n- [ ] Write report #project @work\n")
+		})
+	}
+}
+
+func TestDocument_CountTasks(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Tasks\n\n- [ ] one\n- [x] two\n* [X] three\n- not a task\n")
+
+	counts, err := doc.CountTasks()
+	assert.Nil(t, err)
+	assert.Equal(t, counts, files.TaskCounts{Total: 3, Completed: 2, Pending: 1})
+
+	// Counts follow edits
+	err = doc.AddEntry("four", files.EntryInsertionConfig{Strategy: files.AppendToFile, EntryFormatter: files.TaskEntryFormatter})
+	assert.Nil(t, err)
+
+	counts, err = doc.CountTasks()
+	assert.Nil(t, err)
+	assert.Equal(t, counts.Pending, 2)
+}
+
+func TestDocument_SortTasksInSection(t *testing.T) {
+	t.Parallel()
+
+	content := "# Tasks\n\n## Inbox\n\n" +
+		"- [ ] first\n" +
+		"- [x] second 📌\n" +
+		"  - [ ] second child\n" +
+		"- [ ] third\n" +
+		"- [ ] fourth @pin #work\n" +
+		"- [x] fifth\n" +
+		"- [ ] sixth 📌\n" +
+		"\n## Later\n\n- [ ] later @pin\n- [ ] other\n"
+
+	tests := []struct {
+		name  string
+		order files.TaskSort
+		want  []string
+	}{
+		{
+			name:  "pinned first",
+			order: files.TaskSort{PinnedFirst: true},
+			want:  []string{"second 📌", "fourth @pin #work", "sixth 📌", "first", "third", "fifth"},
+		},
+		{
+			name:  "by state",
+			order: files.TaskSort{ByState: true},
+			want:  []string{"first", "third", "fourth @pin #work", "sixth 📌", "second 📌", "fifth"},
+		},
+		{
+			name:  "pinned first then by state",
+			order: files.TaskSort{PinnedFirst: true, ByState: true},
+			want:  []string{"fourth @pin #work", "sixth 📌", "second 📌", "first", "third", "fifth"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			doc := setupTaskDocument(t, content)
+
+			found, err := doc.SortTasksInSection("Inbox", tt.order)
+			assert.Nil(t, err)
+			assert.True(t, found)
+
+			updated, err := doc.Content()
+			assert.Nil(t, err)
+
+			var labels []string
+			for i := 1; ; i++ {
+				task, err := doc.GetTask(i)
+				if err != nil {
+					break
+				}
+				if strings.HasPrefix(task.Prefix, " ") {
+					continue
+				}
+				labels = append(labels, task.Label)
+			}
+
+			// The Later section is untouched
+			assert.Equal(t, strings.Join(labels[:len(tt.want)], "|"), strings.Join(tt.want, "|"))
+			assert.True(t, strings.HasSuffix(updated, "\n## Later\n\n- [ ] later @pin\n- [ ] other\n"))
+
+			// Subtasks move with their parent
+			assert.True(t, strings.Contains(updated, "- [x] second 📌\n  - [ ] second child\n"))
+		})
+	}
+}
+
+func TestDocument_SortTasksInSection_NotFound(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Tasks\n\n- [ ] one\n")
+
+	found, err := doc.SortTasksInSection("## Missing", files.TaskSort{PinnedFirst: true})
+	assert.Nil(t, err)
+	assert.False(t, found)
+}
+
+func TestDocument_PinnedTasks(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Tasks\n\n- [ ] 📌 urgent\n- [ ] call @pin\n- [ ] call @pinned\n- [ ] plain\n")
+
+	tests := []struct {
+		id     int
+		pinned bool
+	}{
+		{1, true},
+		{2, true},
+		{3, false},
+		{4, false},
+	}
+	for _, tt := range tests {
+		task, err := doc.GetTask(tt.id)
+		assert.Nil(t, err)
+		assert.Equal(t, task.IsPinned, tt.pinned)
+	}
+
+	// Pin markers survive toggling and edits
+	task, err := doc.ToggleTask(2)
+	assert.Nil(t, err)
+	assert.True(t, task.IsPinned)
+
+	_, err = doc.UpdateTaskLabel(1, "📌 very urgent")
+	assert.Nil(t, err)
+	task, err = doc.GetTask(1)
+	assert.Nil(t, err)
+	assert.True(t, task.IsPinned)
+	task, err = doc.GetTask(2)
+	assert.Nil(t, err)
+	assert.True(t, task.IsPinned)
+	assert.True(t, strings.HasPrefix(task.Label, "call @pin"))
+}
+
+func TestDocument_UpdateTaskLabel_AdversarialLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		label string
+		want  string
+	}{
+		{name: "checkbox marker", label: "[x] fake", want: `  - [ ] \[x] fake`},
+		{name: "uppercase checkbox marker", label: "[X]", want: `  - [ ] \[X]`},
+		{name: "surrounding spaces", label: "   Call Sam   ", want: "  - [ ] Call Sam"},
+		{name: "pipes", label: "a | b | c", want: "  - [ ] a | b | c"},
+		{name: "list marker", label: "- not a sublist", want: "  - [ ] - not a sublist"},
+		{name: "closing bracket", label: "] odd [", want: "  - [ ] ] odd ["},
+		{name: "line breaks", label: "first\r\n- [x] injected", want: "  - [ ] first - [x] injected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := setupTaskDocument(t, "# Active\n\n- [ ] Parent\n  - [ ] Child\n- [ ] Last\n")
+
+			task, err := doc.UpdateTaskLabel(2, tt.label)
+			assert.Nil(t, err)
+			assert.False(t, task.IsChecked)
+
+			content, err := doc.Content()
+			assert.Nil(t, err)
+			lines := strings.Split(content, "\n")
+			assert.Equal(t, lines[3], tt.want)
+
+			// The task syntax survives: same number of tasks, and the edited task is still unchecked
+			counts, err := doc.CountTasks()
+			assert.Nil(t, err)
+			assert.Equal(t, counts.Total, 3)
+			assert.Equal(t, counts.Completed, 0)
+		})
+	}
+}
+
+func TestDocument_UpdateTaskLabel_Empty(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Active\n\n- [ ] Keep me\n")
+
+	_, err := doc.UpdateTaskLabel(1, " \n ")
+	assert.ErrorIs(t, err, files.ErrEmptyTaskLabel)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "- [ ] Keep me"))
+}
+
+func TestDocument_DedupeTasks(t *testing.T) {
+	t.Parallel()
+
+	content := "# Tasks\n\n## Inbox\n\n" +
+		"- [ ] buy milk\n" +
+		"- [ ] call Sam\n" +
+		"- [x] buy milk @done(2025-09-01)\n" +
+		"-   [ ]   call Sam  \n" +
+		"- [ ] plan trip\n" +
+		"  - [ ] book flights\n" +
+		"- [ ] plan trip\n" +
+		"  - [ ] book hotel\n" +
+		"- [x] water plants @done(2025-09-02)\n" +
+		"- [ ] water plants\n" +
+		"\n## Later\n\n- [ ] buy milk\n"
+
+	tests := []struct {
+		name          string
+		keepCompleted bool
+		want          string
+	}{
+		{
+			name: "keeps first occurrence",
+			want: "## Inbox\n\n" +
+				"- [ ] buy milk\n" +
+				"- [ ] call Sam\n" +
+				"- [ ] plan trip\n" +
+				"  - [ ] book flights\n" +
+				"- [ ] plan trip\n" +
+				"  - [ ] book hotel\n" +
+				"- [x] water plants @done(2025-09-02)\n" +
+				"\n## Later\n\n- [ ] buy milk\n",
+		},
+		{
+			name:          "prefers completed",
+			keepCompleted: true,
+			want: "## Inbox\n\n" +
+				"- [ ] call Sam\n" +
+				"- [x] buy milk @done(2025-09-01)\n" +
+				"- [ ] plan trip\n" +
+				"  - [ ] book flights\n" +
+				"- [ ] plan trip\n" +
+				"  - [ ] book hotel\n" +
+				"- [x] water plants @done(2025-09-02)\n" +
+				"\n## Later\n\n- [ ] buy milk\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rm, err := files.NewRootManager(t.TempDir())
+			assert.Nil(t, err)
+			config := files.DefaultFileConfig
+			config.DedupeKeepsCompleted = tt.keepCompleted
+			fr := files.NewFileRepository(rm, config)
+			assert.Nil(t, fr.Initialize())
+			fr.ReloadCaches()
+
+			doc, err := fr.GetDocument("active")
+			assert.Nil(t, err)
+			assert.Nil(t, doc.Save(content))
+
+			removed, err := doc.DedupeTasks("Inbox")
+			assert.Nil(t, err)
+			assert.Equal(t, removed, 3)
+
+			updated, err := doc.Content()
+			assert.Nil(t, err)
+			assert.Equal(t, updated, "# Tasks\n\n"+tt.want)
+		})
+	}
+}
+
+func TestDocument_DedupeTasks_NoDuplicates(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Tasks\n\n## Inbox\n\n- [ ] one\n- [ ] two\n")
+
+	removed, err := doc.DedupeTasks("## Inbox")
+	assert.Nil(t, err)
+	assert.Equal(t, removed, 0)
+
+	_, err = doc.DedupeTasks("Missing")
+	assert.NotNil(t, err)
+}
+
+func TestDocument_MoveTask(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("inbox.md", "# Inbox\n\n- [ ] Plan trip\n    - [x] Book flights\n- [ ] Call Sam\n"))
+	assert.Nil(t, rm.WriteString("active.md", "# Active\n\n## Work\n\n- [ ] Ship release\n"))
+	fr.ReloadCaches()
+
+	inbox, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	active, err := fr.GetDocument("active")
+	assert.Nil(t, err)
+
+	// The nested subtask moves with its parent
+	assert.Nil(t, inbox.MoveTask(1, active, "Work"))
+
+	content, err := inbox.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Inbox\n\n- [ ] Call Sam\n")
+
+	content, err = active.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Active\n\n## Work\n\n- [ ] Plan trip\n    - [x] Book flights\n- [ ] Ship release\n")
+
+	// A missing section is created
+	assert.Nil(t, inbox.MoveTask(1, active, "Later"))
+	content, err = active.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "## Later\n\n- [ ] Call Sam\n"))
+
+	_, err = inbox.GetTask(1)
+	assert.NotNil(t, err)
+}
+
+func TestDocument_MoveTask_SameDocument(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Active\n\n## Today\n\n- [ ] Write report\n    - [ ] Outline\n- [ ] Email\n\n## Later\n\n- [ ] Read book\n")
+
+	assert.Nil(t, doc.MoveTask(1, doc, "Later"))
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Active\n\n## Today\n\n- [ ] Email\n\n## Later\n\n- [ ] Write report\n    - [ ] Outline\n- [ ] Read book\n")
+}
+
+func TestDocument_MoveTask_Nested(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("inbox.md", "- [ ] Project\n  - [ ] Subtask\n    - [ ] Detail\n"))
+	fr.ReloadCaches()
+
+	inbox, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	active, err := fr.GetDocument("active")
+	assert.Nil(t, err)
+
+	assert.Nil(t, inbox.MoveTask(2, active, ""))
+
+	content, err := active.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "---\n\n- [ ] Subtask\n  - [ ] Detail\n"))
+
+	content, err = inbox.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "- [ ] Project\n")
+}
+
+func TestDocument_MoveTask_LockedSource(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("inbox.md", "---\nlocked: true\n---\n- [ ] Stay put\n"))
+	assert.Nil(t, rm.WriteString("active.md", "# Active\n"))
+	fr.ReloadCaches()
+
+	inbox, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	active, err := fr.GetDocument("active")
+	assert.Nil(t, err)
+
+	assert.ErrorIs(t, inbox.MoveTask(1, active, "Work"), files.ErrDocumentLocked)
+
+	content, err := active.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Active\n")
+}
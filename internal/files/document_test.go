@@ -1,10 +1,13 @@
 package files_test
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
 )
 
 func TestDocument_Content(t *testing.T) {
@@ -73,7 +76,7 @@ func TestDocument_Save_NewFile(t *testing.T) {
 	err := fr.Initialize()
 	assert.Nil(t, err)
 
-	doc, err := fr.GetOrCreateResourceDocument("new-resource")
+	doc, err := fr.GetOrCreateResourceDocument("new-resource", "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -98,7 +101,7 @@ func TestDocument_Delete(t *testing.T) {
 
 	assert.False(t, fr.FilePathExists("resources/new-resource.md"))
 
-	doc, err := fr.GetOrCreateResourceDocument("new-resource")
+	doc, err := fr.GetOrCreateResourceDocument("new-resource", "", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -108,3 +111,546 @@ func TestDocument_Delete(t *testing.T) {
 	assert.Nil(t, err)
 	assert.False(t, fr.FilePathExists("resources/new-resource.md"))
 }
+
+func TestDocument_SaveIfUnchanged(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, _ := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+
+	baseHash, err := doc.Checksum()
+	assert.Nil(t, err)
+
+	// Saving with the current hash succeeds
+	err = doc.SaveIfUnchanged("First edit", baseHash)
+	assert.Nil(t, err)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(content), "First edit")
+}
+
+func TestDocument_SaveIfUnchanged_Concurrent(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	baseHash, err := doc.Checksum()
+	assert.Nil(t, err)
+
+	// Every save starts from the same content, so only one of them may land
+	const saves = 32
+	errs := make(chan error, saves)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range saves {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tab, err := fr.GetDocument("inbox")
+			<-start
+			if err != nil {
+				errs <- err
+				return
+			}
+			errs <- tab.SaveIfUnchanged(fmt.Sprintf("Edit %d", i), baseHash)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, files.ErrSaveConflict)
+	}
+	assert.Equal(t, succeeded, 1)
+}
+
+func TestDocument_SaveIfUnchanged_StaleHash(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, _ := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	// Two tabs load the same document
+	tabOne, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	tabTwo, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+
+	hashOne, err := tabOne.Checksum()
+	assert.Nil(t, err)
+	hashTwo, err := tabTwo.Checksum()
+	assert.Nil(t, err)
+	assert.Equal(t, hashOne, hashTwo)
+
+	// The first tab saves
+	err = tabOne.SaveIfUnchanged("Edit from tab one", hashOne)
+	assert.Nil(t, err)
+
+	// The second tab's hash is now stale
+	err = tabTwo.SaveIfUnchanged("Edit from tab two", hashTwo)
+	assert.ErrorIs(t, err, files.ErrSaveConflict)
+
+	// The second tab now holds the latest content to merge with
+	content, err := tabTwo.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(content), "Edit from tab one")
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	content, err = doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, strings.TrimSpace(content), "Edit from tab one")
+}
+
+func TestDocument_Save_Normalized(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+
+	err = doc.Save("\n\n  # Inbox\n\nSome content  \n\n\n")
+	assert.Nil(t, err)
+
+	raw, err := rm.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Inbox\n\nSome content\n")
+}
+
+func TestDocument_Save_Verbatim(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	rm, err := files.NewRootManager(tmp)
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.NormalizeOnSave = false
+	fr := files.NewFileRepository(rm, config)
+	err = fr.Initialize()
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+
+	original := "\n\n  # Inbox\r\n\r\nSome content  \n\n\n"
+	err = doc.Save(original)
+	assert.Nil(t, err)
+
+	raw, err := rm.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), original)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, original)
+}
+
+func TestDocument_RemoveSection_AfterFrontmatter(t *testing.T) {
+	t.Parallel()
+
+	doc := setupTaskDocument(t, "---\ntitle: Sections\n---\n## First\none\n\n## Second\ntwo")
+
+	removed, err := doc.RemoveSection("## First")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "---\ntitle: Sections\n---\n## Second\ntwo\n")
+}
+
+func TestDocument_RemoveSection_FencedCode(t *testing.T) {
+	t.Parallel()
+
+	doc := setupTaskDocument(t, "# Notes\n\n## Example\n```markdown\n## Not a section\n```\n\n~~~\n## Example\n~~~\n\n## Keep\nkept")
+
+	// The ## lines inside code blocks neither end the section nor match as its header
+	removed, err := doc.RemoveSection("## Example")
+	assert.Nil(t, err)
+	assert.True(t, removed)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Notes\n\n## Keep\nkept\n")
+
+	// A header that only appears inside a code block isn't a section
+	doc = setupTaskDocument(t, "# Notes\n\n```\n## Ghost\n```\n")
+	removed, err = doc.RemoveSection("## Ghost")
+	assert.Nil(t, err)
+	assert.False(t, removed)
+}
+
+const sectionTestContent = `---
+title: Sections
+---
+# Sections
+
+## First
+one
+
+## Middle
+two
+
+## Last
+three`
+
+func TestDocument_RemoveSection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		removed bool
+	}{
+		{
+			name:    "first section",
+			header:  "## First",
+			removed: true,
+			want:    "---\ntitle: Sections\n---\n# Sections\n\n## Middle\ntwo\n\n## Last\nthree\n",
+		},
+		{
+			name:    "middle section without prefix",
+			header:  "Middle",
+			removed: true,
+			want:    "---\ntitle: Sections\n---\n# Sections\n\n## First\none\n\n## Last\nthree\n",
+		},
+		{
+			name:    "last section",
+			header:  "## Last",
+			removed: true,
+			want:    "---\ntitle: Sections\n---\n# Sections\n\n## First\none\n\n## Middle\ntwo\n",
+		},
+		{
+			name:    "missing section",
+			header:  "## Missing",
+			removed: false,
+			want:    sectionTestContent + "\n",
+		},
+		{
+			name:    "main header is not a section",
+			header:  "# Sections",
+			removed: false,
+			want:    sectionTestContent + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			doc := setupTaskDocument(t, sectionTestContent)
+
+			removed, err := doc.RemoveSection(tt.header)
+			assert.Nil(t, err)
+			assert.Equal(t, removed, tt.removed)
+
+			content, err := doc.Content()
+			assert.Nil(t, err)
+			assert.Equal(t, content, tt.want)
+		})
+	}
+}
+
+const headingTestContent = "# Title\n\n## Section\n\n###### Deep\n\n```markdown\n# Not a heading\n```\n\n#hashtag"
+
+func TestDocument_ShiftHeadings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		delta int
+		want  string
+	}{
+		{
+			name:  "demote",
+			delta: 1,
+			want:  "## Title\n\n### Section\n\n###### Deep\n\n```markdown\n# Not a heading\n```\n\n#hashtag\n",
+		},
+		{
+			name:  "promote",
+			delta: -1,
+			want:  "# Title\n\n# Section\n\n##### Deep\n\n```markdown\n# Not a heading\n```\n\n#hashtag\n",
+		},
+		{
+			name:  "clamped",
+			delta: -10,
+			want:  "# Title\n\n# Section\n\n# Deep\n\n```markdown\n# Not a heading\n```\n\n#hashtag\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			doc := setupTaskDocument(t, headingTestContent)
+
+			err := doc.ShiftHeadings(tt.delta)
+			assert.Nil(t, err)
+
+			content, err := doc.Content()
+			assert.Nil(t, err)
+			assert.Equal(t, content, tt.want)
+		})
+	}
+}
+
+func TestDocument_ShiftHeadings_SkipsFrontmatter(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "---\n# yaml comment\ntitle: Test\n---\n# Title")
+
+	err := doc.ShiftHeadings(1)
+	assert.Nil(t, err)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "---\n# yaml comment\ntitle: Test\n---\n## Title\n")
+}
+
+func TestDocument_Locked(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "---\nlocked: true\n---\n# Decision\n\n- [ ] Sign off")
+
+	locked, err := doc.IsLocked()
+	assert.Nil(t, err)
+	assert.True(t, locked)
+
+	// Saves, entries, and task changes are refused
+	err = doc.Save("# Rewritten")
+	assert.ErrorIs(t, err, files.ErrDocumentLocked)
+
+	err = doc.AddEntry("New entry", files.EntryInsertionConfig{
+		Strategy:       files.AppendToFile,
+		EntryFormatter: files.NoteEntryFormatter,
+	})
+	assert.ErrorIs(t, err, files.ErrDocumentLocked)
+
+	_, err = doc.ToggleTask(1)
+	assert.ErrorIs(t, err, files.ErrDocumentLocked)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "---\nlocked: true\n---\n# Decision\n\n- [ ] Sign off\n")
+
+	// Unlocking is allowed, and edits work again afterward
+	err = doc.SetLocked(false)
+	assert.Nil(t, err)
+
+	_, err = doc.ToggleTask(1)
+	assert.Nil(t, err)
+
+	content, err = doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(content, "---\n---\n# Decision\n\n- [x] Sign off"))
+}
+
+func TestDocument_SetLocked_WithoutFrontmatter(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Decision")
+
+	err := doc.SetLocked(true)
+	assert.Nil(t, err)
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "---\nlocked: true\n---\n# Decision\n")
+
+	err = doc.Save("# Changed")
+	assert.ErrorIs(t, err, files.ErrDocumentLocked)
+
+	err = doc.ForceSave("# Changed")
+	assert.Nil(t, err)
+}
+
+const anchorTestContent = `---
+title: Anchors
+note: <!-- INSERT HERE -->
+---
+# Anchors
+
+<!-- INSERT HERE -->
+- existing
+Version: 1.2`
+
+func TestDocument_InsertAfterMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name               string
+		pattern            string
+		isRegex            bool
+		entry              string
+		found              bool
+		want               string
+		includeFrontmatter bool
+	}{
+		{
+			name:    "literal match skips frontmatter",
+			pattern: "<!-- INSERT HERE -->",
+			entry:   "- new",
+			found:   true,
+			want:    "---\ntitle: Anchors\nnote: <!-- INSERT HERE -->\n---\n# Anchors\n\n<!-- INSERT HERE -->\n- new\n- existing\nVersion: 1.2\n",
+		},
+		{
+			name:    "regex match",
+			pattern: `^Version: \d+\.\d+$`,
+			isRegex: true,
+			entry:   "Released",
+			found:   true,
+			want:    anchorTestContent + "\nReleased\n",
+		},
+		{
+			name:    "literal pattern is not a regex",
+			pattern: `Version: \d`,
+			found:   false,
+			want:    anchorTestContent + "\n",
+		},
+		{
+			name:    "no match",
+			pattern: "<!-- MISSING -->",
+			entry:   "- new",
+			found:   false,
+			want:    anchorTestContent + "\n",
+		},
+		{
+			name:               "frontmatter when asked",
+			pattern:            "<!-- INSERT HERE -->",
+			entry:              "status: draft",
+			found:              true,
+			includeFrontmatter: true,
+			want:               "---\ntitle: Anchors\nnote: <!-- INSERT HERE -->\nstatus: draft\n---\n# Anchors\n\n<!-- INSERT HERE -->\n- existing\nVersion: 1.2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			doc := setupTaskDocument(t, anchorTestContent)
+
+			insert := doc.InsertAfterMatch
+			if tt.includeFrontmatter {
+				insert = doc.InsertAfterMatchIncludingFrontmatter
+			}
+
+			found, err := insert(tt.pattern, tt.isRegex, tt.entry)
+			assert.Nil(t, err)
+			assert.Equal(t, found, tt.found)
+
+			content, err := doc.Content()
+			assert.Nil(t, err)
+			assert.Equal(t, content, tt.want)
+		})
+	}
+}
+
+func TestDocument_InsertAfterMatch_InvalidRegex(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, anchorTestContent)
+
+	found, err := doc.InsertAfterMatch("[unclosed", true, "entry")
+	assert.NotNil(t, err)
+	assert.False(t, found)
+}
+
+func TestDocument_Excerpt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		content  string
+		maxChars int
+		want     string
+	}{
+		{
+			name:    "frontmatter and title",
+			content: "---\ntitle: Notes\n---\n# Notes\n\nThe first paragraph\nspans two lines.\n\nThe second paragraph.",
+			want:    "The first paragraph spans two lines.",
+		},
+		{
+			name:    "no frontmatter or title",
+			content: "Just prose here.\n\n## Later\n\nMore.",
+			want:    "Just prose here.",
+		},
+		{
+			name:    "skips headings, breaks, comments, and code",
+			content: "# Title\n\n<!-- INSERT HERE -->\n\n---\n\n## Overview\n\n```go\nfmt.Println(\"code\")\n```\n\nProse after code.",
+			want:    "Prose after code.",
+		},
+		{
+			name:     "truncated on a word boundary",
+			content:  "# Title\n\nThe quick brown fox jumps over the lazy dog.",
+			maxChars: 22,
+			want:     "The quick brown fox…",
+		},
+		{
+			name:     "cut at a word end",
+			content:  "The quick brown fox jumps.",
+			maxChars: 19,
+			want:     "The quick brown fox…",
+		},
+		{
+			name:     "short enough",
+			content:  "Short.",
+			maxChars: 100,
+			want:     "Short.",
+		},
+		{
+			name:    "no prose",
+			content: "---\ntitle: Empty\n---\n# Empty\n\n## Section",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			doc := setupTaskDocument(t, tt.content)
+
+			excerpt, err := doc.Excerpt(tt.maxChars)
+			assert.Nil(t, err)
+			assert.Equal(t, excerpt, tt.want)
+		})
+	}
+}
+
+func TestDocument_Links(t *testing.T) {
+	content := "# Links\n\n" +
+		"See [[Project Plan]] and the [spec](https://example.com/spec \"Spec\").\n" +
+		"Use `[[not a link]]` or ``[fake](url)`` in code.\n" +
+		"![diagram](/images/diagram.png) is an image, [notes](notes.md) is not.\n" +
+		"\n" +
+		"```markdown\n" +
+		"[[Inside Fence]] and [fenced](https://example.com/fenced)\n" +
+		"```\n" +
+		"\n" +
+		"- [ ] Review [[ inbox ]]\n"
+	doc := setupTaskDocument(t, content)
+
+	wiki, markdown, err := doc.Links()
+	assert.Nil(t, err)
+	assert.Equal(t, wiki, []string{"Project Plan", "inbox"})
+	assert.Equal(t, markdown, []files.Link{
+		{Text: "spec", URL: "https://example.com/spec"},
+		{Text: "notes", URL: "notes.md"},
+	})
+}
@@ -1,36 +1,50 @@
 package files
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/patrickward/padd/internal/contentutil"
 )
 
 // FileInfo represents metadata about a markdown file
 type FileInfo struct {
-	ID            string         // Unique ID for the file
-	Path          string         // The full file path of the file as a string
-	Title         string         // The name of the file as a string (may include the directory path)
-	TitleBase     string         // The title case of th file name without the directory path
-	DirectoryPath string         // The parent directory path of the file as a string
-	DirectoryNode *DirectoryNode // If the file is a directory, this is the directory node in the directory tree
-	Depth         int            // Depth in the resources/ directory structure (0 for core and files at the root of resources/)
-	IsTemporal    bool           // True if the file is a temporal file (daily/journal)
-	IsNavActive   bool           // True if the file should indicate active in navigation
-	IsResource    bool           // True if the file is in the resources/ directory
-	IsDirectory   bool           // True if the file is a directory
-}
-
-// RelativePath returns the file path relative to the resources/ directory if applicable
+	ID            string            // Unique ID for the file
+	Path          string            // The full file path of the file as a string
+	Title         string            // The name of the file as a string (may include the directory path)
+	TitleBase     string            // The title case of th file name without the directory path
+	DirectoryPath string            // The parent directory path of the file as a string
+	DirectoryNode *DirectoryNode    // If the file is a directory, this is the directory node in the directory tree
+	Depth         int               // Depth in the resources/ directory structure (0 for core and files at the root of resources/)
+	IsTemporal    bool              // True if the file is a temporal file (daily/journal)
+	IsNavActive   bool              // True if the file should indicate active in navigation
+	IsResource    bool              // True if the file is in the resources/ directory
+	IsDirectory   bool              // True if the file is a directory
+	IsDraft       bool              // True if the file's frontmatter has "draft: true"
+	TaskStats     TaskCounts        // Task counts from a line scan of the file; zero for encrypted files
+	Frontmatter   map[string]string // Top-level scalar frontmatter values; nil for encrypted files
+	Tags          []string          // Items of the "tags" frontmatter list; empty for encrypted files
+	Links         FileLinks         // Outbound links from a scan of the file; empty for encrypted files
+	Tasks         []FileTask        // Tasks from a scan of the file, without the file fields; empty for encrypted files
+}
+
+// applyScan sets the fields that come from scanning the file's content
+func (f *FileInfo) applyScan(scan markdownScan) {
+	f.Frontmatter = scan.frontmatter
+	f.Tags = scan.tags
+	f.TaskStats = scan.stats
+	f.Links = scan.links
+	f.Tasks = scan.tasks
+}
+
+// RelativePath returns the file path relative to the resources or temporal directory it's in, if any
 func (f FileInfo) RelativePath() string {
-	if f.IsResource {
-		return f.Path[len("resources/"):]
-	} else if f.IsTemporal {
-		// Remove daily/ or journal/ from the path
-		if strings.HasPrefix(f.Path, "daily/") {
-			return f.Path[len("daily/"):]
-		} else if strings.HasPrefix(f.Path, "journal/") {
-			return f.Path[len("journal/"):]
+	if f.IsResource || f.IsTemporal {
+		// These directories are always at the top of the data directory
+		if _, rest, ok := strings.Cut(f.Path, "/"); ok {
+			return rest
 		}
 	}
 
@@ -47,16 +61,18 @@ func (f FileInfo) RelativePathParts() []string {
 	return strings.Split(f.RelativePath(), "/")
 }
 
-// IsCSV returns true if the file is a CSV file
+// IsCSV returns true if the file is a CSV file, including tab-separated (.tsv) files
 func (f FileInfo) IsCSV() bool {
-	return strings.HasSuffix(f.Path, ".csv")
+	path := strings.ToLower(f.Path)
+	return strings.HasSuffix(path, ".csv") || strings.HasSuffix(path, ".tsv")
 }
 
 type Breadcrumb struct {
-	Path    string
-	Name    string
-	IsFirst bool
-	IsLast  bool
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"` // The title of the file at this path, if one exists
+	IsFirst bool   `json:"isFirst"`
+	IsLast  bool   `json:"isLast"`
 }
 
 // BreadcrumbParts returns the breadcrumb parts for a file's path.
@@ -110,6 +126,32 @@ func (f FileInfo) Month() string {
 	return ""
 }
 
+// Day returns the day of a daily temporal file (e.g., "15" for daily/2025/09/15.md), or an empty string for
+// monthly files
+func (f FileInfo) Day() string {
+	if !f.IsTemporal {
+		return ""
+	}
+
+	parts := strings.Split(f.Path, "/")
+	if len(parts) >= 4 {
+		return strings.TrimSuffix(parts[3], ".md")
+	}
+
+	return ""
+}
+
+// CoversDate reports whether the file is the monthly or daily temporal file that entries for the given date
+// belong in
+func (f FileInfo) CoversDate(date time.Time) bool {
+	if !f.IsTemporal || f.Year() != date.Format("2006") || f.Month() != date.Format("01") {
+		return false
+	}
+
+	day := f.Day()
+	return day == "" || day == date.Format("02")
+}
+
 func (f FileInfo) MonthName() string {
 	if !f.IsTemporal {
 		return ""
@@ -122,7 +164,67 @@ func (f FileInfo) MonthName() string {
 		if len(monthParts) >= 2 {
 			return contentutil.TitleCase(monthParts[1]) // 09-september -> September
 		}
+		if month, err := strconv.Atoi(monthFile); err == nil && month >= 1 && month <= 12 {
+			return time.Month(month).String() // daily/2025/09/15.md -> September
+		}
 	}
 
 	return ""
 }
+
+// RelativeTemporalLabel returns a label for a temporal file relative to now, such as "this month",
+// "last month", or "3 months ago", or for daily files "today", "yesterday", or "3 days ago". It returns an
+// empty string for non-temporal files.
+func (f FileInfo) RelativeTemporalLabel(now time.Time) string {
+	year, err := strconv.Atoi(f.Year())
+	if err != nil {
+		return ""
+	}
+
+	month, err := strconv.Atoi(f.Month())
+	if err != nil || month < 1 || month > 12 {
+		return ""
+	}
+
+	if day := f.Day(); day != "" {
+		return relativeDayLabel(year, month, day, now)
+	}
+
+	diff := (now.Year()*12 + int(now.Month())) - (year*12 + month)
+	switch {
+	case diff == 0:
+		return "this month"
+	case diff == 1:
+		return "last month"
+	case diff > 1:
+		return fmt.Sprintf("%d months ago", diff)
+	case diff == -1:
+		return "next month"
+	default:
+		return fmt.Sprintf("in %d months", -diff)
+	}
+}
+
+// relativeDayLabel returns the label of a daily temporal file relative to now
+func relativeDayLabel(year, month int, day string, now time.Time) string {
+	dayOfMonth, err := strconv.Atoi(day)
+	if err != nil {
+		return ""
+	}
+
+	date := time.Date(year, time.Month(month), dayOfMonth, 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	diff := int(today.Sub(date).Hours() / 24)
+	switch {
+	case diff == 0:
+		return "today"
+	case diff == 1:
+		return "yesterday"
+	case diff > 1:
+		return fmt.Sprintf("%d days ago", diff)
+	case diff == -1:
+		return "tomorrow"
+	default:
+		return fmt.Sprintf("in %d days", -diff)
+	}
+}
@@ -0,0 +1,48 @@
+package files_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestFileInfo_RelativeTemporalLabel(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"daily/2026/01-january.md", "this month"},
+		{"daily/2025/12-december.md", "last month"},     // Across a year boundary
+		{"journal/2025/11-november.md", "2 months ago"}, // Across a year boundary
+		{"daily/2024/09-september.md", "16 months ago"},
+		{"daily/2026/02-february.md", "next month"},
+		{"daily/2026/01/15.md", "today"},
+		{"daily/2026/01/14.md", "yesterday"},
+		{"journal/2025/12/31.md", "15 days ago"}, // Across a year boundary
+		{"daily/2026/01/16.md", "tomorrow"},
+	}
+
+	for _, tc := range testCases {
+		info := files.FileInfo{Path: tc.path, IsTemporal: true}
+		assert.Equal(t, info.RelativeTemporalLabel(now), tc.expected)
+	}
+}
+
+func TestFileInfo_RelativeTemporalLabel_NonTemporal(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	info := files.FileInfo{Path: "resources/looney.md", IsResource: true}
+	assert.Equal(t, info.RelativeTemporalLabel(now), "")
+
+	// Temporal directories (not month files) have no label
+	info = files.FileInfo{Path: "daily/2025", IsTemporal: true}
+	assert.Equal(t, info.RelativeTemporalLabel(now), "")
+}
@@ -1,14 +1,21 @@
 package files
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
+	"maps"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
 
 	"github.com/patrickward/padd/internal/contentutil"
 	"github.com/patrickward/padd/internal/crypto"
@@ -16,7 +23,10 @@ import (
 
 const emptyFilePath = "untitled"
 
-var fileExtensions = []string{".md", ".csv"}
+// archiveDirectory holds soft-deleted files, which are kept out of the index
+const archiveDirectory = "_archive"
+
+var fileExtensions = []string{".md", ".csv", ".tsv"}
 
 // FileRepository manages the core files and directories of the application.
 type FileRepository struct {
@@ -27,17 +37,56 @@ type FileRepository struct {
 	directoryTree     *DirectoryNode
 	fileIndex         map[string]FileInfo
 	encryptionManager *crypto.EncryptionManager
+	documentCache     *documentCache
+	searchIndex       *SearchIndex
+	versionStore      VersionStore
+	changeMu          sync.RWMutex
+	changeListeners   []func(ChangeEvent)
+	refreshListeners  []func(CacheRefresh)
+	logger            *slog.Logger
+	pathLocks         sync.Map // Per-path *sync.Mutex held while a document is written
+}
+
+// lockPath locks writes to the file at path and returns the function that unlocks them. Saves of the same file
+// hold it so that one can't land between another's check and write.
+func (fr *FileRepository) lockPath(path string) func() {
+	mu, _ := fr.pathLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
 }
 
 // FileConfig holds the configuration for core files and directories.
 type FileConfig struct {
-	CoreFiles           []string
-	ResourcesDirectory  string
-	DailyDirectory      string
-	JournalDirectory    string
-	temporalDirectories []string
+	CoreFiles            []string
+	ResourcesDirectory   string
+	DailyDirectory       string
+	JournalDirectory     string
+	UnicodeIDs           bool                // Preserve Unicode letters and digits in IDs instead of dropping them
+	NormalizeOnSave      bool                // Trim surrounding whitespace and end with a single newline on save; false writes content verbatim
+	ExcludeGlobs         []string            // Glob patterns for files to leave out of the index (e.g., "**/drafts/**", "*.tmp.md")
+	DonePlacement        DonePlacement       // Where to insert the @done tag when completing a task
+	DedupeKeepsCompleted bool                // Keep a completed duplicate over an earlier pending one when deduplicating tasks
+	EncryptedDirectories []string            // Directories (e.g., "resources/private") whose new files are encrypted by default
+	AutoCreatedAt        bool                // Stamp "created_at" frontmatter on new markdown files
+	AutoUpdatedAt        bool                // Stamp "updated_at" frontmatter on new markdown files and refresh it on every save
+	TimestampDirectories []string            // Directories (e.g., "resources") that AutoCreatedAt and AutoUpdatedAt are limited to; empty applies them everywhere
+	MaxDepth             int                 // Deepest directory level scanned, counted from the data directory ("resources/a/b.md" is 2); 0 is unlimited
+	ExcludeEncrypted     bool                // Leave encrypted files out of search even when identities are loaded to decrypt them
+	TemporalGranularity  TemporalGranularity // Whether daily and journal entries are kept in a file per month or per day
+	DocumentCacheSize    int                 // Most documents kept in the document cache; 0 uses the default of 1000
+	temporalDirectories  []string
 }
 
+// TemporalGranularity controls how much time each file in a temporal directory covers
+type TemporalGranularity int
+
+const (
+	// MonthlyFiles keeps a month of entries in each file (e.g., daily/2025/09-september.md)
+	MonthlyFiles TemporalGranularity = iota
+	// DailyFiles keeps a day of entries in each file (e.g., daily/2025/09/15.md)
+	DailyFiles
+)
+
 // TemporalDirectories returns the list of temporal directories (daily, journal).
 func (fc FileConfig) TemporalDirectories() []string {
 	return fc.temporalDirectories
@@ -49,6 +98,22 @@ var DefaultFileConfig = FileConfig{
 	ResourcesDirectory: "resources",
 	DailyDirectory:     "daily",
 	JournalDirectory:   "journal",
+	NormalizeOnSave:    true,
+	AutoCreatedAt:      true,
+}
+
+// validateDirectoryNames checks that the resources, daily, and journal directories are distinct directories at
+// the top of the data directory
+func validateDirectoryNames(names ...string) error {
+	for i, name := range names {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+			return fmt.Errorf("invalid directory %q: directories are named folders at the top of the data directory", name)
+		}
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("directory %q is used more than once", name)
+		}
+	}
+	return nil
 }
 
 // NewFileRepository creates a new instance of FileRepository with the given configuration.
@@ -59,14 +124,178 @@ func NewFileRepository(rootManager *RootManager, config FileConfig) *FileReposit
 		config:            config,
 		rootManager:       rootManager,
 		encryptionManager: crypto.NewEncryptionManager(),
+		documentCache:     newDocumentCache(config.DocumentCacheSize),
+		searchIndex:       NewSearchIndex(),
+		logger:            slog.Default(),
 	}
+	fr.SetEncryptedDirectories(config.EncryptedDirectories)
 
 	return fr
 }
 
+// SetConfig replaces the configuration of this FileRepository, including the encrypted directories. Call it
+// before Initialize and ReloadCaches, since the core files and directories it names are created there.
+func (fr *FileRepository) SetConfig(config FileConfig) error {
+	if len(config.CoreFiles) == 0 {
+		return fmt.Errorf("at least one core file is required")
+	}
+	for _, file := range config.CoreFiles {
+		if filepath.Ext(file) != ".md" || strings.Contains(file, "/") {
+			return fmt.Errorf("invalid core file %q: core files are markdown files in the data directory", file)
+		}
+	}
+	if config.TemporalGranularity != MonthlyFiles && config.TemporalGranularity != DailyFiles {
+		return fmt.Errorf("invalid temporal granularity: %d", config.TemporalGranularity)
+	}
+	if err := validateDirectoryNames(config.ResourcesDirectory, config.DailyDirectory, config.JournalDirectory); err != nil {
+		return err
+	}
+
+	config.temporalDirectories = []string{config.DailyDirectory, config.JournalDirectory}
+	fr.config = config
+	fr.SetEncryptedDirectories(config.EncryptedDirectories)
+	fr.documentCache = newDocumentCache(config.DocumentCacheSize)
+
+	return nil
+}
+
+// SetLogger sets the logger for this FileRepository, which defaults to slog.Default()
+func (fr *FileRepository) SetLogger(logger *slog.Logger) {
+	fr.logger = logger
+}
+
 // SetEncryptionManager sets the EncryptionManager for this FileRepository.
 func (fr *FileRepository) SetEncryptionManager(manager *crypto.EncryptionManager) {
 	fr.encryptionManager = manager
+	// Cached content may have been read with different identities
+	fr.documentCache = newDocumentCache(fr.config.DocumentCacheSize)
+}
+
+// ReloadEncryption drops document content cached with the previously loaded identities and reloads the
+// caches. Call it after identities are unlocked or locked, so documents are read and indexed with the
+// identities now loaded.
+func (fr *FileRepository) ReloadEncryption() {
+	fr.documentCache.clear()
+	fr.ReloadCaches()
+}
+
+// SetEncryptedDirectories sets the directories whose new files are encrypted by default
+func (fr *FileRepository) SetEncryptedDirectories(dirs []string) {
+	cleaned := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir = strings.Trim(strings.TrimSpace(dir), "/"); dir != "" {
+			cleaned = append(cleaned, filepath.Clean(dir))
+		}
+	}
+	fr.config.EncryptedDirectories = cleaned
+}
+
+// EncryptsByDefault returns true if a new file at path is in one of the EncryptedDirectories
+func (fr *FileRepository) EncryptsByDefault(path string) bool {
+	path = filepath.Clean(strings.TrimPrefix(path, "/"))
+	for _, dir := range fr.config.EncryptedDirectories {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stampsTimestamps returns true if the markdown file at path gets the frontmatter timestamps that
+// AutoCreatedAt and AutoUpdatedAt turn on, which is everywhere unless TimestampDirectories limits them
+func (fr *FileRepository) stampsTimestamps(path string) bool {
+	if !strings.HasSuffix(path, ".md") {
+		return false
+	}
+	if len(fr.config.TimestampDirectories) == 0 {
+		return true
+	}
+
+	path = filepath.Clean(strings.TrimPrefix(path, "/"))
+	for _, dir := range fr.config.TimestampDirectories {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetExcludeEncrypted sets whether encrypted files are left out of search even when they can be decrypted.
+// It takes effect the next time the caches are reloaded.
+func (fr *FileRepository) SetExcludeEncrypted(exclude bool) {
+	fr.config.ExcludeEncrypted = exclude
+}
+
+// CanDecrypt returns true if identities are loaded, so encrypted documents can be read
+func (fr *FileRepository) CanDecrypt() bool {
+	return fr.encryptionManager.IsActive() && fr.encryptionManager.HasIdentities()
+}
+
+// searchesEncrypted returns true if the content of encrypted files is added to the search index. Without
+// identities to decrypt them, encrypted files are never searchable, since their content is ciphertext.
+func (fr *FileRepository) searchesEncrypted() bool {
+	return fr.CanDecrypt() && !fr.config.ExcludeEncrypted
+}
+
+// CanEncrypt returns true if encryption keys are loaded, so encrypted documents can be written
+func (fr *FileRepository) CanEncrypt() bool {
+	return fr.encryptionManager.IsActive() && fr.encryptionManager.HasRecipients()
+}
+
+// timestampLayout is the time layout of the "created_at" and "updated_at" frontmatter stamped on files
+const timestampLayout = "2006-01-02 15:04:05"
+
+// CreateFile writes the initial content of a new file. Every file creation path goes through here, so new
+// markdown files get the same frontmatter: "created_at" and "updated_at" when AutoCreatedAt and AutoUpdatedAt
+// are set, and "encrypted: true" in one of the EncryptedDirectories. Encrypted files are encrypted on disk. If no encryption keys are loaded,
+// a warning is logged and the file is written as plaintext, still flagged so it's encrypted once keys are
+// available.
+func (fr *FileRepository) CreateFile(path, content string) error {
+	if !strings.HasSuffix(path, ".md") {
+		return fr.rootManager.WriteString(path, content)
+	}
+
+	content = fr.newFileContent(path, content)
+	if !fr.EncryptsByDefault(path) {
+		if err := fr.rootManager.WriteString(path, content); err != nil {
+			return err
+		}
+		fr.recordVersion("Create "+fr.CreateID(path), path)
+		return nil
+	}
+
+	if !fr.CanEncrypt() {
+		fr.logger.Warn("Encryption keys are not loaded, so the file is created unencrypted", "path", path)
+	}
+
+	doc := &Document{Info: FileInfo{Path: path}, repo: fr}
+
+	return doc.write(content)
+}
+
+// newFileContent adds the frontmatter of a new markdown file at path to its initial content. A
+// "created_at" value already in the content is kept.
+func (fr *FileRepository) newFileContent(path, content string) string {
+	lines := contentutil.SplitLines(content)
+
+	// Values are added at the top of the frontmatter, so "created_at" goes last to come first
+	now := time.Now().Format(timestampLayout)
+	if fr.config.AutoUpdatedAt && fr.stampsTimestamps(path) {
+		lines = contentutil.SetFrontmatterValue(lines, "updated_at", now)
+	}
+	if fr.config.AutoCreatedAt && fr.stampsTimestamps(path) {
+		if _, ok := contentutil.FrontmatterValue(lines, "created_at"); !ok {
+			lines = contentutil.SetFrontmatterValue(lines, "created_at", now)
+		}
+	}
+
+	if fr.EncryptsByDefault(path) {
+		lines = contentutil.SetFrontmatterValue(lines, "encrypted", "true")
+	}
+
+	return strings.Join(lines, "\n")
 }
 
 // EncryptionManager returns the EncryptionManager for this FileRepository.
@@ -185,6 +414,24 @@ func (fr *FileRepository) FileIDExists(id string) bool {
 	return exists
 }
 
+// Breadcrumbs returns the breadcrumb parts for a file, resolving the title of each segment
+// whose path prefix is a file in the index. Directory segments are left without a title.
+func (fr *FileRepository) Breadcrumbs(info FileInfo) []Breadcrumb {
+	breadcrumbs := info.BreadcrumbParts()
+
+	fr.cacheMux.RLock()
+	defer fr.cacheMux.RUnlock()
+
+	for i, crumb := range breadcrumbs {
+		id := fr.CreateID(strings.TrimPrefix(crumb.Path, "/"))
+		if file, ok := fr.fileIndex[id]; ok {
+			breadcrumbs[i].Title = file.TitleBase
+		}
+	}
+
+	return breadcrumbs
+}
+
 // FilePathExists checks if a file with the given path exists in either core, resources, or temporal files.
 func (fr *FileRepository) FilePathExists(path string) bool {
 	return fr.rootManager.FileExists(path)
@@ -192,26 +439,32 @@ func (fr *FileRepository) FilePathExists(path string) bool {
 
 // ReloadCaches refreshes both the core files and resource caches.
 func (fr *FileRepository) ReloadCaches() {
+	start := time.Now()
 	fr.cacheMux.Lock()
-	defer fr.cacheMux.Unlock()
 
-	tree, index := fr.buildDirectoryTree(".")
+	search := NewSearchIndex()
+	tree, index := fr.buildDirectoryTree(".", search)
 	fr.directoryTree = tree
 	fr.fileIndex = index
+	fr.searchIndex.replaceWith(search)
 	fr.lastCacheTime = time.Now()
-	log.Printf("Cache refreshed with %d files", len(fr.fileIndex))
-	log.Println("Cache:")
-	fr.printDirectoryTree(tree, "  ")
+	fr.logger.Debug("Cache refreshed", "files", len(fr.fileIndex))
+	if fr.logger.Enabled(context.Background(), slog.LevelDebug) {
+		fr.printDirectoryTree(tree, "  ")
+	}
+
+	fr.cacheMux.Unlock()
+	fr.notifyCacheRefresh(CacheRefresh{Files: len(index), Duration: time.Since(start)})
 }
 
-// printDirectoryTree prints the directory tree to a log.
+// printDirectoryTree logs the directory tree at the debug level.
 func (fr *FileRepository) printDirectoryTree(tree *DirectoryNode, indent string) {
 	for _, file := range tree.Files {
-		log.Printf("%sFile: %s", indent, file.Path)
+		fr.logger.Debug(indent + "File: " + file.Path)
 	}
 
 	for _, dir := range tree.Directories {
-		log.Printf("%sDirectory: %s", indent, dir.Name)
+		fr.logger.Debug(indent + "Directory: " + dir.Name)
 		fr.printDirectoryTree(dir, indent+"  ")
 	}
 }
@@ -229,7 +482,7 @@ func (fr *FileRepository) ReloadResources() {
 	// Otherwise, find the resource directory in the DirectoryNode tree if it exists
 	_, ok := fr.directoryTree.Directories[fr.config.ResourcesDirectory]
 	if !ok {
-		log.Printf("Resource directory not found in tree, reloading all caches")
+		fr.logger.Debug("Resource directory not found in tree, creating it")
 		//fr.ReloadCaches()
 		// Create it
 		fr.directoryTree.Directories[fr.config.ResourcesDirectory] = &DirectoryNode{
@@ -240,9 +493,9 @@ func (fr *FileRepository) ReloadResources() {
 	}
 
 	// Now, build the directory for the resources directory
-	tree, index := fr.buildDirectoryTree(fr.config.ResourcesDirectory)
+	tree, index := fr.buildDirectoryTree(fr.config.ResourcesDirectory, fr.searchIndex)
 	if tree == nil {
-		log.Printf("Error building directory tree for resources directory, reloading all caches")
+		fr.logger.Warn("Error building directory tree for resources directory, reloading all caches")
 		fr.ReloadCaches()
 		return
 	}
@@ -252,7 +505,7 @@ func (fr *FileRepository) ReloadResources() {
 	if _, ok := tree.Directories[fr.config.ResourcesDirectory]; ok {
 		fr.directoryTree.Directories[fr.config.ResourcesDirectory] = tree.Directories[fr.config.ResourcesDirectory]
 	} else {
-		log.Printf("Error replacing resources directory in directory tree, reloading all caches")
+		fr.logger.Warn("Error replacing resources directory in directory tree, reloading all caches")
 		fr.ReloadCaches()
 		return
 	}
@@ -263,7 +516,7 @@ func (fr *FileRepository) ReloadResources() {
 	}
 
 	fr.lastCacheTime = time.Now()
-	log.Printf("Resource cache refreshed with %d files", len(fr.fileIndex))
+	fr.logger.Debug("Resource cache refreshed", "files", len(fr.fileIndex))
 }
 
 // ReloadResourcesIfStale refreshes the resource cache if it is older than the specified duration.
@@ -289,7 +542,7 @@ func (fr *FileRepository) DirectoryTreeFor(directory string) *DirectoryNode {
 	}
 
 	if fr.directoryTree == nil {
-		log.Printf("Directory tree is nil, returning empty tree")
+		fr.logger.Debug("Directory tree is nil, returning empty tree")
 		return emptyTree
 	}
 
@@ -380,8 +633,10 @@ func (fr *FileRepository) fileInfoFromPath(path string) FileInfo {
 }
 
 // normalizeFileName creates a URL-safe, consistent filename/path
-// NOTE: This is obviously not perfect and could be improved for internationalization, etc.
-// It's also not guaranteed to be unique, so collisions should be handled at a higher level if needed.
+// By default, only ASCII letters and digits are kept. If FileConfig.UnicodeIDs is enabled, Unicode letters,
+// digits, and combining marks are kept as well (e.g., "café" stays "café"), and are percent-encoded in URLs as
+// needed. Names are normalized to NFC first, so composed and decomposed spellings get the same ID.
+// It's not guaranteed to be unique, so collisions should be handled at a higher level if needed.
 func (fr *FileRepository) normalizeFileName(path string) string {
 	// Handle empty path
 	if path == "" {
@@ -391,8 +646,8 @@ func (fr *FileRepository) normalizeFileName(path string) string {
 	// Strip any .md extension
 	path = strings.TrimSuffix(path, ".md")
 
-	// Convert to lowercase for consistency
-	normalized := strings.ToLower(path)
+	// Compose characters and convert to lowercase for consistency
+	normalized := strings.ToLower(norm.NFC.String(path))
 
 	// Always use forward slashes for URLs
 	normalized = strings.ReplaceAll(normalized, string(filepath.Separator), "/")
@@ -412,6 +667,8 @@ func (fr *FileRepository) normalizeFileName(path string) string {
 		switch {
 		case (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9'):
 			result.WriteRune(char)
+		case fr.config.UnicodeIDs && (unicode.IsLetter(char) || unicode.IsDigit(char) || unicode.In(char, unicode.Mn, unicode.Mc)):
+			result.WriteRune(char)
 		case char == '-' || char == '.' || char == '/':
 			result.WriteRune(char)
 		default:
@@ -456,17 +713,44 @@ func (fr *FileRepository) normalizeFileName(path string) string {
 
 // GetDocument retrieves a document by ID
 func (fr *FileRepository) GetDocument(id string) (*Document, error) {
+	if IsSidecar(id) {
+		return nil, fmt.Errorf("file %s is a metadata sidecar", id)
+	}
+
 	info, err := fr.FileInfo(id)
 	if err != nil {
 		return nil, err
 	}
 
+	if IsSidecar(info.Path) {
+		return nil, fmt.Errorf("file %s is a metadata sidecar", id)
+	}
+
 	return &Document{
 		Info: info,
 		repo: fr,
 	}, nil
 }
 
+// GetDocumentByPath retrieves a document by its relative path without consulting the index, so files left
+// out of the index (e.g., by ExcludeGlobs) can still be read directly.
+func (fr *FileRepository) GetDocumentByPath(path string) (*Document, error) {
+	path = filepath.Clean(strings.TrimPrefix(path, "/"))
+
+	if IsSidecar(path) {
+		return nil, fmt.Errorf("file %s is a metadata sidecar", path)
+	}
+
+	if !fr.rootManager.FileExists(path) {
+		return nil, fmt.Errorf("file %s not found", path)
+	}
+
+	return &Document{
+		Info: fr.fileInfoFromPath(path),
+		repo: fr,
+	}, nil
+}
+
 // GetCSVDocument retrieves a CSV document by ID
 func (fr *FileRepository) GetCSVDocument(id string) (*CSVDocument, error) {
 	doc, err := fr.GetDocument(id)
@@ -474,7 +758,7 @@ func (fr *FileRepository) GetCSVDocument(id string) (*CSVDocument, error) {
 		return nil, err
 	}
 
-	if !strings.HasSuffix(doc.Info.Path, ".csv") {
+	if !doc.Info.IsCSV() {
 		return nil, fmt.Errorf("file is not a CSV file")
 	}
 
@@ -485,19 +769,23 @@ func (fr *FileRepository) GetCSVDocument(id string) (*CSVDocument, error) {
 // If the file doesn't exist, it will be created with default content. Files are always created
 // in the ResourcesDirectory. You can omit the ResourcesDirectory prefix in the ID and it will be
 // automatically added. Similarly, you can omit the .md extension and it will be added.
-func (fr *FileRepository) GetOrCreateResourceDocument(id string) (*Document, error) {
+//
+// A new file is seeded from templateName in the templates directory when one is given (see NoteTemplates),
+// with the file's title, today's date, and tags available to the template. An existing file is returned
+// as is.
+func (fr *FileRepository) GetOrCreateResourceDocument(id, templateName string, tags []string) (*Document, error) {
 	// Ensure the file is in the resources directory
 	if !strings.HasPrefix(id, fr.Config().ResourcesDirectory+"/") {
 		id = fr.Config().ResourcesDirectory + "/" + id
 	}
 
-	return fr.getOrCreateDocument(id)
+	return fr.getOrCreateDocument(id, templateName, tags)
 }
 
 // getOrCreateDocument gets or creates a document for a file, based on its ID.
-// If the file doesn't exist, it will be created with default content.'
-func (fr *FileRepository) getOrCreateDocument(id string) (*Document, error) {
-	info, err := fr.FileInfo(id)
+// If the file doesn't exist, it will be created with default content, or from templateName if given.
+func (fr *FileRepository) getOrCreateDocument(id, templateName string, tags []string) (*Document, error) {
+	info, err := fr.FileInfo(fr.CreateID(id))
 	if err == nil {
 		return &Document{
 			Info: info,
@@ -513,6 +801,14 @@ func (fr *FileRepository) getOrCreateDocument(id string) (*Document, error) {
 		path += ".md"
 	}
 
+	defaultContent := "# " + filepath.Base(path) + "\n\n"
+	if templateName != "" {
+		defaultContent, err = fr.noteTemplateContent(templateName, path, tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// First, get the directory
 	directory := filepath.Dir(path)
 	if directory == "." {
@@ -525,8 +821,7 @@ func (fr *FileRepository) getOrCreateDocument(id string) (*Document, error) {
 	}
 
 	// Create the file
-	defaultContent := []byte("# " + filepath.Base(path) + "\n\n")
-	if err := fr.rootManager.WriteFile(path, defaultContent, 0644); err != nil {
+	if err := fr.CreateFile(path, defaultContent); err != nil {
 		return nil, fmt.Errorf("error creating file: %w", err)
 	}
 
@@ -534,7 +829,7 @@ func (fr *FileRepository) getOrCreateDocument(id string) (*Document, error) {
 	fr.ReloadResources()
 
 	// Get the file info again
-	info, err = fr.FileInfo(id)
+	info, err = fr.FileInfo(fr.CreateID(path))
 	if err != nil {
 		return nil, fmt.Errorf("error getting file info: %w", err)
 	}
@@ -545,17 +840,336 @@ func (fr *FileRepository) getOrCreateDocument(id string) (*Document, error) {
 	}, nil
 }
 
-// TemporalFileInfo retrieves or constructs a FileInfo for a temporal file based on type and date. If not
-// found, the file will be created and returned.
+// MoveToDirectory moves a resource file into another directory, keeping its filename. The target directory
+// may be given with or without the ResourcesDirectory prefix, must stay within the resources directory, and is
+// created if needed. CSV metadata sidecars are moved along with their CSV file. It returns the moved document.
+func (fr *FileRepository) MoveToDirectory(id, targetDir string) (*Document, error) {
+	info, err := fr.FileInfo(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDirectory || !info.IsResource {
+		return nil, fmt.Errorf("only resource files can be moved: %s", id)
+	}
+
+	targetDir, err = fr.resolveResourceDirectory(targetDir)
+	if err != nil {
+		return nil, err
+	}
+
+	newPath := filepath.Join(targetDir, filepath.Base(info.Path))
+	if newPath == info.Path {
+		return nil, fmt.Errorf("file %s is already in directory %s", id, targetDir)
+	}
+
+	if fr.rootManager.FileExists(newPath) {
+		return nil, fmt.Errorf("file %s already exists", newPath)
+	}
+
+	if err := fr.rootManager.MkdirAll(targetDir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory %s: %w", targetDir, err)
+	}
+
+	if err := fr.moveFile(info.Path, newPath); err != nil {
+		return nil, err
+	}
+
+	// A full reload drops the stale index entry for the old path
+	fr.ReloadCaches()
+
+	return fr.GetDocument(fr.CreateID(newPath))
+}
+
+// MoveDocument renames or moves a resource file to newID (e.g., "resources/projects/plan" or "projects/plan"),
+// keeping its extension. The new location must stay within the resources directory, and its directories are
+// created if needed. Wiki links and markdown links in other markdown files that point at the old file are
+// rewritten to point at the new one, as are the moved file's own relative markdown links. Locked documents
+// are left as they are. It returns the moved document and the IDs of the locked documents whose links were
+// not rewritten.
+func (fr *FileRepository) MoveDocument(oldID, newID string) (*Document, []string, error) {
+	info, err := fr.FileInfo(oldID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDirectory || !info.IsResource {
+		return nil, nil, fmt.Errorf("only resource files can be moved: %s", oldID)
+	}
+
+	ext := filepath.Ext(info.Path)
+	newID = strings.TrimSuffix(strings.TrimSpace(newID), ext)
+	if strings.Trim(newID, "/") == "" {
+		return nil, nil, fmt.Errorf("new name cannot be empty")
+	}
+
+	targetDir, err := fr.resolveResourceDirectory(path.Dir(strings.Trim(newID, "/")))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newPath := filepath.Join(targetDir, path.Base(newID)+ext)
+	if newPath == info.Path {
+		return nil, nil, fmt.Errorf("file %s is already at %s", oldID, newPath)
+	}
+
+	if fr.FileIDExists(fr.CreateID(newPath)) || fr.rootManager.FileExists(newPath) {
+		return nil, nil, fmt.Errorf("file %s already exists", newPath)
+	}
+
+	// Rewrite links while the old path still resolves, then save them once the file has moved
+	rewrites, err := fr.linkRewritesForMove(info, newPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := fr.rootManager.MkdirAll(targetDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("error creating directory %s: %w", targetDir, err)
+	}
+
+	if err := fr.moveFile(info.Path, newPath); err != nil {
+		return nil, nil, err
+	}
+
+	fr.ReloadCaches()
+
+	var locked []string
+	for _, linkPath := range slices.Sorted(maps.Keys(rewrites)) {
+		doc, err := fr.GetDocumentByPath(linkPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error updating links in %s: %w", linkPath, err)
+		}
+		if err := doc.Save(rewrites[linkPath]); errors.Is(err, ErrDocumentLocked) {
+			locked = append(locked, doc.Info.ID)
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("error updating links in %s: %w", linkPath, err)
+		}
+	}
+
+	doc, err := fr.GetDocument(fr.CreateID(newPath))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return doc, locked, nil
+}
+
+// moveFile renames a file, keeping a CSV metadata sidecar next to its CSV file, and drops the old path
+// from the document cache. The caller is responsible for creating the target directory and reloading
+// the caches.
+func (fr *FileRepository) moveFile(oldPath, newPath string) error {
+	if err := fr.rootManager.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("error moving file %s: %w", oldPath, err)
+	}
+	fr.documentCache.invalidate(oldPath)
+	moved := []string{oldPath, newPath}
+
+	sidecarPath := oldPath + SidecarSuffix
+	if (FileInfo{Path: oldPath}).IsCSV() && fr.rootManager.FileExists(sidecarPath) {
+		if err := fr.rootManager.Rename(sidecarPath, newPath+SidecarSuffix); err != nil {
+			return fmt.Errorf("error moving metadata file %s: %w", sidecarPath, err)
+		}
+		moved = append(moved, sidecarPath, newPath+SidecarSuffix)
+	}
+
+	fr.notifyChange(ChangeEvent{Operation: ChangeRename, ID: fr.CreateID(newPath), OldID: fr.CreateID(oldPath)})
+	fr.recordVersion(fmt.Sprintf("Move %s to %s", fr.CreateID(oldPath), fr.CreateID(newPath)), moved...)
+
+	return nil
+}
+
+// ArchiveDocument soft-deletes a file by moving it (and any CSV metadata sidecar) under the archive
+// directory, preserving its relative path. Archived files are left out of the index, so they don't show up
+// in listings, navigation, or search, but can be restored with UnarchiveDocument. Core files can't be archived.
+func (fr *FileRepository) ArchiveDocument(id string) error {
+	info, err := fr.FileInfo(id)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDirectory || slices.Contains(fr.config.CoreFiles, info.Path) {
+		return fmt.Errorf("file %s can't be archived", id)
+	}
+
+	archivePath := filepath.Join(archiveDirectory, info.Path)
+	if fr.rootManager.FileExists(archivePath) {
+		return fmt.Errorf("archived file %s already exists", archivePath)
+	}
+
+	if err := fr.rootManager.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return fmt.Errorf("error creating directory %s: %w", filepath.Dir(archivePath), err)
+	}
+
+	if err := fr.moveFile(info.Path, archivePath); err != nil {
+		return err
+	}
+
+	fr.ReloadCaches()
+	return nil
+}
+
+// UnarchiveDocument restores an archived file to its original location. The id is the ID the file had
+// before it was archived.
+func (fr *FileRepository) UnarchiveDocument(id string) error {
+	archived, err := fr.ArchivedDocuments()
+	if err != nil {
+		return err
+	}
+
+	for _, info := range archived {
+		if info.ID != id {
+			continue
+		}
+
+		if fr.rootManager.FileExists(info.Path) {
+			return fmt.Errorf("file %s already exists", info.Path)
+		}
+
+		if err := fr.rootManager.MkdirAll(filepath.Dir(info.Path), 0755); err != nil {
+			return fmt.Errorf("error creating directory %s: %w", filepath.Dir(info.Path), err)
+		}
+
+		if err := fr.moveFile(filepath.Join(archiveDirectory, info.Path), info.Path); err != nil {
+			return err
+		}
+
+		fr.ReloadCaches()
+		return nil
+	}
+
+	return fmt.Errorf("archived file %s not found", id)
+}
+
+// ErrDirectoryNotEmpty is returned when deleting a directory that still contains files
+var ErrDirectoryNotEmpty = errors.New("directory is not empty")
+
+// DeleteDocument permanently deletes a resource or temporal file, along with any CSV metadata sidecar, and
+// drops it from the caches. Core files and directories can't be deleted this way.
+func (fr *FileRepository) DeleteDocument(id string) error {
+	info, err := fr.FileInfo(id)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDirectory || slices.Contains(fr.config.CoreFiles, info.Path) {
+		return fmt.Errorf("file %s can't be deleted", id)
+	}
+
+	doc := &Document{Info: info, repo: fr}
+	if err := doc.Delete(); err != nil {
+		return fmt.Errorf("error deleting file %s: %w", info.Path, err)
+	}
+
+	sidecarPath := info.Path + SidecarSuffix
+	if info.IsCSV() && fr.rootManager.FileExists(sidecarPath) {
+		if err := fr.rootManager.Remove(sidecarPath); err != nil {
+			return fmt.Errorf("error deleting metadata file %s: %w", sidecarPath, err)
+		}
+		fr.recordVersion("Delete "+id+" metadata", sidecarPath)
+	}
+
+	fr.ReloadCaches()
+	return nil
+}
+
+// DeleteDirectory deletes a directory under the resources directory that holds no files, including any
+// empty subdirectories, and drops it from the caches. The directory is looked up on disk, since directories
+// without files aren't in the index. It returns ErrDirectoryNotEmpty if any file is left anywhere inside it,
+// so documents are never deleted along with their directory.
+func (fr *FileRepository) DeleteDirectory(id string) error {
+	dir, err := fr.resolveResourceDirectory(id)
+	if err != nil {
+		return err
+	}
+
+	if dir == fr.config.ResourcesDirectory {
+		return fmt.Errorf("directory %s can't be deleted", id)
+	}
+
+	if stat, err := fr.rootManager.Stat(dir); err != nil || !stat.IsDir() {
+		return fmt.Errorf("directory %s not found", id)
+	}
+
+	hasFiles := false
+	err = fr.rootManager.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			hasFiles = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+	if hasFiles {
+		return fmt.Errorf("%w: %s", ErrDirectoryNotEmpty, id)
+	}
+
+	if err := fr.rootManager.RemoveAll(dir); err != nil {
+		return fmt.Errorf("error deleting directory %s: %w", dir, err)
+	}
+
+	fr.ReloadCaches()
+	return nil
+}
+
+// ArchivedDocuments lists the archived files. Each FileInfo describes the file as it was before archiving,
+// so its ID and Path are the ones UnarchiveDocument restores it to.
+func (fr *FileRepository) ArchivedDocuments() ([]FileInfo, error) {
+	results, err := fr.rootManager.Scan(archiveDirectory, func(path string, d fs.DirEntry) bool {
+		return !d.IsDir() && !IsSidecar(path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error scanning archive: %w", err)
+	}
+
+	archived := make([]FileInfo, 0, len(results))
+	for _, result := range results {
+		archived = append(archived, fr.fileInfoFromPath(result.RelativePath))
+	}
+
+	return archived, nil
+}
+
+// resolveResourceDirectory cleans a directory path and ensures it is within the resources directory.
+// Paths without the ResourcesDirectory prefix are treated as relative to it.
+func (fr *FileRepository) resolveResourceDirectory(dir string) (string, error) {
+	resourcesDir := fr.config.ResourcesDirectory
+	cleaned := filepath.Clean(strings.Trim(strings.TrimSpace(dir), "/"))
+
+	if cleaned != resourcesDir && !strings.HasPrefix(cleaned, resourcesDir+"/") {
+		cleaned = filepath.Join(resourcesDir, cleaned)
+	}
+
+	if cleaned != resourcesDir && !strings.HasPrefix(cleaned, resourcesDir+"/") {
+		return "", fmt.Errorf("directory %s is outside the resources directory", dir)
+	}
+
+	return cleaned, nil
+}
+
+// TemporalFileInfo constructs a FileInfo for the temporal file covering the given date: a monthly file, or a
+// daily file when the TemporalGranularity is DailyFiles. It also reports whether the file exists.
 func (fr *FileRepository) TemporalFileInfo(fileType string, timestamp time.Time) (FileInfo, bool) {
 	year := timestamp.Format("2006")
 	month := timestamp.Format("01-January")
 
 	dirPath := strings.ToLower(filepath.Join(fileType, year))
 	filePath := strings.ToLower(filepath.Join(dirPath, month+".md"))
+	displayName := fmt.Sprintf("%s %d", timestamp.Format("January"), timestamp.Year())
+	directoryPath := fileType + "/" + year
+
+	if fr.config.TemporalGranularity == DailyFiles {
+		dirPath = filepath.Join(fileType, year, timestamp.Format("01"))
+		filePath = filepath.Join(dirPath, timestamp.Format("02")+".md")
+		displayName = timestamp.Format("Monday, January 2, 2006")
+		directoryPath = dirPath
+	}
 
 	id := fr.CreateID(filePath)
-	displayName := fmt.Sprintf("%s %d", timestamp.Format("January"), timestamp.Year())
 
 	info := FileInfo{
 		ID:            id,
@@ -563,7 +1177,7 @@ func (fr *FileRepository) TemporalFileInfo(fileType string, timestamp time.Time)
 		Title:         displayName,
 		TitleBase:     displayName,
 		IsTemporal:    true,
-		DirectoryPath: fileType + "/" + timestamp.Format("2006"),
+		DirectoryPath: directoryPath,
 	}
 
 	found := fr.rootManager.FileExists(filePath)
@@ -571,6 +1185,93 @@ func (fr *FileRepository) TemporalFileInfo(fileType string, timestamp time.Time)
 	return info, found
 }
 
+// TemporalActivity counts the entries per day for a temporal directory (e.g., "daily") over a year, keyed by
+// "2006-01-02". Each "### " heading under a day header counts as one entry; a day with content but no
+// entry headings counts as one. Months, or days with DailyFiles, without a file are skipped.
+func (fr *FileRepository) TemporalActivity(fileType string, year int) (map[string]int, error) {
+	if !slices.Contains(fr.config.temporalDirectories, fileType) {
+		return nil, fmt.Errorf("%s is not a temporal directory", fileType)
+	}
+
+	activity := make(map[string]int)
+	for date := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC); date.Year() == year; date = fr.nextTemporalFile(date) {
+		info, found := fr.TemporalFileInfo(fileType, date)
+		if !found {
+			continue
+		}
+
+		doc, err := fr.GetDocumentByPath(info.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := doc.Content()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", info.Path, err)
+		}
+
+		countDayEntries(contentutil.SplitLines(content), activity)
+	}
+
+	return activity, nil
+}
+
+// nextTemporalFile returns the start of the period covered by the temporal file after the one for date
+func (fr *FileRepository) nextTemporalFile(date time.Time) time.Time {
+	if fr.config.TemporalGranularity == DailyFiles {
+		return date.AddDate(0, 0, 1)
+	}
+	return date.AddDate(0, 1, 0)
+}
+
+// countDayEntries adds the entry counts of each day section in lines to activity. Any H2 that is not a day
+// header ends the current day section.
+func countDayEntries(lines []string, activity map[string]int) {
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		start = bounds.End
+	}
+
+	day := ""
+	entries, hasContent := 0, false
+	flush := func() {
+		if day == "" {
+			return
+		}
+		if entries == 0 && hasContent {
+			entries = 1
+		}
+		if entries > 0 {
+			activity[day] += entries
+		}
+		day, entries, hasContent = "", 0, false
+	}
+
+	for _, line := range lines[start:] {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "## ") || strings.HasPrefix(trimmed, "# ") {
+			flush()
+			if date, ok := parseDayHeader(trimmed); ok {
+				day = date.Format("2006-01-02")
+			}
+			continue
+		}
+
+		if day == "" || trimmed == "" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "### ") {
+			entries++
+		} else {
+			hasContent = true
+		}
+	}
+
+	flush()
+}
+
 // GetOrCreateTemporalDocument gets or creates a document for a temporal file
 func (fr *FileRepository) GetOrCreateTemporalDocument(directory string, date time.Time) (*Document, error) {
 	info, found := fr.TemporalFileInfo(directory, date)
@@ -582,9 +1283,13 @@ func (fr *FileRepository) GetOrCreateTemporalDocument(directory string, date tim
 			return nil, fmt.Errorf("failed to create directory %s: %w", dirPath, err)
 		}
 
-		//content := fmt.Sprintf("# %s\n\n", timestamp.Format("January 2006"))
-		err := fr.rootManager.WriteString(info.Path, "\n")
-		if err != nil {
+		// Start the file with the date's day section when the directory has a day template
+		content := "\n"
+		if section := fr.daySectionTemplate(info.Path, date); section != nil {
+			content = "\n" + strings.Join(append([]string{"## " + date.Format(dayHeaderLayout)}, section...), "\n") + "\n"
+		}
+
+		if err := fr.CreateFile(info.Path, content); err != nil {
 			return nil, fmt.Errorf("failed to create file %s: %w", info.Path, err)
 		}
 	}
@@ -595,6 +1300,25 @@ func (fr *FileRepository) GetOrCreateTemporalDocument(directory string, date tim
 	}, nil
 }
 
+// AddTemporalEntry adds a timestamp-ordered entry to the file of a temporal directory (e.g., "daily") that
+// covers the entry's timestamp, creating the file if needed. It returns the document the entry was added to.
+func (fr *FileRepository) AddTemporalEntry(directory, entry string, config EntryInsertionConfig) (*Document, error) {
+	// Pin the timestamp so the file lookup and the day header agree
+	config.EntryTimestamp = config.Timestamp()
+	config.Strategy = InsertByTimestamp
+
+	doc, err := fr.GetOrCreateTemporalDocument(directory, config.EntryTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.AddEntry(entry, config); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
 func (fr *FileRepository) DirectoryTree() *DirectoryNode {
 	fr.cacheMux.RLock()
 	defer fr.cacheMux.RUnlock()
@@ -603,8 +1327,9 @@ func (fr *FileRepository) DirectoryTree() *DirectoryNode {
 
 // buildDirectoryTree builds a directory tree from the root of the data directory. If the
 // directory is empty, it will use the root of the data directory.
-// It returns the root node and a map of all files in the tree, keyed by ID.
-func (fr *FileRepository) buildDirectoryTree(directory string) (*DirectoryNode, map[string]FileInfo) {
+// It returns the root node and a map of all files in the tree, keyed by ID. The content of each file is
+// added to the search index.
+func (fr *FileRepository) buildDirectoryTree(directory string, search *SearchIndex) (*DirectoryNode, map[string]FileInfo) {
 	if directory == "" {
 		directory = "."
 	}
@@ -617,6 +1342,7 @@ func (fr *FileRepository) buildDirectoryTree(directory string) (*DirectoryNode,
 
 	index := make(map[string]FileInfo)
 
+	tooDeep := 0
 	results, err := fr.rootManager.Scan(directory, func(path string, d fs.DirEntry) bool {
 		// Skip directories and non-markdown files
 		//if d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
@@ -628,28 +1354,33 @@ func (fr *FileRepository) buildDirectoryTree(directory string) (*DirectoryNode,
 			return false
 		}
 
-		// Skip files that do not have one of the valid file extensions
-		ext := strings.ToLower(filepath.Ext(d.Name()))
-		if !slices.Contains(fileExtensions, ext) {
+		if !fr.isIndexableFile(path) {
 			return false
 		}
 
-		// Skip hidden files and temp files
-		if strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "~") {
+		// Skip files nested deeper than the maximum depth
+		if fr.isTooDeep(path) {
+			tooDeep++
 			return false
 		}
 
 		return true
 	})
 
+	if tooDeep > 0 {
+		fr.logger.Warn("Skipped files deeper than the maximum depth", "files", tooDeep, "max_depth", fr.config.MaxDepth)
+	}
+
 	if err != nil {
-		log.Printf("Error scanning resources directory: %v", err)
+		fr.logger.Error("Error scanning resources directory", "error", err)
 		return root, index
 	}
 
 	// Process each file and add to the tree and index
 	for _, result := range results {
 		fileInfo := fr.fileInfoFromPath(result.Path)
+		fileInfo.applyScan(fr.scanFile(result.Path, fileInfo.ID, search))
+		fileInfo.IsDraft = strings.EqualFold(fileInfo.Frontmatter["draft"], "true")
 		fr.addFileToTree(root, fileInfo)
 		index[fileInfo.ID] = fileInfo
 	}
@@ -657,6 +1388,191 @@ func (fr *FileRepository) buildDirectoryTree(directory string) (*DirectoryNode,
 	return root, index
 }
 
+// markdownScan holds what a single pass over a markdown file's lines finds
+type markdownScan struct {
+	frontmatter map[string]string
+	stats       TaskCounts
+	tags        []string
+	links       FileLinks
+	tasks       []FileTask
+}
+
+// scanContent collects the frontmatter values and tags, tasks, task counts, and outbound links of markdown
+// content
+func scanContent(content string) markdownScan {
+	lines := contentutil.SplitLines(content)
+
+	return markdownScan{
+		frontmatter: contentutil.FrontmatterValues(lines),
+		tags:        frontmatterFromLines(lines).GetList("tags"),
+		stats:       countTaskLines(lines),
+		links:       extractLinks(lines),
+		tasks:       scanTasks(lines),
+	}
+}
+
+// scanFile reads the file at path once to add it to the search index and, for markdown files, collect its
+// frontmatter values, tasks, and links. Encrypted files are neither indexed nor scanned, since they can't be
+// read without decrypting them.
+func (fr *FileRepository) scanFile(path, id string, search *SearchIndex) markdownScan {
+	content, err := fr.rootManager.ReadFile(path)
+	if err != nil {
+		return markdownScan{}
+	}
+
+	// Encrypted files are searchable once decrypted, but aren't scanned, so their tasks and links stay private
+	if crypto.IsAgeEncrypted(content) {
+		if !fr.searchesEncrypted() {
+			return markdownScan{}
+		}
+
+		decrypted, err := fr.encryptionManager.Decrypt(content)
+		if err != nil {
+			fr.logger.Warn("Error decrypting file for search", "path", path, "error", err)
+			return markdownScan{}
+		}
+
+		search.Update(id, decrypted)
+		return markdownScan{}
+	}
+
+	search.Update(id, string(content))
+	if !strings.HasSuffix(path, ".md") {
+		return markdownScan{}
+	}
+
+	return scanContent(string(content))
+}
+
+// updateScanResults replaces the cached scan results of the file at path in the index and directory tree
+func (fr *FileRepository) updateScanResults(path string, scan markdownScan) {
+	id := fr.CreateID(path)
+
+	fr.cacheMux.Lock()
+	defer fr.cacheMux.Unlock()
+
+	info, ok := fr.fileIndex[id]
+	if !ok {
+		return
+	}
+	info.applyScan(scan)
+	fr.fileIndex[id] = info
+
+	if fr.directoryTree == nil {
+		return
+	}
+
+	// Callers keep reading the tree after the lock is released, so the file is updated in a copy that replaces it
+	directory := strings.TrimPrefix(filepath.ToSlash(info.DirectoryPath), ".")
+	fr.directoryTree = fr.directoryTree.withFileUpdate(directory, id, func(file *FileInfo) {
+		file.applyScan(scan)
+	})
+}
+
+// DistinctMetadataValues returns how many files use each value of the given frontmatter key (e.g., status,
+// priority, category, or author), read from the cached frontmatter. Values are counted as written, so
+// "Done" and "done" are counted separately.
+func (fr *FileRepository) DistinctMetadataValues(key string) (map[string]int, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("metadata key cannot be empty")
+	}
+
+	fr.cacheMux.RLock()
+	defer fr.cacheMux.RUnlock()
+
+	counts := make(map[string]int)
+	for _, info := range fr.fileIndex {
+		if value, ok := info.Frontmatter[key]; ok {
+			counts[value]++
+		}
+	}
+
+	return counts, nil
+}
+
+// isIndexableFile returns true if the file at path belongs in the index, ignoring the maximum depth
+func (fr *FileRepository) isIndexableFile(path string) bool {
+	// Skip metadata sidecar files
+	if IsSidecar(path) {
+		return false
+	}
+
+	// Skip files that do not have one of the valid file extensions
+	name := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(name))
+	if !slices.Contains(fileExtensions, ext) {
+		return false
+	}
+
+	// Skip hidden files and temp files
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") {
+		return false
+	}
+
+	// Skip files matching an exclusion glob
+	return !fr.isExcluded(path)
+}
+
+// isTooDeep returns true if the path is nested deeper than the configured MaxDepth
+func (fr *FileRepository) isTooDeep(path string) bool {
+	return fr.config.MaxDepth > 0 && strings.Count(filepath.ToSlash(path), "/") > fr.config.MaxDepth
+}
+
+// isExcluded returns true if the path is archived or matches one of the configured ExcludeGlobs
+func (fr *FileRepository) isExcluded(path string) bool {
+	if strings.HasPrefix(filepath.ToSlash(path), archiveDirectory+"/") {
+		return true
+	}
+
+	for _, pattern := range fr.config.ExcludeGlobs {
+		if matchGlob(pattern, filepath.ToSlash(path)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob matches a slash-separated path against a glob pattern. A "**" segment matches zero or more
+// path segments, and other segments use filepath.Match. Patterns without a slash match the file name
+// at any depth, so "*.tmp.md" excludes temp files in every directory.
+func matchGlob(pattern, path string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := filepath.Match(pattern, filepath.Base(path))
+		return matched
+	}
+
+	return matchGlobSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchGlobSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	if pattern[0] == "**" {
+		// Try consuming zero or more path segments
+		for i := 0; i <= len(path); i++ {
+			if matchGlobSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+
+	matched, err := filepath.Match(pattern[0], path[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchGlobSegments(pattern[1:], path[1:])
+}
+
 func (fr *FileRepository) addFileToTree(node *DirectoryNode, fileInfo FileInfo) {
 	if fileInfo.DirectoryPath == "" {
 		// File is at the root of the tree, so add it to the root node
@@ -1,10 +1,16 @@
 package files_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/contentutil"
+	"github.com/patrickward/padd/internal/crypto"
 	"github.com/patrickward/padd/internal/files"
 )
 
@@ -232,7 +238,7 @@ func TestFileRepository_GetOrCreateResourceDocument(t *testing.T) {
 
 	assert.False(t, fr.FilePathExists("resources/foobar.md"))
 
-	doc, err := fr.GetOrCreateResourceDocument("foobar")
+	doc, err := fr.GetOrCreateResourceDocument("foobar", "", nil)
 	assert.Nil(t, err)
 	assert.True(t, fr.FilePathExists("resources/foobar.md"))
 	assert.Equal(t, doc.Info.Path, "resources/foobar.md")
@@ -263,6 +269,66 @@ func TestFileRepository_GetOrCreateTemporalDocument(t *testing.T) {
 	assert.Equal(t, doc.Info.Path, "daily/2025/03-march.md")
 }
 
+func TestFileRepository_DailyFiles(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+
+	config := files.DefaultFileConfig
+	config.CoreFiles = []string{"todo.md"}
+	config.TemporalGranularity = files.DailyFiles
+	assert.Nil(t, fr.SetConfig(config))
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+	assert.True(t, rm.FileExists("todo.md"))
+	assert.False(t, rm.FileExists("inbox.md"))
+
+	doc, err := fr.GetOrCreateTemporalDocument("daily", time.Date(2025, time.September, 15, 10, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, doc.Info.Path, "daily/2025/09/15.md")
+	assert.Equal(t, doc.Info.ID, "daily/2025/09/15")
+	assert.Equal(t, doc.Info.Title, "Monday, September 15, 2025")
+	assert.Equal(t, doc.Info.DirectoryPath, "daily/2025/09")
+	assert.Equal(t, doc.Info.Day(), "15")
+	assert.Equal(t, doc.Info.MonthName(), "September")
+
+	entries := files.EntryInsertionConfig{EntryFormatter: files.TimestampEntryFormatter}
+	entries.EntryTimestamp = time.Date(2025, time.September, 15, 9, 0, 0, 0, time.UTC)
+	_, err = fr.AddTemporalEntry("daily", "Morning", entries)
+	assert.Nil(t, err)
+	entries.EntryTimestamp = time.Date(2025, time.September, 16, 9, 0, 0, 0, time.UTC)
+	next, err := fr.AddTemporalEntry("daily", "Next day", entries)
+	assert.Nil(t, err)
+	assert.Equal(t, next.Info.Path, "daily/2025/09/16.md")
+
+	// An entry for another day doesn't belong in a daily file
+	entries.Strategy = files.InsertByTimestamp
+	assert.ErrorIs(t, doc.AddEntry("Misplaced", entries), files.ErrWrongTemporalMonth)
+
+	activity, err := fr.TemporalActivity("daily", 2025)
+	assert.Nil(t, err)
+	assert.Equal(t, activity["2025-09-15"], 1)
+	assert.Equal(t, activity["2025-09-16"], 1)
+}
+
+func TestFileRepository_SetConfig_Invalid(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, t.TempDir())
+
+	config := files.DefaultFileConfig
+	config.CoreFiles = nil
+	assert.NotNil(t, fr.SetConfig(config))
+
+	config.CoreFiles = []string{"notes/todo.md"}
+	assert.NotNil(t, fr.SetConfig(config))
+
+	config.CoreFiles = []string{"todo.txt"}
+	assert.NotNil(t, fr.SetConfig(config))
+
+	config = files.DefaultFileConfig
+	config.TemporalGranularity = 7
+	assert.NotNil(t, fr.SetConfig(config))
+}
+
 func TestFileRepository_NormalizeFileName_EdgeCases(t *testing.T) {
 	fr, _ := setupTestFileRepo(t, "")
 
@@ -287,3 +353,1224 @@ func TestFileRepository_NormalizeFileName_EdgeCases(t *testing.T) {
 		assert.Equal(t, result, tc.expected)
 	}
 }
+
+func TestFileRepository_Breadcrumbs_NestedResource(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, "")
+
+	info, err := fr.FileInfo("resources/characters/wile")
+	assert.Nil(t, err)
+
+	crumbs := fr.Breadcrumbs(info)
+	assert.Equal(t, len(crumbs), 3)
+
+	assert.Equal(t, crumbs[0].Path, "/resources")
+	assert.Equal(t, crumbs[0].Title, "")
+	assert.True(t, crumbs[0].IsFirst)
+	assert.False(t, crumbs[0].IsLast)
+
+	assert.Equal(t, crumbs[1].Path, "/resources/characters")
+	assert.Equal(t, crumbs[1].Name, "Characters")
+	assert.Equal(t, crumbs[1].Title, "")
+
+	assert.Equal(t, crumbs[2].Path, "/resources/characters/wile.md")
+	assert.Equal(t, crumbs[2].Title, "Wile")
+	assert.False(t, crumbs[2].IsFirst)
+	assert.True(t, crumbs[2].IsLast)
+}
+
+func TestFileRepository_Breadcrumbs_Temporal(t *testing.T) {
+	t.Parallel()
+	fr, _ := setupTestFileRepo(t, "")
+
+	info, err := fr.FileInfo("daily/2025/09-september")
+	assert.Nil(t, err)
+
+	crumbs := fr.Breadcrumbs(info)
+	assert.Equal(t, len(crumbs), 3)
+	assert.Equal(t, crumbs[0].Path, "/daily")
+	assert.Equal(t, crumbs[0].Title, "")
+	assert.Equal(t, crumbs[1].Path, "/daily/2025")
+	assert.Equal(t, crumbs[1].Title, "")
+	assert.Equal(t, crumbs[2].Path, "/daily/2025/09-september.md")
+	assert.Equal(t, crumbs[2].Title, "09 September")
+	assert.True(t, crumbs[2].IsLast)
+}
+
+func TestFileRepository_NormalizeFileName_UnicodeIDs(t *testing.T) {
+	t.Parallel()
+	rm, err := files.NewRootManager("./testdata/data")
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.UnicodeIDs = true
+	fr := files.NewFileRepository(rm, config)
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"café.md", "café"},                                 // Latin accented
+		{"Crème Brûlée.md", "crème-brûlée"},                 // Latin accented with spaces
+		{"resources/Привет мир.md", "resources/привет-мир"}, // Cyrillic
+		{"日本語のメモ.md", "日本語のメモ"},                             // CJK
+		{"notes (２０２５).md", "notes-２０２５"},                   // Full-width digits
+		{"Hello World!.md", "hello-world"},                  // ASCII is unchanged
+		{"¿¡?!.md", "untitled"},                             // Punctuation only
+		{"cafe\u0301.md", "café"},                           // Decomposed (NFD) is composed first
+		{"हिन्दी नोट्स.md", "हिन्दी-नोट्स"},                 // Devanagari keeps its combining marks
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, fr.CreateID(tc.input), tc.expected)
+	}
+}
+
+func TestFileRepository_MoveToDirectory_Markdown(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("resources/notes.md", "# Notes\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.MoveToDirectory("resources/notes", "projects/2025")
+	assert.Nil(t, err)
+	assert.Equal(t, doc.Info.ID, "resources/projects/2025/notes")
+	assert.Equal(t, doc.Info.Path, "resources/projects/2025/notes.md")
+	assert.Equal(t, doc.Info.DirectoryPath, "resources/projects/2025")
+
+	assert.False(t, rm.FileExists("resources/notes.md"))
+	assert.True(t, rm.FileExists("resources/projects/2025/notes.md"))
+
+	// The cache reflects the move
+	assert.False(t, fr.FileIDExists("resources/notes"))
+	assert.True(t, fr.FileIDExists("resources/projects/2025/notes"))
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Notes\n")
+}
+
+func TestFileRepository_MoveDocument_RewritesLinks(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.MkdirAll("resources", 0755))
+	assert.Nil(t, rm.WriteString("resources/alpha.md", "# Alpha\n\nSee [beta](beta.md#top), [[beta]], [[resources/beta]], `[[beta]]`, [abs](/resources/beta), and [gamma](gamma.md).\n\n```\n[beta](beta.md)\n```\n"))
+	assert.Nil(t, rm.WriteString("resources/beta.md", "# Beta\n\nBack to [alpha](alpha.md) and [[beta]].\n"))
+	assert.Nil(t, rm.WriteString("resources/gamma.md", "# Gamma\n"))
+	assert.Nil(t, rm.WriteString("inbox.md", "- [ ] Read [beta](resources/beta.md)\n"))
+	fr.ReloadCaches()
+
+	doc, locked, err := fr.MoveDocument("resources/beta", "projects/b2")
+	assert.Nil(t, err)
+	assert.Equal(t, len(locked), 0)
+	assert.Equal(t, doc.Info.ID, "resources/projects/b2")
+	assert.False(t, fr.FileIDExists("resources/beta"))
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Beta\n\nBack to [alpha](../alpha.md) and [[projects/b2]].\n")
+
+	raw, err := rm.ReadFile("resources/alpha.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Alpha\n\nSee [beta](projects/b2.md#top), [[projects/b2]], [[resources/projects/b2]], `[[beta]]`, [abs](/resources/projects/b2), and [gamma](gamma.md).\n\n```\n[beta](beta.md)\n```\n")
+
+	raw, err = rm.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "- [ ] Read [beta](resources/projects/b2.md)\n")
+
+	// The cached links follow the rewrite
+	assert.Equal(t, len(fr.Backlinks("resources/projects/b2")), 2)
+}
+
+func TestFileRepository_MoveDocument_LockedDocuments(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.MkdirAll("resources", 0755))
+	assert.Nil(t, rm.WriteString("resources/alpha.md", "---\nlocked: true\n---\n# Alpha\n\nSee [[beta]].\n"))
+	assert.Nil(t, rm.WriteString("resources/beta.md", "---\nlocked: true\n---\n# Beta\n\nBack to [alpha](alpha.md).\n"))
+	assert.Nil(t, rm.WriteString("resources/gamma.md", "# Gamma\n\nSee [[beta]].\n"))
+	fr.ReloadCaches()
+
+	// The file still moves, but locked documents keep their content and are reported
+	doc, locked, err := fr.MoveDocument("resources/beta", "projects/beta")
+	assert.Nil(t, err)
+	assert.Equal(t, locked, []string{"resources/alpha", "resources/projects/beta"})
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "---\nlocked: true\n---\n# Beta\n\nBack to [alpha](alpha.md).\n")
+
+	raw, err := rm.ReadFile("resources/alpha.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "---\nlocked: true\n---\n# Alpha\n\nSee [[beta]].\n")
+
+	raw, err = rm.ReadFile("resources/gamma.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Gamma\n\nSee [[projects/beta]].\n")
+}
+
+func TestFileRepository_MoveDocument_Invalid(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.MkdirAll("resources", 0755))
+	assert.Nil(t, rm.WriteString("resources/notes.md", "# Notes\n"))
+	assert.Nil(t, rm.WriteString("resources/taken.md", "# Taken\n"))
+	fr.ReloadCaches()
+
+	tests := []struct {
+		name  string
+		oldID string
+		newID string
+	}{
+		{name: "core file", oldID: "inbox", newID: "resources/inbox"},
+		{name: "missing file", oldID: "resources/missing", newID: "found"},
+		{name: "existing target", oldID: "resources/notes", newID: "taken"},
+		{name: "same location", oldID: "resources/notes", newID: "resources/notes"},
+		{name: "outside resources", oldID: "resources/notes", newID: "../daily/notes"},
+		{name: "empty", oldID: "resources/notes", newID: " "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := fr.MoveDocument(tt.oldID, tt.newID)
+			assert.NotNil(t, err)
+		})
+	}
+
+	assert.True(t, rm.FileExists("resources/notes.md"))
+}
+
+func TestFileRepository_MoveToDirectory_CSVWithSidecar(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("resources/data.csv", "name,age\nJohn,25\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/data.csv.meta.json", `{"title": "Data"}`)
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	doc, err := fr.MoveToDirectory("resources/data.csv", "resources/tables")
+	assert.Nil(t, err)
+	assert.Equal(t, doc.Info.Path, "resources/tables/data.csv")
+
+	assert.False(t, rm.FileExists("resources/data.csv"))
+	assert.False(t, rm.FileExists("resources/data.csv.meta.json"))
+	assert.True(t, rm.FileExists("resources/tables/data.csv"))
+	assert.True(t, rm.FileExists("resources/tables/data.csv.meta.json"))
+
+	metadata, err := files.NewCSVDocument(doc).GetMetadata()
+	assert.Nil(t, err)
+	assert.Equal(t, metadata.Title, "Data")
+}
+
+func TestFileRepository_MoveToDirectory_OutsideResources(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("resources/notes.md", "# Notes\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	_, err = fr.MoveToDirectory("resources/notes", "../daily")
+	assert.NotNil(t, err)
+
+	// Core files can't be moved
+	_, err = fr.MoveToDirectory("inbox", "archive")
+	assert.NotNil(t, err)
+
+	assert.True(t, rm.FileExists("resources/notes.md"))
+}
+
+func TestFileRepository_ExcludeGlobs(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+
+	rm, err := files.NewRootManager(tmp)
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.ExcludeGlobs = []string{"**/drafts/**", "*.tmp.md"}
+	fr := files.NewFileRepository(rm, config)
+	err = fr.Initialize()
+	assert.Nil(t, err)
+
+	assert.Nil(t, rm.MkdirAll("resources/notes/drafts", 0755))
+	assert.Nil(t, rm.WriteString("resources/notes/kept.md", "# Kept\n"))
+	assert.Nil(t, rm.WriteString("resources/notes/scratch.tmp.md", "# Scratch\n"))
+	assert.Nil(t, rm.WriteString("resources/notes/drafts/idea.md", "# Idea\n"))
+	fr.ReloadCaches()
+
+	assert.True(t, fr.FileIDExists("resources/notes/kept"))
+	assert.False(t, fr.FileIDExists("resources/notes/drafts/idea"))
+
+	for _, file := range fr.DirectoryTree().Flatten() {
+		assert.False(t, strings.Contains(file.Path, "drafts"))
+		assert.False(t, strings.HasSuffix(file.Path, ".tmp.md"))
+	}
+
+	// Excluded files can still be read directly by path
+	doc, err := fr.GetDocumentByPath("resources/notes/drafts/idea.md")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Idea\n")
+}
+
+func TestFileRepository_MaxDepth(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+
+	rm, err := files.NewRootManager(tmp)
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.MaxDepth = 3
+	fr := files.NewFileRepository(rm, config)
+	err = fr.Initialize()
+	assert.Nil(t, err)
+
+	assert.Nil(t, rm.MkdirAll("resources/a/b/c/d", 0755))
+	assert.Nil(t, rm.WriteString("resources/top.md", "# Top\n"))
+	assert.Nil(t, rm.WriteString("resources/a/b/limit.md", "# Limit\n"))
+	assert.Nil(t, rm.WriteString("resources/a/b/c/deep.md", "# Deep\n"))
+	assert.Nil(t, rm.WriteString("resources/a/b/c/d/deeper.md", "# Deeper\n"))
+	fr.ReloadCaches()
+
+	assert.True(t, fr.FileIDExists("inbox"))
+	assert.True(t, fr.FileIDExists("resources/top"))
+	assert.True(t, fr.FileIDExists("resources/a/b/limit"))
+	assert.False(t, fr.FileIDExists("resources/a/b/c/deep"))
+	assert.False(t, fr.FileIDExists("resources/a/b/c/d/deeper"))
+
+	for _, file := range fr.DirectoryTree().Flatten() {
+		assert.False(t, strings.Contains(file.Path, "/c/"))
+	}
+}
+
+func TestFileRepository_BrokenLinks(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("resources/notes.md", "# Notes\n\nSee [[inbox]], [[todo]] and [[Missing Page]].\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/todo.md", "# Todo\n\nBack to [[resources/notes]].\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("active.md", "# Active\n\n- [ ] Read [[nowhere]]\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	broken, err := fr.BrokenLinks()
+	assert.Nil(t, err)
+	assert.Equal(t, len(broken), 2)
+	assert.Equal(t, strings.Join(broken["resources/notes"], ","), "Missing Page")
+	assert.Equal(t, strings.Join(broken["active"], ","), "nowhere")
+
+	_, ok := broken["resources/todo"]
+	assert.False(t, ok)
+}
+
+func TestFileRepository_CheckLinks(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.WriteString("resources/notes.md", "# Notes\n\n"+
+		"[[Missing]], [todo](todo.md), [gone](gone.md#top), [up](../inbox), [out](../../outside.md)\n\n"+
+		"[scan](scans/receipt.pdf), [top](#top), [app](/daily), [site](https://example.com/a), [mail](mailto:sam@example.com)\n"))
+	assert.Nil(t, rm.WriteString("resources/todo.md", "# Todo\n\n[site](https://example.com/a) and [other](http://example.org)\n"))
+	assert.Nil(t, rm.MkdirAll("resources/scans", 0755))
+	assert.Nil(t, rm.WriteString("resources/scans/receipt.pdf", "%PDF-1.4\n"))
+	fr.ReloadCaches()
+
+	check, err := fr.CheckLinks()
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Join(check.BrokenWiki["resources/notes"], ","), "Missing")
+	assert.Equal(t, strings.Join(check.DeadRelative["resources/notes"], ","), "gone.md#top,../../outside.md")
+	assert.Equal(t, len(check.DeadRelative), 1)
+
+	assert.Equal(t, len(check.External), 2)
+	assert.Equal(t, strings.Join(check.External["https://example.com/a"], ","), "resources/notes,resources/todo")
+	assert.Equal(t, strings.Join(check.External["http://example.org"], ","), "resources/todo")
+}
+
+func TestFileRepository_TemporalActivity(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+
+	err := rm.MkdirAll("daily/2024", 0755)
+	assert.Nil(t, err)
+
+	content := `---
+title: March 2024
+---
+
+# March 2024
+
+## Sunday, March 3, 2024
+
+### 09:00:00 AM
+
+First entry.
+
+### 10:00:00 AM
+
+Second entry.
+
+### 11:30:00 AM
+
+Third entry.
+
+## Notes
+
+### Not an entry
+
+Sections that aren't day headers don't count.
+
+## Saturday, March 2, 2024
+
+A day with content but no entry headings.
+
+## Friday, March 1, 2024
+
+`
+	err = rm.WriteString("daily/2024/03-march.md", content)
+	assert.Nil(t, err)
+
+	err = rm.WriteString("daily/2024/04-april.md", "## Monday, April 1, 2024\n\n### 08:00:00 AM\n\nApril entry.\n")
+	assert.Nil(t, err)
+
+	activity, err := fr.TemporalActivity("daily", 2024)
+	assert.Nil(t, err)
+	assert.Equal(t, len(activity), 3)
+	assert.Equal(t, activity["2024-03-03"], 3)
+	assert.Equal(t, activity["2024-03-02"], 1)
+	assert.Equal(t, activity["2024-04-01"], 1)
+
+	_, ok := activity["2024-03-01"]
+	assert.False(t, ok)
+}
+
+func TestFileRepository_TemporalActivity_Fixture(t *testing.T) {
+	fr, _ := setupTestFileRepo(t, "")
+
+	activity, err := fr.TemporalActivity("daily", 2025)
+	assert.Nil(t, err)
+	assert.Equal(t, activity["2025-09-05"], 2)
+	assert.Equal(t, activity["2025-09-04"], 1)
+
+	empty, err := fr.TemporalActivity("daily", 1999)
+	assert.Nil(t, err)
+	assert.Equal(t, len(empty), 0)
+
+	_, err = fr.TemporalActivity("resources", 2025)
+	assert.NotNil(t, err)
+}
+
+func TestFileRepository_DeleteDocument(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("resources/notes.md", "# Notes\n\nfindable\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/data.csv", "name,age\nJohn,25\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/data.csv.meta.json", `{"title": "Data"}`)
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	err = fr.DeleteDocument("resources/notes")
+	assert.Nil(t, err)
+	assert.False(t, rm.FileExists("resources/notes.md"))
+	assert.False(t, fr.FileIDExists("resources/notes"))
+	assert.Equal(t, len(fr.Search("findable")), 0)
+
+	err = fr.DeleteDocument("resources/data.csv")
+	assert.Nil(t, err)
+	assert.False(t, rm.FileExists("resources/data.csv"))
+	assert.False(t, rm.FileExists("resources/data.csv.meta.json"))
+
+	// Core files and directories can't be deleted
+	err = fr.DeleteDocument("inbox")
+	assert.NotNil(t, err)
+	assert.True(t, rm.FileExists("inbox.md"))
+
+	err = fr.DeleteDocument("resources")
+	assert.NotNil(t, err)
+
+	err = fr.DeleteDocument("resources/missing")
+	assert.NotNil(t, err)
+}
+
+func TestFileRepository_DeleteDirectory(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.MkdirAll("resources/empty/nested", 0755)
+	assert.Nil(t, err)
+	err = rm.MkdirAll("resources/projects/nested", 0755)
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/projects/nested/plan.md", "# Plan\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	err = fr.DeleteDirectory("resources/empty")
+	assert.Nil(t, err)
+	_, err = rm.Stat("resources/empty")
+	assert.NotNil(t, err)
+
+	// A file anywhere inside the directory keeps it from being deleted
+	err = fr.DeleteDirectory("resources/projects")
+	assert.True(t, errors.Is(err, files.ErrDirectoryNotEmpty))
+	assert.True(t, rm.FileExists("resources/projects/nested/plan.md"))
+
+	// The resources directory itself and directories outside of it can't be deleted
+	err = fr.DeleteDirectory("resources")
+	assert.NotNil(t, err)
+
+	err = fr.DeleteDirectory("../daily")
+	assert.NotNil(t, err)
+
+	err = fr.DeleteDirectory("resources/missing")
+	assert.NotNil(t, err)
+}
+
+func TestFileRepository_ArchiveDocument(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.MkdirAll("resources/projects", 0755)
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/projects/plan.md", "# Plan\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	err = fr.ArchiveDocument("resources/projects/plan")
+	assert.Nil(t, err)
+
+	assert.False(t, rm.FileExists("resources/projects/plan.md"))
+	assert.True(t, rm.FileExists("_archive/resources/projects/plan.md"))
+
+	// Archived files are left out of the index and directory tree
+	assert.False(t, fr.FileIDExists("resources/projects/plan"))
+	assert.False(t, fr.FileIDExists("_archive/resources/projects/plan"))
+	_, ok := fr.DirectoryTree().Directories["_archive"]
+	assert.False(t, ok)
+
+	archived, err := fr.ArchivedDocuments()
+	assert.Nil(t, err)
+	assert.Equal(t, len(archived), 1)
+	assert.Equal(t, archived[0].ID, "resources/projects/plan")
+
+	err = fr.UnarchiveDocument("resources/projects/plan")
+	assert.Nil(t, err)
+
+	assert.True(t, rm.FileExists("resources/projects/plan.md"))
+	assert.False(t, rm.FileExists("_archive/resources/projects/plan.md"))
+	assert.True(t, fr.FileIDExists("resources/projects/plan"))
+
+	doc, err := fr.GetDocument("resources/projects/plan")
+	assert.Nil(t, err)
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Plan\n")
+}
+
+func TestFileRepository_ArchiveDocument_CSVWithSidecar(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	err = rm.WriteString("resources/data.csv", "name,age\nJohn,25\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/data.csv.meta.json", `{"title": "Data"}`)
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	err = fr.ArchiveDocument("resources/data.csv")
+	assert.Nil(t, err)
+	assert.True(t, rm.FileExists("_archive/resources/data.csv"))
+	assert.True(t, rm.FileExists("_archive/resources/data.csv.meta.json"))
+
+	err = fr.UnarchiveDocument("resources/data.csv")
+	assert.Nil(t, err)
+	assert.True(t, rm.FileExists("resources/data.csv"))
+	assert.True(t, rm.FileExists("resources/data.csv.meta.json"))
+}
+
+func TestFileRepository_ArchiveDocument_Errors(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	err := fr.Initialize()
+	assert.Nil(t, err)
+
+	// Core files can't be archived
+	err = fr.ArchiveDocument("inbox")
+	assert.NotNil(t, err)
+	assert.True(t, rm.FileExists("inbox.md"))
+
+	err = fr.ArchiveDocument("resources/missing")
+	assert.NotNil(t, err)
+
+	err = fr.UnarchiveDocument("resources/missing")
+	assert.NotNil(t, err)
+
+	// Restoring doesn't overwrite a file created at the original path in the meantime
+	err = rm.WriteString("resources/notes.md", "# Old\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+	assert.Nil(t, fr.ArchiveDocument("resources/notes"))
+
+	err = rm.WriteString("resources/notes.md", "# New\n")
+	assert.Nil(t, err)
+	err = fr.UnarchiveDocument("resources/notes")
+	assert.NotNil(t, err)
+}
+
+func setupEncryptedDirRepo(t *testing.T, withKeys bool) (*files.FileRepository, *files.RootManager) {
+	t.Helper()
+
+	rm, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.EncryptedDirectories = []string{"resources/private/"}
+	fr := files.NewFileRepository(rm, config)
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	if withKeys {
+		publicKey, privateKey, _, _, err := crypto.GenerateNewEncryptionPair(t.TempDir())
+		assert.Nil(t, err)
+
+		em := crypto.NewEncryptionManager()
+		assert.Nil(t, em.AddRecipient(publicKey))
+		assert.Nil(t, em.AddIdentity(privateKey))
+		em.Activate()
+		fr.SetEncryptionManager(em)
+	}
+
+	return fr, rm
+}
+
+func TestFileRepository_EncryptedDirectories(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupEncryptedDirRepo(t, true)
+
+	assert.True(t, fr.EncryptsByDefault("resources/private/secret.md"))
+	assert.True(t, fr.EncryptsByDefault("resources/private/nested/secret.md"))
+	assert.False(t, fr.EncryptsByDefault("resources/private-notes.md"))
+	assert.False(t, fr.EncryptsByDefault("resources/public.md"))
+
+	doc, err := fr.GetOrCreateResourceDocument("private/secret", "", nil)
+	assert.Nil(t, err)
+
+	raw, err := rm.ReadFile("resources/private/secret.md")
+	assert.Nil(t, err)
+	assert.True(t, crypto.IsAgeEncrypted(raw))
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	value, ok := contentutil.FrontmatterValue(contentutil.SplitLines(content), "encrypted")
+	assert.True(t, ok)
+	assert.Equal(t, value, "true")
+
+	// Files elsewhere stay plaintext
+	_, err = fr.GetOrCreateResourceDocument("public", "", nil)
+	assert.Nil(t, err)
+	raw, err = rm.ReadFile("resources/public.md")
+	assert.Nil(t, err)
+	assert.False(t, crypto.IsAgeEncrypted(raw))
+}
+
+func TestFileRepository_EncryptedDirectories_WithoutKeys(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupEncryptedDirRepo(t, false)
+	assert.False(t, fr.CanEncrypt())
+
+	err := rm.MkdirAll("resources/private", 0755)
+	assert.Nil(t, err)
+	err = fr.CreateFile("resources/private/plan.md", "# Plan\n")
+	assert.Nil(t, err)
+
+	// Written as plaintext, but flagged so it's encrypted once keys are loaded
+	raw, err := rm.ReadFile("resources/private/plan.md")
+	assert.Nil(t, err)
+	assert.False(t, crypto.IsAgeEncrypted(raw))
+	assert.MatchesRegexp(t, string(raw), `^---\nencrypted: true\ncreated_at: [0-9-]+ [0-9:]+\n---\n# Plan\n$`)
+}
+
+func TestFileRepository_OnChange(t *testing.T) {
+	fr, _ := setupTestFileRepo(t, t.TempDir())
+
+	var events []files.ChangeEvent
+	fr.OnChange(func(event files.ChangeEvent) {
+		events = append(events, event)
+	})
+
+	doc, err := fr.GetOrCreateResourceDocument("notes", "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Notes\n"))
+	moved, err := fr.MoveToDirectory(doc.Info.ID, "archive")
+	assert.Nil(t, err)
+
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Operation, files.ChangeSave)
+	assert.Equal(t, events[0].ID, "resources/notes")
+	assert.True(t, events[0].Hash != "")
+	assert.Equal(t, events[1].Operation, files.ChangeRename)
+	assert.Equal(t, events[1].OldID, "resources/notes")
+	assert.Equal(t, events[1].ID, moved.Info.ID)
+}
+
+func TestFileRepository_OnCacheRefresh(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+
+	var refreshes []files.CacheRefresh
+	fr.OnCacheRefresh(func(refresh files.CacheRefresh) {
+		refreshes = append(refreshes, refresh)
+	})
+
+	assert.Nil(t, rm.WriteString("inbox.md", "# Inbox\n"))
+	fr.ReloadCaches()
+
+	assert.Equal(t, len(refreshes), 1)
+	assert.Equal(t, refreshes[0].Files, 1)
+}
+
+func TestFileRepository_TaskStats_ConcurrentListing(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("resources/project.md", "# Project\n\n- [ ] one\n"))
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("resources/project")
+	assert.Nil(t, err)
+
+	// Listings read the tree after the repository's lock is released, while saves update it; run with -race
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			assert.Nil(t, doc.Save(fmt.Sprintf("# Project\n\n- [ ] one\n- [x] done %d\n", i)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			for _, file := range fr.DirectoryTreeFor("resources").Files {
+				_ = file.TaskStats.Total
+			}
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, fr.DirectoryTreeFor("resources").FindFile("resources/project").TaskStats, files.TaskCounts{Total: 2, Completed: 1, Pending: 1})
+}
+
+func TestFileRepository_TaskStats(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("resources/project.md", "# Project\n\n- [ ] one\n- [x] two\n  * [ ] three\n- not a task\n"))
+	fr.ReloadCaches()
+
+	info, err := fr.FileInfo("resources/project")
+	assert.Nil(t, err)
+	assert.Equal(t, info.TaskStats, files.TaskCounts{Total: 3, Completed: 1, Pending: 2})
+
+	// Toggling a task updates the cached counts without a reload
+	doc, err := fr.GetDocument("resources/project")
+	assert.Nil(t, err)
+	_, err = doc.ToggleTask(1)
+	assert.Nil(t, err)
+
+	want := files.TaskCounts{Total: 3, Completed: 2, Pending: 1}
+	assert.Equal(t, doc.Info.TaskStats, want)
+
+	info, err = fr.FileInfo("resources/project")
+	assert.Nil(t, err)
+	assert.Equal(t, info.TaskStats, want)
+
+	dir, err := fr.FileInfo("resources")
+	assert.Nil(t, err)
+	assert.Equal(t, dir.DirectoryNode.FindFile("resources/project").TaskStats, want)
+
+	// A reload scans the file again and agrees
+	fr.ReloadCaches()
+	info, err = fr.FileInfo("resources/project")
+	assert.Nil(t, err)
+	assert.Equal(t, info.TaskStats, want)
+}
+
+func TestFileRepository_DistinctMetadataValues(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("resources/alpha.md", "---\nstatus: active\npriority: high\n---\n# Alpha\n"))
+	assert.Nil(t, rm.WriteString("resources/beta.md", "---\nstatus: \"active\"\npriority: low\n---\n# Beta\n"))
+	assert.Nil(t, rm.WriteString("resources/gamma.md", "---\nstatus: done\n---\n# Gamma\n"))
+	assert.Nil(t, rm.WriteString("resources/delta.md", "# Delta\n\nstatus: ignored outside frontmatter\n"))
+	fr.ReloadCaches()
+
+	statuses, err := fr.DistinctMetadataValues("status")
+	assert.Nil(t, err)
+	assert.Equal(t, statuses, map[string]int{"active": 2, "done": 1})
+
+	priorities, err := fr.DistinctMetadataValues("priority")
+	assert.Nil(t, err)
+	assert.Equal(t, priorities, map[string]int{"high": 1, "low": 1})
+
+	authors, err := fr.DistinctMetadataValues("author")
+	assert.Nil(t, err)
+	assert.Equal(t, len(authors), 0)
+
+	_, err = fr.DistinctMetadataValues(" ")
+	assert.NotNil(t, err)
+
+	// Saving a document updates the cached frontmatter without a reload
+	doc, err := fr.GetDocument("resources/gamma")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("---\nstatus: active\n---\n# Gamma\n"))
+
+	statuses, err = fr.DistinctMetadataValues("status")
+	assert.Nil(t, err)
+	assert.Equal(t, statuses, map[string]int{"active": 3})
+}
+
+func TestFileRepository_TemporalSummary(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.MkdirAll("daily/2024", 0755))
+
+	february := `# February 2024
+
+## Thursday, February 29, 2024
+
+### 05:00:00 PM
+
+- [ ] File the report
+
+## Wednesday, February 28, 2024
+
+Too old to include.
+`
+	march := `---
+title: March 2024
+---
+
+# March 2024
+
+## Saturday, March 2, 2024
+
+### 09:00:00 AM
+
+- [ ] Call the bank
+- [x] Pay rent
+
+
+## Friday, March 1, 2024
+
+### 10:00:00 AM
+
+Quiet day.
+
+## Notes
+
+Not a day section.
+`
+	assert.Nil(t, rm.WriteString("daily/2024/02-february.md", february))
+	assert.Nil(t, rm.WriteString("daily/2024/03-march.md", march))
+	fr.ReloadCaches()
+
+	summary, err := fr.TemporalSummary("daily", 3)
+	assert.Nil(t, err)
+
+	want := `# Recent Daily
+
+2 open tasks in the last 3 days.
+
+## Saturday, March 2, 2024
+
+### 09:00:00 AM
+
+- [ ] Call the bank
+- [x] Pay rent
+
+## Friday, March 1, 2024
+
+### 10:00:00 AM
+
+Quiet day.
+
+## Thursday, February 29, 2024
+
+### 05:00:00 PM
+
+- [ ] File the report
+`
+	assert.Equal(t, summary, want)
+
+	_, err = fr.TemporalSummary("resources", 3)
+	assert.NotNil(t, err)
+
+	_, err = fr.TemporalSummary("daily", 0)
+	assert.NotNil(t, err)
+}
+
+func TestFileRepository_WeeklyReview(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.MkdirAll("daily/2024", 0755))
+	assert.Nil(t, rm.MkdirAll("journal/2024", 0755))
+
+	daily := `# March 2024
+
+## Friday, March 8, 2024
+
+- [x] Pay rent @done(2024-03-08)
+
+## Friday, March 1, 2024
+
+Too old to include.
+`
+	journal := `# March 2024
+
+## Friday, March 8, 2024
+
+A good week.
+
+## Monday, March 4, 2024
+
+Started the project.
+`
+	assert.Nil(t, rm.WriteString("daily/2024/03-march.md", daily))
+	assert.Nil(t, rm.WriteString("journal/2024/03-march.md", journal))
+	assert.Nil(t, rm.WriteString("inbox.md", "# Inbox\n\n- [x] Call the bank @done(2024-03-05)\n- [x] File taxes @done(2024-02-20)\n- [ ] Renew passport\n"))
+	fr.ReloadCaches()
+
+	review, err := fr.WeeklyReview(time.Date(2024, 3, 8, 18, 30, 0, 0, time.Local))
+	assert.Nil(t, err)
+
+	want := `# Weekly Review
+
+Saturday, March 2, 2024 to Friday, March 8, 2024: 3 days with entries and 2 completed tasks.
+
+## Completed Tasks
+
+- Pay rent @done(2024-03-08) ([[daily/2024/03-march]])
+- Call the bank @done(2024-03-05) ([[inbox]])
+
+## Friday, March 8, 2024 (Daily)
+
+- [x] Pay rent @done(2024-03-08)
+
+## Friday, March 8, 2024 (Journal)
+
+A good week.
+
+## Monday, March 4, 2024 (Journal)
+
+Started the project.
+`
+	assert.Equal(t, review, want)
+}
+
+func TestFileRepository_TemporalMonth(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.MkdirAll("journal/2024", 0755))
+
+	march := `---
+title: March 2024
+---
+
+# March 2024
+
+## Friday, March 8, 2024
+
+### 09:00:00 AM
+
+Morning.
+
+### 09:00:00 AM
+
+Again.
+
+## Monday, March 4, 2024
+
+Started the project.
+
+## Friday, March 8, 2024
+
+Moved here by hand.
+
+## Thursday, February 29, 2024
+
+Filed in the wrong month.
+`
+	assert.Nil(t, rm.WriteString("journal/2024/03-march.md", march))
+	fr.ReloadCaches()
+
+	headers, activity, err := fr.TemporalMonth("journal", 2024, time.March)
+	assert.Nil(t, err)
+
+	var anchors []string
+	for _, header := range headers {
+		assert.Equal(t, header.FileID, "journal/2024/03-march")
+		anchors = append(anchors, header.Date.Format("2006-01-02")+"#"+header.Anchor)
+	}
+	assert.Equal(t, strings.Join(anchors, ","), "2024-03-08#friday-march-8-2024,2024-03-04#monday-march-4-2024,2024-03-08#friday-march-8-2024-1")
+	assert.Equal(t, activity, map[string]int{"2024-03-08": 3, "2024-03-04": 1})
+
+	headers, _, err = fr.TemporalMonth("journal", 2024, time.April)
+	assert.Nil(t, err)
+	assert.Equal(t, len(headers), 0)
+
+	_, _, err = fr.TemporalMonth("resources", 2024, time.March)
+	assert.NotNil(t, err)
+}
+
+func TestFileRepository_OnThisDay(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.MkdirAll("daily/2023", 0755))
+	assert.Nil(t, rm.MkdirAll("journal/2024", 0755))
+
+	assert.Nil(t, rm.WriteString("daily/2023/03-march.md", "# March 2023\n\n## Wednesday, March 15, 2023\n\n### 09:00:00 AM\n\nPlanted the garden.\n\n## Tuesday, March 14, 2023\n\nThe day before.\n"))
+	assert.Nil(t, rm.WriteString("journal/2024/02-february.md", "# February 2024\n\n## Thursday, February 15, 2024\n\nA quiet walk.\n"))
+	assert.Nil(t, rm.WriteString("journal/2024/03-march.md", "# March 2024\n\n## Friday, March 15, 2024\n\nToday's entry.\n"))
+	fr.ReloadCaches()
+
+	entries, err := fr.OnThisDay(time.Date(2024, 3, 15, 20, 0, 0, 0, time.Local))
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 2)
+
+	assert.Equal(t, entries[0].Type, "journal")
+	assert.Equal(t, entries[0].FileID, "journal/2024/02-february")
+	assert.Equal(t, entries[0].Anchor, "thursday-february-15-2024")
+	assert.Equal(t, entries[0].Excerpt(0), "A quiet walk.")
+
+	assert.Equal(t, entries[1].Type, "daily")
+	assert.Equal(t, entries[1].Date.Format("2006-01-02"), "2023-03-15")
+	assert.Equal(t, entries[1].Lines[0], "## Wednesday, March 15, 2023")
+	assert.Equal(t, entries[1].Excerpt(0), "Planted the garden.")
+}
+
+func TestFileRepository_AutoCreatedAt(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	createdAt := func(path string) (string, bool) {
+		t.Helper()
+		raw, err := rm.ReadFile(path)
+		assert.Nil(t, err)
+		return contentutil.FrontmatterValue(contentutil.SplitLines(string(raw)), "created_at")
+	}
+
+	// Created directly
+	assert.Nil(t, fr.CreateFile("resources/direct.md", "# Direct\n"))
+	value, ok := createdAt("resources/direct.md")
+	assert.True(t, ok)
+	assert.MatchesRegexp(t, value, `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`)
+
+	// Created on first access
+	_, err := fr.GetOrCreateResourceDocument("on-demand", "", nil)
+	assert.Nil(t, err)
+	_, ok = createdAt("resources/on-demand.md")
+	assert.True(t, ok)
+
+	// Created for a temporal entry
+	doc, err := fr.GetOrCreateTemporalDocument("daily", time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	_, ok = createdAt(doc.Info.Path)
+	assert.True(t, ok)
+
+	// An existing value is kept
+	assert.Nil(t, fr.CreateFile("resources/imported.md", "---\ncreated_at: 2020-01-02 03:04:05\n---\n# Imported\n"))
+	value, _ = createdAt("resources/imported.md")
+	assert.Equal(t, value, "2020-01-02 03:04:05")
+}
+
+func TestFileRepository_AutoCreatedAt_Disabled(t *testing.T) {
+	rm, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.AutoCreatedAt = false
+	fr := files.NewFileRepository(rm, config)
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, fr.CreateFile("resources/plain.md", "# Plain\n"))
+	raw, err := rm.ReadFile("resources/plain.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "# Plain\n")
+}
+
+func TestFileRepository_AutoUpdatedAt(t *testing.T) {
+	rm, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	config := files.DefaultFileConfig
+	config.AutoUpdatedAt = true
+	config.TimestampDirectories = []string{"resources"}
+	fr := files.NewFileRepository(rm, config)
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	read := func(path string) string {
+		t.Helper()
+		raw, err := rm.ReadFile(path)
+		assert.Nil(t, err)
+		return string(raw)
+	}
+
+	// New files get both timestamps, with created_at first
+	assert.Nil(t, fr.CreateFile("resources/plan.md", "# Plan\n"))
+	assert.MatchesRegexp(t, read("resources/plan.md"), `^---\ncreated_at: \S+ \S+\nupdated_at: \S+ \S+\n---\n# Plan\n$`)
+
+	// Saving refreshes updated_at in place, leaving the other fields and the content as they were
+	fr.ReloadCaches()
+	doc, err := fr.GetDocument("resources/plan")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("---\ntitle: Plan\nupdated_at: 2020-01-02 03:04:05\nstatus: draft\n---\n# Plan\n\nRevised.\n"))
+	content := read("resources/plan.md")
+	assert.MatchesRegexp(t, content, `^---\ntitle: Plan\nupdated_at: \d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\nstatus: draft\n---\n# Plan\n\nRevised.\n$`)
+	assert.False(t, strings.Contains(content, "2020-01-02"))
+
+	// A file without frontmatter gets it
+	assert.Nil(t, rm.WriteString("resources/bare.md", "# Bare\n"))
+	fr.ReloadCaches()
+	doc, err = fr.GetDocument("resources/bare")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Bare\n\nEdited.\n"))
+	assert.MatchesRegexp(t, read("resources/bare.md"), `^---\nupdated_at: \S+ \S+\n---\n\n# Bare\n\nEdited.\n$`)
+
+	// Files outside the timestamp directories are left alone
+	doc, err = fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Inbox\n\n- [ ] Call Sam\n"))
+	assert.Equal(t, read("inbox.md"), "# Inbox\n\n- [ ] Call Sam\n")
+}
+
+func TestFileRepository_LinkGraph(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.WriteString("resources/alpha.md", "# Alpha\n\nSee [[beta]], [[beta]] again, [[Missing]] and [[resources/alpha]].\n"))
+	assert.Nil(t, rm.WriteString("resources/beta.md", "# Beta\n\nBack to [alpha](alpha.md) and the [inbox](../inbox.md). [Docs](https://example.com/docs.md)\n"))
+	assert.Nil(t, rm.WriteString("active.md", "# Active\n\n```\n[[beta]]\n```\n"))
+	fr.ReloadCaches()
+
+	graph := fr.LinkGraph()
+
+	ids := make([]string, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		ids = append(ids, node.ID)
+	}
+	assert.Equal(t, ids, []string{"active", "inbox", "resources/alpha", "resources/beta"})
+	assert.Equal(t, graph.Nodes[2], files.GraphNode{ID: "resources/alpha", Title: "Alpha"})
+
+	assert.Equal(t, graph.Edges, []files.GraphEdge{
+		{Source: "resources/alpha", Target: "resources/beta"},
+		{Source: "resources/beta", Target: "inbox"},
+		{Source: "resources/beta", Target: "resources/alpha"},
+	})
+
+	// Saving a document updates its edges without a reload
+	doc, err := fr.GetDocument("active")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Active\n\nWorking on [[alpha]]\n"))
+
+	graph = fr.LinkGraph()
+	assert.Equal(t, graph.Edges[0], files.GraphEdge{Source: "active", Target: "resources/alpha"})
+}
+
+func TestFileRepository_LinkGraph_GroupByTag(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.WriteString("resources/alpha.md", "---\ntags: [project, work]\n---\n# Alpha\n\nSee [[beta]].\n"))
+	assert.Nil(t, rm.WriteString("resources/beta.md", "---\ntags:\n  - reading\n---\n# Beta\n"))
+	fr.ReloadCaches()
+
+	graph := fr.LinkGraph()
+	nodes := make(map[string]files.GraphNode)
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	assert.Equal(t, nodes["resources/alpha"], files.GraphNode{ID: "resources/alpha", Title: "Alpha", Tags: []string{"project", "work"}})
+	assert.Equal(t, nodes["inbox"].Tags, []string(nil))
+
+	graph.GroupByTag()
+	nodes = make(map[string]files.GraphNode)
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	assert.Equal(t, nodes["resources/alpha"].Group, "project")
+	assert.Equal(t, nodes["resources/beta"].Group, "reading")
+	assert.Equal(t, nodes["inbox"].Group, "")
+}
+
+func TestFileRepository_Backlinks(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.WriteString("resources/alpha.md", "# Alpha\n\nSee [[beta]] and [[alpha]].\n"))
+	assert.Nil(t, rm.WriteString("resources/beta.md", "# Beta\n\nBack to [alpha](alpha.md).\n"))
+	assert.Nil(t, rm.WriteString("inbox.md", "# Inbox\n\n- [ ] Read [[beta]]\n"))
+	fr.ReloadCaches()
+
+	ids := func(infos []files.FileInfo) []string {
+		result := make([]string, 0, len(infos))
+		for _, info := range infos {
+			result = append(result, info.ID)
+		}
+		return result
+	}
+
+	doc, err := fr.GetDocument("resources/beta")
+	assert.Nil(t, err)
+	assert.Equal(t, ids(doc.Backlinks()), []string{"inbox", "resources/alpha"})
+
+	// Self-links aren't backlinks
+	assert.Equal(t, ids(fr.Backlinks("resources/alpha")), []string{"resources/beta"})
+	assert.Equal(t, ids(fr.Backlinks("active")), []string{})
+
+	// Saving a document updates the backlinks of the files it links to without a reload
+	inbox, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, inbox.Save("# Inbox\n\n- [ ] Read [[alpha]]\n"))
+	assert.Equal(t, ids(doc.Backlinks()), []string{"resources/alpha"})
+	assert.Equal(t, ids(fr.Backlinks("resources/alpha")), []string{"inbox", "resources/beta"})
+}
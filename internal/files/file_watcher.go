@@ -0,0 +1,233 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ownChangeWindow is how long after the RootManager changes a path that filesystem events for it are
+// treated as its own, rather than as edits made outside the application
+const ownChangeWindow = 2 * time.Second
+
+// recordChange notes that the paths are being changed through this RootManager, so Watch doesn't report them
+func (rm *RootManager) recordChange(paths ...string) {
+	rm.changesMu.Lock()
+	defer rm.changesMu.Unlock()
+
+	now := time.Now()
+	for path, changed := range rm.ownChanges {
+		if now.Sub(changed) > ownChangeWindow {
+			delete(rm.ownChanges, path)
+		}
+	}
+
+	for _, path := range paths {
+		rm.ownChanges[filepath.Clean(path)] = now
+	}
+}
+
+// isOwnChange returns true if the path, or a directory containing it, was changed through this RootManager
+// within the ownChangeWindow
+func (rm *RootManager) isOwnChange(path string) bool {
+	rm.changesMu.Lock()
+	defer rm.changesMu.Unlock()
+
+	now := time.Now()
+	for path = filepath.Clean(path); ; path = filepath.Dir(path) {
+		if changed, ok := rm.ownChanges[path]; ok && now.Sub(changed) <= ownChangeWindow {
+			return true
+		}
+		if path == "." || path == string(filepath.Separator) {
+			return false
+		}
+	}
+}
+
+// Watch watches the directory tree for files created, written, removed, or renamed by other programs, such as
+// an editor or a sync client, until the context is cancelled. Changes made through the RootManager itself
+// are not reported. Events are collected until no new ones arrive for the debounce duration, then onChange is
+// called with the changed paths, relative to the root and sorted. Files inside newly created directories are
+// reported individually.
+func (rm *RootManager) Watch(ctx context.Context, debounce time.Duration, onChange func(paths []string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+
+	if _, err := rm.watchDirectory(watcher, "."); err != nil {
+		return err
+	}
+
+	pending := make(map[string]struct{})
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			rel, err := filepath.Rel(rm.path, event.Name)
+			if err != nil || rm.isOwnChange(rel) {
+				continue
+			}
+
+			// New directories aren't watched yet, and may already hold files moved in with them
+			if event.Has(fsnotify.Create) {
+				if info, err := rm.Stat(rel); err == nil && info.IsDir() {
+					files, err := rm.watchDirectory(watcher, rel)
+					if err != nil {
+						slog.Error("Error watching directory", "dir", rel, "error", err)
+					}
+					for _, file := range files {
+						pending[file] = struct{}{}
+					}
+					timer.Reset(debounce)
+					continue
+				}
+			}
+
+			pending[rel] = struct{}{}
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Error("File watcher error", "error", err)
+
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			slices.Sort(paths)
+			clear(pending)
+
+			onChange(paths)
+		}
+	}
+}
+
+// watchDirectory adds the directory and every directory below it, other than git metadata, to the watcher.
+// It returns the files found along the way.
+func (rm *RootManager) watchDirectory(watcher *fsnotify.Watcher, dir string) ([]string, error) {
+	var files []string
+	err := rm.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Continue walking despite errors
+		}
+
+		if !d.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+
+		if d.Name() == ".git" {
+			return fs.SkipDir
+		}
+
+		if err := watcher.Add(filepath.Join(rm.path, path)); err != nil {
+			return fmt.Errorf("failed to watch directory %s: %w", path, err)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// ApplyExternalChanges updates the caches for files changed outside the application, such as the paths
+// reported by RootManager.Watch. Changed files that are already indexed are rescanned in place, while added
+// or removed files rebuild the directory tree and file index. A ChangeEvent marked External is sent for each
+// indexed file that was saved or deleted.
+func (fr *FileRepository) ApplyExternalChanges(paths []string) {
+	var events []ChangeEvent
+	rebuild := false
+
+	for _, path := range paths {
+		fr.documentCache.invalidate(path)
+
+		info, err := fr.rootManager.Stat(path)
+		exists := err == nil
+		if exists && info.IsDir() {
+			continue
+		}
+
+		// A removed file, or a removed directory and every file indexed below it
+		if !exists {
+			for _, id := range fr.indexedFilesUnder(path) {
+				events = append(events, ChangeEvent{Operation: ChangeDelete, ID: id, External: true})
+				rebuild = true
+			}
+			continue
+		}
+
+		if !fr.isIndexableFile(path) || fr.isTooDeep(path) {
+			continue
+		}
+
+		id := fr.CreateID(path)
+		fr.cacheMux.RLock()
+		_, indexed := fr.fileIndex[id]
+		fr.cacheMux.RUnlock()
+
+		if indexed {
+			fr.updateScanResults(path, fr.scanFile(path, id, fr.searchIndex))
+		} else {
+			rebuild = true
+		}
+		events = append(events, ChangeEvent{Operation: ChangeSave, ID: id, External: true})
+	}
+
+	if rebuild {
+		fr.ReloadCaches()
+	}
+
+	for _, event := range events {
+		fr.notifyChange(event)
+	}
+}
+
+// indexedFilesUnder returns the IDs of indexed files at the path or below it
+func (fr *FileRepository) indexedFilesUnder(path string) []string {
+	fr.cacheMux.RLock()
+	defer fr.cacheMux.RUnlock()
+
+	var ids []string
+	path = filepath.ToSlash(path)
+	for id, info := range fr.fileIndex {
+		infoPath := filepath.ToSlash(info.Path)
+		if infoPath == path || strings.HasPrefix(infoPath, path+"/") {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	return ids
+}
+
+// WatchForExternalChanges keeps the caches current with edits made outside the application until the
+// context is cancelled
+func (fr *FileRepository) WatchForExternalChanges(ctx context.Context, debounce time.Duration) error {
+	return fr.rootManager.Watch(ctx, debounce, func(paths []string) {
+		fr.logger.Info("Detected external file changes", "files", len(paths))
+		fr.ApplyExternalChanges(paths)
+	})
+}
@@ -0,0 +1,130 @@
+package files_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// recordChanges collects the change events sent by the repository
+func recordChanges(fr *files.FileRepository) func() []files.ChangeEvent {
+	var mu sync.Mutex
+	var events []files.ChangeEvent
+	fr.OnChange(func(event files.ChangeEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	return func() []files.ChangeEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]files.ChangeEvent(nil), events...)
+	}
+}
+
+func TestFileRepository_ApplyExternalChanges_NewFile(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, _ := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+	events := recordChanges(fr)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(tmp, "resources"), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(tmp, "resources", "outside.md"), []byte("# Outside\n\nwritten by vim\n"), 0644))
+	assert.False(t, fr.FileIDExists("resources/outside"))
+
+	fr.ApplyExternalChanges([]string{"resources/outside.md"})
+
+	assert.True(t, fr.FileIDExists("resources/outside"))
+	assert.Equal(t, len(fr.Search("vim")["resources/outside"]), 1)
+	assert.Equal(t, len(events()), 1)
+	assert.Equal(t, events()[0].Operation, files.ChangeSave)
+	assert.True(t, events()[0].External)
+}
+
+func TestFileRepository_ApplyExternalChanges_EditedFile(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, _ := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	_, err = doc.Content()
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.WriteFile(filepath.Join(tmp, "inbox.md"), []byte("---\nstatus: synced\n---\n- [ ] from the phone\n"), 0644))
+	fr.ApplyExternalChanges([]string{"inbox.md"})
+
+	info, err := fr.FileInfo("inbox")
+	assert.Nil(t, err)
+	assert.Equal(t, info.Frontmatter["status"], "synced")
+	assert.Equal(t, info.TaskStats.Total, 1)
+	assert.Equal(t, len(fr.Search("phone")["inbox"]), 1)
+}
+
+func TestFileRepository_ApplyExternalChanges_RemovedDirectory(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.MkdirAll("resources/old", 0755))
+	assert.Nil(t, rm.WriteString("resources/old/one.md", "# One\n"))
+	assert.Nil(t, rm.WriteString("resources/old/two.md", "# Two\n"))
+	fr.ReloadCaches()
+	events := recordChanges(fr)
+
+	assert.Nil(t, os.RemoveAll(filepath.Join(tmp, "resources", "old")))
+	fr.ApplyExternalChanges([]string{"resources/old"})
+
+	assert.False(t, fr.FileIDExists("resources/old/one"))
+	assert.False(t, fr.FileIDExists("resources/old/two"))
+	assert.Equal(t, len(events()), 2)
+	assert.Equal(t, events()[0].Operation, files.ChangeDelete)
+	assert.Equal(t, events()[0].ID, "resources/old/one")
+}
+
+func TestRootManager_Watch(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	rm, err := files.NewRootManager(tmp)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan []string, 10)
+	watching := make(chan error, 1)
+	go func() {
+		watching <- rm.Watch(ctx, 20*time.Millisecond, func(paths []string) {
+			changes <- paths
+		})
+	}()
+
+	// Give the watcher a moment to add the directories
+	time.Sleep(100 * time.Millisecond)
+
+	// Changes made through the RootManager aren't reported
+	assert.Nil(t, rm.WriteString("own.md", "# Own\n"))
+	assert.Nil(t, os.WriteFile(filepath.Join(tmp, "external.md"), []byte("# External\n"), 0644))
+
+	select {
+	case paths := <-changes:
+		assert.Equal(t, len(paths), 1)
+		assert.Equal(t, paths[0], "external.md")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the external change")
+	}
+
+	cancel()
+	assert.Nil(t, <-watching)
+}
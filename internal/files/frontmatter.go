@@ -0,0 +1,288 @@
+package files
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// Frontmatter is the YAML frontmatter of a document, kept as lines so that editing one field leaves the
+// order, comments, and formatting of the others as they were. Only top-level fields can be edited; a field's
+// nested lines (e.g., the items of a block list) belong to it.
+type Frontmatter struct {
+	lines []string // Lines between the "---" delimiters
+}
+
+// ParseFrontmatter returns the frontmatter of content, which is empty if the content has none
+func ParseFrontmatter(content string) *Frontmatter {
+	return frontmatterFromLines(contentutil.SplitLines(content))
+}
+
+// frontmatterFromLines returns the frontmatter of content already split into lines
+func frontmatterFromLines(lines []string) *Frontmatter {
+	bounds := contentutil.FindFrontmatter(lines)
+	if !bounds.Found {
+		return &Frontmatter{}
+	}
+
+	return &Frontmatter{lines: slices.Clone(lines[bounds.Start+1 : bounds.End-1])}
+}
+
+// Frontmatter returns the document's frontmatter, which is empty if the document has none
+func (d *Document) Frontmatter() (*Frontmatter, error) {
+	content, err := d.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseFrontmatter(content), nil
+}
+
+// SaveFrontmatter replaces the document's frontmatter with fm and saves the document, leaving the rest of
+// the content as it is. An empty fm removes the frontmatter block.
+func (d *Document) SaveFrontmatter(fm *Frontmatter) error {
+	content, err := d.Content()
+	if err != nil {
+		return err
+	}
+
+	return d.Save(replaceFrontmatter(content, fm))
+}
+
+// replaceFrontmatter returns content with its frontmatter block replaced by fm, or with fm added above it when
+// it has none. An empty fm removes the block along with the blank lines after it.
+func replaceFrontmatter(content string, fm *Frontmatter) string {
+	lines := contentutil.SplitLines(content)
+	bounds := contentutil.FindFrontmatter(lines)
+
+	switch {
+	case !bounds.Found && fm.IsEmpty():
+		return content
+	case !bounds.Found:
+		return fm.String() + "\n\n" + content
+	case fm.IsEmpty():
+		return strings.TrimLeft(strings.Join(lines[bounds.End:], "\n"), "\n")
+	}
+
+	block := append([]string{"---"}, fm.lines...)
+	block = append(block, "---")
+	return strings.Join(slices.Concat(lines[:bounds.Start], block, lines[bounds.End:]), "\n")
+}
+
+// withUpdatedAt returns content with its "updated_at" frontmatter set to now, leaving the other fields as they are
+func withUpdatedAt(content string, now time.Time) string {
+	fm := ParseFrontmatter(content)
+	fm.Set("updated_at", now.Format(timestampLayout))
+	return replaceFrontmatter(content, fm)
+}
+
+// Keys returns the top-level field names, in order
+func (f *Frontmatter) Keys() []string {
+	var keys []string
+	for _, line := range f.lines {
+		if key, ok := frontmatterKey(line); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Get returns the value of a field, unquoted. A field whose value is nested under it (e.g., a block list)
+// has an empty value; use GetList for lists.
+func (f *Frontmatter) Get(key string) (string, bool) {
+	start, _, ok := f.field(key)
+	if !ok {
+		return "", false
+	}
+
+	_, value, _ := strings.Cut(f.lines[start], ":")
+	return unquoteScalar(strings.TrimSpace(value)), true
+}
+
+// GetList returns the items of a list field, written either as a flow list ("tags: [a, b]") or as a block
+// list of "- item" lines. A field with a single scalar value is a list of one.
+func (f *Frontmatter) GetList(key string) []string {
+	start, end, ok := f.field(key)
+	if !ok {
+		return nil
+	}
+
+	_, value, _ := strings.Cut(f.lines[start], ":")
+	value = strings.TrimSpace(value)
+
+	var items []string
+	switch {
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		for _, item := range splitFlowList(value[1 : len(value)-1]) {
+			if item = unquoteScalar(strings.TrimSpace(item)); item != "" {
+				items = append(items, item)
+			}
+		}
+	case value == "":
+		for _, line := range f.lines[start+1 : end] {
+			if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+				items = append(items, unquoteScalar(strings.TrimSpace(item)))
+			}
+		}
+	default:
+		items = []string{unquoteScalar(value)}
+	}
+
+	return items
+}
+
+// Set sets a field to a scalar value, quoting it when YAML would otherwise read it differently. An existing
+// field keeps its place; a new one is added at the end.
+func (f *Frontmatter) Set(key, value string) {
+	f.setLine(key, key+": "+quoteScalar(value))
+}
+
+// SetList sets a field to a flow list (e.g., "tags: [a, b]")
+func (f *Frontmatter) SetList(key string, items []string) {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = quoteScalar(item)
+		if strings.ContainsAny(item, ",[]") && quoted[i] == item {
+			quoted[i] = strconv.Quote(item)
+		}
+	}
+	f.setLine(key, key+": ["+strings.Join(quoted, ", ")+"]")
+}
+
+// Delete removes a field along with its nested lines, reporting whether it was there
+func (f *Frontmatter) Delete(key string) bool {
+	start, end, ok := f.field(key)
+	if !ok {
+		return false
+	}
+
+	f.lines = slices.Delete(f.lines, start, end)
+	return true
+}
+
+// IsEmpty reports whether the frontmatter has no lines
+func (f *Frontmatter) IsEmpty() bool {
+	return len(f.lines) == 0
+}
+
+// String returns the frontmatter block with its delimiters, or an empty string when it is empty
+func (f *Frontmatter) String() string {
+	if f.IsEmpty() {
+		return ""
+	}
+	return "---\n" + strings.Join(f.lines, "\n") + "\n---"
+}
+
+// setLine replaces a field, including its nested lines, with line, or adds line when the field is missing
+func (f *Frontmatter) setLine(key, line string) {
+	start, end, ok := f.field(key)
+	if !ok {
+		f.lines = append(f.lines, line)
+		return
+	}
+
+	f.lines = slices.Replace(f.lines, start, end, line)
+}
+
+// field returns the range of lines of a top-level field: its key line and the indented or list lines after it
+func (f *Frontmatter) field(key string) (start, end int, ok bool) {
+	for i, line := range f.lines {
+		if name, isKey := frontmatterKey(line); isKey && name == key {
+			end = i + 1
+			for end < len(f.lines) && isNestedFrontmatterLine(f.lines[end]) {
+				end++
+			}
+			return i, end, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// frontmatterKey returns the name of the top-level field that starts on line
+func frontmatterKey(line string) (string, bool) {
+	if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") ||
+		strings.HasPrefix(line, "-") || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+
+	name, _, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(name), true
+}
+
+// isNestedFrontmatterLine reports whether line continues the field above it, as an indented line or a
+// block list item
+func isNestedFrontmatterLine(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "- ") || line == "-"
+}
+
+// splitFlowList splits the inside of a flow list at the commas that aren't in a quoted item
+func splitFlowList(value string) []string {
+	var items []string
+	var quote rune
+	escaped := false
+	start := 0
+	for i, char := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && char == '\\':
+			escaped = true
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == ',':
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(items, value[start:])
+}
+
+// yamlKeywords are plain scalars that YAML reads as booleans or null rather than strings
+var yamlKeywords = []string{"true", "false", "yes", "no", "on", "off", "null", "~"}
+
+// quoteScalar double-quotes value when YAML wouldn't read it back as the same string unquoted
+func quoteScalar(value string) string {
+	needsQuotes := value == "" ||
+		value != strings.TrimSpace(value) ||
+		strings.ContainsAny(value[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(value, ": ") ||
+		strings.Contains(value, " #") ||
+		strings.ContainsAny(value, "\n\t") ||
+		slices.Contains(yamlKeywords, strings.ToLower(value))
+
+	if !needsQuotes {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// unquoteScalar removes the quotes around a double- or single-quoted YAML scalar
+func unquoteScalar(value string) string {
+	if len(value) < 2 {
+		return value
+	}
+
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+
+	return value
+}
@@ -0,0 +1,100 @@
+package files_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestFrontmatter_GetSetDelete(t *testing.T) {
+	fm := files.ParseFrontmatter(`---
+title: "Project: Alpha"
+# Keep this comment
+tags:
+  - work
+  - "q3 goals"
+status: draft
+---
+
+# Body`)
+
+	assert.Equal(t, strings.Join(fm.Keys(), ","), "title,tags,status")
+
+	title, ok := fm.Get("title")
+	assert.True(t, ok)
+	assert.Equal(t, title, "Project: Alpha")
+	assert.Equal(t, strings.Join(fm.GetList("tags"), ","), "work,q3 goals")
+
+	_, ok = fm.Get("priority")
+	assert.False(t, ok)
+
+	// Existing fields keep their place, new fields go at the end, and values are quoted as needed
+	fm.Set("status", "in-progress")
+	fm.SetList("tags", []string{"work", "plans, ideas"})
+	fm.Set("priority", "yes")
+	fm.Set("due_date", "2025-10-01")
+	assert.True(t, fm.Delete("title"))
+	assert.False(t, fm.Delete("title"))
+
+	assert.Equal(t, fm.String(), `---
+# Keep this comment
+tags: [work, "plans, ideas"]
+status: in-progress
+priority: "yes"
+due_date: 2025-10-01
+---`)
+
+	assert.Equal(t, strings.Join(fm.GetList("tags"), "|"), "work|plans, ideas")
+	priority, _ := fm.Get("priority")
+	assert.Equal(t, priority, "yes")
+}
+
+func TestFrontmatter_Empty(t *testing.T) {
+	fm := files.ParseFrontmatter("# No frontmatter\n")
+	assert.True(t, fm.IsEmpty())
+	assert.Equal(t, fm.String(), "")
+	assert.Equal(t, len(fm.GetList("tags")), 0)
+
+	fm.Set("title", "Notes")
+	assert.Equal(t, fm.String(), "---\ntitle: Notes\n---")
+}
+
+func TestDocument_SaveFrontmatter(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("resources/plan.md", "# Plan\n\nThe body stays.\n"))
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("resources/plan")
+	assert.Nil(t, err)
+
+	// A document without frontmatter gets a block above its content
+	fm, err := doc.Frontmatter()
+	assert.Nil(t, err)
+	fm.Set("status", "draft")
+	assert.Nil(t, doc.SaveFrontmatter(fm))
+
+	content, err := rm.ReadFile("resources/plan.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "---\nstatus: draft\n---\n\n# Plan\n\nThe body stays.\n")
+
+	// Removing the last field removes the block
+	fm, err = doc.Frontmatter()
+	assert.Nil(t, err)
+	fm.Delete("status")
+	assert.Nil(t, doc.SaveFrontmatter(fm))
+
+	content, err = rm.ReadFile("resources/plan.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "# Plan\n\nThe body stays.\n")
+
+	// Locked documents can't be changed
+	assert.Nil(t, doc.SetLocked(true))
+	fm, err = doc.Frontmatter()
+	assert.Nil(t, err)
+	fm.Set("status", "done")
+	assert.True(t, errors.Is(doc.SaveFrontmatter(fm), files.ErrDocumentLocked))
+}
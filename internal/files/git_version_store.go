@@ -0,0 +1,153 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// revisionIDPattern matches abbreviated or full git commit hashes, so revision IDs from requests can't be
+// mistaken for git options or revision expressions
+var revisionIDPattern = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+
+// ErrInvalidRevision is returned for revision IDs that aren't commit hashes
+var ErrInvalidRevision = errors.New("invalid revision")
+
+// gitFieldSeparator separates the fields of each commit in the git log output
+const gitFieldSeparator = "\x1f"
+
+// GitVersionStore is a VersionStore that commits each change to a git repository in the data directory,
+// using the git command-line tool
+type GitVersionStore struct {
+	dir      string
+	identity []string // "-c" options that set a commit identity when git has none configured
+	mu       sync.Mutex
+}
+
+// IsGitRepository reports whether dir is the top level of a git working tree
+func IsGitRepository(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil && info.IsDir()
+}
+
+// NewGitVersionStore creates a GitVersionStore for the data directory, initializing a git repository in it
+// if there isn't one yet. It fails if git isn't installed.
+func NewGitVersionStore(dir string) (*GitVersionStore, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git is required for version history: %w", err)
+	}
+
+	gs := &GitVersionStore{dir: dir}
+	if !IsGitRepository(dir) {
+		if _, err := gs.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Commits need an author, so fall back to one when the user hasn't configured git
+	if email, _ := gs.git("config", "user.email"); len(bytes.TrimSpace(email)) == 0 {
+		gs.identity = []string{"-c", "user.name=PADD", "-c", "user.email=padd@localhost"}
+	}
+
+	return gs, nil
+}
+
+// Record commits the current state of the given paths. Paths that are neither on disk nor tracked are
+// skipped, and nothing is committed when none of the paths changed.
+func (gs *GitVersionStore) Record(message string, paths ...string) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	var changed []string
+	for _, path := range paths {
+		path = filepath.ToSlash(path)
+		if _, err := os.Stat(filepath.Join(gs.dir, path)); err == nil {
+			changed = append(changed, path)
+		} else if tracked, _ := gs.git("ls-files", "--", path); len(tracked) > 0 {
+			changed = append(changed, path)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if _, err := gs.git(append([]string{"add", "--all", "--"}, changed...)...); err != nil {
+		return err
+	}
+
+	// diff --quiet exits with 1 when there are staged changes
+	if _, err := gs.git(append([]string{"diff", "--cached", "--quiet", "--"}, changed...)...); err == nil {
+		return nil
+	}
+
+	args := append([]string{"commit", "--quiet", "--no-verify", "-m", message, "--"}, changed...)
+	_, err := gs.git(args...)
+	return err
+}
+
+// History lists the commits that changed path, newest first
+func (gs *GitVersionStore) History(path string) ([]Revision, error) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	output, err := gs.git("log", "--format=%H"+gitFieldSeparator+"%aI"+gitFieldSeparator+"%s", "--", filepath.ToSlash(path))
+	if err != nil {
+		// A repository without any commits has no history
+		if head, _ := gs.git("rev-parse", "--verify", "--quiet", "HEAD"); len(head) == 0 {
+			return []Revision{}, nil
+		}
+		return nil, err
+	}
+
+	revisions := []Revision{}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, gitFieldSeparator, 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		committed, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			continue
+		}
+
+		revisions = append(revisions, Revision{ID: fields[0], Time: committed, Message: fields[2]})
+	}
+
+	return revisions, nil
+}
+
+// Content returns the content of path at the commit
+func (gs *GitVersionStore) Content(path, revisionID string) ([]byte, error) {
+	if !revisionIDPattern.MatchString(revisionID) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRevision, revisionID)
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return gs.git("show", revisionID+":"+filepath.ToSlash(path))
+}
+
+// git runs a git command in the data directory and returns its standard output
+func (gs *GitVersionStore) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append(slices.Clone(gs.identity), args...)...)
+	cmd.Dir = gs.dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return output, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
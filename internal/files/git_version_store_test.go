@@ -0,0 +1,110 @@
+package files_test
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func setupGitFileRepo(t *testing.T) *files.FileRepository {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tmp := t.TempDir()
+	fr, _ := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	store, err := files.NewGitVersionStore(tmp)
+	assert.Nil(t, err)
+	assert.True(t, files.IsGitRepository(tmp))
+	fr.SetVersionStore(store)
+
+	return fr
+}
+
+func TestGitVersionStore_History(t *testing.T) {
+	fr := setupGitFileRepo(t)
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Inbox\n\nFirst draft\n"))
+	assert.Nil(t, doc.Save("# Inbox\n\nSecond draft\n"))
+
+	// Saving unchanged content doesn't add a revision
+	assert.Nil(t, doc.Save("# Inbox\n\nSecond draft\n"))
+
+	revisions, err := doc.History()
+	assert.Nil(t, err)
+	assert.Equal(t, len(revisions), 2)
+	assert.Equal(t, revisions[0].Message, "Update inbox")
+	assert.False(t, revisions[0].Time.IsZero())
+
+	content, err := doc.RevisionContent(revisions[1].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Inbox\n\nFirst draft\n")
+
+	// Restoring records a new revision with the old content
+	assert.Nil(t, doc.Restore(revisions[1].ID))
+	current, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, current, "# Inbox\n\nFirst draft\n")
+
+	revisions, err = doc.History()
+	assert.Nil(t, err)
+	assert.Equal(t, len(revisions), 3)
+
+	// Other files have their own history
+	active, err := fr.GetDocument("active")
+	assert.Nil(t, err)
+	revisions, err = active.History()
+	assert.Nil(t, err)
+	assert.Equal(t, len(revisions), 0)
+}
+
+func TestGitVersionStore_RecordsCreateAndDelete(t *testing.T) {
+	fr := setupGitFileRepo(t)
+
+	assert.Nil(t, fr.CreateFile("notes.md", "# Notes\n"))
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("notes")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Delete())
+
+	revisions, err := doc.History()
+	assert.Nil(t, err)
+	assert.Equal(t, len(revisions), 2)
+	assert.Equal(t, revisions[0].Message, "Delete notes")
+	assert.Equal(t, revisions[1].Message, "Create notes")
+}
+
+func TestGitVersionStore_InvalidRevision(t *testing.T) {
+	fr := setupGitFileRepo(t)
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+
+	for _, rev := range []string{"HEAD", "--output=/tmp/x", "abc", "deadbeef:../secret"} {
+		_, err = doc.RevisionContent(rev)
+		assert.True(t, errors.Is(err, files.ErrInvalidRevision))
+	}
+}
+
+func TestDocument_HistoryWithoutVersionStore(t *testing.T) {
+	fr, _ := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+
+	_, err = doc.History()
+	assert.ErrorIs(t, err, files.ErrNoVersionStore)
+}
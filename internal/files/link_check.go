@@ -0,0 +1,103 @@
+package files
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LinkCheck holds the links found by scanning every indexed markdown file. Each map is keyed by file ID,
+// with links in the order they appear; files without links of a kind are left out of its map.
+type LinkCheck struct {
+	BrokenWiki   map[string][]string // Page names of [[Page Name]] wiki links that don't resolve to a file
+	DeadRelative map[string][]string // URLs of relative markdown links to files that don't exist
+	External     map[string][]string // Each http or https URL linked to, with the sorted IDs of the files linking to it
+}
+
+// CheckLinks scans every indexed markdown file for wiki links that don't resolve, relative markdown links to
+// files that have been removed or moved, and the external URLs it links to, which are left to the caller to
+// check. A relative link is dead when it neither resolves to an indexed file nor names a file on disk (e.g.,
+// an image or PDF kept next to the note).
+func (fr *FileRepository) CheckLinks() (LinkCheck, error) {
+	fr.cacheMux.RLock()
+	ids := make([]string, 0, len(fr.fileIndex))
+	for id, info := range fr.fileIndex {
+		if strings.HasSuffix(info.Path, ".md") {
+			ids = append(ids, id)
+		}
+	}
+	fr.cacheMux.RUnlock()
+
+	slices.Sort(ids)
+
+	check := LinkCheck{
+		BrokenWiki:   make(map[string][]string),
+		DeadRelative: make(map[string][]string),
+		External:     make(map[string][]string),
+	}
+	for _, id := range ids {
+		doc, err := fr.GetDocument(id)
+		if err != nil {
+			return LinkCheck{}, err
+		}
+
+		wiki, markdown, err := doc.Links()
+		if err != nil {
+			return LinkCheck{}, fmt.Errorf("error scanning links in %s: %w", id, err)
+		}
+
+		for _, pageName := range wiki {
+			if _, ok := fr.ResolveWikiLink(pageName); !ok {
+				check.BrokenWiki[id] = append(check.BrokenWiki[id], pageName)
+			}
+		}
+
+		for _, link := range markdown {
+			switch {
+			case isExternalURL(link.URL):
+				if !slices.Contains(check.External[link.URL], id) {
+					check.External[link.URL] = append(check.External[link.URL], id)
+				}
+			case fr.isDeadRelativeLink(doc.Info.Path, link.URL):
+				check.DeadRelative[id] = append(check.DeadRelative[id], link.URL)
+			}
+		}
+	}
+
+	return check, nil
+}
+
+// isExternalURL reports whether rawURL is an http or https URL
+func isExternalURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// isDeadRelativeLink reports whether a relative markdown link in the document at documentPath points to a
+// file that doesn't exist. Absolute paths, which can name pages of the app itself (e.g., "/daily"), URLs with
+// a scheme, and links within the page (e.g., "#tasks") aren't relative links.
+func (fr *FileRepository) isDeadRelativeLink(documentPath, rawURL string) bool {
+	target, _, _ := strings.Cut(rawURL, "#")
+	target, _, _ = strings.Cut(target, "?")
+	if target == "" || strings.HasPrefix(target, "/") || strings.Contains(target, ":") {
+		return false
+	}
+
+	if _, ok := fr.ResolveMarkdownLink(documentPath, rawURL); ok {
+		return false
+	}
+
+	unescaped, err := url.PathUnescape(target)
+	if err != nil {
+		return true
+	}
+	target = path.Join(path.Dir(filepath.ToSlash(documentPath)), unescaped)
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return true
+	}
+
+	return !fr.rootManager.FileExists(target)
+}
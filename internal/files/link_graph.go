@@ -0,0 +1,131 @@
+package files
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// GraphNode is a markdown file in the note graph
+type GraphNode struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags,omitempty"`  // Items of the file's "tags" frontmatter
+	Group string   `json:"group,omitempty"` // Cluster the node is drawn in, set by GroupByTag
+}
+
+// GraphEdge is a link from one file in the note graph to another
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Graph is the network of markdown files and the links between them
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// LinkGraph builds the note graph from the links cached when the index was built, without reading any files.
+// Every indexed markdown file is a node. Each wiki or markdown link that resolves to another indexed file is
+// an edge; links to external URLs, to missing files, and to the linking file itself are left out, and
+// repeated links between the same files are one edge. Nodes are sorted by ID and edges by source, then target.
+func (fr *FileRepository) LinkGraph() Graph {
+	fr.cacheMux.RLock()
+	infos := make([]FileInfo, 0, len(fr.fileIndex))
+	for _, info := range fr.fileIndex {
+		if strings.HasSuffix(info.Path, ".md") {
+			infos = append(infos, info)
+		}
+	}
+	fr.cacheMux.RUnlock()
+
+	slices.SortFunc(infos, func(a, b FileInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	graph := Graph{Nodes: make([]GraphNode, 0, len(infos)), Edges: []GraphEdge{}}
+	for _, info := range infos {
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: info.ID, Title: info.TitleBase, Tags: info.Tags})
+
+		for _, target := range fr.linkTargets(info) {
+			graph.Edges = append(graph.Edges, GraphEdge{Source: info.ID, Target: target.ID})
+		}
+	}
+
+	slices.SortFunc(graph.Edges, func(a, b GraphEdge) int {
+		return cmp.Or(cmp.Compare(a.Source, b.Source), cmp.Compare(a.Target, b.Target))
+	})
+
+	return graph
+}
+
+// GroupByTag puts each node in the group of its first tag, so notes that share a tag can be drawn as a
+// cluster. Untagged nodes are left ungrouped.
+func (g *Graph) GroupByTag() {
+	for i, node := range g.Nodes {
+		if len(node.Tags) > 0 {
+			g.Nodes[i].Group = node.Tags[0]
+		}
+	}
+}
+
+// Backlinks returns the markdown files that link to the file with the given ID, sorted by ID. Like the note
+// graph, it's built from the links cached when the index was built and updated as documents are saved, so
+// no files are read.
+func (fr *FileRepository) Backlinks(id string) []FileInfo {
+	fr.cacheMux.RLock()
+	infos := make([]FileInfo, 0, len(fr.fileIndex))
+	for _, info := range fr.fileIndex {
+		if info.ID != id && strings.HasSuffix(info.Path, ".md") {
+			infos = append(infos, info)
+		}
+	}
+	fr.cacheMux.RUnlock()
+
+	var backlinks []FileInfo
+	for _, info := range infos {
+		if slices.ContainsFunc(fr.linkTargets(info), func(target FileInfo) bool { return target.ID == id }) {
+			backlinks = append(backlinks, info)
+		}
+	}
+
+	slices.SortFunc(backlinks, func(a, b FileInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return backlinks
+}
+
+// Backlinks returns the markdown files that link to the document
+func (d *Document) Backlinks() []FileInfo {
+	return d.repo.Backlinks(d.repo.CreateID(d.Info.Path))
+}
+
+// linkTargets resolves the cached wiki and markdown links of a file to the other markdown files they point
+// to, in link order. Each target is listed once.
+func (fr *FileRepository) linkTargets(info FileInfo) []FileInfo {
+	var targets []FileInfo
+	seen := make(map[string]bool)
+	add := func(target FileInfo) {
+		if target.ID == info.ID || seen[target.ID] || !strings.HasSuffix(target.Path, ".md") {
+			return
+		}
+		seen[target.ID] = true
+		targets = append(targets, target)
+	}
+
+	for _, pageName := range info.Links.Wiki {
+		if target, ok := fr.ResolveWikiLink(pageName); ok {
+			add(target)
+		}
+	}
+
+	for _, link := range info.Links.Markdown {
+		if target, ok := fr.ResolveMarkdownLink(info.Path, link.URL); ok {
+			add(target)
+		}
+	}
+
+	return targets
+}
@@ -0,0 +1,241 @@
+package files
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidQuery is returned for a note query that can't be parsed
+var ErrInvalidQuery = errors.New("invalid query")
+
+// NoteQuery is a structured search over the indexed markdown files, written as space-separated terms, all of
+// which must match:
+//
+//	tag:project         the file's tags include "project"
+//	in:resources/work   the file is in the resources/work directory
+//	has:due_date        the file's frontmatter has a due_date field
+//	status:in-progress  the file's frontmatter field equals the value; any key works this way
+//	is:task             list the matching files' tasks instead of the files
+//	is:open, is:done    list only pending or only completed tasks (implies is:task)
+//	is:pinned           list only pinned tasks (implies is:task)
+//	word                the file's content contains word, or for tasks, the label does
+//
+// Matching ignores case. A term prefixed with "-" excludes what it would match, and a value with spaces can
+// be double-quoted (e.g., status:"on hold").
+type NoteQuery struct {
+	Tasks bool // Whether the query lists tasks rather than files
+	terms []queryTerm
+}
+
+// queryTerm is a single term of a note query
+type queryTerm struct {
+	key    string // Filter name ("tag", "in", "has", "is", or a frontmatter key), empty for a word
+	value  string // Lowercase value to match
+	negate bool
+}
+
+// NoteQueryResult holds the files or tasks matching a note query, sorted by file ID and then by position
+// in the file
+type NoteQueryResult struct {
+	Files []FileInfo
+	Tasks []FileTask
+}
+
+// ParseNoteQuery reads a note query. A query without terms, an unknown is: value, or an empty filter value
+// returns ErrInvalidQuery.
+func ParseNoteQuery(query string) (NoteQuery, error) {
+	var q NoteQuery
+	for _, token := range splitQueryTokens(query) {
+		term := queryTerm{}
+		if rest, ok := strings.CutPrefix(token, "-"); ok && rest != "" {
+			term.negate = true
+			token = rest
+		}
+
+		if key, value, ok := strings.Cut(token, ":"); ok && key != "" {
+			term.key = strings.ToLower(key)
+			term.value = strings.ToLower(unquoteScalar(value))
+			if term.value == "" {
+				return NoteQuery{}, fmt.Errorf("%w: %q needs a value", ErrInvalidQuery, token)
+			}
+		} else {
+			term.value = strings.ToLower(unquoteScalar(token))
+		}
+
+		if term.key == "is" {
+			switch term.value {
+			case "task":
+				if term.negate {
+					return NoteQuery{}, fmt.Errorf("%w: is:task can't be excluded", ErrInvalidQuery)
+				}
+			case "open", "done", "pinned":
+			default:
+				return NoteQuery{}, fmt.Errorf("%w: unknown is:%s", ErrInvalidQuery, term.value)
+			}
+			q.Tasks = true
+		}
+
+		q.terms = append(q.terms, term)
+	}
+
+	if len(q.terms) == 0 {
+		return NoteQuery{}, fmt.Errorf("%w: the query is empty", ErrInvalidQuery)
+	}
+
+	return q, nil
+}
+
+// splitQueryTokens splits a query at the spaces that aren't inside double quotes
+func splitQueryTokens(query string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, char := range query {
+		switch {
+		case char == '"':
+			quoted = !quoted
+			current.WriteRune(char)
+		case !quoted && (char == ' ' || char == '\t'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(char)
+		}
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// RunNoteQuery returns the indexed markdown files, or their tasks, that match the query. Like the note graph,
+// it works from the index, so no files are read. Drafts are left out. Encrypted files aren't scanned, so they
+// only match queries made of words, and only when their content is searchable.
+func (fr *FileRepository) RunNoteQuery(q NoteQuery) NoteQueryResult {
+	fr.cacheMux.RLock()
+	infos := make([]FileInfo, 0, len(fr.fileIndex))
+	for _, info := range fr.fileIndex {
+		if strings.HasSuffix(info.Path, ".md") && !info.IsDraft {
+			infos = append(infos, info)
+		}
+	}
+	fr.cacheMux.RUnlock()
+
+	slices.SortFunc(infos, func(a, b FileInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	// Words are looked up in the search index, which has every line of each file
+	var contentMatches map[string]map[string][]LineMatch
+	if !q.Tasks {
+		contentMatches = fr.queryContentMatches(q)
+	}
+
+	result := NoteQueryResult{}
+	for _, info := range infos {
+		if !q.matchesFile(info, contentMatches) {
+			continue
+		}
+
+		if !q.Tasks {
+			result.Files = append(result.Files, info)
+			continue
+		}
+
+		for _, task := range info.Tasks {
+			if q.matchesTask(task) {
+				task.FileID = info.ID
+				task.FileTitle = info.TitleBase
+				result.Tasks = append(result.Tasks, task)
+			}
+		}
+	}
+
+	return result
+}
+
+// queryContentMatches searches the index for each word of the query, returning the matching lines by word
+// and then by file ID
+func (fr *FileRepository) queryContentMatches(q NoteQuery) map[string]map[string][]LineMatch {
+	matches := make(map[string]map[string][]LineMatch)
+	for _, term := range q.terms {
+		if _, searched := matches[term.value]; term.key == "" && !searched {
+			matches[term.value] = fr.Search(term.value)
+		}
+	}
+
+	return matches
+}
+
+// matchesFile reports whether the file passes every file filter of the query. Task filters and, for task
+// queries, words are left to matchesTask.
+func (q NoteQuery) matchesFile(info FileInfo, contentMatches map[string]map[string][]LineMatch) bool {
+	for _, term := range q.terms {
+		var matched bool
+		switch term.key {
+		case "is":
+			continue
+		case "":
+			if q.Tasks {
+				continue
+			}
+			_, matched = contentMatches[term.value][info.ID]
+		case "tag":
+			matched = slices.ContainsFunc(info.Tags, func(tag string) bool {
+				return strings.EqualFold(tag, term.value)
+			})
+		case "in":
+			directory := strings.Trim(term.value, "/")
+			id := strings.ToLower(info.ID)
+			matched = strings.HasPrefix(id, directory+"/") || id == directory
+		case "has":
+			_, matched = info.Frontmatter[term.value]
+			matched = matched || (term.value == "tags" && len(info.Tags) > 0)
+		default:
+			value, ok := info.Frontmatter[term.key]
+			matched = ok && strings.EqualFold(value, term.value)
+		}
+
+		if matched == term.negate {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesTask reports whether the task passes every task filter and word of the query
+func (q NoteQuery) matchesTask(task FileTask) bool {
+	for _, term := range q.terms {
+		var matched bool
+		switch term.key {
+		case "":
+			matched = strings.Contains(strings.ToLower(task.Label), term.value)
+		case "is":
+			switch term.value {
+			case "task":
+				matched = true
+			case "open":
+				matched = !task.Completed
+			case "done":
+				matched = task.Completed
+			case "pinned":
+				matched = task.Pinned
+			}
+		default:
+			continue
+		}
+
+		if matched == term.negate {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package files_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestParseNoteQuery_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, query := range []string{"", "   ", "tag:", "is:someday", "-is:task"} {
+		_, err := files.ParseNoteQuery(query)
+		assert.True(t, errors.Is(err, files.ErrInvalidQuery))
+	}
+}
+
+func TestFileRepository_RunNoteQuery(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+
+	assert.Nil(t, rm.MkdirAll("resources/work", 0755))
+	assert.Nil(t, rm.WriteString("resources/work/site.md", "---\ntags: [project, web]\nstatus: in-progress\ndue_date: 2025-10-01\n---\n# Site\n\n- [ ] Ship the redesign\n- [x] Pick fonts\n"))
+	assert.Nil(t, rm.WriteString("resources/work/api.md", "---\ntags:\n  - Project\nstatus: \"on hold\"\n---\n# API\n\n- [ ] Write the rate limiter\n"))
+	assert.Nil(t, rm.WriteString("resources/garden.md", "---\ntags: [home]\nstatus: in-progress\n---\n# Garden\n\nPlant the redesign of the beds.\n"))
+	assert.Nil(t, rm.WriteString("resources/draft.md", "---\ntags: [project]\ndraft: true\n---\n# Draft\n"))
+	fr.ReloadCaches()
+
+	ids := func(query string) []string {
+		t.Helper()
+
+		q, err := files.ParseNoteQuery(query)
+		assert.Nil(t, err)
+		assert.False(t, q.Tasks)
+
+		result := []string{}
+		for _, info := range fr.RunNoteQuery(q).Files {
+			result = append(result, info.ID)
+		}
+		return result
+	}
+
+	assert.Equal(t, ids("tag:project"), []string{"resources/work/api", "resources/work/site"})
+	assert.Equal(t, ids("tag:project status:in-progress"), []string{"resources/work/site"})
+	assert.Equal(t, ids(`status:"ON HOLD"`), []string{"resources/work/api"})
+	assert.Equal(t, ids("status:in-progress -tag:project"), []string{"resources/garden"})
+	assert.Equal(t, ids("in:resources/work has:due_date"), []string{"resources/work/site"})
+	assert.Equal(t, ids("redesign"), []string{"resources/garden", "resources/work/site"})
+	assert.Equal(t, ids("tag:nothing"), []string{})
+
+	labels := func(query string) []string {
+		t.Helper()
+
+		q, err := files.ParseNoteQuery(query)
+		assert.Nil(t, err)
+		assert.True(t, q.Tasks)
+
+		result := []string{}
+		for _, task := range fr.RunNoteQuery(q).Tasks {
+			result = append(result, task.FileID+": "+task.Label)
+		}
+		return result
+	}
+
+	assert.Equal(t, labels("tag:project is:task"), []string{
+		"resources/work/api: Write the rate limiter",
+		"resources/work/site: Ship the redesign",
+		"resources/work/site: Pick fonts",
+	})
+	assert.Equal(t, labels("tag:project is:done"), []string{"resources/work/site: Pick fonts"})
+	assert.Equal(t, labels("is:open redesign"), []string{"resources/work/site: Ship the redesign"})
+}
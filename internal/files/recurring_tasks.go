@@ -0,0 +1,151 @@
+package files
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RecurrenceTarget controls where renewed recurring tasks are added
+type RecurrenceTarget int
+
+const (
+	// RecurInPlace adds the renewed task just above the completed one, in its original section
+	RecurInPlace RecurrenceTarget = iota
+	// RecurToInbox adds the renewed task to the top of the inbox
+	RecurToInbox
+)
+
+// RecurringTaskManager brings completed recurring tasks back as pending tasks once their next occurrence
+// comes around. See Recurrence for the @repeat and @every schedules.
+type RecurringTaskManager struct {
+	repo   *FileRepository
+	target RecurrenceTarget
+	inbox  string // ID of the document that receives renewed tasks when the target is RecurToInbox
+}
+
+// NewRecurringTaskManager creates a RecurringTaskManager that adds renewed tasks to the given target
+func NewRecurringTaskManager(repo *FileRepository, target RecurrenceTarget) *RecurringTaskManager {
+	inbox := "inbox"
+	if len(repo.config.CoreFiles) > 0 {
+		inbox = repo.CreateID(repo.config.CoreFiles[0])
+	}
+
+	return &RecurringTaskManager{
+		repo:   repo,
+		target: target,
+		inbox:  inbox,
+	}
+}
+
+// Run renews the recurring tasks that are due by now in every indexed markdown file with completed tasks,
+// stopping early when the context is cancelled. It returns the number of tasks renewed.
+//
+// With RecurToInbox, the new tasks are added to the inbox before any schedule is removed from the completed
+// ones, so a failed inbox write loses nothing: the tasks are still due on the next run.
+func (m *RecurringTaskManager) Run(ctx context.Context, now time.Time) (int, error) {
+	if m.target == RecurToInbox {
+		return m.runToInbox(ctx, now)
+	}
+
+	renewed := 0
+	for _, id := range m.candidates() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		doc, err := m.repo.GetDocument(id)
+		if err != nil {
+			m.repo.logger.Error("Error loading document for recurring tasks", "id", id, "error", err)
+			continue
+		}
+
+		labels, err := doc.RenewRecurringTasks(now)
+		if err != nil {
+			m.repo.logger.Error("Error renewing recurring tasks", "id", id, "error", err)
+			continue
+		}
+		renewed += len(labels)
+	}
+
+	return renewed, nil
+}
+
+// runToInbox adds the new tasks of the due recurring tasks to the top of the inbox, then removes the schedules
+// from the completed tasks they came from
+func (m *RecurringTaskManager) runToInbox(ctx context.Context, now time.Time) (int, error) {
+	var renewed, sources []string
+	for _, id := range m.candidates() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		doc, err := m.repo.GetDocument(id)
+		if err != nil {
+			m.repo.logger.Error("Error loading document for recurring tasks", "id", id, "error", err)
+			continue
+		}
+
+		labels, _, err := doc.dueRecurrences(now, false)
+		if err != nil {
+			m.repo.logger.Error("Error renewing recurring tasks", "id", id, "error", err)
+			continue
+		}
+		if len(labels) > 0 {
+			renewed = append(renewed, labels...)
+			sources = append(sources, id)
+		}
+	}
+
+	if len(renewed) == 0 {
+		return 0, nil
+	}
+
+	inbox, err := m.repo.GetDocument(m.inbox)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load inbox for recurring tasks: %w", err)
+	}
+
+	err = inbox.AddEntries(renewed, EntryInsertionConfig{
+		Strategy:       InsertInSection,
+		EntryFormatter: TaskEntryFormatter,
+		SectionConfig: &SectionInsertionConfig{
+			InsertAtTop: true,
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add recurring tasks to the inbox: %w", err)
+	}
+
+	// Reload each source, since the inbox may be one of them, and only then remove the schedules. A source
+	// that can't be saved keeps its schedule, so its task comes back again rather than never.
+	for _, id := range sources {
+		doc, err := m.repo.GetDocument(id)
+		if err == nil {
+			_, err = doc.DetachRecurringTasks(now)
+		}
+		if err != nil {
+			m.repo.logger.Error("Error removing renewed recurring schedules", "id", id, "error", err)
+		}
+	}
+
+	return len(renewed), nil
+}
+
+// candidates returns the IDs of the indexed markdown files with completed tasks, in a stable order
+func (m *RecurringTaskManager) candidates() []string {
+	m.repo.cacheMux.RLock()
+	defer m.repo.cacheMux.RUnlock()
+
+	var ids []string
+	for id, info := range m.repo.fileIndex {
+		if info.TaskStats.Completed > 0 && strings.HasSuffix(info.Path, ".md") {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	return ids
+}
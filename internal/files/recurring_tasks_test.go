@@ -0,0 +1,182 @@
+package files_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		label string
+		want  files.Recurrence
+		ok    bool
+	}{
+		{"Water plants @repeat(weekly)", files.Recurrence{Days: 7}, true},
+		{"Pay rent @repeat(monthly) #home", files.Recurrence{Months: 1}, true},
+		{"Rotate tires @repeat(6m)", files.Recurrence{Months: 6}, true},
+		{"Backups @repeat(2w)", files.Recurrence{Days: 14}, true},
+		{"Review @repeat(1y)", files.Recurrence{Months: 12}, true},
+		{"Stand-up @every(weekday)", files.Recurrence{Weekdays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}, true},
+		{"Gym @every(mon, Thursday)", files.Recurrence{Weekdays: []time.Weekday{time.Monday, time.Thursday}}, true},
+		{"Journal @every(day)", files.Recurrence{Days: 1}, true},
+		{"One-off task", files.Recurrence{}, false},
+		{"Bad @repeat(fortnightly)", files.Recurrence{}, false},
+		{"Bad @repeat(0d)", files.Recurrence{}, false},
+		{"Bad @every(mo)", files.Recurrence{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			got, ok := files.ParseRecurrence(tt.label)
+			assert.Equal(t, ok, tt.ok)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestRecurrence_Next(t *testing.T) {
+	// A Wednesday
+	done := time.Date(2025, time.January, 29, 15, 30, 0, 0, time.Local)
+
+	weekly, _ := files.ParseRecurrence("@repeat(weekly)")
+	assert.Equal(t, weekly.Next(done), time.Date(2025, time.February, 5, 0, 0, 0, 0, time.Local))
+
+	monthly, _ := files.ParseRecurrence("@repeat(monthly)")
+	assert.Equal(t, monthly.Next(done), time.Date(2025, time.March, 1, 0, 0, 0, 0, time.Local))
+
+	mondays, _ := files.ParseRecurrence("@every(monday)")
+	assert.Equal(t, mondays.Next(done), time.Date(2025, time.February, 3, 0, 0, 0, 0, time.Local))
+
+	wednesdays, _ := files.ParseRecurrence("@every(wed)")
+	assert.Equal(t, wednesdays.Next(done), time.Date(2025, time.February, 5, 0, 0, 0, 0, time.Local))
+}
+
+func TestDocument_RenewRecurringTasks(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+
+	content := strings.Join([]string{
+		"## Chores",
+		"",
+		"- [x] Water plants @repeat(weekly) #home @done(2025-01-01)",
+		"- [x] Pay rent @repeat(monthly) @done(2025-01-05)",
+		"- [x] One-off task @done(2025-01-01)",
+		"- [ ] Gym @every(monday)",
+		"",
+	}, "\n")
+	assert.Nil(t, rm.WriteString("resources/chores.md", content))
+	fr.ReloadCaches()
+
+	doc, err := fr.GetDocument("resources/chores")
+	assert.Nil(t, err)
+
+	renewed, err := doc.RenewRecurringTasks(time.Date(2025, time.January, 10, 9, 0, 0, 0, time.Local))
+	assert.Nil(t, err)
+	assert.Equal(t, renewed, []string{"Water plants @repeat(weekly) #home"})
+
+	updated, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, updated, strings.Join([]string{
+		"## Chores",
+		"",
+		"- [ ] Water plants @repeat(weekly) #home",
+		"- [x] Water plants #home @done(2025-01-01)",
+		"- [x] Pay rent @repeat(monthly) @done(2025-01-05)",
+		"- [x] One-off task @done(2025-01-01)",
+		"- [ ] Gym @every(monday)",
+		"",
+	}, "\n"))
+
+	// Nothing else is due yet, and the renewed task isn't renewed twice
+	renewed, err = doc.RenewRecurringTasks(time.Date(2025, time.January, 10, 9, 0, 0, 0, time.Local))
+	assert.Nil(t, err)
+	assert.Equal(t, len(renewed), 0)
+}
+
+func TestRecurringTaskManager_Inbox(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("resources/chores.md", "- [x] Take out trash @every(tue) @done(2025-01-07)\n"))
+	assert.Nil(t, rm.WriteString("resources/notes.md", "- [ ] Not done @repeat(daily)\n"))
+	fr.ReloadCaches()
+
+	manager := files.NewRecurringTaskManager(fr, files.RecurToInbox)
+	renewed, err := manager.Run(context.Background(), time.Date(2025, time.January, 14, 8, 0, 0, 0, time.Local))
+	assert.Nil(t, err)
+	assert.Equal(t, renewed, 1)
+
+	inbox, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	content, err := inbox.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "- [ ] Take out trash @every(tue)\n"))
+
+	chores, err := fr.GetDocument("resources/chores")
+	assert.Nil(t, err)
+	content, err = chores.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "- [x] Take out trash @done(2025-01-07)\n")
+}
+
+func TestRecurringTaskManager_InboxWriteFails(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("resources/chores.md", "- [x] Take out trash @every(tue) @done(2025-01-07)\n"))
+	assert.Nil(t, rm.WriteString("inbox.md", "---\nlocked: true\n---\n# Inbox\n"))
+	fr.ReloadCaches()
+
+	// The locked inbox can't take the renewed task, so the completed task keeps its schedule
+	manager := files.NewRecurringTaskManager(fr, files.RecurToInbox)
+	now := time.Date(2025, time.January, 14, 8, 0, 0, 0, time.Local)
+	_, err := manager.Run(context.Background(), now)
+	assert.ErrorIs(t, err, files.ErrDocumentLocked)
+
+	raw, err := rm.ReadFile("resources/chores.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "- [x] Take out trash @every(tue) @done(2025-01-07)\n")
+
+	// Once the inbox can be written, the task is renewed on the next run
+	assert.Nil(t, rm.WriteString("inbox.md", "# Inbox\n"))
+	fr.ReloadCaches()
+
+	renewed, err := manager.Run(context.Background(), now)
+	assert.Nil(t, err)
+	assert.Equal(t, renewed, 1)
+
+	raw, err = rm.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(raw), "- [ ] Take out trash @every(tue)"))
+
+	raw, err = rm.ReadFile("resources/chores.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(raw), "- [x] Take out trash @done(2025-01-07)\n")
+}
+
+func TestRecurringTaskManager_InboxIsSource(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("inbox.md", "# Inbox\n\n- [x] Water plants @every(tue) @done(2025-01-07)\n"))
+	fr.ReloadCaches()
+
+	manager := files.NewRecurringTaskManager(fr, files.RecurToInbox)
+	renewed, err := manager.Run(context.Background(), time.Date(2025, time.January, 14, 8, 0, 0, 0, time.Local))
+	assert.Nil(t, err)
+	assert.Equal(t, renewed, 1)
+
+	// The renewed task and the stripped schedule are both kept
+	raw, err := rm.ReadFile("inbox.md")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(raw), "- [ ] Water plants @every(tue)"))
+	assert.True(t, strings.Contains(string(raw), "- [x] Water plants @done(2025-01-07)"))
+}
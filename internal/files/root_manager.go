@@ -6,12 +6,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 // RootManager provides safe filesystem operations within a specific directory using os.Root
 type RootManager struct {
-	path string
+	path       string
+	changesMu  sync.Mutex
+	ownChanges map[string]time.Time // When each path was last written, removed, or renamed through this RootManager
 }
 
 // NewRootManager creates a new RootManager for the given directory path
@@ -28,7 +31,7 @@ func NewRootManager(path string) (*RootManager, error) {
 	}
 	_ = testRoot.Close()
 
-	return &RootManager{path: path}, nil
+	return &RootManager{path: path, ownChanges: make(map[string]time.Time)}, nil
 }
 
 // withRoot executes a function with a safely opened os.Root
@@ -57,6 +60,7 @@ func (rm *RootManager) ReadFile(filename string) ([]byte, error) {
 
 // WriteFile writes content to a file using Root.WriteFile
 func (rm *RootManager) WriteFile(filename string, content []byte, perm os.FileMode) error {
+	rm.recordChange(filename)
 	return rm.withRoot(func(root *os.Root) error {
 		return root.WriteFile(filename, content, perm)
 	})
@@ -96,8 +100,17 @@ func (rm *RootManager) MkdirAll(dir string, perm os.FileMode) error {
 	})
 }
 
+// Rename renames (moves) a file or directory using Root.Rename
+func (rm *RootManager) Rename(oldPath, newPath string) error {
+	rm.recordChange(oldPath, newPath)
+	return rm.withRoot(func(root *os.Root) error {
+		return root.Rename(oldPath, newPath)
+	})
+}
+
 // Remove removes a file using Root.Remove
 func (rm *RootManager) Remove(filename string) error {
+	rm.recordChange(filename)
 	return rm.withRoot(func(root *os.Root) error {
 		return root.Remove(filename)
 	})
@@ -105,6 +118,7 @@ func (rm *RootManager) Remove(filename string) error {
 
 // RemoveAll removes a directory and all its contents using Root.RemoveAll
 func (rm *RootManager) RemoveAll(path string) error {
+	rm.recordChange(path)
 	return rm.withRoot(func(root *os.Root) error {
 		return root.RemoveAll(path)
 	})
@@ -160,6 +174,11 @@ func (rm *RootManager) Scan(rootDir string, filter func(string, fs.DirEntry) boo
 			return nil // Continue walking despite errors
 		}
 
+		// Git metadata never holds documents, and walking it slows down every scan when history is on
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+
 		if filter != nil && !filter(path, d) {
 			return nil
 		}
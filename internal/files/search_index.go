@@ -0,0 +1,187 @@
+package files
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// LineMatch is a line of a file that matches a search query
+type LineMatch struct {
+	LineNum int    // 1-based line number
+	Line    string // The line as written in the file
+}
+
+// indexedFile holds the lines of an indexed file, along with their lowercase form for matching
+type indexedFile struct {
+	lines []string
+	lower []string
+	terms []string
+}
+
+// SearchIndex is an in-memory inverted index of file contents, keyed by file ID. Queries match
+// case-insensitive substrings of a line, like a plain text search, but only the files whose terms can
+// contain the query are scanned.
+type SearchIndex struct {
+	mu    sync.RWMutex
+	terms map[string]map[string]struct{} // Lowercase term to the IDs of the files that contain it
+	files map[string]indexedFile
+}
+
+// NewSearchIndex creates an empty SearchIndex
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{
+		terms: make(map[string]map[string]struct{}),
+		files: make(map[string]indexedFile),
+	}
+}
+
+// Update indexes the content of a file, replacing anything indexed for it before
+func (si *SearchIndex) Update(id, content string) {
+	lines := contentutil.SplitLines(content)
+	file := indexedFile{lines: lines, lower: make([]string, len(lines))}
+
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		file.lower[i] = strings.ToLower(line)
+		for _, term := range searchTerms(file.lower[i]) {
+			if !seen[term] {
+				seen[term] = true
+				file.terms = append(file.terms, term)
+			}
+		}
+	}
+
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(id)
+	si.files[id] = file
+	for _, term := range file.terms {
+		ids, ok := si.terms[term]
+		if !ok {
+			ids = make(map[string]struct{})
+			si.terms[term] = ids
+		}
+		ids[id] = struct{}{}
+	}
+}
+
+// Remove drops a file from the index
+func (si *SearchIndex) Remove(id string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.remove(id)
+}
+
+// remove drops a file from the index. The caller must hold the write lock.
+func (si *SearchIndex) remove(id string) {
+	file, ok := si.files[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range file.terms {
+		delete(si.terms[term], id)
+		if len(si.terms[term]) == 0 {
+			delete(si.terms, term)
+		}
+	}
+	delete(si.files, id)
+}
+
+// replaceWith swaps in the contents of another index, so a rebuilt index can replace this one in place
+func (si *SearchIndex) replaceWith(other *SearchIndex) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	si.terms = other.terms
+	si.files = other.files
+}
+
+// Search returns the lines containing the query, ignoring case, by file ID
+func (si *SearchIndex) Search(query string) map[string][]LineMatch {
+	query = strings.ToLower(query)
+	results := make(map[string][]LineMatch)
+	if query == "" {
+		return results
+	}
+
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	for id := range si.candidates(query) {
+		file := si.files[id]
+		for i, line := range file.lower {
+			if strings.Contains(line, query) {
+				results[id] = append(results[id], LineMatch{LineNum: i + 1, Line: file.lines[i]})
+			}
+		}
+	}
+
+	return results
+}
+
+// Len returns the number of indexed files
+func (si *SearchIndex) Len() int {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	return len(si.files)
+}
+
+// candidates returns the IDs of the files that could contain the lowercase query: every term of the query
+// must be part of a term in the file. A query without any terms (e.g., only punctuation) can match any file.
+// The caller must hold the read lock.
+func (si *SearchIndex) candidates(query string) map[string]struct{} {
+	queryTerms := searchTerms(query)
+	if len(queryTerms) == 0 {
+		all := make(map[string]struct{}, len(si.files))
+		for id := range si.files {
+			all[id] = struct{}{}
+		}
+		return all
+	}
+
+	var result map[string]struct{}
+	for _, queryTerm := range queryTerms {
+		matching := make(map[string]struct{})
+		for term, ids := range si.terms {
+			if !strings.Contains(term, queryTerm) {
+				continue
+			}
+			for id := range ids {
+				if result == nil {
+					matching[id] = struct{}{}
+				} else if _, ok := result[id]; ok {
+					matching[id] = struct{}{}
+				}
+			}
+		}
+
+		result = matching
+		if len(result) == 0 {
+			break
+		}
+	}
+
+	return result
+}
+
+// searchTerms splits lowercase text into runs of letters and digits
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Search returns the lines of the indexed files that contain the query, ignoring case, by file ID. The index
+// is built with the file caches and kept up to date as documents are saved and deleted, so no files are read.
+func (fr *FileRepository) Search(query string) map[string][]LineMatch {
+	return fr.searchIndex.Search(query)
+}
@@ -0,0 +1,134 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/crypto"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestSearchIndex_Search(t *testing.T) {
+	t.Parallel()
+
+	index := files.NewSearchIndex()
+	index.Update("inbox", "# Inbox\n\n- [ ] Call the Plumber\n- [ ] Water plants")
+	index.Update("active", "# Active\n\nPlumbing estimate: $400\n")
+
+	tests := []struct {
+		name  string
+		query string
+		want  map[string][]files.LineMatch
+	}{
+		{
+			name:  "substring of a term, ignoring case",
+			query: "PLUMB",
+			want: map[string][]files.LineMatch{
+				"inbox":  {{LineNum: 3, Line: "- [ ] Call the Plumber"}},
+				"active": {{LineNum: 3, Line: "Plumbing estimate: $400"}},
+			},
+		},
+		{
+			name:  "phrase across terms",
+			query: "the plumber",
+			want:  map[string][]files.LineMatch{"inbox": {{LineNum: 3, Line: "- [ ] Call the Plumber"}}},
+		},
+		{
+			name:  "terms that are in the file but not on one line",
+			query: "inbox water",
+			want:  map[string][]files.LineMatch{},
+		},
+		{
+			name:  "punctuation only",
+			query: "$",
+			want:  map[string][]files.LineMatch{"active": {{LineNum: 3, Line: "Plumbing estimate: $400"}}},
+		},
+		{
+			name:  "no match",
+			query: "zebra",
+			want:  map[string][]files.LineMatch{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := index.Search(tt.query)
+			assert.Equal(t, len(got), len(tt.want))
+			for id, lines := range tt.want {
+				assert.Equal(t, len(got[id]), len(lines))
+				for i := range lines {
+					assert.Equal(t, got[id][i], lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchIndex_UpdateAndRemove(t *testing.T) {
+	t.Parallel()
+
+	index := files.NewSearchIndex()
+	index.Update("inbox", "old plans")
+	index.Update("inbox", "new ideas")
+
+	assert.Equal(t, len(index.Search("plans")), 0)
+	assert.Equal(t, len(index.Search("ideas")), 1)
+
+	index.Remove("inbox")
+	assert.Equal(t, len(index.Search("ideas")), 0)
+	assert.Equal(t, index.Len(), 0)
+}
+
+func TestFileRepository_Search(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+
+	// Built with the caches
+	assert.Nil(t, rm.MkdirAll("resources", 0755))
+	assert.Nil(t, rm.WriteString("resources/garden.md", "# Garden\n\nPlant the tomatoes.\n"))
+	fr.ReloadCaches()
+	assert.Equal(t, len(fr.Search("tomatoes")["resources/garden"]), 1)
+
+	// Updated on save
+	doc, err := fr.GetDocument("resources/garden")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("# Garden\n\nPlant the peppers.\n"))
+	assert.Equal(t, len(fr.Search("tomatoes")), 0)
+	assert.Equal(t, fr.Search("peppers")["resources/garden"][0].LineNum, 3)
+
+	// Removed on delete
+	assert.Nil(t, doc.Delete())
+	assert.Equal(t, len(fr.Search("peppers")), 0)
+}
+
+func TestFileRepository_Search_Encrypted(t *testing.T) {
+	t.Parallel()
+	fr, rm := setupEncryptedDirRepo(t, true)
+
+	doc, err := fr.GetOrCreateResourceDocument("private/secret", "", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("---\nencrypted: true\n---\n# Secret\n\nThe launch codes\n"))
+	raw, err := rm.ReadFile("resources/private/secret.md")
+	assert.Nil(t, err)
+	assert.True(t, crypto.IsAgeEncrypted(raw))
+
+	// Encrypted files are searchable by their decrypted content, both on save and when the index is rebuilt
+	assert.Equal(t, len(fr.Search("launch codes")["resources/private/secret"]), 1)
+	fr.ReloadCaches()
+	assert.Equal(t, len(fr.Search("launch codes")["resources/private/secret"]), 1)
+
+	fr.SetExcludeEncrypted(true)
+	fr.ReloadCaches()
+	assert.Equal(t, len(fr.Search("launch codes")), 0)
+	assert.Nil(t, doc.Save("---\nencrypted: true\n---\n# Secret\n\nThe launch codes, again\n"))
+	assert.Equal(t, len(fr.Search("launch codes")), 0)
+
+	// Without identities, the ciphertext is never indexed
+	fr.SetExcludeEncrypted(false)
+	fr.SetEncryptionManager(crypto.NewEncryptionManager())
+	fr.ReloadCaches()
+	assert.Equal(t, len(fr.Search("launch codes")), 0)
+	assert.Equal(t, len(fr.Search("age-encryption")), 0)
+}
@@ -0,0 +1,196 @@
+package files
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// tableDelimiterPattern matches the delimiter row under a GFM table's header, e.g., "|---|:--:|"
+var tableDelimiterPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// TableDocument edits the GFM tables in a markdown document, cell by cell. Tables are numbered in document
+// order from 0, skipping the frontmatter and fenced code blocks, and rows are numbered from 0 for the header
+// row, the same way CSVDocument numbers records.
+type TableDocument struct {
+	*Document
+}
+
+// MarkdownTable is a GFM table found in a markdown document
+type MarkdownTable struct {
+	StartLine int        // Index of the header row's line
+	EndLine   int        // Index just past the table's last line
+	Rows      [][]string // Cell values by row, with the header row first and escaped pipes unescaped
+}
+
+// NewTableDocument creates a new table document
+func NewTableDocument(doc *Document) *TableDocument {
+	return &TableDocument{
+		Document: doc,
+	}
+}
+
+// GetTables returns the tables in the document
+func (t *TableDocument) GetTables() ([]MarkdownTable, error) {
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+
+	return findTables(strings.Split(t.content, "\n")), nil
+}
+
+// GetTable returns the table at the given position in the document
+func (t *TableDocument) GetTable(table int) (*MarkdownTable, error) {
+	tables, err := t.GetTables()
+	if err != nil {
+		return nil, err
+	}
+
+	if table < 0 || table >= len(tables) {
+		return nil, fmt.Errorf("table index %d out of range (document has %d tables)", table, len(tables))
+	}
+
+	return &tables[table], nil
+}
+
+// GetCell returns the value of a cell. Rows with fewer cells than the header read as empty.
+func (t *TableDocument) GetCell(table, row, col int) (string, error) {
+	found, err := t.GetTable(table)
+	if err != nil {
+		return "", err
+	}
+
+	if err := found.checkCell(row, col); err != nil {
+		return "", err
+	}
+
+	if col >= len(found.Rows[row]) {
+		return "", nil
+	}
+
+	return found.Rows[row][col], nil
+}
+
+// UpdateCell sets the value of a cell and saves the document. Line breaks in the value become spaces and
+// pipes are escaped, so the value stays in its cell. The row is rewritten as "| a | b |", keeping its
+// indentation.
+func (t *TableDocument) UpdateCell(table, row, col int, value string) error {
+	found, err := t.GetTable(table)
+	if err != nil {
+		return err
+	}
+
+	if err := found.checkCell(row, col); err != nil {
+		return err
+	}
+
+	value = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(value)
+
+	cells := make([]string, max(len(found.Rows[row]), col+1))
+	copy(cells, found.Rows[row])
+	cells[col] = strings.TrimSpace(value)
+
+	lines := strings.Split(t.content, "\n")
+	lineIndex := found.StartLine
+	if row > 0 {
+		lineIndex += row + 1 // Skip the delimiter row
+	}
+
+	indent := lines[lineIndex][:len(lines[lineIndex])-len(strings.TrimLeft(lines[lineIndex], " \t"))]
+	lines[lineIndex] = indent + formatTableRow(cells)
+
+	return t.Save(strings.Join(lines, "\n"))
+}
+
+// checkCell returns an error if the cell is outside the table. Columns are limited by the header row.
+func (m *MarkdownTable) checkCell(row, col int) error {
+	if row < 0 || row >= len(m.Rows) {
+		return fmt.Errorf("row index %d out of range (table has %d rows)", row, len(m.Rows))
+	}
+
+	if col < 0 || col >= len(m.Rows[0]) {
+		return fmt.Errorf("column index %d out of range (table has %d columns)", col, len(m.Rows[0]))
+	}
+
+	return nil
+}
+
+// findTables returns the GFM tables in the lines. A table is a row of cells followed by a delimiter row
+// with the same number of columns, and runs until a blank line or a line without a pipe.
+func findTables(lines []string) []MarkdownTable {
+	var tables []MarkdownTable
+
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		start = bounds.End + 1
+	}
+
+	inFence := false
+	for i := start; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+
+		if inFence || i+1 >= len(lines) || !strings.Contains(lines[i], "|") || !tableDelimiterPattern.MatchString(lines[i+1]) {
+			continue
+		}
+
+		header := splitTableRow(lines[i])
+		if len(header) != len(splitTableRow(lines[i+1])) {
+			continue
+		}
+
+		table := MarkdownTable{StartLine: i, Rows: [][]string{header}}
+		end := i + 2
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" && strings.Contains(lines[end], "|") {
+			table.Rows = append(table.Rows, splitTableRow(lines[end]))
+			end++
+		}
+		table.EndLine = end
+
+		tables = append(tables, table)
+		i = end - 1
+	}
+
+	return tables
+}
+
+// splitTableRow splits a table row into its trimmed cell values, unescaping escaped pipes
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// formatTableRow writes cell values as a table row, escaping pipes in the values
+func formatTableRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+	}
+
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
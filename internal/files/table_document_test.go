@@ -0,0 +1,70 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestTableDocument_GetTables(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, `# Groceries
+
+| Item | Qty | Note |
+|:-----|----:|------|
+| Milk | 2 | a \| b |
+| Eggs |
+
+`+"```"+`
+| Not | A table |
+|-----|---------|
+`+"```"+`
+
+Name | Phone
+--- | ---
+Sam | 555
+`)
+
+	tables, err := files.NewTableDocument(doc).GetTables()
+	assert.Nil(t, err)
+	assert.Equal(t, len(tables), 2)
+
+	assert.Equal(t, tables[0].StartLine, 2)
+	assert.Equal(t, tables[0].EndLine, 6)
+	assert.Equal(t, tables[0].Rows, [][]string{
+		{"Item", "Qty", "Note"},
+		{"Milk", "2", "a | b"},
+		{"Eggs"},
+	})
+
+	assert.Equal(t, tables[1].Rows, [][]string{{"Name", "Phone"}, {"Sam", "555"}})
+}
+
+func TestTableDocument_UpdateCell(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, "# Groceries\n\n| Item | Qty |\n|------|-----|\n| Milk | 2 |\n| Eggs |\n")
+	tables := files.NewTableDocument(doc)
+
+	assert.Nil(t, tables.UpdateCell(0, 1, 1, "3"))
+	assert.Nil(t, tables.UpdateCell(0, 2, 1, "a dozen\n| or two"))
+	assert.Nil(t, tables.UpdateCell(0, 0, 0, "Food"))
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, content, "# Groceries\n\n| Food | Qty |\n|------|-----|\n| Milk | 3 |\n| Eggs | a dozen \\| or two |\n")
+
+	value, err := tables.GetCell(0, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, value, "a dozen | or two")
+
+	// Missing cells read as empty, but cells outside the table are errors
+	assert.Nil(t, doc.Save("| A | B |\n|---|---|\n| 1 |\n"))
+	value, err = tables.GetCell(0, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, value, "")
+
+	assert.NotNil(t, tables.UpdateCell(0, 1, 2, "x"))
+	assert.NotNil(t, tables.UpdateCell(0, 2, 0, "x"))
+	assert.NotNil(t, tables.UpdateCell(1, 0, 0, "x"))
+}
@@ -0,0 +1,136 @@
+package files
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// statusPattern matches the @status(...) annotation that places a task in a column of a task board
+var statusPattern = regexp.MustCompile(`\s*@status\(([^)]*)\)`)
+
+// BoardGrouping controls how a document's tasks are split into the columns of a task board
+type BoardGrouping int
+
+const (
+	// BoardBySection makes a column for each section (## and deeper headings) of the document
+	BoardBySection BoardGrouping = iota
+	// BoardByStatus makes a column for each @status(...) value used in the document
+	BoardByStatus
+)
+
+// BoardColumn is one column of a task board
+type BoardColumn struct {
+	Name  string // The section's heading text or the status; empty for tasks outside any section or status
+	Key   string // Identifies the column to MoveTaskToColumn: the heading line, or the status
+	Cards []BoardCard
+}
+
+// BoardCard is a task shown on a task board
+type BoardCard struct {
+	TaskID    int
+	Label     string // The task label without its @status annotation
+	Completed bool
+}
+
+// TaskBoard groups the document's tasks into board columns, in the order the columns first appear in the
+// document. Grouped by section, every section is a column, even an empty one, so tasks can be moved into
+// it; tasks above the first section get an unnamed column. Grouped by status, tasks without a @status
+// annotation get an unnamed column.
+func (d *Document) TaskBoard(grouping BoardGrouping) ([]BoardColumn, error) {
+	tasks, err := d.getAllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []BoardColumn
+	positions := make(map[string]int)
+	addCard := func(name, key string, task *Task) {
+		i, ok := positions[key]
+		if !ok {
+			i = len(columns)
+			positions[key] = i
+			columns = append(columns, BoardColumn{Name: name, Key: key})
+		}
+
+		if task != nil {
+			columns[i].Cards = append(columns[i].Cards, BoardCard{
+				TaskID:    task.ID,
+				Label:     strings.TrimSpace(statusPattern.ReplaceAllString(task.Label, "")),
+				Completed: task.IsChecked,
+			})
+		}
+	}
+
+	if grouping == BoardByStatus {
+		for i := range tasks {
+			addCard(tasks[i].Status, tasks[i].Status, &tasks[i])
+		}
+		return columns, nil
+	}
+
+	var name, key string
+	next := 0
+	for i, line := range strings.Split(d.content, "\n") {
+		if heading, ok := headingText(line); ok && strings.HasPrefix(line, "##") {
+			name, key = heading, strings.TrimSpace(line)
+			addCard(name, key, nil)
+			continue
+		}
+
+		if next < len(tasks) && tasks[next].LineIndex == i {
+			addCard(name, key, &tasks[next])
+			next++
+		}
+	}
+
+	return columns, nil
+}
+
+// HasTaskStatuses reports whether any task in the document has a @status annotation
+func (d *Document) HasTaskStatuses() (bool, error) {
+	tasks, err := d.getAllTasks()
+	if err != nil {
+		return false, err
+	}
+
+	for _, task := range tasks {
+		if task.Status != "" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// SetTaskStatus replaces a task's @status annotation with the given status, or removes it when the status
+// is empty
+func (d *Document) SetTaskStatus(taskID int, status string) (*Task, error) {
+	task, err := d.findTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	status = strings.TrimSpace(status)
+	if strings.ContainsAny(status, "()\r\n") {
+		return nil, fmt.Errorf("invalid task status %q", status)
+	}
+
+	label := strings.TrimSpace(statusPattern.ReplaceAllString(task.Label, ""))
+	if status != "" {
+		label += " @status(" + status + ")"
+	}
+
+	return d.UpdateTaskLabel(taskID, label)
+}
+
+// MoveTaskToColumn moves a task to the board column with the given key. Grouped by section, the task and its
+// nested lines move to the top of that section; grouped by status, the task's @status annotation changes.
+func (d *Document) MoveTaskToColumn(taskID int, grouping BoardGrouping, key string) error {
+	if grouping == BoardByStatus {
+		_, err := d.SetTaskStatus(taskID, key)
+		return err
+	}
+
+	return d.MoveTask(taskID, d, key)
+}
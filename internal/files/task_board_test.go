@@ -0,0 +1,90 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestDocument_TaskBoard_BySection(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, `# Active
+
+- [ ] Loose task
+
+## Today
+
+- [ ] Write report
+- [x] Email Sam @done(2025-09-01)
+
+## Later
+
+### Someday
+`)
+
+	columns, err := doc.TaskBoard(files.BoardBySection)
+	assert.Nil(t, err)
+	assert.Equal(t, len(columns), 4)
+
+	assert.Equal(t, columns[0].Name, "")
+	assert.Equal(t, len(columns[0].Cards), 1)
+
+	assert.Equal(t, columns[1].Name, "Today")
+	assert.Equal(t, columns[1].Key, "## Today")
+	assert.Equal(t, columns[1].Cards, []files.BoardCard{
+		{TaskID: 2, Label: "Write report"},
+		{TaskID: 3, Label: "Email Sam @done(2025-09-01)", Completed: true},
+	})
+
+	// Empty sections are columns too, so tasks can be moved into them
+	assert.Equal(t, columns[3].Key, "### Someday")
+	assert.Equal(t, len(columns[3].Cards), 0)
+
+	assert.Nil(t, doc.MoveTaskToColumn(2, files.BoardBySection, "### Someday"))
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "### Someday\n\n- [ ] Write report\n"))
+}
+
+func TestDocument_TaskBoard_ByStatus(t *testing.T) {
+	t.Parallel()
+	doc := setupTaskDocument(t, `# Active
+
+- [ ] Write report @status(doing)
+- [ ] Plan trip
+- [ ] Call Sam @status(todo) #home
+- [ ] Pay rent @status(doing)
+`)
+
+	hasStatuses, err := doc.HasTaskStatuses()
+	assert.Nil(t, err)
+	assert.True(t, hasStatuses)
+
+	columns, err := doc.TaskBoard(files.BoardByStatus)
+	assert.Nil(t, err)
+	assert.Equal(t, len(columns), 3)
+	assert.Equal(t, columns[0].Key, "doing")
+	assert.Equal(t, columns[0].Cards, []files.BoardCard{
+		{TaskID: 1, Label: "Write report"},
+		{TaskID: 4, Label: "Pay rent"},
+	})
+	assert.Equal(t, columns[1].Key, "")
+	assert.Equal(t, columns[2].Cards, []files.BoardCard{{TaskID: 3, Label: "Call Sam #home"}})
+
+	assert.Nil(t, doc.MoveTaskToColumn(3, files.BoardByStatus, "doing"))
+	assert.Nil(t, doc.MoveTaskToColumn(1, files.BoardByStatus, ""))
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(content, "- [ ] Write report\n"))
+	assert.True(t, strings.Contains(content, "- [ ] Call Sam #home @status(doing)\n"))
+
+	task, err := doc.GetTask(3)
+	assert.Nil(t, err)
+	assert.Equal(t, task.Status, "doing")
+
+	_, err = doc.SetTaskStatus(2, "done)")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,93 @@
+package files
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// FileTask is a task found in an indexed file, along with where it is
+type FileTask struct {
+	FileID    string `json:"file_id"`
+	FileTitle string `json:"file_title"`
+	Section   string `json:"section,omitempty"` // Text of the nearest heading above the task, without the #s
+	ID        int    `json:"id"`                // Position among the file's tasks, as used by the task endpoints
+	Line      int    `json:"line"`              // 1-based line number in the file
+	Label     string `json:"label"`
+	Completed bool   `json:"completed"`
+	Pinned    bool   `json:"pinned,omitempty"`
+	Recurring bool   `json:"recurring,omitempty"`
+}
+
+// scanTasks collects the tasks in the lines along with the heading each one falls under. The file fields are
+// left empty, since a scan only sees the content.
+func scanTasks(lines []string) []FileTask {
+	var tasks []FileTask
+	var section string
+
+	for i, line := range lines {
+		if heading, ok := headingText(line); ok {
+			section = heading
+			continue
+		}
+
+		matches := taskListPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+
+		label := strings.TrimSpace(matches[3])
+		_, recurring := ParseRecurrence(label)
+		tasks = append(tasks, FileTask{
+			Section:   section,
+			ID:        len(tasks) + 1,
+			Line:      i + 1,
+			Label:     label,
+			Completed: matches[2] != " ",
+			Pinned:    pinPattern.MatchString(label),
+			Recurring: recurring,
+		})
+	}
+
+	return tasks
+}
+
+// headingText returns the text of an ATX heading line (e.g., "## Inbox Dump" is "Inbox Dump")
+func headingText(line string) (string, bool) {
+	trimmed := strings.TrimLeft(line, "#")
+	level := len(line) - len(trimmed)
+	if level == 0 || level > 6 || (trimmed != "" && !strings.HasPrefix(trimmed, " ")) {
+		return "", false
+	}
+
+	return strings.TrimSpace(trimmed), true
+}
+
+// AllTasks returns every task in the indexed markdown files, sorted by file ID and then by position in the
+// file. Tasks are cached with each file's scan when the index is built and updated as files are saved or
+// changed on disk, so no files are read. Encrypted files are left out.
+func (fr *FileRepository) AllTasks() []FileTask {
+	fr.cacheMux.RLock()
+	infos := make([]FileInfo, 0, len(fr.fileIndex))
+	for _, info := range fr.fileIndex {
+		if len(info.Tasks) > 0 {
+			infos = append(infos, info)
+		}
+	}
+	fr.cacheMux.RUnlock()
+
+	slices.SortFunc(infos, func(a, b FileInfo) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	tasks := []FileTask{}
+	for _, info := range infos {
+		for _, task := range info.Tasks {
+			task.FileID = info.ID
+			task.FileTitle = info.TitleBase
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks
+}
@@ -0,0 +1,33 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+)
+
+func TestFileRepository_AllTasks(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	fr, rm := setupTestFileRepo(t, tmp)
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.WriteString("inbox.md", "- [ ] Loose task\n"))
+	assert.Nil(t, rm.WriteString("active.md", "# Active\n\n## Work\n\n- [x] Ship it @done(2025-01-02)\n\n## Home\n\n- [ ] Water plants @repeat(weekly) 📌\n"))
+	fr.ReloadCaches()
+
+	assert.Equal(t, fr.AllTasks(), []files.FileTask{
+		{FileID: "active", FileTitle: "Active", Section: "Work", ID: 1, Line: 5, Label: "Ship it @done(2025-01-02)", Completed: true},
+		{FileID: "active", FileTitle: "Active", Section: "Home", ID: 2, Line: 9, Label: "Water plants @repeat(weekly) 📌", Pinned: true, Recurring: true},
+		{FileID: "inbox", FileTitle: "Inbox", ID: 1, Line: 1, Label: "Loose task"},
+	})
+
+	// Saving a document updates its cached tasks
+	doc, err := fr.GetDocument("inbox")
+	assert.Nil(t, err)
+	assert.Nil(t, doc.Save("Nothing to do"))
+
+	tasks := fr.AllTasks()
+	assert.Equal(t, len(tasks), 2)
+	assert.Equal(t, tasks[0].FileID, "active")
+}
@@ -0,0 +1,128 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// templatesDirectory holds the templates that seed new content: templates/daily.md and templates/journal.md
+// for the day sections of the temporal directories, and any other template for new resources
+const templatesDirectory = "templates"
+
+// ErrTemplateNotFound is returned when a new file names a template that doesn't exist
+var ErrTemplateNotFound = errors.New("template not found")
+
+// TemplateData holds the variables a template can use (e.g., {{.Date}})
+type TemplateData struct {
+	Date    string // e.g., "2025-09-15"
+	Day     string // Day header text, e.g., "Monday, September 15, 2025"
+	Weekday string // e.g., "Monday"
+	Month   string // e.g., "September"
+	Year    int
+	Title   string   // Title of the new file; empty for day sections
+	Tags    []string // Tags given for the new file; empty for day sections
+}
+
+// newTemplateData returns the template variables for a date
+func newTemplateData(date time.Time) TemplateData {
+	return TemplateData{
+		Date:    date.Format("2006-01-02"),
+		Day:     date.Format(dayHeaderLayout),
+		Weekday: date.Format("Monday"),
+		Month:   date.Format("January"),
+		Year:    date.Year(),
+	}
+}
+
+// NoteTemplates returns the names of the templates for new resources, without ".md", sorted. The day
+// templates of the temporal directories aren't included.
+func (fr *FileRepository) NoteTemplates() []string {
+	entries, err := fr.rootManager.ReadDir(templatesDirectory)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".md")
+		if !ok || entry.IsDir() || slices.Contains(fr.config.temporalDirectories, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// expandTemplate reads templates/<name>.md from the data directory and expands it with data. A missing
+// template returns ErrTemplateNotFound.
+func (fr *FileRepository) expandTemplate(name string, data TemplateData) (string, error) {
+	templatePath := path.Join(templatesDirectory, name+".md")
+	if !fr.rootManager.FileExists(templatePath) {
+		return "", fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	content, err := fr.rootManager.ReadFile(templatePath)
+	if err != nil {
+		return "", fmt.Errorf("error reading template %s: %w", templatePath, err)
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", fmt.Errorf("error parsing template %s: %w", templatePath, err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("error expanding template %s: %w", templatePath, err)
+	}
+
+	return sb.String(), nil
+}
+
+// noteTemplateContent expands a note template for a new file at filePath, with the file's title and the given
+// tags. Only the names returned by NoteTemplates can be used.
+func (fr *FileRepository) noteTemplateContent(name, filePath string, tags []string) (string, error) {
+	if !slices.Contains(fr.NoteTemplates(), name) {
+		return "", fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	data := newTemplateData(time.Now())
+	_, data.Title = fr.DisplayName(filePath)
+	data.Tags = tags
+
+	return fr.expandTemplate(name, data)
+}
+
+// daySectionTemplate returns the lines of the expanded template for a new day section of the temporal file at
+// filePath (e.g., templates/journal.md for journal files), or nil when its directory has no template. A
+// template that can't be expanded is logged and skipped, so entries are still added.
+func (fr *FileRepository) daySectionTemplate(filePath string, date time.Time) []string {
+	directory, _, _ := strings.Cut(filePath, "/")
+	if !slices.Contains(fr.config.temporalDirectories, directory) {
+		return nil
+	}
+
+	content, err := fr.expandTemplate(directory, newTemplateData(date))
+	if errors.Is(err, ErrTemplateNotFound) {
+		return nil
+	} else if err != nil {
+		fr.logger.Warn("Error expanding day template", "directory", directory, "error", err)
+		return nil
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil
+	}
+
+	return append([]string{""}, contentutil.SplitLines(content)...)
+}
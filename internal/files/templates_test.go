@@ -0,0 +1,125 @@
+package files_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/contentutil"
+	"github.com/patrickward/padd/internal/files"
+)
+
+// withoutFrontmatter drops the created_at frontmatter that new files get, along with the blank lines after it
+func withoutFrontmatter(content string) string {
+	lines := contentutil.SplitLines(content)
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		lines = lines[bounds.End:]
+	}
+	return strings.TrimLeft(strings.Join(lines, "\n"), "\n")
+}
+
+func TestFileRepository_DayTemplate(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.MkdirAll("templates", 0755))
+	assert.Nil(t, rm.WriteString("templates/daily.md", "### Plan for {{.Weekday}}\n\n- [ ] Review {{.Month}} {{.Year}} goals ({{.Date}})\n"))
+	fr.ReloadCaches()
+
+	config := files.EntryInsertionConfig{EntryFormatter: files.TimestampEntryFormatter}
+
+	// A new file starts with the day section from the template
+	config.EntryTimestamp = time.Date(2025, 9, 15, 10, 0, 0, 0, time.UTC)
+	doc, err := fr.AddTemporalEntry("daily", "Shipped the release", config)
+	assert.Nil(t, err)
+
+	// A new day section in an existing file gets the template too
+	config.EntryTimestamp = time.Date(2025, 9, 16, 9, 0, 0, 0, time.UTC)
+	_, err = fr.AddTemporalEntry("daily", "Wrote the notes", config)
+	assert.Nil(t, err)
+
+	content, err := rm.ReadFile(doc.Info.Path)
+	assert.Nil(t, err)
+
+	want := `## Tuesday, September 16, 2025
+
+### 09:00:00 AM
+
+Wrote the notes
+
+### Plan for Tuesday
+
+- [ ] Review September 2025 goals (2025-09-16)
+
+## Monday, September 15, 2025
+
+### 10:00:00 AM
+
+Shipped the release
+
+### Plan for Monday
+
+- [ ] Review September 2025 goals (2025-09-15)
+`
+	assert.Equal(t, withoutFrontmatter(string(content)), want)
+
+	// Journal files have no template
+	config.EntryTimestamp = time.Date(2025, 9, 15, 21, 0, 0, 0, time.UTC)
+	doc, err = fr.AddTemporalEntry("journal", "A good day", config)
+	assert.Nil(t, err)
+	content, err = rm.ReadFile(doc.Info.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, withoutFrontmatter(string(content)), "## Monday, September 15, 2025\n\n### 09:00:00 PM\n\nA good day\n")
+}
+
+func TestFileRepository_DayTemplate_Invalid(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Nil(t, rm.MkdirAll("templates", 0755))
+	assert.Nil(t, rm.WriteString("templates/journal.md", "Mood: {{.Mood}}\n"))
+	fr.ReloadCaches()
+
+	// A template that can't be expanded is skipped rather than failing the entry
+	config := files.EntryInsertionConfig{EntryFormatter: files.NoteEntryFormatter, EntryTimestamp: time.Date(2025, 9, 15, 21, 0, 0, 0, time.UTC)}
+	doc, err := fr.AddTemporalEntry("journal", "A good day", config)
+	assert.Nil(t, err)
+	content, err := rm.ReadFile(doc.Info.Path)
+	assert.Nil(t, err)
+	assert.Equal(t, withoutFrontmatter(string(content)), "## Monday, September 15, 2025\n\nA good day\n")
+}
+
+func TestFileRepository_NoteTemplates(t *testing.T) {
+	fr, rm := setupTestFileRepo(t, t.TempDir())
+	assert.Nil(t, fr.Initialize())
+	assert.Equal(t, len(fr.NoteTemplates()), 0)
+
+	assert.Nil(t, rm.MkdirAll("templates/drafts", 0755))
+	assert.Nil(t, rm.WriteString("templates/project.md", "# {{.Title}}\n\nStarted {{.Date}}.\n{{range .Tags}}\n- #{{.}}{{end}}\n"))
+	assert.Nil(t, rm.WriteString("templates/book.md", "# {{.Title}}\n"))
+	assert.Nil(t, rm.WriteString("templates/journal.md", "Mood:\n"))
+	assert.Nil(t, rm.WriteString("templates/notes.txt", "Not a template\n"))
+	assert.Equal(t, strings.Join(fr.NoteTemplates(), ","), "book,project")
+
+	doc, err := fr.GetOrCreateResourceDocument("garden-plan", "project", []string{"home", "outdoors"})
+	assert.Nil(t, err)
+	assert.Equal(t, doc.Info.ID, "resources/garden-plan")
+
+	content, err := doc.Content()
+	assert.Nil(t, err)
+	want := "# Garden Plan\n\nStarted " + time.Now().Format("2006-01-02") + ".\n\n- #home\n- #outdoors\n"
+	assert.Equal(t, withoutFrontmatter(content), want)
+
+	// Existing files are returned as they are
+	doc, err = fr.GetOrCreateResourceDocument("garden-plan", "book", nil)
+	assert.Nil(t, err)
+	content, err = doc.Content()
+	assert.Nil(t, err)
+	assert.Equal(t, withoutFrontmatter(content), want)
+
+	// Day templates and paths outside the templates directory can't seed resources
+	for _, name := range []string{"journal", "missing", "../inbox"} {
+		_, err = fr.GetOrCreateResourceDocument("from-"+name, name, nil)
+		assert.ErrorIs(t, err, files.ErrTemplateNotFound)
+	}
+	assert.False(t, rm.FileExists("resources/from-journal.md"))
+}
@@ -0,0 +1,196 @@
+package files
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// DayHeader is a "## " day header in a temporal file, along with the anchor of its heading on the file's page
+type DayHeader struct {
+	Date   time.Time
+	FileID string
+	Anchor string
+}
+
+// TemporalMonth returns the day headers in a temporal directory's files for a month, in file order, and the
+// entry counts per day, keyed by "2006-01-02" and counted like TemporalActivity. Day headers from other
+// months are left out.
+func (fr *FileRepository) TemporalMonth(fileType string, year int, month time.Month) ([]DayHeader, map[string]int, error) {
+	if !slices.Contains(fr.config.temporalDirectories, fileType) {
+		return nil, nil, fmt.Errorf("%s is not a temporal directory", fileType)
+	}
+
+	var headers []DayHeader
+	activity := make(map[string]int)
+	for date := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC); date.Month() == month; date = fr.nextTemporalFile(date) {
+		info, found := fr.TemporalFileInfo(fileType, date)
+		if !found {
+			continue
+		}
+
+		doc, err := fr.GetDocumentByPath(info.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		content, err := doc.Content()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", info.Path, err)
+		}
+
+		lines := contentutil.SplitLines(content)
+		for _, header := range dayHeaderAnchors(lines) {
+			if header.Date.Year() == year && header.Date.Month() == month {
+				header.FileID = info.ID
+				headers = append(headers, header)
+			}
+		}
+		countDayEntries(lines, activity)
+	}
+
+	for date := range activity {
+		if !strings.HasPrefix(date, fmt.Sprintf("%04d-%02d-", year, month)) {
+			delete(activity, date)
+		}
+	}
+
+	return headers, activity, nil
+}
+
+// dayHeaderAnchors returns the day headers in lines with the anchors their headings get when the file is
+// rendered. Repeated heading IDs are numbered in document order, so every heading outside frontmatter and
+// fenced code blocks is followed, not just the day headers.
+func dayHeaderAnchors(lines []string) []DayHeader {
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		start = bounds.End
+	}
+
+	var headers []DayHeader
+	used := make(map[string]bool)
+	fence := ""
+	for _, line := range lines[start:] {
+		trimmed := strings.TrimSpace(line)
+
+		// Skip fenced code blocks, which close with the same marker that opened them
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			continue
+		} else if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		text, ok := headingText(trimmed)
+		if !ok {
+			continue
+		}
+
+		anchor := headingAnchor(text)
+		if used[anchor] {
+			for i := 1; ; i++ {
+				if numbered := fmt.Sprintf("%s-%d", anchor, i); !used[numbered] {
+					anchor = numbered
+					break
+				}
+			}
+		}
+		used[anchor] = true
+
+		if date, ok := parseDayHeader(trimmed); ok {
+			headers = append(headers, DayHeader{Date: date, Anchor: anchor})
+		}
+	}
+
+	return headers
+}
+
+// headingAnchor returns the ID the markdown renderer's automatic heading IDs give a heading's text: ASCII
+// letters and digits in lowercase, with spaces, hyphens, and underscores as hyphens. Everything else is
+// dropped (e.g., "Monday, January 2, 2006" is "monday-january-2-2006").
+func headingAnchor(text string) string {
+	var sb strings.Builder
+	for _, char := range text {
+		switch {
+		case char >= 'a' && char <= 'z', char >= '0' && char <= '9':
+			sb.WriteRune(char)
+		case char >= 'A' && char <= 'Z':
+			sb.WriteRune(char + 'a' - 'A')
+		case char == ' ' || char == '\t' || char == '-' || char == '_':
+			sb.WriteByte('-')
+		}
+	}
+
+	if sb.Len() == 0 {
+		return "heading"
+	}
+	return sb.String()
+}
+
+// DayEntry is a day section of a temporal file
+type DayEntry struct {
+	DayHeader
+	Type  string   // Temporal directory the file is in (e.g., "daily")
+	Lines []string // Lines of the section, starting with its day header
+}
+
+// Excerpt returns the first paragraph of prose in the entry, like Document.Excerpt
+func (e DayEntry) Excerpt(maxChars int) string {
+	return excerpt(e.Lines, maxChars)
+}
+
+// OnThisDay returns the day sections of the daily and journal directories from the same day of the month as
+// date in earlier months and years, newest first. Within a day, daily entries come before journal entries.
+func (fr *FileRepository) OnThisDay(date time.Time) ([]DayEntry, error) {
+	today := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	var entries []DayEntry
+	for _, fileType := range fr.config.temporalDirectories {
+		for _, path := range fr.temporalFilePaths(fileType) {
+			doc, err := fr.GetDocumentByPath(path)
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := doc.Content()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+
+			lines := contentutil.SplitLines(content)
+			headers := dayHeaderAnchors(lines)
+			for _, section := range collectDaySections(lines) {
+				if section.date.Day() != today.Day() || !section.date.Before(today) {
+					continue
+				}
+
+				entry := DayEntry{
+					DayHeader: DayHeader{Date: section.date, FileID: fr.CreateID(path)},
+					Type:      fileType,
+					Lines:     section.lines,
+				}
+				// Pair the section with the first unclaimed header of its date to find its anchor
+				for i, header := range headers {
+					if header.Date.Equal(section.date) {
+						entry.Anchor = header.Anchor
+						headers = slices.Delete(headers, i, i+1)
+						break
+					}
+				}
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b DayEntry) int {
+		return b.Date.Compare(a.Date)
+	})
+
+	return entries, nil
+}
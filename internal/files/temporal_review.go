@@ -0,0 +1,91 @@
+package files
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// weeklyReviewDays is the number of days, ending on the review date, that a weekly review covers
+const weeklyReviewDays = 7
+
+// WeeklyReview assembles the day sections of the daily and journal directories from the week ending on end
+// into one markdown document, newest first, with each day header naming the directory it came from. The
+// review opens with the tasks completed that week, going by the date of their @done tag, each linking to
+// the file it's in.
+func (fr *FileRepository) WeeklyReview(end time.Time) (string, error) {
+	last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	first := last.AddDate(0, 0, 1-weeklyReviewDays)
+	inWeek := func(date time.Time) bool {
+		return !date.Before(first) && !date.After(last)
+	}
+
+	var sections []daySection
+	for _, fileType := range fr.config.temporalDirectories {
+		dirSections, err := fr.temporalDaySections(fileType)
+		if err != nil {
+			return "", err
+		}
+
+		for _, section := range dirSections {
+			if !inWeek(section.date) {
+				continue
+			}
+			// Label the header with its directory, since a day can have both a daily and a journal section
+			lines := slices.Clone(section.lines)
+			lines[0] = fmt.Sprintf("%s (%s)", lines[0], contentutil.TitleCase(fileType))
+			sections = append(sections, daySection{date: section.date, lines: lines})
+		}
+	}
+
+	// Keep the directory order within a day
+	slices.SortStableFunc(sections, func(a, b daySection) int {
+		return b.date.Compare(a.date)
+	})
+
+	type completedTask struct {
+		task FileTask
+		done time.Time
+	}
+	var completed []completedTask
+	for _, task := range fr.AllTasks() {
+		if !task.Completed {
+			continue
+		}
+		matches := doneDatePattern.FindStringSubmatch(task.Label)
+		if matches == nil {
+			continue
+		}
+		done, err := time.Parse("2006-01-02", matches[1])
+		if err != nil || !inWeek(done) {
+			continue
+		}
+		completed = append(completed, completedTask{task: task, done: done})
+	}
+	slices.SortStableFunc(completed, func(a, b completedTask) int {
+		return b.done.Compare(a.done)
+	})
+
+	var sb strings.Builder
+	sb.WriteString("# Weekly Review\n\n")
+	fmt.Fprintf(&sb, "%s to %s: %s with entries and %s.\n", first.Format(dayHeaderLayout), last.Format(dayHeaderLayout),
+		pluralize(len(sections), "day"), pluralize(len(completed), "completed task"))
+
+	if len(completed) > 0 {
+		sb.WriteString("\n## Completed Tasks\n\n")
+		for _, c := range completed {
+			fmt.Fprintf(&sb, "- %s ([[%s]])\n", c.task.Label, c.task.FileID)
+		}
+	}
+
+	for _, section := range sections {
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(section.lines, "\n"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
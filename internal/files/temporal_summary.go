@@ -0,0 +1,149 @@
+package files
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// daySection is a "## " day section of a temporal file, including its header line
+type daySection struct {
+	date  time.Time
+	lines []string
+}
+
+// TemporalSummary assembles the most recent day sections of a temporal directory (e.g., "daily") into one
+// markdown document, newest first. Sections are collected across all monthly files, so a summary early in
+// the month still includes the end of the previous one. The summary starts with the number of open tasks
+// in the included days.
+func (fr *FileRepository) TemporalSummary(fileType string, days int) (string, error) {
+	if !slices.Contains(fr.config.temporalDirectories, fileType) {
+		return "", fmt.Errorf("%s is not a temporal directory", fileType)
+	}
+	if days < 1 {
+		return "", fmt.Errorf("invalid number of summary days: %d", days)
+	}
+
+	sections, err := fr.temporalDaySections(fileType)
+	if err != nil {
+		return "", err
+	}
+	if len(sections) > days {
+		sections = sections[:days]
+	}
+
+	var openTasks int
+	for _, section := range sections {
+		openTasks += countTaskLines(section.lines).Pending
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Recent %s\n\n", contentutil.TitleCase(fileType))
+	if len(sections) == 0 {
+		sb.WriteString("No entries yet.\n")
+		return sb.String(), nil
+	}
+
+	fmt.Fprintf(&sb, "%s in the last %s.\n", pluralize(openTasks, "open task"), pluralize(len(sections), "day"))
+	for _, section := range sections {
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(section.lines, "\n"))
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+// temporalDaySections returns the day sections of all files in a temporal directory, newest first
+func (fr *FileRepository) temporalDaySections(fileType string) ([]daySection, error) {
+	var sections []daySection
+	for _, path := range fr.temporalFilePaths(fileType) {
+		doc, err := fr.GetDocumentByPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := doc.Content()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		sections = append(sections, collectDaySections(contentutil.SplitLines(content))...)
+	}
+
+	slices.SortStableFunc(sections, func(a, b daySection) int {
+		return b.date.Compare(a.date)
+	})
+
+	return sections, nil
+}
+
+// temporalFilePaths returns the paths of the indexed markdown files in a temporal directory
+func (fr *FileRepository) temporalFilePaths(fileType string) []string {
+	fr.cacheMux.RLock()
+	defer fr.cacheMux.RUnlock()
+
+	var paths []string
+	for _, info := range fr.fileIndex {
+		if strings.HasPrefix(info.Path, fileType+"/") && strings.HasSuffix(info.Path, ".md") {
+			paths = append(paths, info.Path)
+		}
+	}
+
+	return paths
+}
+
+// collectDaySections returns the day sections in lines, each ending at the next H1 or H2. Trailing blank
+// lines are dropped from each section.
+func collectDaySections(lines []string) []daySection {
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		start = bounds.End
+	}
+
+	var sections []daySection
+	var current *daySection
+	flush := func() {
+		if current == nil {
+			return
+		}
+		end := len(current.lines)
+		for end > 1 && strings.TrimSpace(current.lines[end-1]) == "" {
+			end--
+		}
+		current.lines = current.lines[:end]
+		sections = append(sections, *current)
+		current = nil
+	}
+
+	for _, line := range lines[start:] {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "## ") || strings.HasPrefix(trimmed, "# ") {
+			flush()
+			if date, ok := parseDayHeader(trimmed); ok {
+				current = &daySection{date: date, lines: []string{trimmed}}
+			}
+			continue
+		}
+
+		if current != nil {
+			current.lines = append(current.lines, line)
+		}
+	}
+
+	flush()
+
+	return sections
+}
+
+// pluralize formats a count with a noun, adding an "s" unless the count is one
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
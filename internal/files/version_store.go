@@ -0,0 +1,106 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickward/padd/internal/crypto"
+)
+
+// ErrNoVersionStore is returned by history operations when no VersionStore is configured
+var ErrNoVersionStore = errors.New("version history is not enabled")
+
+// Revision is a recorded version of a file
+type Revision struct {
+	ID      string    // Backend-specific revision identifier (e.g., a git commit hash)
+	Time    time.Time // When the revision was recorded
+	Message string    // Description of the change
+}
+
+// VersionStore records the versions of files in the data directory as they change. Paths are relative to the
+// data directory, and file contents are read as stored on disk, so encrypted files stay encrypted.
+type VersionStore interface {
+	// Record records the current state of the given paths, including deletions, as one revision
+	Record(message string, paths ...string) error
+	// History lists the revisions of a path, newest first
+	History(path string) ([]Revision, error)
+	// Content returns the content of a path at a revision
+	Content(path, revisionID string) ([]byte, error)
+}
+
+// SetVersionStore sets the VersionStore that records file changes. A nil store turns history off.
+func (fr *FileRepository) SetVersionStore(store VersionStore) {
+	fr.versionStore = store
+}
+
+// HasVersionStore reports whether file changes are being recorded
+func (fr *FileRepository) HasVersionStore() bool {
+	return fr.versionStore != nil
+}
+
+// RecordChanges records paths that were changed outside the repository, such as files extracted from an
+// archive, as one revision when a VersionStore is configured
+func (fr *FileRepository) RecordChanges(message string, paths ...string) {
+	fr.recordVersion(message, paths...)
+}
+
+// recordVersion records a change to the given paths when a VersionStore is configured. The change has
+// already been written, so a failure to record it is logged rather than returned.
+func (fr *FileRepository) recordVersion(message string, paths ...string) {
+	if fr.versionStore == nil {
+		return
+	}
+
+	if err := fr.versionStore.Record(message, paths...); err != nil {
+		fr.logger.Error("Error recording version", "paths", paths, "error", err)
+	}
+}
+
+// History lists the recorded revisions of the document, newest first
+func (d *Document) History() ([]Revision, error) {
+	if d.repo.versionStore == nil {
+		return nil, ErrNoVersionStore
+	}
+
+	return d.repo.versionStore.History(d.Info.Path)
+}
+
+// RevisionContent returns the content of the document at a revision, decrypted if it was stored encrypted
+func (d *Document) RevisionContent(revisionID string) (string, error) {
+	if d.repo.versionStore == nil {
+		return "", ErrNoVersionStore
+	}
+
+	content, err := d.repo.versionStore.Content(d.Info.Path, revisionID)
+	if err != nil {
+		return "", err
+	}
+
+	if d.repo.encryptionManager.IsActive() &&
+		d.repo.encryptionManager.HasIdentities() &&
+		crypto.IsAgeEncrypted(content) {
+		decrypted, err := d.repo.encryptionManager.Decrypt(content)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt revision %s of %s: %w", revisionID, d.Info.Path, err)
+		}
+		return decrypted, nil
+	}
+
+	return string(content), nil
+}
+
+// Restore saves the content of the document at a revision as its current content, which records a new
+// revision rather than rewriting history
+func (d *Document) Restore(revisionID string) error {
+	content, err := d.RevisionContent(revisionID)
+	if err != nil {
+		return err
+	}
+
+	if crypto.IsAgeEncrypted([]byte(content)) {
+		return fmt.Errorf("revision %s of %s is encrypted and no identities are loaded to decrypt it", revisionID, d.Info.Path)
+	}
+
+	return d.Save(content)
+}
@@ -0,0 +1,310 @@
+package files
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/patrickward/padd/internal/contentutil"
+)
+
+// WikiLinkPattern matches a [[Page Name]] wiki link, capturing the page name
+var WikiLinkPattern = regexp.MustCompile(`\[\[([^]\n]+)]]`)
+
+// markdownLinkPattern matches a [text](url) or [text](url "title") markdown link, capturing the text and URL.
+// A leading "!" is captured too, so images can be told apart from links.
+var markdownLinkPattern = regexp.MustCompile(`(!?)\[([^]\n]*)]\(([^)\s]+)(?:\s+"[^"\n]*")?\)`)
+
+// codeSpanPattern matches inline code spans delimited by one or two backticks
+var codeSpanPattern = regexp.MustCompile("``[^`\n]*``|`[^`\n]*`")
+
+// Link is an outbound [text](url) markdown link
+type Link struct {
+	Text string
+	URL  string
+}
+
+// FileLinks holds the outbound links of a file, each in the order they appear
+type FileLinks struct {
+	Wiki     []string // Page names of [[Page Name]] wiki links
+	Markdown []Link   // [text](url) markdown links
+}
+
+// Links returns the outbound links of the document: the page names of its [[Page Name]] wiki links and its
+// [text](url) markdown links, each in the order they appear. Images, inline code spans, and fenced code
+// blocks are skipped.
+func (d *Document) Links() (wiki []string, markdown []Link, err error) {
+	content, err := d.Content()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	links := extractLinks(contentutil.SplitLines(content))
+
+	return links.Wiki, links.Markdown, nil
+}
+
+// extractLinks finds the wiki and markdown links in lines, skipping images, inline code spans, and fenced
+// code blocks
+func extractLinks(lines []string) FileLinks {
+	var links FileLinks
+
+	fence := ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// Skip fenced code blocks, which close with the same marker that opened them
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			continue
+		} else if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		line = codeSpanPattern.ReplaceAllString(line, "")
+
+		for _, match := range WikiLinkPattern.FindAllStringSubmatch(line, -1) {
+			if pageName := strings.TrimSpace(match[1]); pageName != "" {
+				links.Wiki = append(links.Wiki, pageName)
+			}
+		}
+
+		for _, match := range markdownLinkPattern.FindAllStringSubmatch(line, -1) {
+			if match[1] == "!" {
+				continue
+			}
+			links.Markdown = append(links.Markdown, Link{Text: match[2], URL: match[3]})
+		}
+	}
+
+	return links
+}
+
+// ResolveMarkdownLink resolves the URL of a markdown link in the document at documentPath to an indexed file.
+// Relative URLs (e.g., "notes.md" or "../bar.md") are resolved against the document's directory, and absolute
+// ones (e.g., "/resources/bar.md" or "/resources/bar") against the data directory. URLs with a scheme, URLs
+// that leave the data directory, and links to files that don't exist don't resolve.
+func (fr *FileRepository) ResolveMarkdownLink(documentPath, rawURL string) (FileInfo, bool) {
+	rawURL, _, _ = strings.Cut(rawURL, "#")
+	rawURL, _, _ = strings.Cut(rawURL, "?")
+	if rawURL == "" || strings.Contains(rawURL, ":") {
+		return FileInfo{}, false
+	}
+
+	unescaped, err := url.PathUnescape(rawURL)
+	if err != nil {
+		return FileInfo{}, false
+	}
+
+	target := path.Clean(strings.TrimPrefix(unescaped, "/"))
+	if !strings.HasPrefix(unescaped, "/") {
+		target = path.Join(path.Dir(filepath.ToSlash(documentPath)), unescaped)
+	}
+	if target == "." || target == ".." || strings.HasPrefix(target, "../") {
+		return FileInfo{}, false
+	}
+
+	fr.cacheMux.RLock()
+	defer fr.cacheMux.RUnlock()
+
+	info, ok := fr.fileIndex[fr.CreateID(target)]
+	return info, ok
+}
+
+// ResolveWikiLink resolves the page name of a [[Page Name]] wiki link to a file. The name is tried
+// as an ID first, then relative to the resources directory.
+func (fr *FileRepository) ResolveWikiLink(pageName string) (FileInfo, bool) {
+	pageName = strings.TrimSpace(pageName)
+	if pageName == "" {
+		return FileInfo{}, false
+	}
+
+	if file, err := fr.FileInfo(fr.CreateID(pageName)); err == nil {
+		return file, true
+	}
+
+	if file, err := fr.FileInfo(filepath.Join(fr.config.ResourcesDirectory, pageName)); err == nil {
+		return file, true
+	}
+
+	return FileInfo{}, false
+}
+
+// BrokenLinks scans every indexed markdown file for wiki links that don't resolve to a file. It returns a
+// map of file ID to the text of each broken link, in the order they appear. Files without broken links are
+// left out of the map.
+func (fr *FileRepository) BrokenLinks() (map[string][]string, error) {
+	check, err := fr.CheckLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	return check.BrokenWiki, nil
+}
+
+// linkRewritesForMove finds the markdown files with links that a move of the target file to newPath would
+// break, using the cached links, and returns their rewritten content by path after the move. It must be called
+// before the move, while the old links still resolve.
+func (fr *FileRepository) linkRewritesForMove(target FileInfo, newPath string) (map[string]string, error) {
+	fr.cacheMux.RLock()
+	infos := make([]FileInfo, 0, len(fr.fileIndex))
+	for _, info := range fr.fileIndex {
+		if strings.HasSuffix(info.Path, ".md") {
+			infos = append(infos, info)
+		}
+	}
+	fr.cacheMux.RUnlock()
+
+	rewrites := make(map[string]string)
+	for _, info := range infos {
+		docNewPath := info.Path
+		if info.Path == target.Path {
+			docNewPath = newPath
+		} else if !fr.linksTo(info, target) {
+			continue
+		}
+
+		doc, err := fr.GetDocumentByPath(info.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := doc.Content()
+		if err != nil {
+			return nil, err
+		}
+
+		if rewritten := fr.rewriteMovedLinks(content, info.Path, docNewPath, target, newPath); rewritten != content {
+			rewrites[docNewPath] = rewritten
+		}
+	}
+
+	return rewrites, nil
+}
+
+// linksTo reports whether any of the cached links of a file resolve to the target file
+func (fr *FileRepository) linksTo(info FileInfo, target FileInfo) bool {
+	for _, pageName := range info.Links.Wiki {
+		if resolved, ok := fr.ResolveWikiLink(pageName); ok && resolved.ID == target.ID {
+			return true
+		}
+	}
+
+	for _, link := range info.Links.Markdown {
+		if resolved, ok := fr.ResolveMarkdownLink(info.Path, link.URL); ok && resolved.ID == target.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewriteMovedLinks rewrites the links in the content of the document at docPath, which moves to docNewPath,
+// for a move of the target file to newPath. Wiki links to the target get its new page name. Markdown links to
+// the target, and relative markdown links in a document that moves, get a URL that resolves from the new
+// location, keeping their style (absolute or relative, with or without an extension) and any fragment.
+// Links in inline code spans and fenced code blocks are left alone.
+func (fr *FileRepository) rewriteMovedLinks(content, docPath, docNewPath string, target FileInfo, newPath string) string {
+	newID := fr.CreateID(newPath)
+	resourcesPrefix := fr.config.ResourcesDirectory + "/"
+
+	rewriteSegment := func(segment string) string {
+		segment = WikiLinkPattern.ReplaceAllStringFunc(segment, func(match string) string {
+			pageName := strings.TrimSpace(WikiLinkPattern.FindStringSubmatch(match)[1])
+			resolved, ok := fr.ResolveWikiLink(pageName)
+			if !ok || resolved.ID != target.ID {
+				return match
+			}
+
+			// Keep page names that were relative to the resources directory relative
+			if fr.CreateID(pageName) != target.ID {
+				return "[[" + strings.TrimPrefix(newID, resourcesPrefix) + "]]"
+			}
+			return "[[" + newID + "]]"
+		})
+
+		return markdownLinkPattern.ReplaceAllStringFunc(segment, func(match string) string {
+			parts := markdownLinkPattern.FindStringSubmatch(match)
+			if parts[1] == "!" {
+				return match
+			}
+
+			newURL, ok := fr.movedLinkURL(parts[3], docPath, docNewPath, target.Path, newPath)
+			if !ok {
+				return match
+			}
+			return strings.Replace(match, "("+parts[3], "("+newURL, 1)
+		})
+	}
+
+	lines := contentutil.SplitLines(content)
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")) {
+			fence = trimmed[:3]
+			continue
+		} else if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+
+		// Rewrite only the text between inline code spans
+		var sb strings.Builder
+		last := 0
+		for _, span := range codeSpanPattern.FindAllStringIndex(line, -1) {
+			sb.WriteString(rewriteSegment(line[last:span[0]]))
+			sb.WriteString(line[span[0]:span[1]])
+			last = span[1]
+		}
+		sb.WriteString(rewriteSegment(line[last:]))
+		lines[i] = sb.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// movedLinkURL returns the new URL of a markdown link in the document at docPath, which moves to docNewPath,
+// after the file at oldPath moves to newPath. It returns false when the link doesn't need to change.
+func (fr *FileRepository) movedLinkURL(rawURL, docPath, docNewPath, oldPath, newPath string) (string, bool) {
+	resolved, ok := fr.ResolveMarkdownLink(docPath, rawURL)
+	if !ok {
+		return "", false
+	}
+
+	absolute := strings.HasPrefix(rawURL, "/")
+	targetPath := resolved.Path
+	if targetPath == oldPath {
+		targetPath = newPath
+	} else if absolute || docPath == docNewPath {
+		return "", false
+	}
+
+	base, suffix := rawURL, ""
+	if i := strings.IndexAny(rawURL, "#?"); i >= 0 {
+		base, suffix = rawURL[:i], rawURL[i:]
+	}
+
+	targetPath = filepath.ToSlash(targetPath)
+	if path.Ext(base) == "" {
+		targetPath = strings.TrimSuffix(targetPath, path.Ext(targetPath))
+	}
+
+	newURL := "/" + targetPath
+	if !absolute {
+		relative, err := filepath.Rel(filepath.Dir(docNewPath), filepath.FromSlash(targetPath))
+		if err != nil {
+			return "", false
+		}
+		newURL = filepath.ToSlash(relative)
+	}
+
+	return (&url.URL{Path: newURL}).EscapedPath() + suffix, true
+}
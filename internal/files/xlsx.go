@@ -0,0 +1,297 @@
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidXLSX is returned when an uploaded spreadsheet isn't a readable .xlsx workbook
+var ErrInvalidXLSX = errors.New("invalid xlsx workbook")
+
+// maxXLSXPartSize caps how much of any one part of a workbook is read, so a small upload can't expand into
+// an unbounded amount of XML
+const maxXLSXPartSize = 64 << 20
+
+// xlsxWorkbook is the part of xl/workbook.xml that lists the sheets
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// xlsxRelationships is a .rels part, which maps relationship IDs to other parts
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a string that may be split into rich text runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	var sb strings.Builder
+	sb.WriteString(t.Text)
+	for _, run := range t.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+// xlsxSharedStrings is xl/sharedStrings.xml, the table most cells' text is stored in
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+// xlsxSheet is the cell data of a worksheet
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string    `xml:"r,attr"`
+			Type   string    `xml:"t,attr"`
+			Value  string    `xml:"v"`
+			Inline *xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readXLSX returns the rows of the first worksheet in an .xlsx workbook, padded so every row has the same
+// number of cells. Cells hold their stored values: numbers and dates come through as Excel stores them
+// (dates as serial day numbers), and booleans as TRUE or FALSE.
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+
+	sheetPath, err := firstSheetPath(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared xlsxSharedStrings
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(parts, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxSheet
+	if err := decodeXLSXPart(parts, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	width := 0
+	for _, row := range sheet.Rows {
+		var record []string
+		for _, cell := range row.Cells {
+			col := len(record)
+			if cell.Ref != "" {
+				if col, err = xlsxColumnIndex(cell.Ref); err != nil {
+					return nil, err
+				}
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(cell.Value)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, fmt.Errorf("%w: shared string %q not found", ErrInvalidXLSX, cell.Value)
+				}
+				value = shared.Items[i].String()
+			case "inlineStr":
+				if cell.Inline != nil {
+					value = cell.Inline.String()
+				}
+			case "b":
+				value = map[string]string{"1": "TRUE", "0": "FALSE"}[cell.Value]
+			}
+
+			for len(record) < col {
+				record = append(record, "")
+			}
+			if col < len(record) {
+				record[col] = value
+			} else {
+				record = append(record, value)
+			}
+		}
+
+		records = append(records, record)
+		width = max(width, len(record))
+	}
+
+	// Drop trailing rows without any values, which spreadsheets often leave behind
+	for len(records) > 0 && strings.Join(records[len(records)-1], "") == "" {
+		records = records[:len(records)-1]
+	}
+
+	for i := range records {
+		for len(records[i]) < width {
+			records[i] = append(records[i], "")
+		}
+	}
+
+	return records, nil
+}
+
+// firstSheetPath finds the part holding the workbook's first worksheet
+func firstSheetPath(parts map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+
+	if len(workbook.Sheets) == 0 {
+		return "", fmt.Errorf("%w: no worksheets", ErrInvalidXLSX)
+	}
+
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+
+		// Targets are relative to xl/ unless they start at the package root
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+
+	return "", fmt.Errorf("%w: first worksheet not found", ErrInvalidXLSX)
+}
+
+// decodeXLSXPart decodes the XML part with the given name
+func decodeXLSXPart(parts map[string]*zip.File, name string, v any) error {
+	file, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("%w: missing %s", ErrInvalidXLSX, name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidXLSX, err)
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	if err := xml.NewDecoder(io.LimitReader(reader, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: failed to read %s: %v", ErrInvalidXLSX, name, err)
+	}
+
+	return nil
+}
+
+// xlsxColumnIndex returns the zero-based column of a cell reference, e.g., 2 for "C7"
+func xlsxColumnIndex(ref string) (int, error) {
+	col := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		letters++
+	}
+
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("%w: invalid cell reference %q", ErrInvalidXLSX, ref)
+	}
+
+	return col - 1, nil
+}
+
+// xlsxColumnName returns the letters of a zero-based column, e.g., "C" for 2 or "AA" for 26
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// writeXLSX writes the records as the only worksheet of an .xlsx workbook. Cells in columns typed as
+// numbers that hold a number are written as numbers; everything else is written as text.
+func writeXLSX(w io.Writer, records [][]string, columnTypes map[int]CellType) error {
+	var sheet bytes.Buffer
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, record := range records {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range record {
+			ref := xlsxColumnName(j) + strconv.Itoa(i+1)
+			if _, err := strconv.ParseFloat(value, 64); err == nil && i > 0 && columnTypes[j] == CellTypeNum {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+				return err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to write xlsx: %w", err)
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return fmt.Errorf("failed to write xlsx: %w", err)
+		}
+	}
+
+	return archive.Close()
+}
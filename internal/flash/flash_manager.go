@@ -4,8 +4,17 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
+// DefaultMaxAge is how long queued flash messages survive when no max-age is given
+const DefaultMaxAge = 5 * time.Minute
+
+// maxQueued caps the number of queued flash messages so the cookie stays well under browser size limits.
+// When the queue is full, the oldest message is dropped.
+const maxQueued = 10
+
 // Flash represents a single flash message
 type Flash struct {
 	Type    string `json:"type"`
@@ -19,24 +28,32 @@ type Manager struct {
 	path       string
 }
 
-// NewManager creates a new Manager with sensible defaults
-func NewManager() *Manager {
+// NewManager creates a new Manager whose queued messages expire after maxAge. A non-positive maxAge uses
+// DefaultMaxAge.
+func NewManager(maxAge time.Duration) *Manager {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+
 	return &Manager{
 		cookieName: "padd_flash_message",
-		maxAge:     300, // 5 minutes
+		maxAge:     int(maxAge.Seconds()),
 		path:       "/",
 	}
 }
 
-// Set stores a flash message in a cookie
-func (fm *Manager) Set(w http.ResponseWriter, msgType, message string) {
-	flash := Flash{
+// Set queues a flash message, keeping any messages already queued by the request or earlier in the response
+func (fm *Manager) Set(w http.ResponseWriter, r *http.Request, msgType, message string) {
+	flashes := append(fm.pending(w, r), Flash{
 		Type:    msgType,
 		Message: message,
+	})
+	if len(flashes) > maxQueued {
+		flashes = flashes[len(flashes)-maxQueued:]
 	}
 
-	// JSON encode the flash message
-	flashData, err := json.Marshal(flash)
+	// JSON encode the flash messages
+	flashData, err := json.Marshal(flashes)
 	if err != nil {
 		// Fallback to simple message format if JSON encoding fails
 		flashData = []byte(message)
@@ -52,21 +69,22 @@ func (fm *Manager) Set(w http.ResponseWriter, msgType, message string) {
 		SameSite: http.SameSiteLaxMode,
 	}
 
+	fm.removeResponseCookie(w)
 	http.SetCookie(w, cookie)
 }
 
 // SetSuccess is a convenience method for success messages
-func (fm *Manager) SetSuccess(w http.ResponseWriter, message string) {
-	fm.Set(w, "success", message)
+func (fm *Manager) SetSuccess(w http.ResponseWriter, r *http.Request, message string) {
+	fm.Set(w, r, "success", message)
 }
 
 // SetError is a convenience method for error messages
-func (fm *Manager) SetError(w http.ResponseWriter, message string) {
-	fm.Set(w, "danger", message)
+func (fm *Manager) SetError(w http.ResponseWriter, r *http.Request, message string) {
+	fm.Set(w, r, "danger", message)
 }
 
-// Get retrieves and clears a flash message from cookies
-func (fm *Manager) Get(w http.ResponseWriter, r *http.Request) *Flash {
+// Get retrieves and clears all queued flash messages, oldest first
+func (fm *Manager) Get(w http.ResponseWriter, r *http.Request) []Flash {
 	cookie, err := r.Cookie(fm.cookieName)
 	if err != nil {
 		return nil
@@ -81,25 +99,10 @@ func (fm *Manager) Get(w http.ResponseWriter, r *http.Request) *Flash {
 		HttpOnly: true,
 		SameSite: http.SameSiteLaxMode,
 	}
+	fm.removeResponseCookie(w)
 	http.SetCookie(w, clearCookie)
 
-	// Decode the cookie value
-	decodedValue, err := url.QueryUnescape(cookie.Value)
-	if err != nil {
-		return nil
-	}
-
-	// Try to parse as JSON first
-	var flash Flash
-	if err := json.Unmarshal([]byte(decodedValue), &flash); err == nil {
-		return &flash
-	}
-
-	// Fallback: treat as plain message with success type
-	return &Flash{
-		Type:    "success",
-		Message: decodedValue,
-	}
+	return decode(cookie.Value)
 }
 
 // HasFlash checks if there's a flash message without consuming it
@@ -108,28 +111,73 @@ func (fm *Manager) HasFlash(r *http.Request) bool {
 	return err == nil
 }
 
-// Peek gets a flash message without clearing it (useful for debugging)
-func (fm *Manager) Peek(r *http.Request) *Flash {
+// Peek gets the queued flash messages without clearing them (useful for debugging)
+func (fm *Manager) Peek(r *http.Request) []Flash {
 	cookie, err := r.Cookie(fm.cookieName)
 	if err != nil {
 		return nil
 	}
 
-	// Decode the cookie value
-	decodedValue, err := url.QueryUnescape(cookie.Value)
-	if err != nil {
+	return decode(cookie.Value)
+}
+
+// pending returns the flash messages queued so far. A flash cookie already set on the response (by an
+// earlier Set or Get) takes precedence over the one sent with the request.
+func (fm *Manager) pending(w http.ResponseWriter, r *http.Request) []Flash {
+	for _, header := range w.Header().Values("Set-Cookie") {
+		cookie, err := http.ParseSetCookie(header)
+		if err == nil && cookie.Name == fm.cookieName {
+			if cookie.MaxAge < 0 {
+				return nil
+			}
+			return decode(cookie.Value)
+		}
+	}
+
+	if r == nil {
 		return nil
 	}
 
-	// Try to parse as JSON
+	return fm.Peek(r)
+}
+
+// removeResponseCookie drops any flash cookie already set on the response so it can be replaced
+func (fm *Manager) removeResponseCookie(w http.ResponseWriter) {
+	headers := w.Header().Values("Set-Cookie")
+	if len(headers) == 0 {
+		return
+	}
+
+	kept := make([]string, 0, len(headers))
+	for _, header := range headers {
+		if !strings.HasPrefix(header, fm.cookieName+"=") {
+			kept = append(kept, header)
+		}
+	}
+	w.Header()["Set-Cookie"] = kept
+}
+
+// decode parses a flash cookie value. It accepts a JSON array of flashes, a single JSON flash, or a plain
+// message, which is treated as a success.
+func decode(value string) []Flash {
+	decodedValue, err := url.QueryUnescape(value)
+	if err != nil || decodedValue == "" {
+		return nil
+	}
+
+	var flashes []Flash
+	if err := json.Unmarshal([]byte(decodedValue), &flashes); err == nil {
+		return flashes
+	}
+
 	var flash Flash
 	if err := json.Unmarshal([]byte(decodedValue), &flash); err == nil {
-		return &flash
+		return []Flash{flash}
 	}
 
-	// Fallback
-	return &Flash{
+	// Fallback: treat as plain message with success type
+	return []Flash{{
 		Type:    "success",
 		Message: decodedValue,
-	}
+	}}
 }
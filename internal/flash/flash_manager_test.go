@@ -0,0 +1,111 @@
+package flash_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/flash"
+)
+
+// nextRequest builds a request carrying the cookies set on rec, as a browser would after a redirect
+func nextRequest(rec *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			req.AddCookie(cookie)
+		}
+	}
+	return req
+}
+
+func TestManager_MultipleFlashes(t *testing.T) {
+	fm := flash.NewManager(flash.DefaultMaxAge)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	fm.SetSuccess(rec, req, "First")
+	fm.SetError(rec, req, "Second")
+
+	// Both flashes share a single cookie
+	assert.Equal(t, len(rec.Result().Cookies()), 1)
+
+	getRec := httptest.NewRecorder()
+	flashes := fm.Get(getRec, nextRequest(rec))
+	assert.Equal(t, len(flashes), 2)
+	assert.Equal(t, flashes[0], flash.Flash{Type: "success", Message: "First"})
+	assert.Equal(t, flashes[1], flash.Flash{Type: "danger", Message: "Second"})
+
+	// Get clears the queue
+	cleared := getRec.Result().Cookies()
+	assert.Equal(t, len(cleared), 1)
+	assert.True(t, cleared[0].MaxAge < 0)
+}
+
+func TestManager_QueuesAcrossRequests(t *testing.T) {
+	fm := flash.NewManager(flash.DefaultMaxAge)
+
+	first := httptest.NewRecorder()
+	fm.SetSuccess(first, httptest.NewRequest(http.MethodPost, "/", nil), "Saved")
+
+	// A second operation before the next render keeps the earlier flash
+	second := httptest.NewRecorder()
+	fm.SetSuccess(second, nextRequest(first), "Entry added")
+
+	flashes := fm.Get(httptest.NewRecorder(), nextRequest(second))
+	assert.Equal(t, len(flashes), 2)
+	assert.Equal(t, flashes[0].Message, "Saved")
+	assert.Equal(t, flashes[1].Message, "Entry added")
+}
+
+func TestManager_QueueLimit(t *testing.T) {
+	fm := flash.NewManager(flash.DefaultMaxAge)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	for i := range 15 {
+		fm.SetSuccess(rec, req, string(rune('a'+i)))
+	}
+
+	flashes := fm.Peek(nextRequest(rec))
+	assert.Equal(t, len(flashes), 10)
+	assert.Equal(t, flashes[0].Message, "f")
+	assert.Equal(t, flashes[9].Message, "o")
+}
+
+func TestManager_MaxAge(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		want   int
+	}{
+		{name: "custom", maxAge: 30 * time.Second, want: 30},
+		{name: "default when zero", maxAge: 0, want: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := flash.NewManager(tt.maxAge)
+
+			rec := httptest.NewRecorder()
+			fm.SetSuccess(rec, httptest.NewRequest(http.MethodPost, "/", nil), "Done")
+
+			cookies := rec.Result().Cookies()
+			assert.Equal(t, len(cookies), 1)
+			assert.Equal(t, cookies[0].MaxAge, tt.want)
+		})
+	}
+}
+
+func TestManager_LegacySingleFlash(t *testing.T) {
+	fm := flash.NewManager(flash.DefaultMaxAge)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "padd_flash_message", Value: `%7B%22type%22%3A%22success%22%2C%22message%22%3A%22Old%22%7D`})
+
+	flashes := fm.Get(httptest.NewRecorder(), req)
+	assert.Equal(t, len(flashes), 1)
+	assert.Equal(t, flashes[0].Message, "Old")
+}
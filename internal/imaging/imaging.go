@@ -0,0 +1,258 @@
+// Package imaging shrinks uploaded photos and makes thumbnails of them. It only uses the standard library's
+// codecs, so it handles JPEG and PNG images; other formats are left as they are.
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// ErrTooLarge is returned for images with more pixels than maxPixels
+var ErrTooLarge = errors.New("image is too large to process")
+
+// maxPixels caps the size of images that are decoded, so a small, highly compressed upload can't use
+// gigabytes of memory
+const maxPixels = 80_000_000
+
+// jpegQuality is the quality that resized JPEGs are saved with
+const jpegQuality = 85
+
+// Supported returns true if images with the file extension can be resized
+func Supported(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	default:
+		return false
+	}
+}
+
+// Fit scales an image down so it fits within maxWidth by maxHeight, keeping its aspect ratio, and returns it
+// re-encoded in its original format. JPEGs are also turned upright using their EXIF orientation, since
+// re-encoding drops the EXIF data (including any location). A zero dimension leaves that side unbounded.
+// When the image already fits and is upright, it's returned unchanged and changed is false.
+func Fit(content []byte, ext string, maxWidth, maxHeight int) (out []byte, changed bool, err error) {
+	if !Supported(ext) {
+		return content, false, nil
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return nil, false, fmt.Errorf("%w (%dx%d)", ErrTooLarge, config.Width, config.Height)
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(content)
+	}
+
+	// Orientations 5 to 8 turn the image on its side, so its width becomes its height
+	width, height := config.Width, config.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = min(scale, float64(maxHeight)/float64(height))
+	}
+
+	if scale == 1 && orientation == 1 {
+		return content, false, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Scale before rotating, so the rotation only touches the smaller image
+	scaledWidth := max(1, int(math.Round(float64(config.Width)*scale)))
+	scaledHeight := max(1, int(math.Round(float64(config.Height)*scale)))
+	img := orient(downscale(src, scaledWidth, scaledHeight), orientation)
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return buf.Bytes(), true, nil
+}
+
+// downscale resizes an image to width by height by averaging the source pixels each target pixel covers,
+// which keeps detail without the aliasing of picking single pixels
+func downscale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride+x0*4 : sy*rgba.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint64(row[i])
+					g += uint64(row[i+1])
+					b += uint64(row[i+2])
+					a += uint64(row[i+3])
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// orient turns an image upright according to its EXIF orientation, from 1 (already upright) to 8
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dstWidth, dstHeight := w, h
+	if orientation >= 5 {
+		dstWidth, dstHeight = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored
+				dx, dy = w-1-x, y
+			case 3: // Upside down
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored upside down
+				dx, dy = x, h-1-y
+			case 5: // Mirrored and turned left
+				dx, dy = y, x
+			case 6: // Turned left, so rotate clockwise
+				dx, dy = h-1-y, x
+			case 7: // Mirrored and turned right
+				dx, dy = h-1-y, w-1-x
+			case 8: // Turned right, so rotate counterclockwise
+				dx, dy = y, w-1-x
+			}
+
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+
+	return dst
+}
+
+// jpegOrientation returns the EXIF orientation of a JPEG, or 1 (upright) when it has none
+func jpegOrientation(content []byte) int {
+	if len(content) < 4 || content[0] != 0xFF || content[1] != 0xD8 {
+		return 1
+	}
+
+	// Walk the segments before the image data, looking for the APP1 segment that holds the EXIF data
+	for i := 2; i+4 <= len(content); {
+		if content[i] != 0xFF {
+			return 1
+		}
+
+		marker := content[i+1]
+		if marker == 0xDA { // Start of the image data
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(content[i+2 : i+4]))
+		if length < 2 || i+2+length > len(content) {
+			return 1
+		}
+
+		segment := content[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of EXIF data
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 { // Orientation
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
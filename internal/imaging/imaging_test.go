@@ -0,0 +1,167 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/imaging"
+)
+
+// halves returns an image with a red left half and a blue right half
+func halves(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// encodeJPEG encodes an image as a JPEG, with an EXIF orientation tag when orientation isn't 0
+func encodeJPEG(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	content := buf.Bytes()
+	if orientation == 0 {
+		return content
+	}
+
+	// A little-endian TIFF header with a single IFD entry: the orientation tag, a SHORT with one value
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	// Insert the APP1 segment right after the start-of-image marker
+	return append(append(append([]byte{}, content[:2]...), app1...), content[2:]...)
+}
+
+func decode(t *testing.T, content []byte) image.Image {
+	t.Helper()
+	img, _, err := image.Decode(bytes.NewReader(content))
+	assert.Nil(t, err)
+	return img
+}
+
+func TestFit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scales large images down keeping their aspect ratio", func(t *testing.T) {
+		t.Parallel()
+
+		out, changed, err := imaging.Fit(encodePNG(t, halves(400, 200)), ".png", 100, 100)
+		assert.Nil(t, err)
+		assert.True(t, changed)
+
+		img := decode(t, out)
+		assert.Equal(t, img.Bounds().Dx(), 100)
+		assert.Equal(t, img.Bounds().Dy(), 50)
+
+		r, _, b, _ := img.At(10, 25).RGBA()
+		assert.True(t, r > b)
+		r, _, b, _ = img.At(90, 25).RGBA()
+		assert.True(t, b > r)
+	})
+
+	t.Run("leaves images that fit unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		content := encodeJPEG(t, halves(40, 20), 0)
+		out, changed, err := imaging.Fit(content, ".jpg", 100, 100)
+		assert.Nil(t, err)
+		assert.False(t, changed)
+		assert.True(t, bytes.Equal(out, content))
+	})
+
+	t.Run("a zero dimension is unbounded", func(t *testing.T) {
+		t.Parallel()
+
+		out, changed, err := imaging.Fit(encodePNG(t, halves(400, 200)), ".png", 0, 100)
+		assert.Nil(t, err)
+		assert.True(t, changed)
+
+		img := decode(t, out)
+		assert.Equal(t, img.Bounds().Dx(), 200)
+		assert.Equal(t, img.Bounds().Dy(), 100)
+
+		_, changed, err = imaging.Fit(encodePNG(t, halves(400, 200)), ".png", 0, 0)
+		assert.Nil(t, err)
+		assert.False(t, changed)
+	})
+
+	t.Run("turns JPEGs upright using their EXIF orientation", func(t *testing.T) {
+		t.Parallel()
+
+		// Orientation 6 means the camera was turned, so the image is rotated clockwise and the left half
+		// ends up on top
+		out, changed, err := imaging.Fit(encodeJPEG(t, halves(40, 20), 6), ".jpeg", 100, 100)
+		assert.Nil(t, err)
+		assert.True(t, changed)
+
+		img := decode(t, out)
+		assert.Equal(t, img.Bounds().Dx(), 20)
+		assert.Equal(t, img.Bounds().Dy(), 40)
+
+		r, _, b, _ := img.At(10, 5).RGBA()
+		assert.True(t, r > b)
+		r, _, b, _ = img.At(10, 35).RGBA()
+		assert.True(t, b > r)
+	})
+
+	t.Run("bounds apply to the upright image", func(t *testing.T) {
+		t.Parallel()
+
+		out, _, err := imaging.Fit(encodeJPEG(t, halves(400, 200), 8), ".jpg", 0, 100)
+		assert.Nil(t, err)
+
+		img := decode(t, out)
+		assert.Equal(t, img.Bounds().Dx(), 50)
+		assert.Equal(t, img.Bounds().Dy(), 100)
+	})
+
+	t.Run("passes unsupported formats through", func(t *testing.T) {
+		t.Parallel()
+
+		content := []byte("<svg></svg>")
+		out, changed, err := imaging.Fit(content, ".svg", 10, 10)
+		assert.Nil(t, err)
+		assert.False(t, changed)
+		assert.True(t, bytes.Equal(out, content))
+	})
+
+	t.Run("rejects content that isn't an image", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := imaging.Fit([]byte("not a png"), ".png", 10, 10)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,42 @@
+package pdf
+
+// helveticaWidths and helveticaBoldWidths are the advance widths of the printable ASCII characters (from space
+// to tilde) in thousandths of the font size, from the fonts' Adobe metrics. Helvetica-Oblique has the same
+// widths as Helvetica, and every Courier character is 600 wide.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// runeWidth returns the width of a character in thousandths of the font size. Characters outside ASCII are
+// given the width of a digit, which is close enough for the accented letters that make up most of them.
+func runeWidth(r rune, font Font) float64 {
+	if font == Mono {
+		return 600
+	}
+
+	if r < ' ' || r > '~' {
+		if font == Bold {
+			return 611
+		}
+		return 556
+	}
+
+	if font == Bold {
+		return float64(helveticaBoldWidths[r-' '])
+	}
+	return float64(helveticaWidths[r-' '])
+}
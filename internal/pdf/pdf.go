@@ -0,0 +1,280 @@
+// Package pdf writes simple, text-only PDF documents: wrapped paragraphs in the standard Helvetica and Courier
+// fonts, on US Letter pages. It's meant for exporting notes, so it supports what notes need (headings, lists,
+// quotes, code, and rules) and nothing else; the standard fonts mean nothing has to be embedded.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Page size and margins, in points
+const (
+	PageWidth  = 612.0
+	PageHeight = 792.0
+	Margin     = 54.0
+)
+
+// lineSpacing is the height of a line as a multiple of the font size
+const lineSpacing = 1.35
+
+// Font is one of the standard PDF fonts
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+	Italic
+	Mono
+)
+
+// fontNames are the base font names of the standard fonts, in Font order
+var fontNames = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Courier"}
+
+// Style describes how a block of text is laid out
+type Style struct {
+	Font        Font
+	Size        float64 // Font size in points
+	Indent      float64 // Left indent in points
+	Prefix      string  // Text placed in the indent before the first line, such as a list bullet
+	SpaceBefore float64
+	SpaceAfter  float64
+	Preformat   bool // Keep line breaks and spacing, as in code blocks
+}
+
+// Document is a PDF being written. Text is added from the top of the first page down, and new pages are
+// started as pages fill up.
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+	y     float64 // Baseline of the last line written on the current page
+}
+
+// New returns an empty document with the given title, which is shown by PDF viewers
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// NewPage starts a new page, unless the current page is still empty
+func (d *Document) NewPage() {
+	if len(d.pages) > 0 && d.y == PageHeight-Margin {
+		return
+	}
+
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = PageHeight - Margin
+}
+
+// Text adds a block of text, wrapped to the width of the page
+func (d *Document) Text(text string, style Style) {
+	if len(d.pages) == 0 {
+		d.NewPage()
+	}
+
+	if style.Size == 0 {
+		style.Size = 11
+	}
+
+	width := PageWidth - 2*Margin - style.Indent
+	var lines []string
+	if style.Preformat {
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			lines = append(lines, d.wrap(strings.ReplaceAll(line, "\t", "    "), style, width)...)
+		}
+	} else {
+		lines = d.wrap(strings.Join(strings.Fields(text), " "), style, width)
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	leading := style.Size * lineSpacing
+	if d.y != PageHeight-Margin {
+		d.y -= style.SpaceBefore
+	}
+
+	for i, line := range lines {
+		if d.y-leading < Margin {
+			d.NewPage()
+		}
+		d.y -= leading
+
+		if i == 0 && style.Prefix != "" {
+			prefixX := Margin + style.Indent - d.measure(style.Prefix+" ", style.Font, style.Size)
+			d.writeText(style.Prefix, style.Font, style.Size, prefixX, d.y)
+		}
+		d.writeText(line, style.Font, style.Size, Margin+style.Indent, d.y)
+	}
+
+	d.y -= style.SpaceAfter
+}
+
+// Rule adds a horizontal line across the page
+func (d *Document) Rule() {
+	if len(d.pages) == 0 || d.y-24 < Margin {
+		d.NewPage()
+	}
+
+	d.y -= 12
+	fmt.Fprintf(d.page(), "0.6 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", Margin, d.y, PageWidth-Margin, d.y)
+	d.y -= 12
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.NewPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 and 2 are the catalog and the page tree, 3 to 6 the fonts, and 7 the document info. Each page
+	// then takes two objects: the page and its content stream.
+	firstPage := 8
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, name := range fontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+	object(fmt.Sprintf("<< /Title %s /Producer (padd) >>", d.literal(d.title)))
+
+	for i, page := range d.pages {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		_, _ = zw.Write(page.Bytes())
+		_ = zw.Close()
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Contents %d 0 R "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R /F4 6 0 R >> >> >>",
+			PageWidth, PageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 7 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(out.Bytes())
+	return int64(n), err
+}
+
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// writeText draws a line of text with its baseline at x, y
+func (d *Document) writeText(text string, font Font, size, x, y float64) {
+	fmt.Fprintf(d.page(), "BT /F%d %g Tf %.2f %.2f Td %s Tj ET\n", font+1, size, x, y, d.literal(text))
+}
+
+// wrap breaks text into lines that fit within width, breaking words that are wider than a line. Preformatted
+// text keeps its spacing, so it's broken wherever it reaches the edge.
+func (d *Document) wrap(text string, style Style, width float64) []string {
+	if style.Preformat {
+		var lines []string
+		for d.measure(text, style.Font, style.Size) > width {
+			cut := d.fit(text, style.Font, style.Size, width)
+			lines = append(lines, text[:cut])
+			text = text[cut:]
+		}
+		return append(lines, text)
+	}
+
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if d.measure(candidate, style.Font, style.Size) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = word
+		for d.measure(line, style.Font, style.Size) > width {
+			cut := d.fit(line, style.Font, style.Size, width)
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// fit returns the byte length of the longest prefix of text that fits within width, which is always at least
+// one character
+func (d *Document) fit(text string, font Font, size, width float64) int {
+	var w float64
+	for i, r := range text {
+		w += runeWidth(r, font) * size / 1000
+		if w > width && i > 0 {
+			return i
+		}
+	}
+	return len(text)
+}
+
+// measure returns the width of text in points
+func (d *Document) measure(text string, font Font, size float64) float64 {
+	var w float64
+	for _, r := range text {
+		w += runeWidth(r, font)
+	}
+	return w * size / 1000
+}
+
+// literal returns text as a PDF string literal in the WinAnsi encoding, with characters it can't encode
+// replaced by question marks
+func (d *Document) literal(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		switch {
+		case unicode.IsControl(r):
+			c = ' '
+		case !ok:
+			c = '?'
+		}
+
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte(')')
+
+	return b.String()
+}
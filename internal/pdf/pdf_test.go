@@ -0,0 +1,125 @@
+package pdf_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/pdf"
+)
+
+var streamPattern = regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`)
+
+// pageContents returns the decompressed content stream of each page
+func pageContents(t *testing.T, output []byte) []string {
+	t.Helper()
+
+	var pages []string
+	for _, match := range streamPattern.FindAllSubmatch(output, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(match[1]))
+		assert.Nil(t, err)
+		content, err := io.ReadAll(zr)
+		assert.Nil(t, err)
+		pages = append(pages, string(content))
+	}
+	return pages
+}
+
+func write(t *testing.T, doc *pdf.Document) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	assert.Nil(t, err)
+	return buf.Bytes()
+}
+
+func TestDocument(t *testing.T) {
+	t.Parallel()
+
+	t.Run("writes a valid cross-reference table", func(t *testing.T) {
+		t.Parallel()
+
+		doc := pdf.New("Notes")
+		doc.Text("Hello", pdf.Style{Font: pdf.Bold, Size: 20})
+		output := write(t, doc)
+
+		assert.True(t, bytes.HasPrefix(output, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(output, []byte("%%EOF\n")))
+		assert.True(t, bytes.Contains(output, []byte("/Title (Notes)")))
+
+		startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(output)
+		assert.NotNil(t, startxref)
+		xref, err := strconv.Atoi(string(startxref[1]))
+		assert.Nil(t, err)
+		assert.True(t, bytes.HasPrefix(output[xref:], []byte("xref\n")))
+
+		// Every entry points at the start of its object
+		entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(output[xref:], -1)
+		assert.Equal(t, len(entries), 9)
+		for i, entry := range entries {
+			offset, err := strconv.Atoi(string(entry[1]))
+			assert.Nil(t, err)
+			assert.True(t, bytes.HasPrefix(output[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+		}
+
+		pages := pageContents(t, output)
+		assert.Equal(t, len(pages), 1)
+		assert.True(t, strings.Contains(pages[0], "/F2 20 Tf"))
+		assert.True(t, strings.Contains(pages[0], "(Hello) Tj"))
+	})
+
+	t.Run("wraps text and starts new pages as pages fill up", func(t *testing.T) {
+		t.Parallel()
+
+		doc := pdf.New("Long")
+		for i := 0; i < 20; i++ {
+			doc.Text(strings.Repeat("lorem ipsum dolor sit amet ", 20), pdf.Style{SpaceAfter: 6})
+		}
+		pages := pageContents(t, write(t, doc))
+
+		assert.True(t, len(pages) > 1)
+		for _, line := range regexp.MustCompile(`\((.*)\) Tj`).FindAllStringSubmatch(pages[0], -1) {
+			assert.True(t, len(line[1]) < 110)
+		}
+	})
+
+	t.Run("keeps the spacing of preformatted text", func(t *testing.T) {
+		t.Parallel()
+
+		doc := pdf.New("Code")
+		doc.Text("func main() {\n\tfmt.Println(\"(hi)\")\n}\n", pdf.Style{Font: pdf.Mono, Preformat: true})
+		pages := pageContents(t, write(t, doc))
+
+		assert.True(t, strings.Contains(pages[0], `(    fmt.Println\("\(hi\)"\)) Tj`))
+		assert.Equal(t, strings.Count(pages[0], " Tj"), 3)
+	})
+
+	t.Run("encodes text as WinAnsi", func(t *testing.T) {
+		t.Parallel()
+
+		doc := pdf.New("Café")
+		doc.Text("Café – 日本", pdf.Style{Prefix: "•"})
+		pages := pageContents(t, write(t, doc))
+
+		assert.True(t, strings.Contains(pages[0], "(\x95) Tj"))
+		assert.True(t, strings.Contains(pages[0], "(Caf\xe9 \x96 ??) Tj"))
+	})
+
+	t.Run("doesn't leave empty pages", func(t *testing.T) {
+		t.Parallel()
+
+		doc := pdf.New("Empty")
+		doc.NewPage()
+		doc.NewPage()
+		doc.Text("One", pdf.Style{})
+		doc.NewPage()
+		doc.Text("Two", pdf.Style{})
+		assert.Equal(t, len(pageContents(t, write(t, doc))), 2)
+	})
+}
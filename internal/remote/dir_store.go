@@ -0,0 +1,58 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// DirStore keeps the remote copy in a directory. Pointing it at an rclone mount, or a folder synced by another
+// tool, syncs with any remote that tool supports.
+type DirStore struct {
+	dir         string
+	rootManager *files.RootManager
+}
+
+// NewDirStore returns a store in dir, creating the directory if it doesn't exist
+func NewDirStore(dir string) (*DirStore, error) {
+	rootManager, err := files.NewRootManager(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DirStore{dir: dir, rootManager: rootManager}, nil
+}
+
+func (d *DirStore) Get(_ context.Context, key string) ([]byte, error) {
+	content, err := d.rootManager.ReadFile(filepath.FromSlash(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
+
+func (d *DirStore) Put(_ context.Context, key string, content []byte) error {
+	name := filepath.FromSlash(key)
+	if dir := filepath.Dir(name); dir != "." {
+		if err := d.rootManager.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return d.rootManager.WriteFile(name, content, 0644)
+}
+
+func (d *DirStore) Delete(_ context.Context, key string) error {
+	err := d.rootManager.Remove(filepath.FromSlash(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (d *DirStore) String() string {
+	return d.dir
+}
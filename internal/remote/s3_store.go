@@ -0,0 +1,257 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config holds the endpoint and credentials of an S3-compatible service
+type S3Config struct {
+	Endpoint        string // Base URL of an S3-compatible service, such as MinIO or R2; empty for AWS
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Only for temporary credentials
+}
+
+// S3ConfigFromEnv reads an S3Config from the standard AWS environment variables: AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION (or AWS_DEFAULT_REGION, defaulting to us-east-1),
+// and AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL) for services other than AWS
+func S3ConfigFromEnv() (S3Config, error) {
+	config := S3Config{
+		Endpoint:        firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+		Region:          firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return config, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for s3:// remotes")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	return config, nil
+}
+
+// firstEnv returns the value of the first environment variable that's set
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// S3Store keeps the remote copy in an S3-compatible bucket. Requests are signed with AWS Signature Version 4,
+// so it works with AWS and the services that follow its API without an SDK.
+type S3Store struct {
+	bucket string
+	prefix string
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Store returns a store that keeps its objects under prefix in bucket
+func NewS3Store(bucket, prefix string, config S3Config) *S3Store {
+	return &S3Store{
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+		config: config,
+		client: &http.Client{Timeout: time.Minute},
+		now:    time.Now,
+	}
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s.responseError(resp, key)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, content []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, content)
+	if err != nil {
+		return err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError(resp, key)
+	}
+	return nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError(resp, key)
+	}
+	return nil
+}
+
+func (s *S3Store) String() string {
+	return "s3://" + path.Join(s.bucket, s.prefix)
+}
+
+// do sends a signed request for an object
+func (s *S3Store) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := s.request(ctx, method, key, body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, body, s.now())
+
+	return s.client.Do(req)
+}
+
+// request returns an unsigned request for an object. Buckets on AWS are addressed by host name, and buckets
+// on other services by path, which every S3-compatible service supports.
+func (s *S3Store) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	objectPath := "/" + path.Join(s.prefix, key)
+
+	var u *url.URL
+	if s.config.Endpoint != "" {
+		endpoint, err := url.Parse(s.config.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid S3 endpoint %q: %w", s.config.Endpoint, err)
+		}
+		u = &url.URL{Scheme: endpoint.Scheme, Host: endpoint.Host, Path: path.Join(endpoint.Path, "/", s.bucket) + objectPath}
+	} else {
+		host := "s3.amazonaws.com"
+		if s.config.Region != "us-east-1" {
+			host = "s3." + s.config.Region + ".amazonaws.com"
+		}
+		u = &url.URL{Scheme: "https", Host: s.bucket + "." + host, Path: objectPath}
+	}
+	u.RawPath = escapePath(u.Path)
+
+	return http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+}
+
+// sign adds the AWS Signature Version 4 authorization header to a request, signing the host and every header
+// already set on it
+func (s *S3Store) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// responseError returns an error for an unexpected response, with the message from its S3 error document
+func (s *S3Store) responseError(resp *http.Response, key string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := string(body)
+	if start, end := strings.Index(message, "<Message>"), strings.Index(message, "</Message>"); start >= 0 && end > start {
+		message = message[start+len("<Message>") : end]
+	}
+
+	return fmt.Errorf("%s %s: %s: %s", resp.Request.Method, key, resp.Status, strings.TrimSpace(message))
+}
+
+// escapePath percent-encodes every byte of a path but slashes and the characters AWS leaves unreserved
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,90 @@
+// Package remote syncs the data directory with a copy kept somewhere else, so several machines can share it:
+// an S3-compatible bucket, or a directory such as an rclone mount or a folder synced by another tool. Files are
+// copied as they are, so encrypted files stay encrypted on the remote.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned by a Store for keys it doesn't have
+var ErrNotFound = errors.New("not found in remote")
+
+// Store holds the remote copy of the data directory, as objects keyed by their slash-separated paths
+type Store interface {
+	// Get returns the content of a key, or ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put creates or replaces a key
+	Put(ctx context.Context, key string, content []byte) error
+	// Delete removes a key. Removing a key that doesn't exist isn't an error.
+	Delete(ctx context.Context, key string) error
+	// String describes the store for logs and messages
+	String() string
+}
+
+// Open returns the Store for a remote: s3://bucket/prefix for an S3-compatible bucket, configured from the
+// standard AWS environment variables (see S3ConfigFromEnv), or a directory path, optionally as a file:// URL
+func Open(remote string) (Store, error) {
+	if remote == "" {
+		return nil, errors.New("no remote given")
+	}
+
+	scheme, rest, found := strings.Cut(remote, "://")
+	if !found {
+		return NewDirStore(remote)
+	}
+
+	switch scheme {
+	case "file":
+		u, err := url.Parse(remote)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote %q: %w", remote, err)
+		}
+		return NewDirStore(u.Path)
+	case "s3":
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid remote %q: no bucket given", remote)
+		}
+		config, err := S3ConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Store(bucket, prefix, config), nil
+	default:
+		return nil, fmt.Errorf("unsupported remote %q (expected s3://bucket/prefix or a directory)", remote)
+	}
+}
+
+// prefixStore keeps its keys under a prefix of another store
+type prefixStore struct {
+	store  Store
+	prefix string
+}
+
+// WithPrefix returns a store that keeps its keys under prefix in store, such as one vault's directory in a
+// bucket shared by several vaults
+func WithPrefix(store Store, prefix string) Store {
+	return &prefixStore{store: store, prefix: strings.Trim(prefix, "/")}
+}
+
+func (p *prefixStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.store.Get(ctx, path.Join(p.prefix, key))
+}
+
+func (p *prefixStore) Put(ctx context.Context, key string, content []byte) error {
+	return p.store.Put(ctx, path.Join(p.prefix, key), content)
+}
+
+func (p *prefixStore) Delete(ctx context.Context, key string) error {
+	return p.store.Delete(ctx, path.Join(p.prefix, key))
+}
+
+func (p *prefixStore) String() string {
+	return strings.TrimSuffix(p.store.String(), "/") + "/" + p.prefix
+}
@@ -0,0 +1,386 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/patrickward/padd/internal/files"
+)
+
+// ManifestKey is the key of the manifest in the remote, which lists the hash of every file there, so a sync
+// only has to download the files that changed
+const ManifestKey = ".padd-sync.json"
+
+// StatePath is where a data directory records the hashes of its files as of its last sync, in the service
+// directory, which is never synced
+const StatePath = "service/sync-state.json"
+
+// excludedDirs are the directories of the data directory that are never synced: the version history, which
+// each machine keeps on its own, and the server's logs and sync state
+var excludedDirs = []string{".git", "service"}
+
+// Mode limits the direction of a sync
+type Mode int
+
+const (
+	Both Mode = iota // Push local changes and pull remote ones
+	Push             // Only push local changes
+	Pull             // Only pull remote changes
+)
+
+// Action is what a sync does to a file
+type Action string
+
+const (
+	ActionPush         Action = "push"          // Upload the local file
+	ActionPull         Action = "pull"          // Download the remote file
+	ActionDeleteRemote Action = "delete remote" // Delete the remote file, which was deleted here
+	ActionDeleteLocal  Action = "delete local"  // Delete the local file, which was deleted on the remote
+	ActionConflict     Action = "conflict"      // Both copies changed; the remote one is saved as a conflict copy
+)
+
+// Change is one file changed by a sync
+type Change struct {
+	Path   string
+	Action Action
+	Copy   string // Path the remote version of a conflicting file was saved to
+}
+
+// Result lists the changes made by a sync, or planned by a dry run
+type Result struct {
+	Changes []Change
+	Skipped []string // Conflicts left alone, since a one-way sync can't resolve them
+}
+
+// LocalPaths returns the paths of the data directory that the sync changed
+func (r Result) LocalPaths() []string {
+	var paths []string
+	for _, change := range r.Changes {
+		switch change.Action {
+		case ActionPull, ActionDeleteLocal:
+			paths = append(paths, change.Path)
+		case ActionConflict:
+			paths = append(paths, change.Copy)
+		}
+	}
+	return paths
+}
+
+// manifest is the list of files in the remote, or in the data directory as of its last sync
+type manifest struct {
+	Files   map[string]string `json:"files"` // SHA-256 of each file, by slash-separated path
+	Updated time.Time         `json:"updated"`
+}
+
+// Syncer syncs a data directory with a Store. Each side's changes are found by comparing its hashes with the
+// ones recorded at the last sync: files changed on one side are copied to the other, and a file changed on both
+// sides keeps the local version while the remote one is saved next to it as a conflict copy, so nothing is lost.
+type Syncer struct {
+	rootManager *files.RootManager
+	store       Store
+	now         func() time.Time
+}
+
+// NewSyncer returns a Syncer for the data directory of rootManager
+func NewSyncer(rootManager *files.RootManager, store Store) *Syncer {
+	return &Syncer{rootManager: rootManager, store: store, now: time.Now}
+}
+
+// Store returns the store the data directory is synced with
+func (s *Syncer) Store() Store {
+	return s.store
+}
+
+// Sync syncs the data directory with the store in the direction mode allows. A dry run returns the changes
+// without making them.
+func (s *Syncer) Sync(ctx context.Context, mode Mode, dryRun bool) (Result, error) {
+	var result Result
+
+	local, err := s.localHashes()
+	if err != nil {
+		return result, err
+	}
+
+	remote, err := s.readManifest(ctx, func() ([]byte, error) { return s.store.Get(ctx, ManifestKey) })
+	if err != nil {
+		return result, fmt.Errorf("could not read the remote manifest: %w", err)
+	}
+
+	base, err := s.readManifest(ctx, func() ([]byte, error) { return s.rootManager.ReadFile(filepath.FromSlash(StatePath)) })
+	if err != nil {
+		return result, fmt.Errorf("could not read the sync state: %w", err)
+	}
+
+	paths := make(map[string]bool)
+	for _, hashes := range []map[string]string{local, remote.Files, base.Files} {
+		for p := range hashes {
+			paths[p] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	// synced becomes the sync state: the hash each side agrees on for every file, as of this sync
+	synced := make(map[string]string)
+	remoteFiles := maps.Clone(remote.Files)
+	for _, p := range sorted {
+		l, r, b := local[p], remote.Files[p], base.Files[p]
+
+		// A file changed on one side only is copied to the other. One changed on one side and deleted on the
+		// other is copied too, so no edit is lost.
+		pull := l == b || (r != b && l == "")
+		push := !pull && (r == b || r == "")
+
+		var change Change
+		switch {
+		case l == r:
+			keepHash(synced, p, l)
+			continue
+		case pull:
+			if mode == Push {
+				if l != b {
+					result.Skipped = append(result.Skipped, p)
+				}
+				keepHash(synced, p, b)
+				continue
+			}
+			change = Change{Path: p, Action: ActionPull}
+			if r == "" {
+				change.Action = ActionDeleteLocal
+			}
+			keepHash(synced, p, r)
+		case push:
+			if mode == Pull {
+				if r != b {
+					result.Skipped = append(result.Skipped, p)
+				}
+				keepHash(synced, p, b)
+				continue
+			}
+			change = Change{Path: p, Action: ActionPush}
+			if l == "" {
+				change.Action = ActionDeleteRemote
+				delete(remoteFiles, p)
+			} else {
+				remoteFiles[p] = l
+			}
+			keepHash(synced, p, l)
+		default:
+			if mode != Both {
+				result.Skipped = append(result.Skipped, p)
+				keepHash(synced, p, b)
+				continue
+			}
+			change = Change{Path: p, Action: ActionConflict, Copy: s.conflictPath(p, local)}
+			synced[p], remoteFiles[p] = l, l
+			synced[change.Copy], remoteFiles[change.Copy] = r, r
+		}
+
+		if !dryRun {
+			if err := s.apply(ctx, change); err != nil {
+				return result, fmt.Errorf("failed to %s %s: %w", change.Action, p, err)
+			}
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if !maps.Equal(remoteFiles, remote.Files) {
+		if err := s.writeManifest(ctx, remoteFiles); err != nil {
+			return result, err
+		}
+	}
+
+	return result, s.writeState(synced)
+}
+
+// apply makes one change to the data directory or the store
+func (s *Syncer) apply(ctx context.Context, change Change) error {
+	name := filepath.FromSlash(change.Path)
+
+	switch change.Action {
+	case ActionPush:
+		content, err := s.rootManager.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		return s.store.Put(ctx, change.Path, content)
+	case ActionPull:
+		content, err := s.store.Get(ctx, change.Path)
+		if err != nil {
+			return err
+		}
+		return s.writeLocal(name, content)
+	case ActionDeleteRemote:
+		return s.store.Delete(ctx, change.Path)
+	case ActionDeleteLocal:
+		err := s.rootManager.Remove(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	case ActionConflict:
+		remote, err := s.store.Get(ctx, change.Path)
+		if err != nil {
+			return err
+		}
+		if err := s.writeLocal(filepath.FromSlash(change.Copy), remote); err != nil {
+			return err
+		}
+		if err := s.store.Put(ctx, change.Copy, remote); err != nil {
+			return err
+		}
+
+		content, err := s.rootManager.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		return s.store.Put(ctx, change.Path, content)
+	default:
+		return fmt.Errorf("unknown action %q", change.Action)
+	}
+}
+
+// writeLocal writes a file to the data directory, creating its directory if needed
+func (s *Syncer) writeLocal(name string, content []byte) error {
+	if dir := filepath.Dir(name); dir != "." {
+		if err := s.rootManager.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	perm := fs.FileMode(0644)
+	if stat, err := s.rootManager.Stat(name); err == nil {
+		perm = stat.Mode().Perm()
+	}
+
+	return s.rootManager.WriteFile(name, content, perm)
+}
+
+// conflictPath returns the path a conflicting remote file is saved to, such as notes.conflict-20250304-143215.md
+// for notes.md. The marker goes before the first dot of the name, so sidecars keep their .meta.json suffix.
+func (s *Syncer) conflictPath(p string, local map[string]string) string {
+	dir, base := path.Split(p)
+	stem, ext := base, ""
+	if i := strings.Index(base[1:], "."); i >= 0 {
+		stem, ext = base[:i+1], base[i+1:]
+	}
+
+	candidate := dir + stem + ".conflict-" + s.now().Format("20060102-150405") + ext
+	for n := 2; local[candidate] != ""; n++ {
+		candidate = fmt.Sprintf("%s%s.conflict-%s-%d%s", dir, stem, s.now().Format("20060102-150405"), n, ext)
+	}
+
+	return candidate
+}
+
+// localHashes returns the SHA-256 of every file in the data directory that's synced, by slash-separated path
+func (s *Syncer) localHashes() (map[string]string, error) {
+	hashes := make(map[string]string)
+	err := s.rootManager.WalkDir(".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if slices.Contains(excludedDirs, p) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || p == ManifestKey {
+			return nil
+		}
+
+		content, err := s.rootManager.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(content)
+		hashes[filepath.ToSlash(p)] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading the data directory: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// readManifest reads a manifest, which is empty when it doesn't exist yet
+func (s *Syncer) readManifest(_ context.Context, read func() ([]byte, error)) (manifest, error) {
+	m := manifest{Files: make(map[string]string)}
+
+	content, err := read()
+	if errors.Is(err, ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+
+	if err := json.Unmarshal(content, &m); err != nil {
+		return m, err
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]string)
+	}
+
+	return m, nil
+}
+
+// writeManifest writes the remote manifest with the hashes of the files in the remote
+func (s *Syncer) writeManifest(ctx context.Context, files map[string]string) error {
+	content, err := json.MarshalIndent(manifest{Files: files, Updated: s.now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.Put(ctx, ManifestKey, content); err != nil {
+		return fmt.Errorf("could not write the remote manifest: %w", err)
+	}
+	return nil
+}
+
+// writeState records the hashes of the synced files, as the base the next sync compares both sides with
+func (s *Syncer) writeState(synced map[string]string) error {
+	content, err := json.MarshalIndent(manifest{Files: synced, Updated: s.now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := s.rootManager.MkdirAll(filepath.Dir(filepath.FromSlash(StatePath)), 0755); err != nil {
+		return err
+	}
+	if err := s.rootManager.WriteFile(filepath.FromSlash(StatePath), content, 0644); err != nil {
+		return fmt.Errorf("could not write the sync state: %w", err)
+	}
+	return nil
+}
+
+// keepHash records the hash a path has as of this sync, unless the file doesn't exist
+func keepHash(hashes map[string]string, p, hash string) {
+	if hash != "" {
+		hashes[p] = hash
+	}
+}
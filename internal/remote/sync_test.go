@@ -0,0 +1,145 @@
+package remote_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/remote"
+)
+
+// newMachine returns the data directory of one machine, with a syncer for the shared store
+func newMachine(t *testing.T, store remote.Store) (*files.RootManager, *remote.Syncer) {
+	t.Helper()
+
+	rootManager, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+	return rootManager, remote.NewSyncer(rootManager, store)
+}
+
+func sync(t *testing.T, syncer *remote.Syncer, mode remote.Mode) remote.Result {
+	t.Helper()
+
+	result, err := syncer.Sync(context.Background(), mode, false)
+	assert.Nil(t, err)
+	return result
+}
+
+func read(t *testing.T, rootManager *files.RootManager, path string) string {
+	t.Helper()
+
+	content, err := rootManager.ReadFile(path)
+	assert.Nil(t, err)
+	return string(content)
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	store, err := remote.NewDirStore(t.TempDir())
+	assert.Nil(t, err)
+
+	laptop, laptopSync := newMachine(t, store)
+	desktop, desktopSync := newMachine(t, store)
+
+	assert.Nil(t, laptop.MkdirAll("resources/trip", 0755))
+	assert.Nil(t, laptop.MkdirAll(".git", 0755))
+	assert.Nil(t, laptop.WriteString("inbox.md", "# Inbox\n"))
+	assert.Nil(t, laptop.WriteString("resources/trip/packing-list.md", "# Packing\n"))
+	assert.Nil(t, laptop.WriteString(".git/HEAD", "ref: refs/heads/main\n"))
+
+	// A dry run plans the changes without making them
+	result, err := laptopSync.Sync(context.Background(), remote.Both, true)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Changes, []remote.Change{
+		{Path: "inbox.md", Action: remote.ActionPush},
+		{Path: "resources/trip/packing-list.md", Action: remote.ActionPush},
+	})
+	_, err = store.Get(context.Background(), "inbox.md")
+	assert.ErrorIs(t, err, remote.ErrNotFound)
+
+	// New files are pushed, leaving out the version history, and pulled on the other machine
+	result = sync(t, laptopSync, remote.Both)
+	assert.Equal(t, len(result.Changes), 2)
+	_, err = store.Get(context.Background(), ".git/HEAD")
+	assert.ErrorIs(t, err, remote.ErrNotFound)
+
+	result = sync(t, desktopSync, remote.Both)
+	assert.Equal(t, result.LocalPaths(), []string{"inbox.md", "resources/trip/packing-list.md"})
+	assert.Equal(t, read(t, desktop, "resources/trip/packing-list.md"), "# Packing\n")
+
+	// Nothing changes when both sides are in sync
+	assert.Equal(t, len(sync(t, laptopSync, remote.Both).Changes), 0)
+
+	// Edits and deletions on one machine reach the other
+	assert.Nil(t, desktop.WriteString("inbox.md", "# Inbox\n\n- call mom\n"))
+	assert.Nil(t, desktop.Remove("resources/trip/packing-list.md"))
+	sync(t, desktopSync, remote.Both)
+
+	result = sync(t, laptopSync, remote.Both)
+	assert.Equal(t, result.Changes, []remote.Change{
+		{Path: "inbox.md", Action: remote.ActionPull},
+		{Path: "resources/trip/packing-list.md", Action: remote.ActionDeleteLocal},
+	})
+	assert.Equal(t, read(t, laptop, "inbox.md"), "# Inbox\n\n- call mom\n")
+	assert.False(t, laptop.FileExists("resources/trip/packing-list.md"))
+}
+
+func TestSyncer_Sync_Conflicts(t *testing.T) {
+	store, err := remote.NewDirStore(t.TempDir())
+	assert.Nil(t, err)
+
+	laptop, laptopSync := newMachine(t, store)
+	desktop, desktopSync := newMachine(t, store)
+
+	assert.Nil(t, laptop.WriteString("inbox.md", "# Inbox\n"))
+	assert.Nil(t, laptop.WriteString("active.md", "# Active\n"))
+	sync(t, laptopSync, remote.Both)
+	sync(t, desktopSync, remote.Both)
+
+	assert.Nil(t, laptop.WriteString("inbox.md", "# Inbox\n\nfrom the laptop\n"))
+	assert.Nil(t, laptop.WriteString("active.md", "# Active\n\nkept\n"))
+	sync(t, laptopSync, remote.Both)
+
+	assert.Nil(t, desktop.WriteString("inbox.md", "# Inbox\n\nfrom the desktop\n"))
+	assert.Nil(t, desktop.Remove("active.md"))
+
+	// One-way syncs leave conflicts alone
+	result := sync(t, desktopSync, remote.Push)
+	assert.Equal(t, len(result.Changes), 0)
+	assert.Equal(t, result.Skipped, []string{"active.md", "inbox.md"})
+
+	// The local copy wins, the remote one is saved as a conflict copy, and changes win over deletions
+	result = sync(t, desktopSync, remote.Both)
+	assert.Equal(t, len(result.Changes), 2)
+	assert.Equal(t, result.Changes[0], remote.Change{Path: "active.md", Action: remote.ActionPull})
+	assert.Equal(t, result.Changes[1].Action, remote.ActionConflict)
+	assert.MatchesRegexp(t, result.Changes[1].Copy, `^inbox\.conflict-\d{8}-\d{6}\.md$`)
+
+	assert.Equal(t, read(t, desktop, "inbox.md"), "# Inbox\n\nfrom the desktop\n")
+	assert.Equal(t, read(t, desktop, result.Changes[1].Copy), "# Inbox\n\nfrom the laptop\n")
+	assert.Equal(t, read(t, desktop, "active.md"), "# Active\n\nkept\n")
+
+	// Both versions reach the other machine
+	sync(t, laptopSync, remote.Both)
+	assert.Equal(t, read(t, laptop, "inbox.md"), "# Inbox\n\nfrom the desktop\n")
+	assert.Equal(t, read(t, laptop, result.Changes[1].Copy), "# Inbox\n\nfrom the laptop\n")
+}
+
+func TestWithPrefix(t *testing.T) {
+	dir := t.TempDir()
+	store, err := remote.NewDirStore(dir)
+	assert.Nil(t, err)
+
+	work := remote.WithPrefix(store, "/work/")
+	assert.Nil(t, work.Put(context.Background(), "inbox.md", []byte("# Work\n")))
+
+	content, err := store.Get(context.Background(), "work/inbox.md")
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), "# Work\n")
+	assert.Equal(t, work.String(), dir+"/work")
+
+	assert.Nil(t, work.Delete(context.Background(), "inbox.md"))
+	assert.Nil(t, work.Delete(context.Background(), "inbox.md"))
+	_, err = work.Get(context.Background(), "inbox.md")
+	assert.ErrorIs(t, err, remote.ErrNotFound)
+}
@@ -1,6 +1,7 @@
 package rendering
 
 import (
+	"html"
 	"io"
 	"io/fs"
 	"path/filepath"
@@ -15,18 +16,42 @@ import (
 // NOTE: some of this could be in an extension, but it's good enough for now
 type MarkdownPostprocessor struct {
 	rootManager *files.RootManager
+	fileRepo    *files.FileRepository
 }
 
-// NewMarkdownPostprocessor creates a new MarkdownPostprocessor for the given RootManager
-func NewMarkdownPostprocessor(rootManager *files.RootManager) *MarkdownPostprocessor {
-	return &MarkdownPostprocessor{rootManager: rootManager}
+// NewMarkdownPostprocessor creates a new MarkdownPostprocessor for the given RootManager and FileRepository
+func NewMarkdownPostprocessor(rootManager *files.RootManager, fileRepo *files.FileRepository) *MarkdownPostprocessor {
+	return &MarkdownPostprocessor{rootManager: rootManager, fileRepo: fileRepo}
 }
 
-// Process performs the postprocessing of the given Markdown content
-func (mp *MarkdownPostprocessor) Process(content string) string {
+// Process performs the postprocessing of the given Markdown content. Relative links are resolved against
+// documentPath, the path of the rendered document, or against the data directory if it is empty.
+func (mp *MarkdownPostprocessor) Process(content string, documentPath string) string {
+	content = mp.processMarkdownLinks(content, documentPath)
 	return mp.processInlineSVG(content)
 }
 
+// markdownLinkPattern matches the href of links to .md files, with an optional #fragment
+var markdownLinkPattern = regexp.MustCompile(`<a href="([^":#?]+\.md)(#[^"]*)?"`)
+
+// processMarkdownLinks rewrites links to markdown files in the data directory (e.g., "foo.md",
+// "../bar.md", or "/resources/bar.md") to the IDs of the files they point to, so they work like wiki links.
+// Links to files that don't exist, or that leave the data directory, are left as they are. External links
+// never match, since a URL scheme needs a colon.
+func (mp *MarkdownPostprocessor) processMarkdownLinks(htmlContent string, documentPath string) string {
+	return markdownLinkPattern.ReplaceAllStringFunc(htmlContent, func(link string) string {
+		match := markdownLinkPattern.FindStringSubmatch(link)
+		href, fragment := match[1], match[2]
+
+		file, ok := mp.fileRepo.ResolveMarkdownLink(documentPath, html.UnescapeString(href))
+		if !ok {
+			return link
+		}
+
+		return `<a href="/` + html.EscapeString(file.ID) + fragment + `"`
+	})
+}
+
 func (mp *MarkdownPostprocessor) processInlineSVG(htmlContent string) string {
 	// Replace <img> tags with inline SVG content
 	re := regexp.MustCompile(`<img[^>]+src="([^">]+\.svg)"[^>]*>`)
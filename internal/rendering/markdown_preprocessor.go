@@ -2,10 +2,12 @@ package rendering
 
 import (
 	"fmt"
+	"html/template"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	pextension "github.com/patrickward/padd/extension"
 	"github.com/patrickward/padd/internal/contentutil"
 	"github.com/patrickward/padd/internal/files"
 )
@@ -14,6 +16,7 @@ type PreprocessingResult struct {
 	Title          string
 	Content        string
 	SectionHeaders []string
+	Frontmatter    string // The frontmatter block, including its fences, which is removed from Content
 }
 
 // MarkdownPreprocessor represents a Markdown preprocessor for markdown files
@@ -33,12 +36,26 @@ func (mp *MarkdownPreprocessor) Process(content string) PreprocessingResult {
 	// Compile regexes once for efficiency
 	titleRe := regexp.MustCompile(`^#\s+(.+)$`)
 	sectionsRe := regexp.MustCompile(`^##\s+(.+)$`)
-	wikiLinksRe := regexp.MustCompile(`\[\[([^]\n]+)]]`)
+	csvTableRe := regexp.MustCompile(`^\s*\{\{csvtable\s+([^}]+?)\s*}}\s*$`)
 
 	var title string
 	var headers []string
 
-	for i, line := range lines {
+	// Lift the frontmatter out of the content so it never renders as body text, even when the YAML is
+	// malformed. The lines are blanked rather than removed so the body can't start with a "---" line.
+	var frontmatter string
+	start := 0
+	if bounds := contentutil.FindFrontmatter(lines); bounds.Found {
+		frontmatter = strings.Join(lines[bounds.Start:bounds.End], "\n")
+		for i := bounds.Start; i < bounds.End; i++ {
+			lines[i] = ""
+		}
+		start = bounds.End
+	}
+
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+
 		// Process title
 		if title == "" {
 			if matches := titleRe.FindStringSubmatch(line); matches != nil {
@@ -54,8 +71,14 @@ func (mp *MarkdownPreprocessor) Process(content string) PreprocessingResult {
 			continue // Skip adding the header line to headers
 		}
 
+		// Process CSV table shortcodes
+		if matches := csvTableRe.FindStringSubmatch(line); matches != nil {
+			lines[i] = mp.processCSVTableShortcode(matches[1])
+			continue
+		}
+
 		// Process wiki links
-		line = mp.processWikiLinkShortcodes(line, wikiLinksRe)
+		line = mp.processWikiLinkShortcodes(line, files.WikiLinkPattern)
 		lines[i] = line
 	}
 
@@ -63,11 +86,13 @@ func (mp *MarkdownPreprocessor) Process(content string) PreprocessingResult {
 		Title:          title,
 		Content:        strings.Join(lines, "\n"),
 		SectionHeaders: headers,
+		Frontmatter:    frontmatter,
 	}
 }
 
 // processWikiLinkShortcodes processes wiki link shortcodes in the format [[Page Name]]
-// and replaces them with appropriate links or not-found messages.
+// and replaces them with appropriate links or not-found messages. Resolved links carry a
+// data-preview-id attribute so the client can fetch an excerpt of the target on hover.
 // TODO: Move to a proper goldmark extension?
 func (mp *MarkdownPreprocessor) processWikiLinkShortcodes(line string, wikiRe *regexp.Regexp) string {
 	// Process wiki links first
@@ -82,15 +107,10 @@ func (mp *MarkdownPreprocessor) processWikiLinkShortcodes(line string, wikiRe *r
 			return match // Return original if empty
 		}
 
-		fileID := mp.fileRepo.CreateID(pageName)
-
 		// Check if the file exists
-		if file, err := mp.fileRepo.FileInfo(fileID); err == nil {
-			// File exists, return a link
-			return fmt.Sprintf(`[%s](/%s)`, file.Title, file.ID)
-		} else if file, err := mp.fileRepo.FileInfo(filepath.Join(mp.fileRepo.Config().ResourcesDirectory, pageName)); err == nil {
-			// File exists in resources, return a link
-			return fmt.Sprintf(`[%s](/%s)`, file.Title, file.ID)
+		if file, ok := mp.fileRepo.ResolveWikiLink(pageName); ok {
+			id := template.HTMLEscapeString(file.ID)
+			return fmt.Sprintf(`<a href="/%s" data-preview-id="%s">%s</a>`, id, id, template.HTMLEscapeString(file.Title))
 		}
 
 		return fmt.Sprintf(`<span class="text-color danger">!! [[%s]] not found !!</span>`, pageName)
@@ -98,3 +118,56 @@ func (mp *MarkdownPreprocessor) processWikiLinkShortcodes(line string, wikiRe *r
 
 	return line
 }
+
+// processCSVTableShortcode renders a {{csvtable path}} shortcode as a markdown table, using the
+// CSV's metadata to apply sorting. Missing or unreadable files are replaced with a not-found message.
+func (mp *MarkdownPreprocessor) processCSVTableShortcode(csvPath string) string {
+	notFound := fmt.Sprintf(`<span class="text-color danger">!! {{csvtable %s}} not found !!</span>`, csvPath)
+
+	info, err := mp.fileRepo.FileInfo(mp.fileRepo.CreateID(csvPath))
+	if err != nil {
+		info, err = mp.fileRepo.FileInfo(filepath.Join(mp.fileRepo.Config().ResourcesDirectory, csvPath))
+	}
+
+	if err != nil || !info.IsCSV() {
+		return notFound
+	}
+
+	doc, err := mp.fileRepo.GetDocument(info.ID)
+	if err != nil {
+		return notFound
+	}
+
+	records, err := files.NewCSVDocument(doc).SortedRecords()
+	if err != nil || len(records) == 0 {
+		return notFound
+	}
+
+	var sb strings.Builder
+	// Tables need to be separated from surrounding paragraphs. The marker keeps the table, which isn't in the
+	// document itself, from being edited in place.
+	sb.WriteString("\n" + pextension.GeneratedTableMarker + "\n")
+	for i, record := range records {
+		cells := make([]string, len(records[0]))
+		for j := range cells {
+			if j < len(record) {
+				cells[j] = escapeTableCell(record[j])
+			}
+		}
+
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+
+		// Write the delimiter row after the header
+		if i == 0 {
+			sb.WriteString(strings.Repeat("| --- ", len(cells)) + "|\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// escapeTableCell makes a CSV value safe to use as a markdown table cell
+func escapeTableCell(value string) string {
+	value = strings.ReplaceAll(strings.TrimSpace(value), "|", `\|`)
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,97 @@
+package rendering_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/rendering"
+)
+
+func setupTestRenderer(t *testing.T) (*rendering.MarkdownRenderer, *files.RootManager, *files.FileRepository) {
+	t.Helper()
+
+	rm, err := files.NewRootManager(t.TempDir())
+	assert.Nil(t, err)
+
+	fr := files.NewFileRepository(rm, files.DefaultFileConfig)
+	assert.Nil(t, fr.Initialize())
+
+	return rendering.NewMarkdownRenderer(rm, fr), rm, fr
+}
+
+func TestMarkdownRenderer_CSVTableShortcode(t *testing.T) {
+	t.Parallel()
+	renderer, rm, fr := setupTestRenderer(t)
+
+	err := rm.WriteString("resources/people.csv", "name,age\nZoe,30\nAda,36\n")
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/people.csv.meta.json", `{"sort_column": "name"}`)
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	rendered := renderer.Render("# People\n\nSome people:\n{{csvtable resources/people.csv}}\n\nThe end.\n")
+	html := string(rendered.HTML)
+
+	assert.Equal(t, rendered.Title, "People")
+	assert.True(t, strings.Contains(html, "<table>"))
+	assert.True(t, strings.Contains(html, "<th>name</th>"))
+	assert.True(t, strings.Contains(html, "<td>Ada</td>"))
+	assert.True(t, strings.Contains(html, "The end."))
+
+	// The table isn't in the document, so its cells can't be edited in place
+	assert.False(t, strings.Contains(html, "table-cell"))
+
+	// Rows are sorted by the metadata sort column
+	assert.True(t, strings.Index(html, "Ada") < strings.Index(html, "Zoe"))
+}
+
+func TestMarkdownRenderer_CSVTableShortcode_ResourcesRelative(t *testing.T) {
+	t.Parallel()
+	renderer, rm, fr := setupTestRenderer(t)
+
+	err := rm.MkdirAll("resources/data", 0755)
+	assert.Nil(t, err)
+	err = rm.WriteString("resources/data/pipes.csv", "expr,result\na|b,true\n")
+	assert.Nil(t, err)
+	fr.ReloadCaches()
+
+	html := string(renderer.Render("{{csvtable data/pipes.csv}}\n").HTML)
+
+	assert.True(t, strings.Contains(html, "<table>"))
+	assert.True(t, strings.Contains(html, "<td>a|b</td>"))
+}
+
+func TestMarkdownRenderer_CSVTableShortcode_NotFound(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	html := string(renderer.Render("{{csvtable resources/missing.csv}}\n").HTML)
+
+	assert.False(t, strings.Contains(html, "<table>"))
+	assert.True(t, strings.Contains(html, "resources/missing.csv}} not found"))
+}
+
+func TestMarkdownRenderer_WikiLinkPreview(t *testing.T) {
+	t.Parallel()
+	renderer, rm, fr := setupTestRenderer(t)
+
+	assert.Nil(t, rm.WriteString("resources/project-ideas.md", "# Project Ideas\n\nSome ideas.\n"))
+	fr.ReloadCaches()
+
+	html := string(renderer.Render("See [[project-ideas]] and [[missing-page]].\n").HTML)
+
+	assert.True(t, strings.Contains(html, `<a href="/resources/project-ideas" data-preview-id="resources/project-ideas"`))
+	assert.True(t, strings.Contains(html, "!! [[missing-page]] not found !!"))
+	assert.Equal(t, strings.Count(html, "data-preview-id"), 1)
+}
+
+func TestMarkdownRenderer_PreviewIDSanitized(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	html := string(renderer.Render(`<a href="/x" data-preview-id="&quot;><script>">x</a> <span data-preview-id="inbox">y</span>`).HTML)
+
+	assert.False(t, strings.Contains(html, "data-preview-id"))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"regexp"
 	"strings"
 
 	"github.com/microcosm-cc/bluemonday"
@@ -12,6 +13,7 @@ import (
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 
 	"github.com/patrickward/padd"
 	pextension "github.com/patrickward/padd/extension"
@@ -42,6 +44,7 @@ type RenderOptions struct {
 	SearchQuery  string
 	TargetIndex  int
 	EnableSearch bool
+	DocumentPath string // Path of the rendered document, used to resolve relative .md links
 }
 
 // NewMarkdownRenderer creates a new MarkdownRenderer instance.
@@ -55,6 +58,8 @@ func NewMarkdownRenderer(rootManager *files.RootManager, fileRepo *files.FileRep
 			extension.Typographer,
 			extension.DefinitionList,
 			pextension.TaskList,
+			pextension.TableCells,
+			pextension.Progress,
 			pextension.NewIconExtension(pextension.NewDefaultIconChecker(rootManager, padd.StaticFS)),
 			meta.Meta,
 		),
@@ -77,13 +82,13 @@ func NewMarkdownRenderer(rootManager *files.RootManager, fileRepo *files.FileRep
 		fileRepo:      fileRepo,
 		rootManager:   rootManager,
 		preprocessor:  NewMarkdownPreprocessor(fileRepo),
-		postprocessor: NewMarkdownPostprocessor(rootManager),
+		postprocessor: NewMarkdownPostprocessor(rootManager, fileRepo),
 	}
 }
 
 // Render renders the given Markdown content.
 func (mr *MarkdownRenderer) Render(content string) RenderedContent {
-	return mr.renderWithOptions(content, RenderOptions{})
+	return mr.RenderWithOptions(content, RenderOptions{})
 }
 
 // RenderWithHighlight renders the given Markdown content with search highlighting.
@@ -94,11 +99,11 @@ func (mr *MarkdownRenderer) RenderWithHighlight(content string, query string, ta
 		EnableSearch: true,
 	}
 
-	return mr.renderWithOptions(content, opts)
+	return mr.RenderWithOptions(content, opts)
 }
 
-// renderWithOptions renders the given Markdown content with the given options.
-func (mr *MarkdownRenderer) renderWithOptions(content string, opts RenderOptions) RenderedContent {
+// RenderWithOptions renders the given Markdown content with the given options.
+func (mr *MarkdownRenderer) RenderWithOptions(content string, opts RenderOptions) RenderedContent {
 	processResult := mr.preprocessor.Process(content)
 
 	// Apply search highlighting if enabled
@@ -106,15 +111,20 @@ func (mr *MarkdownRenderer) renderWithOptions(content string, opts RenderOptions
 		processResult.Content = mr.applySearchHighlighting(processResult.Content, opts)
 	}
 
+	// Parse the frontmatter on its own so its metadata is available without it being part of the body
+	ctx := parser.NewContext()
+	if processResult.Frontmatter != "" {
+		mr.md.Parser().Parse(text.NewReader([]byte(processResult.Frontmatter)), parser.WithContext(ctx))
+	}
+
 	// Convert to HTML
 	var buf bytes.Buffer
-	ctx := parser.NewContext()
 	if err := mr.md.Convert([]byte(processResult.Content), &buf, parser.WithContext(ctx)); err != nil {
 		return mr.renderError(ctx, content, processResult, err)
 	}
 
 	// Post-process HTML
-	processedHTML := mr.postprocessor.Process(buf.String())
+	processedHTML := mr.postprocessor.Process(buf.String(), opts.DocumentPath)
 	processedHTML = mr.sanitizer.Sanitize(processedHTML)
 	metadata := meta.Get(ctx)
 
@@ -214,11 +224,23 @@ func (mr *MarkdownRenderer) renderError(ctx parser.Context, content string, proc
 	}
 }
 
+// previewIDPattern matches the document IDs used in wiki link data-preview-id attributes
+var previewIDPattern = regexp.MustCompile(`^[\p{L}\p{N}_\-./ ]+$`)
+
+// progressWidthPattern matches the percentage widths used by progress bars
+var progressWidthPattern = regexp.MustCompile(`^(100|[1-9]?[0-9])%$`)
+
 // createSanitizerPolicy creates a new sanitizer policy for HTML rendering.
 func createSanitizerPolicy() *bluemonday.Policy {
 	sanitizer := bluemonday.UGCPolicy()
 	sanitizer.AllowAttrs("class", "id").OnElements("span", "div", "i", "code", "pre", "p", "h1", "h2", "h3", "h4", "h5", "h6")
 
+	// Allow wiki links to name the document they preview on hover
+	sanitizer.AllowAttrs("data-preview-id").Matching(previewIDPattern).OnElements("a")
+
+	// Allow percentage widths on spans for progress bars
+	sanitizer.AllowStyles("width").Matching(progressWidthPattern).OnElements("span")
+
 	// Allow form elements, so we can use them in markdown for checklists, etc.
 	sanitizer.AllowElements("form", "input", "textarea", "button", "select", "option", "label")
 	sanitizer.AllowAttrs("type", "checked", "disabled", "name", "value", "placeholder").OnElements("input", "textarea", "button", "select", "option", "label")
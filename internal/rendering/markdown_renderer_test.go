@@ -0,0 +1,172 @@
+package rendering_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/rendering"
+)
+
+func TestMarkdownRenderer_MalformedFrontmatter(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	rendered := renderer.Render("---\ntitle: [unclosed\nstatus: draft: oops\n---\n# Notes\n\nBody text.\n")
+	html := string(rendered.HTML)
+
+	assert.Equal(t, rendered.Title, "Notes")
+	assert.True(t, strings.Contains(html, "Body text."))
+	assert.False(t, strings.Contains(html, "<hr"))
+	assert.False(t, strings.Contains(html, "unclosed"))
+	assert.False(t, strings.Contains(html, "status"))
+}
+
+func TestMarkdownRenderer_Frontmatter(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"at start", "---\nstatus: draft\n---\n# Notes\n\nBody text.\n"},
+		{"after blank lines", "\n\n---\nstatus: draft\n---\n# Notes\n\nBody text.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered := renderer.Render(tt.content)
+			html := string(rendered.HTML)
+
+			assert.Equal(t, rendered.Metadata["status"], any("draft"))
+			assert.True(t, strings.Contains(html, "Body text."))
+			assert.False(t, strings.Contains(html, "<hr"))
+			assert.False(t, strings.Contains(html, "status"))
+		})
+	}
+}
+
+func TestMarkdownRenderer_ThematicBreakAfterFrontmatter(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	rendered := renderer.Render("---\nstatus: draft\n---\n---\n\nAfter the break.\n")
+	html := string(rendered.HTML)
+
+	assert.True(t, strings.Contains(html, "<hr"))
+	assert.True(t, strings.Contains(html, "After the break."))
+}
+
+func TestMarkdownRenderer_Progress(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "in a heading",
+			content: "## Launch [3/5]\n",
+			want:    `<span class="progress"><span class="progress-bar" style="width: 60%"></span></span><span class="progress-label">3/5</span>`,
+		},
+		{
+			name:    "in a line",
+			content: "Docs are [1/4] done\n",
+			want:    `Docs are <span class="progress"><span class="progress-bar" style="width: 25%"></span></span><span class="progress-label">1/4</span> done`,
+		},
+		{
+			name:    "over the total",
+			content: "Bugs [7/5]\n",
+			want:    `<span class="progress-bar" style="width: 100%"></span></span><span class="progress-label">7/5</span>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := string(renderer.Render(tt.content).HTML)
+			assert.True(t, strings.Contains(html, tt.want))
+		})
+	}
+}
+
+func TestMarkdownRenderer_ProgressIgnoresTasksAndLinks(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	rendered := renderer.Render("- [ ] Open task\n- [x] Done task\n\nSteps [2/3]\n\nSee [1/2](https://example.com) and [0/0]\n")
+	html := string(rendered.HTML)
+
+	assert.Equal(t, rendered.TasksTotal, 2)
+	assert.Equal(t, rendered.TasksCompleted, 1)
+	assert.Equal(t, strings.Count(html, `type="checkbox"`), 2)
+	assert.Equal(t, strings.Count(html, `class="progress"`), 1)
+	assert.True(t, strings.Contains(html, `<a href="https://example.com" rel="nofollow">1/2</a>`))
+	assert.True(t, strings.Contains(html, "[0/0]"))
+}
+
+func TestMarkdownRenderer_MarkdownLinks(t *testing.T) {
+	t.Parallel()
+	renderer, rm, fr := setupTestRenderer(t)
+
+	assert.Nil(t, rm.WriteString("resources/roadmap.md", "# Roadmap\n"))
+	fr.ReloadCaches()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "sibling file",
+			content: "[Roadmap](roadmap.md)\n",
+			want:    `<a href="/resources/roadmap"`,
+		},
+		{
+			name:    "parent directory with fragment",
+			content: "[Inbox](../inbox.md#notes)\n",
+			want:    `<a href="/inbox#notes"`,
+		},
+		{
+			name:    "absolute path",
+			content: "[Roadmap](/resources/roadmap.md)\n",
+			want:    `<a href="/resources/roadmap"`,
+		},
+		{
+			name:    "missing file",
+			content: "[Missing](missing.md)\n",
+			want:    `<a href="missing.md"`,
+		},
+		{
+			name:    "outside the data directory",
+			content: "[Escape](../../inbox.md)\n",
+			want:    `<a href="../../inbox.md"`,
+		},
+		{
+			name:    "external link",
+			content: "[Spec](https://example.com/roadmap.md)\n",
+			want:    `<a href="https://example.com/roadmap.md"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered := renderer.RenderWithOptions(tt.content, rendering.RenderOptions{DocumentPath: "resources/index.md"})
+			assert.True(t, strings.Contains(string(rendered.HTML), tt.want))
+		})
+	}
+}
+
+func TestMarkdownRenderer_EditableTableCells(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	rendered := renderer.Render("---\nstatus: draft\n---\n| Name | Qty |\n|------|-----|\n| Milk | 2 |\n\n```\n| a | b |\n|---|---|\n```\n\n| Second |\n|--------|\n| Table |\n")
+	html := string(rendered.HTML)
+
+	assert.True(t, strings.Contains(html, `<span id="table-0-cell-1-1" class="table-cell" hx-get="/tables/edit/0/1/1" hx-trigger="dblclick" hx-swap="outerHTML">2</span>`))
+	assert.True(t, strings.Contains(html, `hx-get="/tables/edit/1/1/0"`))
+	assert.False(t, strings.Contains(html, `/tables/edit/2/`))
+}
@@ -0,0 +1,214 @@
+package rendering
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	gast "github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	pext "github.com/patrickward/padd/extension/ast"
+	"github.com/patrickward/padd/internal/pdf"
+)
+
+// headingSizes are the font sizes of headings in PDFs, by level
+var headingSizes = map[int]float64{1: 20, 2: 16, 3: 13.5, 4: 12, 5: 11, 6: 11}
+
+// listIndent is how far each level of a list is indented in PDFs
+const listIndent = 18.0
+
+// WritePDF adds a markdown document to a PDF, starting on a new page under its title. Formatting within a
+// paragraph, such as bold text and links, is dropped, since PDFs are written as plain text.
+func (mr *MarkdownRenderer) WritePDF(doc *pdf.Document, content string, fallbackTitle string) {
+	processResult := mr.preprocessor.Process(content)
+	source := []byte(processResult.Content)
+	root := mr.md.Parser().Parse(text.NewReader(source))
+
+	title := processResult.Title
+	if title == "" {
+		title = fallbackTitle
+	}
+
+	doc.NewPage()
+	doc.Text(title, pdf.Style{Font: pdf.Bold, Size: headingSizes[1], SpaceAfter: 8})
+
+	w := pdfWriter{doc: doc, source: source, parser: mr.md.Parser()}
+	w.blocks(root, 0)
+}
+
+// pdfWriter writes the blocks of a markdown document to a PDF
+type pdfWriter struct {
+	doc    *pdf.Document
+	source []byte
+	parser parser.Parser
+}
+
+// blocks writes the block children of a node, indented by indent points
+func (w *pdfWriter) blocks(parent gast.Node, indent float64) {
+	for node := parent.FirstChild(); node != nil; node = node.NextSibling() {
+		w.block(node, indent)
+	}
+}
+
+func (w *pdfWriter) block(node gast.Node, indent float64) {
+	switch n := node.(type) {
+	case *gast.Heading:
+		w.doc.Text(w.inline(n), pdf.Style{Font: pdf.Bold, Size: headingSizes[n.Level], Indent: indent, SpaceBefore: 10, SpaceAfter: 4})
+	case *gast.Paragraph, *gast.TextBlock:
+		w.doc.Text(w.inline(n), pdf.Style{Indent: indent, SpaceAfter: 6})
+	case *gast.List:
+		number := n.Start
+		for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+			marker := "•"
+			if n.IsOrdered() {
+				marker = fmt.Sprintf("%d.", number)
+				number++
+			}
+			w.listItem(item, indent+listIndent, marker)
+		}
+		w.doc.Text(" ", pdf.Style{Size: 4})
+	case *gast.Blockquote:
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			if _, ok := child.(*gast.Paragraph); ok {
+				w.doc.Text(w.inline(child), pdf.Style{Font: pdf.Italic, Indent: indent + listIndent, SpaceAfter: 6})
+			} else {
+				w.block(child, indent+listIndent)
+			}
+		}
+	case *gast.FencedCodeBlock, *gast.CodeBlock:
+		w.doc.Text(w.lines(n), pdf.Style{Font: pdf.Mono, Size: 9, Indent: indent + 6, Preformat: true, SpaceBefore: 2, SpaceAfter: 8})
+	case *gast.ThematicBreak:
+		w.doc.Rule()
+	case *east.Table:
+		w.table(n, indent)
+	case *east.DefinitionList:
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			if _, ok := child.(*east.DefinitionTerm); ok {
+				w.doc.Text(w.inline(child), pdf.Style{Font: pdf.Bold, Indent: indent})
+			} else {
+				w.blocks(child, indent+listIndent)
+			}
+		}
+	case *gast.HTMLBlock:
+		// Raw HTML can't be laid out as text, so only its text is kept
+		if content := stripTags(w.lines(n)); strings.TrimSpace(content) != "" {
+			w.doc.Text(content, pdf.Style{Indent: indent, SpaceAfter: 6})
+		}
+	default:
+		w.blocks(n, indent)
+	}
+}
+
+// listItem writes a list item, with its marker before the first line. Task items are marked with their
+// checkbox instead.
+func (w *pdfWriter) listItem(item gast.Node, indent float64, marker string) {
+	for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+		switch child.(type) {
+		case *gast.Paragraph, *gast.TextBlock:
+			line := w.inline(child)
+			if checkbox, ok := child.FirstChild().(*pext.TaskCheckBox); ok {
+				marker = "[ ]"
+				if checkbox.IsChecked {
+					marker = "[x]"
+				}
+				line = w.markdownText(html.UnescapeString(checkbox.Label))
+			}
+
+			w.doc.Text(line, pdf.Style{Indent: indent, Prefix: marker, SpaceAfter: 2})
+			marker = ""
+		default:
+			w.block(child, indent)
+		}
+	}
+}
+
+// table writes each row of a table as a line, with its cells separated by bars
+func (w *pdfWriter) table(table *east.Table, indent float64) {
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			cells = append(cells, w.inline(cell))
+		}
+
+		style := pdf.Style{Size: 10, Indent: indent, SpaceAfter: 2}
+		if _, ok := row.(*east.TableHeader); ok {
+			style.Font = pdf.Bold
+		}
+		w.doc.Text(strings.Join(cells, " | "), style)
+	}
+	w.doc.Text(" ", pdf.Style{Size: 4})
+}
+
+// inline returns the text of a node's inline children
+func (w *pdfWriter) inline(node gast.Node) string {
+	var b strings.Builder
+	_ = gast.Walk(node, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering {
+			return gast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *gast.Text:
+			b.Write(n.Segment.Value(w.source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *gast.String:
+			b.Write(n.Value)
+		case *gast.AutoLink:
+			b.Write(n.Label(w.source))
+		case *pext.TaskCheckBox:
+			b.WriteString(w.markdownText(html.UnescapeString(n.Label)))
+		case *pext.Icon:
+			return gast.WalkSkipChildren, nil
+		case *pext.Progress:
+			_, _ = fmt.Fprintf(&b, "[%d/%d]", n.Done, n.Total)
+		case *gast.RawHTML:
+			// Tags such as the links of resolved wiki links are dropped, keeping the text between them
+			return gast.WalkSkipChildren, nil
+		}
+
+		return gast.WalkContinue, nil
+	})
+
+	return html.UnescapeString(b.String())
+}
+
+// markdownText returns the text of a snippet of inline markdown, such as the label of a task, which is kept
+// as markdown rather than parsed with the rest of the document
+func (w *pdfWriter) markdownText(markdown string) string {
+	source := []byte(markdown)
+	snippet := pdfWriter{source: source, parser: w.parser}
+	return strings.TrimSpace(snippet.inline(w.parser.Parse(text.NewReader(source))))
+}
+
+// lines returns the raw lines of a block node
+func (w *pdfWriter) lines(node gast.Node) string {
+	var b strings.Builder
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		b.Write(segment.Value(w.source))
+	}
+	return b.String()
+}
+
+// stripTags removes the HTML tags from a string
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return html.UnescapeString(b.String())
+}
@@ -0,0 +1,47 @@
+package rendering_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/padd/internal/assert"
+	"github.com/patrickward/padd/internal/pdf"
+)
+
+func TestMarkdownRenderer_WritePDF(t *testing.T) {
+	t.Parallel()
+	renderer, _, _ := setupTestRenderer(t)
+
+	doc := pdf.New("Trip")
+	renderer.WritePDF(doc, "---\ntags: trip\n---\n# Trip\n\n## Tasks\n\n- [x] Book *flights*\n- [ ] Pack, see [the list](/resources/packing) & <b>go</b>\n\n"+
+		"1. First\n2. Second\n\n> Quoted\n\n```\ncode  here\n```\n\n| a | b |\n|---|---|\n| 1 | 2 |\n", "fallback")
+	renderer.WritePDF(doc, "No title here.\n", "Fallback Title")
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	assert.Nil(t, err)
+
+	// Gather the lines of text drawn on each page
+	var pages [][]string
+	for _, stream := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(buf.Bytes(), -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		assert.Nil(t, err)
+		content, err := io.ReadAll(zr)
+		assert.Nil(t, err)
+
+		var lines []string
+		for _, match := range regexp.MustCompile(`\((.*)\) Tj`).FindAllStringSubmatch(string(content), -1) {
+			lines = append(lines, match[1])
+		}
+		pages = append(pages, lines)
+	}
+
+	assert.Equal(t, len(pages), 2)
+	assert.Equal(t, strings.Join(pages[0], "|"), `Trip|Tasks|[x]|Book flights|[ ]|Pack, see the list & go|1.|First|2.|Second|`+
+		`Quoted|code  here|a | b|1 | 2`)
+	assert.Equal(t, strings.Join(pages[1], "|"), "Fallback Title|No title here.")
+}
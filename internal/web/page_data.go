@@ -1,52 +1,81 @@
 package web
 
 import (
+	"fmt"
 	"html/template"
+	"time"
 
 	"github.com/patrickward/padd/internal/files"
+	"github.com/patrickward/padd/internal/flash"
 )
 
 // PageData holds data passed to templates for rendering
 type PageData struct {
-	Title            string                   // Page title - if an H1 (#) is present, it will be used, otherwise a metadata title will be used, finally the file name
-	Description      string                   // Description from metadata
-	Encrypted        bool                     // Whether the current file is encrypted
-	Tags             []string                 // Tags from metadata (e.g. development, personal)
-	Category         string                   // Category from metadata (e.g. work, personal)
-	Status           string                   // Status from metadata (e.g. draft, in-progress, completed)
-	StatusColor      string                   // Status color determined from MetadataConfig
-	Priority         string                   // Priority from metadata (e.g. low, medium, high)
-	PriorityColor    string                   // Priority color determined from MetadataConfig
-	DueDate          string                   // Due date from metadata (if any)
-	DueColor         string                   // Due date color determined from MetadataConfig
-	TagColor         string                   // Tag color determined from MetadataConfig
-	ContextColor     string                   // Context color determined from MetadataConfig
-	CreatedAt        string                   // Created at from metadata (if any)
-	UpdatedAt        string                   // Updated at from metadata (if any)
-	Author           string                   // Author from metadata (if any)
-	Contexts         []string                 // Contexts from metadata (e.g. @home, @work)
-	SectionHeaders   []string                 // H2 headers in the current file for TOC
-	CurrentFile      files.FileInfo           // The current file info
-	Content          template.HTML            // The rendered HTML content
-	TasksTotal       int                      // Total number of tasks in the current file
-	TasksCompleted   int                      // Total number of completed tasks in the current file
-	TasksPending     int                      // Total number of pending tasks in the current file
-	RawContent       string                   // The raw content of the current file
-	IsEditing        bool                     // Whether the user is currently editing the file
-	IsSearching      bool                     // Whether the user is currently searching the file
-	IsResources      bool                     // Whether the current file is in the resources/ directory
-	NavMenuFiles     []files.FileInfo         // List of file info objects for the navigation menu
-	ArchiveType      string                   // "daily" or "journal" for archive pages
-	SearchQuery      string                   // The current search query, if any
-	SearchResults    map[string][]SearchMatch // Search results for the current query
-	FlashMessage     string                   // Flash message to display
-	FlashMessageType string                   // Flash message type
-	ErrorMessage     string                   // Error message to display
-	SearchMatch      int                      // To indicate which match in the line to highlight
-	DirectoryTree    *files.DirectoryNode     // Directory tree for a page. For instance, resources or temporal archive pages.
-	PADDVersion      string                   // The current version of PADD
-	PADDDataDir      string                   // The current data directory for PADD
-	CSVData          *CSVData                 // CSV data for a page
+	Title          string                   // Page title - if an H1 (#) is present, it will be used, otherwise a metadata title will be used, finally the file name
+	Description    string                   // Description from metadata
+	Encrypted      bool                     // Whether the current file is encrypted
+	Locked         bool                     // Whether the current file is locked against edits
+	Tags           []string                 // Tags from metadata (e.g. development, personal)
+	Category       string                   // Category from metadata (e.g. work, personal)
+	Status         string                   // Status from metadata (e.g. draft, in-progress, completed)
+	StatusColor    string                   // Status color determined from MetadataConfig
+	Priority       string                   // Priority from metadata (e.g. low, medium, high)
+	PriorityColor  string                   // Priority color determined from MetadataConfig
+	DueDate        string                   // Due date from metadata (if any)
+	DueColor       string                   // Due date color determined from MetadataConfig
+	TagColor       string                   // Tag color determined from MetadataConfig
+	ContextColor   string                   // Context color determined from MetadataConfig
+	CreatedAt      string                   // Created at from metadata (if any)
+	UpdatedAt      string                   // Updated at from metadata (if any)
+	Author         string                   // Author from metadata (if any)
+	Contexts       []string                 // Contexts from metadata (e.g. @home, @work)
+	SectionHeaders []string                 // H2 headers in the current file for TOC
+	CurrentFile    files.FileInfo           // The current file info
+	Content        template.HTML            // The rendered HTML content
+	TasksTotal     int                      // Total number of tasks in the current file
+	TasksCompleted int                      // Total number of completed tasks in the current file
+	TasksPending   int                      // Total number of pending tasks in the current file
+	RawContent     string                   // The raw content of the current file
+	ContentHash    string                   // Checksum of the raw content, used to detect conflicting saves
+	IsEditing      bool                     // Whether the user is currently editing the file
+	IsSearching    bool                     // Whether the user is currently searching the file
+	IsResources    bool                     // Whether the current file is in the resources/ directory
+	NavMenuFiles   []files.FileInfo         // List of file info objects for the navigation menu
+	ArchiveType    string                   // "daily" or "journal" for archive pages
+	SearchQuery    string                   // The current search query, if any
+	SearchResults  map[string][]SearchMatch // Search results for the current query
+	Flashes        []flash.Flash            // Queued flash messages to display
+	ErrorMessage   string                   // Error message to display
+	SearchMatch    int                      // To indicate which match in the line to highlight
+	DirectoryTree  *files.DirectoryNode     // Directory tree for a page. For instance, resources or temporal archive pages.
+	PADDVersion    string                   // The current version of PADD
+	PADDDataDir    string                   // The current data directory for PADD
+	BasePath       string                   // The path prefix the vault is mounted under, empty at the root
+	Vault          string                   // Name of the current vault, empty when serving a single data directory
+	Vaults         []string                 // Names of every vault served, for the vault switcher
+	CSVData        *CSVData                 // CSV data for a page
+	LinkReport     *LinkReport              // Links that don't lead anywhere, for the link report
+	Backlinks      []files.FileInfo         // Files that link to the current file
+	SavedSearch    *SavedSearchData         // Results of the current file's "query" frontmatter; nil when it has none
+	NoteTemplates  []string                 // Names of the templates a new resource can start from
+	OnThisDay      []OnThisDayEntry         // Entries from the same day of earlier months, for the home page
+	Revisions      []files.Revision         // Recorded revisions of the current file, newest first
+	Revision       string                   // ID of the revision being shown on the history page
+	HasHistory     bool                     // Whether file changes are recorded, so the history page is available
+	EncryptionLock string                   // "locked" or "unlocked" when identities are passphrase-protected, empty otherwise
+	UnlockNext     string                   // Page to return to after unlocking encrypted files
+	LoginNext      string                   // Page to return to after signing in
+	SignedIn       bool                     // Whether the page is shown to a signed-in session, so it can offer to sign out
+	Calendar       *CalendarData            // Activity heatmap for the calendar page
+	MonthCalendar  *MonthCalendarData       // Month grid for the month calendar page
+	Board          *BoardData               // Task columns for the board page
+	Graph          *GraphData               // Summary of the note graph for the graph page
+	CanDecrypt     bool                     // Whether encrypted files can be decrypted for a data directory archive
+	Backups        *BackupData              // Backup schedule and status for the settings page; nil when backups are off
+
+	ResourcesDirectory string // Name of the resources directory, which is also the path of the resources page
+	DailyDirectory     string // Name of the daily directory, which is also the path of the daily pages
+	JournalDirectory   string // Name of the journal directory, which is also the path of the journal pages
 }
 
 func (p PageData) HasTasks() bool {
@@ -57,9 +86,197 @@ func (p PageData) HasCompletedTasks() bool {
 	return p.TasksCompleted > 0
 }
 
+// BoardData holds a document's tasks grouped into columns for the board page
+type BoardData struct {
+	Grouping string // "section" or "status", as given in the board's "by" query parameter
+	Columns  []files.BoardColumn
+}
+
+// GraphData summarizes the note graph for the graph page, which fetches the nodes and edges to draw as JSON
+type GraphData struct {
+	Group string // "tags" to cluster notes by their first tag, or empty, as given in the "group" query parameter
+	Nodes int    // Number of markdown files in the graph
+	Edges int    // Number of links between them
+}
+
+// LinkReport lists the links that don't lead anywhere, grouped by the file they're in
+type LinkReport struct {
+	Files            []LinkReportFile // Files with broken links, sorted by ID
+	ExternalEnabled  bool             // Whether external URLs are checked in the background
+	ExternalChecked  time.Time        // When external URLs were last checked; zero if they haven't been yet
+	ExternalChecking bool             // Whether a check of external URLs is in progress
+}
+
+// LinkReportFile holds the broken links of a file, by kind, each in the order they appear
+type LinkReportFile struct {
+	ID       string
+	Wiki     []string              // Page names of wiki links that don't resolve
+	Relative []string              // URLs of relative links to files that don't exist
+	External []ExternalLinkFailure // External URLs that failed the last check
+}
+
+// ExternalLinkFailure is an external URL that failed the last check, with the reason (e.g., "404 Not Found")
+type ExternalLinkFailure struct {
+	URL    string
+	Reason string
+}
+
+// BackupData holds the backup configuration and the outcome of the most recent backup for the settings page
+type BackupData struct {
+	Dir       string
+	Interval  time.Duration // How often backups are taken; 0 when they're only taken by hand
+	Keep      int           // How many backups are kept; 0 keeps them all
+	LastTime  time.Time     // When the most recent backup was taken; zero if there hasn't been one
+	LastPath  string
+	LastFiles int
+	LastError string
+}
+
 type CSVData struct {
 	Records     [][]string
+	RowIndexes  []int // Row in the file of each record, which differs from the record's position when sorted
 	Metadata    *files.CSVMetadata
 	RecordCount int
 	ColumnCount int
+	Sort        string   // The view's "sort" query parameter
+	Filters     []string // The view's "filter" query parameters
+	ShownCount  int      // Number of data rows matching the filters
+	QueryError  string   // Why the sort or filters couldn't be applied
+}
+
+// SavedSearchData holds the results of a note's saved search, shown below its content
+type SavedSearchData struct {
+	Query string           // The "query" frontmatter, as written
+	Error string           // Why the query couldn't be run, if it couldn't
+	Tasks bool             // Whether the query lists tasks rather than files
+	Files []files.FileInfo // Matching files, sorted by ID
+	Items []files.FileTask // Matching tasks, sorted by file and then by position in the file
+}
+
+// OnThisDayEntry is an earlier day entry shown in the home page's "On this day" list
+type OnThisDayEntry struct {
+	Title   string // The entry's date and directory (e.g., "August 15, 2025 · Journal")
+	Link    string // Page and anchor of the entry
+	Excerpt string
+}
+
+// CalendarData holds a year of temporal activity laid out by month for the calendar heatmap
+type CalendarData struct {
+	Type   string
+	Year   int
+	Total  int
+	Months []CalendarMonth
+}
+
+// PrevYear returns the year before the calendar's year, for navigation
+func (c CalendarData) PrevYear() int {
+	return c.Year - 1
+}
+
+// NextYear returns the year after the calendar's year, for navigation
+func (c CalendarData) NextYear() int {
+	return c.Year + 1
+}
+
+// CalendarMonth is one month of the calendar heatmap
+type CalendarMonth struct {
+	Name        string
+	Number      int // Month number from 1 (January) to 12, for linking to the month's calendar
+	StartColumn int // Grid column of the first day, from 1 (Sunday) to 7 (Saturday)
+	Days        []CalendarDay
+}
+
+// CalendarDay is a single day cell in the calendar heatmap
+type CalendarDay struct {
+	Date  string // Date as "2006-01-02"
+	Day   int
+	Count int
+	Level int    // Heat level from 0 (no entries) to 4
+	Link  string // Page and anchor of the day's entries; empty when the day has none
+}
+
+// NewCalendarData lays out activity (entry counts keyed by "2006-01-02") for every day of year
+func NewCalendarData(fileType string, year int, activity map[string]int) *CalendarData {
+	cal := &CalendarData{Type: fileType, Year: year}
+
+	for month := time.January; month <= time.December; month++ {
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		m := CalendarMonth{Name: month.String(), Number: int(month), StartColumn: int(first.Weekday()) + 1}
+
+		for day := first; day.Month() == month; day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			count := activity[date]
+			cal.Total += count
+			m.Days = append(m.Days, CalendarDay{Date: date, Day: day.Day(), Count: count, Level: activityLevel(count)})
+		}
+
+		cal.Months = append(cal.Months, m)
+	}
+
+	return cal
+}
+
+// MonthCalendarData holds a month of temporal activity laid out as a grid, with each day linking to its entries
+type MonthCalendarData struct {
+	Type  string
+	Year  int
+	Total int
+	CalendarMonth
+}
+
+// PrevPath returns the path of the previous month's calendar, for navigation
+func (c MonthCalendarData) PrevPath() string {
+	return c.monthPath(-1)
+}
+
+// NextPath returns the path of the next month's calendar, for navigation
+func (c MonthCalendarData) NextPath() string {
+	return c.monthPath(1)
+}
+
+// YearPath returns the path of the heatmap for the month's year
+func (c MonthCalendarData) YearPath() string {
+	return fmt.Sprintf("/calendar?type=%s&year=%d", c.Type, c.Year)
+}
+
+// monthPath returns the path of the calendar the given number of months away
+func (c MonthCalendarData) monthPath(months int) string {
+	month := time.Date(c.Year, time.Month(c.Number), 1, 0, 0, 0, 0, time.UTC).AddDate(0, months, 0)
+	return fmt.Sprintf("/calendar/%s/%d/%02d", c.Type, month.Year(), int(month.Month()))
+}
+
+// NewMonthCalendarData lays out activity (entry counts keyed by "2006-01-02") for every day of a month. links
+// holds the page and anchor of each day's entries, keyed the same way.
+func NewMonthCalendarData(fileType string, year int, month time.Month, activity map[string]int, links map[string]string) *MonthCalendarData {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	cal := &MonthCalendarData{
+		Type:          fileType,
+		Year:          year,
+		CalendarMonth: CalendarMonth{Name: month.String(), Number: int(month), StartColumn: int(first.Weekday()) + 1},
+	}
+
+	for day := first; day.Month() == month; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		count := activity[date]
+		cal.Total += count
+		cal.Days = append(cal.Days, CalendarDay{Date: date, Day: day.Day(), Count: count, Level: activityLevel(count), Link: links[date]})
+	}
+
+	return cal
+}
+
+// activityLevel buckets an entry count into a heat level from 0 to 4
+func activityLevel(count int) int {
+	switch {
+	case count <= 0:
+		return 0
+	case count == 1:
+		return 1
+	case count <= 3:
+		return 2
+	case count <= 5:
+		return 3
+	default:
+		return 4
+	}
 }
@@ -2,7 +2,8 @@ package workers
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -21,6 +22,9 @@ type BackgroundWorker struct {
 	wg     sync.WaitGroup
 	tasks  []BackgroundTask
 	mu     sync.Mutex
+
+	onError func(task string, err error)
+	logger  *slog.Logger
 }
 
 // NewBackgroundWorker creates a new BackgroundWorker
@@ -30,6 +34,7 @@ func NewBackgroundWorker(ctx context.Context) *BackgroundWorker {
 		ctx:    cctx,
 		cancel: cancel,
 		tasks:  make([]BackgroundTask, 0),
+		logger: slog.Default(),
 	}
 }
 
@@ -51,15 +56,47 @@ func (br *BackgroundWorker) AddPeriodicTask(name string, interval time.Duration,
 	br.startTask(task) // Start immediately
 }
 
-// StartOneTimeTask adds a new one-time background task to the runner and starts it immediately
+// StartOneTimeTask starts a one-time background task immediately
 func (br *BackgroundWorker) StartOneTimeTask(name string, handler func(ctx context.Context) error) {
-	br.AddTask(BackgroundTask{
+	br.startTask(BackgroundTask{
 		Name:    name,
 		Handler: handler,
 		// Interval is 0 for one-time tasks
 	})
 }
 
+// SetLogger sets the logger for task errors and lifecycle messages, which defaults to slog.Default(). It
+// should be set before any task starts.
+func (br *BackgroundWorker) SetLogger(logger *slog.Logger) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.logger = logger
+}
+
+// OnError registers a function that is called whenever a task returns an error or panics. It should be set
+// before any task starts.
+func (br *BackgroundWorker) OnError(handler func(task string, err error)) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.onError = handler
+}
+
+// reportError logs a task's error and passes it to the error handler
+func (br *BackgroundWorker) reportError(task string, err error) {
+	br.logger.Error("Background task error", "task", task, "error", err)
+	br.notifyError(task, err)
+}
+
+// notifyError passes a task's error to the error handler, if one is registered
+func (br *BackgroundWorker) notifyError(task string, err error) {
+	br.mu.Lock()
+	handler := br.onError
+	br.mu.Unlock()
+	if handler != nil {
+		handler(task, err)
+	}
+}
+
 // Start begins executing all added background tasks
 func (br *BackgroundWorker) Start() {
 	br.mu.Lock()
@@ -78,7 +115,8 @@ func (br *BackgroundWorker) startTask(task BackgroundTask) {
 
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("Recovered from panic in task %s: %v", t.Name, r)
+				br.logger.Error("Recovered from panic in background task", "task", t.Name, "panic", r)
+				br.notifyError(t.Name, fmt.Errorf("panic: %v", r))
 			}
 		}()
 
@@ -88,23 +126,23 @@ func (br *BackgroundWorker) startTask(task BackgroundTask) {
 
 			// Run once immediately
 			if err := t.Handler(br.ctx); err != nil {
-				log.Printf("Error in task %s: %v", t.Name, err)
+				br.reportError(t.Name, err)
 			}
 
 			for {
 				select {
 				case <-br.ctx.Done():
-					log.Printf("Background task '%s' stopping", t.Name)
+					br.logger.Debug("Background task stopping", "task", t.Name)
 					return
 				case <-ticker.C:
 					if err := t.Handler(br.ctx); err != nil {
-						log.Printf("Background task '%s' error: %v", t.Name, err)
+						br.reportError(t.Name, err)
 					}
 				}
 			}
 		} else {
 			if err := t.Handler(br.ctx); err != nil {
-				log.Printf("Background task '%s' error: %v", t.Name, err)
+				br.reportError(t.Name, err)
 			}
 		}
 	}(task)
@@ -112,8 +150,8 @@ func (br *BackgroundWorker) startTask(task BackgroundTask) {
 
 // Shutdown gracefully stops all background tasks
 func (br *BackgroundWorker) Shutdown() {
-	log.Println("Shutting down background tasks...")
+	br.logger.Info("Shutting down background tasks")
 	br.cancel()
 	br.wg.Wait()
-	log.Println("All background tasks stopped.")
+	br.logger.Info("All background tasks stopped")
 }
@@ -0,0 +1,48 @@
+'use strict';
+
+// Drag and drop for the task board. Dropping a card on another column posts the move, the same as picking
+// the column from the card's menu, and the server sends back the updated board.
+(() => {
+  let dragged = null
+
+  document.addEventListener('dragstart', function (evt) {
+    const card = evt.target.closest && evt.target.closest('[data-board-task]')
+    if (!card) {
+      return
+    }
+
+    dragged = card
+    evt.dataTransfer.effectAllowed = 'move'
+    evt.dataTransfer.setData('text/plain', card.dataset.boardTask)
+  })
+
+  document.addEventListener('dragover', function (evt) {
+    const column = dragged && evt.target.closest('[data-board-column]')
+    if (!column) {
+      return
+    }
+
+    evt.preventDefault()
+    document.querySelectorAll('.board-column.drag-over').forEach(el => el !== column && el.classList.remove('drag-over'))
+    column.classList.add('drag-over')
+  })
+
+  document.addEventListener('dragend', function () {
+    dragged = null
+    document.querySelectorAll('.board-column.drag-over').forEach(el => el.classList.remove('drag-over'))
+  })
+
+  document.addEventListener('drop', function (evt) {
+    const column = dragged && evt.target.closest('[data-board-column]')
+    if (!column) {
+      return
+    }
+
+    evt.preventDefault()
+    const select = dragged.querySelector('select[name="column"]')
+    if (select && select.value !== column.dataset.boardColumn) {
+      select.value = column.dataset.boardColumn
+      htmx.trigger(select.form, 'change')
+    }
+  })
+})()
@@ -0,0 +1,168 @@
+'use strict';
+
+// Draws the note graph page. The nodes and edges come from /graph as JSON and are laid out with a small force
+// simulation: linked notes pull together, every note pushes the others away, and notes in the same group are
+// drawn toward a shared center so clusters stand apart. Clicking a note opens it.
+(() => {
+  const SVG_NS = 'http://www.w3.org/2000/svg'
+  const ITERATIONS = 300
+  const PALETTE = ['#4e79a7', '#f28e2b', '#e15759', '#76b7b2', '#59a14f', '#edc948', '#b07aa1', '#ff9da7', '#9c755f', '#bab0ac']
+  const UNGROUPED = '#888888'
+
+  function init () {
+    const container = document.getElementById('note-graph')
+    if (!container) {
+      return
+    }
+
+    let url = '/graph?format=json'
+    if (container.dataset.graphGroup) {
+      url += '&group=' + encodeURIComponent(container.dataset.graphGroup)
+    }
+
+    fetch(window.appURL(url), { headers: { Accept: 'application/json' } })
+      .then(response => response.ok ? response.json() : Promise.reject(new Error(response.statusText)))
+      .then(graph => draw(container, graph))
+      .catch(err => {
+        container.textContent = 'The graph could not be loaded: ' + err.message
+      })
+  }
+
+  // layout places the nodes, returning their positions in a width x height box
+  function layout (graph, width, height) {
+    const groups = [...new Set(graph.nodes.map(n => n.group).filter(Boolean))]
+    const centers = new Map(groups.map((group, i) => {
+      const angle = 2 * Math.PI * i / groups.length
+      return [group, { x: width / 2 + width / 3 * Math.cos(angle), y: height / 2 + height / 3 * Math.sin(angle) }]
+    }))
+
+    const nodes = graph.nodes.map((node, i) => {
+      const angle = 2 * Math.PI * i / graph.nodes.length
+      return { ...node, x: width / 2 + width / 4 * Math.cos(angle), y: height / 2 + height / 4 * Math.sin(angle), dx: 0, dy: 0 }
+    })
+    const byID = new Map(nodes.map(n => [n.id, n]))
+    const edges = graph.edges.map(e => ({ source: byID.get(e.source), target: byID.get(e.target) })).filter(e => e.source && e.target)
+
+    const spacing = Math.sqrt(width * height / Math.max(nodes.length, 1))
+    for (let step = 0; step < ITERATIONS; step++) {
+      const cooling = 1 - step / ITERATIONS
+
+      for (const a of nodes) {
+        for (const b of nodes) {
+          if (a === b) continue
+          const dx = a.x - b.x
+          const dy = a.y - b.y
+          const dist = Math.max(Math.hypot(dx, dy), 1)
+          const force = spacing * spacing / dist / dist
+          a.dx += dx / dist * force
+          a.dy += dy / dist * force
+        }
+      }
+
+      for (const { source, target } of edges) {
+        const dx = target.x - source.x
+        const dy = target.y - source.y
+        const dist = Math.max(Math.hypot(dx, dy), 1)
+        const force = dist / spacing
+        source.dx += dx / dist * force * spacing / 4
+        source.dy += dy / dist * force * spacing / 4
+        target.dx -= dx / dist * force * spacing / 4
+        target.dy -= dy / dist * force * spacing / 4
+      }
+
+      for (const node of nodes) {
+        const center = centers.get(node.group) || { x: width / 2, y: height / 2 }
+        node.dx += (center.x - node.x) * 0.05
+        node.dy += (center.y - node.y) * 0.05
+
+        const move = Math.hypot(node.dx, node.dy)
+        const limit = spacing * cooling
+        if (move > limit) {
+          node.dx *= limit / move
+          node.dy *= limit / move
+        }
+        node.x = Math.min(width - 20, Math.max(20, node.x + node.dx))
+        node.y = Math.min(height - 20, Math.max(20, node.y + node.dy))
+        node.dx = 0
+        node.dy = 0
+      }
+    }
+
+    return { nodes, edges, groups }
+  }
+
+  function draw (container, graph) {
+    const width = container.clientWidth || 800
+    const height = container.clientHeight || 600
+    const { nodes, edges, groups } = layout(graph, width, height)
+    const colors = new Map(groups.map((group, i) => [group, PALETTE[i % PALETTE.length]]))
+
+    const degree = new Map()
+    for (const { source, target } of edges) {
+      degree.set(source.id, (degree.get(source.id) || 0) + 1)
+      degree.set(target.id, (degree.get(target.id) || 0) + 1)
+    }
+
+    const svg = document.createElementNS(SVG_NS, 'svg')
+    svg.setAttribute('viewBox', `0 0 ${width} ${height}`)
+
+    for (const { source, target } of edges) {
+      const line = document.createElementNS(SVG_NS, 'line')
+      line.setAttribute('x1', source.x)
+      line.setAttribute('y1', source.y)
+      line.setAttribute('x2', target.x)
+      line.setAttribute('y2', target.y)
+      svg.appendChild(line)
+    }
+
+    for (const node of nodes) {
+      const g = document.createElementNS(SVG_NS, 'g')
+      g.setAttribute('class', 'graph-node')
+      g.addEventListener('click', () => {
+        window.location.href = window.appURL('/' + node.id)
+      })
+
+      const title = document.createElementNS(SVG_NS, 'title')
+      title.textContent = node.id + (node.tags ? ' (' + node.tags.join(', ') + ')' : '')
+
+      const circle = document.createElementNS(SVG_NS, 'circle')
+      circle.setAttribute('cx', node.x)
+      circle.setAttribute('cy', node.y)
+      circle.setAttribute('r', 4 + Math.min(degree.get(node.id) || 0, 8))
+      circle.setAttribute('fill', colors.get(node.group) || UNGROUPED)
+
+      const label = document.createElementNS(SVG_NS, 'text')
+      label.setAttribute('x', node.x + 8)
+      label.setAttribute('y', node.y + 3)
+      label.textContent = node.title
+
+      g.append(title, circle, label)
+      svg.appendChild(g)
+    }
+
+    container.replaceChildren(svg)
+    drawLegend(colors)
+  }
+
+  function drawLegend (colors) {
+    const legend = document.getElementById('note-graph-legend')
+    if (!legend) {
+      return
+    }
+
+    legend.replaceChildren(...[...colors].map(([group, color]) => {
+      const item = document.createElement('li')
+      const swatch = document.createElement('span')
+      swatch.className = 'swatch'
+      swatch.style.backgroundColor = color
+      item.append(swatch, group)
+      return item
+    }))
+  }
+
+  if (document.readyState === 'loading') {
+    document.addEventListener('DOMContentLoaded', init)
+  } else {
+    init()
+  }
+})()
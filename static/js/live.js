@@ -0,0 +1,46 @@
+'use strict';
+
+// Keeps the open document view current. The server sends a "change" event on /events whenever a document is
+// saved, deleted, or renamed, whether from another tab, the API, or an edit made outside PADD.
+(() => {
+  const fileId = window.getAppMeta('file-id')
+  if (!fileId || !window.EventSource) {
+    return
+  }
+
+  const source = new EventSource(window.appURL('/events'))
+
+  source.addEventListener('change', function (evt) {
+    const change = JSON.parse(evt.data)
+    if (change.id !== fileId && change.old_id !== fileId) {
+      return
+    }
+
+    // Never replace what is being typed; saving checks for conflicts instead. The editor's own autosaves
+    // come back with the hash it already holds.
+    const editor = document.querySelector('textarea#content')
+    if (editor) {
+      const status = document.getElementById('autosave-status')
+      const baseHash = document.getElementById('base_hash')
+      if (status && change.operation === 'save' && change.id === fileId && (!baseHash || change.hash !== baseHash.value)) {
+        status.textContent = 'This file was changed elsewhere'
+      }
+      return
+    }
+
+    // Follow the document to its new location
+    if (change.operation === 'rename' && change.old_id === fileId) {
+      window.location.href = window.appURL('/' + change.id)
+      return
+    }
+
+    if (change.operation === 'delete') {
+      window.location.reload()
+      return
+    }
+
+    htmx.ajax('GET', window.location.href, { target: 'main', select: 'main', swap: 'outerHTML' })
+  })
+
+  window.addEventListener('beforeunload', () => source.close())
+})()
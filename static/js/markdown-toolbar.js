@@ -130,7 +130,7 @@
           label: 'Or, upload an image',
           type: 'file',
           accept: 'image/*',
-          help: 'Image will get converted to a base64 data-uri.',
+          help: 'Images can also be pasted or dropped into the editor.',
           handler: 'handleImageUpload'
         }
       ],
@@ -237,9 +237,41 @@
     #setupBehaviors() {
       this.#setupStickyBehavior();
       this.#setupKeyboardShortcuts();
+      this.#setupImagePaste();
       this.addEventListener('click', this.#handleClick.bind(this));
     }
 
+    // Upload images pasted or dropped into the textarea, inserting their markdown at the cursor
+    #setupImagePaste() {
+      const imageFiles = (items) => Array.from(items || [])
+        .filter(item => item.kind === 'file' && item.type.startsWith('image/'))
+        .map(item => item.getAsFile())
+        .filter(Boolean);
+
+      this.#textarea.addEventListener('paste', (e) => {
+        const images = imageFiles(e.clipboardData?.items);
+        if (images.length) {
+          e.preventDefault();
+          images.forEach(file => this.pasteImage(file));
+        }
+      });
+
+      this.#textarea.addEventListener('dragover', (e) => {
+        if (Array.from(e.dataTransfer?.items || []).some(item => item.kind === 'file')) {
+          e.preventDefault();
+          e.dataTransfer.dropEffect = 'copy';
+        }
+      });
+
+      this.#textarea.addEventListener('drop', (e) => {
+        const images = imageFiles(e.dataTransfer?.items);
+        if (images.length) {
+          e.preventDefault();
+          images.forEach(file => this.pasteImage(file));
+        }
+      });
+    }
+
     #setupStickyBehavior() {
       const sentinel = document.createElement('div');
       sentinel.className = 'toolbar-sentinel';
@@ -375,7 +407,7 @@
 
       const iconGrid = this.#availableIcons.map(icon =>
         `<button type="button" class="markdown-icon-option" data-icon="${icon}" title="${icon}">
-          <img src="/images/icons/${icon}.svg" alt="${icon}" width="20" height="20">
+          <img src="${window.appURL('/images/icons/' + icon + '.svg')}" alt="${icon}" width="20" height="20">
           <span>${icon}</span>
         </button>`
       ).join('');
@@ -505,6 +537,48 @@
       }
     }
 
+    // Upload a pasted or dropped image, showing a placeholder in the textarea until it's replaced by the
+    // image's markdown
+    async pasteImage(file) {
+      const placeholder = `![Uploading ${file.name || 'image'}...]()`;
+      this.insertAtCursor(placeholder);
+
+      const replacePlaceholder = (text) => {
+        const start = this.#textarea.value.indexOf(placeholder);
+        if (start !== -1) {
+          this.#textarea.setRangeText(text, start, start + placeholder.length, 'end');
+          this.#textarea.dispatchEvent(new Event('input', { bubbles: true }));
+        }
+      };
+
+      try {
+        const formData = new FormData();
+        formData.append('image', file);
+
+        const headers = {};
+        const fileId = window.getAppMeta('file-id');
+        if (fileId) {
+          headers['X-PADD-File-ID'] = fileId;
+        }
+
+        const response = await fetch(window.appURL('/api/images/paste'), {
+          method: 'POST',
+          headers,
+          body: formData
+        });
+
+        const result = await response.json();
+        if (!result.success) {
+          throw new Error(result.error || 'Upload failed');
+        }
+
+        replacePlaceholder(result.markdown);
+      } catch (error) {
+        replacePlaceholder('');
+        alert(`Image upload failed: ${error.message}`);
+      }
+    }
+
     // Keep existing image upload handler for compatibility
     async handleImageUpload(file, dialog) {
       const statusEl = document.getElementById('upload-status');
@@ -519,8 +593,15 @@
         const formData = new FormData();
         formData.append('image', file);
 
-        const response = await fetch('/api/images/upload', {
+        const headers = {};
+        const fileId = window.getAppMeta('file-id');
+        if (fileId) {
+          headers['X-PADD-File-ID'] = fileId;
+        }
+
+        const response = await fetch(window.appURL('/api/images/upload'), {
           method: 'POST',
+          headers,
           body: formData
         });
 
@@ -535,7 +616,7 @@
           statusEl.className = 'upload-status success';
           document.getElementById('image-upload').value = '';
         } else {
-          throw new Error(result.message || 'Upload failed');
+          throw new Error(result.error || 'Upload failed');
         }
       } catch (error) {
         statusEl.textContent = `Error: ${error.message}`;
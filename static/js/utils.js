@@ -7,6 +7,11 @@
     return metaTag ? metaTag.content : null
   }
 
+  // Helper function to build an app URL under the vault's base path
+  window.appURL = function appURL (path) {
+    return (window.getAppMeta('base-path') || '') + path
+  }
+
   if (!window.paddListenersAdded) {
     window.paddListenersAdded = true
 
@@ -45,6 +50,24 @@
       }
     })
 
+    // Show an excerpt of the linked document when hovering a wiki link
+    document.addEventListener('mouseover', function (e) {
+      const link = e.target.closest('a[data-preview-id]')
+      if (!link || link.dataset.previewLoaded) {
+        return
+      }
+      link.dataset.previewLoaded = 'true'
+
+      fetch(window.appURL('/api/doc/' + link.dataset.previewId + '/excerpt'))
+        .then(response => response.ok ? response.json() : null)
+        .then(data => {
+          if (data && data.excerpt) {
+            link.title = data.excerpt
+          }
+        })
+        .catch(() => {})
+    })
+
     // Add custom headers to all htmx requests
     document.addEventListener("htmx:configRequest", (evt) => {
       // Add the page id to the evt.detail.headers object as a name/value pair
@@ -55,8 +78,8 @@
     });
 
     document.body.addEventListener('htmx:beforeSwap', function (evt) {
-      if (evt.detail.xhr.status === 400 || evt.detail.xhr.status === 404 || evt.detail.xhr.status === 422 || evt.detail.xhr.status === 500) {
-        // if the response code is 404, 422, or 500, we want to swap the content
+      if ([400, 401, 404, 409, 413, 422, 500].includes(evt.detail.xhr.status)) {
+        // if the response code is 400, 401, 404, 409, 413, 422, or 500, we want to swap the content
         evt.detail.shouldSwap = true
         // set isError to 'false' to avoid error logging in console
         evt.detail.isError = false